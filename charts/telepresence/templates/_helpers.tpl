@@ -84,6 +84,11 @@ RBAC rules required to create an intercept in a namespace; excludes any rules th
 - apiGroups: ["apps"]
   resources: ["deployments", "replicasets", "statefulsets"]
   verbs: ["get", "watch", "list"]
+{{- if and .Values.workloads .Values.workloads.daemonSets .Values.workloads.daemonSets.enabled }}
+- apiGroups: ["apps"]
+  resources: ["daemonsets"]
+  verbs: ["get", "watch", "list"]
+{{- end }}
 {{- if and .Values.workloads .Values.workloads.argoRollouts .Values.workloads.argoRollouts.enabled }}
 - apiGroups: ["argoproj.io"]
   resources: ["rollouts"]
@@ -98,6 +103,19 @@ RBAC rules required to create an intercept in a namespace; excludes any rules th
 {{- end }}
 {{- end }}
 
+{{- /*
+The port of the listener that authenticates clients, or an empty string when it's disabled.
+*/}}
+{{- define "traffic-manager.clientAuthPort" -}}
+{{- with .Values.grpc }}
+{{- with .clientAuth }}
+{{- if .port }}
+{{- print .port }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
 {{/*
 Kubernetes version
 */}}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/pkg/sftp"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/net/webdav"
 	"google.golang.org/grpc"
 
 	"github.com/datawire/dlib/dgroup"
@@ -25,6 +27,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -33,6 +36,14 @@ import (
 
 var DisplayName = "OSS Traffic Agent" //nolint:gochecknoglobals // extension point
 
+// downwardAPIEnv maps the names of synthesized Downward API variables to the agent variables that provide their values.
+var downwardAPIEnv = map[string]string{ //nolint:gochecknoglobals // constant
+	"POD_NAME":      "NAME",
+	"POD_NAMESPACE": "NAMESPACE",
+	"POD_IP":        "POD_IP",
+	"NODE_NAME":     "NODE_NAME",
+}
+
 // AppEnvironment returns the environment visible to this agent together with environment variables
 // explicitly declared for the app container and minus the environment variables provided by this
 // config.
@@ -70,6 +81,14 @@ func AppEnvironment(ctx context.Context, ag *agentconfig.Container) (map[string]
 			}
 		}
 	}
+	// Synthesize the Downward API variables that apps commonly rely on, unless the app container declares them.
+	for k, ak := range downwardAPIEnv {
+		if _, ok := fullEnv[k]; !ok {
+			if v := dos.Getenv(ctx, agentconfig.EnvPrefixAgent+ak); v != "" {
+				fullEnv[k] = v
+			}
+		}
+	}
 	fullEnv[agentconfig.EnvInterceptContainer] = ag.Name
 	if len(ag.Mounts) > 0 {
 		fullEnv[agentconfig.EnvInterceptMounts] = strings.Join(ag.Mounts, ":")
@@ -77,6 +96,20 @@ func AppEnvironment(ctx context.Context, ag *agentconfig.Container) (map[string]
 	return fullEnv, nil
 }
 
+// AppSecretNames returns the names of the environment variables that the app container gets from secrets.
+func AppSecretNames(ctx context.Context, ag *agentconfig.Container) map[string]bool {
+	prefix := agentconfig.EnvPrefixSecret + ag.EnvPrefix
+	names := make(map[string]bool)
+	for _, env := range dos.Environ(ctx) {
+		if strings.HasPrefix(env, prefix) {
+			if eq := strings.IndexByte(env, '='); eq > len(prefix) {
+				names[env[len(prefix):eq]] = true
+			}
+		}
+	}
+	return names
+}
+
 // sftpServer creates a listener on the next available port, writes that port on the
 // given channel, and then starts accepting connections on that port. Each connection
 // starts a sftp-server that communicates with that connection using its stdin and stdout.
@@ -126,6 +159,40 @@ func sftpServer(ctx context.Context, sftpPortCh chan<- uint16) error {
 	}
 }
 
+// webdavServer creates a listener on the next available port, writes that port on the given channel, and then
+// serves the exported volumes using WebDAV on that port. The URL path of a volume is its path relative to the
+// agentconfig.ExportsMountPoint.
+func webdavServer(ctx context.Context, webdavPortCh chan<- uint16) error {
+	defer close(webdavPortCh)
+
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", ":0")
+	if err != nil {
+		return err
+	}
+	_, webdavPort, err := iputil.SplitToIPPort(l.Addr())
+	if err != nil {
+		_ = l.Close()
+		return err
+	}
+	webdavPortCh <- webdavPort
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.Dir(agentconfig.ExportsMountPoint),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				dlog.Debugf(ctx, "WebDAV %s %s failed: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	sc := &dhttp.ServerConfig{Handler: handler}
+	if err = sc.Serve(ctx, l); err != nil && ctx.Err() != nil {
+		err = nil // Normal shutdown
+	}
+	return err
+}
+
 func Main(ctx context.Context, _ ...string) error {
 	dlog.Infof(ctx, "Traffic Agent %s", version.Version)
 
@@ -157,13 +224,23 @@ func Main(ctx context.Context, _ ...string) error {
 func sidecar(ctx context.Context, s State, info *rpc.AgentInfo) error {
 	// Manage the forwarders
 	ac := s.AgentConfig()
+
+	// The terminating TLS secret is mounted when the pod has the inject-terminating-tls-secret annotation.
+	terminatingTLS, err := forwarder.LoadTerminatingTLS(agentconfig.TerminatingTLSMountPoint)
+	if err != nil {
+		dlog.Errorf(ctx, "intercepts will not be able to terminate TLS: %v", err)
+	}
+	propagations, err := matcher.ParsePropagations(ac.Propagation)
+	if err != nil {
+		return err
+	}
 	for _, cn := range ac.Containers {
 		env, err := AppEnvironment(ctx, cn)
 		if err != nil {
 			return err
 		}
 		cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
-		s.AddContainerState(cn.Name, NewContainerState(cnMountPoint, env))
+		s.AddContainerState(cn.Name, NewContainerState(cnMountPoint, env, AppSecretNames(ctx, cn)))
 
 		// Group the containers intercepts by agent port
 		icStates := make(map[agentconfig.PortAndProto][]*agentconfig.Intercept, len(cn.Intercepts))
@@ -175,11 +252,12 @@ func sidecar(ctx context.Context, s State, info *rpc.AgentInfo) error {
 		for pp, ics := range icStates {
 			ic := ics[0] // They all have the same protocol container port, so the first one will do.
 			var cp uint16
-			if ic.TargetPortNumeric {
+			if (ic.TargetPortNumeric || ic.Headless) && ac.PortRedirection != agentconfig.PortRedirectionEBPF {
 				// We must differentiate between connections originating from the agent's forwarder to the container
 				// port and those from other sources. The former should not be routed back, while the latter should
 				// always be routed to the agent. We do this by using a proxy port that will be recognized by the
-				// iptables filtering in our init-container.
+				// iptables filtering in our init-container. The eBPF programs never redirect the forwarder's
+				// connections, because they use the loopback interface.
 				cp = ac.ProxyPort(ic)
 			} else {
 				cp = ic.ContainerPort
@@ -193,6 +271,10 @@ func sidecar(ctx context.Context, s State, info *rpc.AgentInfo) error {
 			targetHost := s.PodIP()
 
 			fwd := forwarder.NewInterceptor(lisAddr, targetHost, cp)
+			fwd.SetHeaderProbes(s.HeaderProbes())
+			fwd.SetRecorder(s.Recorder().ForPort(ic.ContainerPort))
+			fwd.SetTerminatingTLS(terminatingTLS)
+			fwd.SetPropagations(propagations)
 			dgroup.ParentGroup(ctx).Go(fmt.Sprintf("forward-%s", iputil.JoinHostPort(cn.Name, cp)), func(ctx context.Context) error {
 				return fwd.Serve(tunnel.WithPool(ctx, tunnel.NewPool()), nil)
 			})
@@ -274,6 +356,7 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 
 	sftpPortCh := make(chan uint16)
 	ftpPortCh := make(chan uint16)
+	webdavPortCh := make(chan uint16)
 	if config.HasMounts(ctx) {
 		g.Go("sftp-server", func(ctx context.Context) error {
 			return sftpServer(ctx, sftpPortCh)
@@ -285,9 +368,13 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 				return ftp.Start(ctx, config.PodIP(), agentconfig.ExportsMountPoint, ftpPortCh)
 			}
 		})
+		g.Go("webdav-server", func(ctx context.Context) error {
+			return webdavServer(ctx, webdavPortCh)
+		})
 	} else {
 		close(sftpPortCh)
 		close(ftpPortCh)
+		close(webdavPortCh)
 		dlog.Info(ctx, "Not starting sftp-server because there's nothing to mount")
 	}
 	grpcPort, err := waitForPort(ctx, grpcPortCh)
@@ -302,11 +389,19 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 	if err != nil {
 		return nil, err
 	}
-	srv.SetFileSharingPorts(ftpPort, sftpPort)
+	webdavPort, err := waitForPort(ctx, webdavPortCh)
+	if err != nil {
+		return nil, err
+	}
+	srv.SetFileSharingPorts(ftpPort, sftpPort, webdavPort)
 
 	if ac.APIPort != 0 {
+		propagations, err := matcher.ParsePropagations(ac.Propagation)
+		if err != nil {
+			return nil, err
+		}
 		g.Go("API-server", func(ctx context.Context) error {
-			return restapi.NewServer(srv.AgentState()).ListenAndServe(ctx, int(ac.APIPort))
+			return restapi.NewServer(srv.AgentState(), propagations...).ListenAndServe(ctx, int(ac.APIPort))
 		})
 	}
 
@@ -325,6 +420,7 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 				Version: version.Version,
 			},
 		},
+		TunnelCompressions: tunnel.Compressions(),
 	}, nil
 }
 
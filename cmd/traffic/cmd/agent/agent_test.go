@@ -103,7 +103,47 @@ func Test_AppEnvironment(t *testing.T) {
 	require.Equal(t, map[string]string{
 		"ALPHA":                           "alpha",
 		"ZULU":                            "zulu",
+		"POD_IP":                          "192.168.50.34",
 		agentconfig.EnvInterceptContainer: "test-echo",
 		agentconfig.EnvInterceptMounts:    "/home/bob",
 	}, env)
 }
+
+func Test_AppEnvironmentDownwardAPI(t *testing.T) {
+	ctx := testContext(t, dos.MapEnv{
+		agentconfig.EnvPrefixAgent + "NAMESPACE":     "default",
+		agentconfig.EnvPrefixAgent + "NODE_NAME":     "node-1",
+		agentconfig.EnvPrefixApp + "A_" + "POD_NAME": "declared", // declared by the app container
+	})
+
+	config, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+
+	env, err := agent.AppEnvironment(ctx, config.AgentConfig().Containers[0])
+	require.NoError(t, err)
+	require.Equal(t, "declared", env["POD_NAME"])
+	require.Equal(t, "default", env["POD_NAMESPACE"])
+	require.Equal(t, "node-1", env["NODE_NAME"])
+	require.Equal(t, "192.168.50.34", env["POD_IP"])
+}
+
+func Test_AppSecretNames(t *testing.T) {
+	ctx := testContext(t, dos.MapEnv{
+		agentconfig.EnvPrefixApp + "A_" + "ALPHA":       "alpha",
+		agentconfig.EnvPrefixApp + "A_" + "PASSWORD":    "secret",
+		agentconfig.EnvPrefixSecret + "A_" + "PASSWORD": "",
+		agentconfig.EnvPrefixSecret + "B_" + "TOKEN":    "", // other container
+	})
+
+	config, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+
+	cn := config.AgentConfig().Containers[0]
+	require.Equal(t, map[string]bool{"PASSWORD": true}, agent.AppSecretNames(ctx, cn))
+
+	env, err := agent.AppEnvironment(ctx, cn)
+	require.NoError(t, err)
+	cs := agent.NewContainerState("", env, agent.AppSecretNames(ctx, cn))
+	require.NotContains(t, cs.Env(false), "PASSWORD")
+	require.Equal(t, "secret", cs.Env(true)["PASSWORD"])
+}
@@ -23,6 +23,7 @@ import (
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
@@ -79,6 +80,10 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 	if err != nil {
 		return err
 	}
+	if session.TunnelCompression != "" {
+		dlog.Infof(ctx, "Using %s compression for tunnels to the traffic-manager", session.TunnelCompression)
+	}
+	manager = tunnel.CompressingManagerClient(manager, session.TunnelCompression)
 
 	state.SetManager(ctx, session, manager, mgrVer)
 
@@ -122,6 +127,15 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 		return lookupDNSWaitLoop(ctx, manager, session, dnsStream)
 	})
 
+	// Deal with header probes dispatched to this agent when header propagation is verified
+	probeStream, err := manager.WatchHeaderProbes(ctx, session)
+	if err != nil {
+		return err
+	}
+	wg.Go("headerProbeWait", func(ctx context.Context) error {
+		return headerProbeWaitLoop(ctx, manager, session, probeStream, state.HeaderProbes())
+	})
+
 	// Deal with dial requests from the manager
 	dialerStream, err := manager.WatchDial(ctx, session)
 	if err != nil {
@@ -259,6 +273,42 @@ func lookupDNSAndRespond(ctx context.Context, manager rpc.ManagerClient, session
 	}
 }
 
+func headerProbeWaitLoop(
+	ctx context.Context,
+	manager rpc.ManagerClient,
+	session *rpc.SessionInfo,
+	probeStream rpc.Manager_WatchHeaderProbesClient,
+	probes *forwarder.HeaderProbes,
+) error {
+	report := func(id string, observed bool) {
+		if _, err := manager.ReportHeaderProbe(ctx, &rpc.HeaderProbeReport{Session: session, Id: id, Observed: observed}); err != nil {
+			if ctx.Err() == nil {
+				dlog.Errorf(ctx, "ReportHeaderProbe: %v", err)
+			}
+		}
+	}
+	for ctx.Err() == nil {
+		p, err := probeStream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.Unimplemented {
+				// The traffic-manager is too old to send header probes.
+				return nil
+			}
+			if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("header probe stream recv: %w", err)
+			}
+			return nil
+		}
+		dlog.Debugf(ctx, "Arming header probe %s for %s: %s", p.Id, p.Header, p.Value)
+		probes.Arm(p.Id, p.Header, p.Value, p.Timeout.AsDuration(), func() {
+			dlog.Debugf(ctx, "Observed header probe %s", p.Id)
+			report(p.Id, true)
+		})
+		go report(p.Id, false)
+	}
+	return nil
+}
+
 func logLevelWaitLoop(ctx context.Context, logLevelStream rpc.Manager_WatchLogLevelClient) error {
 	timedLevel := log.NewTimedLevel(log.DlogLevelNames[dlog.MaxLogLevel(ctx)], log.SetLevel)
 	for ctx.Err() == nil {
@@ -3,20 +3,34 @@ package agent
 type containerState struct {
 	mountPoint string
 	env        map[string]string
+	secrets    map[string]bool
 }
 
 func (c containerState) MountPoint() string {
 	return c.mountPoint
 }
 
-func (c containerState) Env() map[string]string {
-	return c.env
+// Env returns the environment of the container. Variables that the container gets from secrets are
+// excluded unless includeSecrets is true.
+func (c containerState) Env(includeSecrets bool) map[string]string {
+	if includeSecrets || len(c.secrets) == 0 {
+		return c.env
+	}
+	env := make(map[string]string, len(c.env))
+	for k, v := range c.env {
+		if !c.secrets[k] {
+			env[k] = v
+		}
+	}
+	return env
 }
 
 // NewContainerState creates a ContainerState that provides the environment variables and the mount point for a container.
-func NewContainerState(mountPoint string, env map[string]string) ContainerState {
+// The secrets are the names of the environment variables that the container gets from secrets.
+func NewContainerState(mountPoint string, env map[string]string, secrets map[string]bool) ContainerState {
 	return &containerState{
 		mountPoint: mountPoint,
 		env:        env,
+		secrets:    secrets,
 	}
 }
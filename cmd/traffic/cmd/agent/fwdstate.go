@@ -4,11 +4,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
@@ -37,14 +43,14 @@ func (fs *fwdState) Target() InterceptTarget {
 }
 
 func (fs *fwdState) InterceptInfo(ctx context.Context, callerID, path string, containerPort uint16, headers http.Header) (*restapi.InterceptInfo, error) {
-	// The OSS agent is either intercepting or it isn't. There's no way to tell what it is that's being intercepted.
+	// The OSS agent intercepts all requests, or the requests that match the header filters of the intercept.
 	fw := fs.forwarder
 	if containerPort == 0 {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	_, port := fw.Target()
 	if containerPort == port {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	portInfo := ""
 	if containerPort != 0 {
@@ -131,7 +137,23 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 		}
 		if cept.Disposition == manager.InterceptDispositionType_WAITING {
 			// This intercept is ready to be active
+			filterErr := validateHTTPFilters(cept.Spec)
 			switch {
+			case cept.Spec.TerminateTls && !fs.forwarder.TerminatesTLS():
+				reviews = append(reviews, &manager.ReviewInterceptRequest{
+					Id:          cept.Id,
+					Disposition: manager.InterceptDispositionType_AGENT_ERROR,
+					Message: fmt.Sprintf("unable to terminate TLS, because the workload has no %s annotation",
+						agentconfig.TerminatingTLSSecretAnnotation),
+					MechanismArgsDesc: mechanismArgsDesc(cept),
+				})
+			case filterErr != nil:
+				reviews = append(reviews, &manager.ReviewInterceptRequest{
+					Id:                cept.Id,
+					Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+					Message:           fmt.Sprintf("invalid HTTP filters: %v", filterErr),
+					MechanismArgsDesc: "all TCP connections",
+				})
 			case cept == myChoice:
 				// We've already chosen this one, but it's not active yet in this
 				// snapshot. Let's go ahead and tell the manager to mark it ACTIVE.
@@ -142,9 +164,12 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 					PodIp:             fs.PodIP(),
 					FtpPort:           int32(fs.FtpPort()),
 					SftpPort:          int32(fs.SftpPort()),
+					WebdavPort:        int32(fs.WebdavPort()),
 					MountPoint:        cs.MountPoint(),
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       cs.Env(),
+					MechanismArgsDesc: mechanismArgsDesc(cept),
+					Headers:           cept.Spec.HttpHeaders,
+					Message:           fs.httpFilterWarning(ctx, cept),
+					Environment:       cs.Env(cept.Spec.SecretsVerified),
 				})
 			case fs.chosenIntercept == nil:
 				// We don't have an intercept in play, so choose this one. All
@@ -161,9 +186,12 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 					PodIp:             fs.PodIP(),
 					FtpPort:           int32(fs.FtpPort()),
 					SftpPort:          int32(fs.SftpPort()),
+					WebdavPort:        int32(fs.WebdavPort()),
 					MountPoint:        cs.MountPoint(),
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       cs.Env(),
+					MechanismArgsDesc: mechanismArgsDesc(cept),
+					Headers:           cept.Spec.HttpHeaders,
+					Message:           fs.httpFilterWarning(ctx, cept),
+					Environment:       cs.Env(cept.Spec.SecretsVerified),
 				})
 			default:
 				// We already have an intercept in play, so reject this one.
@@ -186,3 +214,75 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 	}
 	return reviews
 }
+
+// mechanismArgsDesc returns the description of what the given intercept intercepts.
+func mechanismArgsDesc(cept *manager.InterceptInfo) string {
+	spec := cept.Spec
+	desc := "all TCP connections"
+	if hasHTTPFilters(spec) {
+		var filters []string
+		if filter, err := matcher.NewRequestFromMap(spec.HttpHeaders); err == nil && len(spec.HttpHeaders) > 0 {
+			filters = append(filters, filter.String())
+		}
+		if body, err := matcher.NewBody(spec.HttpBodyJson, spec.HttpBodyXpath); err == nil && body != nil {
+			filters = append(filters, body.String())
+		}
+		if len(filters) == 0 {
+			filters = append(filters, "requests")
+		}
+		desc = "HTTP " + strings.Join(filters, "\nand ") + "\nand all connections that aren't HTTP"
+	}
+	if spec.TerminateTls {
+		desc += ", TLS terminated by the traffic-agent"
+	}
+	if spec.InjectLatency > 0 {
+		desc += fmt.Sprintf(", %s latency injected", time.Duration(spec.InjectLatency))
+	}
+	if spec.InjectErrorRate > 0 {
+		desc += fmt.Sprintf(", %g%% errors injected", spec.InjectErrorRate)
+	}
+	if spec.BandwidthLimit > 0 {
+		desc += fmt.Sprintf(", bandwidth limited to %s bytes per second", resource.NewQuantity(spec.BandwidthLimit, resource.BinarySI))
+	}
+	return desc
+}
+
+// hasHTTPFilters returns true if the given spec selects the HTTP requests that are routed to the client.
+func hasHTTPFilters(spec *manager.InterceptSpec) bool {
+	return len(spec.HttpHeaders) > 0 || len(spec.HttpBodyJson) > 0 || len(spec.HttpBodyXpath) > 0
+}
+
+func validateHTTPFilters(spec *manager.InterceptSpec) error {
+	if _, err := matcher.NewRequestFromMap(spec.HttpHeaders); err != nil {
+		return err
+	}
+	_, err := matcher.NewBody(spec.HttpBodyJson, spec.HttpBodyXpath)
+	return err
+}
+
+// httpFilterWarning returns a warning when the given intercept has HTTP filters, but the intercepted port
+// declares an application protocol that the traffic-agent can't filter. The traffic-agent still sniffs each
+// connection, so no warning is given when the application protocol is unknown.
+func (fs *fwdState) httpFilterWarning(ctx context.Context, cept *manager.InterceptInfo) string {
+	spec := cept.Spec
+	if !hasHTTPFilters(spec) {
+		return ""
+	}
+	if p := fs.intercept.Protocol(); p != core.ProtocolTCP {
+		return fmt.Sprintf("HTTP filters are ignored, because %s uses %s. All traffic is routed to the client", fs.intercept, p)
+	}
+	ap := fs.intercept.AppProtocol(ctx)
+	switch strings.ToLower(ap) {
+	case "", "http", "http2", "h2c", "grpc", "grpc-web", "ws", "kubernetes.io/h2c", "kubernetes.io/ws":
+		return ""
+	case "https", "tls", "h2", "wss", "kubernetes.io/wss":
+		if spec.TerminateTls {
+			return ""
+		}
+		return fmt.Sprintf("HTTP filters are ignored, because %s uses appProtocol %s and TLS isn't terminated by the traffic-agent. "+
+			"All connections are routed to the client", fs.intercept, ap)
+	default:
+		return fmt.Sprintf("HTTP filters are likely ignored, because %s uses appProtocol %s. "+
+			"Connections that aren't HTTP are routed to the client in full", fs.intercept, ap)
+	}
+}
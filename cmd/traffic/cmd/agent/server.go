@@ -1,7 +1,13 @@
 package agent
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
@@ -12,6 +18,8 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/agent"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/tarstream"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
 )
@@ -79,6 +87,166 @@ func (s *state) WatchDial(session *rpc.SessionInfo, server agent.Agent_WatchDial
 	}
 }
 
+// fileChunkWriter is an io.Writer that sends what it writes as FileChunks.
+type fileChunkWriter struct {
+	server agent.Agent_FetchFilesServer
+}
+
+func (w fileChunkWriter) Write(data []byte) (int, error) {
+	if err := w.server.Send(&agent.FileChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (s *state) FetchFiles(request *agent.FetchFilesRequest, server agent.Agent_FetchFilesServer) error {
+	ctx := server.Context()
+	mountPoint := filepath.Clean(request.MountPoint)
+	if !strings.HasPrefix(mountPoint, agentconfig.ExportsMountPoint+"/") {
+		return status.Errorf(codes.InvalidArgument, "%q is not a mount point of this agent", request.MountPoint)
+	}
+	if len(request.Paths) == 0 {
+		return status.Error(codes.InvalidArgument, "no paths to fetch")
+	}
+	for _, p := range request.Paths {
+		if _, err := os.Stat(filepath.Join(mountPoint, filepath.FromSlash(tarstream.CleanPath(p)))); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return status.Errorf(codes.NotFound, "%s: no such file or directory", p)
+			}
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	dlog.Debugf(ctx, "Fetching %v from %s", request.Paths, mountPoint)
+	w := bufio.NewWriterSize(fileChunkWriter{server: server}, 64*1024)
+	if err := tarstream.Write(ctx, w, mountPoint, request.Paths); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := w.Flush(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// putFilesReader is an io.Reader that reads the data of a PutFiles stream.
+type putFilesReader struct {
+	server agent.Agent_PutFilesServer
+	data   []byte
+}
+
+func (r *putFilesReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		rq, err := r.server.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.data = rq.Data
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (s *state) PutFiles(server agent.Agent_PutFilesServer) error {
+	ctx := server.Context()
+	rq, err := server.Recv()
+	if err != nil {
+		return err
+	}
+	mountPoint := filepath.Clean(rq.MountPoint)
+	var cn *agentconfig.Container
+	for _, c := range s.AgentConfig().Containers {
+		if filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(c.MountPoint)) == mountPoint {
+			cn = c
+			break
+		}
+	}
+	if cn == nil {
+		return status.Errorf(codes.InvalidArgument, "%q is not a mount point of this agent", rq.MountPoint)
+	}
+	dest := "/" + tarstream.CleanPath(rq.Dest)
+	inVolume := false
+	for _, m := range cn.Mounts {
+		if dest == m || strings.HasPrefix(dest, strings.TrimSuffix(m, "/")+"/") {
+			inVolume = true
+			break
+		}
+	}
+	if !inVolume {
+		return status.Errorf(codes.InvalidArgument, "%s is not in a volume of container %s", rq.Dest, cn.Name)
+	}
+	dlog.Debugf(ctx, "Putting files into %s of container %s", dest, cn.Name)
+	count, err := tarstream.ExtractWritable(&putFilesReader{server: server, data: rq.Data}, filepath.Join(mountPoint, filepath.FromSlash(dest)))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return server.SendAndClose(&agent.PutFilesResult{Count: int32(count)})
+}
+
+func (s *state) Capture(request *agent.CaptureRequest, server agent.Agent_CaptureServer) error {
+	ctx := server.Context()
+	if err := s.authorizeCapture(ctx, request.ClientSessionId, request.IncludeCredentials); err != nil {
+		return err
+	}
+	port := uint16(request.Port)
+	dlog.Debugf(ctx, "Capture of port %d started by client session %s", port, request.ClientSessionId)
+	crCh, stop := s.recorder.Capture(port, request.IncludeCredentials)
+	defer func() {
+		dlog.Debugf(ctx, "Capture of port %d ended, %d requests were dropped", port, stop())
+	}()
+	podName := s.PodName()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case cr := <-crCh:
+			cr.Pod = podName
+			if err := server.Send(cr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// authorizeCapture asks the traffic-manager if the given client session is allowed to capture the requests that
+// this agent receives, with or without credentials.
+func (s *state) authorizeCapture(ctx context.Context, clientSessionID string, includeCredentials bool) error {
+	mc := s.ManagerClient()
+	si := s.SessionInfo()
+	if mc == nil || si == nil {
+		return status.Error(codes.Unavailable, "the traffic-agent has no session with the traffic-manager")
+	}
+	_, err := mc.AuthorizeCapture(ctx, &rpc.AuthorizeCaptureRequest{
+		Session:            si,
+		ClientSessionId:    clientSessionID,
+		IncludeCredentials: includeCredentials,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		err = status.Error(codes.PermissionDenied, "the traffic-manager is too old to authorize captures")
+	}
+	return err
+}
+
+func (s *state) Mounts(_ context.Context, request *agent.MountsRequest) (*agent.MountsInfo, error) {
+	for _, cn := range s.AgentConfig().Containers {
+		if request.Container != "" && cn.Name != request.Container {
+			continue
+		}
+		cs, ok := s.containerStates[cn.Name]
+		if !ok {
+			break
+		}
+		return &agent.MountsInfo{
+			Container:  cn.Name,
+			MountPoint: cs.MountPoint(),
+			Mounts:     cn.Mounts,
+			FtpPort:    int32(s.FtpPort()),
+			SftpPort:   int32(s.SftpPort()),
+			WebdavPort: int32(s.WebdavPort()),
+		}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "found no container %q in pod %s", request.Container, s.PodName())
+}
+
 func (s *state) CreateClientStream(ctx context.Context, sessionID string, id tunnel.ConnID, roundTripLatency, dialTimeout time.Duration) (tunnel.Stream, error) {
 	dlog.Debugf(ctx, "Creating tunnel to client %s for id %s", sessionID, id)
 	drCh, ok := s.dialWatchers.Load(sessionID)
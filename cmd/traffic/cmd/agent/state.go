@@ -25,20 +25,23 @@ type State interface {
 	AgentState() restapi.AgentState
 	InterceptStates() []InterceptState
 	HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest
+	HeaderProbes() *forwarder.HeaderProbes
+	Recorder() *forwarder.Recorder
 	ManagerClient() manager.ManagerClient
 	ManagerVersion() semver.Version
 	SessionInfo() *manager.SessionInfo
-	SetFileSharingPorts(ftp uint16, sftp uint16)
+	SetFileSharingPorts(ftp uint16, sftp uint16, webdav uint16)
 	SetManager(ctx context.Context, sessionInfo *manager.SessionInfo, manager manager.ManagerClient, version semver.Version)
 	FtpPort() uint16
 	SftpPort() uint16
+	WebdavPort() uint16
 	NewInterceptState(forwarder forwarder.Interceptor, target InterceptTarget, container string) InterceptState
 	AddContainerState(containerName string, containerState ContainerState)
 }
 
 type ContainerState interface {
 	MountPoint() string
-	Env() map[string]string
+	Env(includeSecrets bool) map[string]string
 }
 
 // An InterceptState implements what's needed to intercept one target port.
@@ -53,8 +56,11 @@ type state struct {
 	Config
 	ftpPort          uint16
 	sftpPort         uint16
+	webdavPort       uint16
 	dialWatchers     *xsync.MapOf[string, chan *manager.DialRequest]
 	awaitingForwards *xsync.MapOf[string, *xsync.MapOf[tunnel.ConnID, *awaitingForward]]
+	headerProbes     *forwarder.HeaderProbes
+	recorder         *forwarder.Recorder
 
 	// The sessionInfo and manager client are needed when forwarders establish their
 	// tunnel to the traffic-manager.
@@ -75,9 +81,10 @@ func (s *state) ManagerVersion() semver.Version {
 	return s.mgrVer
 }
 
-func (s *state) SetFileSharingPorts(ftp uint16, sftp uint16) {
+func (s *state) SetFileSharingPorts(ftp uint16, sftp uint16, webdav uint16) {
 	s.ftpPort = ftp
 	s.sftpPort = sftp
+	s.webdavPort = webdav
 }
 
 func (s *state) SessionInfo() *manager.SessionInfo {
@@ -90,9 +97,19 @@ func NewState(config Config) State {
 		containerStates:  make(map[string]ContainerState),
 		dialWatchers:     xsync.NewMapOf[string, chan *manager.DialRequest](),
 		awaitingForwards: xsync.NewMapOf[string, *xsync.MapOf[tunnel.ConnID, *awaitingForward]](),
+		headerProbes:     forwarder.NewHeaderProbes(),
+		recorder:         forwarder.NewRecorder(),
 	}
 }
 
+func (s *state) HeaderProbes() *forwarder.HeaderProbes {
+	return s.headerProbes
+}
+
+func (s *state) Recorder() *forwarder.Recorder {
+	return s.recorder
+}
+
 func (s *state) AddInterceptState(is InterceptState) {
 	s.interceptStates = append(s.interceptStates, is)
 }
@@ -153,3 +170,7 @@ func (s *state) FtpPort() uint16 {
 func (s *state) SftpPort() uint16 {
 	return s.sftpPort
 }
+
+func (s *state) WebdavPort() uint16 {
+	return s.webdavPort
+}
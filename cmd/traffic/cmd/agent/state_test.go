@@ -3,14 +3,21 @@ package agent_test
 import (
 	"context"
 	"net"
+	"net/http"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/datawire/dlib/dlog"
+	agentrpc "github.com/telepresenceio/telepresence/rpc/v2/agent"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/agent"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
@@ -43,7 +50,7 @@ func makeFS(t *testing.T, ctx context.Context) (forwarder.Interceptor, agent.Sta
 	s := agent.NewState(c)
 	cn := c.AgentConfig().Containers[0]
 	cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
-	s.AddContainerState(cn.Name, agent.NewContainerState(cnMountPoint, map[string]string{}))
+	s.AddContainerState(cn.Name, agent.NewContainerState(cnMountPoint, map[string]string{}, nil))
 	s.AddInterceptState(s.NewInterceptState(f, agent.NewInterceptTarget(cn.Intercepts), cn.Name))
 	return f, s
 }
@@ -150,3 +157,140 @@ func TestState_HandleIntercepts(t *testing.T) {
 	a.Len(reviews, 0)
 	a.Equal("", f.InterceptId())
 }
+
+func TestState_HandleIntercepts_headerFilters(t *testing.T) {
+	ic := testConfig.Containers[0].Intercepts[0]
+	ic.AppProtocol = "tcp"
+	t.Cleanup(func() { ic.AppProtocol = "" })
+
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+	defer s.HandleIntercepts(ctx, nil)
+
+	cept := &rpc.InterceptInfo{
+		Spec: &rpc.InterceptSpec{
+			Name:           "cept1Name",
+			Client:         "user@host1",
+			Agent:          "agentName",
+			Mechanism:      "tcp",
+			Namespace:      namespace,
+			ServiceName:    serviceName,
+			PortIdentifier: "http",
+			TargetPort:     8080,
+			HttpHeaders:    map[string]string{"x-dev": "alice"},
+			HttpBodyJson:   map[string]string{"type": "order.created"},
+		},
+		Id:          "intercept-01",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+	}
+
+	// The filters are passed on, and the client is warned that the port isn't HTTP.
+	reviews := s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+	a.Equal(cept.Spec.HttpHeaders, reviews[0].Headers)
+	a.Contains(reviews[0].Message, "appProtocol tcp")
+	a.Contains(reviews[0].MechanismArgsDesc, "'X-Dev: alice'")
+	a.Contains(reviews[0].MechanismArgsDesc, "'type == order.created'")
+
+	// Only the requests that match the filters are intercepted.
+	cept.Disposition = rpc.InterceptDispositionType_ACTIVE
+	s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Equal(cept.Id, f.InterceptId())
+	a.True(f.InterceptInfo("/", http.Header{"X-Dev": {"alice"}}).Intercepted)
+	a.False(f.InterceptInfo("/", http.Header{"X-Dev": {"bob"}}).Intercepted)
+
+	// Invalid filters are rejected.
+	cept = &rpc.InterceptInfo{
+		Spec: &rpc.InterceptSpec{
+			Name:           "cept2Name",
+			Client:         "user@host2",
+			Agent:          "agentName",
+			Mechanism:      "tcp",
+			Namespace:      namespace,
+			ServiceName:    serviceName,
+			PortIdentifier: "http",
+			TargetPort:     8080,
+			HttpHeaders:    map[string]string{":path-regex:": "("},
+		},
+		Id:          "intercept-02",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+	}
+	s.HandleIntercepts(ctx, nil)
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+}
+
+func TestState_Mounts(t *testing.T) {
+	ctx := testContext(t, nil)
+	c, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+	s := agent.NewState(c)
+	s.SetFileSharingPorts(2121, 2222, 8080)
+	cn := c.AgentConfig().Containers[0]
+	cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
+	s.AddContainerState(cn.Name, agent.NewContainerState(cnMountPoint, map[string]string{}, nil))
+
+	mi, err := s.Mounts(ctx, &agentrpc.MountsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, cn.Name, mi.Container)
+	assert.Equal(t, cnMountPoint, mi.MountPoint)
+	assert.Equal(t, cn.Mounts, mi.Mounts)
+	assert.Equal(t, int32(2121), mi.FtpPort)
+	assert.Equal(t, int32(2222), mi.SftpPort)
+	assert.Equal(t, int32(8080), mi.WebdavPort)
+
+	_, err = s.Mounts(ctx, &agentrpc.MountsRequest{Container: "no-such-container"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// captureManager is a traffic-manager that only authorizes captures by the client session "alice", and only
+// authorizes captures that include credentials when alice intercepts the workload.
+type captureManager struct {
+	rpc.ManagerClient
+	intercepted bool
+}
+
+func (m captureManager) AuthorizeCapture(_ context.Context, rq *rpc.AuthorizeCaptureRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	if rq.ClientSessionId != "alice" {
+		return nil, status.Error(codes.Unauthenticated, "no such session")
+	}
+	if rq.IncludeCredentials && !m.intercepted {
+		return nil, status.Error(codes.PermissionDenied, "no intercept")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+type captureServer struct {
+	agentrpc.Agent_CaptureServer
+	ctx context.Context
+}
+
+func (s *captureServer) Context() context.Context {
+	return s.ctx
+}
+
+func TestState_Capture(t *testing.T) {
+	ctx := testContext(t, nil)
+	c, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+	s := agent.NewState(c)
+
+	cCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	server := &captureServer{ctx: cCtx}
+	err = s.Capture(&agentrpc.CaptureRequest{ClientSessionId: "alice"}, server)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	s.SetManager(ctx, &rpc.SessionInfo{SessionId: "agent"}, captureManager{}, semver.MustParse("2.21.0"))
+	err = s.Capture(&agentrpc.CaptureRequest{ClientSessionId: "mallory"}, server)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.NoError(t, s.Capture(&agentrpc.CaptureRequest{ClientSessionId: "alice"}, server))
+	err = s.Capture(&agentrpc.CaptureRequest{ClientSessionId: "alice", IncludeCredentials: true}, server)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	s.SetManager(ctx, &rpc.SessionInfo{SessionId: "agent"}, captureManager{intercepted: true}, semver.MustParse("2.21.0"))
+	assert.NoError(t, s.Capture(&agentrpc.CaptureRequest{ClientSessionId: "alice", IncludeCredentials: true}, server))
+}
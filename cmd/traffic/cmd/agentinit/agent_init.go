@@ -20,6 +20,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/portredirect"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
 )
 
@@ -105,7 +106,7 @@ func (c *config) configureIptables(ctx context.Context, iptables *iptables.IPTab
 					if err != nil {
 						return fmt.Errorf("failed to append rule to %s: %w", outputChain, err)
 					}
-					if ic.TargetPortNumeric {
+					if ic.TargetPortNumeric || ic.Headless {
 						// The agent forwarder will not write directly to the container port when it is inactive.
 						// Instead, it writes to a proxy port and relies on it being redirected to the
 						// container port here.
@@ -193,6 +194,21 @@ func findLoopback() (string, error) {
 	return "", fmt.Errorf("unable to find loopback network interface")
 }
 
+// redirectPorts attaches the eBPF programs that redirect the numeric container ports of the intercepts to their
+// agent ports. The programs remain attached to the network interfaces of the pod when this container exits.
+func redirectPorts(ctx context.Context, ac *agentconfig.Sidecar) error {
+	ics := agentconfig.EBPFRedirectedIntercepts(ac)
+	rules := make([]portredirect.Rule, len(ics))
+	for i, ic := range ics {
+		rules[i] = portredirect.Rule{Protocol: ic.Protocol, From: ic.ContainerPort, To: ic.AgentPort}
+	}
+	if err := portredirect.Attach(ctx, rules); err != nil {
+		return fmt.Errorf("unable to redirect ports using eBPF: %w", err)
+	}
+	dlog.Infof(ctx, "Redirecting %d ports using eBPF", len(rules))
+	return nil
+}
+
 // Main is the main function for the agent init container.
 func Main(ctx context.Context, args ...string) error {
 	dlog.Infof(ctx, "Traffic Agent Init %s", version.Version)
@@ -206,6 +222,12 @@ func Main(ctx context.Context, args ...string) error {
 		dlog.Error(ctx, err)
 		return err
 	}
+	if ac := cfg.AgentConfig(); ac.PortRedirection == agentconfig.PortRedirectionEBPF {
+		if err = redirectPorts(ctx, ac); err != nil {
+			dlog.Error(ctx, err)
+		}
+		return err
+	}
 
 	lo, err := findLoopback()
 	if err != nil {
@@ -0,0 +1,140 @@
+package manager
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// Evict forcibly removes sessions and intercepts on behalf of a cluster operator. The operator is authenticated
+// using a token review, and must be allowed to delete sessions.telepresence.io in the namespace of the request, or
+// cluster-wide when the request has no namespace.
+func (s *service) Evict(ctx context.Context, request *rpc.EvictRequest) (*rpc.ReapResult, error) {
+	dlog.Debugf(ctx, "Evict called: user %q, namespace %q, workload %q", request.User, request.Namespace, request.Workload)
+	if !managerutil.GetEnv(ctx).AdminAPIEnabled {
+		return nil, status.Error(codes.Unavailable, "the admin API is not enabled in the traffic-manager")
+	}
+	if request.User == "" && request.Namespace == "" && request.Workload == "" {
+		return nil, status.Error(codes.InvalidArgument, "at least one of user, namespace, and workload must be given")
+	}
+	admin, err := authorizeAdmin(ctx, request.BearerToken, request.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	dlog.Infof(ctx, "%s evicts sessions and intercepts: user %q, namespace %q, workload %q",
+		admin, request.User, request.Namespace, request.Workload)
+	return s.state.Evict(ctx, request), nil
+}
+
+// authorizeAdmin returns the name of the user that the given bearer token belongs to, provided that the user is
+// allowed to delete sessions.telepresence.io in the given namespace.
+func authorizeAdmin(ctx context.Context, token, namespace string) (string, error) {
+	return reviewAccess(ctx, token, &authz.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "delete",
+		Group:     "telepresence.io",
+		Resource:  "sessions",
+	})
+}
+
+// authorizeSecrets returns the name of the user that the given bearer token belongs to, provided that the user is
+// allowed to get secrets in the given namespace.
+func authorizeSecrets(ctx context.Context, token, namespace string) (string, error) {
+	return reviewAccess(ctx, token, &authz.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "get",
+		Resource:  "secrets",
+	})
+}
+
+// authorizePublish returns the name of the user that the given bearer token belongs to, provided that the user is
+// allowed to create services in the given namespace.
+func authorizePublish(ctx context.Context, token, namespace string) (string, error) {
+	return reviewAccess(ctx, token, &authz.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "create",
+		Resource:  "services",
+	})
+}
+
+// authorizeJoin returns the name of the user that the given bearer token belongs to, provided that the user is
+// allowed to get the workload of the given intercept.
+func authorizeJoin(ctx context.Context, token string, spec *rpc.InterceptSpec) (string, error) {
+	ra := &authz.ResourceAttributes{
+		Namespace: spec.Namespace,
+		Verb:      "get",
+		Name:      spec.Agent,
+	}
+	switch spec.WorkloadKind {
+	case "Deployment":
+		ra.Group, ra.Resource = "apps", "deployments"
+	case "ReplicaSet":
+		ra.Group, ra.Resource = "apps", "replicasets"
+	case "StatefulSet":
+		ra.Group, ra.Resource = "apps", "statefulsets"
+	case "DaemonSet":
+		ra.Group, ra.Resource = "apps", "daemonsets"
+	case "Rollout":
+		ra.Group, ra.Resource = "argoproj.io", "rollouts"
+	default:
+		// The kind is unknown, so the user must be allowed to get the pods of the workload instead.
+		ra.Resource, ra.Name = "pods", ""
+	}
+	return reviewAccess(ctx, token, ra)
+}
+
+// reviewAccess authenticates the given bearer token using a token review, and returns the name of the user that it
+// belongs to, provided that a subject access review allows that user the given resource attributes.
+func reviewAccess(ctx context.Context, token string, ra *authz.ResourceAttributes) (string, error) {
+	if token == "" {
+		return "", status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+	api := k8sapi.GetK8sInterface(ctx)
+	tr, err := api.AuthenticationV1().TokenReviews().Create(ctx, &authn.TokenReview{
+		Spec: authn.TokenReviewSpec{Token: token},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "unable to review token: %v", err)
+	}
+	if !tr.Status.Authenticated {
+		return "", status.Error(codes.Unauthenticated, "the bearer token is not valid")
+	}
+
+	ui := tr.Status.User
+	extra := make(map[string]authz.ExtraValue, len(ui.Extra))
+	for k, v := range ui.Extra {
+		extra[k] = authz.ExtraValue(v)
+	}
+	sar, err := api.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authz.SubjectAccessReview{
+		Spec: authz.SubjectAccessReviewSpec{
+			ResourceAttributes: ra,
+			User:               ui.Username,
+			Groups:             ui.Groups,
+			UID:                ui.UID,
+			Extra:              extra,
+		},
+	}, meta.CreateOptions{})
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "unable to review access: %v", err)
+	}
+	if !sar.Status.Allowed {
+		res := ra.Resource
+		if ra.Subresource != "" {
+			res += "/" + ra.Subresource
+		}
+		if ra.Group != "" {
+			res += "." + ra.Group
+		}
+		return "", status.Errorf(codes.PermissionDenied, "%s is not allowed to %s %s", ui.Username, ra.Verb, res)
+	}
+	return ui.Username, nil
+}
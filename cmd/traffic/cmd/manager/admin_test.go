@@ -0,0 +1,138 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	fakeargorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned/fake"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+func TestEvict(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	// The token "admin" belongs to a user that may delete sessions, and the token "dev" to one that may not.
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "admin", "dev":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: tr.Spec.Token}}
+		}
+		return true, tr, nil
+	})
+	fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "admin" && ra.Verb == "delete" && ra.Group == "telepresence.io" && ra.Resource == "sessions"
+		return true, sar, nil
+	})
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+	env := &managerutil.Env{}
+	ctx = managerutil.WithEnv(ctx, env)
+	s := &service{ctx: ctx, clock: wall{}, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := s.state.AddClient(&rpc.ClientInfo{Name: "alice@laptop", Namespace: "default"}, time.Now())
+	rq := &rpc.EvictRequest{BearerToken: "admin", User: "alice"}
+	_, err := s.Evict(ctx, rq)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	env.AdminAPIEnabled = true
+	_, err = s.Evict(ctx, &rpc.EvictRequest{BearerToken: "admin"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.Evict(ctx, &rpc.EvictRequest{BearerToken: "forged", User: "alice"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.Evict(ctx, &rpc.EvictRequest{BearerToken: "dev", User: "alice"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.NotNil(t, s.state.GetClient(alice))
+
+	result, err := s.Evict(ctx, rq)
+	require.NoError(t, err)
+	assert.Equal(t, []string{alice}, result.SessionIds)
+	assert.Nil(t, s.state.GetClient(alice))
+}
+
+func TestAuthorizeSecrets(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	// The token "dev" belongs to a user that may get secrets in the namespace "dev", and the token "guest" to one
+	// that may not.
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "dev", "guest":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: tr.Spec.Token}}
+		}
+		return true, tr, nil
+	})
+	fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "dev" && ra.Namespace == "dev" && ra.Verb == "get" && ra.Resource == "secrets"
+		return true, sar, nil
+	})
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+
+	_, err := authorizeSecrets(ctx, "", "dev")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = authorizeSecrets(ctx, "guest", "dev")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = authorizeSecrets(ctx, "dev", "prod")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	user, err := authorizeSecrets(ctx, "dev", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", user)
+}
+
+func TestAuthorizeJoin(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	// The token "dev" belongs to a user that may get the deployment "echo" in the namespace "dev", and the token
+	// "guest" to one that may not.
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "dev", "guest":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: tr.Spec.Token}}
+		}
+		return true, tr, nil
+	})
+	fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "dev" && ra.Namespace == "dev" && ra.Verb == "get" &&
+			ra.Group == "apps" && ra.Resource == "deployments" && ra.Name == "echo"
+		return true, sar, nil
+	})
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+
+	spec := &rpc.InterceptSpec{Name: "echo", Agent: "echo", WorkloadKind: "Deployment", Namespace: "dev"}
+	_, err := authorizeJoin(ctx, "", spec)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = authorizeJoin(ctx, "guest", spec)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = authorizeJoin(ctx, "dev", &rpc.InterceptSpec{Name: "echo", Agent: "echo", WorkloadKind: "StatefulSet", Namespace: "dev"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	user, err := authorizeJoin(ctx, "dev", spec)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", user)
+}
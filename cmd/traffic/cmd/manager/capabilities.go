@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"context"
+	"slices"
+
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
+)
+
+// GetCapabilities returns the optional features that this traffic-manager supports. Features that were
+// introduced after the CompatibilityVersion, or that the configuration disables, are excluded.
+func (s *service) GetCapabilities(ctx context.Context, _ *empty.Empty) (*rpc.Capabilities, error) {
+	dlog.Debug(ctx, "GetCapabilities called")
+	env := managerutil.GetEnv(ctx)
+	features := make([]string, 0, len(capability.Features))
+	for name, f := range capability.Features {
+		if checkCompat(ctx, name, f.Since.String()) != nil || !featureEnabled(env, name) {
+			continue
+		}
+		features = append(features, name)
+	}
+	slices.Sort(features)
+	return &rpc.Capabilities{Features: features}, nil
+}
+
+// featureEnabled returns false if the named feature is disabled by the given configuration.
+func featureEnabled(env *managerutil.Env, name string) bool {
+	switch name {
+	case capability.Metrics:
+		return env.PrometheusPort != 0
+	case capability.PublishedServices:
+		return env.PublishedServicesEnabled
+	case capability.RegistryProxy:
+		return env.RegistryProxyPort != 0
+	case capability.AdminAPI:
+		return env.AdminAPIEnabled
+	default:
+		return true
+	}
+}
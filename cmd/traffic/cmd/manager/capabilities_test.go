@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
+)
+
+func TestGetCapabilities(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	env := &managerutil.Env{}
+	ctx = managerutil.WithEnv(ctx, env)
+	s := &service{ctx: ctx}
+
+	mc, err := s.GetCapabilities(ctx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, mc.Features, capability.InterceptSharing)
+	assert.NotContains(t, mc.Features, capability.PublishedServices)
+	assert.NotContains(t, mc.Features, capability.AdminAPI)
+	assert.NotContains(t, mc.Features, capability.Metrics)
+
+	env.PublishedServicesEnabled = true
+	env.PrometheusPort = 9090
+	mc, err = s.GetCapabilities(ctx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, mc.Features, capability.PublishedServices)
+	assert.Contains(t, mc.Features, capability.Metrics)
+
+	// A traffic-manager that pretends to be an older version doesn't report newer features.
+	cv := semver.MustParse("2.20.0")
+	env.CompatibilityVersion = &cv
+	mc, err = s.GetCapabilities(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{capability.Metrics, capability.ProxyVia, capability.Replace, capability.UDP}, mc.Features)
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authz "k8s.io/api/authorization/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// clientAuthTTL is how long a successful review of a client's bearer token is trusted before the token is
+// reviewed again.
+const clientAuthTTL = time.Minute
+
+// clientAuth authenticates clients that reach the traffic-manager from outside the cluster, i.e. through an
+// Ingress, a Gateway API route, or a WebSocket listener. Such clients must present a Kubernetes bearer token in
+// the "authorization" metadata of each call, and the token's user must be allowed to create pods/portforward in
+// the traffic-manager's namespace, which is what clients that use a port-forward need anyway.
+type clientAuth struct {
+	sync.Mutex
+	reviewed map[[sha256.Size]byte]time.Time
+	now      func() time.Time
+}
+
+func newClientAuth() *clientAuth {
+	return &clientAuth{reviewed: make(map[[sha256.Size]byte]time.Time), now: time.Now}
+}
+
+// serverOptions returns the options that install the authentication as interceptors on a gRPC server.
+func (a *clientAuth) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, rq any, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			if err := a.authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return h(ctx, rq)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return h(srv, ss)
+		}),
+	}
+}
+
+// authorize returns an error unless the call carries a bearer token that is allowed access. The health service
+// is exempt, so that load balancers can probe the listener.
+func (a *clientAuth) authorize(ctx context.Context, method string) error {
+	if strings.HasPrefix(method, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if t, ok := strings.CutPrefix(v, "Bearer "); ok {
+				token = t
+				break
+			}
+		}
+	}
+	if token == "" {
+		return status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+
+	key := sha256.Sum256([]byte(token))
+	now := a.now()
+	a.Lock()
+	exp, ok := a.reviewed[key]
+	a.Unlock()
+	if ok && now.Before(exp) {
+		return nil
+	}
+	user, err := reviewAccess(ctx, token, &authz.ResourceAttributes{
+		Namespace:   managerutil.GetEnv(ctx).ManagerNamespace,
+		Verb:        "create",
+		Resource:    "pods",
+		Subresource: "portforward",
+	})
+	if err != nil {
+		return err
+	}
+	dlog.Debugf(ctx, "Authenticated %s calling %s", user, method)
+	a.Lock()
+	for k, e := range a.reviewed {
+		if !now.Before(e) {
+			delete(a.reviewed, k)
+		}
+	}
+	a.reviewed[key] = now.Add(clientAuthTTL)
+	a.Unlock()
+	return nil
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	fakeargorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned/fake"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func TestClientAuth(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	// The token "dev" belongs to a user that may port-forward to the traffic-manager, and the token "guest" to one
+	// that may not.
+	reviews := 0
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "dev", "guest":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: tr.Spec.Token}}
+		}
+		return true, tr, nil
+	})
+	fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "dev" && ra.Namespace == "ambassador" &&
+			ra.Verb == "create" && ra.Resource == "pods" && ra.Subresource == "portforward"
+		return true, sar, nil
+	})
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{ManagerNamespace: "ambassador"})
+
+	now := time.Now()
+	a := newClientAuth()
+	a.now = func() time.Time { return now }
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+	const method = "/telepresence.manager.Manager/ArriveAsClient"
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(a.authorize(ctx, method)))
+	assert.Equal(t, codes.Unauthenticated, status.Code(a.authorize(withToken("forged"), method)))
+	assert.Equal(t, codes.PermissionDenied, status.Code(a.authorize(withToken("guest"), method)))
+	assert.NoError(t, a.authorize(ctx, "/grpc.health.v1.Health/Check"))
+
+	// A successful review is trusted until it expires.
+	reviews = 0
+	assert.NoError(t, a.authorize(withToken("dev"), method))
+	assert.NoError(t, a.authorize(withToken("dev"), method))
+	assert.Equal(t, 1, reviews)
+	now = now.Add(clientAuthTTL)
+	assert.NoError(t, a.authorize(withToken("dev"), method))
+	assert.Equal(t, 2, reviews)
+}
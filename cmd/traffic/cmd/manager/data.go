@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/labels"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 func validateClient(client *rpc.ClientInfo) string {
@@ -76,6 +78,43 @@ func validateIntercept(spec *rpc.InterceptSpec) string {
 		return "namespace must not be empty"
 	case spec.Mechanism == "":
 		return "mechanism must not be empty"
+	case spec.InjectLatency < 0:
+		return "inject latency must not be negative"
+	case spec.InjectErrorRate < 0 || spec.InjectErrorRate > 100:
+		return "inject error rate must be between 0 and 100"
+	case spec.BandwidthLimit < 0:
+		return "bandwidth limit must not be negative"
+	case spec.HttpBodyLimit < 0:
+		return "http body limit must not be negative"
+	}
+	if _, err := matcher.NewRequestFromMap(spec.HttpHeaders); err != nil {
+		return fmt.Sprintf("invalid http headers: %v", err)
+	}
+	if _, err := matcher.NewBody(spec.HttpBodyJson, spec.HttpBodyXpath); err != nil {
+		return fmt.Sprintf("invalid http body filters: %v", err)
+	}
+	if _, err := labels.Parse(spec.PodSelector); err != nil {
+		return fmt.Sprintf("invalid pod selector: %v", err)
+	}
+	if len(spec.PodOrdinals) > 0 {
+		if spec.WorkloadKind != "" && spec.WorkloadKind != "StatefulSet" {
+			return fmt.Sprintf("pod ordinals can only be used with a StatefulSet, not with a %s", spec.WorkloadKind)
+		}
+		for _, o := range spec.PodOrdinals {
+			if o < 0 {
+				return "pod ordinals must not be negative"
+			}
+		}
+	}
+	if spec.ReplicaSet != "" || spec.Revision != 0 || spec.PodTemplateHash != "" {
+		switch spec.WorkloadKind {
+		case "", "Deployment", "Rollout":
+		default:
+			return fmt.Sprintf("a replica set or revision can only be selected for a Deployment or a Rollout, not for a %s", spec.WorkloadKind)
+		}
+		if spec.Revision < 0 {
+			return "revision must not be negative"
+		}
 	}
 
 	return ""
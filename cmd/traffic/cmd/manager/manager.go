@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"slices"
@@ -16,23 +17,28 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	argorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned"
+	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dhttp"
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
+	"github.com/telepresenceio/telepresence/v2/pkg/wsconn"
 )
 
 var (
@@ -158,6 +164,14 @@ func MainWithEnv(ctx context.Context) (err error) {
 
 	g.Go("session-gc", mgr.runSessionGCLoop)
 
+	if env.ReaperInterval > 0 {
+		g.Go("reaper", mgr.runReaperLoop)
+	}
+
+	if env.RegistryProxyPort != 0 {
+		g.Go("registry-proxy", mgr.serveRegistryProxy)
+	}
+
 	if tracer != nil {
 		g.Go("tracer-grpc", func(c context.Context) error {
 			return tracer.ServeGrpc(c, env.TracingGrpcPort)
@@ -235,6 +249,15 @@ func (s *service) servePrometheus(ctx context.Context) error {
 	newGaugeFunc("tunnel_count", "Number of tunnels", s.state.CountTunnels)
 	newCounterFunc("tunnel_ingress_bytes", "Number of bytes tunneled from clients", s.state.CountTunnelIngress)
 	newCounterFunc("tunnel_egress_bytes", "Number bytes tunneled to clients", s.state.CountTunnelEgress)
+	newCounterFunc("watch_updates_sent", "Number of workload and agent updates sent on watch streams", func() uint64 {
+		return s.state.CountWatchUpdates(state.WatchUpdateSent)
+	})
+	newCounterFunc("watch_updates_coalesced", "Number of workload and agent updates on watch streams that were replaced by later updates", func() uint64 {
+		return s.state.CountWatchUpdates(state.WatchUpdateCoalesced)
+	})
+	newCounterFunc("watch_updates_dropped", "Number of workload and agent updates on watch streams that were dropped because nothing changed", func() uint64 {
+		return s.state.CountWatchUpdates(state.WatchUpdateDropped)
+	})
 
 	newGaugeFunc("active_http_request_count", "Number of currently served http requests", func() int {
 		return int(atomic.LoadInt32(&s.activeHttpRequests))
@@ -284,18 +307,53 @@ func (s *service) serveHTTP(ctx context.Context) error {
 	}
 
 	grpcHandler := grpc.NewServer(opts...)
+	s.self.RegisterServers(grpcHandler)
+	if s.health != nil {
+		// Report NOT_SERVING while the server drains its connections.
+		context.AfterFunc(ctx, s.health.Shutdown)
+	}
+	context.AfterFunc(ctx, func() { s.beginShutdown(ctx) })
+	if env.ClientAuthPort == 0 && env.WebSocketPort == 0 {
+		return s.grpcServerConfig(ctx, "grpc-api", host, port, grpcHandler).ListenAndServe(ctx, iputil.JoinHostPort(host, port))
+	}
+
+	// Listeners that are reached from outside the cluster, through an Ingress, a Gateway API route, or a
+	// WebSocket, serve the same API, but require that clients authenticate.
+	authHandler := grpc.NewServer(append(opts, newClientAuth().serverOptions()...)...)
+	s.self.RegisterServers(authHandler)
+
+	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+	g.Go("grpc-api", func(ctx context.Context) error {
+		return s.grpcServerConfig(ctx, "grpc-api", host, port, grpcHandler).ListenAndServe(ctx, iputil.JoinHostPort(host, port))
+	})
+	if aPort := env.ClientAuthPort; aPort != 0 {
+		g.Go("grpc-client-auth", func(ctx context.Context) error {
+			dlog.Infof(ctx, "Authenticated gRPC server started on port: %d", aPort)
+			defer dlog.Info(ctx, "Authenticated gRPC server stopped")
+			return s.grpcServerConfig(ctx, "grpc-client-auth", host, aPort, authHandler).ListenAndServe(ctx, iputil.JoinHostPort(host, aPort))
+		})
+	}
+	if env.WebSocketPort != 0 {
+		g.Go("grpc-websocket", func(ctx context.Context) error {
+			return s.serveWebSocket(ctx, authHandler)
+		})
+	}
+	return g.Wait()
+}
+
+// grpcServerConfig returns a server config that dispatches gRPC requests to the given gRPC server.
+func (s *service) grpcServerConfig(ctx context.Context, name, host string, port uint16, grpcHandler *grpc.Server) *dhttp.ServerConfig {
 	httpHandler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Hello World from: %s\n", r.URL.Path)
 	}))
 
 	lg := dlog.StdLogger(ctx, dlog.MaxLogLevel(ctx))
-	addr := iputil.JoinHostPort(host, port)
 	if host == "" {
-		lg.SetPrefix(fmt.Sprintf("grpc-api:%d", port))
+		lg.SetPrefix(fmt.Sprintf("%s:%d", name, port))
 	} else {
-		lg.SetPrefix(fmt.Sprintf("grpc-api %s", addr))
+		lg.SetPrefix(fmt.Sprintf("%s %s", name, iputil.JoinHostPort(host, port)))
 	}
-	sc := &dhttp.ServerConfig{
+	return &dhttp.ServerConfig{
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
 				atomic.AddInt32(&s.activeGrpcRequests, 1)
@@ -309,13 +367,53 @@ func (s *service) serveHTTP(ctx context.Context) error {
 		}),
 		ErrorLog: lg,
 	}
-	s.self.RegisterServers(grpcHandler)
-	return sc.ListenAndServe(ctx, fmt.Sprintf("%s:%d", host, port))
 }
 
+// serveWebSocket serves the gRPC API over WebSocket connections, for clients that must connect
+// through proxies that only allow HTTPS and WebSocket traffic. The given gRPC server must
+// authenticate its clients.
+func (s *service) serveWebSocket(ctx context.Context, grpcHandler *grpc.Server) error {
+	env := managerutil.GetEnv(ctx)
+	addr := iputil.JoinHostPort(env.ServerHost, env.WebSocketPort)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	wsl := wsconn.NewListener(ln.Addr(), env.WebSocketOrigins...)
+	go func() {
+		<-ctx.Done()
+		_ = wsl.Close()
+	}()
+	go func() {
+		if err := grpcHandler.Serve(wsl); err != nil && ctx.Err() == nil {
+			dlog.Errorf(ctx, "gRPC over WebSocket: %v", err)
+		}
+	}()
+
+	lg := dlog.StdLogger(ctx, dlog.MaxLogLevel(ctx))
+	lg.SetPrefix(fmt.Sprintf("grpc-websocket:%d", env.WebSocketPort))
+	sc := &dhttp.ServerConfig{
+		Handler:  wsl,
+		ErrorLog: lg,
+	}
+	dlog.Infof(ctx, "gRPC over WebSocket server started on port: %d", env.WebSocketPort)
+	defer dlog.Info(ctx, "gRPC over WebSocket server stopped")
+	return sc.Serve(ctx, ln)
+}
+
+// RegisterServers registers the manager API, and the standard health and reflection services, with the
+// given server. It's called once for each listener, and the same health server is used for all of them.
 func (s *service) RegisterServers(grpcHandler *grpc.Server) {
 	rpc.RegisterManagerServer(grpcHandler, s)
-	grpc_health_v1.RegisterHealthServer(grpcHandler, &HealthChecker{})
+
+	// The standard health and reflection services let tools like grpc-health-probe and grpcurl probe the
+	// traffic-manager and discover its API without a copy of its protos.
+	if s.health == nil {
+		s.health = health.NewServer()
+		s.health.SetServingStatus(rpc.Manager_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+	grpc_health_v1.RegisterHealthServer(grpcHandler, s.health)
+	reflection.Register(grpcHandler)
 }
 
 func (s *service) runSessionGCLoop(ctx context.Context) error {
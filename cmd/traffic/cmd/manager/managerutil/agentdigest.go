@@ -0,0 +1,60 @@
+package managerutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/imageref"
+)
+
+// ResolveDigestFunc returns the digest of the given image for the given architecture, or the digest of the image
+// index when the architecture is empty.
+var ResolveDigestFunc = func(ctx context.Context, img, arch string) (string, error) { //nolint:gochecknoglobals // extension point
+	r := imageref.Resolver{}
+	return r.PlatformDigest(ctx, img, "linux", arch)
+}
+
+const (
+	// digestTTL is how long a resolved digest is cached. A tag that is moved is picked up when it expires.
+	digestTTL = 5 * time.Minute
+
+	// digestTimeout limits the time that the resolution of a digest may add to a webhook call.
+	digestTimeout = 5 * time.Second
+)
+
+type pinnedImage struct {
+	image   string
+	expires time.Time
+}
+
+var pinnedImages sync.Map //nolint:gochecknoglobals // cache
+
+// PinAgentImage returns the given traffic-agent image pinned to its digest, e.g.
+// "ghcr.io/telepresenceio/tel2:2.21.0@sha256:…", when the AgentImagePinDigest is enabled. The digest is the digest of
+// the image for the given architecture, or the digest of the multi-arch image index when the architecture is empty.
+// The image is returned unchanged when pinning is disabled, or when the digest cannot be resolved.
+func PinAgentImage(ctx context.Context, img, arch string) string {
+	if img == "" || !GetEnv(ctx).AgentImagePinDigest {
+		return img
+	}
+	img = imageref.WithoutDigest(img)
+	key := img + "/" + arch
+	if v, ok := pinnedImages.Load(key); ok {
+		if pi := v.(pinnedImage); time.Now().Before(pi.expires) {
+			return pi.image
+		}
+	}
+	pinned := img
+	tc, cancel := context.WithTimeout(ctx, digestTimeout)
+	defer cancel()
+	if dg, err := ResolveDigestFunc(tc, img, arch); err != nil {
+		// Failures are cached too, so that a registry that can't be reached doesn't slow down every injection.
+		dlog.Warnf(ctx, "unable to pin traffic-agent image %s to a digest, using the tag: %v", img, err)
+	} else {
+		pinned = img + "@" + dg
+	}
+	pinnedImages.Store(key, pinnedImage{image: pinned, expires: time.Now().Add(digestTTL)})
+	return pinned
+}
@@ -0,0 +1,45 @@
+package managerutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestPinAgentImage(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	env := &Env{}
+	ctx = WithEnv(ctx, env)
+
+	calls := 0
+	saved := ResolveDigestFunc
+	ResolveDigestFunc = func(_ context.Context, img, arch string) (string, error) {
+		calls++
+		switch arch {
+		case "arm64":
+			return "sha256:arm64", nil
+		case "s390x":
+			return "", errors.New("no such platform")
+		}
+		return "sha256:index", nil
+	}
+	t.Cleanup(func() { ResolveDigestFunc = saved })
+
+	const img = "example.com/tel2:2.21.0"
+	assert.Equal(t, img, PinAgentImage(ctx, img, ""), "pinning is disabled")
+	assert.Equal(t, 0, calls)
+
+	env.AgentImagePinDigest = true
+	assert.Equal(t, img+"@sha256:index", PinAgentImage(ctx, img, ""))
+	assert.Equal(t, img+"@sha256:arm64", PinAgentImage(ctx, img, "arm64"))
+	assert.Equal(t, img+"@sha256:arm64", PinAgentImage(ctx, img+"@sha256:other", "arm64"), "existing digest is replaced")
+	assert.Equal(t, 2, calls, "digests are cached")
+
+	assert.Equal(t, img, PinAgentImage(ctx, img, "s390x"), "failure falls back to the tag")
+	assert.Equal(t, img, PinAgentImage(ctx, img, "s390x"))
+	assert.Equal(t, 3, calls, "failures are cached")
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+	"github.com/telepresenceio/telepresence/v2/pkg/version"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
 )
 
@@ -43,8 +45,31 @@ type Env struct {
 	APIPort             uint16        `env:"AGENT_REST_API_PORT,      parser=port-number, default=0"`
 	AgentArrivalTimeout time.Duration `env:"AGENT_ARRIVAL_TIMEOUT,    parser=time.ParseDuration, default=0"`
 
-	TracingGrpcPort uint16            `env:"TRACING_GRPC_PORT,     parser=port-number,default=0"`
-	MaxReceiveSize  resource.Quantity `env:"GRPC_MAX_RECEIVE_SIZE, parser=quantity"`
+	InterceptPropagation []string `env:"INTERCEPT_PROPAGATION, parser=split-trim, default="`
+
+	TracingGrpcPort         uint16            `env:"TRACING_GRPC_PORT,         parser=port-number,default=0"`
+	MaxReceiveSize          resource.Quantity `env:"GRPC_MAX_RECEIVE_SIZE,     parser=quantity"`
+	WebSocketPort           uint16            `env:"GRPC_WEBSOCKET_PORT,       parser=port-number,default=0"`
+	WebSocketOrigins        []string          `env:"GRPC_WEBSOCKET_ORIGINS,    parser=split-trim, default="`
+	ClientAuthPort          uint16            `env:"GRPC_CLIENT_AUTH_PORT,     parser=port-number,default=0"`
+	TunnelCompressionClient []string          `env:"TUNNEL_COMPRESSION_CLIENT, parser=split-trim, default=zstd gzip"`
+	TunnelCompressionAgent  []string          `env:"TUNNEL_COMPRESSION_AGENT,  parser=split-trim, default="`
+	WatchCoalesceWindow     time.Duration     `env:"WATCH_COALESCE_WINDOW,     parser=time.ParseDuration, default=0"`
+
+	RegistryProxyPort    uint16 `env:"REGISTRY_PROXY_PORT,    parser=port-number, default=0"`
+	RegistryProxyAddress string `env:"REGISTRY_PROXY_ADDRESS, parser=string,      default="`
+
+	PublishedServicesEnabled bool `env:"PUBLISHED_SERVICES_ENABLED, parser=bool, default=false"`
+	AdminAPIEnabled          bool `env:"ADMIN_API_ENABLED,          parser=bool, default=false"`
+
+	ReaperInterval     time.Duration `env:"REAPER_INTERVAL,      parser=time.ParseDuration, default=0"`
+	ReaperInterceptTTL time.Duration `env:"REAPER_INTERCEPT_TTL, parser=time.ParseDuration, default=0"`
+	ReaperGracePeriod  time.Duration `env:"REAPER_GRACE_PERIOD,  parser=time.ParseDuration, default=0"`
+
+	RateLimitInterval time.Duration `env:"RATE_LIMIT_INTERVAL, parser=time.ParseDuration, default=0"`
+	RateLimitBurst    int           `env:"RATE_LIMIT_BURST,    parser=strconv.ParseInt,   default=10"`
+
+	ShutdownGracePeriod time.Duration `env:"SHUTDOWN_GRACE_PERIOD, parser=time.ParseDuration, default=5s"`
 
 	PodCIDRStrategy string         `env:"POD_CIDR_STRATEGY, parser=nonempty-string"`
 	PodCIDRs        []netip.Prefix `env:"POD_CIDRS,         parser=split-ipnet, default="`
@@ -55,15 +80,26 @@ type Env struct {
 	AgentImageTag            string                      `env:"AGENT_IMAGE_TAG,          parser=string,         default="`
 	AgentImagePullPolicy     string                      `env:"AGENT_IMAGE_PULL_POLICY,  parser=string,         default="`
 	AgentImagePullSecrets    []core.LocalObjectReference `env:"AGENT_IMAGE_PULL_SECRETS, parser=json-local-refs,default="`
+	AgentImagePinDigest      bool                        `env:"AGENT_IMAGE_PIN_DIGEST,   parser=bool,           default=false"`
 	AgentInjectPolicy        agentconfig.InjectPolicy    `env:"AGENT_INJECT_POLICY,      parser=enable-policy,  default=Never"`
 	AgentAppProtocolStrategy k8sapi.AppProtocolStrategy  `env:"AGENT_APP_PROTO_STRATEGY, parser=app-proto-strategy, default=http2Probe"`
 	AgentLogLevel            string                      `env:"AGENT_LOG_LEVEL,          parser=logLevel,       defaultFrom=LogLevel"`
 	AgentPort                uint16                      `env:"AGENT_PORT,               parser=port-number,    default=0"`
+	AgentRetention           time.Duration               `env:"AGENT_RETENTION,          parser=retention,      default=0"`
 	AgentResources           *core.ResourceRequirements  `env:"AGENT_RESOURCES,          parser=json-resources, default="`
 	AgentInitResources       *core.ResourceRequirements  `env:"AGENT_INIT_RESOURCES,     parser=json-resources, default="`
 	AgentInjectorName        string                      `env:"AGENT_INJECTOR_NAME,      parser=string,         default="`
 	AgentInjectorSecret      string                      `env:"AGENT_INJECTOR_SECRET,    parser=string,         default="`
 	AgentSecurityContext     *core.SecurityContext       `env:"AGENT_SECURITY_CONTEXT,   parser=json-security-context, default="`
+	AgentPatch               *agentconfig.SidecarPatch   `env:"AGENT_PATCH,              parser=json-sidecar-patch, default="`
+	AgentMeshAware           bool                        `env:"AGENT_MESH_AWARE,         parser=bool,           default=true"`
+	AgentPortRedirection     string                      `env:"AGENT_PORT_REDIRECTION,   parser=port-redirection, default=iptables"`
+
+	AgentPodSecurityStandard    string `env:"AGENT_POD_SECURITY_STANDARD,     parser=pod-security-standard, default=privileged"`
+	AgentRunAsUser              int64  `env:"AGENT_RUN_AS_USER,               parser=strconv.ParseInt,      default=1000"`
+	AgentReadOnlyRootFilesystem bool   `env:"AGENT_READ_ONLY_ROOT_FILESYSTEM, parser=bool,                  default=false"`
+
+	PodDaemonImage string `env:"POD_DAEMON_IMAGE, parser=string, default="`
 
 	ClientRoutingAlsoProxySubnets        []netip.Prefix `env:"CLIENT_ROUTING_ALSO_PROXY_SUBNETS,  		parser=split-ipnet, default="`
 	ClientRoutingNeverProxySubnets       []netip.Prefix `env:"CLIENT_ROUTING_NEVER_PROXY_SUBNETS, 		parser=split-ipnet, default="`
@@ -79,6 +115,9 @@ type Env struct {
 }
 
 func (e *Env) GeneratorConfig(qualifiedAgentImage string) (agentmap.GeneratorConfig, error) {
+	if _, err := matcher.ParsePropagations(e.InterceptPropagation); err != nil {
+		return nil, fmt.Errorf("INTERCEPT_PROPAGATION: %w", err)
+	}
 	return &agentmap.BasicGeneratorConfig{
 		AgentPort:           e.AgentPort,
 		APIPort:             e.APIPort,
@@ -93,9 +132,26 @@ func (e *Env) GeneratorConfig(qualifiedAgentImage string) (agentmap.GeneratorCon
 		PullSecrets:         e.AgentImagePullSecrets,
 		AppProtocolStrategy: e.AgentAppProtocolStrategy,
 		SecurityContext:     e.AgentSecurityContext,
+		Propagation:         e.InterceptPropagation,
+		Patch:               e.AgentPatch,
+		PodSecurity:         e.agentPodSecurity(),
+		PortRedirection:     e.AgentPortRedirection,
 	}, nil
 }
 
+// agentPodSecurity returns the PodSecurity of the injected traffic-agents, or nil when they need no adjustments.
+func (e *Env) agentPodSecurity() *agentconfig.PodSecurity {
+	ps := &agentconfig.PodSecurity{
+		Standard:               e.AgentPodSecurityStandard,
+		RunAsUser:              e.AgentRunAsUser,
+		ReadOnlyRootFilesystem: e.AgentReadOnlyRootFilesystem,
+	}
+	if ps.AllowsInitContainer() && !ps.ReadOnlyRootFilesystem {
+		return nil
+	}
+	return ps
+}
+
 func (e *Env) QualifiedAgentImage() string {
 	img := e.AgentImageName
 	if img == "" {
@@ -108,13 +164,30 @@ func (e *Env) QualifiedAgentImage() string {
 	return img
 }
 
+// QualifiedPodDaemonImage returns the image of the pod-daemon sidecars that the agent injector adds to pods
+// that enable them. It defaults to the client image of the traffic-manager's registry and version.
+func (e *Env) QualifiedPodDaemonImage() string {
+	if e.PodDaemonImage != "" {
+		return e.PodDaemonImage
+	}
+	return e.Registry + "/telepresence:" + strings.TrimPrefix(version.Version, "v")
+}
+
 func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 	fhs := envconfig.DefaultFieldTypeHandlers()
 	fp := fhs[reflect.TypeOf("")]
 	fp.Parsers["string"] = fp.Parsers["possibly-empty-string"]
 	fp.Parsers["logLevel"] = fp.Parsers["logrus.ParseLevel"]
+	fp.Parsers["pod-security-standard"] = func(str string) (any, error) {
+		return str, agentconfig.ValidatePodSecurityStandard(str)
+	}
+	fp.Parsers["port-redirection"] = func(str string) (any, error) {
+		return str, agentconfig.ValidatePortRedirection(str)
+	}
 	fp = fhs[reflect.TypeOf(true)]
 	fp.Parsers["bool"] = fp.Parsers["strconv.ParseBool"]
+	fp = fhs[reflect.TypeOf(time.Duration(0))]
+	fp.Parsers["retention"] = func(str string) (any, error) { return agentconfig.ParseRetention(str) }
 	fhs[reflect.TypeOf(uint16(0))] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"port-number": func(str string) (any, error) {
@@ -235,6 +308,24 @@ func fieldTypeHandlers() map[reflect.Type]envconfig.FieldTypeHandler {
 		},
 		Setter: func(dst reflect.Value, src any) { dst.Set(reflect.ValueOf(src.(*core.SecurityContext))) },
 	}
+	fhs[reflect.TypeOf(&agentconfig.SidecarPatch{})] = envconfig.FieldTypeHandler{
+		Parsers: map[string]func(string) (any, error){
+			"json-sidecar-patch": func(js string) (any, error) {
+				if js == "" {
+					return nil, nil
+				}
+				var sp *agentconfig.SidecarPatch
+				if err := json.Unmarshal([]byte(js), &sp); err != nil {
+					return nil, err
+				}
+				if err := sp.Validate(); err != nil {
+					return nil, err
+				}
+				return sp, nil
+			},
+		},
+		Setter: func(dst reflect.Value, src any) { dst.Set(reflect.ValueOf(src.(*agentconfig.SidecarPatch))) },
+	}
 	fhs[reflect.TypeOf(true)] = envconfig.FieldTypeHandler{
 		Parsers: map[string]func(string) (any, error){
 			"bool": func(str string) (any, error) {
@@ -44,6 +44,10 @@ func TestEnvconfig(t *testing.T) {
 		AgentAppProtocolStrategy: k8sapi.Http2Probe,
 		AgentLogLevel:            "info",
 		AgentPort:                9900,
+		AgentMeshAware:           true,
+		AgentPortRedirection:     "iptables",
+		AgentPodSecurityStandard: "privileged",
+		AgentRunAsUser:           1000,
 		AgentInjectorName:        "agent-injector",
 		AgentInjectorSecret:      "mutator-webhook-tls",
 		AgentArrivalTimeout:      45 * time.Second,
@@ -52,8 +56,11 @@ func TestEnvconfig(t *testing.T) {
 		LogLevel:                 "info",
 		MaxReceiveSize:           resource.MustParse("4Mi"),
 		PodCIDRStrategy:          "auto",
+		RateLimitBurst:           10,
+		ShutdownGracePeriod:      5 * time.Second,
 		PodIP:                    netip.AddrFrom4([4]byte{203, 0, 113, 18}),
 		ServerPort:               8081,
+		TunnelCompressionClient:  []string{"zstd", "gzip"},
 		EnabledWorkloadKinds:     []workload.WorkloadKind{workload.DeploymentWorkloadKind, workload.StatefulSetWorkloadKind, workload.ReplicaSetWorkloadKind},
 	}
 
@@ -83,6 +90,14 @@ func TestEnvconfig(t *testing.T) {
 				e.ClientRoutingNeverProxySubnets = []netip.Prefix{a, b}
 			},
 		},
+		"retention": {
+			Input: map[string]string{
+				"AGENT_RETENTION": "7d",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentRetention = 7 * 24 * time.Hour
+			},
+		},
 	}
 
 	for tcName, tc := range testcases {
@@ -18,6 +18,7 @@ import (
 	core "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"k8s.io/utils/strings/slices"
 
 	"github.com/datawire/dlib/derror"
@@ -26,6 +27,8 @@ import (
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/imageref"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/maps"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
@@ -119,6 +122,15 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 	dlog.Debugf(ctx, "Handling admission request %s %s.%s", req.Operation, pod.Name, pod.Namespace)
 	env := managerutil.GetEnv(ctx)
 
+	switch pa := pod.Annotations[agentconfig.InjectPodDaemonAnnotation]; pa {
+	case "", "false", "disabled":
+	case "enabled":
+		// A pod that handles an intercept using a pod-daemon is never intercepted itself.
+		return injectPodDaemon(ctx, pod)
+	default:
+		return nil, fmt.Errorf("invalid value %q for annotation %s", pa, agentconfig.InjectPodDaemonAnnotation)
+	}
+
 	ia := pod.Annotations[agentconfig.InjectAnnotation]
 	span.SetAttributes(
 		attribute.String("tel2.pod-name", pod.Name),
@@ -156,8 +168,13 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 				supportedKinds[i] = "ReplicaSet"
 			case workload.StatefulSetWorkloadKind:
 				supportedKinds[i] = "StatefulSet"
+			case workload.DaemonSetWorkloadKind:
+				supportedKinds[i] = "DaemonSet"
 			case workload.RolloutWorkloadKind:
 				supportedKinds[i] = "Rollout"
+			default:
+				// A kind that is resolved by a registered agentmap.WorkloadResolver.
+				supportedKinds[i] = string(wlKind)
 			}
 		}
 		wl, err := agentmap.FindOwnerWorkload(ctx, k8sapi.Pod(pod), supportedKinds)
@@ -169,8 +186,12 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 			case errors.As(err, &uwkError):
 				dlog.Debugf(ctx, "Workload owner with %s found for pod %s.%s", uwkError.Error(), pod.Name, pod.Namespace)
 			}
-			// Not an error. It just means that the pod is not eligible for intercepts.
-			return nil, nil
+			// Not an error. It just means that the pod is not eligible for intercepts, unless it is
+			// a bare pod that has been intercepted explicitly.
+			if scx, err = a.agentConfigs.Get(ctx, pod.Name, pod.Namespace); err != nil || !isBarePodConfig(scx) {
+				return nil, nil
+			}
+			wl = k8sworkload.Pod(pod)
 		}
 		scx, err = a.agentConfigs.Get(ctx, wl.GetName(), wl.GetNamespace())
 		switch {
@@ -198,13 +219,25 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 
 	var patches PatchOps
 	config := scx.AgentConfig()
+	if err = agentconfig.CheckPortRedirection(config); err != nil {
+		// The config was stored before such configs were rejected. Injecting it would leave the pod with an
+		// intercept that receives no traffic.
+		return nil, err
+	}
+	if env.AgentImagePinDigest {
+		// The pinned image is only used in the pod. The agent config retains the tag.
+		pc := *config
+		pc.AgentImage = managerutil.PinAgentImage(ctx, config.AgentImage, podArchitecture(pod))
+		config = &pc
+	}
 	patches = disableAppContainer(ctx, pod, config, patches)
 	patches = addInitContainer(pod, config, patches)
 	patches = addAgentContainer(ctx, pod, config, patches)
 	patches = addPullSecrets(pod, config, patches)
 	patches = addAgentVolumes(pod, config, patches)
+	patches = addTolerations(pod, config, patches)
 	patches = hidePorts(pod, config, patches)
-	patches = addPodAnnotations(ctx, pod, patches)
+	patches = addPodAnnotations(ctx, pod, config, patches)
 	patches = addPodLabels(ctx, pod, config, patches)
 
 	if config.APIPort != 0 {
@@ -228,17 +261,6 @@ func (a *agentInjector) Uninstall(ctx context.Context) {
 	a.agentConfigs.DeleteMapsAndRolloutAll(ctx)
 }
 
-func needInitContainer(config *agentconfig.Sidecar) bool {
-	for _, cc := range config.Containers {
-		for _, ic := range cc.Intercepts {
-			if ic.Headless || ic.TargetPortNumeric {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 const sleeperImage = "alpine:latest"
 
 var sleeperArgs = []string{"sleep", "infinity"} //nolint:gochecknoglobals // constant
@@ -292,7 +314,7 @@ podContainers:
 }
 
 func addInitContainer(pod *core.Pod, config *agentconfig.Sidecar, patches PatchOps) PatchOps {
-	if !needInitContainer(config) {
+	if !agentconfig.NeedsInitContainer(config) {
 		for i, oc := range pod.Spec.InitContainers {
 			if agentconfig.InitContainerName == oc.Name {
 				return append(patches, PatchOperation{
@@ -317,7 +339,7 @@ func addInitContainer(pod *core.Pod, config *agentconfig.Sidecar, patches PatchO
 	for i := range pis {
 		oc := &pis[i]
 		if ic.Name == oc.Name {
-			if ic.Image == oc.Image &&
+			if sameImage(ic.Image, oc.Image) &&
 				slices.Equal(ic.Args, oc.Args) &&
 				compareVolumeMounts(ic.VolumeMounts, oc.VolumeMounts) &&
 				compareCapabilities(ic.SecurityContext, oc.SecurityContext) {
@@ -345,6 +367,10 @@ func addAgentVolumes(pod *core.Pod, ag *agentconfig.Sidecar, patches PatchOps) P
 		}
 	}
 	avs := agentconfig.AgentVolumes(ag.AgentName, pod)
+	avs = append(avs, ag.PodSecurity.Volumes()...)
+	if ag.Patch != nil {
+		avs = append(avs, ag.Patch.Volumes...)
+	}
 	if len(avs) == 0 {
 		return patches
 	}
@@ -424,14 +450,49 @@ func compareVolumeMounts(a, b []core.VolumeMount) bool {
 }
 
 func containerEqual(a, b *core.Container) bool {
-	// skips contain defaults assigned by Kubernetes that are not zero values
-	return cmp.Equal(a, b,
+	// skips contain defaults assigned by Kubernetes that are not zero values. Images are compared without
+	// digests, because a pinned image of a pod is never pinned in the agent config.
+	return sameImage(a.Image, b.Image) && cmp.Equal(a, b,
 		cmp.Comparer(compareProbes),
 		cmp.Comparer(compareVolumeMounts),
-		cmpopts.IgnoreFields(core.Container{}, "ImagePullPolicy", "Resources", "TerminationMessagePath", "TerminationMessagePolicy"))
+		cmpopts.IgnoreFields(core.Container{}, "Image", "ImagePullPolicy", "Resources", "RestartPolicy", "TerminationMessagePath",
+			"TerminationMessagePolicy"))
 }
 
-// addAgentContainer creates a patch operation to add the traffic-agent container.
+func sameImage(a, b string) bool {
+	return imageref.WithoutDigest(a) == imageref.WithoutDigest(b)
+}
+
+// podArchitecture returns the architecture that the pod selects using a kubernetes.io/arch node selector or
+// required node affinity, or an empty string when the pod can run on nodes of any architecture.
+func podArchitecture(pod *core.Pod) string {
+	if arch := pod.Spec.NodeSelector[core.LabelArchStable]; arch != "" {
+		return arch
+	}
+	af := pod.Spec.Affinity
+	if af == nil || af.NodeAffinity == nil || af.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	// The terms are ORed, so they must all select the same single architecture.
+	arch := ""
+	for _, term := range af.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		termArch := ""
+		for _, me := range term.MatchExpressions {
+			if me.Key == core.LabelArchStable && me.Operator == core.NodeSelectorOpIn && len(me.Values) == 1 {
+				termArch = me.Values[0]
+			}
+		}
+		if termArch == "" || arch != "" && arch != termArch {
+			return ""
+		}
+		arch = termArch
+	}
+	return arch
+}
+
+// addAgentContainer creates a patch operation to add the traffic-agent container. The container is added as a
+// native sidecar, directly after the mesh proxy, when mesh awareness is enabled and the pod's mesh proxy is a
+// native sidecar. The traffic-agent will then start after, and terminate before, the proxy.
 func addAgentContainer(
 	ctx context.Context,
 	pod *core.Pod,
@@ -442,11 +503,45 @@ func addAgentContainer(
 	if acn == nil {
 		return patches
 	}
+	native := managerutil.GetEnv(ctx).AgentMeshAware && nativeMeshSidecar(pod)
+	if native {
+		acn.RestartPolicy = ptr.To(core.ContainerRestartPolicyAlways)
+	}
 
 	refPodName := pod.Name + "." + pod.Namespace
+	for i := range pod.Spec.InitContainers {
+		pcn := &pod.Spec.InitContainers[i]
+		if pcn.Name == agentconfig.ContainerName {
+			if containerEqual(pcn, acn) {
+				dlog.Infof(ctx, "Pod %s already has native sidecar %s and it isn't modified", refPodName, agentconfig.ContainerName)
+				return patches
+			}
+			dlog.Debugf(ctx, "Pod %s already has native sidecar %s but it is modified", refPodName, agentconfig.ContainerName)
+			acn.RestartPolicy = ptr.To(core.ContainerRestartPolicyAlways)
+			return append(patches, PatchOperation{
+				Op:    "replace",
+				Path:  "/spec/initContainers/" + strconv.Itoa(i),
+				Value: acn,
+			})
+		}
+	}
+
+	last := len(pod.Spec.Containers) - 1
 	for i := range pod.Spec.Containers {
 		pcn := &pod.Spec.Containers[i]
 		if pcn.Name == agentconfig.ContainerName {
+			// The container is moved to the init containers when the mesh became native after it was injected,
+			// which happens when this webhook is reinvoked after the mesh injector. It's only moved when it's the
+			// last container, because the removal would otherwise invalidate the paths of other patches.
+			if native && i == last {
+				dlog.Debugf(ctx, "Pod %s has a native mesh sidecar, moving %s to the init containers", refPodName, agentconfig.ContainerName)
+				patches = append(patches, PatchOperation{
+					Op:   "remove",
+					Path: "/spec/containers/" + strconv.Itoa(i),
+				})
+				return append(patches, addNativeSidecar(pod, acn))
+			}
+			acn.RestartPolicy = nil
 			if containerEqual(pcn, acn) {
 				dlog.Infof(ctx, "Pod %s already has container %s and it isn't modified", refPodName, agentconfig.ContainerName)
 				return patches
@@ -460,6 +555,9 @@ func addAgentContainer(
 		}
 	}
 
+	if native {
+		return append(patches, addNativeSidecar(pod, acn))
+	}
 	return append(patches, PatchOperation{
 		Op:    "add",
 		Path:  "/spec/containers/-",
@@ -467,6 +565,27 @@ func addAgentContainer(
 	})
 }
 
+// addNativeSidecar creates a patch operation that adds the given container to the init containers, directly after
+// the mesh proxy.
+func addNativeSidecar(pod *core.Pod, acn *core.Container) PatchOperation {
+	pos := len(pod.Spec.InitContainers)
+	for i := range pod.Spec.InitContainers {
+		if n := pod.Spec.InitContainers[i].Name; n == istioProxyName || n == linkerdProxyName {
+			pos = i + 1
+			break
+		}
+	}
+	path := "/spec/initContainers/-"
+	if pos < len(pod.Spec.InitContainers) {
+		path = "/spec/initContainers/" + strconv.Itoa(pos)
+	}
+	return PatchOperation{
+		Op:    "add",
+		Path:  path,
+		Value: acn,
+	}
+}
+
 // addAgentContainer creates a patch operation to add the traffic-agent container.
 func addPullSecrets(
 	pod *core.Pod,
@@ -502,6 +621,41 @@ func addPullSecrets(
 	return patches
 }
 
+// addTolerations adds the tolerations of the sidecar patch that the pod doesn't already have.
+func addTolerations(
+	pod *core.Pod,
+	config *agentconfig.Sidecar,
+	patches PatchOps,
+) PatchOps {
+	if config.Patch == nil || len(config.Patch.Tolerations) == 0 {
+		return patches
+	}
+	if len(pod.Spec.Tolerations) == 0 {
+		return append(patches, PatchOperation{
+			Op:    "replace",
+			Path:  "/spec/tolerations",
+			Value: config.Patch.Tolerations,
+		})
+	}
+	for _, nt := range config.Patch.Tolerations {
+		found := false
+		for i := range pod.Spec.Tolerations {
+			if nt.MatchToleration(&pod.Spec.Tolerations[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			patches = append(patches, PatchOperation{
+				Op:    "add",
+				Path:  "/spec/tolerations/-",
+				Value: nt,
+			})
+		}
+	}
+	return patches
+}
+
 // addTPEnv adds telepresence specific environment variables to all interceptable app containers.
 func addTPEnv(pod *core.Pod, config *agentconfig.Sidecar, env map[string]string, patches PatchOps) PatchOps {
 	agentconfig.EachContainer(pod, config, func(app *core.Container, cc *agentconfig.Container) {
@@ -622,7 +776,7 @@ func hideContainerPorts(pod *core.Pod, app *core.Container, isReplace bool, port
 	return patches
 }
 
-func addPodAnnotations(_ context.Context, pod *core.Pod, patches PatchOps) PatchOps {
+func addPodAnnotations(ctx context.Context, pod *core.Pod, config *agentconfig.Sidecar, patches PatchOps) PatchOps {
 	op := "replace"
 	changed := false
 	am := pod.Annotations
@@ -638,6 +792,15 @@ func addPodAnnotations(_ context.Context, pod *core.Pod, patches PatchOps) Patch
 		am[agentconfig.InjectAnnotation] = "enabled"
 	}
 
+	if managerutil.GetEnv(ctx).AgentMeshAware {
+		if mesh := detectMesh(ctx, pod); mesh != meshNone {
+			if addMeshAnnotations(ctx, mesh, config, am) {
+				dlog.Debugf(ctx, "Adding %s annotations to pod %s.%s", mesh, pod.Name, pod.Namespace)
+				changed = true
+			}
+		}
+	}
+
 	if changed {
 		patches = append(patches, PatchOperation{
 			Op:    op,
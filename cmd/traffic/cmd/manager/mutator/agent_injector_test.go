@@ -301,6 +301,41 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		},
 	}
 
+	podNoService := core.Pod{
+		ObjectMeta: podObjectMeta("no-service", "app"),
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "some-container",
+					Ports: []core.ContainerPort{
+						{
+							Name: "http", ContainerPort: 8080,
+						},
+						{
+							ContainerPort: 9000, Protocol: core.ProtocolUDP,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	podHeadless := core.Pod{
+		ObjectMeta: podObjectMeta("headless", "app"),
+		Spec: core.PodSpec{
+			Containers: []core.Container{
+				{
+					Name: "some-container",
+					Ports: []core.ContainerPort{
+						{
+							Name: "http", ContainerPort: 8080,
+						},
+					},
+				},
+			},
+		},
+	}
+
 	deployment := func(pod *core.Pod) *apps.Deployment {
 		name := wlName(pod.Name)
 		return &apps.Deployment{
@@ -334,6 +369,7 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 	grpcPortUID := makeUID()
 	unnamedNumericPortUID := makeUID()
 	multiPortUID := makeUID()
+	headlessUID := makeUID()
 
 	clientset := fake.NewClientset(
 		&core.Service{
@@ -456,6 +492,29 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 				},
 			},
 		},
+		&core.Service{
+			TypeMeta: meta.TypeMeta{
+				Kind:       "Service",
+				APIVersion: "v1",
+			},
+			ObjectMeta: meta.ObjectMeta{
+				Name:      "headless",
+				Namespace: "some-ns",
+				UID:       headlessUID,
+			},
+			Spec: core.ServiceSpec{
+				ClusterIP: core.ClusterIPNone,
+				Ports: []core.ServicePort{{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       80,
+					TargetPort: intstr.FromString("http"),
+				}},
+				Selector: map[string]string{
+					"app": "headless",
+				},
+			},
+		},
 		&podNamedPort,
 		&podNumericPort,
 		&podGRPCPort,
@@ -469,6 +528,8 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		deployment(&podNamedAndNumericPort),
 		deployment(&podMultiPort),
 		deployment(&podMultiSplitPort),
+		deployment(&podNoService),
+		deployment(&podHeadless),
 	)
 	type testInput struct {
 		name           string
@@ -609,6 +670,76 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"No service",
+			&podNoService,
+			&agentconfig.Sidecar{
+				AgentName:    "no-service",
+				AgentImage:   "ghcr.io/telepresenceio/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "no-service",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8080,
+							},
+							{
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolUDP,
+								AgentPort:         9901,
+								ContainerPort:     9000,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Headless service",
+			&podHeadless,
+			&agentconfig.Sidecar{
+				AgentName:    "headless",
+				AgentImage:   "ghcr.io/telepresenceio/tel2:2.13.3",
+				Namespace:    "some-ns",
+				WorkloadName: "headless",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "headless",
+								ServiceUID:        headlessUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Headless:          true,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8080,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
+		},
 		{
 			"Named and numeric port containers",
 			&podNamedAndNumericPort,
@@ -1086,6 +1217,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: ghcr.io/telepresenceio/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1178,6 +1319,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: ghcr.io/telepresenceio/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1319,6 +1470,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: ghcr.io/telepresenceio/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1427,6 +1588,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: ghcr.io/telepresenceio/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1535,6 +1706,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     image: ghcr.io/telepresenceio/tel2:2.13.3
     name: traffic-agent
     ports:
@@ -1640,6 +1821,24 @@ func TestTrafficAgentInjector(t *testing.T) {
 										},
 									},
 								},
+								{
+									Name: "_TEL_AGENT_NAMESPACE",
+									ValueFrom: &core.EnvVarSource{
+										FieldRef: &core.ObjectFieldSelector{
+											APIVersion: "v1",
+											FieldPath:  "metadata.namespace",
+										},
+									},
+								},
+								{
+									Name: "_TEL_AGENT_NODE_NAME",
+									ValueFrom: &core.EnvVarSource{
+										FieldRef: &core.ObjectFieldSelector{
+											APIVersion: "v1",
+											FieldPath:  "spec.nodeName",
+										},
+									},
+								},
 							},
 							Resources:                core.ResourceRequirements{},
 							TerminationMessagePath:   "/dev/termination-log",
@@ -1748,6 +1947,16 @@ func TestTrafficAgentInjector(t *testing.T) {
         fieldRef:
           apiVersion: v1
           fieldPath: metadata.name
+    - name: _TEL_AGENT_NAMESPACE
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: metadata.namespace
+    - name: _TEL_AGENT_NODE_NAME
+      valueFrom:
+        fieldRef:
+          apiVersion: v1
+          fieldPath: spec.nodeName
     - name: A_TELEPRESENCE_MOUNTS
       value: /var/run/secrets/kubernetes.io/serviceaccount
     image: ghcr.io/telepresenceio/tel2:2.13.3
@@ -1948,3 +2157,51 @@ func generateForPod(t *testing.T, ctx context.Context, pod *core.Pod, gc agentma
 	}
 	return gc.Generate(ctx, wl, nil)
 }
+
+func TestPodArchitecture(t *testing.T) {
+	archAffinity := func(terms ...[]string) *core.Affinity {
+		nsts := make([]core.NodeSelectorTerm, len(terms))
+		for i, values := range terms {
+			nsts[i].MatchExpressions = []core.NodeSelectorRequirement{{
+				Key:      core.LabelArchStable,
+				Operator: core.NodeSelectorOpIn,
+				Values:   values,
+			}}
+		}
+		return &core.Affinity{NodeAffinity: &core.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &core.NodeSelector{NodeSelectorTerms: nsts},
+		}}
+	}
+	tests := []struct {
+		name string
+		spec core.PodSpec
+		want string
+	}{
+		{"none", core.PodSpec{}, ""},
+		{"node selector", core.PodSpec{NodeSelector: map[string]string{core.LabelArchStable: "arm64"}}, "arm64"},
+		{"affinity", core.PodSpec{Affinity: archAffinity([]string{"arm64"})}, "arm64"},
+		{"affinity multiple values", core.PodSpec{Affinity: archAffinity([]string{"amd64", "arm64"})}, ""},
+		{"affinity same terms", core.PodSpec{Affinity: archAffinity([]string{"amd64"}, []string{"amd64"})}, "amd64"},
+		{"affinity different terms", core.PodSpec{Affinity: archAffinity([]string{"amd64"}, []string{"arm64"})}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, podArchitecture(&core.Pod{Spec: tt.spec}))
+		})
+	}
+}
+
+func TestAddTolerations(t *testing.T) {
+	existing := core.Toleration{Key: "dedicated", Operator: core.TolerationOpExists}
+	added := core.Toleration{Key: "spot", Operator: core.TolerationOpEqual, Value: "true", Effect: core.TaintEffectNoSchedule}
+	config := &agentconfig.Sidecar{Patch: &agentconfig.SidecarPatch{Tolerations: []core.Toleration{existing, added}}}
+
+	assert.Empty(t, addTolerations(&core.Pod{}, &agentconfig.Sidecar{}, nil))
+
+	patches := addTolerations(&core.Pod{}, config, nil)
+	assert.Equal(t, PatchOps{{Op: "replace", Path: "/spec/tolerations", Value: config.Patch.Tolerations}}, patches)
+
+	pod := &core.Pod{Spec: core.PodSpec{Tolerations: []core.Toleration{existing}}}
+	patches = addTolerations(pod, config, nil)
+	assert.Equal(t, PatchOps{{Op: "add", Path: "/spec/tolerations/-", Value: added}}, patches)
+}
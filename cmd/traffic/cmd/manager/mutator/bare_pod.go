@@ -0,0 +1,125 @@
+package mutator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+// isBarePodConfig returns true if the given config was generated for a bare pod.
+func isBarePodConfig(scx agentconfig.SidecarExt) bool {
+	return scx != nil && scx.AgentConfig().WorkloadKind == k8sworkload.PodKind
+}
+
+// hasAgentContainer returns true if the given pod spec has a traffic-agent container.
+func hasAgentContainer(spec *core.PodSpec) bool {
+	return slices.ContainsFunc(spec.Containers, func(c core.Container) bool { return c.Name == agentconfig.ContainerName })
+}
+
+// recreateBarePod deletes the given pod and creates it again, so that the agent injector gets a chance to inject
+// the traffic-agent. The spec of a running pod cannot be changed, so this is the only way to add an agent to a
+// pod that isn't controlled by a workload. The agent of a bare pod is never removed. It remains until the pod is
+// deleted.
+func recreateBarePod(ctx context.Context, pod *core.Pod, ac *agentconfig.Sidecar, span trace.Span) {
+	if ac == nil {
+		if hasAgentContainer(&pod.Spec) {
+			dlog.Infof(ctx, "The traffic-agent of pod %s.%s remains until the pod is deleted", pod.Name, pod.Namespace)
+		}
+		return
+	}
+	if pod.DeletionTimestamp != nil || hasAgentContainer(&pod.Spec) {
+		return
+	}
+
+	np := pod.DeepCopy()
+	np.ObjectMeta = meta.ObjectMeta{
+		Name:            pod.Name,
+		Namespace:       pod.Namespace,
+		Labels:          pod.Labels,
+		Annotations:     pod.Annotations,
+		OwnerReferences: pod.OwnerReferences,
+		Finalizers:      pod.Finalizers,
+	}
+	// Let the scheduler find a node that has room for the pod, now that it also has an agent.
+	np.Spec.NodeName = ""
+	np.Status = core.PodStatus{}
+
+	span.AddEvent("tel2.do-recreate")
+	dlog.Debugf(ctx, "Recreating pod %s.%s to inject a traffic-agent", pod.Name, pod.Namespace)
+	pods := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(pod.Namespace)
+	fail := func(err error) {
+		dlog.Error(ctx, err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if err := pods.Delete(ctx, pod.Name, meta.DeleteOptions{Preconditions: meta.NewUIDPreconditions(string(pod.UID))}); err != nil {
+		fail(fmt.Errorf("unable to delete pod %s.%s: %w", pod.Name, pod.Namespace, err))
+		return
+	}
+	gone := false
+	for retry := 0; retry < 200; retry++ {
+		if _, err := pods.Get(ctx, pod.Name, meta.GetOptions{}); errors.IsNotFound(err) {
+			gone = true
+			break
+		}
+		dtime.SleepWithContext(ctx, 300*time.Millisecond)
+	}
+	if !gone {
+		fail(fmt.Errorf("pod %s.%s was never deleted", pod.Name, pod.Namespace))
+		return
+	}
+	if _, err := pods.Create(ctx, np, meta.CreateOptions{}); err != nil {
+		fail(fmt.Errorf("unable to recreate pod %s.%s: %w", pod.Name, pod.Namespace, err))
+		return
+	}
+	dlog.Infof(ctx, "Successfully recreated pod %s.%s", pod.Name, pod.Namespace)
+}
+
+// watchBarePods removes the config of a bare pod when the pod is deleted.
+func (c *configWatcher) watchBarePods(ctx context.Context, ix cache.SharedIndexInformer) error {
+	_, err := ix.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj any) {
+			if dfsu, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = dfsu.Obj
+			}
+			if pod, ok := obj.(*core.Pod); ok {
+				c.deleteBarePodConfig(ctx, pod)
+			}
+		},
+	})
+	return err
+}
+
+func (c *configWatcher) deleteBarePodConfig(ctx context.Context, pod *core.Pod) {
+	scx, err := c.Get(ctx, pod.Name, pod.Namespace)
+	if err != nil || !isBarePodConfig(scx) || scx.AgentConfig().Manual {
+		return
+	}
+	// The rollout lock is held while the pod is recreated, and the config must then be retained.
+	lck := c.getRolloutLock(k8sworkload.Pod(pod))
+	if !lck.TryLock() {
+		return
+	}
+	defer lck.Unlock()
+	if _, err = k8sworkload.GetPod(ctx, pod.Name, pod.Namespace); !errors.IsNotFound(err) {
+		// The pod has been recreated, or its existence cannot be determined.
+		return
+	}
+	dlog.Debugf(ctx, "Pod %s.%s was deleted. Removing its agent config", pod.Name, pod.Namespace)
+	if err = c.remove(ctx, pod.Name, pod.Namespace); err != nil {
+		dlog.Errorf(ctx, "unable to remove agent config of pod %s.%s: %v", pod.Name, pod.Namespace, err)
+	}
+}
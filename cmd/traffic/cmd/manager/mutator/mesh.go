@@ -0,0 +1,186 @@
+package mutator
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// meshKind is a service mesh that injects a proxy sidecar into the pods that it manages.
+type meshKind int
+
+const (
+	meshNone meshKind = iota
+	meshIstio
+	meshLinkerd
+)
+
+const (
+	istioProxyName              = "istio-proxy"
+	istioStatusAnnotation       = "sidecar.istio.io/status"
+	istioInjectLabel            = "sidecar.istio.io/inject"
+	istioInjectionLabel         = "istio-injection"
+	istioRevisionLabel          = "istio.io/rev"
+	istioProxyConfigAnnotation  = "proxy.istio.io/config"
+	istioExcludeInboundPorts    = "traffic.sidecar.istio.io/excludeInboundPorts"
+	istioExcludeOutboundPorts   = "traffic.sidecar.istio.io/excludeOutboundPorts"
+	istioHoldApplicationSetting = "holdApplicationUntilProxyStarts"
+
+	linkerdProxyName         = "linkerd-proxy"
+	linkerdInjectAnnotation  = "linkerd.io/inject"
+	linkerdAwaitAnnotation   = "config.linkerd.io/proxy-await"
+	linkerdSkipInboundPorts  = "config.linkerd.io/skip-inbound-ports"
+	linkerdSkipOutboundPorts = "config.linkerd.io/skip-outbound-ports"
+)
+
+func (m meshKind) String() string {
+	switch m {
+	case meshIstio:
+		return "Istio"
+	case meshLinkerd:
+		return "Linkerd"
+	default:
+		return "none"
+	}
+}
+
+// detectMesh returns the service mesh that manages the given pod. The pod is managed by a mesh when it already has
+// the mesh's proxy, or when the pod or its namespace enables the mesh's injection.
+func detectMesh(ctx context.Context, pod *core.Pod) meshKind {
+	for _, cns := range [][]core.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range cns {
+			switch cns[i].Name {
+			case istioProxyName:
+				return meshIstio
+			case linkerdProxyName:
+				return meshLinkerd
+			}
+		}
+	}
+
+	// The sidecar.istio.io/inject label was an annotation in older versions of Istio.
+	istioInject, ok := pod.Labels[istioInjectLabel]
+	if !ok {
+		istioInject = pod.Annotations[istioInjectLabel]
+	}
+	switch {
+	case pod.Annotations[istioStatusAnnotation] != "", istioInject == "true":
+		return meshIstio
+	case pod.Annotations[linkerdInjectAnnotation] == "enabled", pod.Annotations[linkerdInjectAnnotation] == "ingress":
+		return meshLinkerd
+	case istioInject == "false" || pod.Annotations[linkerdInjectAnnotation] == "disabled":
+		return meshNone
+	}
+
+	ns, err := k8sapi.GetK8sInterface(ctx).CoreV1().Namespaces().Get(ctx, pod.Namespace, meta.GetOptions{})
+	if err != nil {
+		dlog.Debugf(ctx, "unable to get namespace %s to detect a service mesh: %v", pod.Namespace, err)
+		return meshNone
+	}
+	if _, ok := ns.Labels[istioRevisionLabel]; ok || ns.Labels[istioInjectionLabel] == "enabled" {
+		return meshIstio
+	}
+	if li := ns.Annotations[linkerdInjectAnnotation]; li == "enabled" || li == "ingress" {
+		return meshLinkerd
+	}
+	return meshNone
+}
+
+// nativeMeshSidecar returns true when the pod's mesh proxy is a native sidecar, i.e. an init container with
+// restartPolicy Always. Native sidecars require Kubernetes 1.29 or later.
+func nativeMeshSidecar(pod *core.Pod) bool {
+	for i := range pod.Spec.InitContainers {
+		ic := &pod.Spec.InitContainers[i]
+		if (ic.Name == istioProxyName || ic.Name == linkerdProxyName) &&
+			ic.RestartPolicy != nil && *ic.RestartPolicy == core.ContainerRestartPolicyAlways {
+			return true
+		}
+	}
+	return false
+}
+
+// addMeshAnnotations adds the annotations that make the given mesh start its proxy before the traffic-agent, and
+// that let the traffic-agent's connections to and from the traffic-manager bypass the proxy. It returns true when
+// the given annotations were modified.
+func addMeshAnnotations(ctx context.Context, mesh meshKind, config *agentconfig.Sidecar, am map[string]string) bool {
+	var inbound []uint16
+	if config.APIPort != 0 {
+		inbound = append(inbound, config.APIPort)
+	}
+	if config.TracingPort != 0 {
+		inbound = append(inbound, config.TracingPort)
+	}
+	outbound := []uint16{config.ManagerPort}
+
+	changed := false
+	switch mesh {
+	case meshIstio:
+		changed = holdApplicationUntilProxyStarts(ctx, am)
+		changed = addPortsAnnotation(am, istioExcludeInboundPorts, inbound) || changed
+		changed = addPortsAnnotation(am, istioExcludeOutboundPorts, outbound) || changed
+	case meshLinkerd:
+		if _, ok := am[linkerdAwaitAnnotation]; !ok {
+			am[linkerdAwaitAnnotation] = "enabled"
+			changed = true
+		}
+		changed = addPortsAnnotation(am, linkerdSkipInboundPorts, inbound) || changed
+		changed = addPortsAnnotation(am, linkerdSkipOutboundPorts, outbound) || changed
+	}
+	return changed
+}
+
+// holdApplicationUntilProxyStarts enables Istio's holdApplicationUntilProxyStarts in the proxy config annotation,
+// unless the annotation already has that setting.
+func holdApplicationUntilProxyStarts(ctx context.Context, am map[string]string) bool {
+	pc := make(map[string]any)
+	if pcs, ok := am[istioProxyConfigAnnotation]; ok {
+		if err := yaml.Unmarshal([]byte(pcs), &pc); err != nil {
+			dlog.Warnf(ctx, "unable to parse the %s annotation: %v", istioProxyConfigAnnotation, err)
+			return false
+		}
+		if _, ok := pc[istioHoldApplicationSetting]; ok {
+			return false
+		}
+	}
+	pc[istioHoldApplicationSetting] = true
+	js, err := yaml.Marshal(pc)
+	if err != nil {
+		dlog.Error(ctx, err)
+		return false
+	}
+	am[istioProxyConfigAnnotation] = string(js)
+	return true
+}
+
+// addPortsAnnotation adds the given ports to the comma separated list of ports in the given annotation. It returns
+// true when the annotation was modified.
+func addPortsAnnotation(am map[string]string, key string, ports []uint16) bool {
+	var ps []string
+	if v := am[key]; v != "" {
+		ps = strings.Split(v, ",")
+		for i := range ps {
+			ps[i] = strings.TrimSpace(ps[i])
+		}
+	}
+	changed := false
+	for _, p := range ports {
+		s := strconv.Itoa(int(p))
+		if !slices.Contains(ps, s) {
+			ps = append(ps, s)
+			changed = true
+		}
+	}
+	if changed {
+		am[key] = strings.Join(ps, ",")
+	}
+	return changed
+}
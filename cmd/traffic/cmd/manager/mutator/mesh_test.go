@@ -0,0 +1,186 @@
+package mutator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func meshTestContext(t *testing.T, namespaces ...*core.Namespace) context.Context {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{AgentMeshAware: true})
+	cs := fake.NewClientset()
+	for _, ns := range namespaces {
+		_, err := cs.CoreV1().Namespaces().Create(ctx, ns, meta.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return k8sapi.WithK8sInterface(ctx, cs)
+}
+
+func TestDetectMesh(t *testing.T) {
+	ctx := meshTestContext(t,
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "istio", Labels: map[string]string{istioInjectionLabel: "enabled"}}},
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "istio-rev", Labels: map[string]string{istioRevisionLabel: "canary"}}},
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "linkerd", Annotations: map[string]string{linkerdInjectAnnotation: "enabled"}}},
+		&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: "plain"}},
+	)
+	tests := []struct {
+		name string
+		pod  core.Pod
+		want meshKind
+	}{
+		{
+			"plain namespace",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "plain"}},
+			meshNone,
+		},
+		{
+			"unknown namespace",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "unknown"}},
+			meshNone,
+		},
+		{
+			"istio namespace",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "istio"}},
+			meshIstio,
+		},
+		{
+			"istio revision namespace",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "istio-rev"}},
+			meshIstio,
+		},
+		{
+			"istio namespace, pod opts out",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "istio", Labels: map[string]string{istioInjectLabel: "false"}}},
+			meshNone,
+		},
+		{
+			"istio pod label",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "plain", Labels: map[string]string{istioInjectLabel: "true"}}},
+			meshIstio,
+		},
+		{
+			"istio proxy container",
+			core.Pod{
+				ObjectMeta: meta.ObjectMeta{Namespace: "plain"},
+				Spec:       core.PodSpec{Containers: []core.Container{{Name: "echo"}, {Name: istioProxyName}}},
+			},
+			meshIstio,
+		},
+		{
+			"linkerd namespace",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "linkerd"}},
+			meshLinkerd,
+		},
+		{
+			"linkerd namespace, pod opts out",
+			core.Pod{ObjectMeta: meta.ObjectMeta{Namespace: "linkerd", Annotations: map[string]string{linkerdInjectAnnotation: "disabled"}}},
+			meshNone,
+		},
+		{
+			"linkerd native proxy",
+			core.Pod{
+				ObjectMeta: meta.ObjectMeta{Namespace: "plain"},
+				Spec:       core.PodSpec{InitContainers: []core.Container{{Name: linkerdProxyName}}},
+			},
+			meshLinkerd,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectMesh(ctx, &tt.pod))
+		})
+	}
+}
+
+func TestAddMeshAnnotations(t *testing.T) {
+	ctx := meshTestContext(t)
+	config := &agentconfig.Sidecar{ManagerPort: 8081, APIPort: 9980, TracingPort: 15766}
+
+	am := map[string]string{}
+	require.True(t, addMeshAnnotations(ctx, meshIstio, config, am))
+	assert.Equal(t, map[string]string{
+		istioProxyConfigAnnotation: "holdApplicationUntilProxyStarts: true\n",
+		istioExcludeInboundPorts:   "9980,15766",
+		istioExcludeOutboundPorts:  "8081",
+	}, am)
+	assert.False(t, addMeshAnnotations(ctx, meshIstio, config, am), "annotations are modified twice")
+
+	// Existing settings are retained.
+	am = map[string]string{
+		istioProxyConfigAnnotation: `{"concurrency": 2}`,
+		istioExcludeInboundPorts:   "9090, 9980",
+	}
+	require.True(t, addMeshAnnotations(ctx, meshIstio, config, am))
+	assert.Equal(t, "concurrency: 2\nholdApplicationUntilProxyStarts: true\n", am[istioProxyConfigAnnotation])
+	assert.Equal(t, "9090,9980,15766", am[istioExcludeInboundPorts])
+
+	am = map[string]string{istioProxyConfigAnnotation: "holdApplicationUntilProxyStarts: false"}
+	addMeshAnnotations(ctx, meshIstio, config, am)
+	assert.Equal(t, "holdApplicationUntilProxyStarts: false", am[istioProxyConfigAnnotation])
+
+	am = map[string]string{linkerdAwaitAnnotation: "disabled"}
+	require.True(t, addMeshAnnotations(ctx, meshLinkerd, config, am))
+	assert.Equal(t, map[string]string{
+		linkerdAwaitAnnotation:   "disabled",
+		linkerdSkipInboundPorts:  "9980,15766",
+		linkerdSkipOutboundPorts: "8081",
+	}, am)
+}
+
+func TestAddAgentContainer_nativeSidecar(t *testing.T) {
+	ctx := meshTestContext(t)
+	config := &agentconfig.Sidecar{
+		AgentImage: "tel2",
+		Containers: []*agentconfig.Container{{
+			Name:       "echo",
+			Intercepts: []*agentconfig.Intercept{{ContainerPortName: "http", AgentPort: 9900, Protocol: core.ProtocolTCP}},
+		}},
+	}
+	proxy := core.Container{Name: istioProxyName, RestartPolicy: ptr.To(core.ContainerRestartPolicyAlways)}
+	pod := &core.Pod{Spec: core.PodSpec{
+		InitContainers: []core.Container{{Name: "istio-validation"}, proxy, {Name: "migrate"}},
+		Containers:     []core.Container{{Name: "echo"}},
+	}}
+
+	patches := addAgentContainer(ctx, pod, config, nil)
+	require.Len(t, patches, 1)
+	assert.Equal(t, "add", patches[0].Op)
+	assert.Equal(t, "/spec/initContainers/2", patches[0].Path)
+	acn := patches[0].Value.(*core.Container)
+	assert.Equal(t, core.ContainerRestartPolicyAlways, *acn.RestartPolicy)
+
+	// A traffic-agent that was injected before the mesh proxy is moved to the init containers.
+	plain := *acn
+	plain.RestartPolicy = nil
+	pod.Spec.InitContainers = []core.Container{proxy}
+	pod.Spec.Containers = append(pod.Spec.Containers, plain)
+	patches = addAgentContainer(ctx, pod, config, nil)
+	require.Len(t, patches, 2)
+	assert.Equal(t, PatchOperation{Op: "remove", Path: "/spec/containers/1"}, patches[0])
+	assert.Equal(t, "/spec/initContainers/-", patches[1].Path)
+
+	// An injected native sidecar isn't modified.
+	pod.Spec.Containers = pod.Spec.Containers[:1]
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, *acn)
+	assert.Empty(t, addAgentContainer(ctx, pod, config, nil))
+
+	// The traffic-agent is a regular container when mesh awareness is disabled.
+	pod.Spec.InitContainers = []core.Container{proxy}
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{})
+	patches = addAgentContainer(ctx, pod, config, nil)
+	require.Len(t, patches, 1)
+	assert.Equal(t, "/spec/containers/-", patches[0].Path)
+	assert.Nil(t, patches[0].Value.(*core.Container).RestartPolicy)
+}
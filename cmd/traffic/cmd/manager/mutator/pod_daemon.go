@@ -0,0 +1,80 @@
+package mutator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// podDaemonContainer returns the pod-daemon sidecar that intercepts the workload declared in the annotations of
+// the given pod.
+func podDaemonContainer(ctx context.Context, pod *core.Pod) (*core.Container, error) {
+	an := pod.Annotations
+	wl := an[agentconfig.PodDaemonWorkloadAnnotation]
+	if wl == "" {
+		return nil, fmt.Errorf("annotation %s is required when %s is enabled", agentconfig.PodDaemonWorkloadAnnotation, agentconfig.InjectPodDaemonAnnotation)
+	}
+	port := an[agentconfig.PodDaemonPortAnnotation]
+	if port == "" {
+		return nil, fmt.Errorf("annotation %s is required when %s is enabled", agentconfig.PodDaemonPortAnnotation, agentconfig.InjectPodDaemonAnnotation)
+	}
+	pn, pi, ok := strings.Cut(port, ":")
+	_, err := agentconfig.ParseNumericPort(pn)
+	if err == nil && ok {
+		err = agentconfig.ValidatePort(pi)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for annotation %s: %w", port, agentconfig.PodDaemonPortAnnotation, err)
+	}
+
+	env := managerutil.GetEnv(ctx)
+	args := []string{
+		"pod-daemon-foreground",
+		"--workload", wl,
+		"--port", port,
+		"--namespace", pod.Namespace,
+		"--manager-namespace", env.ManagerNamespace,
+	}
+	if name := an[agentconfig.PodDaemonNameAnnotation]; name != "" {
+		args = append(args, "--name", name)
+	}
+	if svc := an[agentconfig.PodDaemonServiceAnnotation]; svc != "" {
+		args = append(args, "--service", svc)
+	}
+	return &core.Container{
+		Name:            agentconfig.PodDaemonContainerName,
+		Image:           env.QualifiedPodDaemonImage(),
+		Args:            args,
+		ImagePullPolicy: core.PullPolicy(env.AgentImagePullPolicy),
+	}, nil
+}
+
+// injectPodDaemon returns the patches that add a pod-daemon sidecar to a pod that enables it using the
+// agentconfig.InjectPodDaemonAnnotation. The sidecar makes the pod the handler of an intercept for as long as the
+// pod lives, so that ephemeral pods, such as preview deployments, can handle intercepts without a CLI.
+func injectPodDaemon(ctx context.Context, pod *core.Pod) (PatchOps, error) {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == agentconfig.PodDaemonContainerName {
+			dlog.Debugf(ctx, "Pod %s.%s already has container %s", pod.Name, pod.Namespace, agentconfig.PodDaemonContainerName)
+			return nil, nil
+		}
+	}
+	cn, err := podDaemonContainer(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+	patches := PatchOps{{
+		Op:    "add",
+		Path:  "/spec/containers/-",
+		Value: cn,
+	}}
+	patches = addPullSecrets(pod, &agentconfig.Sidecar{PullSecrets: managerutil.GetEnv(ctx).AgentImagePullSecrets}, patches)
+	dlog.Infof(ctx, "Injecting %s into pod %s.%s", agentconfig.PodDaemonContainerName, pod.Name, pod.Namespace)
+	return patches, nil
+}
@@ -0,0 +1,94 @@
+package mutator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestInjectPodDaemon(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{
+		Registry:              "ghcr.io/telepresenceio",
+		ManagerNamespace:      "ambassador",
+		PodDaemonImage:        "ghcr.io/telepresenceio/telepresence:2.20.0",
+		AgentImagePullSecrets: []core.LocalObjectReference{{Name: "registry-creds"}},
+	})
+
+	pod := func(annotations map[string]string, containers ...string) *core.Pod {
+		p := &core.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "preview-1234",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		}
+		for _, c := range containers {
+			p.Spec.Containers = append(p.Spec.Containers, core.Container{Name: c})
+		}
+		return p
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		patches, err := injectPodDaemon(ctx, pod(map[string]string{
+			agentconfig.InjectPodDaemonAnnotation:   "enabled",
+			agentconfig.PodDaemonWorkloadAnnotation: "echo",
+			agentconfig.PodDaemonPortAnnotation:     "8080:http",
+			agentconfig.PodDaemonNameAnnotation:     "echo-preview",
+		}, "app"))
+		require.NoError(t, err)
+		require.Len(t, patches, 2)
+		assert.Equal(t, "/spec/containers/-", patches[0].Path)
+		cn := patches[0].Value.(*core.Container)
+		assert.Equal(t, agentconfig.PodDaemonContainerName, cn.Name)
+		assert.Equal(t, "ghcr.io/telepresenceio/telepresence:2.20.0", cn.Image)
+		assert.Equal(t, []string{
+			"pod-daemon-foreground",
+			"--workload", "echo",
+			"--port", "8080:http",
+			"--namespace", "default",
+			"--manager-namespace", "ambassador",
+			"--name", "echo-preview",
+		}, cn.Args)
+		assert.Equal(t, "/spec/imagePullSecrets", patches[1].Path)
+	})
+
+	t.Run("already injected", func(t *testing.T) {
+		patches, err := injectPodDaemon(ctx, pod(map[string]string{
+			agentconfig.InjectPodDaemonAnnotation:   "enabled",
+			agentconfig.PodDaemonWorkloadAnnotation: "echo",
+			agentconfig.PodDaemonPortAnnotation:     "8080",
+		}, "app", agentconfig.PodDaemonContainerName))
+		require.NoError(t, err)
+		assert.Empty(t, patches)
+	})
+
+	t.Run("missing workload", func(t *testing.T) {
+		_, err := injectPodDaemon(ctx, pod(map[string]string{
+			agentconfig.InjectPodDaemonAnnotation: "enabled",
+			agentconfig.PodDaemonPortAnnotation:   "8080",
+		}, "app"))
+		require.ErrorContains(t, err, agentconfig.PodDaemonWorkloadAnnotation)
+	})
+
+	t.Run("bad port", func(t *testing.T) {
+		_, err := injectPodDaemon(ctx, pod(map[string]string{
+			agentconfig.InjectPodDaemonAnnotation:   "enabled",
+			agentconfig.PodDaemonWorkloadAnnotation: "echo",
+			agentconfig.PodDaemonPortAnnotation:     "http",
+		}, "app"))
+		require.ErrorContains(t, err, agentconfig.PodDaemonPortAnnotation)
+	})
+
+	t.Run("default image", func(t *testing.T) {
+		env := managerutil.Env{Registry: "example.com/tel"}
+		assert.Regexp(t, `^example\.com/tel/telepresence:\d`, env.QualifiedPodDaemonImage())
+	})
+}
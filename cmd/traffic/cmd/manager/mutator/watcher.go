@@ -32,6 +32,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
 )
@@ -55,6 +56,7 @@ type Map interface {
 	RegenerateAgentMaps(ctx context.Context, s string) error
 
 	Delete(ctx context.Context, name, namespace string) error
+	OrphanedEntries(ctx context.Context) (map[string][]string, error)
 	Update(ctx context.Context, namespace string, updater func(cm *core.ConfigMap) (bool, error)) error
 }
 
@@ -198,12 +200,12 @@ func isRolloutNeededForPod(ctx context.Context, ac *agentconfig.Sidecar, name, n
 	}
 	podIc := agentmap.InitContainer(pod)
 	if podIc == nil {
-		if needInitContainer(ac) {
+		if agentconfig.NeedsInitContainer(ac) {
 			return fmt.Sprintf("Rollout of %s.%s is necessary. An init-container is desired but the pod %s doesn't have one",
 				name, namespace, pod.GetName())
 		}
 	} else {
-		if !needInitContainer(ac) {
+		if !agentconfig.NeedsInitContainer(ac) {
 			return fmt.Sprintf("Rollout of %s.%s is necessary. No init-container is desired but the pod %s has one",
 				name, namespace, pod.GetName())
 		}
@@ -246,6 +248,16 @@ func (c *configWatcher) triggerRollout(ctx context.Context, wl k8sapi.Workload,
 	}
 	defer lck.Unlock()
 
+	if pod, ok := k8sworkload.PodImpl(wl); ok {
+		if !c.rolloutDisabled {
+			ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "mutator.triggerRollout")
+			defer span.End()
+			tracing.RecordWorkloadInfo(span, wl)
+			recreateBarePod(ctx, pod, ac, span)
+		}
+		return
+	}
+
 	if !c.isRolloutNeeded(ctx, wl, ac) {
 		return
 	}
@@ -443,9 +455,11 @@ type configWatcher struct {
 
 	cms []cache.SharedIndexInformer
 	svs []cache.SharedIndexInformer
+	pds []cache.SharedIndexInformer
 	dps []cache.SharedIndexInformer
 	rss []cache.SharedIndexInformer
 	sss []cache.SharedIndexInformer
+	dss []cache.SharedIndexInformer
 	rls []cache.SharedIndexInformer
 
 	self Map // For extension
@@ -476,6 +490,35 @@ func (c *configWatcher) Delete(ctx context.Context, name, namespace string) erro
 	return c.remove(ctx, name, namespace)
 }
 
+// OrphanedEntries returns the names of the entries in the telepresence-agents ConfigMaps whose workloads no
+// longer exist, keyed by namespace. Manually added entries are never considered orphaned.
+func (c *configWatcher) OrphanedEntries(ctx context.Context) (map[string][]string, error) {
+	nss := managerutil.GetEnv(ctx).ManagedNamespaces
+	if len(nss) == 0 {
+		nss = []string{""}
+	}
+	orphans := make(map[string][]string)
+	for _, ns := range nss {
+		cml, err := tpAgentsInformer(ctx, ns).Lister().List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, cm := range cml {
+			for n, yml := range cm.Data {
+				scx, err := agentconfig.UnmarshalYAML([]byte(yml))
+				if err != nil || scx.AgentConfig().Manual {
+					continue
+				}
+				ac := scx.AgentConfig()
+				if _, err = agentmap.GetWorkload(ctx, ac.WorkloadName, cm.Namespace, ac.WorkloadKind); errors.IsNotFound(err) {
+					orphans[cm.Namespace] = append(orphans[cm.Namespace], n)
+				}
+			}
+		}
+	}
+	return orphans, nil
+}
+
 func (c *configWatcher) Update(ctx context.Context, namespace string, updater func(cm *core.ConfigMap) (bool, error)) error {
 	api := k8sapi.GetK8sInterface(ctx).CoreV1().ConfigMaps(namespace)
 	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
@@ -560,6 +603,11 @@ func (c *configWatcher) StartWatchers(ctx context.Context) error {
 			return err
 		}
 	}
+	for _, si := range c.pds {
+		if err := c.watchBarePods(ctx, si); err != nil {
+			return err
+		}
+	}
 	if c.dps != nil {
 		for _, si := range c.dps {
 			if err := c.watchWorkloads(ctx, si); err != nil {
@@ -581,6 +629,13 @@ func (c *configWatcher) StartWatchers(ctx context.Context) error {
 			}
 		}
 	}
+	if c.dss != nil {
+		for _, si := range c.dss {
+			if err := c.watchWorkloads(ctx, si); err != nil {
+				return err
+			}
+		}
+	}
 	if c.rls != nil {
 		for _, si := range c.rls {
 			if err := c.watchWorkloads(ctx, si); err != nil {
@@ -840,6 +895,7 @@ func (c *configWatcher) Start(ctx context.Context) {
 
 	c.svs = make([]cache.SharedIndexInformer, len(nss))
 	c.cms = make([]cache.SharedIndexInformer, len(nss))
+	c.pds = make([]cache.SharedIndexInformer, len(nss))
 	for _, wlKind := range env.EnabledWorkloadKinds {
 		switch wlKind {
 		case workload.DeploymentWorkloadKind:
@@ -848,6 +904,8 @@ func (c *configWatcher) Start(ctx context.Context) {
 			c.rss = make([]cache.SharedIndexInformer, len(nss))
 		case workload.StatefulSetWorkloadKind:
 			c.sss = make([]cache.SharedIndexInformer, len(nss))
+		case workload.DaemonSetWorkloadKind:
+			c.dss = make([]cache.SharedIndexInformer, len(nss))
 		case workload.RolloutWorkloadKind:
 			c.rls = make([]cache.SharedIndexInformer, len(nss))
 		}
@@ -864,7 +922,10 @@ func (c *configWatcher) Start(ctx context.Context) {
 		if c.sss != nil {
 			c.sss[i] = workload.StartStatefulSets(ctx, ns)
 		}
-		c.startPods(ctx, ns)
+		if c.dss != nil {
+			c.dss[i] = workload.StartDaemonSets(ctx, ns)
+		}
+		c.pds[i] = c.startPods(ctx, ns)
 		kf := informer.GetK8sFactory(ctx, ns)
 		kf.Start(ctx.Done())
 		kf.WaitForCacheSync(ctx.Done())
@@ -0,0 +1,337 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	core "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+const (
+	// publishedByLabel is the label of a published Service that holds the ID of the session that published it.
+	publishedByLabel = "telepresence.io/published-by"
+
+	// publishedServiceDeleteTimeout is how long the traffic-manager waits for a published Service to be deleted.
+	publishedServiceDeleteTimeout = 10 * time.Second
+)
+
+// publishedService is a Service whose connections are forwarded to ports on the workstation of a client. The
+// traffic-manager accepts the connections on one listener per port, and the Service's EndpointSlice points to
+// the listeners.
+type publishedService struct {
+	*rpc.PublishedServiceInfo
+	sessionID string
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// publishedServices are the Services that clients have published, keyed by "<name>.<namespace>". An entry
+// remains after its session has ended, until it's replaced, but it's then done.
+type publishedServices struct {
+	sync.Mutex
+	services map[string]*publishedService
+}
+
+func (s *service) PublishService(ctx context.Context, request *rpc.PublishServiceRequest) (*rpc.PublishedServiceInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "PublishService called: %s.%s, user DNS %t", request.Name, request.Namespace, request.UserDns)
+
+	env := managerutil.GetEnv(ctx)
+	if !env.PublishedServicesEnabled {
+		return nil, status.Error(codes.Unavailable, "publishing of services is not enabled in the traffic-manager")
+	}
+	sessionID := request.GetSession().GetSessionId()
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	sessionDone, err := s.state.SessionDone(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	name, ns := publishedName(ctx, request, client)
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid service name %q: %s", name, strings.Join(errs, ", "))
+	}
+	if len(request.Ports) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "a published service must have at least one port")
+	}
+	for _, p := range request.Ports {
+		if p.Port <= 0 || p.Port > 0xffff || p.TargetPort <= 0 || p.TargetPort > 0xffff {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid port %d:%d", p.Port, p.TargetPort)
+		}
+	}
+	if !managesNamespace(env, ns) {
+		return nil, status.Errorf(codes.PermissionDenied, "namespace %s is not managed by this traffic-manager", ns)
+	}
+	user, err := authorizePublish(ctx, request.BearerToken, ns)
+	if err != nil {
+		return nil, err
+	}
+	key := name + "." + ns
+	ps := &s.published
+	ps.Lock()
+	defer ps.Unlock()
+	if old, ok := ps.services[key]; ok {
+		if old.sessionID != sessionID && !old.isDone() {
+			return nil, status.Errorf(codes.AlreadyExists, "service %s is published by another client", key)
+		}
+		// Publishing again replaces the ports.
+		old.cancel()
+		<-old.done
+		delete(ps.services, key)
+	}
+
+	if svc, err := k8sapi.GetK8sInterface(ctx).CoreV1().Services(ns).Get(ctx, name, meta.GetOptions{}); err == nil {
+		if svc.Labels[publishedByLabel] == "" {
+			return nil, status.Errorf(codes.AlreadyExists, "service %s already exists", key)
+		}
+		// A leftover from a session that is gone.
+		s.deletePublishedService(ctx, &publishedService{PublishedServiceInfo: &rpc.PublishedServiceInfo{Name: name, Namespace: ns}})
+	}
+
+	pub := &publishedService{
+		PublishedServiceInfo: &rpc.PublishedServiceInfo{Name: name, Namespace: ns, Ports: request.Ports},
+		sessionID:            sessionID,
+		done:                 make(chan struct{}),
+	}
+	// The listeners must survive this call, so they are controlled by the context of the service.
+	pctx, cancel := context.WithCancel(s.ctx)
+	pub.cancel = cancel
+	lps, err := s.listenPublished(pctx, pub)
+	if err == nil {
+		err = s.createPublishedService(ctx, pub, lps)
+	}
+	if err != nil {
+		cancel()
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to publish service %s: %v", key, err)
+	}
+	go func() {
+		defer close(pub.done)
+		select {
+		case <-pctx.Done():
+		case <-sessionDone:
+			cancel()
+		}
+		s.deletePublishedService(pctx, pub)
+	}()
+	if ps.services == nil {
+		ps.services = make(map[string]*publishedService)
+	}
+	ps.services[key] = pub
+	dlog.Infof(ctx, "Service %s published by %s in session %s", key, user, sessionID)
+	return pub.PublishedServiceInfo, nil
+}
+
+func (s *service) UnpublishService(ctx context.Context, request *rpc.PublishServiceRequest) (*emptypb.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "UnpublishService called: %s.%s, user DNS %t", request.Name, request.Namespace, request.UserDns)
+
+	sessionID := request.GetSession().GetSessionId()
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	name, ns := publishedName(ctx, request, client)
+	key := name + "." + ns
+	ps := &s.published
+	ps.Lock()
+	defer ps.Unlock()
+	pub, ok := ps.services[key]
+	if !ok || pub.sessionID != sessionID || pub.isDone() {
+		return nil, status.Errorf(codes.NotFound, "service %s isn't published by this client", key)
+	}
+	delete(ps.services, key)
+	pub.cancel()
+	<-pub.done
+	return &emptypb.Empty{}, nil
+}
+
+// publishedName returns the name and namespace of the Service that the given request publishes.
+func publishedName(ctx context.Context, request *rpc.PublishServiceRequest, client *rpc.ClientInfo) (name, ns string) {
+	if request.UserDns {
+		return userServiceName(client.Name), managerutil.GetEnv(ctx).ManagerNamespace
+	}
+	ns = request.Namespace
+	if ns == "" {
+		ns = client.Namespace
+	}
+	return request.Name, ns
+}
+
+// managesNamespace returns true if the traffic-manager manages the given namespace. A traffic-manager without managed
+// namespaces manages all of them.
+func managesNamespace(env *managerutil.Env, ns string) bool {
+	return len(env.ManagedNamespaces) == 0 || ns == env.ManagerNamespace || slices.Contains(env.ManagedNamespaces, ns)
+}
+
+// userServiceName returns a DNS label that is derived from the user of a client name on the form "user@host".
+func userServiceName(clientName string) string {
+	user, _, _ := strings.Cut(clientName, "@")
+	var sb strings.Builder
+	for _, r := range strings.ToLower(user) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if name == "" || name[0] < 'a' {
+		name = "user-" + name
+	}
+	if len(name) > validation.DNS1035LabelMaxLength {
+		name = name[:validation.DNS1035LabelMaxLength]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// isDone returns true when the published service has been deleted, e.g. because its session ended.
+func (pub *publishedService) isDone() bool {
+	select {
+	case <-pub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// listenPublished starts one listener for each port of the given published service, and returns the ports that
+// the listeners use. The listeners are closed when the given context is cancelled.
+func (s *service) listenPublished(ctx context.Context, pub *publishedService) ([]int32, error) {
+	env := managerutil.GetEnv(ctx)
+	lps := make([]int32, len(pub.Ports))
+	for i, p := range pub.Ports {
+		lc := net.ListenConfig{}
+		ln, err := lc.Listen(ctx, "tcp", iputil.JoinHostPort(env.ServerHost, 0))
+		if err != nil {
+			return nil, err
+		}
+		context.AfterFunc(ctx, func() {
+			_ = ln.Close()
+		})
+		lps[i] = int32(ln.Addr().(*net.TCPAddr).Port)
+		targetPort := uint16(p.TargetPort)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+						dlog.Errorf(ctx, "published service %s.%s: %v", pub.Name, pub.Namespace, err)
+					}
+					return
+				}
+				go func() {
+					if err := s.state.ProxyPublishedConn(ctx, conn, pub.sessionID, targetPort); err != nil {
+						dlog.Errorf(ctx, "published service %s.%s: %v", pub.Name, pub.Namespace, err)
+					}
+				}()
+			}
+		}()
+	}
+	return lps, nil
+}
+
+// createPublishedService creates a Service without a selector for the given published service, and an
+// EndpointSlice that points to the listeners of the traffic-manager. The EndpointSlice is owned by the Service,
+// so it's deleted with it. The Service is deleted again if the EndpointSlice can't be created. The cluster IP
+// of the Service is assigned to the published service.
+func (s *service) createPublishedService(ctx context.Context, pub *publishedService, listenerPorts []int32) error {
+	env := managerutil.GetEnv(ctx)
+	labels := map[string]string{
+		"app.kubernetes.io/created-by": "traffic-manager",
+		publishedByLabel:               pub.sessionID,
+	}
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{Name: pub.Name, Namespace: pub.Namespace, Labels: labels},
+	}
+	epPorts := make([]discovery.EndpointPort, len(pub.Ports))
+	for i, p := range pub.Ports {
+		name := fmt.Sprintf("port-%d", p.Port)
+		svc.Spec.Ports = append(svc.Spec.Ports, core.ServicePort{
+			Name:       name,
+			Protocol:   core.ProtocolTCP,
+			Port:       p.Port,
+			TargetPort: intstr.FromInt32(listenerPorts[i]),
+		})
+		epPorts[i] = discovery.EndpointPort{Name: &name, Protocol: &svc.Spec.Ports[i].Protocol, Port: &listenerPorts[i]}
+	}
+	api := k8sapi.GetK8sInterface(ctx)
+	svc, err := api.CoreV1().Services(pub.Namespace).Create(ctx, svc, meta.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	pub.ClusterIp = svc.Spec.ClusterIP
+
+	addrType := discovery.AddressTypeIPv4
+	if env.PodIP.Is6() {
+		addrType = discovery.AddressTypeIPv6
+	}
+	ready := true
+	eps := &discovery.EndpointSlice{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      pub.Name,
+			Namespace: pub.Namespace,
+			Labels: map[string]string{
+				discovery.LabelServiceName: pub.Name,
+				discovery.LabelManagedBy:   "traffic-manager.telepresence.io",
+				publishedByLabel:           pub.sessionID,
+			},
+			OwnerReferences: []meta.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Service",
+				Name:       svc.Name,
+				UID:        svc.UID,
+			}},
+		},
+		AddressType: addrType,
+		Endpoints: []discovery.Endpoint{{
+			Addresses:  []string{env.PodIP.String()},
+			Conditions: discovery.EndpointConditions{Ready: &ready},
+		}},
+		Ports: epPorts,
+	}
+	if _, err = api.DiscoveryV1().EndpointSlices(pub.Namespace).Create(ctx, eps, meta.CreateOptions{}); err != nil {
+		s.deletePublishedService(ctx, pub)
+	}
+	return err
+}
+
+// deletePublishedService deletes the Service and the EndpointSlice of the given published service. The
+// EndpointSlice is deleted explicitly, so that the service can be published again before the garbage collector
+// gets to it.
+func (s *service) deletePublishedService(ctx context.Context, pub *publishedService) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishedServiceDeleteTimeout)
+	defer cancel()
+	api := k8sapi.GetK8sInterface(ctx)
+	err := api.DiscoveryV1().EndpointSlices(pub.Namespace).Delete(ctx, pub.Name, meta.DeleteOptions{})
+	if err == nil || k8serrors.IsNotFound(err) {
+		err = api.CoreV1().Services(pub.Namespace).Delete(ctx, pub.Name, meta.DeleteOptions{})
+	}
+	switch {
+	case err == nil:
+		dlog.Infof(ctx, "Published service %s.%s deleted", pub.Name, pub.Namespace)
+	case !k8serrors.IsNotFound(err):
+		dlog.Errorf(ctx, "unable to delete published service %s.%s: %v", pub.Name, pub.Namespace, err)
+	}
+}
@@ -0,0 +1,211 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	authn "k8s.io/api/authentication/v1"
+	authz "k8s.io/api/authorization/v1"
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	fakeargorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned/fake"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+// publishingClientset returns a fake clientset where the token "dev" belongs to a user that may create services in
+// all namespaces except "prod", and the token "guest" to one that may not create services at all.
+func publishingClientset(objects ...runtime.Object) *fake.Clientset {
+	fakeClient := fake.NewSimpleClientset(objects...)
+	fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authn.TokenReview)
+		switch tr.Spec.Token {
+		case "dev", "guest":
+			tr.Status = authn.TokenReviewStatus{Authenticated: true, User: authn.UserInfo{Username: tr.Spec.Token}}
+		}
+		return true, tr, nil
+	})
+	fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authz.SubjectAccessReview)
+		ra := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "dev" && ra.Namespace != "prod" && ra.Verb == "create" && ra.Resource == "services"
+		return true, sar, nil
+	})
+	return fakeClient
+}
+
+func TestPublishService(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	fakeClient := publishingClientset(&core.Service{ObjectMeta: meta.ObjectMeta{Name: "payments", Namespace: "default"}})
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{
+		PublishedServicesEnabled: true,
+		PodIP:                    netip.MustParseAddr("10.1.2.3"),
+		ServerHost:               "127.0.0.1",
+		ManagerNamespace:         "ambassador",
+	})
+	s := &service{ctx: ctx, clock: wall{}, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := &rpc.SessionInfo{SessionId: s.state.AddClient(&rpc.ClientInfo{Name: "alice@laptop", Namespace: "default"}, time.Now())}
+	bob := &rpc.SessionInfo{SessionId: s.state.AddClient(&rpc.ClientInfo{Name: "bob", Namespace: "default"}, time.Now())}
+	request := func(session *rpc.SessionInfo, name string) *rpc.PublishServiceRequest {
+		return &rpc.PublishServiceRequest{
+			Session:     session,
+			Name:        name,
+			Ports:       []*rpc.PublishedPort{{Port: 80, TargetPort: 8080}},
+			BearerToken: "dev",
+		}
+	}
+
+	info, err := s.PublishService(ctx, request(alice, "payments-dev"))
+	require.NoError(t, err)
+	assert.Equal(t, "default", info.Namespace)
+
+	// The Service targets a listener of the traffic-manager through its EndpointSlice.
+	svc, err := fakeClient.CoreV1().Services("default").Get(ctx, "payments-dev", meta.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, alice.SessionId, svc.Labels[publishedByLabel])
+	require.Len(t, svc.Spec.Ports, 1)
+	assert.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+	listenerPort := svc.Spec.Ports[0].TargetPort.IntVal
+	eps, err := fakeClient.DiscoveryV1().EndpointSlices("default").Get(ctx, "payments-dev", meta.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.1.2.3"}, eps.Endpoints[0].Addresses)
+	assert.Equal(t, listenerPort, *eps.Ports[0].Port)
+	assert.Equal(t, svc.UID, eps.OwnerReferences[0].UID)
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(listenerPort))))
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	// Services that are published by others, or that weren't published, can't be replaced.
+	_, err = s.PublishService(ctx, request(bob, "payments-dev"))
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = s.PublishService(ctx, request(alice, "payments"))
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = s.UnpublishService(ctx, request(bob, "payments-dev"))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.UnpublishService(ctx, request(alice, "payments-dev"))
+	require.NoError(t, err)
+	_, err = fakeClient.CoreV1().Services("default").Get(ctx, "payments-dev", meta.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// The Service is deleted when the session ends, and can then be published by others.
+	_, err = s.PublishService(ctx, request(alice, "payments-dev"))
+	require.NoError(t, err)
+	s.state.RemoveSession(ctx, alice.SessionId)
+	assert.Eventually(t, func() bool {
+		_, err := fakeClient.CoreV1().Services("default").Get(ctx, "payments-dev", meta.GetOptions{})
+		return k8serrors.IsNotFound(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = s.PublishService(ctx, request(bob, "payments-dev"))
+	require.NoError(t, err)
+}
+
+func TestPublishService_userDNS(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	fakeClient := publishingClientset()
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{
+		PublishedServicesEnabled: true,
+		PodIP:                    netip.MustParseAddr("10.1.2.3"),
+		ServerHost:               "127.0.0.1",
+		ManagerNamespace:         "ambassador",
+	})
+	s := &service{ctx: ctx, clock: wall{}, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := &rpc.SessionInfo{SessionId: s.state.AddClient(&rpc.ClientInfo{Name: "Alice.Smith@laptop", Namespace: "default"}, time.Now())}
+	rq := &rpc.PublishServiceRequest{Session: alice, UserDns: true, Ports: []*rpc.PublishedPort{{Port: 3000, TargetPort: 3000}}, BearerToken: "dev"}
+	info, err := s.PublishService(ctx, rq)
+	require.NoError(t, err)
+	assert.Equal(t, "alice-smith", info.Name)
+	assert.Equal(t, "ambassador", info.Namespace)
+	_, err = fakeClient.CoreV1().Services("ambassador").Get(ctx, "alice-smith", meta.GetOptions{})
+	require.NoError(t, err)
+
+	_, err = s.UnpublishService(ctx, &rpc.PublishServiceRequest{Session: alice, UserDns: true})
+	require.NoError(t, err)
+	_, err = fakeClient.CoreV1().Services("ambassador").Get(ctx, "alice-smith", meta.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestPublishService_authorization(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	fakeClient := publishingClientset()
+	ctx = k8sapi.WithJoinedClientSetInterface(ctx, fakeClient, fakeargorollouts.NewSimpleClientset())
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{
+		PublishedServicesEnabled: true,
+		PodIP:                    netip.MustParseAddr("10.1.2.3"),
+		ServerHost:               "127.0.0.1",
+		ManagerNamespace:         "ambassador",
+		ManagedNamespaces:        []string{"default", "prod"},
+	})
+	s := &service{ctx: ctx, clock: wall{}, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := &rpc.SessionInfo{SessionId: s.state.AddClient(&rpc.ClientInfo{Name: "alice@laptop", Namespace: "default"}, time.Now())}
+	request := func(namespace, token string) *rpc.PublishServiceRequest {
+		return &rpc.PublishServiceRequest{
+			Session:     alice,
+			Name:        "payments-dev",
+			Namespace:   namespace,
+			Ports:       []*rpc.PublishedPort{{Port: 80, TargetPort: 8080}},
+			BearerToken: token,
+		}
+	}
+
+	// Namespaces that the traffic-manager doesn't manage are refused before any review.
+	_, err := s.PublishService(ctx, request("kube-system", "dev"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// The user must be authenticated, and be allowed to create services in the namespace.
+	_, err = s.PublishService(ctx, request("default", ""))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.PublishService(ctx, request("default", "guest"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.PublishService(ctx, request("prod", "dev"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	services, err := fakeClient.CoreV1().Services("").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, services.Items)
+
+	info, err := s.PublishService(ctx, request("default", "dev"))
+	require.NoError(t, err)
+	assert.Equal(t, "default", info.Namespace)
+}
+
+func Test_userServiceName(t *testing.T) {
+	tests := map[string]string{
+		"jane@laptop":           "jane",
+		"Jane.Doe@laptop":       "jane-doe",
+		`CORP\jane@host`:        "corp-jane",
+		"42@host":               "user-42",
+		"@host":                 "user",
+		strings.Repeat("a", 70): strings.Repeat("a", 63),
+	}
+	for clientName, want := range tests {
+		assert.Equal(t, want, userServiceName(clientName), clientName)
+	}
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// rateLimits are the token buckets that limit the rate at which client sessions call the expensive RPCs, keyed by
+// session ID and then by RPC name. The buckets of a session are removed when the session ends.
+type rateLimits struct {
+	sync.Mutex
+	sessions map[string]map[string]*rate.Limiter
+}
+
+// checkRateLimit takes a token from the bucket of the given session and RPC. A ResourceExhausted error is returned
+// when the bucket is empty. Its RetryInfo detail tells the client when the bucket has been refilled.
+func (s *service) checkRateLimit(ctx context.Context, sessionID, name string) error {
+	env := managerutil.GetEnv(ctx)
+	if env.RateLimitInterval <= 0 {
+		return nil
+	}
+	lim := s.rateLimiter(sessionID, name, env)
+	if lim == nil {
+		// The session is unknown, which the RPC will report.
+		return nil
+	}
+	now := s.clock.Now()
+	r := lim.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	r.CancelAt(now)
+	dlog.Debugf(ctx, "Rate limit of %s exceeded by session %s", name, sessionID)
+	st := status.Newf(codes.ResourceExhausted, "rate limit of %s exceeded, retry in %s", name, delay.Round(time.Millisecond))
+	if dst, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); err == nil {
+		st = dst
+	}
+	return st.Err()
+}
+
+// rateLimiter returns the token bucket of the given session and RPC, or nil if the session is unknown.
+func (s *service) rateLimiter(sessionID, name string, env *managerutil.Env) *rate.Limiter {
+	rl := &s.rateLimits
+	rl.Lock()
+	defer rl.Unlock()
+	limiters, ok := rl.sessions[sessionID]
+	if !ok {
+		done, err := s.state.SessionDone(sessionID)
+		if err != nil {
+			return nil
+		}
+		if rl.sessions == nil {
+			rl.sessions = make(map[string]map[string]*rate.Limiter)
+		}
+		limiters = make(map[string]*rate.Limiter)
+		rl.sessions[sessionID] = limiters
+		go func() {
+			<-done
+			rl.Lock()
+			delete(rl.sessions, sessionID)
+			rl.Unlock()
+		}()
+	}
+	lim, ok := limiters[name]
+	if !ok {
+		lim = rate.NewLimiter(rate.Every(env.RateLimitInterval), max(env.RateLimitBurst, 1))
+		limiters[name] = lim
+	}
+	return lim
+}
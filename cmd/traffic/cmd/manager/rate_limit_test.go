@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	env := &managerutil.Env{RateLimitBurst: 2}
+	ctx = managerutil.WithEnv(ctx, env)
+	clock := &fakeClock{now: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	s := &service{ctx: ctx, clock: clock, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := s.state.AddClient(&rpc.ClientInfo{Name: "alice", Namespace: "default"}, clock.Now())
+	bob := s.state.AddClient(&rpc.ClientInfo{Name: "bob", Namespace: "default"}, clock.Now())
+
+	// Disabled by default.
+	for range 5 {
+		require.NoError(t, s.checkRateLimit(ctx, alice, "EnsureAgent"))
+	}
+
+	env.RateLimitInterval = 10 * time.Second
+	require.NoError(t, s.checkRateLimit(ctx, alice, "EnsureAgent"))
+	require.NoError(t, s.checkRateLimit(ctx, alice, "EnsureAgent"))
+	err := s.checkRateLimit(ctx, alice, "EnsureAgent")
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, 10*time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	// Buckets are per session and RPC.
+	require.NoError(t, s.checkRateLimit(ctx, alice, "PrepareIntercept"))
+	require.NoError(t, s.checkRateLimit(ctx, bob, "EnsureAgent"))
+
+	// A rejected call doesn't consume a token.
+	clock.now = clock.now.Add(10 * time.Second)
+	require.NoError(t, s.checkRateLimit(ctx, alice, "EnsureAgent"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(s.checkRateLimit(ctx, alice, "EnsureAgent")))
+
+	// Unknown sessions aren't limited, and the buckets of a session are removed when it ends.
+	require.NoError(t, s.checkRateLimit(ctx, "unknown", "EnsureAgent"))
+	s.state.RemoveSession(ctx, alice)
+	assert.Eventually(t, func() bool {
+		s.rateLimits.Lock()
+		defer s.rateLimits.Unlock()
+		_, ok := s.rateLimits.sessions[alice]
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+// staleSessionTTL is how long a client session can go without a call to Remain before a new session from the same
+// installation and user considers it stale. Clients call Remain every five seconds.
+const staleSessionTTL = 30 * time.Second
+
+// ReapStaleSessions removes the stale sessions of the calling client's installation and user, together with
+// their intercepts.
+func (s *service) ReapStaleSessions(ctx context.Context, session *rpc.SessionInfo) (*rpc.ReapResult, error) {
+	ctx = managerutil.WithSessionInfo(ctx, session)
+	sessionID := session.GetSessionId()
+	dlog.Debug(ctx, "ReapStaleSessions called")
+	if s.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	return s.state.ReapStaleSessions(ctx, sessionID, s.clock.Now().Add(-staleSessionTTL)), nil
+}
+
+// runReaperLoop periodically removes the intercepts of clients that have stopped calling Remain, restores app
+// containers that are replaced although no intercept uses them, and prunes the agent configs of workloads that no
+// longer exist. Containers and agent configs are collected once they have been orphaned for the grace period.
+// Traffic-agents are removed once their workloads haven't been intercepted for the agent retention period.
+func (s *service) runReaperLoop(ctx context.Context) error {
+	env := managerutil.GetEnv(ctx)
+	ticker := time.NewTicker(env.ReaperInterval)
+	defer ticker.Stop()
+
+	var containerLeases, configLeases, agentLeases state.Leases
+	for {
+		select {
+		case <-ticker.C:
+			now := s.clock.Now()
+			if env.ReaperInterceptTTL > 0 {
+				s.state.ReapOrphanedIntercepts(ctx, now.Add(-env.ReaperInterceptTTL))
+			}
+			containerLeases, _ = s.state.RestoreOrphanedContainers(ctx, containerLeases, now, env.ReaperGracePeriod)
+			configLeases, _ = s.state.PruneOrphanedAgentConfigs(ctx, configLeases, now, env.ReaperGracePeriod)
+			agentLeases, _ = s.state.RemoveIdleAgents(ctx, agentLeases, now, env.AgentRetention)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
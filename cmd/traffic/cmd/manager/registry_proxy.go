@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// registryProxyUser is the user name of the credentials that pulls through the registry proxy must use.
+const registryProxyUser = "telepresence"
+
+func (s *service) ExposeRegistry(ctx context.Context, request *rpc.RegistryProxyRequest) (*rpc.RegistryProxyInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "ExposeRegistry called: %q", request.Address)
+
+	env := managerutil.GetEnv(ctx)
+	if env.RegistryProxyPort == 0 {
+		return nil, status.Error(codes.Unavailable, "the registry proxy of the traffic-manager is not enabled")
+	}
+	sessionID := request.GetSession().GetSessionId()
+	if s.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	var addr netip.AddrPort
+	if request.Address != "" {
+		var err error
+		if addr, err = netip.ParseAddrPort(request.Address); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid registry address %q: %v", request.Address, err)
+		}
+	}
+	password, err := s.state.SetRegistryProxy(sessionID, addr)
+	if err != nil {
+		return nil, err
+	}
+	info := &rpc.RegistryProxyInfo{}
+	if addr.IsValid() {
+		info.Address = registryProxyAddress(env)
+		info.Username = registryProxyUser
+		info.Password = password
+	}
+	return info, nil
+}
+
+// registryProxyAddress returns the address that container runtimes in the cluster use to pull images from
+// the registry proxy. Unless configured, it's the address of the traffic-manager pod.
+func registryProxyAddress(env *managerutil.Env) string {
+	if env.RegistryProxyAddress != "" {
+		return env.RegistryProxyAddress
+	}
+	return iputil.JoinHostPort(env.PodIP.String(), env.RegistryProxyPort)
+}
+
+// serveRegistryProxy serves the registry proxy, which forwards the image pulls of container runtimes in the cluster
+// to the registry that a client has exposed.
+func (s *service) serveRegistryProxy(ctx context.Context) error {
+	env := managerutil.GetEnv(ctx)
+	sc := &dhttp.ServerConfig{Handler: s.registryProxyHandler(ctx)}
+	dlog.Infof(ctx, "Registry proxy started on port: %d", env.RegistryProxyPort)
+	defer dlog.Info(ctx, "Registry proxy stopped")
+	return sc.ListenAndServe(ctx, iputil.JoinHostPort(env.ServerHost, env.RegistryProxyPort))
+}
+
+// registryProxyHandler returns the handler of the registry proxy. Only GET and HEAD requests are forwarded, so that
+// the cluster can pull images from, but never push images to, the exposed registry. The requests must carry the
+// credentials that were returned to the client that exposed the registry. They are removed before the request is
+// forwarded.
+func (s *service) registryProxyHandler(ctx context.Context) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = "http"
+			r.Out.URL.Host = r.In.Host
+			r.Out.Header.Del("Authorization")
+		},
+		Transport: &http.Transport{
+			// The connection outlives the request that dialed it, so it's tied to the proxy's context.
+			DialContext: func(context.Context, string, string) (net.Conn, error) {
+				return s.state.DialRegistry(ctx)
+			},
+			IdleConnTimeout: time.Minute,
+		},
+		ErrorLog: dlog.StdLogger(ctx, dlog.LogLevelError),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "the registry proxy only allows pulls", http.StatusMethodNotAllowed)
+			return
+		}
+		user, password, ok := r.BasicAuth()
+		if !ok || user != registryProxyUser || !s.state.AuthorizeRegistryPull(password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="telepresence"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
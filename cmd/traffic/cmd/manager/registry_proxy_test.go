@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestRegistryProxyHandler(t *testing.T) {
+	// The tunnel goroutines may log after the test has completed, so a test logger can't be used.
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	defer cancel()
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{RegistryProxyPort: 5000, RegistryProxyAddress: "localhost:30500"})
+	s := &service{ctx: ctx, clock: wall{}, state: state.NewState(ctx)}
+	s.self = s
+
+	// The "registry" records the requests that reach it.
+	var reached []*http.Request
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = append(reached, r)
+		_, _ = io.WriteString(w, "manifest")
+	}))
+	defer registry.Close()
+	ru, err := url.Parse(registry.URL)
+	require.NoError(t, err)
+
+	sessionID := s.state.AddClient(&rpc.ClientInfo{Name: "a"}, time.Now())
+	go func() {
+		for dr := range s.state.WatchDial(sessionID) {
+			clientEnd, managerEnd := tunnel.NewPipe(tunnel.ConnID(dr.ConnId), sessionID)
+			go func() { _ = s.state.Tunnel(ctx, managerEnd) }()
+			tunnel.NewDialer(clientEnd, func() {}, nil, nil).Start(ctx)
+		}
+	}()
+	info, err := s.ExposeRegistry(ctx, &rpc.RegistryProxyRequest{
+		Session: &rpc.SessionInfo{SessionId: sessionID},
+		Address: netip.MustParseAddrPort(ru.Host).String(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:30500", info.Address)
+	assert.Equal(t, registryProxyUser, info.Username)
+	require.NotEmpty(t, info.Password)
+
+	proxy := httptest.NewServer(s.registryProxyHandler(ctx))
+	defer proxy.Close()
+	do := func(method, user, password string) *http.Response {
+		rq, err := http.NewRequestWithContext(ctx, method, proxy.URL+"/v2/my-image/manifests/latest", nil)
+		require.NoError(t, err)
+		if user != "" {
+			rq.SetBasicAuth(user, password)
+		}
+		rs, err := http.DefaultClient.Do(rq)
+		require.NoError(t, err)
+		_ = rs.Body.Close()
+		return rs
+	}
+
+	rs := do(http.MethodGet, "", "")
+	assert.Equal(t, http.StatusUnauthorized, rs.StatusCode)
+	assert.Equal(t, `Basic realm="telepresence"`, rs.Header.Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, info.Username, "forged").StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPut, info.Username, info.Password).StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPost, info.Username, info.Password).StatusCode)
+	assert.Empty(t, reached)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, info.Username, info.Password).StatusCode)
+	assert.Equal(t, http.StatusOK, do(http.MethodHead, info.Username, info.Password).StatusCode)
+	require.Len(t, reached, 2)
+	assert.Equal(t, "/v2/my-image/manifests/latest", reached[0].URL.Path)
+	assert.Empty(t, reached[0].Header.Get("Authorization"))
+}
@@ -3,8 +3,11 @@ package manager
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/blang/semver/v4"
@@ -13,7 +16,9 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -28,6 +33,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -52,9 +58,11 @@ type Service interface {
 
 	// unexported methods.
 	runConfigWatcher(context.Context) error
+	runReaperLoop(context.Context) error
 	runSessionGCLoop(context.Context) error
 	serveHTTP(context.Context) error
 	servePrometheus(context.Context) error
+	serveRegistryProxy(context.Context) error
 }
 
 type service struct {
@@ -66,6 +74,10 @@ type service struct {
 	configWatcher      config.Watcher
 	activeHttpRequests int32
 	activeGrpcRequests int32
+	health             *health.Server
+	published          publishedServices
+	rateLimits         rateLimits
+	shutdownDeadline   atomic.Pointer[time.Time]
 
 	// Possibly extended version of the service. Use when calling interface methods.
 	self Service
@@ -111,7 +123,7 @@ func NewService(ctx context.Context) (Service, *dgroup.Group, error) {
 	ret.self = ret
 	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{
 		EnableSignalHandling: true,
-		SoftShutdownTimeout:  5 * time.Second,
+		SoftShutdownTimeout:  managerutil.GetEnv(ctx).ShutdownGracePeriod,
 	})
 	return ret, g, nil
 }
@@ -183,6 +195,9 @@ func (s *service) ArriveAsClient(ctx context.Context, client *rpc.ClientInfo) (*
 	if val := validateClient(client); val != "" {
 		return nil, status.Error(codes.InvalidArgument, val)
 	}
+	if err := s.checkShutdown(); err != nil {
+		return nil, err
+	}
 
 	installId := client.GetInstallId()
 
@@ -190,9 +205,10 @@ func (s *service) ArriveAsClient(ctx context.Context, client *rpc.ClientInfo) (*
 	SetGauge(s.state.GetConnectActiveStatus(), client.Name, client.InstallId, nil, 1)
 
 	return &rpc.SessionInfo{
-		SessionId: s.state.AddClient(client, s.clock.Now()),
-		ClusterId: s.clusterInfo.ID(),
-		InstallId: &installId,
+		SessionId:         s.state.AddClient(client, s.clock.Now()),
+		ClusterId:         s.clusterInfo.ID(),
+		InstallId:         &installId,
+		TunnelCompression: tunnel.NegotiateCompression(client.TunnelCompressions, managerutil.GetEnv(ctx).TunnelCompressionClient),
 	}, nil
 }
 
@@ -203,13 +219,17 @@ func (s *service) ArriveAsAgent(ctx context.Context, agent *rpc.AgentInfo) (*rpc
 	if val := validateAgent(agent); val != "" {
 		return nil, status.Error(codes.InvalidArgument, val)
 	}
+	if err := s.checkShutdown(); err != nil {
+		return nil, err
+	}
 
 	sessionID := s.state.AddAgent(agent, s.clock.Now())
 	mutator.GetMap(ctx).Whitelist(agent.PodName, agent.Namespace)
 
 	return &rpc.SessionInfo{
-		SessionId: sessionID,
-		ClusterId: s.clusterInfo.ID(),
+		SessionId:         sessionID,
+		ClusterId:         s.clusterInfo.ID(),
+		TunnelCompression: tunnel.NegotiateCompression(agent.TunnelCompressions, managerutil.GetEnv(ctx).TunnelCompressionAgent),
 	}, nil
 }
 
@@ -237,6 +257,13 @@ func (s *service) Remain(ctx context.Context, req *rpc.RemainRequest) (*empty.Em
 
 	s.state.RefreshSessionConsumptionMetrics(sessionID)
 
+	if s.state.GetClient(sessionID) != nil {
+		// Tell the client when to reconnect if the traffic-manager is shutting down. Agents are
+		// replaced along with the traffic-manager's pod, so they're not told.
+		if err := s.checkShutdown(); err != nil {
+			return nil, err
+		}
+	}
 	return &empty.Empty{}, nil
 }
 
@@ -339,8 +366,14 @@ func (s *service) WatchAgents(session *rpc.SessionInfo, stream rpc.Manager_Watch
 	if err != nil {
 		return err
 	}
+	throttle := newWatchThrottle[*rpc.AgentInfoSnapshot](s.state, watchInterval(ctx, nil))
+	defer throttle.Stop()
 	for {
 		select {
+		case <-throttle.C():
+			if err := stream.Send(throttle.Take()); err != nil {
+				return err
+			}
 		case snapshot, ok := <-snapshotCh:
 			if !ok {
 				// The request has been canceled.
@@ -359,8 +392,12 @@ func (s *service) WatchAgents(session *rpc.SessionInfo, stream rpc.Manager_Watch
 			resp := &rpc.AgentInfoSnapshot{
 				Agents: agents,
 			}
+			if !throttle.Add(resp) {
+				dlog.Debugf(ctx, "WatchAgents holding back update %v", names)
+				continue
+			}
 			dlog.Debugf(ctx, "WatchAgents sending update %v", names)
-			if err := stream.Send(resp); err != nil {
+			if err := stream.Send(throttle.Take()); err != nil {
 				return err
 			}
 		case <-sessionDone:
@@ -436,8 +473,14 @@ func (s *service) WatchAgentsNS(request *rpc.AgentsRequest, stream rpc.Manager_W
 		return false
 	}
 
+	throttle := newWatchThrottle[*rpc.AgentInfoSnapshot](s.state, watchInterval(ctx, request.MinInterval))
+	defer throttle.Stop()
 	for {
 		select {
+		case <-throttle.C():
+			if err := stream.Send(throttle.Take()); err != nil {
+				return err
+			}
 		case snapshot, ok := <-snapshotCh:
 			if !ok {
 				// The request has been canceled.
@@ -451,6 +494,7 @@ func (s *service) WatchAgentsNS(request *rpc.AgentsRequest, stream rpc.Manager_W
 				}
 			}
 			if snapEqual(agents) {
+				s.state.AddWatchUpdates(state.WatchUpdateDropped, 1)
 				continue
 			}
 			lastSnap = make(map[string]*rpc.AgentInfo, len(agents))
@@ -463,11 +507,15 @@ func (s *service) WatchAgentsNS(request *rpc.AgentsRequest, stream rpc.Manager_W
 				names[i] = a.Name + "." + a.Namespace
 				i++
 			}
-			dlog.Debugf(ctx, "WatchAgentsNS sending update %v", names)
 			resp := &rpc.AgentInfoSnapshot{
 				Agents: agents,
 			}
-			if err := stream.Send(resp); err != nil {
+			if !throttle.Add(resp) {
+				dlog.Debugf(ctx, "WatchAgentsNS holding back update %v", names)
+				continue
+			}
+			dlog.Debugf(ctx, "WatchAgentsNS sending update %v", names)
+			if err := stream.Send(throttle.Take()); err != nil {
 				return err
 			}
 		case <-sessionDone:
@@ -506,6 +554,10 @@ func (s *service) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 					// Don't return intercepts for different agents.
 					return false
 				}
+				if !appliesToAgentPod(ctx, info.Spec, agent) {
+					// Don't return intercepts that are limited to other pods of the workload.
+					return false
+				}
 				// Don't return intercepts that aren't in a "agent-owned" state.
 				switch info.Disposition {
 				case rpc.InterceptDispositionType_WAITING,
@@ -564,6 +616,22 @@ func (s *service) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 	}
 }
 
+// appliesToAgentPod returns true if the given intercept applies to the pod of the given agent.
+func appliesToAgentPod(ctx context.Context, spec *rpc.InterceptSpec, agent *rpc.AgentInfo) bool {
+	if !state.HasPodScope(spec) {
+		return true
+	}
+	var podLabels map[string]string
+	if state.PodScopeNeedsLabels(spec) {
+		var err error
+		if podLabels, err = state.PodLabels(ctx, agent.PodName, agent.Namespace); err != nil {
+			dlog.Errorf(ctx, "unable to get labels of pod %s.%s: %v", agent.PodName, agent.Namespace, err)
+			return false
+		}
+	}
+	return state.InterceptAppliesToPod(spec, agent.PodName, podLabels)
+}
+
 func (s *service) PrepareIntercept(ctx context.Context, request *rpc.CreateInterceptRequest) (pi *rpc.PreparedIntercept, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -573,6 +641,9 @@ func (s *service) PrepareIntercept(ctx context.Context, request *rpc.CreateInter
 	}()
 	ctx = managerutil.WithSessionInfo(ctx, request.Session)
 	dlog.Debugf(ctx, "PrepareIntercept %s called", request.InterceptSpec.Name)
+	if err := s.checkRateLimit(ctx, request.GetSession().GetSessionId(), "PrepareIntercept"); err != nil {
+		return nil, err
+	}
 	span := trace.SpanFromContext(ctx)
 	tracing.RecordInterceptSpec(span, request.InterceptSpec)
 	return s.state.PrepareIntercept(ctx, request)
@@ -585,18 +656,21 @@ func (s *service) GetKnownWorkloadKinds(ctx context.Context, request *rpc.Sessio
 	ctx = managerutil.WithSessionInfo(ctx, request)
 	dlog.Debugf(ctx, "GetKnownWorkloadKinds called")
 	enabledWorkloadKinds := managerutil.GetEnv(ctx).EnabledWorkloadKinds
-	kinds := make([]rpc.WorkloadInfo_Kind, len(enabledWorkloadKinds))
-	for i, wlKind := range enabledWorkloadKinds {
+	kinds := make([]rpc.WorkloadInfo_Kind, 0, len(enabledWorkloadKinds))
+	for _, wlKind := range enabledWorkloadKinds {
 		switch wlKind {
 		case workload.DeploymentWorkloadKind:
-			kinds[i] = rpc.WorkloadInfo_DEPLOYMENT
+			kinds = append(kinds, rpc.WorkloadInfo_DEPLOYMENT)
 		case workload.ReplicaSetWorkloadKind:
-			kinds[i] = rpc.WorkloadInfo_REPLICASET
+			kinds = append(kinds, rpc.WorkloadInfo_REPLICASET)
 		case workload.StatefulSetWorkloadKind:
-			kinds[i] = rpc.WorkloadInfo_STATEFULSET
+			kinds = append(kinds, rpc.WorkloadInfo_STATEFULSET)
 		case workload.RolloutWorkloadKind:
-			kinds[i] = rpc.WorkloadInfo_ROLLOUT
+			kinds = append(kinds, rpc.WorkloadInfo_ROLLOUT)
+		case workload.DaemonSetWorkloadKind:
+			kinds = append(kinds, rpc.WorkloadInfo_DAEMONSET)
 		}
+		// Kinds resolved by an agentmap.WorkloadResolver are unknown to the client.
 	}
 	return &rpc.KnownWorkloadKinds{Kinds: kinds}, nil
 }
@@ -606,13 +680,21 @@ func (s *service) EnsureAgent(ctx context.Context, request *rpc.EnsureAgentReque
 	ctx = managerutil.WithSessionInfo(ctx, session)
 	dlog.Debugf(ctx, "EnsureAgent called")
 	sessionID := session.GetSessionId()
+	if err := s.checkRateLimit(ctx, sessionID, "EnsureAgent"); err != nil {
+		return nil, err
+	}
 	client := s.state.GetClient(sessionID)
 	if client == nil {
 		return &empty.Empty{}, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
 	}
 	err := s.state.EnsureAgent(ctx, request.Name, client.Namespace)
 	if err != nil {
-		err = status.Errorf(codes.Internal, "failed to ensure agent for workload %s: %v", request.Name, err)
+		code := codes.Internal
+		if errcat.GetCategory(err) == errcat.User {
+			// E.g. the workload doesn't exist, or the events explain why the agent didn't arrive.
+			code = codes.FailedPrecondition
+		}
+		err = status.Errorf(code, "failed to ensure agent for workload %s: %v", request.Name, err)
 	}
 	return &empty.Empty{}, err
 }
@@ -630,6 +712,17 @@ func (s *service) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 		return nil, status.Error(codes.InvalidArgument, val)
 	}
 
+	// Only the traffic-manager decides whether the traffic-agent hands out secrets.
+	spec.SecretsVerified = false
+	if spec.IncludeSecrets {
+		user, err := authorizeSecrets(ctx, ciReq.BearerToken, spec.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		dlog.Infof(ctx, "%s includes secrets in the environment of intercept %s", user, spec.Name)
+		spec.SecretsVerified = true
+	}
+
 	if ciReq.InterceptSpec.Replace {
 		_, err := s.state.PrepareIntercept(ctx, ciReq)
 		if err != nil {
@@ -748,6 +841,7 @@ func (s *service) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewIntercep
 			intercept.PodName = agent.PodName
 			intercept.FtpPort = rIReq.FtpPort
 			intercept.SftpPort = rIReq.SftpPort
+			intercept.WebdavPort = rIReq.WebdavPort
 			intercept.MountPoint = rIReq.MountPoint
 			intercept.MechanismArgsDesc = rIReq.MechanismArgsDesc
 			intercept.Headers = rIReq.Headers
@@ -763,6 +857,38 @@ func (s *service) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewIntercep
 	return &empty.Empty{}, nil
 }
 
+// AuthorizeCapture lets an agent verify that a client is allowed to capture the requests that the agent receives.
+// That requires the same authorization as creating an intercept, i.e. a client session in the agent's namespace.
+func (s *service) AuthorizeCapture(ctx context.Context, request *rpc.AuthorizeCaptureRequest) (*empty.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "AuthorizeCapture called for client session %s", request.ClientSessionId)
+
+	agent := s.state.GetActiveAgent(request.GetSession().GetSessionId())
+	if agent == nil {
+		return nil, status.Errorf(codes.NotFound, "Agent session %q not found", request.GetSession().GetSessionId())
+	}
+	client := s.state.GetClient(request.ClientSessionId)
+	if client == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Client session %q not found", request.ClientSessionId)
+	}
+	if client.Namespace != agent.Namespace {
+		return nil, status.Errorf(codes.PermissionDenied, "client %s is connected to namespace %s, not to %s",
+			client.Name, client.Namespace, agent.Namespace)
+	}
+	if request.IncludeCredentials {
+		// The credentials are only revealed to a client that already receives the requests of the workload.
+		owned := s.state.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+			return ii.ClientSession.GetSessionId() == request.ClientSessionId &&
+				ii.Spec.Namespace == agent.Namespace && ii.Spec.Agent == agent.Name
+		})
+		if len(owned) == 0 {
+			return nil, status.Errorf(codes.PermissionDenied, "client %s must intercept %s.%s to capture its credentials",
+				client.Name, agent.Name, agent.Namespace)
+		}
+	}
+	return &empty.Empty{}, nil
+}
+
 func (s *service) removeExcludedEnvVars(ctx context.Context, envVars map[string]string) map[string]string {
 	cm, err := k8sapi.GetK8sInterface(ctx).CoreV1().ConfigMaps(managerutil.GetEnv(ctx).ManagerNamespace).Get(ctx, "telepresence-intercept-env", v1.GetOptions{})
 	if err != nil {
@@ -787,6 +913,56 @@ func (s *service) Tunnel(server rpc.Manager_TunnelServer) error {
 	return s.state.Tunnel(ctx, stream)
 }
 
+func (s *service) WatchAgentEvents(session *rpc.SessionInfo, stream rpc.Manager_WatchAgentEventsServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), session)
+	dlog.Debugf(ctx, "WatchAgentEvents called")
+	aeCh, err := s.state.WatchAgentEvents(ctx, session.GetSessionId())
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.ctx.Done():
+			return nil
+		case ae, ok := <-aeCh:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(ae); err != nil {
+				dlog.Debugf(ctx, "WatchAgentEvents encountered a write error: %v", err)
+				return nil
+			}
+		}
+	}
+}
+
+func (s *service) WatchAgentArrivalProgress(session *rpc.SessionInfo, stream rpc.Manager_WatchAgentArrivalProgressServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), session)
+	dlog.Debugf(ctx, "WatchAgentArrivalProgress called")
+	apCh, err := s.state.WatchAgentArrivalProgress(ctx, session.GetSessionId())
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.ctx.Done():
+			return nil
+		case ap, ok := <-apCh:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(ap); err != nil {
+				dlog.Debugf(ctx, "WatchAgentArrivalProgress encountered a write error: %v", err)
+				return nil
+			}
+		}
+	}
+}
+
 func (s *service) WatchDial(session *rpc.SessionInfo, stream rpc.Manager_WatchDialServer) error {
 	ctx := managerutil.WithSessionInfo(stream.Context(), session)
 	dlog.Debugf(ctx, "WatchDial called")
@@ -936,6 +1112,94 @@ func (s *service) WatchLookupDNS(session *rpc.SessionInfo, stream rpc.Manager_Wa
 	}
 }
 
+func (s *service) DescribeWorkload(ctx context.Context, request *rpc.DescribeWorkloadRequest) (*rpc.WorkloadDescription, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "DescribeWorkload called: %s.%s", request.Name, request.Namespace)
+	if sessionID := request.GetSession().GetSessionId(); s.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	if request.Name == "" || request.Namespace == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and namespace must not be empty")
+	}
+	return s.state.DescribeWorkload(ctx, request.Name, request.Namespace, request.WorkloadKind)
+}
+
+// defaultHeaderProbeTimeout is used when a HeaderPropagationRequest has no timeout.
+const defaultHeaderProbeTimeout = 5 * time.Second
+
+func (s *service) VerifyHeaderPropagation(ctx context.Context, request *rpc.HeaderPropagationRequest) (*rpc.HeaderPropagationResult, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "VerifyHeaderPropagation called: %s %v", request.Name, request.Chain)
+
+	interceptID, err := s.MakeInterceptID(ctx, request.GetSession().GetSessionId(), request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	intercept, ok := s.state.GetIntercept(interceptID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Intercept named %q not found", request.Name)
+	}
+	if len(request.Chain) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "the chain must contain at least one workload")
+	}
+	if request.Header == "" {
+		if request.Header = literalInterceptHeader(intercept.Headers); request.Header == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "intercept %q has no header that can be used as a probe", request.Name)
+		}
+	}
+	spec := intercept.Spec
+	if request.Url == "" {
+		request.Url = fmt.Sprintf("http://%s.%s/", request.Chain[0], spec.Namespace)
+	}
+	if request.Timeout.AsDuration() <= 0 {
+		request.Timeout = durationpb.New(defaultHeaderProbeTimeout)
+	}
+	hops := append(slices.Clone(request.Chain), spec.Agent)
+	return s.state.VerifyHeaderPropagation(ctx, spec.Namespace, hops, request)
+}
+
+// literalInterceptHeader returns the alphabetically first header that the given matchers match
+// using a literal value, or an empty string if no such header exists.
+func literalInterceptHeader(matchers map[string]string) string {
+	var names []string
+	for k, v := range matchers {
+		if !strings.HasPrefix(k, ":") && regexp.QuoteMeta(v) == v {
+			names = append(names, k)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return slices.Min(names)
+}
+
+func (s *service) ReportHeaderProbe(ctx context.Context, report *rpc.HeaderProbeReport) (*empty.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, report.GetSession())
+	dlog.Debugf(ctx, "ReportHeaderProbe called %s observed=%t", report.Id, report.Observed)
+	s.state.PostHeaderProbeReport(ctx, report)
+	return &empty.Empty{}, nil
+}
+
+func (s *service) WatchHeaderProbes(session *rpc.SessionInfo, stream rpc.Manager_WatchHeaderProbesServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), session)
+	dlog.Debugf(ctx, "WatchHeaderProbes called")
+	pCh := s.state.WatchHeaderProbes(session.SessionId)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case p := <-pCh:
+			if p == nil {
+				return nil
+			}
+			if err := stream.Send(p); err != nil {
+				dlog.Errorf(ctx, "WatchHeaderProbes.Send() failed: %v", err)
+				return nil
+			}
+		}
+	}
+}
+
 // GetLogs acquires the logs for the traffic-manager and/or traffic-agents specified by the
 // GetLogsRequest and returns them to the caller
 // Deprecated: Clients should use the user daemon's GatherLogs method.
@@ -984,6 +1248,9 @@ func (s *service) WatchWorkloads(request *rpc.WorkloadEventsRequest, stream rpc.
 		return status.Error(codes.InvalidArgument, "SessionInfo is required")
 	}
 	clientSession := request.SessionInfo.SessionId
+	if err := s.checkRateLimit(ctx, clientSession, "WatchWorkloads"); err != nil {
+		return err
+	}
 	namespace := request.Namespace
 	if namespace == "" {
 		clientInfo := s.state.GetClient(clientSession)
@@ -992,7 +1259,7 @@ func (s *service) WatchWorkloads(request *rpc.WorkloadEventsRequest, stream rpc.
 		}
 		namespace = clientInfo.Namespace
 	}
-	ww := s.state.NewWorkloadInfoWatcher(clientSession, namespace)
+	ww := s.state.NewWorkloadInfoWatcher(clientSession, namespace, watchInterval(ctx, request.MinInterval))
 	return ww.Watch(ctx, stream)
 }
 
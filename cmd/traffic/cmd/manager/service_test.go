@@ -9,7 +9,11 @@ import (
 	"github.com/go-json-experiment/json"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 	empty "google.golang.org/protobuf/types/known/emptypb"
@@ -157,6 +161,24 @@ func TestConnect(t *testing.T) {
 	_, err = client.Remain(ctx, &rpc.RemainRequest{Session: aliceSess2})
 	require.NoError(err)
 
+	// Hello's agent lets Alice, but no one else, capture its requests
+
+	_, err = client.AuthorizeCapture(ctx, &rpc.AuthorizeCaptureRequest{Session: helloSess, ClientSessionId: aliceSess2.SessionId})
+	require.NoError(err)
+	_, err = client.AuthorizeCapture(ctx, &rpc.AuthorizeCaptureRequest{Session: helloSess, ClientSessionId: aliceSess1.SessionId})
+	require.Equal(codes.Unauthenticated, status.Code(err))
+
+	// A workload that doesn't exist is the user's mistake
+
+	_, err = client.EnsureAgent(ctx, &rpc.EnsureAgentRequest{Session: aliceSess2, Name: "no-such-workload"})
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// Alice must intercept hello to capture its credentials
+
+	credCapture := &rpc.AuthorizeCaptureRequest{Session: helloSess, ClientSessionId: aliceSess2.SessionId, IncludeCredentials: true}
+	_, err = client.AuthorizeCapture(ctx, credCapture)
+	require.Equal(codes.PermissionDenied, status.Code(err))
+
 	// Hello Pro's agent arrives and departs
 
 	helloProSess, err := client.ArriveAsAgent(ctx, testAgents["helloPro"])
@@ -194,6 +216,9 @@ func TestConnect(t *testing.T) {
 		Mechanism:  "tcp",
 		TargetHost: "asdf",
 		TargetPort: 9876,
+
+		// Only the traffic-manager may set this.
+		SecretsVerified: true,
 	}
 
 	first, err := client.CreateIntercept(ctx, &rpc.CreateInterceptRequest{
@@ -201,6 +226,8 @@ func TestConnect(t *testing.T) {
 		InterceptSpec: spec,
 	})
 	require.NoError(err)
+	require.False(first.Spec.SecretsVerified)
+	spec.SecretsVerified = false
 	require.True(proto.Equal(spec, first.Spec))
 	t.Logf("=> intercept info: %s", dumps(first))
 
@@ -240,6 +267,9 @@ func TestConnect(t *testing.T) {
 	require.Equal(rpc.InterceptDispositionType_ACTIVE, hSnapI.Intercepts[0].Disposition)
 	t.Logf("=> agent[hello] intercept snapshot = %s", dumps(hSnapI))
 
+	_, err = client.AuthorizeCapture(ctx, credCapture)
+	require.NoError(err)
+
 	// Creating require duplicate intercept yields an error
 
 	second, err := client.CreateIntercept(ctx, &rpc.CreateInterceptRequest{
@@ -269,6 +299,9 @@ func TestConnect(t *testing.T) {
 	require.Len(hSnapI.Intercepts, 0)
 	t.Logf("=> agent[hello] intercept snapshot = %s", dumps(hSnapI))
 
+	_, err = client.AuthorizeCapture(ctx, credCapture)
+	require.Equal(codes.PermissionDenied, status.Code(err))
+
 	_, err = client.RemoveIntercept(ctx, &rpc.RemoveInterceptRequest2{
 		Session: aliceSess1, // no longer require valid session, right?
 		Name:    spec.Name,  // doesn't matter...
@@ -284,6 +317,34 @@ func TestConnect(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestHealthAndReflection(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	conn := getTestClientConn(ctx, t)
+	defer conn.Close()
+
+	hc := grpc_health_v1.NewHealthClient(conn)
+	for _, svc := range []string{"", rpc.Manager_ServiceDesc.ServiceName} {
+		rsp, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: svc})
+		require.NoError(t, err)
+		require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, rsp.Status)
+	}
+
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	}))
+	rsp, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, svc := range rsp.GetListServicesResponse().GetService() {
+		names = append(names, svc.Name)
+	}
+	require.Contains(t, names, rpc.Manager_ServiceDesc.ServiceName)
+	require.Contains(t, names, grpc_health_v1.Health_ServiceDesc.ServiceName)
+	require.NoError(t, stream.CloseSend())
+}
+
 func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
 	const bufsize = 64 * 1024
 	var cancel func()
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// ShareIntercept returns a token that other clients can use to join one of the calling client's intercepts.
+func (s *service) ShareIntercept(ctx context.Context, request *rpc.GetInterceptRequest) (*rpc.InterceptShareToken, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debugf(ctx, "ShareIntercept called: %s", request.Name)
+
+	sessionID := request.GetSession().GetSessionId()
+	if s.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	if _, ok := s.state.GetIntercept(sessionID + ":" + request.Name); !ok {
+		return nil, status.Errorf(codes.NotFound, "Intercept named %q not found", request.Name)
+	}
+	token, err := s.state.ShareIntercept(ctx, sessionID+":"+request.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.InterceptShareToken{Token: token}, nil
+}
+
+// JoinIntercept adds the calling client to the shared sessions of the intercept that the token was issued for. The
+// client must be connected to the namespace of the intercept, and its bearer token must allow it to get the
+// intercepted workload.
+func (s *service) JoinIntercept(ctx context.Context, request *rpc.JoinInterceptRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debug(ctx, "JoinIntercept called")
+
+	sessionID := request.GetSession().GetSessionId()
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	ii, ok := s.state.SharedIntercept(request.Token)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "the share token is invalid, or its intercept has ended")
+	}
+	spec := ii.Spec
+	if client.Namespace != spec.Namespace {
+		return nil, status.Errorf(codes.InvalidArgument, "intercept %s is in namespace %s, but this client is connected to namespace %s",
+			spec.Name, spec.Namespace, client.Namespace)
+	}
+	user, err := authorizeJoin(ctx, request.BearerToken, spec)
+	if err != nil {
+		return nil, err
+	}
+	dlog.Infof(ctx, "%s joins intercept %s of %s", user, spec.Name, spec.Client)
+	if ii, err = s.state.JoinIntercept(ctx, request.Token, request.Session); err != nil {
+		return nil, err
+	}
+
+	// The joining client is only told about its own route.
+	ii = proto.Clone(ii).(*rpc.InterceptInfo)
+	ii.SharedSessionRoutes = map[string]string{sessionID: ii.SharedSessionRoutes[sessionID]}
+	return ii, nil
+}
+
+// LeaveIntercept removes the calling client from the shared sessions of the intercept that the token was issued for.
+func (s *service) LeaveIntercept(ctx context.Context, request *rpc.JoinInterceptRequest) (*emptypb.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, request.GetSession())
+	dlog.Debug(ctx, "LeaveIntercept called")
+
+	if err := s.state.LeaveIntercept(ctx, request.Token, request.GetSession().GetSessionId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
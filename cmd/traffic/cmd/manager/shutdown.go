@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// beginShutdown is called when the traffic-manager is asked to terminate. From then on, no new sessions are
+// accepted, and the clients are told to reconnect once the shutdown deadline has passed. Existing tunnels are
+// kept alive until the deadline.
+func (s *service) beginShutdown(ctx context.Context) {
+	deadline := s.clock.Now().Add(managerutil.GetEnv(ctx).ShutdownGracePeriod)
+	s.shutdownDeadline.Store(&deadline)
+	dlog.Infof(ctx, "Shutting down, draining tunnels until %s", deadline.Format(time.RFC3339))
+}
+
+// checkShutdown returns an Unavailable error when the traffic-manager is shutting down. Its RetryInfo detail
+// tells the caller when to reconnect.
+func (s *service) checkShutdown() error {
+	deadline := s.shutdownDeadline.Load()
+	if deadline == nil {
+		return nil
+	}
+	delay := max(deadline.Sub(s.clock.Now()), 0)
+	st := status.Newf(codes.Unavailable, "traffic-manager is shutting down, reconnect in %s", delay.Round(time.Second))
+	if dst, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); err == nil {
+		st = dst
+	}
+	return st.Err()
+}
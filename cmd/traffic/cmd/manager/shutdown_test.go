@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+func TestShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{ShutdownGracePeriod: 20 * time.Second})
+	clock := &fakeClock{now: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	s := &service{ctx: ctx, clock: clock, state: state.NewState(ctx)}
+	s.self = s
+
+	alice := s.state.AddClient(&rpc.ClientInfo{Name: "alice", Namespace: "default"}, clock.Now())
+	agent := s.state.AddAgent(&rpc.AgentInfo{Name: "echo", Namespace: "default"}, clock.Now())
+	require.NoError(t, s.checkShutdown())
+	_, err := s.Remain(ctx, &rpc.RemainRequest{Session: &rpc.SessionInfo{SessionId: alice}})
+	require.NoError(t, err)
+
+	s.beginShutdown(ctx)
+	clock.now = clock.now.Add(5 * time.Second)
+
+	// New sessions are rejected.
+	_, err = s.ArriveAsClient(ctx, &rpc.ClientInfo{Name: "bob", InstallId: "5678", Product: "telepresence", Version: "2.21.0"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Clients are told when to reconnect, but their sessions are kept alive.
+	_, err = s.Remain(ctx, &rpc.RemainRequest{Session: &rpc.SessionInfo{SessionId: alice}})
+	st := status.Convert(err)
+	require.Equal(t, codes.Unavailable, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, 15*time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+	assert.True(t, clock.Now().Equal(s.state.GetSession(alice).LastMarked()))
+
+	// Agents aren't told.
+	_, err = s.Remain(ctx, &rpc.RemainRequest{Session: &rpc.SessionInfo{SessionId: agent}})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	core "k8s.io/api/core/v1"
+	events "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+const (
+	// agentConfigRepairedReason is the reason of the event that is recorded on a workload when its stale agent
+	// config entry is regenerated.
+	agentConfigRepairedReason = "AgentConfigRepaired"
+
+	reportingController = "telepresence.io/traffic-manager"
+)
+
+// staleConfigReason returns a description of why the given agent config no longer matches the pod template of
+// the given workload, e.g. because a container was renamed or a named container port was removed or renumbered
+// by a deploy, or because the resource annotations of the pod template changed. The given resources are the
+// cluster-wide resources of the traffic-agent. An empty string is returned when the config matches.
+func staleConfigReason(ac *agentconfig.Sidecar, wl k8sapi.Workload, resources *core.ResourceRequirements) string {
+	tpl := wl.GetPodTemplate()
+	cns := tpl.Spec.Containers
+	for i := range cns {
+		if cns[i].Name == agentconfig.ContainerName {
+			// The template is the spec of a bare pod that has already been modified by the agent injector.
+			return ""
+		}
+	}
+	for _, cc := range ac.Containers {
+		var cn *core.Container
+		for i := range cns {
+			if cns[i].Name == cc.Name {
+				cn = &cns[i]
+				break
+			}
+		}
+		if cn == nil {
+			return fmt.Sprintf("container %s no longer exists", cc.Name)
+		}
+		for _, ic := range cc.Intercepts {
+			if ic.ContainerPortName == "" {
+				continue
+			}
+			var cp *core.ContainerPort
+			for i := range cn.Ports {
+				if cn.Ports[i].Name == ic.ContainerPortName {
+					cp = &cn.Ports[i]
+					break
+				}
+			}
+			if cp == nil {
+				return fmt.Sprintf("container %s no longer has a port named %s", cc.Name, ic.ContainerPortName)
+			}
+			if uint16(cp.ContainerPort) != ic.ContainerPort {
+				return fmt.Sprintf("port %s of container %s changed from %d to %d", ic.ContainerPortName, cc.Name, ic.ContainerPort, cp.ContainerPort)
+			}
+		}
+	}
+	// Invalid annotations are reported when the config is generated.
+	if rr, err := agentconfig.AgentResources(resources, tpl.Annotations); err == nil && !equality.Semantic.DeepEqual(rr, ac.Resources) {
+		return fmt.Sprintf("the resources of the %s changed", agentconfig.ContainerName)
+	}
+	return ""
+}
+
+// recordConfigRepairedEvent records an event on the given workload that tells that its agent config entry
+// was regenerated. Failures are logged but otherwise ignored.
+func recordConfigRepairedEvent(ctx context.Context, wl k8sapi.Workload, reason string) {
+	instance, _ := os.Hostname()
+	if instance == "" {
+		instance = "traffic-manager"
+	}
+	ev := &events.Event{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: wl.GetName() + ".",
+			Namespace:    wl.GetNamespace(),
+		},
+		EventTime:           meta.NowMicro(),
+		ReportingController: reportingController,
+		ReportingInstance:   instance,
+		Action:              "RegenerateAgentConfig",
+		Reason:              agentConfigRepairedReason,
+		Regarding: core.ObjectReference{
+			Kind:      wl.GetKind(),
+			Namespace: wl.GetNamespace(),
+			Name:      wl.GetName(),
+			UID:       wl.GetUID(),
+		},
+		Note: fmt.Sprintf("Regenerated the traffic-agent config because %s", reason),
+		Type: core.EventTypeNormal,
+	}
+	if _, err := k8sapi.GetK8sInterface(ctx).EventsV1().Events(wl.GetNamespace()).Create(ctx, ev, meta.CreateOptions{}); err != nil {
+		dlog.Warnf(ctx, "unable to record %s event for %s %s.%s: %v", agentConfigRepairedReason, wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
+}
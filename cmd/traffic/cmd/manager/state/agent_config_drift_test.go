@@ -0,0 +1,113 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+func testDeployment(cns ...core.Container) k8sapi.Workload {
+	return k8sapi.Deployment(&apps.Deployment{
+		TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default", UID: "1234"},
+		Spec: apps.DeploymentSpec{
+			Template: core.PodTemplateSpec{Spec: core.PodSpec{Containers: cns}},
+		},
+	})
+}
+
+func Test_staleConfigReason(t *testing.T) {
+	ac := &agentconfig.Sidecar{
+		Containers: []*agentconfig.Container{{
+			Name: "echo",
+			Intercepts: []*agentconfig.Intercept{
+				{ContainerPortName: "http", ContainerPort: 8080},
+				{ContainerPort: 9090},
+			},
+		}},
+	}
+	tests := []struct {
+		name string
+		cns  []core.Container
+		want string
+	}{
+		{
+			name: "match",
+			cns:  []core.Container{{Name: "echo", Ports: []core.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+		},
+		{
+			name: "container renamed",
+			cns:  []core.Container{{Name: "echo-server", Ports: []core.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+			want: "container echo no longer exists",
+		},
+		{
+			name: "port removed",
+			cns:  []core.Container{{Name: "echo", Ports: []core.ContainerPort{{Name: "grpc", ContainerPort: 8080}}}},
+			want: "container echo no longer has a port named http",
+		},
+		{
+			name: "port renumbered",
+			cns:  []core.Container{{Name: "echo", Ports: []core.ContainerPort{{Name: "http", ContainerPort: 8081}}}},
+			want: "port http of container echo changed from 8080 to 8081",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, staleConfigReason(ac, testDeployment(tt.cns...), nil))
+		})
+	}
+
+	t.Run("injected bare pod", func(t *testing.T) {
+		wl := k8sworkload.Pod(&core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+			Spec: core.PodSpec{Containers: []core.Container{
+				{Name: "echo", Ports: []core.ContainerPort{{Name: "tm-http", ContainerPort: 8080}}},
+				{Name: agentconfig.ContainerName},
+			}},
+		})
+		assert.Empty(t, staleConfigReason(ac, wl, nil))
+	})
+
+	t.Run("resources changed", func(t *testing.T) {
+		wl := testDeployment(core.Container{Name: "echo", Ports: []core.ContainerPort{{Name: "http", ContainerPort: 8080}}})
+		base := &core.ResourceRequirements{Limits: core.ResourceList{core.ResourceCPU: resource.MustParse("100m")}}
+		assert.Equal(t, "the resources of the traffic-agent changed", staleConfigReason(ac, wl, base))
+
+		rac := *ac
+		rac.Resources = base
+		assert.Empty(t, staleConfigReason(&rac, wl, base))
+
+		wl.GetPodTemplate().Annotations = map[string]string{agentconfig.CPULimitAnnotation: "500m"}
+		assert.Equal(t, "the resources of the traffic-agent changed", staleConfigReason(&rac, wl, base))
+
+		rac.Resources = &core.ResourceRequirements{Limits: core.ResourceList{core.ResourceCPU: resource.MustParse("0.5")}}
+		assert.Empty(t, staleConfigReason(&rac, wl, base))
+	})
+}
+
+func Test_recordConfigRepairedEvent(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+	wl := testDeployment(core.Container{Name: "echo"})
+	recordConfigRepairedEvent(ctx, wl, "container echo no longer exists")
+
+	evs, err := cs.EventsV1().Events("default").List(ctx, meta.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, evs.Items, 1)
+	ev := evs.Items[0]
+	assert.Equal(t, agentConfigRepairedReason, ev.Reason)
+	assert.Equal(t, "echo", ev.Regarding.Name)
+	assert.Equal(t, "Deployment", ev.Regarding.Kind)
+	assert.Equal(t, "Regenerated the traffic-agent config because container echo no longer exists", ev.Note)
+}
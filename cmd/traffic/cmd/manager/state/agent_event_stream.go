@@ -0,0 +1,122 @@
+package state
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+// sessionSubscriberBufferSize is the number of messages that are buffered for each subscriber. Messages are
+// dropped for subscribers that fall this far behind.
+const sessionSubscriberBufferSize = 32
+
+// sessionSubscribers distributes the messages that are produced while the intercepts of a client session
+// are prepared to the watchers of that session.
+type sessionSubscribers[T any] struct {
+	sync.Mutex
+	idGen       int
+	subscribers map[int]chan T
+}
+
+func (ss *sessionSubscribers[T]) notify(msg T) {
+	ss.Lock()
+	defer ss.Unlock()
+	for _, ch := range ss.subscribers {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+func (ss *sessionSubscribers[T]) subscribe() (int, <-chan T) {
+	ch := make(chan T, sessionSubscriberBufferSize)
+	ss.Lock()
+	if ss.subscribers == nil {
+		ss.subscribers = make(map[int]chan T)
+	}
+	id := ss.idGen
+	ss.idGen++
+	ss.subscribers[id] = ch
+	ss.Unlock()
+	return id, ch
+}
+
+func (ss *sessionSubscribers[T]) unsubscribe(id int) {
+	ss.Lock()
+	ch, ok := ss.subscribers[id]
+	if ok {
+		delete(ss.subscribers, id)
+	}
+	ss.Unlock()
+	if ok {
+		close(ch)
+	}
+}
+
+// watchClientSession subscribes to the subscribers that the given function returns for the client session
+// with the given ID. The returned channel is closed when the context is cancelled or the session ends.
+func watchClientSession[T any](
+	ctx context.Context,
+	s *state,
+	sessionID string,
+	subscribers func(*clientSessionState) *sessionSubscribers[T],
+) (<-chan T, error) {
+	cs, ok := s.GetSession(sessionID).(*clientSessionState)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	ss := subscribers(cs)
+	id, ch := ss.subscribe()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-cs.Done():
+		}
+		ss.unsubscribe(id)
+	}()
+	return ch, nil
+}
+
+// WatchAgentEvents returns a channel that receives the agent events that are observed while the intercepts of
+// the given client session are prepared. The channel is closed when the context is cancelled or the session ends.
+func (s *state) WatchAgentEvents(ctx context.Context, sessionID string) (<-chan *rpc.AgentEvent, error) {
+	return watchClientSession(ctx, s, sessionID, func(cs *clientSessionState) *sessionSubscribers[*rpc.AgentEvent] {
+		return &cs.agentEvents
+	})
+}
+
+// WatchAgentArrivalProgress returns a channel that receives the stages that workloads reach while the intercepts
+// of the given client session are prepared. The channel is closed when the context is cancelled or the session ends.
+func (s *state) WatchAgentArrivalProgress(ctx context.Context, sessionID string) (<-chan *rpc.AgentArrivalProgress, error) {
+	return watchClientSession(ctx, s, sessionID, func(cs *clientSessionState) *sessionSubscribers[*rpc.AgentArrivalProgress] {
+		return &cs.agentProgress
+	})
+}
+
+// publishAgentEvent sends the given event to the WatchAgentEvents calls of the client session of the given context.
+func (s *state) publishAgentEvent(ctx context.Context, ae *rpc.AgentEvent) {
+	if cs, ok := s.GetSession(managerutil.GetSessionID(ctx)).(*clientSessionState); ok {
+		cs.agentEvents.notify(ae)
+	}
+}
+
+// publishAgentProgress sends the given stage of the given workload to the WatchAgentArrivalProgress calls of
+// the client session of the given context.
+func (s *state) publishAgentProgress(ctx context.Context, stage rpc.AgentArrivalProgress_Stage, name, namespace, podName string) {
+	if cs, ok := s.GetSession(managerutil.GetSessionID(ctx)).(*clientSessionState); ok {
+		cs.agentProgress.notify(&rpc.AgentArrivalProgress{
+			Stage:        stage,
+			WorkloadName: name,
+			Namespace:    namespace,
+			PodName:      podName,
+			Time:         timestamppb.Now(),
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	events "k8s.io/api/events/v1"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// eventClass maps Kubernetes events to a cause and a severity. An event matches when its reason is
+// one of the reasons (or when reasons is empty), and its note matches the note expression (or when
+// the expression is nil).
+type eventClass struct {
+	reasons  []string
+	note     *regexp.Regexp
+	cause    manager.AgentEvent_Cause
+	severity manager.AgentEvent_Severity
+}
+
+// eventClasses are evaluated in order, and the first matching class wins. The event reasons are
+// stable identifiers. Matching on notes is only used to distinguish between events that share the same
+// reason, and each such expression is kept here, in one place, so that they can easily be amended.
+//
+//nolint:gochecknoglobals // constant
+var eventClasses = []eventClass{
+	{
+		reasons:  []string{"Failed", "FailedCreate"},
+		note:     regexp.MustCompile(`violates PodSecurity|runAsNonRoot and image will run as root`),
+		cause:    manager.AgentEvent_POD_SECURITY_VIOLATION,
+		severity: manager.AgentEvent_FATAL,
+	},
+	{
+		reasons:  []string{"BackOff"},
+		cause:    manager.AgentEvent_CONTAINER_BACKOFF,
+		severity: manager.AgentEvent_FATAL,
+	},
+	{
+		reasons:  []string{"Failed", "FailedCreate", "FailedScheduling"},
+		note:     regexp.MustCompile(`waiting for ephemeral volume`),
+		cause:    manager.AgentEvent_EPHEMERAL_VOLUME_PENDING,
+		severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+	},
+	{
+		reasons:  []string{"Failed", "FailedCreate", "FailedScheduling"},
+		note:     regexp.MustCompile(`unbound immediate PersistentVolumeClaims`),
+		cause:    manager.AgentEvent_UNBOUND_VOLUME_CLAIM,
+		severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+	},
+	{
+		reasons:  []string{"Failed", "FailedCreate", "FailedScheduling"},
+		note:     regexp.MustCompile(`skip schedule deleting pod`),
+		cause:    manager.AgentEvent_POD_TERMINATING,
+		severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+	},
+	{
+		reasons:  []string{"Failed", "FailedCreate", "FailedScheduling"},
+		note:     regexp.MustCompile(`nodes are available`),
+		cause:    manager.AgentEvent_INSUFFICIENT_NODES,
+		severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+	},
+	{
+		reasons:  []string{"FailedCreate"},
+		note:     regexp.MustCompile(`exceeded quota`),
+		cause:    manager.AgentEvent_QUOTA_EXCEEDED,
+		severity: manager.AgentEvent_FATAL,
+	},
+	{
+		reasons:  []string{"FailedScheduling"},
+		cause:    manager.AgentEvent_SCHEDULING_FAILED,
+		severity: manager.AgentEvent_FATAL,
+	},
+	{
+		reasons:  []string{"Failed", "FailedCreate"},
+		cause:    manager.AgentEvent_CREATE_FAILED,
+		severity: manager.AgentEvent_FATAL,
+	},
+}
+
+func (ec *eventClass) matches(e *events.Event) bool {
+	if len(ec.reasons) > 0 && !slices.Contains(ec.reasons, e.Reason) {
+		return false
+	}
+	if ec.note != nil {
+		// Notes are only matched for warnings. Other event types are never fatal.
+		return e.Type == "Warning" && ec.note.MatchString(e.Note)
+	}
+	return true
+}
+
+// classifyEvent returns the AgentEvent for the given Kubernetes event. Events that don't match any of the
+// eventClasses get the cause UNKNOWN_UNSPECIFIED and are considered transient.
+func classifyEvent(e *events.Event) *manager.AgentEvent {
+	ae := &manager.AgentEvent{
+		Type:            e.Type,
+		Reason:          e.Reason,
+		Note:            e.Note,
+		ObjectKind:      e.Regarding.Kind,
+		ObjectName:      e.Regarding.Name,
+		ObjectNamespace: e.Regarding.Namespace,
+		CreateTime:      timestamppb.New(e.CreationTimestamp.Time),
+	}
+	for i := range eventClasses {
+		ec := &eventClasses[i]
+		if ec.matches(e) {
+			ae.Cause = ec.cause
+			ae.Severity = ec.severity
+			break
+		}
+	}
+	return ae
+}
+
+// agentEventCause returns a short, human-readable name for the given cause.
+func agentEventCause(c manager.AgentEvent_Cause) string {
+	if c == manager.AgentEvent_UNKNOWN_UNSPECIFIED {
+		return "unknown"
+	}
+	return strings.ToLower(strings.ReplaceAll(c.String(), "_", "-"))
+}
+
+// writeAgentEvents writes the given events to the given builder as a table, similar to the one
+// produced by "kubectl events".
+func writeAgentEvents(bf *strings.Builder, es []*manager.AgentEvent) {
+	now := time.Now()
+	age := func(e *manager.AgentEvent) string {
+		return now.Sub(e.CreateTime.AsTime()).Truncate(time.Second).String()
+	}
+	object := func(e *manager.AgentEvent) string {
+		return strings.ToLower(e.ObjectKind) + "/" + e.ObjectName
+	}
+	ageLen, typeLen, reasonLen, causeLen, objectLen := len("AGE"), len("TYPE"), len("REASON"), len("CAUSE"), len("OBJECT")
+	for _, e := range es {
+		ageLen = max(ageLen, len(age(e)))
+		typeLen = max(typeLen, len(e.Type))
+		reasonLen = max(reasonLen, len(e.Reason))
+		causeLen = max(causeLen, len(agentEventCause(e.Cause)))
+		objectLen = max(objectLen, len(object(e)))
+	}
+	ageLen += 3
+	typeLen += 3
+	reasonLen += 3
+	causeLen += 3
+	objectLen += 3
+	fmt.Fprintf(bf, "%-*s%-*s%-*s%-*s%-*s%s\n",
+		ageLen, "AGE", typeLen, "TYPE", reasonLen, "REASON", causeLen, "CAUSE", objectLen, "OBJECT", "MESSAGE")
+	for _, e := range es {
+		fmt.Fprintf(bf, "%-*s%-*s%-*s%-*s%-*s%s\n",
+			ageLen, age(e), typeLen, e.Type, reasonLen, e.Reason, causeLen, agentEventCause(e.Cause), objectLen, object(e), e.Note)
+	}
+}
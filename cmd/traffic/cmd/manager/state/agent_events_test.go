@@ -0,0 +1,157 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	core "k8s.io/api/core/v1"
+	events "k8s.io/api/events/v1"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_classifyEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      string
+		reason   string
+		note     string
+		cause    manager.AgentEvent_Cause
+		severity manager.AgentEvent_Severity
+	}{
+		{
+			name:     "backoff",
+			typ:      "Warning",
+			reason:   "BackOff",
+			note:     "Back-off restarting failed container traffic-agent in pod echo-easy-7b8f8c9c4-xk2vq_default",
+			cause:    manager.AgentEvent_CONTAINER_BACKOFF,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "pod security",
+			typ:      "Warning",
+			reason:   "FailedCreate",
+			note:     `Error creating: pods "echo-easy-7b8f8c9c4-xk2vq" is forbidden: violates PodSecurity "restricted:latest": allowPrivilegeEscalation != false`,
+			cause:    manager.AgentEvent_POD_SECURITY_VIOLATION,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "run as root",
+			typ:      "Warning",
+			reason:   "Failed",
+			note:     `Error: container has runAsNonRoot and image will run as root (pod: "echo-easy-7b8f8c9c4-xk2vq_default", container: traffic-agent)`,
+			cause:    manager.AgentEvent_POD_SECURITY_VIOLATION,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "quota",
+			typ:      "Warning",
+			reason:   "FailedCreate",
+			note:     `pods "echo-easy-7b8f8c9c4-xk2vq" is forbidden: exceeded quota: compute-resources`,
+			cause:    manager.AgentEvent_QUOTA_EXCEEDED,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "ephemeral volume",
+			typ:      "Warning",
+			reason:   "FailedScheduling",
+			note:     `waiting for ephemeral volume controller to create the persistentvolumeclaim "echo-easy-data"`,
+			cause:    manager.AgentEvent_EPHEMERAL_VOLUME_PENDING,
+			severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+		},
+		{
+			name:     "unbound claim",
+			typ:      "Warning",
+			reason:   "FailedScheduling",
+			note:     "0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims.",
+			cause:    manager.AgentEvent_UNBOUND_VOLUME_CLAIM,
+			severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+		},
+		{
+			name:     "terminating",
+			typ:      "Warning",
+			reason:   "FailedScheduling",
+			note:     "skip schedule deleting pod: default/echo-easy-7b8f8c9c4-xk2vq",
+			cause:    manager.AgentEvent_POD_TERMINATING,
+			severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+		},
+		{
+			name:     "insufficient nodes",
+			typ:      "Warning",
+			reason:   "FailedScheduling",
+			note:     "0/3 nodes are available: 3 Insufficient cpu.",
+			cause:    manager.AgentEvent_INSUFFICIENT_NODES,
+			severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+		},
+		{
+			name:     "scheduling failed",
+			typ:      "Warning",
+			reason:   "FailedScheduling",
+			note:     "running PreBind plugin failed",
+			cause:    manager.AgentEvent_SCHEDULING_FAILED,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "create failed",
+			typ:      "Warning",
+			reason:   "FailedCreate",
+			note:     `admission webhook "agent-injector-default.getambassador.io" denied the request`,
+			cause:    manager.AgentEvent_CREATE_FAILED,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "note only matched for warnings",
+			typ:      "Normal",
+			reason:   "Failed",
+			note:     "skip schedule deleting pod: default/echo-easy-7b8f8c9c4-xk2vq",
+			cause:    manager.AgentEvent_CREATE_FAILED,
+			severity: manager.AgentEvent_FATAL,
+		},
+		{
+			name:     "unknown",
+			typ:      "Warning",
+			reason:   "Unhealthy",
+			note:     "Readiness probe failed",
+			cause:    manager.AgentEvent_UNKNOWN_UNSPECIFIED,
+			severity: manager.AgentEvent_TRANSIENT_UNSPECIFIED,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ae := classifyEvent(&events.Event{
+				Type:   tt.typ,
+				Reason: tt.reason,
+				Note:   tt.note,
+				Regarding: core.ObjectReference{
+					Kind: "Pod",
+					Name: "echo-easy-7b8f8c9c4-xk2vq",
+				},
+			})
+			assert.Equal(t, tt.cause, ae.Cause)
+			assert.Equal(t, tt.severity, ae.Severity)
+			assert.Equal(t, tt.reason, ae.Reason)
+			assert.Equal(t, tt.note, ae.Note)
+			assert.Equal(t, "Pod", ae.ObjectKind)
+		})
+	}
+}
+
+func Test_writeAgentEvents(t *testing.T) {
+	bf := &strings.Builder{}
+	writeAgentEvents(bf, []*manager.AgentEvent{{
+		CreateTime: timestamppb.New(time.Now().Add(-5 * time.Second)),
+		Type:       "Warning",
+		Reason:     "FailedScheduling",
+		Cause:      manager.AgentEvent_INSUFFICIENT_NODES,
+		ObjectKind: "Pod",
+		ObjectName: "echo",
+		Note:       "0/3 nodes are available",
+	}})
+	assert.Equal(t, ""+
+		"AGE   TYPE      REASON             CAUSE                OBJECT     MESSAGE\n"+
+		"5s    Warning   FailedScheduling   insufficient-nodes   pod/echo   0/3 nodes are available\n",
+		bf.String())
+}
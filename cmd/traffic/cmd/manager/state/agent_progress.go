@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// watchAgentPods watches the pods of the given workload until the context is cancelled, and publishes the
+// stages of agent arrival that they reach to the client session of the context. Only pods that are created
+// or deleted after the call are considered.
+func (s *state) watchAgentPods(ctx context.Context, wl k8sapi.Workload) {
+	podLabels := wl.GetPodTemplate().Labels
+	if len(podLabels) == 0 {
+		return
+	}
+	name, namespace := wl.GetName(), wl.GetNamespace()
+
+	// A timestamp with second granularity is needed here, because that's what the creation and deletion
+	// timestamps of the pods use.
+	start := time.Unix(time.Now().Unix(), 0)
+	w, err := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(namespace).Watch(ctx, meta.ListOptions{
+		LabelSelector: labels.SelectorFromSet(podLabels).String(),
+	})
+	if err != nil {
+		// Progress reporting is informational, so this doesn't prevent the agent from arriving.
+		dlog.Warnf(ctx, "unable to watch the pods of %s.%s: %v", name, namespace, err)
+		return
+	}
+	go func() {
+		defer w.Stop()
+		reached := make(map[string]managerrpc.AgentArrivalProgress_Stage)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case eo, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				pod, ok := eo.Object.(*core.Pod)
+				if !ok {
+					continue
+				}
+				stage := agentPodStage(pod, start)
+				if stage > reached[pod.Name] {
+					reached[pod.Name] = stage
+					s.publishAgentProgress(ctx, stage, name, namespace, pod.Name)
+				}
+			}
+		}
+	}()
+}
+
+// agentPodStage returns the last stage of agent arrival that the given pod has reached, or UNSPECIFIED
+// when the pod is unrelated to the arrival of an agent that started at the given time.
+func agentPodStage(pod *core.Pod, start time.Time) managerrpc.AgentArrivalProgress_Stage {
+	hasAgent := slices.ContainsFunc(pod.Spec.Containers, func(c core.Container) bool {
+		return c.Name == agentconfig.ContainerName
+	})
+	if dt := pod.DeletionTimestamp; dt != nil {
+		if !hasAgent && !dt.Time.Before(start) {
+			return managerrpc.AgentArrivalProgress_POD_TERMINATING
+		}
+		return managerrpc.AgentArrivalProgress_UNSPECIFIED
+	}
+	if !hasAgent || pod.CreationTimestamp.Time.Before(start) {
+		return managerrpc.AgentArrivalProgress_UNSPECIFIED
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == agentconfig.ContainerName && cs.State.Running != nil {
+			return managerrpc.AgentArrivalProgress_AGENT_STARTED
+		}
+	}
+	if pod.Spec.NodeName != "" {
+		return managerrpc.AgentArrivalProgress_POD_SCHEDULED
+	}
+	return managerrpc.AgentArrivalProgress_UNSPECIFIED
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func Test_agentPodStage(t *testing.T) {
+	start := time.Unix(time.Now().Unix(), 0)
+	before := meta.NewTime(start.Add(-time.Minute))
+	after := meta.NewTime(start.Add(time.Second))
+	appContainers := []core.Container{{Name: "echo"}}
+	agentContainers := []core.Container{{Name: "echo"}, {Name: agentconfig.ContainerName}}
+	running := core.ContainerState{Running: &core.ContainerStateRunning{}}
+	waiting := core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "ContainerCreating"}}
+
+	tests := []struct {
+		name  string
+		pod   *core.Pod
+		stage manager.AgentArrivalProgress_Stage
+	}{
+		{
+			name: "old pod terminating",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: before, DeletionTimestamp: &after},
+				Spec:       core.PodSpec{Containers: appContainers, NodeName: "node-1"},
+			},
+			stage: manager.AgentArrivalProgress_POD_TERMINATING,
+		},
+		{
+			name: "old pod terminating since before start",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: before, DeletionTimestamp: &before},
+				Spec:       core.PodSpec{Containers: appContainers},
+			},
+			stage: manager.AgentArrivalProgress_UNSPECIFIED,
+		},
+		{
+			name: "existing agent pod",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: before},
+				Spec:       core.PodSpec{Containers: agentContainers, NodeName: "node-1"},
+			},
+			stage: manager.AgentArrivalProgress_UNSPECIFIED,
+		},
+		{
+			name: "new pod pending",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: after},
+				Spec:       core.PodSpec{Containers: agentContainers},
+			},
+			stage: manager.AgentArrivalProgress_UNSPECIFIED,
+		},
+		{
+			name: "new pod scheduled",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: after},
+				Spec:       core.PodSpec{Containers: agentContainers, NodeName: "node-1"},
+				Status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{
+					{Name: agentconfig.ContainerName, State: waiting},
+				}},
+			},
+			stage: manager.AgentArrivalProgress_POD_SCHEDULED,
+		},
+		{
+			name: "agent started",
+			pod: &core.Pod{
+				ObjectMeta: meta.ObjectMeta{CreationTimestamp: after},
+				Spec:       core.PodSpec{Containers: agentContainers, NodeName: "node-1"},
+				Status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{
+					{Name: "echo", State: waiting},
+					{Name: agentconfig.ContainerName, State: running},
+				}},
+			},
+			stage: manager.AgentArrivalProgress_AGENT_STARTED,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.stage, agentPodStage(tt.pod, start))
+		})
+	}
+}
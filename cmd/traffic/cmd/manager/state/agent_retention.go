@@ -0,0 +1,69 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+// RemoveIdleAgents removes the traffic-agents of workloads that haven't been intercepted during their retention
+// period, by removing their agent configs. The retention period is the given one, unless the pod template of the
+// workload overrides it using the agentconfig.RetentionAnnotation. A retention period of zero retains the agent
+// forever. Agents that are injected because the workload enables injection using an annotation, and agents with
+// manually added configs, are never removed. The returned leases must be passed to the next call.
+func (s *state) RemoveIdleAgents(ctx context.Context, leases Leases, now time.Time, retention time.Duration) (next Leases, removed []string) {
+	next = make(Leases)
+	mm := mutator.GetMap(ctx)
+	seen := make(map[string]struct{})
+	for _, ai := range s.agents.LoadAll() {
+		name, ns := ai.Name, ai.Namespace
+		key := name + "." + ns
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if len(s.usedContainers(name, ns)) > 0 {
+			continue
+		}
+		scx, err := mm.Get(ctx, name, ns)
+		if err != nil || scx == nil {
+			continue
+		}
+		ac := scx.AgentConfig()
+		if ac.Manual {
+			continue
+		}
+		wl, err := agentmap.GetWorkload(ctx, ac.WorkloadName, ns, ac.WorkloadKind)
+		if err != nil {
+			continue
+		}
+		anns := wl.GetPodTemplate().GetAnnotations()
+		if anns[agentconfig.InjectAnnotation] == "enabled" {
+			continue
+		}
+		rp := retention
+		if a, ok := anns[agentconfig.RetentionAnnotation]; ok {
+			if rp, err = agentconfig.ParseRetention(a); err != nil {
+				dlog.Errorf(ctx, "%s.%s: %v", ac.WorkloadName, ns, err)
+				rp = retention
+			}
+		}
+		if rp == 0 || !leases.expired(next, key, now, rp) {
+			continue
+		}
+		dlog.Infof(ctx, "Removing the traffic-agent of %s.%s that hasn't been intercepted for %s", ac.WorkloadName, ns, rp)
+		if err := mm.Delete(ctx, name, ns); err != nil {
+			dlog.Errorf(ctx, "unable to remove the traffic-agent of %s.%s: %v", ac.WorkloadName, ns, err)
+			next[key] = leases[key]
+			continue
+		}
+		removed = append(removed, key)
+	}
+	slices.Sort(removed)
+	return next, removed
+}
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+// DescribeWorkload returns a description of the given workload the way that PrepareIntercept sees it. Unlike
+// PrepareIntercept, it never creates or updates the agent config of the workload. When the workload has no
+// agent config, the description contains the config that the first intercept would create.
+func (s *state) DescribeWorkload(ctx context.Context, name, namespace, workloadKind string) (*managerrpc.WorkloadDescription, error) {
+	wl, err := agentmap.GetWorkload(ctx, name, namespace, workloadKind)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	name, kind := wl.GetName(), wl.GetKind()
+	d := &managerrpc.WorkloadDescription{
+		Name:        name,
+		Namespace:   namespace,
+		Kind:        kind,
+		Annotations: telepresenceAnnotations(wl),
+	}
+	block := func(err error) {
+		d.BlockingConditions = append(d.BlockingConditions, err.Error())
+	}
+
+	switch {
+	case kind == k8sworkload.PodKind:
+		if err = checkBarePod(ctx, wl); err != nil {
+			block(err)
+		}
+	case !slices.Contains(workloadKinds(ctx), kind) && agentmap.GetWorkloadResolver(kind) == nil:
+		block(errcat.User.Newf("the traffic-manager is not configured to handle workloads of kind %s", kind))
+	}
+	if wl.Replicas() == 0 {
+		block(errcat.User.Newf("%s %s.%s has no replicas", kind, name, namespace))
+	}
+
+	injector := managerutil.AgentInjectorEnabled(ctx)
+	canGenerate := injector
+	if injector {
+		enabled, err := checkInterceptAnnotations(wl)
+		switch {
+		case err != nil:
+			block(err)
+			canGenerate = false
+		case !enabled:
+			block(errcat.User.Newf("%s %s.%s is not interceptable", kind, name, namespace))
+			canGenerate = false
+		}
+		if err = s.self.ValidateAgentImage(managerutil.GetAgentImage(ctx), false); err != nil {
+			block(err)
+			canGenerate = false
+		}
+	}
+
+	sce, err := mutator.GetMap(ctx).Get(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case sce != nil:
+		d.AgentConfigStored = true
+		if ac := sce.AgentConfig(); injector && !ac.Manual {
+			d.StaleReason = staleConfigReason(ac, wl, managerutil.GetEnv(ctx).AgentResources)
+		}
+	case !injector:
+		block(errcat.User.Newf("agent-injector is disabled and no agent has been added manually for %s.%s", name, namespace))
+	case canGenerate:
+		var gc agentmap.GeneratorConfig
+		if gc, err = agentmap.GeneratorConfigFunc(managerutil.GetAgentImage(ctx)); err != nil {
+			return nil, err
+		}
+		if sce, err = gc.Generate(ctx, wl, nil); err != nil {
+			block(err)
+		} else if err = s.self.ValidateCreateAgent(ctx, wl, sce); err != nil {
+			block(err)
+		}
+	}
+
+	if sce != nil {
+		ac := sce.AgentConfig()
+		yml, err := sce.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		d.AgentConfig = string(yml)
+		if !ac.Manual {
+			if err = agentconfig.CheckPodSecurity(ac); err != nil {
+				block(err)
+			}
+		}
+		d.Containers = describeContainers(ac)
+		if len(d.Containers) == 0 {
+			block(errcat.User.Newf("%s %s.%s has no interceptable ports", kind, name, namespace))
+		}
+	}
+
+	for _, ai := range s.getAgentsByName(name, namespace) {
+		d.AgentPods = append(d.AgentPods, ai.PodName)
+	}
+	slices.Sort(d.AgentPods)
+
+	ics := s.LoadMatchingIntercepts(func(_ string, ii *managerrpc.InterceptInfo) bool {
+		return ii.Spec.Agent == name && ii.Spec.Namespace == namespace
+	})
+	for _, ii := range ics {
+		d.Intercepts = append(d.Intercepts, ii)
+	}
+	slices.SortFunc(d.Intercepts, func(a, b *managerrpc.InterceptInfo) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return d, nil
+}
+
+// telepresenceAnnotations returns the annotations of the pod template of the given workload that control the
+// traffic-agent.
+func telepresenceAnnotations(wl k8sapi.Workload) map[string]string {
+	var as map[string]string
+	for k, v := range wl.GetPodTemplate().Annotations {
+		switch {
+		case strings.HasPrefix(k, agentconfig.DomainPrefix),
+			k == agentconfig.LegacyTerminatingTLSSecretAnnotation,
+			k == agentconfig.LegacyOriginatingTLSSecretAnnotation:
+			if as == nil {
+				as = make(map[string]string)
+			}
+			as[k] = v
+		}
+	}
+	return as
+}
+
+// describeContainers returns the containers of the given agent config that have interceptable ports.
+func describeContainers(ac *agentconfig.Sidecar) []*managerrpc.WorkloadDescription_Container {
+	var dcs []*managerrpc.WorkloadDescription_Container
+	for _, cn := range ac.Containers {
+		if len(cn.Intercepts) == 0 {
+			continue
+		}
+		dc := &managerrpc.WorkloadDescription_Container{
+			Name:    cn.Name,
+			Replace: bool(cn.Replace),
+		}
+		for _, ic := range cn.Intercepts {
+			dc.Ports = append(dc.Ports, &managerrpc.WorkloadDescription_Port{
+				ServiceName:       ic.ServiceName,
+				ServicePortName:   ic.ServicePortName,
+				ServicePort:       int32(ic.ServicePort),
+				ContainerPortName: ic.ContainerPortName,
+				ContainerPort:     int32(ic.ContainerPort),
+				Protocol:          string(ic.Protocol),
+				Headless:          ic.Headless,
+			})
+		}
+		dcs = append(dcs, dc)
+	}
+	return dcs
+}
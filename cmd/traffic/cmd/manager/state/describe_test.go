@@ -0,0 +1,57 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func Test_telepresenceAnnotations(t *testing.T) {
+	wl := k8sapi.Deployment(&apps.Deployment{
+		Spec: apps.DeploymentSpec{Template: core.PodTemplateSpec{ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{
+			agentconfig.InjectAnnotation:                     "enabled",
+			agentconfig.LegacyTerminatingTLSSecretAnnotation: "tls",
+			"prometheus.io/scrape":                           "true",
+		}}}},
+	})
+	assert.Equal(t, map[string]string{
+		agentconfig.InjectAnnotation:                     "enabled",
+		agentconfig.LegacyTerminatingTLSSecretAnnotation: "tls",
+	}, telepresenceAnnotations(wl))
+	assert.Nil(t, telepresenceAnnotations(k8sapi.Deployment(&apps.Deployment{})))
+}
+
+func Test_describeContainers(t *testing.T) {
+	ac := &agentconfig.Sidecar{Containers: []*agentconfig.Container{
+		{Name: "sidecar"},
+		{
+			Name:    "echo",
+			Replace: true,
+			Intercepts: []*agentconfig.Intercept{{
+				ServiceName:       "echo",
+				ServicePortName:   "http",
+				ServicePort:       80,
+				ContainerPortName: "http",
+				ContainerPort:     8080,
+				Protocol:          core.ProtocolTCP,
+			}},
+		},
+	}}
+	dcs := describeContainers(ac)
+	require.Len(t, dcs, 1)
+	assert.Equal(t, "echo", dcs[0].Name)
+	assert.True(t, dcs[0].Replace)
+	require.Len(t, dcs[0].Ports, 1)
+	p := dcs[0].Ports[0]
+	assert.Equal(t, "echo", p.ServiceName)
+	assert.Equal(t, int32(80), p.ServicePort)
+	assert.Equal(t, int32(8080), p.ContainerPort)
+	assert.Equal(t, "TCP", p.Protocol)
+}
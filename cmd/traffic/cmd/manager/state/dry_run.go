@@ -0,0 +1,120 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// previewAgentConfig returns the agent config that ensureAgent would use for an intercept of the given workload,
+// together with the reason why the pods of the workload would be replaced, or an empty string when they wouldn't.
+// Unlike ensureAgent, it never stores the config or waits for an agent.
+func (s *state) previewAgentConfig(
+	ctx context.Context,
+	wl k8sapi.Workload,
+	extended bool,
+	spec *managerrpc.InterceptSpec,
+) (*agentconfig.Sidecar, string, error) {
+	name, namespace, kind := wl.GetName(), wl.GetNamespace(), wl.GetKind()
+	sce, err := mutator.GetMap(ctx).Get(ctx, name, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	if !managerutil.AgentInjectorEnabled(ctx) {
+		if sce == nil {
+			return nil, "", errcat.User.Newf("agent-injector is disabled and no agent has been added manually for %s.%s", name, namespace)
+		}
+		return sce.AgentConfig(), "", nil
+	}
+	enabled, err := checkInterceptAnnotations(wl)
+	if err != nil {
+		return nil, "", err
+	}
+	if !enabled {
+		return nil, "", errcat.User.Newf("%s %s.%s is not interceptable", kind, name, namespace)
+	}
+	agentImage := managerutil.GetAgentImage(ctx)
+	if err = s.self.ValidateAgentImage(agentImage, extended); err != nil {
+		return nil, "", err
+	}
+
+	reason := ""
+	generate := func(existing agentconfig.SidecarExt) (agentconfig.SidecarExt, error) {
+		gc, err := agentmap.GeneratorConfigFunc(agentImage)
+		if err != nil {
+			return nil, err
+		}
+		return gc.Generate(ctx, wl, existing)
+	}
+	if sce == nil {
+		if sce, err = generate(nil); err != nil {
+			return nil, "", err
+		}
+		if err = s.self.ValidateCreateAgent(ctx, wl, sce); err != nil {
+			return nil, "", err
+		}
+		reason = "a traffic-agent is injected into its pods"
+	} else if ac := sce.AgentConfig(); !ac.Manual {
+		if stale := staleConfigReason(ac, wl, managerutil.GetEnv(ctx).AgentResources); stale != "" {
+			if sce, err = generate(sce); err != nil {
+				return nil, "", err
+			}
+			reason = "its agent config is regenerated: " + stale
+		} else if ac.AgentImage != agentImage && extended {
+			reason = "its traffic-agent image changes to " + agentImage
+		}
+	}
+
+	ac := sce.AgentConfig()
+	cn, _, err := findIntercept(ac, spec)
+	if err != nil {
+		return nil, "", err
+	}
+	if reason == "" && bool(cn.Replace) != spec.Replace {
+		if spec.Replace {
+			reason = fmt.Sprintf("the traffic-agent replaces container %s", cn.Name)
+		} else {
+			reason = fmt.Sprintf("container %s is restored, because it is no longer replaced by the traffic-agent", cn.Name)
+		}
+	}
+	if !ac.Manual {
+		if err = agentconfig.CheckPodSecurity(ac); err != nil {
+			return nil, "", errcat.User.New(err)
+		}
+	}
+	return ac, reason, nil
+}
+
+// interceptConflicts returns the current intercepts of the given workload that intercept the same container port
+// as the given prepared intercept. The agent only serves one of them, so they prevent the intercept from becoming
+// active.
+func (s *state) interceptConflicts(pi *managerrpc.PreparedIntercept) []*managerrpc.InterceptInfo {
+	ics := s.LoadMatchingIntercepts(func(_ string, ii *managerrpc.InterceptInfo) bool {
+		spec := ii.Spec
+		return ii.Disposition != managerrpc.InterceptDispositionType_REMOVED &&
+			spec.Agent == pi.WorkloadName &&
+			spec.Namespace == pi.Namespace &&
+			spec.ContainerPort == pi.ContainerPort &&
+			spec.Protocol == pi.Protocol
+	})
+	if len(ics) == 0 {
+		return nil
+	}
+	conflicts := make([]*managerrpc.InterceptInfo, 0, len(ics))
+	for _, ii := range ics {
+		conflicts = append(conflicts, ii)
+	}
+	slices.SortFunc(conflicts, func(a, b *managerrpc.InterceptInfo) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return conflicts
+}
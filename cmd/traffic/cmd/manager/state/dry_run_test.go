@@ -0,0 +1,46 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestInterceptConflicts(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := NewState(ctx).(*state)
+
+	add := func(id, workload, namespace string, port int32, protocol string, disposition manager.InterceptDispositionType) {
+		s.intercepts.Store(id, &manager.InterceptInfo{
+			Id: id,
+			Spec: &manager.InterceptSpec{
+				Name:          id,
+				Agent:         workload,
+				Namespace:     namespace,
+				ContainerPort: port,
+				Protocol:      protocol,
+			},
+			Disposition: disposition,
+		})
+	}
+	add("b", "echo", "default", 8080, "TCP", manager.InterceptDispositionType_ACTIVE)
+	add("a", "echo", "default", 8080, "TCP", manager.InterceptDispositionType_WAITING)
+	add("removed", "echo", "default", 8080, "TCP", manager.InterceptDispositionType_REMOVED)
+	add("other-port", "echo", "default", 9090, "TCP", manager.InterceptDispositionType_ACTIVE)
+	add("other-protocol", "echo", "default", 8080, "UDP", manager.InterceptDispositionType_ACTIVE)
+	add("other-namespace", "echo", "staging", 8080, "TCP", manager.InterceptDispositionType_ACTIVE)
+	add("other-workload", "web", "default", 8080, "TCP", manager.InterceptDispositionType_ACTIVE)
+
+	pi := &manager.PreparedIntercept{WorkloadName: "echo", Namespace: "default", ContainerPort: 8080, Protocol: "TCP"}
+	var ids []string
+	for _, ii := range s.interceptConflicts(pi) {
+		ids = append(ids, ii.Id)
+	}
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	pi.ContainerPort = 7070
+	assert.Nil(t, s.interceptConflicts(pi))
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// Evict forcibly removes the intercepts that match the namespace, workload, and user of the given request. When
+// the request has neither a namespace nor a workload, the sessions of the user are removed instead, together with
+// their intercepts. Nothing is removed when the request has no filter at all.
+func (s *state) Evict(ctx context.Context, rq *rpc.EvictRequest) *rpc.ReapResult {
+	result := &rpc.ReapResult{}
+	if rq.Namespace == "" && rq.Workload == "" {
+		if rq.User == "" {
+			return result
+		}
+		clients := s.GetAllClients()
+		for id, client := range clients {
+			if isClientOfUser(client.Name, rq.User) {
+				result.SessionIds = append(result.SessionIds, id)
+			}
+		}
+		slices.Sort(result.SessionIds)
+		for _, id := range result.SessionIds {
+			result.InterceptIds = append(result.InterceptIds, s.sessionInterceptIDs(id)...)
+			dlog.Infof(ctx, "Evicting session %s of client %s", id, clients[id].Name)
+			s.RemoveSession(ctx, id)
+		}
+		return result
+	}
+
+	for id, ii := range s.intercepts.LoadAll() {
+		spec := ii.Spec
+		if ii.Disposition == rpc.InterceptDispositionType_REMOVED ||
+			rq.Namespace != "" && spec.Namespace != rq.Namespace ||
+			rq.Workload != "" && spec.Agent != rq.Workload ||
+			rq.User != "" && !isClientOfUser(spec.Client, rq.User) {
+			continue
+		}
+		result.InterceptIds = append(result.InterceptIds, id)
+	}
+	slices.Sort(result.InterceptIds)
+	for _, id := range result.InterceptIds {
+		dlog.Infof(ctx, "Evicting intercept %s", id)
+		s.self.RemoveIntercept(ctx, id)
+	}
+	return result
+}
+
+// isClientOfUser returns true if the given client name, which has the form "user@hostname", is equal to the given
+// user, or if its user part is.
+func isClientOfUser(clientName, user string) bool {
+	if clientName == user {
+		return true
+	}
+	u, _, _ := strings.Cut(clientName, "@")
+	return u == user
+}
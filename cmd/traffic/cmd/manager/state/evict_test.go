@@ -0,0 +1,52 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestEvict(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	clock := &FakeClock{}
+	s := NewState(ctx).(*state)
+
+	alice := s.AddClient(&manager.ClientInfo{Name: "alice@laptop", InstallId: "1234"}, clock.Now())
+	bob := s.AddClient(&manager.ClientInfo{Name: "bob@desktop", InstallId: "5678"}, clock.Now())
+	add := func(sessionID, client, workload, namespace string) {
+		id := sessionID + ":" + workload + "-" + namespace
+		s.intercepts.Store(id, &manager.InterceptInfo{
+			Id:            id,
+			Spec:          &manager.InterceptSpec{Name: workload + "-" + namespace, Client: client, Agent: workload, Namespace: namespace},
+			ClientSession: &manager.SessionInfo{SessionId: sessionID},
+			Disposition:   manager.InterceptDispositionType_ACTIVE,
+		})
+	}
+	add(alice, "alice@laptop", "echo", "default")
+	add(alice, "alice@laptop", "echo", "staging")
+	add(bob, "bob@desktop", "echo", "default")
+	add(bob, "bob@desktop", "web", "default")
+
+	assert.Empty(t, s.Evict(ctx, &manager.EvictRequest{}).InterceptIds)
+
+	result := s.Evict(ctx, &manager.EvictRequest{Workload: "echo", User: "bob"})
+	assert.Empty(t, result.SessionIds)
+	assert.Equal(t, []string{bob + ":echo-default"}, result.InterceptIds)
+
+	result = s.Evict(ctx, &manager.EvictRequest{Namespace: "staging"})
+	assert.Equal(t, []string{alice + ":echo-staging"}, result.InterceptIds)
+	assert.NotNil(t, s.GetClient(alice))
+
+	result = s.Evict(ctx, &manager.EvictRequest{User: "alice@laptop"})
+	assert.Equal(t, []string{alice}, result.SessionIds)
+	assert.Equal(t, []string{alice + ":echo-default"}, result.InterceptIds)
+	assert.Nil(t, s.GetClient(alice))
+
+	result = s.Evict(ctx, &manager.EvictRequest{User: "bob"})
+	assert.Equal(t, []string{bob}, result.SessionIds)
+	assert.Equal(t, []string{bob + ":web-default"}, result.InterceptIds)
+	assert.Empty(t, s.intercepts.LoadAll())
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+const (
+	handoverMessage = "Handing over to a new traffic-agent"
+	awaitingMessage = "Waiting for a replacement traffic-agent"
+)
+
+// isDraining returns true if the given session is an agent session that has been superseded by a
+// newer agent for the same workload.
+func (s *state) isDraining(sessionID string) bool {
+	if as, ok := s.GetSession(sessionID).(*agentSessionState); ok {
+		return as.Draining()
+	}
+	return false
+}
+
+// supersedeAgents is called when the agent with the given sessionID arrives. All other agents for the
+// same workload that aren't compatible with the new agent, typically because the agent image was
+// upgraded, are marked as draining. A draining agent continues to serve the connections that it
+// already has, but it will no longer be considered when the manager checks the agents of an
+// intercept, and it can no longer review intercepts. Intercepts that were routed by a draining agent
+// are sent back to the WAITING state so that the new agent can adopt them before the old one
+// terminates.
+func (s *state) supersedeAgents(sessionID string, agent *rpc.AgentInfo) {
+	agn, ok := s.agentsByName.Load(agent.Name)
+	if !ok {
+		return
+	}
+	var drained []string
+	agn.Range(func(id string, old *rpc.AgentInfo) bool {
+		if id == sessionID || old.Namespace != agent.Namespace || s.isDraining(id) {
+			return true
+		}
+		if managerutil.AgentsAreCompatible([]*rpc.AgentInfo{old, agent}) {
+			return true
+		}
+		if as, ok := s.GetSession(id).(*agentSessionState); ok {
+			as.draining.Store(true)
+			drained = append(drained, old.PodIp)
+			dlog.Infof(s.backgroundCtx, "Agent %s.%s in pod %s is superseded by agent in pod %s",
+				old.Name, old.Namespace, old.PodName, agent.PodName)
+		}
+		return true
+	})
+	if len(drained) == 0 {
+		return
+	}
+	for interceptID, intercept := range s.intercepts.LoadAll() {
+		if intercept.Disposition != rpc.InterceptDispositionType_ACTIVE ||
+			intercept.Spec.Agent != agent.Name || intercept.Spec.Namespace != agent.Namespace {
+			continue
+		}
+		for _, podIP := range drained {
+			if intercept.PodIp == podIP {
+				intercept.Disposition = rpc.InterceptDispositionType_WAITING
+				intercept.Message = handoverMessage
+				s.intercepts.Store(interceptID, intercept)
+				break
+			}
+		}
+	}
+}
+
+// awaitHandover keeps an intercept that lost its last agent in the WAITING state for the duration of the
+// agent arrival timeout, so that an agent in a pod that replaces the departed one can adopt it. The intercept
+// transitions to an error state if no such agent arrives in time. The function returns false if the timeout
+// is zero, in which case the caller must transition the intercept immediately.
+func (s *state) awaitHandover(ctx context.Context, interceptID string, intercept *rpc.InterceptInfo) bool {
+	timeout := managerutil.GetEnv(ctx).AgentArrivalTimeout
+	if timeout <= 0 {
+		return false
+	}
+	intercept.Disposition = rpc.InterceptDispositionType_WAITING
+	intercept.Message = awaitingMessage
+	s.intercepts.Store(interceptID, intercept)
+	go func() {
+		select {
+		case <-s.backgroundCtx.Done():
+		case <-time.After(timeout):
+			intercept, ok := s.intercepts.Load(interceptID)
+			if !ok || intercept.Disposition != rpc.InterceptDispositionType_WAITING {
+				return
+			}
+			if errCode, errMsg := s.checkAgentsForIntercept(intercept); errCode != 0 {
+				dlog.Infof(s.backgroundCtx, "No traffic-agent adopted intercept %s within %s", interceptID, timeout)
+				s.UpdateIntercept(interceptID, func(intercept *rpc.InterceptInfo) {
+					if intercept.Disposition == rpc.InterceptDispositionType_WAITING {
+						intercept.Disposition = errCode
+						intercept.Message = errMsg
+					}
+				})
+			}
+		}
+	}()
+	return true
+}
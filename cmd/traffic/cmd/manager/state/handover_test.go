@@ -0,0 +1,104 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func handoverAgent(pod, ip, version string) *manager.AgentInfo {
+	return &manager.AgentInfo{
+		Name:      "echo",
+		Namespace: "default",
+		PodName:   pod,
+		PodIp:     ip,
+		Product:   "telepresence",
+		Version:   version,
+		Mechanisms: []*manager.AgentInfo_Mechanism{
+			{Name: "tcp", Product: "telepresence", Version: version},
+		},
+	}
+}
+
+func handoverState(t *testing.T, timeout time.Duration) (context.Context, *state, string) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{AgentArrivalTimeout: timeout})
+	s := NewState(ctx).(*state)
+	client := s.AddClient(&manager.ClientInfo{Name: "alice", InstallId: "1234"}, time.Now())
+	id := client + ":echo"
+	s.intercepts.Store(id, &manager.InterceptInfo{
+		Id:            id,
+		Spec:          &manager.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default", Mechanism: "tcp"},
+		ClientSession: &manager.SessionInfo{SessionId: client},
+		Disposition:   manager.InterceptDispositionType_ACTIVE,
+		PodIp:         "10.0.0.1",
+	})
+	s.interceptStates.Store(id, newInterceptState(id))
+	return ctx, s, id
+}
+
+func TestSupersedeAgents(t *testing.T) {
+	ctx, s, id := handoverState(t, 0)
+	old := s.AddAgent(handoverAgent("echo-1", "10.0.0.1", "2.20.0"), time.Now())
+
+	// An agent with the same version is just another replica.
+	replica := s.AddAgent(handoverAgent("echo-2", "10.0.0.2", "2.20.0"), time.Now())
+	ii, _ := s.GetIntercept(id)
+	assert.Equal(t, manager.InterceptDispositionType_ACTIVE, ii.Disposition)
+	assert.NotNil(t, s.GetActiveAgent(replica))
+	s.RemoveSession(ctx, replica)
+
+	// An upgraded agent supersedes the old one and gets to adopt the intercept.
+	upgraded := s.AddAgent(handoverAgent("echo-3", "10.0.0.3", "2.21.0"), time.Now())
+	ii, _ = s.GetIntercept(id)
+	assert.Equal(t, manager.InterceptDispositionType_WAITING, ii.Disposition)
+	assert.Equal(t, handoverMessage, ii.Message)
+	assert.Nil(t, s.GetActiveAgent(old))
+	assert.NotNil(t, s.GetActiveAgent(upgraded))
+
+	// The old agent terminating doesn't affect the intercept.
+	s.RemoveSession(ctx, old)
+	ii, _ = s.GetIntercept(id)
+	assert.Equal(t, manager.InterceptDispositionType_WAITING, ii.Disposition)
+}
+
+func TestAwaitHandover(t *testing.T) {
+	t.Run("adopted", func(t *testing.T) {
+		ctx, s, id := handoverState(t, 100*time.Millisecond)
+		old := s.AddAgent(handoverAgent("echo-1", "10.0.0.1", "2.20.0"), time.Now())
+		s.RemoveSession(ctx, old)
+		ii, _ := s.GetIntercept(id)
+		assert.Equal(t, manager.InterceptDispositionType_WAITING, ii.Disposition)
+		assert.Equal(t, awaitingMessage, ii.Message)
+
+		s.AddAgent(handoverAgent("echo-2", "10.0.0.2", "2.20.0"), time.Now())
+		time.Sleep(200 * time.Millisecond)
+		ii, _ = s.GetIntercept(id)
+		assert.Equal(t, manager.InterceptDispositionType_WAITING, ii.Disposition)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, s, id := handoverState(t, 100*time.Millisecond)
+		old := s.AddAgent(handoverAgent("echo-1", "10.0.0.1", "2.20.0"), time.Now())
+		s.RemoveSession(ctx, old)
+		require.Eventually(t, func() bool {
+			ii, _ := s.GetIntercept(id)
+			return ii.Disposition == manager.InterceptDispositionType_NO_AGENT
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx, s, id := handoverState(t, 0)
+		old := s.AddAgent(handoverAgent("echo-1", "10.0.0.1", "2.20.0"), time.Now())
+		s.RemoveSession(ctx, old)
+		ii, _ := s.GetIntercept(id)
+		assert.Equal(t, manager.InterceptDispositionType_NO_AGENT, ii.Disposition)
+	})
+}
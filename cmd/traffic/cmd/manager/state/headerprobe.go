@@ -0,0 +1,309 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+)
+
+const (
+	// headerProbeArmTimeout is the max time to wait for the agents to arm a header probe.
+	headerProbeArmTimeout = 2 * time.Second
+
+	// headerProbeGracePeriod is the time to wait for reports after the probe request has completed.
+	headerProbeGracePeriod = time.Second
+)
+
+// VerifyHeaderPropagation arms a header probe in the agents of the given hops, sends a probe
+// request with the header to the URL of the request, and then waits for the agents to report
+// that they observed the header. The request must have a header, a URL, and a timeout.
+//
+// The URL is given by a client, so the probe request is only sent when the URL's host resolves to
+// the pods of the hops, or to the services that select them. Redirects aren't followed.
+func (s *state) VerifyHeaderPropagation(ctx context.Context, namespace string, hops []string, request *rpc.HeaderPropagationRequest) (*rpc.HeaderPropagationResult, error) {
+	timeout := request.Timeout.AsDuration()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if u, err := url.Parse(request.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid probe URL %q", request.Url)
+	}
+	targets, err := s.probeTargets(ctx, namespace, hops)
+	if err != nil {
+		return nil, err
+	}
+	probeClient := newProbeClient(targets, timeout)
+
+	id := uuid.NewString()
+	probe := &rpc.HeaderProbe{
+		Id:      id,
+		Header:  request.Header,
+		Value:   "probe-" + id,
+		Timeout: durationpb.New(timeout),
+	}
+
+	// Find the agents of each hop.
+	hopAgents := make(map[string]string)
+	hopErrors := make(map[string]string)
+	for _, hop := range hops {
+		if as, ok := s.agentsByName.Load(hop); ok {
+			as.Range(func(sessionID string, ai *rpc.AgentInfo) bool {
+				if ai.Namespace == namespace {
+					hopAgents[sessionID] = hop
+				}
+				return true
+			})
+		}
+		if !hasHop(hopAgents, hop) {
+			hopErrors[hop] = "no traffic-agent is installed in the workload"
+		}
+	}
+
+	reports := make(chan *rpc.HeaderProbeReport, 2*len(hopAgents))
+	s.headerProbeReports.Store(id, reports)
+	defer s.headerProbeReports.Delete(id)
+	for sessionID := range hopAgents {
+		go s.sendHeaderProbe(ctx, sessionID, probe)
+	}
+
+	armed := make(map[string]bool)
+	observed := make(map[string]bool)
+	record := func(r *rpc.HeaderProbeReport) {
+		if hop, ok := hopAgents[r.GetSession().GetSessionId()]; ok {
+			if r.Observed {
+				observed[hop] = true
+			} else {
+				armed[hop] = true
+			}
+		}
+	}
+
+	// Wait for the agents to arm the probe. Agents that don't support header probes will never respond.
+	armCtx, armCancel := context.WithTimeout(ctx, headerProbeArmTimeout)
+	for pending := len(hopAgents); pending > 0; {
+		select {
+		case <-armCtx.Done():
+			pending = 0
+		case r := <-reports:
+			record(r)
+			if !r.Observed {
+				pending--
+			}
+		}
+	}
+	armCancel()
+	if len(armed) == 0 {
+		return headerPropagationResult(request.Header, hops, hopErrors, armed, observed), nil
+	}
+
+	probeDone := make(chan error, 1)
+	go func() {
+		probeDone <- sendProbeRequest(ctx, probeClient, request.Url, probe.Header, probe.Value)
+	}()
+
+	var probeErr error
+	var grace <-chan time.Time
+waitLoop:
+	for len(observed) < len(armed) {
+		select {
+		case <-ctx.Done():
+			break waitLoop
+		case <-grace:
+			break waitLoop
+		case probeErr = <-probeDone:
+			if probeErr != nil {
+				break waitLoop
+			}
+			grace = time.After(headerProbeGracePeriod)
+		case r := <-reports:
+			record(r)
+		}
+	}
+
+	// Collect reports that arrived while the loop ended.
+	for len(reports) > 0 {
+		record(<-reports)
+	}
+	result := headerPropagationResult(request.Header, hops, hopErrors, armed, observed)
+	if probeErr != nil {
+		result.ProbeError = probeErr.Error()
+	}
+	return result, nil
+}
+
+// PostHeaderProbeReport receives a header probe report from an agent and places it in the channel
+// that corresponds to the probe.
+func (s *state) PostHeaderProbeReport(ctx context.Context, report *rpc.HeaderProbeReport) {
+	if rch, ok := s.headerProbeReports.Load(report.Id); ok {
+		select {
+		case rch <- report:
+			return
+		default:
+		}
+	}
+	dlog.Debugf(ctx, "attempted to post header probe report failed because there was no recipient. ID=%s", report.Id)
+}
+
+func (s *state) WatchHeaderProbes(agentSessionID string) <-chan *rpc.HeaderProbe {
+	if as, ok := s.GetSession(agentSessionID).(*agentSessionState); ok {
+		return as.headerProbes
+	}
+	return nil
+}
+
+func (s *state) sendHeaderProbe(ctx context.Context, agentSessionID string, probe *rpc.HeaderProbe) {
+	as, ok := s.GetSession(agentSessionID).(*agentSessionState)
+	if !ok {
+		return
+	}
+	// the as.headerProbes channel may be closed at this point, so guard for panic
+	defer func() {
+		_ = recover()
+	}()
+	select {
+	case <-ctx.Done():
+	case as.headerProbes <- probe:
+	}
+}
+
+// probeTargets returns the addresses that a probe request may be sent to, which are the IPs of the pods
+// that have agents in the given hops, and the cluster IPs of the services that select those workloads.
+func (s *state) probeTargets(ctx context.Context, namespace string, hops []string) (map[netip.Addr]struct{}, error) {
+	targets := make(map[netip.Addr]struct{})
+	for _, hop := range hops {
+		if as, ok := s.agentsByName.Load(hop); ok {
+			as.Range(func(_ string, ai *rpc.AgentInfo) bool {
+				if ai.Namespace == namespace {
+					if ip, err := netip.ParseAddr(ai.PodIp); err == nil {
+						targets[ip] = struct{}{}
+					}
+				}
+				return true
+			})
+		}
+		wl, err := agentmap.GetWorkload(ctx, hop, namespace, "")
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		pod := wl.GetPodTemplate().DeepCopy()
+		pod.Namespace = namespace
+		if len(pod.Labels) == 0 {
+			continue
+		}
+		svcs, err := agentmap.FindServicesForPod(ctx, pod, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, so := range svcs {
+			if svc, ok := k8sapi.ServiceImpl(so); ok {
+				for _, cip := range svc.Spec.ClusterIPs {
+					if ip, err := netip.ParseAddr(cip); err == nil {
+						targets[ip] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// newProbeClient returns a client that only connects to the given addresses, that doesn't use proxies or follow
+// redirects, and that gives up after the given timeout.
+func newProbeClient(targets map[netip.Addr]struct{}, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			Proxy:             nil,
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+				if err != nil {
+					return nil, err
+				}
+				for _, ip := range ips {
+					if _, ok := targets[ip.Unmap()]; ok {
+						// Dial the verified IP, so that a second lookup can't yield another one.
+						return dialer.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+					}
+				}
+				return nil, fmt.Errorf("%s doesn't resolve to a pod or service of the workloads in the chain", host)
+			},
+		},
+	}
+}
+
+func sendProbeRequest(ctx context.Context, client *http.Client, url, header, value string) error {
+	rq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	rq.Header.Set(header, value)
+	rs, err := client.Do(rq)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, rs.Body)
+	return rs.Body.Close()
+}
+
+func hasHop(hopAgents map[string]string, hop string) bool {
+	for _, h := range hopAgents {
+		if h == hop {
+			return true
+		}
+	}
+	return false
+}
+
+// headerPropagationResult creates the result of a header probe. The header was dropped by the hop
+// that precedes the first probed hop that didn't receive it.
+func headerPropagationResult(header string, hops []string, hopErrors map[string]string, armed, observed map[string]bool) *rpc.HeaderPropagationResult {
+	result := &rpc.HeaderPropagationResult{
+		Header: header,
+		Hops:   make([]*rpc.HeaderPropagationHop, len(hops)),
+	}
+	dropped := false
+	for i, w := range hops {
+		hop := &rpc.HeaderPropagationHop{Workload: w, Received: observed[w]}
+		switch {
+		case hopErrors[w] != "":
+			hop.Error = hopErrors[w]
+		case !hop.Received && !armed[w]:
+			hop.Error = "the traffic-agent doesn't support header probes"
+		case !hop.Received && !dropped:
+			dropped = true
+			if i > 0 {
+				result.DroppedBy = hops[i-1]
+			}
+		}
+		result.Hops[i] = hop
+	}
+	return result
+}
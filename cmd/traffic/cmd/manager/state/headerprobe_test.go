@@ -0,0 +1,65 @@
+package state
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestHeaderPropagationResult(t *testing.T) {
+	hops := []string{"frontend", "legacy", "orders", "greeter"}
+	hopErrors := map[string]string{"legacy": "no traffic-agent is installed in the workload"}
+	armed := map[string]bool{"frontend": true, "orders": true, "greeter": true}
+
+	// The header reaches all probed hops
+	result := headerPropagationResult("x-id", hops, hopErrors, armed, map[string]bool{"frontend": true, "orders": true, "greeter": true})
+	assert.Empty(t, result.DroppedBy)
+	assert.Equal(t, []*rpc.HeaderPropagationHop{
+		{Workload: "frontend", Received: true},
+		{Workload: "legacy", Error: "no traffic-agent is installed in the workload"},
+		{Workload: "orders", Received: true},
+		{Workload: "greeter", Received: true},
+	}, result.Hops)
+
+	// The header is dropped before it reaches orders
+	result = headerPropagationResult("x-id", hops, hopErrors, armed, map[string]bool{"frontend": true})
+	assert.Equal(t, "legacy", result.DroppedBy)
+	assert.False(t, result.Hops[2].Received)
+	assert.False(t, result.Hops[3].Received)
+
+	// The probe request never reaches the first hop
+	result = headerPropagationResult("x-id", hops, hopErrors, armed, map[string]bool{})
+	assert.Empty(t, result.DroppedBy)
+
+	// An agent that didn't arm the probe is reported as an error
+	result = headerPropagationResult("x-id", hops, hopErrors, map[string]bool{"frontend": true, "greeter": true}, map[string]bool{"frontend": true})
+	assert.Equal(t, "the traffic-agent doesn't support header probes", result.Hops[2].Error)
+	assert.Equal(t, "orders", result.DroppedBy)
+}
+
+func TestProbeClient(t *testing.T) {
+	ctx := context.Background()
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("x-id")
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	// The server isn't a target
+	client := newProbeClient(map[netip.Addr]struct{}{netip.MustParseAddr("10.1.2.3"): {}}, time.Second)
+	assert.ErrorContains(t, sendProbeRequest(ctx, client, srv.URL, "x-id", "probe-1"), "doesn't resolve to a pod or service")
+	assert.Empty(t, got)
+
+	// The server is a target, and its redirect isn't followed
+	client = newProbeClient(map[netip.Addr]struct{}{netip.MustParseAddr("127.0.0.1"): {}}, time.Second)
+	assert.NoError(t, sendProbeRequest(ctx, client, srv.URL, "x-id", "probe-2"))
+	assert.Equal(t, "probe-2", got)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	events "k8s.io/api/events/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -30,6 +32,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 )
 
@@ -43,6 +46,10 @@ import (
 //
 // The second step matches all PortIdentifiers in the request to the intercepts of the agent config.
 //
+// A dry run request performs the same steps without storing the agent config or waiting for an agent, and
+// the returned PreparedIntercept also tells if the pods of the workload would be replaced, and what current
+// intercepts that would conflict with the intercept.
+//
 // It's expected that the client that makes the call will update any unqualified port identifiers
 // with the ones in the returned PreparedIntercept.
 func (s *state) PrepareIntercept(
@@ -64,7 +71,10 @@ func (s *state) PrepareIntercept(
 			return nil, err
 		}
 		dlog.Errorf(ctx, "PrepareIntercept error %v", err)
-		return &managerrpc.PreparedIntercept{Error: err.Error(), ErrorCategory: int32(errcat.GetCategory(err))}, nil
+		return &managerrpc.PreparedIntercept{
+			Error:         err.Error(),
+			ErrorCategory: int32(errcat.GetCategory(err)),
+		}, nil
 	}
 
 	spec := cr.InterceptSpec
@@ -72,12 +82,34 @@ func (s *state) PrepareIntercept(
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			err = errcat.User.New(err)
+			if spec.WorkloadKind == "" {
+				wl, err = workloadForService(ctx, spec, err)
+			}
+		}
+		if err != nil {
+			dlog.Error(ctx, err)
+			return interceptError(err)
+		}
+	}
+	if spec.WorkloadKind == k8sworkload.PodKind {
+		if err = checkBarePod(ctx, wl); err != nil {
+			return interceptError(err)
+		}
+	}
+	var rs *apps.ReplicaSet
+	if spec.ReplicaSet != "" || spec.Revision != 0 {
+		if rs, err = findReplicaSet(ctx, wl, spec.ReplicaSet, spec.Revision); err != nil {
+			return interceptError(err)
 		}
-		dlog.Error(ctx, err)
-		return interceptError(err)
 	}
 
-	ac, err := s.ensureAgent(ctx, wl, s.isExtended(spec), spec)
+	var ac *agentconfig.Sidecar
+	restartReason := ""
+	if cr.DryRun {
+		ac, restartReason, err = s.previewAgentConfig(ctx, wl, s.isExtended(spec), spec)
+	} else {
+		ac, err = s.ensureAgent(ctx, wl, s.isExtended(spec), spec)
+	}
 	if err != nil {
 		return interceptError(err)
 	}
@@ -85,7 +117,7 @@ func (s *state) PrepareIntercept(
 	if err != nil {
 		return interceptError(err)
 	}
-	return &managerrpc.PreparedIntercept{
+	pi = &managerrpc.PreparedIntercept{
 		Namespace:       ac.Namespace,
 		ServiceUid:      string(ic.ServiceUID),
 		ServiceName:     ic.ServiceName,
@@ -94,9 +126,151 @@ func (s *state) PrepareIntercept(
 		Protocol:        string(ic.Protocol),
 		ContainerPort:   int32(ic.ContainerPort),
 		ServicePort:     int32(ic.ServicePort),
-		AgentImage:      ac.AgentImage,
+		AgentImage:      managerutil.PinAgentImage(ctx, ac.AgentImage, ""),
 		WorkloadKind:    ac.WorkloadKind,
-	}, nil
+		WorkloadName:    ac.WorkloadName,
+	}
+	if rs != nil {
+		pi.ReplicaSet = rs.Name
+		pi.Revision = k8sworkload.Revision(rs)
+		pi.PodTemplateHash = k8sworkload.PodTemplateHash(rs)
+	}
+	if cr.DryRun {
+		pi.PodRestartReason = restartReason
+		pi.Conflicts = s.interceptConflicts(pi)
+	}
+	return pi, nil
+}
+
+// maxExternalNameHops is the max number of ExternalName services that workloadForService follows.
+const maxExternalNameHops = 5
+
+// workloadForService is called when there's no workload named by the agent of the given spec. It returns the
+// workload that is selected by a service with that name, and also makes the spec use that service. An
+// ExternalName service is followed when it refers to a service in the same namespace. The given notFound
+// error is returned when there's no such service.
+func workloadForService(ctx context.Context, spec *managerrpc.InterceptSpec, notFound error) (k8sapi.Workload, error) {
+	name, ns := spec.Agent, spec.Namespace
+	var svc *core.Service
+	for hops := 0; ; hops++ {
+		var err error
+		if svc, err = agentmap.GetService(ctx, name, ns); err != nil {
+			if k8sErrors.IsNotFound(err) {
+				if hops == 0 {
+					return nil, notFound
+				}
+				err = errcat.User.New(err)
+			}
+			return nil, err
+		}
+		if svc.Spec.Type != core.ServiceTypeExternalName {
+			break
+		}
+		en := svc.Spec.ExternalName
+		tn, tns, ok := agentmap.ExternalNameService(en)
+		switch {
+		case !ok:
+			return nil, errcat.User.Newf(
+				"service %s.%s is of type ExternalName and refers to %s, which is outside the cluster, so there is no workload to intercept",
+				name, ns, en)
+		case tns != ns:
+			return nil, errcat.User.Newf(
+				"service %s.%s is of type ExternalName and refers to service %s in namespace %s.\n"+
+					"Please intercept that service by connecting to namespace %s.", name, ns, tn, tns, tns)
+		case hops == maxExternalNameHops:
+			return nil, errcat.User.Newf("service %s.%s is of type ExternalName and refers to too many other ExternalName services",
+				spec.Agent, ns)
+		}
+		dlog.Debugf(ctx, "Service %s.%s is of type ExternalName and refers to service %s.%s", name, ns, tn, tns)
+		name = tn
+	}
+
+	if len(svc.Spec.Selector) == 0 {
+		return nil, errcat.User.Newf("service %s.%s has no selector, so there is no workload to intercept", name, ns)
+	}
+	wls, err := agentmap.FindWorkloadsForService(ctx, svc, workloadKinds(ctx))
+	if err != nil {
+		return nil, err
+	}
+	switch len(wls) {
+	case 0:
+		return nil, errcat.User.Newf("service %s.%s selects no workload that can be intercepted", name, ns)
+	case 1:
+	default:
+		sb := strings.Builder{}
+		for _, wl := range wls {
+			fmt.Fprintf(&sb, "\n  %s %s", wl.GetKind(), wl.GetName())
+		}
+		return nil, errcat.User.Newf("service %s.%s selects multiple workloads:%s\n"+
+			"Please specify the workload you want to intercept by passing the --workload=<name> flag.", name, ns, sb.String())
+	}
+	wl := wls[0]
+	if spec.ServiceName == "" {
+		spec.ServiceName = name
+	}
+	dlog.Debugf(ctx, "Service %s.%s selects %s %s", name, ns, wl.GetKind(), wl.GetName())
+	return wl, nil
+}
+
+// checkBarePod returns an error if the given pod is controlled by a workload that can be intercepted, because
+// the agent must then be injected using that workload.
+func checkBarePod(ctx context.Context, wl k8sapi.Workload) error {
+	wks := workloadKinds(ctx)
+	for _, or := range wl.GetOwnerReferences() {
+		if or.Controller != nil && *or.Controller && slices.Contains(wks, or.Kind) {
+			return errcat.User.Newf("pod %s.%s is controlled by %s %s. Please intercept the %s instead",
+				wl.GetName(), wl.GetNamespace(), or.Kind, or.Name, or.Kind)
+		}
+	}
+	return nil
+}
+
+// findReplicaSet returns the ReplicaSet of the given Deployment or Rollout that has the given name and/or revision.
+func findReplicaSet(ctx context.Context, wl k8sapi.Workload, name string, revision int64) (*apps.ReplicaSet, error) {
+	kind := wl.GetKind()
+	if kind != "Deployment" && kind != "Rollout" {
+		return nil, errcat.User.Newf("a replica set or revision can only be selected for a Deployment or a Rollout, not for %s %s.%s",
+			kind, wl.GetName(), wl.GetNamespace())
+	}
+	rss, err := k8sworkload.OwnedReplicaSets(ctx, wl)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range rss {
+		if (name == "" || rs.Name == name) && (revision == 0 || k8sworkload.Revision(rs) == revision) {
+			switch {
+			case k8sworkload.PodTemplateHash(rs) == "":
+				return nil, errcat.User.Newf("replica set %s.%s has no pod-template-hash label", rs.Name, rs.Namespace)
+			case rs.Status.Replicas == 0:
+				return nil, errcat.User.Newf("replica set %s.%s (revision %d) has no pods", rs.Name, rs.Namespace, k8sworkload.Revision(rs))
+			}
+			return rs, nil
+		}
+	}
+	var what string
+	switch {
+	case name == "":
+		what = fmt.Sprintf("revision %d", revision)
+	case revision == 0:
+		what = fmt.Sprintf("name %s", name)
+	default:
+		what = fmt.Sprintf("name %s and revision %d", name, revision)
+	}
+	avail := make([]string, len(rss))
+	for i, rs := range rss {
+		avail[i] = fmt.Sprintf("%s (revision %d)", rs.Name, k8sworkload.Revision(rs))
+	}
+	return nil, errcat.User.Newf("%s %s.%s has no replica set with %s. Available replica sets are: %s",
+		kind, wl.GetName(), wl.GetNamespace(), what, strings.Join(avail, ", "))
+}
+
+func workloadKinds(ctx context.Context) []string {
+	ewks := managerutil.GetEnv(ctx).EnabledWorkloadKinds
+	wks := make([]string, len(ewks))
+	for i, wk := range ewks {
+		wks[i] = string(wk)
+	}
+	return wks
 }
 
 func (s *state) EnsureAgent(ctx context.Context, n, ns string) error {
@@ -133,6 +307,7 @@ func (s *state) ensureAgent(parentCtx context.Context, wl k8sapi.Workload, exten
 	if err != nil {
 		return nil, err
 	}
+	s.watchAgentPods(ctx, wl)
 
 	sce, err := s.getOrCreateAgentConfig(ctx, wl, extended, spec)
 	if err != nil {
@@ -147,6 +322,7 @@ func (s *state) ensureAgent(parentCtx context.Context, wl k8sapi.Workload, exten
 		}
 		return nil, err
 	}
+	s.publishAgentProgress(parentCtx, managerrpc.AgentArrivalProgress_AGENT_ARRIVED, wl.GetName(), wl.GetNamespace(), "")
 	return ac, nil
 }
 
@@ -244,7 +420,11 @@ func (s *state) getOrCreateAgentConfig(
 	if err = s.self.ValidateAgentImage(agentImage, extended); err != nil {
 		return nil, err
 	}
+	repaired := ""
+	stored := false
 	err = mutator.GetMap(ctx).Update(ctx, wl.GetNamespace(), func(cm *core.ConfigMap) (changed bool, err error) {
+		repaired = ""
+		stored = false
 		doUpdate := false
 		y, cmFound := cm.Data[wl.GetName()]
 		if cmFound {
@@ -252,6 +432,21 @@ func (s *state) getOrCreateAgentConfig(
 				return false, err
 			}
 			ac := sce.AgentConfig()
+			if reason := staleConfigReason(ac, wl, managerutil.GetEnv(ctx).AgentResources); reason != "" && !ac.Manual {
+				// The entry no longer matches the workload, most likely because the workload was changed by
+				// a deploy. Regenerate it from the current pod template rather than failing the intercept.
+				dlog.Infof(ctx, "Regenerating stale config entry for %s %s.%s: %s", wl.GetKind(), wl.GetName(), wl.GetNamespace(), reason)
+				var gc agentmap.GeneratorConfig
+				if gc, err = agentmap.GeneratorConfigFunc(agentImage); err != nil {
+					return false, err
+				}
+				if sce, err = gc.Generate(ctx, wl, sce); err != nil {
+					return false, err
+				}
+				ac = sce.AgentConfig()
+				repaired = reason
+				doUpdate = true
+			}
 			// If the agentImage has changed, and the extended image is requested, then update
 			if ac.AgentImage != agentImage && extended {
 				ac.AgentImage = agentImage
@@ -282,6 +477,11 @@ func (s *state) getOrCreateAgentConfig(
 				doUpdate = true
 			}
 		}
+		if !ac.Manual {
+			if err = agentconfig.CheckPodSecurity(ac); err != nil {
+				return false, errcat.User.New(err)
+			}
+		}
 		if doUpdate {
 			if cmFound {
 				// The pods for this workload be killed once the new updated sidecar
@@ -297,10 +497,17 @@ func (s *state) getOrCreateAgentConfig(
 					return false, err
 				}
 			}
-			return updateSidecar(sce, cm, wl.GetName())
+			stored, err = updateSidecar(sce, cm, wl.GetName())
+			return stored, err
 		}
 		return false, nil
 	})
+	if err == nil && repaired != "" {
+		recordConfigRepairedEvent(ctx, wl, repaired)
+	}
+	if err == nil && stored {
+		s.publishAgentProgress(ctx, managerrpc.AgentArrivalProgress_CONFIG_UPDATED, wl.GetName(), wl.GetNamespace(), "")
+	}
 	return sce, err
 }
 
@@ -395,20 +602,29 @@ func (s *state) waitForAgent(ctx context.Context, name, namespace string, failed
 	failedContainerRx := regexp.MustCompile(`restarting failed container (\S+) in pod ([0-9A-Za-z_-]+)_` + namespace)
 	mm := mutator.GetMap(ctx)
 
-	// fes collects events from the failedCreatedCh and is included in the error message in case
+	// aes collects events from the failedCreatedCh and is included in the error in case
 	// the waitForAgent call times out.
-	var fes []*events.Event
+	var aes []*managerrpc.AgentEvent
 	for {
 		select {
 		case fe, ok := <-failedCreateCh:
 			if !ok {
 				return errors.New("failed create channel closed")
 			}
-			msg := fe.Note
+			ae := classifyEvent(fe)
+			aes = append(aes, ae)
+			s.publishAgentEvent(ctx, ae)
+			if ae.Severity != managerrpc.AgentEvent_FATAL {
+				// Something went wrong, but it might not be fatal. There are several events logged that are just
+				// warnings where the action will be retried and eventually succeed.
+				continue
+			}
+
 			// Terminate directly on known fatal events. No need for the user to wait for a timeout
 			// when one of these are encountered.
-			switch fe.Reason {
-			case "BackOff":
+			msg := fe.Note
+			switch ae.Cause {
+			case managerrpc.AgentEvent_CONTAINER_BACKOFF:
 				// The traffic-agent container was injected, but it fails to start
 				if rr := failedContainerRx.FindStringSubmatch(msg); rr != nil {
 					cn := rr[1]
@@ -428,24 +644,15 @@ func (s *state) waitForAgent(ctx context.Context, name, namespace string, failed
 					}
 				}
 				msg = fmt.Sprintf("%s\nThe logs of %s %s might provide more details", msg, fe.Regarding.Kind, fe.Regarding.Name)
-			case "Failed", "FailedCreate", "FailedScheduling":
+			case managerrpc.AgentEvent_POD_SECURITY_VIOLATION:
+				msg = fmt.Sprintf(
+					"%s\nHint: the namespace enforces a Pod Security Standard. The injected containers can be configured to comply with it by providing a value for agent.podSecurity.standard to telepresence helm install",
+					msg)
+			default:
 				// The injection of the traffic-agent failed for some reason, most likely due to resource quota restrictions.
-				if fe.Type == "Warning" && (strings.Contains(msg, "waiting for ephemeral volume") ||
-					strings.Contains(msg, "unbound immediate PersistentVolumeClaims") ||
-					strings.Contains(msg, "skip schedule deleting pod") ||
-					strings.Contains(msg, "nodes are available")) {
-					// This isn't fatal.
-					fes = append(fes, fe)
-					continue
-				}
 				msg = fmt.Sprintf(
 					"%s\nHint: if the error mentions resource quota, the traffic-agent's requested resources can be configured by providing values to telepresence helm install",
 					msg)
-			default:
-				// Something went wrong, but it might not be fatal. There are several events logged that are just
-				// warnings where the action will be retried and eventually succeed.
-				fes = append(fes, fe)
-				continue
 			}
 			return errcat.User.New(msg)
 		case snapshot, ok := <-snapshotCh:
@@ -467,49 +674,15 @@ func (s *state) waitForAgent(ctx context.Context, name, namespace string, failed
 			}
 			bf := &strings.Builder{}
 			fmt.Fprintf(bf, "request %s while waiting for agent %s.%s to arrive", v, name, namespace)
-			if len(fes) > 0 {
+			if len(aes) > 0 {
 				bf.WriteString(": Events that may be relevant:\n")
-				writeEventList(bf, fes)
+				writeAgentEvents(bf, aes)
 			}
 			return errcat.User.New(bf.String())
 		}
 	}
 }
 
-func writeEventList(bf *strings.Builder, es []*events.Event) {
-	now := time.Now()
-	age := func(e *events.Event) string {
-		return now.Sub(e.CreationTimestamp.Time).Truncate(time.Second).String()
-	}
-	object := func(e *events.Event) string {
-		or := e.Regarding
-		return strings.ToLower(or.Kind) + "/" + or.Name
-	}
-	ageLen, typeLen, reasonLen, objectLen := len("AGE"), len("TYPE"), len("REASON"), len("OBJECT")
-	for _, e := range es {
-		if l := len(age(e)); l > ageLen {
-			ageLen = l
-		}
-		if l := len(e.Type); l > typeLen {
-			typeLen = l
-		}
-		if l := len(e.Reason); l > reasonLen {
-			reasonLen = l
-		}
-		if l := len(object(e)); l > objectLen {
-			objectLen = l
-		}
-	}
-	ageLen += 3
-	typeLen += 3
-	reasonLen += 3
-	objectLen += 3
-	fmt.Fprintf(bf, "%-*s%-*s%-*s%-*s%s\n", ageLen, "AGE", typeLen, "TYPE", reasonLen, "REASON", objectLen, "OBJECT", "MESSAGE")
-	for _, e := range es {
-		fmt.Fprintf(bf, "%-*s%-*s%-*s%-*s%s\n", ageLen, age(e), typeLen, e.Type, reasonLen, e.Reason, objectLen, object(e), e.Note)
-	}
-}
-
 func unmarshalConfigMapEntry(y string, name, namespace string) (agentconfig.SidecarExt, error) {
 	scx, err := agentconfig.UnmarshalYAML([]byte(y))
 	if err != nil {
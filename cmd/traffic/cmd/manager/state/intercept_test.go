@@ -0,0 +1,168 @@
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+	"github.com/telepresenceio/telepresence/v2/pkg/workload"
+)
+
+func Test_workloadForService(t *testing.T) {
+	podTemplate := func(app string) core.PodTemplateSpec {
+		return core.PodTemplateSpec{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": app}}}
+	}
+	deployment := func(name, app string) *apps.Deployment {
+		return &apps.Deployment{
+			TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       apps.DeploymentSpec{Template: podTemplate(app)},
+		}
+	}
+	selectorService := func(name, app string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       core.ServiceSpec{Selector: map[string]string{"app": app}},
+		}
+	}
+	externalNameService := func(name, externalName string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       core.ServiceSpec{Type: core.ServiceTypeExternalName, ExternalName: externalName},
+		}
+	}
+	yes := true
+	cs := fake.NewClientset(
+		deployment("echo-v1", "echo"),
+		deployment("orders", "orders"),
+		deployment("payments-v1", "payments"),
+		deployment("payments-v2", "payments"),
+		&apps.ReplicaSet{
+			TypeMeta: meta.TypeMeta{Kind: "ReplicaSet", APIVersion: "apps/v1"},
+			ObjectMeta: meta.ObjectMeta{
+				Name:            "orders-6699c6cb54",
+				Namespace:       "default",
+				OwnerReferences: []meta.OwnerReference{{Kind: "Deployment", Name: "orders", Controller: &yes}},
+			},
+			Spec: apps.ReplicaSetSpec{Template: podTemplate("orders")},
+		},
+		selectorService("echo", "echo"),
+		selectorService("orders", "orders"),
+		selectorService("payments", "payments"),
+		selectorService("nobody", "nobody"),
+		externalNameService("echo-alias", "echo.default.svc.cluster.local"),
+		externalNameService("other-ns", "echo.other.svc.cluster.local"),
+		externalNameService("external", "api.example.com"),
+		externalNameService("loop", "loop.default.svc.cluster.local"),
+	)
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), cs)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{EnabledWorkloadKinds: []workload.WorkloadKind{
+		workload.DeploymentWorkloadKind,
+		workload.ReplicaSetWorkloadKind,
+		workload.StatefulSetWorkloadKind,
+	}})
+
+	notFound := errors.New("not found")
+	tests := []struct {
+		name     string
+		workload string
+		service  string
+		err      string
+	}{
+		{name: "echo", workload: "echo-v1", service: "echo"},
+		{name: "orders", workload: "orders", service: "orders"},
+		{name: "echo-alias", workload: "echo-v1", service: "echo"},
+		{name: "missing", err: "not found"},
+		{name: "payments", err: "selects multiple workloads:\n  Deployment payments-v1\n  Deployment payments-v2\n"},
+		{name: "nobody", err: "selects no workload"},
+		{name: "other-ns", err: "refers to service echo in namespace other"},
+		{name: "external", err: "refers to api.example.com, which is outside the cluster"},
+		{name: "loop", err: "refers to too many other ExternalName services"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &managerrpc.InterceptSpec{Agent: tt.name, Namespace: "default"}
+			wl, err := workloadForService(ctx, spec, notFound)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.workload, wl.GetName())
+			assert.Equal(t, tt.service, spec.ServiceName)
+		})
+	}
+}
+
+func Test_checkBarePod(t *testing.T) {
+	ctx := managerutil.WithEnv(dlog.NewTestContext(t, false), &managerutil.Env{EnabledWorkloadKinds: []workload.WorkloadKind{
+		workload.DeploymentWorkloadKind,
+		workload.ReplicaSetWorkloadKind,
+	}})
+	yes := true
+	pod := func(owners ...meta.OwnerReference) k8sapi.Workload {
+		return k8sworkload.Pod(&core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "scratch", Namespace: "default", OwnerReferences: owners},
+		})
+	}
+	assert.NoError(t, checkBarePod(ctx, pod()))
+	assert.NoError(t, checkBarePod(ctx, pod(meta.OwnerReference{Kind: "CronTab", Name: "tab", Controller: &yes})))
+	assert.NoError(t, checkBarePod(ctx, pod(meta.OwnerReference{Kind: "ReplicaSet", Name: "echo-6699c6cb54"})))
+	assert.EqualError(t, checkBarePod(ctx, pod(meta.OwnerReference{Kind: "ReplicaSet", Name: "echo-6699c6cb54", Controller: &yes})),
+		"pod scratch.default is controlled by ReplicaSet echo-6699c6cb54. Please intercept the ReplicaSet instead")
+}
+
+func Test_findReplicaSet(t *testing.T) {
+	dep := &apps.Deployment{
+		TypeMeta:   meta.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default", UID: "echo-uid"},
+	}
+	yes := true
+	rs := func(name, revision, hash string, replicas int32) *apps.ReplicaSet {
+		return &apps.ReplicaSet{
+			ObjectMeta: meta.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Annotations:     map[string]string{k8sworkload.DeploymentRevisionAnnotation: revision},
+				Labels:          map[string]string{k8sworkload.PodTemplateHashLabel: hash},
+				OwnerReferences: []meta.OwnerReference{{Kind: "Deployment", Name: "echo", UID: dep.UID, Controller: &yes}},
+			},
+			Status: apps.ReplicaSetStatus{Replicas: replicas},
+		}
+	}
+	ctx := k8sapi.WithK8sInterface(dlog.NewTestContext(t, false), fake.NewSimpleClientset(
+		rs("echo-77c9", "3", "77c9", 0),
+		rs("echo-5f8b", "4", "5f8b", 3),
+		rs("echo-6a1d", "5", "6a1d", 1),
+	))
+	wl := k8sapi.Deployment(dep)
+
+	found, err := findReplicaSet(ctx, wl, "echo-6a1d", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "6a1d", k8sworkload.PodTemplateHash(found))
+
+	found, err = findReplicaSet(ctx, wl, "", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "echo-5f8b", found.Name)
+
+	_, err = findReplicaSet(ctx, wl, "echo-5f8b", 5)
+	assert.EqualError(t, err, "Deployment echo.default has no replica set with name echo-5f8b and revision 5. "+
+		"Available replica sets are: echo-77c9 (revision 3), echo-5f8b (revision 4), echo-6a1d (revision 5)")
+
+	_, err = findReplicaSet(ctx, wl, "", 3)
+	assert.EqualError(t, err, "replica set echo-77c9.default (revision 3) has no pods")
+
+	_, err = findReplicaSet(ctx, k8sworkload.DaemonSet(&apps.DaemonSet{ObjectMeta: meta.ObjectMeta{Name: "ds", Namespace: "default"}}), "", 1)
+	assert.EqualError(t, err, "a replica set or revision can only be selected for a Deployment or a Rollout, not for DaemonSet ds.default")
+}
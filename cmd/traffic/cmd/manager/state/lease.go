@@ -0,0 +1,27 @@
+package state
+
+import (
+	"time"
+)
+
+// Leases records when the reaper first found things that it collects orphaned, keyed by a string that identifies
+// the thing. A lease expires when the thing has been orphaned for longer than the grace period, and is dropped as
+// soon as the thing is found in use again, because only the leases of things that are still orphaned are carried
+// over to the next round.
+type Leases map[string]time.Time
+
+// expired returns true if the lease of the given key has expired. Otherwise, the lease is carried over to next,
+// and a new lease is started if the key has none. A new lease never expires in the same round, so that things
+// that are about to be used, e.g. containers of intercepts that are being created, get at least one round of
+// grace also when the grace period is zero.
+func (l Leases) expired(next Leases, key string, now time.Time, grace time.Duration) bool {
+	since, ok := l[key]
+	if ok && now.Sub(since) >= grace {
+		return true
+	}
+	if !ok {
+		since = now
+	}
+	next[key] = since
+	return false
+}
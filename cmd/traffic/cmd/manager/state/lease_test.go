@@ -0,0 +1,31 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeases_expired(t *testing.T) {
+	clock := &FakeClock{}
+	start := clock.Now()
+	leases := Leases{}
+
+	next := Leases{}
+	assert.False(t, leases.expired(next, "a", clock.Now(), 0))
+	assert.Equal(t, Leases{"a": start}, next)
+	assert.True(t, next.expired(Leases{}, "a", clock.Now(), 0))
+
+	leases, next = next, Leases{}
+	clock.When = 30
+	assert.False(t, leases.expired(next, "a", clock.Now(), time.Minute))
+	assert.False(t, leases.expired(next, "b", clock.Now(), time.Minute))
+	assert.Equal(t, Leases{"a": start, "b": clock.Now()}, next)
+
+	// Leases that aren't carried over are dropped.
+	leases, next = next, Leases{}
+	clock.When = 60
+	assert.True(t, leases.expired(next, "a", clock.Now(), time.Minute))
+	assert.Empty(t, next)
+}
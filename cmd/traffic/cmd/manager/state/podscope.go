@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"strconv"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+// HasPodScope returns true if the given intercept is limited to a subset of the pods of its workload.
+func HasPodScope(spec *managerrpc.InterceptSpec) bool {
+	return spec.PodSelector != "" || len(spec.PodOrdinals) > 0 || spec.PodTemplateHash != ""
+}
+
+// PodScopeNeedsLabels returns true if the pod labels are needed to determine if the given intercept applies to
+// a pod.
+func PodScopeNeedsLabels(spec *managerrpc.InterceptSpec) bool {
+	return spec.PodSelector != "" || spec.PodTemplateHash != ""
+}
+
+// InterceptAppliesToPod returns true if the given intercept applies to the pod with the given name and labels. The
+// labels are only needed when PodScopeNeedsLabels returns true.
+func InterceptAppliesToPod(spec *managerrpc.InterceptSpec, podName string, podLabels map[string]string) bool {
+	if len(spec.PodOrdinals) > 0 {
+		found := false
+		for _, o := range spec.PodOrdinals {
+			if podName == spec.Agent+"-"+strconv.Itoa(int(o)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if spec.PodTemplateHash != "" && !k8sworkload.HasPodTemplateHash(podLabels, spec.PodTemplateHash) {
+		return false
+	}
+	if spec.PodSelector != "" {
+		sel, err := labels.Parse(spec.PodSelector)
+		if err != nil || !sel.Matches(labels.Set(podLabels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// PodLabels returns the labels of the pod with the given name and namespace.
+func PodLabels(ctx context.Context, name, namespace string) (map[string]string, error) {
+	if f := informer.GetK8sFactory(ctx, namespace); f != nil {
+		pod, err := f.Core().V1().Pods().Lister().Pods(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return pod.Labels, nil
+	}
+	dlog.Debugf(ctx, "fetching pod %s.%s using direct API call", name, namespace)
+	pod, err := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(namespace).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return pod.Labels, nil
+}
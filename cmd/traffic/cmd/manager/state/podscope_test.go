@@ -0,0 +1,43 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestInterceptAppliesToPod(t *testing.T) {
+	canary := map[string]string{"app": "db", "tier": "canary"}
+	stable := map[string]string{"app": "db", "tier": "stable"}
+	canaryRS := map[string]string{"app": "db", "pod-template-hash": "5f8b"}
+	stableRS := map[string]string{"app": "db", "pod-template-hash": "77c9"}
+	tests := []struct {
+		name     string
+		spec     *managerrpc.InterceptSpec
+		podName  string
+		labels   map[string]string
+		expected bool
+	}{
+		{"no scope", &managerrpc.InterceptSpec{Agent: "db"}, "db-1", stable, true},
+		{"ordinal match", &managerrpc.InterceptSpec{Agent: "db", PodOrdinals: []int32{0, 2}}, "db-2", stable, true},
+		{"ordinal mismatch", &managerrpc.InterceptSpec{Agent: "db", PodOrdinals: []int32{0}}, "db-1", stable, false},
+		{"ordinal prefix", &managerrpc.InterceptSpec{Agent: "db", PodOrdinals: []int32{1}}, "db-10", stable, false},
+		{"selector match", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier=canary"}, "db-1", canary, true},
+		{"selector mismatch", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier=canary"}, "db-1", stable, false},
+		{"set selector", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier in (canary,beta)"}, "db-1", canary, true},
+		{"both match", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier=canary", PodOrdinals: []int32{1}}, "db-1", canary, true},
+		{"both, selector mismatch", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier=canary", PodOrdinals: []int32{1}}, "db-1", stable, false},
+		{"hash match", &managerrpc.InterceptSpec{Agent: "db", PodTemplateHash: "5f8b"}, "db-1", canaryRS, true},
+		{"hash mismatch", &managerrpc.InterceptSpec{Agent: "db", PodTemplateHash: "5f8b"}, "db-1", stableRS, false},
+		{"hash without label", &managerrpc.InterceptSpec{Agent: "db", PodTemplateHash: "5f8b"}, "db-1", canary, false},
+		{"hash and selector", &managerrpc.InterceptSpec{Agent: "db", PodTemplateHash: "5f8b", PodSelector: "app=db"}, "db-1", canaryRS, true},
+		{"invalid selector", &managerrpc.InterceptSpec{Agent: "db", PodSelector: "tier in canary"}, "db-1", canary, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, InterceptAppliesToPod(tt.spec, tt.podName, tt.labels))
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package state
+
+import (
+	"context"
+	"net"
+	"net/netip"
+)
+
+// ProxyPublishedConn forwards the given connection to the given port on the workstation of the client with the
+// given session. The call blocks until the connection is closed.
+func (s *state) ProxyPublishedConn(ctx context.Context, conn net.Conn, sessionID string, port uint16) error {
+	defer conn.Close()
+	src, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+	if err != nil {
+		return err
+	}
+	return s.proxyToClient(ctx, conn, src, sessionID, netip.AddrPortFrom(netip.AddrFrom4([4]byte{127, 0, 0, 1}), port))
+}
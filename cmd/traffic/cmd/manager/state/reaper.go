@@ -0,0 +1,179 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+)
+
+// ReapStaleSessions removes the client sessions that belong to the same installation and user as the session with
+// the given ID, and that haven't been marked since the given moment, together with their intercepts. Such sessions
+// are left behind by clients that crashed or lost their session info.
+func (s *state) ReapStaleSessions(ctx context.Context, sessionID string, moment time.Time) *rpc.ReapResult {
+	result := &rpc.ReapResult{}
+	self := s.GetClient(sessionID)
+	if self == nil {
+		return result
+	}
+	for id, client := range s.GetAllClients() {
+		if id == sessionID || client.InstallId != self.InstallId || client.Name != self.Name {
+			continue
+		}
+		sess := s.GetSession(id)
+		if sess == nil || !sess.LastMarked().Before(moment) {
+			continue
+		}
+		result.InterceptIds = append(result.InterceptIds, s.sessionInterceptIDs(id)...)
+		dlog.Infof(ctx, "Reaping stale session %s of client %s", id, client.Name)
+		s.RemoveSession(ctx, id)
+		result.SessionIds = append(result.SessionIds, id)
+	}
+	return result
+}
+
+// ReapOrphanedIntercepts removes the intercepts whose client session is gone, or hasn't been marked since the given
+// moment. The sessions themselves are kept, so that their clients can resume them and create new intercepts.
+func (s *state) ReapOrphanedIntercepts(ctx context.Context, moment time.Time) []string {
+	var reaped []string
+	for id, ii := range s.intercepts.LoadAll() {
+		if ii.Disposition == rpc.InterceptDispositionType_REMOVED {
+			continue
+		}
+		sessionID := ii.ClientSession.GetSessionId()
+		if sess := s.GetSession(sessionID); sess != nil && !sess.LastMarked().Before(moment) {
+			continue
+		}
+		dlog.Infof(ctx, "Reaping intercept %s of client session %s that is gone", id, sessionID)
+		if client := s.GetClient(sessionID); client != nil {
+			workload := ii.Spec.Agent
+			s.allInterceptsFinalizerCall(client, &workload)
+		}
+		s.self.RemoveIntercept(ctx, id)
+		reaped = append(reaped, id)
+	}
+	slices.Sort(reaped)
+	return reaped
+}
+
+// RestoreOrphanedContainers restores app containers that are replaced by traffic-agents although no intercept uses
+// them, e.g. because their intercepts were lost when the traffic-manager restarted. A container is restored only
+// when its lease in the given leases has expired, so that intercepts that are still being created are left alone.
+// The returned leases must be passed to the next call.
+func (s *state) RestoreOrphanedContainers(ctx context.Context, leases Leases, now time.Time, grace time.Duration) (next Leases, restored []string) {
+	next = make(Leases)
+	namespaces := make(map[string]struct{})
+	for _, ai := range s.agents.LoadAll() {
+		namespaces[ai.Namespace] = struct{}{}
+	}
+
+	mm := mutator.GetMap(ctx)
+	for ns := range namespaces {
+		err := mm.Update(ctx, ns, func(cm *core.ConfigMap) (changed bool, err error) {
+			for name, y := range cm.Data {
+				sce, err := unmarshalConfigMapEntry(y, name, ns)
+				if err != nil {
+					dlog.Errorf(ctx, "unable to parse config entry for %s.%s: %v", name, ns, err)
+					continue
+				}
+				ac := sce.AgentConfig()
+				used := s.usedContainers(ac.AgentName, ns)
+				entryChanged := false
+				for _, cn := range ac.Containers {
+					if !bool(cn.Replace) || used[cn.Name] || used[""] {
+						continue
+					}
+					key := ns + "/" + name + "/" + cn.Name
+					if !leases.expired(next, key, now, grace) {
+						continue
+					}
+					dlog.Infof(ctx, "Restoring app container %s of %s.%s that is replaced without an intercept", cn.Name, name, ns)
+					cn.Replace = false
+					entryChanged = true
+					restored = append(restored, key)
+				}
+				if !entryChanged {
+					continue
+				}
+				for sessionID, ai := range s.getAgentsByName(ac.AgentName, ns) {
+					if as, ok := s.GetSession(sessionID).(*agentSessionState); ok {
+						as.active.Store(false)
+					}
+					mm.Blacklist(ai.PodName, ns)
+				}
+				if c, err := updateSidecar(sce, cm, name); err != nil {
+					return false, err
+				} else if c {
+					changed = true
+				}
+			}
+			return changed, nil
+		})
+		if err != nil {
+			dlog.Errorf(ctx, "unable to restore orphaned app containers in namespace %s: %v", ns, err)
+		}
+	}
+	slices.Sort(restored)
+	return next, restored
+}
+
+// PruneOrphanedAgentConfigs removes the entries of the telepresence-agents ConfigMaps whose workloads no longer
+// exist, e.g. because they were deleted while the traffic-manager was down. An entry is removed only when its lease
+// in the given leases has expired. The returned leases must be passed to the next call.
+func (s *state) PruneOrphanedAgentConfigs(ctx context.Context, leases Leases, now time.Time, grace time.Duration) (next Leases, pruned []string) {
+	next = make(Leases)
+	mm := mutator.GetMap(ctx)
+	orphans, err := mm.OrphanedEntries(ctx)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to find orphaned agent configs: %v", err)
+		return leases, nil
+	}
+	for ns, names := range orphans {
+		for _, name := range names {
+			key := name + "." + ns
+			if !leases.expired(next, key, now, grace) {
+				continue
+			}
+			dlog.Infof(ctx, "Pruning agent config %s of a workload that no longer exists", key)
+			if err := mm.Delete(ctx, name, ns); err != nil {
+				dlog.Errorf(ctx, "unable to prune agent config %s: %v", key, err)
+				next[key] = leases[key]
+				continue
+			}
+			pruned = append(pruned, key)
+		}
+	}
+	slices.Sort(pruned)
+	return next, pruned
+}
+
+// usedContainers returns the names of the containers of the given workload that are used by intercepts.
+func (s *state) usedContainers(name, namespace string) map[string]bool {
+	used := make(map[string]bool)
+	for _, ii := range s.intercepts.LoadAll() {
+		spec := ii.Spec
+		if ii.Disposition == rpc.InterceptDispositionType_REMOVED || spec.Agent != name || spec.Namespace != namespace {
+			continue
+		}
+		// An empty name means that the container is unknown, and then all containers are considered used.
+		used[spec.ContainerName] = true
+	}
+	return used
+}
+
+// sessionInterceptIDs returns the IDs of the intercepts that belong to the given session.
+func (s *state) sessionInterceptIDs(sessionID string) []string {
+	var ids []string
+	for id, ii := range s.intercepts.LoadAll() {
+		if ii.ClientSession.GetSessionId() == sessionID && ii.Disposition != rpc.InterceptDispositionType_REMOVED {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestReapStaleSessions(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	clock := &FakeClock{}
+	s := NewState(ctx).(*state)
+
+	alice := &manager.ClientInfo{Name: "alice", InstallId: "1234"}
+	stale := s.AddClient(alice, clock.Now())
+	other := s.AddClient(&manager.ClientInfo{Name: "bob", InstallId: "5678"}, clock.Now())
+	s.intercepts.Store("stale:echo", &manager.InterceptInfo{
+		Id:            "stale:echo",
+		Spec:          &manager.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default"},
+		ClientSession: &manager.SessionInfo{SessionId: stale},
+		Disposition:   manager.InterceptDispositionType_ACTIVE,
+	})
+
+	clock.When = 60
+	fresh := s.AddClient(alice, clock.Now())
+	require.True(t, s.MarkSession(&manager.RemainRequest{Session: &manager.SessionInfo{SessionId: other}}, clock.Now()))
+
+	result := s.ReapStaleSessions(ctx, fresh, clock.Now().Add(-30*time.Second))
+	assert.Equal(t, []string{stale}, result.SessionIds)
+	assert.Equal(t, []string{"stale:echo"}, result.InterceptIds)
+	assert.Nil(t, s.GetClient(stale))
+	assert.NotNil(t, s.GetClient(fresh))
+	assert.NotNil(t, s.GetClient(other))
+	_, ok := s.GetIntercept("stale:echo")
+	assert.False(t, ok)
+
+	assert.Empty(t, s.ReapStaleSessions(ctx, "unknown", clock.Now()).SessionIds)
+}
+
+func TestReapOrphanedIntercepts(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	clock := &FakeClock{}
+	s := NewState(ctx).(*state)
+
+	live := s.AddClient(&manager.ClientInfo{Name: "alice", InstallId: "1234"}, clock.Now())
+	silent := s.AddClient(&manager.ClientInfo{Name: "bob", InstallId: "5678"}, clock.Now())
+	add := func(id, sessionID, container string) {
+		s.intercepts.Store(id, &manager.InterceptInfo{
+			Id:            id,
+			Spec:          &manager.InterceptSpec{Name: id, Agent: "echo", Namespace: "default", ContainerName: container},
+			ClientSession: &manager.SessionInfo{SessionId: sessionID},
+			Disposition:   manager.InterceptDispositionType_ACTIVE,
+		})
+	}
+	add("live", live, "echo")
+	add("silent", silent, "echo")
+	add("gone", "no-such-session", "sidecar")
+
+	clock.When = 60
+	require.True(t, s.MarkSession(&manager.RemainRequest{Session: &manager.SessionInfo{SessionId: live}}, clock.Now()))
+	assert.Equal(t, map[string]bool{"echo": true, "sidecar": true}, s.usedContainers("echo", "default"))
+
+	reaped := s.ReapOrphanedIntercepts(ctx, clock.Now().Add(-30*time.Second))
+	assert.Equal(t, []string{"gone", "silent"}, reaped)
+	assert.NotNil(t, s.GetClient(silent))
+	assert.Equal(t, []string{"live"}, s.sessionInterceptIDs(live))
+	assert.Equal(t, map[string]bool{"echo": true}, s.usedContainers("echo", "default"))
+	assert.Empty(t, s.usedContainers("echo", "other"))
+}
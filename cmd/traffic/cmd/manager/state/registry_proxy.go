@@ -0,0 +1,135 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// clientDialTimeout is how long a client is given to dial a registry or service on its workstation.
+const clientDialTimeout = 10 * time.Second
+
+// registryProxyTarget is the registry, on the workstation of a client, that the registry proxy forwards to.
+type registryProxyTarget struct {
+	sessionID string
+	addr      netip.AddrPort
+	password  string
+}
+
+// clientDialStream is the manager's end of a tunnel to a registry or service on a client's workstation. It
+// gives the client more time to dial than the pipe that it wraps.
+type clientDialStream struct {
+	tunnel.Stream
+}
+
+func (clientDialStream) DialTimeout() time.Duration {
+	return clientDialTimeout
+}
+
+// SetRegistryProxy makes the registry at the given address on the workstation of the client with the given
+// session the target of the registry proxy, and returns the password that pulls through the proxy must use. The
+// password is created when the session exposes its registry, and is kept when the session changes the address.
+// An invalid address removes the target, provided that it was set by the same session. An error is returned when
+// another active session already has exposed its registry.
+func (s *state) SetRegistryProxy(sessionID string, addr netip.AddrPort) (string, error) {
+	for {
+		old := s.registryProxy.Load()
+		var target *registryProxyTarget
+		if addr.IsValid() {
+			if old != nil && old.sessionID != sessionID {
+				if _, ok := s.sessions.Load(old.sessionID); ok {
+					return "", status.Error(codes.FailedPrecondition, "a registry is already exposed by another client")
+				}
+			}
+			target = &registryProxyTarget{sessionID: sessionID, addr: addr}
+			if old != nil && old.sessionID == sessionID {
+				target.password = old.password
+			} else {
+				pw := make([]byte, 24)
+				if _, err := rand.Read(pw); err != nil {
+					return "", status.Errorf(codes.Internal, "unable to create registry password: %v", err)
+				}
+				target.password = base64.RawURLEncoding.EncodeToString(pw)
+			}
+		} else if old == nil || old.sessionID != sessionID {
+			return "", nil
+		}
+		if s.registryProxy.CompareAndSwap(old, target) {
+			if target == nil {
+				return "", nil
+			}
+			return target.password, nil
+		}
+	}
+}
+
+// AuthorizeRegistryPull returns true if a registry is exposed by an active session, and the given password is the
+// password of that exposure.
+func (s *state) AuthorizeRegistryPull(password string) bool {
+	target := s.registryProxy.Load()
+	if target == nil {
+		return false
+	}
+	if _, ok := s.sessions.Load(target.sessionID); !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(target.password)) == 1
+}
+
+// DialRegistry returns a connection to the exposed registry. A DialRequest is sent to the client that exposed the
+// registry, and the tunnel that the client then creates is connected to the returned connection.
+func (s *state) DialRegistry(ctx context.Context) (net.Conn, error) {
+	target := s.registryProxy.Load()
+	if target == nil {
+		return nil, fmt.Errorf("no registry is exposed")
+	}
+	// The connection has no remote address of its own, so the tunnel gets a unique loopback source instead.
+	src := netip.AddrPortFrom(netip.AddrFrom4([4]byte{127, 0, 0, 1}), uint16(s.registryConnCounter.Add(1)))
+	ours, theirs := net.Pipe()
+	go func() {
+		defer theirs.Close()
+		if err := s.proxyToClient(ctx, theirs, src, target.sessionID, target.addr); err != nil {
+			dlog.Errorf(ctx, "registry proxy: %v", err)
+		}
+	}()
+	return ours, nil
+}
+
+// proxyToClient forwards the given connection, that originates from src, to the given destination, as seen from
+// the workstation of the client with the given session. A DialRequest is sent to the client, and the tunnel that
+// the client then creates is connected to the connection. The call blocks until the connection is closed.
+func (s *state) proxyToClient(ctx context.Context, conn net.Conn, src netip.AddrPort, sessionID string, dst netip.AddrPort) error {
+	ss, ok := s.sessions.Load(sessionID)
+	if !ok {
+		return fmt.Errorf("the session %s is gone", sessionID)
+	}
+	id := tunnel.NewConnID(ipproto.TCP, src.Addr().Unmap().AsSlice(), dst.Addr().AsSlice(), src.Port(), dst.Port())
+	dlog.Debugf(ctx, "proxying connection %s to session %s", id, sessionID)
+	ours, theirs := tunnel.NewPipe(id, sessionID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bidiPipe, err := ss.EstablishBidiPipe(ctx, clientDialStream{Stream: ours})
+	if err != nil {
+		return fmt.Errorf("unable to establish tunnel to the client: %w", err)
+	}
+	ep := tunnel.NewConnEndpoint(theirs, conn, cancel, nil, nil)
+	ep.Start(ctx)
+	select {
+	case <-ep.Done():
+	case <-bidiPipe.Done():
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestSetRegistryProxy(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := NewState(ctx)
+	now := time.Now()
+	sa := s.AddClient(&rpc.ClientInfo{Name: "a"}, now)
+	sb := s.AddClient(&rpc.ClientInfo{Name: "b"}, now)
+	addr := netip.MustParseAddrPort("127.0.0.1:5000")
+
+	assert.False(t, s.AuthorizeRegistryPull(""))
+	pwA, err := s.SetRegistryProxy(sa, addr)
+	require.NoError(t, err)
+	require.NotEmpty(t, pwA)
+	assert.True(t, s.AuthorizeRegistryPull(pwA))
+	assert.False(t, s.AuthorizeRegistryPull(""))
+	_, err = s.SetRegistryProxy(sb, addr)
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The password is kept when the session changes the address.
+	pw, err := s.SetRegistryProxy(sa, netip.MustParseAddrPort("127.0.0.1:5001"))
+	require.NoError(t, err)
+	assert.Equal(t, pwA, pw)
+
+	// Removal by another session is a no-op.
+	_, err = s.SetRegistryProxy(sb, netip.AddrPort{})
+	require.NoError(t, err)
+	_, err = s.SetRegistryProxy(sb, addr)
+	require.Error(t, err)
+
+	// The registry can be taken over once the session that exposed it is gone, and the credentials of the
+	// old exposure are then no longer valid.
+	s.RemoveSession(ctx, sa)
+	assert.False(t, s.AuthorizeRegistryPull(pwA))
+	pwB, err := s.SetRegistryProxy(sb, addr)
+	require.NoError(t, err)
+	assert.NotEqual(t, pwA, pwB)
+	assert.False(t, s.AuthorizeRegistryPull(pwA))
+	assert.True(t, s.AuthorizeRegistryPull(pwB))
+	_, err = s.SetRegistryProxy(sb, netip.AddrPort{})
+	require.NoError(t, err)
+	assert.False(t, s.AuthorizeRegistryPull(pwB))
+}
+
+func TestDialRegistry(t *testing.T) {
+	// The tunnel goroutines may log after the test has completed, so a test logger can't be used.
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	defer cancel()
+	s := NewState(ctx)
+	sessionID := s.AddClient(&rpc.ClientInfo{Name: "a"}, time.Now())
+
+	// The "registry" echoes what it receives.
+	registry, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer registry.Close()
+	go func() {
+		for {
+			conn, err := registry.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	_, err = s.SetRegistryProxy(sessionID, netip.MustParseAddrPort(registry.Addr().String()))
+	require.NoError(t, err)
+
+	// The client responds to dial requests with a tunnel and a dialer.
+	go func() {
+		for dr := range s.WatchDial(sessionID) {
+			clientEnd, managerEnd := tunnel.NewPipe(tunnel.ConnID(dr.ConnId), sessionID)
+			go func() { _ = s.Tunnel(ctx, managerEnd) }()
+			tunnel.NewDialer(clientEnd, func() {}, nil, nil).Start(ctx)
+		}
+	}()
+
+	conn, err := s.DialRegistry(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /v2/ HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 21)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "GET /v2/ HTTP/1.1\r\n\r\n", string(buf))
+}
@@ -164,6 +164,8 @@ type clientSessionState struct {
 	pool *tunnel.Pool
 
 	consumptionMetrics *SessionConsumptionMetrics
+	agentEvents        sessionSubscribers[*rpc.AgentEvent]
+	agentProgress      sessionSubscribers[*rpc.AgentArrivalProgress]
 }
 
 func (css *clientSessionState) ConsumptionMetrics() *SessionConsumptionMetrics {
@@ -183,7 +185,12 @@ type agentSessionState struct {
 	sessionState
 	dnsRequests  chan *rpc.DNSRequest
 	dnsResponses map[string]chan *rpc.DNSResponse
+	headerProbes chan *rpc.HeaderProbe
 	active       atomic.Bool
+
+	// draining is set when a newer agent for the same workload has arrived and taken over
+	// the routing of intercepts from this agent, or when the agent departs.
+	draining atomic.Bool
 }
 
 func newAgentSessionState(ctx context.Context, ts time.Time) *agentSessionState {
@@ -191,6 +198,7 @@ func newAgentSessionState(ctx context.Context, ts time.Time) *agentSessionState
 		sessionState: newSessionState(ctx, ts),
 		dnsRequests:  make(chan *rpc.DNSRequest),
 		dnsResponses: make(map[string]chan *rpc.DNSResponse),
+		headerProbes: make(chan *rpc.HeaderProbe),
 	}
 	as.active.Store(true)
 	return as
@@ -200,9 +208,16 @@ func (ss *agentSessionState) Active() bool {
 	return ss.active.Load()
 }
 
+// Draining returns true when this agent has been superseded by a newer agent and only serves
+// the connections that it already has.
+func (ss *agentSessionState) Draining() bool {
+	return ss.draining.Load()
+}
+
 func (ss *agentSessionState) Cancel() {
 	ss.active.Store(false)
 	close(ss.dnsRequests)
+	close(ss.headerProbes)
 	for k, lr := range ss.dnsResponses {
 		delete(ss.dnsResponses, k)
 		close(lr)
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ShareIntercept returns a token that other clients can use to join the intercept with the given ID. The same
+// token is returned until the intercept is removed.
+func (s *state) ShareIntercept(ctx context.Context, interceptID string) (string, error) {
+	if _, ok := s.intercepts.Load(interceptID); !ok {
+		return "", status.Errorf(codes.NotFound, "no such intercept %s", interceptID)
+	}
+	token := ""
+	s.shareTokens.Range(func(t, id string) bool {
+		if id == interceptID {
+			token = t
+			return false
+		}
+		return true
+	})
+	if token != "" {
+		return token, nil
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", status.Errorf(codes.Internal, "unable to generate token: %v", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	s.shareTokens.Store(token, interceptID)
+	err := s.AddInterceptFinalizer(interceptID, func(context.Context, *rpc.InterceptInfo) error {
+		s.shareTokens.Delete(token)
+		return nil
+	})
+	if err != nil {
+		s.shareTokens.Delete(token)
+		return "", err
+	}
+	dlog.Debugf(ctx, "Intercept %s shared", interceptID)
+	return token, nil
+}
+
+// SharedIntercept returns the intercept that the given share token was issued for.
+func (s *state) SharedIntercept(token string) (*rpc.InterceptInfo, bool) {
+	interceptID, ok := s.shareTokens.Load(token)
+	if !ok {
+		return nil, false
+	}
+	return s.intercepts.Load(interceptID)
+}
+
+// JoinIntercept adds the given session to the shared sessions of the intercept that the given token was issued for,
+// and assigns a route to it. The traffic-agent routes the HTTP requests that carry the route in the
+// x-telepresence-route header to the given session.
+func (s *state) JoinIntercept(ctx context.Context, token string, session *rpc.SessionInfo) (*rpc.InterceptInfo, error) {
+	interceptID, ok := s.shareTokens.Load(token)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "the share token is invalid, or its intercept has ended")
+	}
+	ii, ok := s.intercepts.Load(interceptID)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "the share token is invalid, or its intercept has ended")
+	}
+	if ii.ClientSession.SessionId == session.SessionId {
+		return nil, status.Errorf(codes.InvalidArgument, "intercept %s can't be joined by its own client", ii.Spec.Name)
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to generate route: %v", err)
+	}
+	ii = s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		if !slices.ContainsFunc(ii.SharedSessions, isSession(session.SessionId)) {
+			ii.SharedSessions = append(ii.SharedSessions, session)
+		}
+		if _, ok := ii.SharedSessionRoutes[session.SessionId]; !ok {
+			if ii.SharedSessionRoutes == nil {
+				ii.SharedSessionRoutes = make(map[string]string)
+			}
+			ii.SharedSessionRoutes[session.SessionId] = hex.EncodeToString(b)
+		}
+	})
+	if ii == nil {
+		return nil, status.Error(codes.NotFound, "the share token is invalid, or its intercept has ended")
+	}
+	dlog.Debugf(ctx, "Session %s joined intercept %s", session.SessionId, interceptID)
+	return ii, nil
+}
+
+// LeaveIntercept removes the given session from the shared sessions of the intercept that the given token was
+// issued for.
+func (s *state) LeaveIntercept(ctx context.Context, token string, sessionID string) error {
+	interceptID, ok := s.shareTokens.Load(token)
+	if !ok {
+		return status.Error(codes.NotFound, "the share token is invalid, or its intercept has ended")
+	}
+	s.leaveIntercept(interceptID, sessionID)
+	dlog.Debugf(ctx, "Session %s left intercept %s", sessionID, interceptID)
+	return nil
+}
+
+func (s *state) leaveIntercept(interceptID string, sessionID string) {
+	s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		ii.SharedSessions = slices.DeleteFunc(ii.SharedSessions, isSession(sessionID))
+		delete(ii.SharedSessionRoutes, sessionID)
+	})
+}
+
+func isSession(sessionID string) func(*rpc.SessionInfo) bool {
+	return func(si *rpc.SessionInfo) bool {
+		return si.SessionId == sessionID
+	}
+}
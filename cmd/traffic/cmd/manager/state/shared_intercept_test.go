@@ -0,0 +1,72 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestShareIntercept(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := NewState(ctx).(*state)
+
+	alice := s.AddClient(&manager.ClientInfo{Name: "alice", InstallId: "1234"}, time.Now())
+	bob := s.AddClient(&manager.ClientInfo{Name: "bob", InstallId: "5678"}, time.Now())
+	id := alice + ":echo"
+	s.intercepts.Store(id, &manager.InterceptInfo{
+		Id:            id,
+		Spec:          &manager.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default"},
+		ClientSession: &manager.SessionInfo{SessionId: alice},
+		Disposition:   manager.InterceptDispositionType_ACTIVE,
+	})
+	s.interceptStates.Store(id, newInterceptState(id))
+
+	token, err := s.ShareIntercept(ctx, id)
+	require.NoError(t, err)
+	again, err := s.ShareIntercept(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	_, err = s.JoinIntercept(ctx, "bogus", &manager.SessionInfo{SessionId: bob})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.JoinIntercept(ctx, token, &manager.SessionInfo{SessionId: alice})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ii, err := s.JoinIntercept(ctx, token, &manager.SessionInfo{SessionId: bob})
+	require.NoError(t, err)
+	require.Len(t, ii.SharedSessions, 1)
+	assert.Equal(t, bob, ii.SharedSessions[0].SessionId)
+	route := ii.SharedSessionRoutes[bob]
+	assert.Len(t, route, 16)
+
+	// Joining twice has no effect.
+	ii, err = s.JoinIntercept(ctx, token, &manager.SessionInfo{SessionId: bob})
+	require.NoError(t, err)
+	assert.Len(t, ii.SharedSessions, 1)
+	assert.Equal(t, map[string]string{bob: route}, ii.SharedSessionRoutes)
+
+	require.NoError(t, s.LeaveIntercept(ctx, token, bob))
+	ii, _ = s.GetIntercept(id)
+	assert.Empty(t, ii.SharedSessions)
+	assert.Empty(t, ii.SharedSessionRoutes)
+
+	// A client that joined leaves when its session ends, without affecting the intercept.
+	_, err = s.JoinIntercept(ctx, token, &manager.SessionInfo{SessionId: bob})
+	require.NoError(t, err)
+	s.RemoveSession(ctx, bob)
+	ii, ok := s.GetIntercept(id)
+	require.True(t, ok)
+	assert.Empty(t, ii.SharedSessions)
+
+	// The token is invalidated when the intercept is removed.
+	s.RemoveIntercept(ctx, id)
+	_, ok = s.shareTokens.Load(token)
+	assert.False(t, ok)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -41,6 +43,7 @@ type State interface {
 	AddIntercept(context.Context, string, string, *rpc.CreateInterceptRequest) (*rpc.ClientInfo, *rpc.InterceptInfo, error)
 	AddInterceptFinalizer(string, InterceptFinalizer) error
 	AddSessionConsumptionMetrics(metrics *rpc.TunnelMetrics)
+	AddWatchUpdates(WatchUpdate, uint64)
 	AgentsLookupDNS(context.Context, string, *rpc.DNSRequest) (dnsproxy.RRs, int, error)
 	CountAgents() int
 	CountClients() int
@@ -49,6 +52,7 @@ type State interface {
 	CountTunnels() int
 	CountTunnelIngress() uint64
 	CountTunnelEgress() uint64
+	CountWatchUpdates(WatchUpdate) uint64
 	ExpireSessions(context.Context, time.Time, time.Time)
 	GetAgent(sessionID string) *rpc.AgentInfo
 	GetActiveAgent(sessionID string) *rpc.AgentInfo
@@ -65,6 +69,7 @@ type State interface {
 	HasAgent(name, namespace string) bool
 	MarkSession(*rpc.RemainRequest, time.Time) bool
 	NewInterceptInfo(string, *rpc.SessionInfo, *rpc.CreateInterceptRequest) *rpc.InterceptInfo
+	PostHeaderProbeReport(context.Context, *rpc.HeaderProbeReport)
 	PostLookupDNSResponse(context.Context, *rpc.DNSAgentResponse)
 	EnsureAgent(context.Context, string, string) error
 	PrepareIntercept(context.Context, *rpc.CreateInterceptRequest) (*rpc.PreparedIntercept, error)
@@ -74,6 +79,11 @@ type State interface {
 	FinalizeIntercept(ctx context.Context, intercept *rpc.InterceptInfo)
 	LoadMatchingIntercepts(filter func(string, *rpc.InterceptInfo) bool) map[string]*rpc.InterceptInfo
 	RemoveSession(context.Context, string)
+	ReapOrphanedIntercepts(context.Context, time.Time) []string
+	ReapStaleSessions(context.Context, string, time.Time) *rpc.ReapResult
+	RestoreOrphanedContainers(context.Context, Leases, time.Time, time.Duration) (Leases, []string)
+	PruneOrphanedAgentConfigs(context.Context, Leases, time.Time, time.Duration) (Leases, []string)
+	RemoveIdleAgents(context.Context, Leases, time.Time, time.Duration) (Leases, []string)
 	SessionDone(string) (<-chan struct{}, error)
 	SetTempLogLevel(context.Context, *rpc.LogLevelRequest)
 	SetAllClientSessionsFinalizer(finalizer allClientSessionsFinalizer)
@@ -87,14 +97,28 @@ type State interface {
 	UpdateClient(sessionID string, apply func(*rpc.ClientInfo)) *rpc.ClientInfo
 	RefreshSessionConsumptionMetrics(sessionID string)
 	ValidateAgentImage(string, bool) error
+	DescribeWorkload(ctx context.Context, name, namespace, workloadKind string) (*rpc.WorkloadDescription, error)
+	VerifyHeaderPropagation(context.Context, string, []string, *rpc.HeaderPropagationRequest) (*rpc.HeaderPropagationResult, error)
 	WaitForTempLogLevel(rpc.Manager_WatchLogLevelServer) error
 	WatchAgents(context.Context, func(sessionID string, agent *rpc.AgentInfo) bool) <-chan watchable.Snapshot[*rpc.AgentInfo]
 	WatchDial(sessionID string) <-chan *rpc.DialRequest
+	WatchAgentEvents(ctx context.Context, sessionID string) (<-chan *rpc.AgentEvent, error)
+	WatchAgentArrivalProgress(ctx context.Context, sessionID string) (<-chan *rpc.AgentArrivalProgress, error)
 	WatchIntercepts(context.Context, func(sessionID string, intercept *rpc.InterceptInfo) bool) <-chan watchable.Snapshot[*rpc.InterceptInfo]
 	WatchWorkloads(ctx context.Context, sessionID string) (ch <-chan []workload.WorkloadEvent, err error)
 	WatchLookupDNS(string) <-chan *rpc.DNSRequest
+	WatchHeaderProbes(string) <-chan *rpc.HeaderProbe
 	ValidateCreateAgent(context.Context, k8sapi.Workload, agentconfig.SidecarExt) error
-	NewWorkloadInfoWatcher(clientSession, namespace string) WorkloadInfoWatcher
+	NewWorkloadInfoWatcher(clientSession, namespace string, interval time.Duration) WorkloadInfoWatcher
+	SetRegistryProxy(sessionID string, addr netip.AddrPort) (string, error)
+	AuthorizeRegistryPull(password string) bool
+	DialRegistry(ctx context.Context) (net.Conn, error)
+	ProxyPublishedConn(ctx context.Context, conn net.Conn, sessionID string, port uint16) error
+	ShareIntercept(ctx context.Context, interceptID string) (string, error)
+	JoinIntercept(ctx context.Context, token string, session *rpc.SessionInfo) (*rpc.InterceptInfo, error)
+	SharedIntercept(token string) (*rpc.InterceptInfo, bool)
+	LeaveIntercept(ctx context.Context, token string, sessionID string) error
+	Evict(ctx context.Context, rq *rpc.EvictRequest) *rpc.ReapResult
 }
 
 type (
@@ -134,12 +158,17 @@ type state struct {
 	sessions                   *xsync.MapOf[string, SessionState]                         // info for all sessions, keyed by session id
 	agentsByName               *xsync.MapOf[string, *xsync.MapOf[string, *rpc.AgentInfo]] // indexed copy of `agents`
 	interceptStates            *xsync.MapOf[string, *interceptState]
+	headerProbeReports         *xsync.MapOf[string, chan *rpc.HeaderProbeReport] // channels for header probe reports, keyed by probe id
+	shareTokens                *xsync.MapOf[string, string]                      // intercept ids, keyed by share token
 	timedLogLevel              log.TimedLevel
 	llSubs                     *loglevelSubscribers
 	workloadWatchers           *xsync.MapOf[string, workload.Watcher] // workload watchers, created on demand and keyed by namespace
 	tunnelCounter              int32
 	tunnelIngressCounter       uint64
 	tunnelEgressCounter        uint64
+	watchUpdateCounters        [watchUpdateKinds]uint64
+	registryProxy              atomic.Pointer[registryProxyTarget]
+	registryConnCounter        atomic.Uint32
 	connectCounter             *prometheus.CounterVec
 	connectActiveStatusGauge   *prometheus.GaugeVec
 	interceptCounter           *prometheus.CounterVec
@@ -154,13 +183,15 @@ var NewStateFunc = NewState //nolint:gochecknoglobals // extension point
 func NewState(ctx context.Context) State {
 	loglevel := os.Getenv("LOG_LEVEL")
 	s := &state{
-		backgroundCtx:    ctx,
-		sessions:         xsync.NewMapOf[string, SessionState](),
-		agentsByName:     xsync.NewMapOf[string, *xsync.MapOf[string, *rpc.AgentInfo]](),
-		interceptStates:  xsync.NewMapOf[string, *interceptState](),
-		workloadWatchers: xsync.NewMapOf[string, workload.Watcher](),
-		timedLogLevel:    log.NewTimedLevel(loglevel, log.SetLevel),
-		llSubs:           newLoglevelSubscribers(),
+		backgroundCtx:      ctx,
+		sessions:           xsync.NewMapOf[string, SessionState](),
+		agentsByName:       xsync.NewMapOf[string, *xsync.MapOf[string, *rpc.AgentInfo]](),
+		interceptStates:    xsync.NewMapOf[string, *interceptState](),
+		headerProbeReports: xsync.NewMapOf[string, chan *rpc.HeaderProbeReport](),
+		shareTokens:        xsync.NewMapOf[string, string](),
+		workloadWatchers:   xsync.NewMapOf[string, workload.Watcher](),
+		timedLogLevel:      log.NewTimedLevel(loglevel, log.SetLevel),
+		llSubs:             newLoglevelSubscribers(),
 	}
 	s.self = s
 	return s
@@ -209,8 +240,8 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 
 	var agentList []*rpc.AgentInfo
 	if agentSet, ok := s.agentsByName.Load(intercept.Spec.Agent); ok {
-		agentSet.Range(func(_ string, agent *rpc.AgentInfo) bool {
-			if agent.Namespace == intercept.Spec.Namespace {
+		agentSet.Range(func(id string, agent *rpc.AgentInfo) bool {
+			if agent.Namespace == intercept.Spec.Namespace && !s.isDraining(id) {
 				agentList = append(agentList, agent)
 			}
 			return true
@@ -261,6 +292,10 @@ func (s *state) RemoveSession(ctx context.Context, sessionID string) {
 
 		// kill the session
 		defer sess.Cancel()
+		if as, ok := sess.(*agentSessionState); ok {
+			// A departing agent must not count when checking the agents of its intercepts.
+			as.draining.Store(true)
+		}
 		s.gcSessionIntercepts(ctx, sessionID)
 
 		agent, isAgent := s.agents.LoadAndDelete(sessionID)
@@ -295,6 +330,11 @@ func (s *state) gcSessionIntercepts(ctx context.Context, sessionID string) {
 		if intercept.Disposition == rpc.InterceptDispositionType_REMOVED {
 			continue
 		}
+		if slices.ContainsFunc(intercept.SharedSessions, isSession(sessionID)) {
+			// A client that joined the intercept went away.
+			s.leaveIntercept(interceptID, sessionID)
+			continue
+		}
 		if intercept.ClientSession.SessionId == sessionID {
 			// Client went away:
 			// Delete it.
@@ -304,6 +344,12 @@ func (s *state) gcSessionIntercepts(ctx context.Context, sessionID string) {
 			}
 			s.self.RemoveIntercept(ctx, interceptID)
 		} else if errCode, errMsg := s.checkAgentsForIntercept(intercept); errCode != 0 {
+			if isAgent && errCode == rpc.InterceptDispositionType_NO_AGENT && agent.PodIp == intercept.PodIp &&
+				s.awaitHandover(ctx, interceptID, intercept) {
+				// The last agent went away, most likely because its pod is being replaced. Give
+				// the agent of the new pod a chance to adopt the intercept.
+				continue
+			}
 			// Refcount went to zero:
 			// Tell the client, so that the client can tell us to delete it.
 			intercept.Disposition = errCode
@@ -418,6 +464,7 @@ func (s *state) AddAgent(agent *rpc.AgentInfo, now time.Time) string {
 	})
 	agn.Store(sessionID, agent)
 	s.sessions.Store(sessionID, newAgentSessionState(s.backgroundCtx, now))
+	s.supersedeAgents(sessionID, agent)
 
 	for interceptID, intercept := range s.intercepts.LoadAll() {
 		if intercept.Disposition == rpc.InterceptDispositionType_REMOVED {
@@ -444,9 +491,11 @@ func (s *state) GetAgent(sessionID string) *rpc.AgentInfo {
 	return ret
 }
 
+// GetActiveAgent returns the agent for the given sessionID, provided that its session is active and that
+// the agent hasn't been superseded by a newer agent.
 func (s *state) GetActiveAgent(sessionID string) *rpc.AgentInfo {
 	if ret, ok := s.agents.Load(sessionID); ok {
-		if as := s.GetSession(sessionID); as != nil && as.Active() {
+		if as := s.GetSession(sessionID); as != nil && as.Active() && !s.isDraining(sessionID) {
 			return ret
 		}
 	}
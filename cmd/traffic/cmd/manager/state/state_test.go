@@ -11,6 +11,7 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
 )
@@ -25,12 +26,14 @@ type suiteState struct {
 func (s *suiteState) SetupTest() {
 	s.ctx = dlog.NewTestContext(s.T(), false)
 	s.state = &state{
-		backgroundCtx:   s.ctx,
-		sessions:        xsync.NewMapOf[string, SessionState](),
-		agentsByName:    xsync.NewMapOf[string, *xsync.MapOf[string, *manager.AgentInfo]](),
-		interceptStates: xsync.NewMapOf[string, *interceptState](),
-		timedLogLevel:   log.NewTimedLevel("debug", log.SetLevel),
-		llSubs:          newLoglevelSubscribers(),
+		backgroundCtx:      s.ctx,
+		sessions:           xsync.NewMapOf[string, SessionState](),
+		agentsByName:       xsync.NewMapOf[string, *xsync.MapOf[string, *manager.AgentInfo]](),
+		interceptStates:    xsync.NewMapOf[string, *interceptState](),
+		headerProbeReports: xsync.NewMapOf[string, chan *manager.HeaderProbeReport](),
+		shareTokens:        xsync.NewMapOf[string, string](),
+		timedLogLevel:      log.NewTimedLevel("debug", log.SetLevel),
+		llSubs:             newLoglevelSubscribers(),
 	}
 }
 
@@ -185,6 +188,62 @@ func (s *suiteState) TestRemoveSession() {
 	assert.Equal(s.T(), s.state.sessions.Size(), 0)
 }
 
+func (s *suiteState) TestWatchAgentEvents() {
+	now := time.Now()
+	s.state.sessions.Store("session-1", newClientSessionState(s.ctx, now))
+	s.state.sessions.Store("session-2", newAgentSessionState(s.ctx, now))
+
+	_, err := s.state.WatchAgentEvents(s.ctx, "session-2")
+	s.Error(err)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	ch, err := s.state.WatchAgentEvents(ctx, "session-1")
+	s.Require().NoError(err)
+
+	ae := &manager.AgentEvent{Reason: "BackOff", ObjectName: "echo-abcde", ObjectNamespace: "default"}
+	s.state.publishAgentEvent(managerutil.WithSessionID(s.ctx, "session-1"), ae)
+	s.state.publishAgentEvent(managerutil.WithSessionID(s.ctx, "session-2"), &manager.AgentEvent{Reason: "Other"})
+	s.Equal(ae, <-ch)
+
+	cancel()
+	s.Eventually(func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (s *suiteState) TestWatchAgentArrivalProgress() {
+	s.state.sessions.Store("session-1", newClientSessionState(s.ctx, time.Now()))
+
+	ch, err := s.state.WatchAgentArrivalProgress(s.ctx, "session-1")
+	s.Require().NoError(err)
+
+	ctx := managerutil.WithSessionID(s.ctx, "session-1")
+	s.state.publishAgentProgress(ctx, manager.AgentArrivalProgress_CONFIG_UPDATED, "echo", "default", "")
+	s.state.publishAgentProgress(ctx, manager.AgentArrivalProgress_POD_SCHEDULED, "echo", "default", "echo-abcde")
+	ap := <-ch
+	s.Equal(manager.AgentArrivalProgress_CONFIG_UPDATED, ap.Stage)
+	s.Equal("echo", ap.WorkloadName)
+	ap = <-ch
+	s.Equal(manager.AgentArrivalProgress_POD_SCHEDULED, ap.Stage)
+	s.Equal("echo-abcde", ap.PodName)
+	s.NotNil(ap.Time)
+
+	s.state.RemoveSession(s.ctx, "session-1")
+	s.Eventually(func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 func TestSuiteState(testing *testing.T) {
 	suite.Run(testing, new(suiteState))
 }
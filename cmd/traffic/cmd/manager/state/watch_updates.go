@@ -0,0 +1,33 @@
+package state
+
+import (
+	"sync/atomic"
+)
+
+// WatchUpdate is the fate of an update on a stream that watches workloads or agents.
+type WatchUpdate int
+
+const (
+	// WatchUpdateSent is an update that was sent to the client.
+	WatchUpdateSent WatchUpdate = iota
+
+	// WatchUpdateCoalesced is an update that was replaced by a later update before it was sent, because it
+	// arrived within the coalescing interval of the stream.
+	WatchUpdateCoalesced
+
+	// WatchUpdateDropped is an update that wasn't sent, because it didn't change anything that the client
+	// had already received.
+	WatchUpdateDropped
+
+	watchUpdateKinds
+)
+
+// AddWatchUpdates adds n to the counter of the given kind of watch updates.
+func (s *state) AddWatchUpdates(kind WatchUpdate, n uint64) {
+	atomic.AddUint64(&s.watchUpdateCounters[kind], n)
+}
+
+// CountWatchUpdates returns the counter of the given kind of watch updates.
+func (s *state) CountWatchUpdates(kind WatchUpdate) uint64 {
+	return atomic.LoadUint64(&s.watchUpdateCounters[kind])
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
@@ -32,13 +33,19 @@ type workloadInfoWatcher struct {
 	interceptInfos map[string]*rpc.InterceptInfo
 	start          time.Time
 	ticker         *time.Ticker
+
+	// interval is the minimum interval between two deltas sent on the stream. Events that arrive within
+	// the interval are coalesced into one delta.
+	interval time.Duration
+	lastSend time.Time
 }
 
-func (s *state) NewWorkloadInfoWatcher(clientSession, namespace string) WorkloadInfoWatcher {
+func (s *state) NewWorkloadInfoWatcher(clientSession, namespace string, interval time.Duration) WorkloadInfoWatcher {
 	return &workloadInfoWatcher{
 		State:         s,
 		clientSession: clientSession,
 		namespace:     namespace,
+		interval:      interval,
 	}
 }
 
@@ -114,14 +121,39 @@ func (wf *workloadInfoWatcher) Watch(ctx context.Context, stream rpc.Manager_Wat
 func (wf *workloadInfoWatcher) getIntercepts(name, namespace string) (iis []*rpc.WorkloadInfo_Intercept) {
 	for _, ii := range wf.interceptInfos {
 		if name == ii.Spec.Agent && namespace == ii.Spec.Namespace && ii.Disposition == rpc.InterceptDispositionType_ACTIVE {
-			iis = append(iis, &rpc.WorkloadInfo_Intercept{
-				Client: ii.Spec.Client,
-			})
+			iis = append(iis, wf.rpcIntercept(ii))
 		}
 	}
+	sortIntercepts(iis)
 	return iis
 }
 
+func (wf *workloadInfoWatcher) rpcIntercept(ii *rpc.InterceptInfo) *rpc.WorkloadInfo_Intercept {
+	return &rpc.WorkloadInfo_Intercept{
+		Client:            ii.Spec.Client,
+		Name:              ii.Spec.Name,
+		Own:               ii.ClientSession.GetSessionId() == wf.clientSession,
+		MechanismArgs:     ii.Spec.MechanismArgs,
+		MechanismArgsDesc: ii.MechanismArgsDesc,
+	}
+}
+
+// sortIntercepts sorts the given intercepts so that equal sets of intercepts compare equal.
+func sortIntercepts(iis []*rpc.WorkloadInfo_Intercept) {
+	slices.SortFunc(iis, func(a, b *rpc.WorkloadInfo_Intercept) int {
+		if c := strings.Compare(a.Client, b.Client); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+func interceptsEqual(a, b []*rpc.WorkloadInfo_Intercept) bool {
+	return slices.EqualFunc(a, b, func(x, y *rpc.WorkloadInfo_Intercept) bool {
+		return proto.Equal(x, y)
+	})
+}
+
 func (wf *workloadInfoWatcher) sendEvents(ctx context.Context, sendEmpty bool) {
 	// Time to send what we have
 	wf.ticker.Reset(time.Duration(math.MaxInt64))
@@ -134,6 +166,9 @@ func (wf *workloadInfoWatcher) sendEvents(ctx context.Context, sendEmpty bool) {
 		}
 		evs = append(evs, rew)
 	}
+	if dropped := len(wf.workloadEvents) - len(evs); dropped > 0 {
+		wf.AddWatchUpdates(WatchUpdateDropped, uint64(dropped))
+	}
 	if !sendEmpty && len(evs) == 0 {
 		return
 	}
@@ -146,13 +181,21 @@ func (wf *workloadInfoWatcher) sendEvents(ctx context.Context, sendEmpty bool) {
 		dlog.Warnf(ctx, "failed to send workload events delta: %v", err)
 		return
 	}
+	wf.AddWatchUpdates(WatchUpdateSent, uint64(len(evs)))
 	wf.lastEvents = wf.workloadEvents
 	wf.workloadEvents = make(map[string]*rpc.WorkloadEvent)
 	wf.start = time.Now()
+	wf.lastSend = wf.start
 }
 
+// resetTicker schedules the sending of the accumulated events. The delay ensures that events arriving in a
+// burst end up in the same delta, and that deltas aren't sent more often than once per interval.
 func (wf *workloadInfoWatcher) resetTicker() {
-	wf.ticker.Reset(5 * time.Millisecond)
+	delay := 5 * time.Millisecond
+	if d := time.Until(wf.lastSend.Add(wf.interval)); d > delay {
+		delay = d
+	}
+	wf.ticker.Reset(delay)
 }
 
 func rpcKind(s string) rpc.WorkloadInfo_Kind {
@@ -165,6 +208,8 @@ func rpcKind(s string) rpc.WorkloadInfo_Kind {
 		return rpc.WorkloadInfo_STATEFULSET
 	case "rollout":
 		return rpc.WorkloadInfo_ROLLOUT
+	case "daemonset":
+		return rpc.WorkloadInfo_DAEMONSET
 	default:
 		return rpc.WorkloadInfo_UNSPECIFIED
 	}
@@ -202,6 +247,9 @@ func (wf *workloadInfoWatcher) addEvent(
 	as rpc.WorkloadInfo_AgentState,
 	iClients []*rpc.WorkloadInfo_Intercept,
 ) {
+	if _, ok := wf.workloadEvents[wl.GetName()]; ok {
+		wf.AddWatchUpdates(WatchUpdateCoalesced, 1)
+	}
 	wf.workloadEvents[wl.GetName()] = &rpc.WorkloadEvent{
 		Type:     rpc.WorkloadEvent_Type(eventType),
 		Workload: rpcWorkload(wl, as, iClients),
@@ -280,7 +328,7 @@ func (wf *workloadInfoWatcher) handleAgentSnapshot(ctx context.Context, ais map[
 							AgentState: as,
 						},
 					}
-					wf.sendEvents(ctx, false)
+					wf.resetTicker()
 				}
 			}
 		}
@@ -296,7 +344,7 @@ func (wf *workloadInfoWatcher) handleAgentSnapshot(ctx context.Context, ais map[
 		if w, ok := wf.workloadEvents[name]; ok && w.Type != rpc.WorkloadEvent_DELETED {
 			wl := w.Workload
 			dlog.Debugf(ctx, "WorkloadInfoEvent: AgentInfo %s.%s %s %s", a.Name, a.Namespace, as, w.Workload.State)
-			if wl.AgentState != as {
+			if wl.AgentState != as || !interceptsEqual(wl.InterceptClients, iClients) {
 				wl.AgentState = as
 				wl.InterceptClients = iClients
 				wf.resetTicker()
@@ -341,10 +389,11 @@ func (wf *workloadInfoWatcher) handleInterceptSnapshot(ctx context.Context, iis
 		iClients := make([]*rpc.WorkloadInfo_Intercept, len(iis))
 		as := rpc.WorkloadInfo_INTERCEPTED
 		for i, ii := range iis {
-			iClients[i] = &rpc.WorkloadInfo_Intercept{Client: ii.Spec.Client}
+			iClients[i] = wf.rpcIntercept(ii)
 		}
+		sortIntercepts(iClients)
 		if w, ok := wf.workloadEvents[name]; ok && w.Type != rpc.WorkloadEvent_DELETED {
-			if w.Workload.AgentState != as {
+			if w.Workload.AgentState != as || !interceptsEqual(w.Workload.InterceptClients, iClients) {
 				w.Workload.AgentState = as
 				w.Workload.InterceptClients = iClients
 				dlog.Debugf(ctx, "WorkloadInfoEvent: InterceptInfo %s.%s %s %s", w.Workload.Name, w.Workload.Namespace, as, w.Workload.State)
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_workloadInfoWatcher_getIntercepts(t *testing.T) {
+	cept := func(id, session, client string, disposition rpc.InterceptDispositionType) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Id:                id,
+			Spec:              &rpc.InterceptSpec{Name: id, Client: client, Agent: "echo", Namespace: "default", MechanismArgs: []string{"--match=x-user=" + client}},
+			ClientSession:     &rpc.SessionInfo{SessionId: session},
+			Disposition:       disposition,
+			MechanismArgsDesc: "requests with x-user=" + client,
+		}
+	}
+	wf := &workloadInfoWatcher{
+		clientSession: "s1",
+		namespace:     "default",
+		interceptInfos: map[string]*rpc.InterceptInfo{
+			"c": cept("c", "s2", "bob@box", rpc.InterceptDispositionType_ACTIVE),
+			"a": cept("a", "s1", "alice@laptop", rpc.InterceptDispositionType_ACTIVE),
+			"w": cept("w", "s2", "bob@box", rpc.InterceptDispositionType_WAITING),
+		},
+	}
+	iis := wf.getIntercepts("echo", "default")
+	require.Len(t, iis, 2)
+	assert.Equal(t, "a", iis[0].Name)
+	assert.True(t, iis[0].Own)
+	assert.Equal(t, "c", iis[1].Name)
+	assert.False(t, iis[1].Own)
+	assert.Equal(t, "bob@box", iis[1].Client)
+	assert.Equal(t, []string{"--match=x-user=bob@box"}, iis[1].MechanismArgs)
+	assert.Equal(t, "requests with x-user=bob@box", iis[1].MechanismArgsDesc)
+
+	assert.True(t, interceptsEqual(iis, wf.getIntercepts("echo", "default")))
+	assert.False(t, interceptsEqual(iis, iis[:1]))
+	assert.Empty(t, wf.getIntercepts("other", "default"))
+}
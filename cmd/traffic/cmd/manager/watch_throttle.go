@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+// maxWatchInterval is the longest interval between two updates that a client can request on a watch stream.
+const maxWatchInterval = time.Minute
+
+// watchInterval returns the minimum interval between two updates on a watch stream. It's the interval
+// requested by the client, but never shorter than the configured coalescing window, and never longer
+// than maxWatchInterval.
+func watchInterval(ctx context.Context, requested *durationpb.Duration) time.Duration {
+	iv := managerutil.GetEnv(ctx).WatchCoalesceWindow
+	if rq := requested.AsDuration(); rq > iv {
+		iv = min(rq, maxWatchInterval)
+	}
+	return iv
+}
+
+// watchThrottle limits the rate of the snapshots sent on a watch stream to one per interval. A snapshot
+// that arrives before the interval since the previous send has elapsed is held back, and replaced by any
+// snapshot that arrives after it, so that only the latest one is sent once the interval has elapsed.
+type watchThrottle[T any] struct {
+	state    state.State
+	interval time.Duration
+	lastSend time.Time
+	pending  T
+	held     bool
+	timer    *time.Timer
+}
+
+func newWatchThrottle[T any](s state.State, interval time.Duration) *watchThrottle[T] {
+	return &watchThrottle[T]{
+		state:    s,
+		interval: interval,
+		timer:    time.NewTimer(time.Duration(math.MaxInt64)),
+	}
+}
+
+// C returns the channel that fires when a held back snapshot is due.
+func (t *watchThrottle[T]) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Add adds the given snapshot and returns true if it can be sent right away. If not, the snapshot is
+// held back and sent when C fires.
+func (t *watchThrottle[T]) Add(snapshot T) bool {
+	if t.held {
+		t.state.AddWatchUpdates(state.WatchUpdateCoalesced, 1)
+	}
+	t.pending = snapshot
+	d := time.Until(t.lastSend.Add(t.interval))
+	if d <= 0 {
+		return true
+	}
+	if !t.held {
+		t.held = true
+		t.timer.Reset(d)
+	}
+	return false
+}
+
+// Take returns the snapshot that was added last, and records that it's sent.
+func (t *watchThrottle[T]) Take() T {
+	var zero T
+	snapshot := t.pending
+	t.pending = zero
+	if t.held {
+		t.held = false
+		t.timer.Stop()
+	}
+	t.lastSend = time.Now()
+	t.state.AddWatchUpdates(state.WatchUpdateSent, 1)
+	return snapshot
+}
+
+// Stop stops the timer of the throttle.
+func (t *watchThrottle[T]) Stop() {
+	t.timer.Stop()
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
+)
+
+func Test_watchInterval(t *testing.T) {
+	ctx := managerutil.WithEnv(dlog.NewTestContext(t, false), &managerutil.Env{WatchCoalesceWindow: 100 * time.Millisecond})
+	assert.Equal(t, 100*time.Millisecond, watchInterval(ctx, nil))
+	assert.Equal(t, 100*time.Millisecond, watchInterval(ctx, durationpb.New(10*time.Millisecond)))
+	assert.Equal(t, time.Second, watchInterval(ctx, durationpb.New(time.Second)))
+	assert.Equal(t, maxWatchInterval, watchInterval(ctx, durationpb.New(time.Hour)))
+}
+
+func Test_watchThrottle(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := state.NewState(ctx)
+	th := newWatchThrottle[int](s, 100*time.Millisecond)
+	defer th.Stop()
+
+	// The first snapshot is sent right away.
+	require.True(t, th.Add(1))
+	assert.Equal(t, 1, th.Take())
+
+	// Snapshots that arrive within the interval are held back, and only the latest one is sent.
+	assert.False(t, th.Add(2))
+	assert.False(t, th.Add(3))
+	select {
+	case <-th.C():
+		assert.Equal(t, 3, th.Take())
+	case <-time.After(time.Second):
+		t.Fatal("held back snapshot was never due")
+	}
+	assert.Equal(t, uint64(2), s.CountWatchUpdates(state.WatchUpdateSent))
+	assert.Equal(t, uint64(1), s.CountWatchUpdates(state.WatchUpdateCoalesced))
+
+	// A snapshot that arrives after the interval is sent right away.
+	time.Sleep(110 * time.Millisecond)
+	require.True(t, th.Add(4))
+	assert.Equal(t, 4, th.Take())
+}
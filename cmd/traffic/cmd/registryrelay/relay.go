@@ -0,0 +1,90 @@
+package registryrelay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/version"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Main is the main function of the registry relay that runs on every node when the registry proxy of the
+// traffic-manager is enabled. It listens to REGISTRY_RELAY_LISTEN, an address on the node's loopback interface,
+// and relays the connections that the node's container runtime makes when it pulls images to the registry proxy
+// at REGISTRY_RELAY_TARGET. Binding to the loopback interface means that the relay is only reachable from the node
+// itself, and that the runtime pulls from "localhost", which it treats as an insecure registry, without relying on
+// the node routing localhost traffic to a NodePort.
+func Main(ctx context.Context, _ ...string) error {
+	dlog.Infof(ctx, "Traffic Registry Relay %s", version.Version)
+	listen := os.Getenv("REGISTRY_RELAY_LISTEN")
+	target := os.Getenv("REGISTRY_RELAY_TARGET")
+	if listen == "" || target == "" {
+		return errors.New("REGISTRY_RELAY_LISTEN and REGISTRY_RELAY_TARGET must be set")
+	}
+	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableSignalHandling: true,
+	})
+	g.Go("relay", func(ctx context.Context) error {
+		return relay(ctx, listen, target)
+	})
+	return g.Wait()
+}
+
+func relay(ctx context.Context, listen, target string) error {
+	lc := net.ListenConfig{}
+	ln, err := lc.Listen(ctx, "tcp", listen)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	dlog.Infof(ctx, "Relaying %s to %s", ln.Addr(), target)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			if err := relayConn(ctx, conn, target); err != nil {
+				dlog.Errorf(ctx, "registry relay: %v", err)
+			}
+		}()
+	}
+}
+
+func relayConn(ctx context.Context, conn net.Conn, target string) error {
+	defer conn.Close()
+	d := net.Dialer{Timeout: dialTimeout}
+	tc, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return fmt.Errorf("unable to dial %s: %w", target, err)
+	}
+	defer tc.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	cp := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+	}
+	go cp(tc, conn)
+	go cp(conn, tc)
+	wg.Wait()
+	return nil
+}
@@ -11,14 +11,16 @@ import (
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/agent"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/agentinit"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/registryrelay"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
 )
 
 func main() {
 	cmds := map[string]func(ctx context.Context, args ...string) error{
-		"agent":      agent.Main,
-		"agent-init": agentinit.Main,
-		"manager":    manager.Main,
+		"agent":          agent.Main,
+		"agent-init":     agentinit.Main,
+		"manager":        manager.Main,
+		"registry-relay": registryrelay.Main,
 	}
 
 	var name string
@@ -40,7 +40,7 @@ func (s *workloadConfigurationSuite) disabledWorkloadKind(tp, wl string) {
 	time.Sleep(6 * time.Second)
 
 	list := itest.TelepresenceOk(ctx, "list")
-	require.Equal("No Workloads (Deployments, StatefulSets, ReplicaSets, or Rollouts)", list)
+	require.Equal("No Workloads (Deployments, StatefulSets, ReplicaSets, DaemonSets, or Rollouts)", list)
 
 	_, stderr, err := itest.Telepresence(ctx, "intercept", wl)
 	require.Error(err)
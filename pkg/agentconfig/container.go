@@ -3,6 +3,7 @@ package agentconfig
 import (
 	"context"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -64,13 +65,36 @@ func AgentContainer(
 					FieldPath:  "metadata.name",
 				},
 			},
+		},
+		core.EnvVar{
+			Name: EnvPrefixAgent + "NAMESPACE",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.namespace",
+				},
+			},
+		},
+		core.EnvVar{
+			Name: EnvPrefixAgent + "NODE_NAME",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "spec.nodeName",
+				},
+			},
 		})
 
 	mounts := make([]core.VolumeMount, 0, len(config.Containers)*3)
 	var agentVersion semver.Version
-	if sep := strings.LastIndexByte(config.AgentImage, ':'); sep > 0 {
+	agentImage := config.AgentImage
+	if at := strings.IndexByte(agentImage, '@'); at > 0 {
+		// The image may be pinned to a digest, e.g. "tel2:2.21.0@sha256:..."
+		agentImage = agentImage[:at]
+	}
+	if sep := strings.LastIndexByte(agentImage, ':'); sep > 0 {
 		var err error
-		if agentVersion, err = semver.Parse(config.AgentImage[sep+1:]); err != nil {
+		if agentVersion, err = semver.Parse(agentImage[sep+1:]); err != nil {
 			dlog.Errorf(ctx, "unable to parse agent version from image name %s", config.AgentImage)
 		}
 	}
@@ -164,9 +188,21 @@ func AgentContainer(
 			return nil
 		}
 	}
-	ac.SecurityContext = appSc
+	ac.SecurityContext = config.PodSecurity.securityContext(appSc, pod.Spec.SecurityContext)
+	if len(config.PodSecurity.Volumes()) > 0 {
+		// The traffic-agent creates directories in MountPrefixApp.
+		ac.VolumeMounts = append([]core.VolumeMount{{
+			Name:      AppMountsVolumeName,
+			MountPath: MountPrefixApp,
+		}}, ac.VolumeMounts...)
+	}
 
-	return ac
+	pc, err := config.Patch.PatchContainer(ac)
+	if err != nil {
+		dlog.Error(ctx, err)
+		return nil
+	}
+	return pc
 }
 
 // Find security context of the first container (with both intercepts and a set security context) and ensure
@@ -229,6 +265,9 @@ func InitContainer(config *Sidecar) *core.Container {
 			},
 		},
 	}
+	if config.PortRedirection == PortRedirectionEBPF {
+		ic.SecurityContext.Capabilities.Add = slices.Clone(EBPFCapabilities)
+	}
 	if r := config.InitResources; r != nil {
 		ic.Resources = *r
 	}
@@ -441,6 +480,18 @@ func appendAppContainerEnv(app *core.Container, cc *Container, es []core.EnvVar)
 	pfx := EnvPrefixApp + cc.EnvPrefix
 	pfxReplace := "$(" + pfx + "$1)"
 	for _, e := range app.Env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			// Mark the variable as a secret, so that the agent can exclude it from intercepts that don't
+			// include secrets.
+			es = append(es, core.EnvVar{Name: EnvPrefixSecret + cc.EnvPrefix + e.Name})
+		}
+		if vf := e.ValueFrom; vf != nil && vf.ResourceFieldRef != nil && vf.ResourceFieldRef.ContainerName == "" {
+			// A resourceFieldRef without a containerName refers to the container that declares it, so it must
+			// be made explicit for the agent to get the resources of the app container.
+			vf = vf.DeepCopy()
+			vf.ResourceFieldRef.ContainerName = app.Name
+			e.ValueFrom = vf
+		}
 		e.Name = pfx + e.Name
 		e.Value = envRxReplace.ReplaceAllString(e.Value, pfxReplace)
 		es = append(es, e)
@@ -450,6 +501,13 @@ func appendAppContainerEnv(app *core.Container, cc *Container, es []core.EnvVar)
 
 func appendAppContainerEnvFrom(app *core.Container, cc *Container, es []core.EnvFromSource) []core.EnvFromSource {
 	for _, e := range app.EnvFrom {
+		if e.SecretRef != nil {
+			// The keys of the secret are unknown until the agent starts, so the secret is added a second time
+			// using a prefix that tells the agent which variables that are secrets.
+			se := e
+			se.Prefix = EnvPrefixSecret + cc.EnvPrefix + e.Prefix
+			es = append(es, se)
+		}
 		e.Prefix = EnvPrefixApp + cc.EnvPrefix + e.Prefix
 		es = append(es, e)
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-json-experiment/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
 )
 
 func Test_prefixInterpolated(t *testing.T) {
@@ -98,3 +99,21 @@ func Test_ReplacePolicy(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, string(data), `{"replace":1}`)
 }
+
+func Test_appendAppContainerEnv(t *testing.T) {
+	app := &core.Container{
+		Name: "echo",
+		Env: []core.EnvVar{
+			{Name: "HOST", Value: "$(NAME).local"},
+			{Name: "CPU", ValueFrom: &core.EnvVarSource{ResourceFieldRef: &core.ResourceFieldSelector{Resource: "limits.cpu"}}},
+			{Name: "TOKEN", ValueFrom: &core.EnvVarSource{SecretKeyRef: &core.SecretKeySelector{Key: "token"}}},
+		},
+	}
+	es := appendAppContainerEnv(app, &Container{EnvPrefix: "A_"}, nil)
+	require.Len(t, es, 4)
+	assert.Equal(t, core.EnvVar{Name: "_TEL_APP_A_HOST", Value: "$(_TEL_APP_A_NAME).local"}, es[0])
+	assert.Equal(t, "echo", es[1].ValueFrom.ResourceFieldRef.ContainerName)
+	assert.Empty(t, app.Env[1].ValueFrom.ResourceFieldRef.ContainerName, "app container must not be modified")
+	assert.Equal(t, core.EnvVar{Name: "_TEL_SEC_A_TOKEN"}, es[2])
+	assert.Equal(t, "_TEL_APP_A_TOKEN", es[3].Name)
+}
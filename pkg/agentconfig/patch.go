@@ -0,0 +1,59 @@
+package agentconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// SidecarPatch contains additions and changes that are applied to every injected traffic-agent, e.g. to add
+// volumes, environment variables, or tolerations that a cluster requires.
+type SidecarPatch struct {
+	// Container is a strategic merge patch that is applied to the traffic-agent container.
+	Container map[string]any `json:"container,omitempty"`
+
+	// Volumes are added to the pod, typically so that they can be mounted by the traffic-agent container.
+	Volumes []core.Volume `json:"volumes,omitempty"`
+
+	// Tolerations are added to the pod.
+	Tolerations []core.Toleration `json:"tolerations,omitempty"`
+}
+
+// Validate checks that the container patch can be applied to a traffic-agent container and that the
+// patched container retains its name.
+func (p *SidecarPatch) Validate() error {
+	_, err := p.PatchContainer(&core.Container{Name: ContainerName})
+	return err
+}
+
+// PatchContainer returns the result of applying the strategic merge patch of the receiver to the given
+// traffic-agent container. The given container is returned unchanged when there's no patch.
+func (p *SidecarPatch) PatchContainer(ac *core.Container) (*core.Container, error) {
+	if p == nil || len(p.Container) == 0 {
+		return ac, nil
+	}
+	orig, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ac)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := strategicpatch.StrategicMergeMapPatch(orig, p.Container, core.Container{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply the %s container patch: %w", ContainerName, err)
+	}
+	// A round-trip through JSON ensures that a patch with fields of the wrong type is rejected.
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return nil, err
+	}
+	pc := &core.Container{}
+	if err = json.Unmarshal(data, pc); err != nil {
+		return nil, fmt.Errorf("invalid %s container patch: %w", ContainerName, err)
+	}
+	if pc.Name != ac.Name {
+		return nil, fmt.Errorf("the %s container patch must not change the container name", ContainerName)
+	}
+	return pc, nil
+}
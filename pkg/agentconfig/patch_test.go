@@ -0,0 +1,54 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func TestSidecarPatch_PatchContainer(t *testing.T) {
+	ac := &core.Container{
+		Name:  ContainerName,
+		Image: "tel2",
+		Env:   []core.EnvVar{{Name: "A", Value: "1"}},
+	}
+
+	var sp *SidecarPatch
+	pc, err := sp.PatchContainer(ac)
+	require.NoError(t, err)
+	assert.Same(t, ac, pc)
+
+	parse := func(js string) *SidecarPatch {
+		t.Helper()
+		var sp SidecarPatch
+		require.NoError(t, json.Unmarshal([]byte(js), &sp))
+		return &sp
+	}
+
+	sp = parse(`{
+  "container": {
+    "env": [{"name": "A", "value": "2"}, {"name": "B", "value": "3"}],
+    "volumeMounts": [{"name": "ca", "mountPath": "/etc/ca"}],
+    "securityContext": {"readOnlyRootFilesystem": true}
+  },
+  "volumes": [{"name": "ca", "configMap": {"name": "ca"}}]
+}`)
+	require.NoError(t, sp.Validate())
+	pc, err = sp.PatchContainer(ac)
+	require.NoError(t, err)
+	assert.Equal(t, "tel2", pc.Image)
+	assert.Equal(t, []core.EnvVar{{Name: "A", Value: "2"}, {Name: "B", Value: "3"}}, pc.Env)
+	assert.Equal(t, []core.VolumeMount{{Name: "ca", MountPath: "/etc/ca"}}, pc.VolumeMounts)
+	require.NotNil(t, pc.SecurityContext)
+	assert.True(t, *pc.SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, []core.EnvVar{{Name: "A", Value: "1"}}, ac.Env, "original container was modified")
+
+	sp = parse(`{"container": {"name": "other"}}`)
+	assert.ErrorContains(t, sp.Validate(), "must not change the container name")
+
+	sp = parse(`{"container": {"env": "A=1"}}`)
+	assert.Error(t, sp.Validate())
+}
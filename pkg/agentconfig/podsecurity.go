@@ -0,0 +1,147 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// The Pod Security Standards that the injected containers can comply with. See
+// https://kubernetes.io/docs/concepts/security/pod-security-standards/
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// AppMountsVolumeName is the name of the emptyDir volume that is mounted at MountPrefixApp when the root filesystem
+// of the traffic-agent is read-only, so that the traffic-agent can create directories there.
+const AppMountsVolumeName = "tel-agent-app-mounts"
+
+// PodSecurity controls how the injected containers comply with the Pod Security Standard of their namespace.
+type PodSecurity struct {
+	// Standard is one of PodSecurityPrivileged, PodSecurityBaseline, or PodSecurityRestricted. Empty means privileged.
+	Standard string `json:"standard,omitzero"`
+
+	// RunAsUser is the UID that the traffic-agent runs as under the restricted standard, unless its security context
+	// or the security context of the pod declares a non-root UID.
+	RunAsUser int64 `json:"runAsUser,omitzero"`
+
+	// ReadOnlyRootFilesystem makes the root filesystem of the traffic-agent read-only.
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitzero"`
+}
+
+// ValidatePodSecurityStandard returns an error unless the given string is the name of a Pod Security Standard.
+func ValidatePodSecurityStandard(s string) error {
+	switch s {
+	case PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted:
+		return nil
+	default:
+		return fmt.Errorf("invalid Pod Security Standard %q, must be one of %q, %q, or %q",
+			s, PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted)
+	}
+}
+
+// Restricted returns true when the injected containers must comply with the restricted standard.
+func (ps *PodSecurity) Restricted() bool {
+	return ps != nil && ps.Standard == PodSecurityRestricted
+}
+
+// AllowsInitContainer returns false when the standard doesn't allow the NET_ADMIN capability that the init
+// container needs.
+func (ps *PodSecurity) AllowsInitContainer() bool {
+	return ps == nil || ps.Standard == "" || ps.Standard == PodSecurityPrivileged
+}
+
+// Volumes returns the volumes that the pod needs in addition to the AgentVolumes.
+func (ps *PodSecurity) Volumes() []core.Volume {
+	if ps == nil || !ps.ReadOnlyRootFilesystem {
+		return nil
+	}
+	return []core.Volume{{
+		Name: AppMountsVolumeName,
+		VolumeSource: core.VolumeSource{
+			EmptyDir: &core.EmptyDirVolumeSource{},
+		},
+	}}
+}
+
+// NeedsInitContainer returns true when an intercept of the given config targets a headless service or a
+// numeric container port, because the init container must then set up the iptables rules of the pod. When the
+// config uses eBPF port redirection, the init container is needed to attach the eBPF programs that redirect the
+// numeric container ports. CheckPortRedirection rejects the intercepts that the eBPF programs can't redirect.
+func NeedsInitContainer(config *Sidecar) bool {
+	if config.PortRedirection == PortRedirectionEBPF {
+		return len(EBPFRedirectedIntercepts(config)) > 0
+	}
+	for _, cc := range config.Containers {
+		for _, ic := range cc.Intercepts {
+			if ic.Headless || ic.TargetPortNumeric {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckPodSecurity returns an error when the injected containers of the given config can't comply with the
+// Pod Security Standard of the config.
+func CheckPodSecurity(config *Sidecar) error {
+	if NeedsInitContainer(config) && !config.PodSecurity.AllowsInitContainer() {
+		caps := "the NET_ADMIN capability"
+		if config.PortRedirection == PortRedirectionEBPF {
+			caps = "the BPF and NET_ADMIN capabilities"
+		}
+		return fmt.Errorf("%s %s.%s needs the %s init container, which requires %s that the %s "+
+			"Pod Security Standard doesn't allow. Use a symbolic targetPort in a service that isn't headless",
+			config.WorkloadKind, config.WorkloadName, config.Namespace, InitContainerName, caps, config.PodSecurity.Standard)
+	}
+	return nil
+}
+
+// securityContext returns a copy of the given security context of the traffic-agent, adjusted to comply with the
+// Pod Security Standard of the receiver, given the security context of the pod.
+func (ps *PodSecurity) securityContext(sc *core.SecurityContext, psc *core.PodSecurityContext) *core.SecurityContext {
+	if ps == nil || !(ps.Restricted() || ps.ReadOnlyRootFilesystem) {
+		return sc
+	}
+	if sc == nil {
+		sc = &core.SecurityContext{}
+	} else {
+		sc = sc.DeepCopy()
+	}
+	if ps.ReadOnlyRootFilesystem {
+		sc.ReadOnlyRootFilesystem = ptr.To(true)
+	}
+	if !ps.Restricted() {
+		return sc
+	}
+	if psc == nil {
+		psc = &core.PodSecurityContext{}
+	}
+	sc.Privileged = nil
+	sc.AllowPrivilegeEscalation = ptr.To(false)
+	// The traffic binary has the NET_BIND_SERVICE file capability, so it must stay in the bounding set.
+	sc.Capabilities = &core.Capabilities{
+		Drop: []core.Capability{"ALL"},
+		Add:  []core.Capability{"NET_BIND_SERVICE"},
+	}
+	sc.RunAsNonRoot = ptr.To(true)
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		sc.RunAsUser = nil
+	}
+	if sc.RunAsUser == nil && (psc.RunAsUser == nil || *psc.RunAsUser == 0) && ps.RunAsUser > 0 {
+		// The traffic-agent image doesn't declare a non-root user.
+		sc.RunAsUser = ptr.To(ps.RunAsUser)
+	}
+	sp := sc.SeccompProfile
+	if sp == nil {
+		// The container inherits the seccomp profile of the pod.
+		sp = psc.SeccompProfile
+	}
+	if sp == nil || sp.Type == core.SeccompProfileTypeUnconfined {
+		sc.SeccompProfile = &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault}
+	}
+	return sc
+}
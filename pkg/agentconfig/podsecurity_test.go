@@ -0,0 +1,87 @@
+package agentconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestPodSecurity_securityContext(t *testing.T) {
+	var ps *PodSecurity
+	sc := &core.SecurityContext{RunAsUser: ptr.To(int64(0))}
+	assert.Same(t, sc, ps.securityContext(sc, nil))
+
+	ps = &PodSecurity{Standard: PodSecurityRestricted, RunAsUser: 1000}
+	rsc := ps.securityContext(sc, nil)
+	assert.Equal(t, &core.SecurityContext{
+		Capabilities: &core.Capabilities{
+			Drop: []core.Capability{"ALL"},
+			Add:  []core.Capability{"NET_BIND_SERVICE"},
+		},
+		RunAsUser:                ptr.To(int64(1000)),
+		RunAsNonRoot:             ptr.To(true),
+		AllowPrivilegeEscalation: ptr.To(false),
+		SeccompProfile:           &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+	}, rsc)
+	assert.Equal(t, int64(0), *sc.RunAsUser, "original security context was modified")
+
+	// The UID and seccomp profile of the pod are retained.
+	psc := &core.PodSecurityContext{
+		RunAsUser:      ptr.To(int64(1000680000)),
+		SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeLocalhost, LocalhostProfile: ptr.To("agent.json")},
+	}
+	rsc = ps.securityContext(nil, psc)
+	assert.Nil(t, rsc.RunAsUser)
+	assert.Nil(t, rsc.SeccompProfile)
+
+	// An unconfined seccomp profile of the pod is not inherited.
+	psc = &core.PodSecurityContext{SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeUnconfined}}
+	rsc = ps.securityContext(nil, psc)
+	assert.Equal(t, &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault}, rsc.SeccompProfile)
+
+	ps = &PodSecurity{Standard: PodSecurityBaseline, ReadOnlyRootFilesystem: true}
+	rsc = ps.securityContext(nil, nil)
+	assert.Equal(t, &core.SecurityContext{ReadOnlyRootFilesystem: ptr.To(true)}, rsc)
+}
+
+func TestCheckPodSecurity(t *testing.T) {
+	config := &Sidecar{
+		WorkloadKind: "Deployment",
+		WorkloadName: "echo",
+		Namespace:    "default",
+		Containers: []*Container{{
+			Intercepts: []*Intercept{{TargetPortNumeric: true}},
+		}},
+	}
+	require.NoError(t, CheckPodSecurity(config))
+
+	config.PodSecurity = &PodSecurity{Standard: PodSecurityPrivileged}
+	require.NoError(t, CheckPodSecurity(config))
+
+	config.PodSecurity.Standard = PodSecurityBaseline
+	assert.ErrorContains(t, CheckPodSecurity(config), "the baseline Pod Security Standard doesn't allow")
+
+	config.Containers[0].Intercepts[0].TargetPortNumeric = false
+	require.NoError(t, CheckPodSecurity(config))
+}
+
+func TestAgentContainer_readOnlyRootFilesystem(t *testing.T) {
+	config := &Sidecar{
+		AgentImage: "tel2",
+		Containers: []*Container{{
+			Name:       "echo",
+			Intercepts: []*Intercept{{ContainerPortName: "http", AgentPort: 9900, Protocol: core.ProtocolTCP}},
+		}},
+		PodSecurity: &PodSecurity{Standard: PodSecurityRestricted, RunAsUser: 1000, ReadOnlyRootFilesystem: true},
+	}
+	pod := &core.Pod{Spec: core.PodSpec{Containers: []core.Container{{Name: "echo"}}}}
+	ac := AgentContainer(context.Background(), pod, config)
+	require.NotNil(t, ac)
+	assert.True(t, *ac.SecurityContext.ReadOnlyRootFilesystem)
+	assert.True(t, *ac.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, core.VolumeMount{Name: AppMountsVolumeName, MountPath: MountPrefixApp}, ac.VolumeMounts[0])
+}
@@ -0,0 +1,69 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+)
+
+// The ways that the traffic-agent can redirect the numeric container ports of intercepts to its agent ports.
+const (
+	// PortRedirectionIPTables uses iptables rules that are set up by the tel-agent-init container.
+	PortRedirectionIPTables = "iptables"
+
+	// PortRedirectionEBPF uses eBPF programs that the tel-agent-init container attaches to the network interfaces of
+	// the pod.
+	PortRedirectionEBPF = "ebpf"
+)
+
+// EBPFCapabilities are the capabilities that the init container needs to attach the eBPF programs. The programs
+// remain attached when the init container exits, so the traffic-agent itself needs no capabilities.
+var EBPFCapabilities = []core.Capability{"BPF", "NET_ADMIN"} //nolint:gochecknoglobals // constant
+
+// ValidatePortRedirection returns an error unless the given string is PortRedirectionIPTables or PortRedirectionEBPF.
+func ValidatePortRedirection(s string) error {
+	switch s {
+	case PortRedirectionIPTables, PortRedirectionEBPF:
+		return nil
+	default:
+		return fmt.Errorf("invalid port redirection %q, must be %q or %q", s, PortRedirectionIPTables, PortRedirectionEBPF)
+	}
+}
+
+// EBPFRedirectedIntercepts returns the port unique intercepts of numeric container ports when the given config uses
+// eBPF port redirection. The eBPF programs are stateless, so unlike the iptables rules, they don't redirect the
+// symbolic ports of headless services. Connections to the agent port of such a port would otherwise break.
+func EBPFRedirectedIntercepts(config *Sidecar) []*Intercept {
+	if config.PortRedirection != PortRedirectionEBPF {
+		return nil
+	}
+	var ics []*Intercept
+	for _, cc := range config.Containers {
+		for _, ic := range PortUniqueIntercepts(cc) {
+			if ic.TargetPortNumeric {
+				ics = append(ics, ic)
+			}
+		}
+	}
+	return ics
+}
+
+// CheckPortRedirection returns an error when the given config uses eBPF port redirection and has an intercept of a
+// headless service with a symbolic target port. Such an intercept relies on the iptables rules of the init
+// container, and the eBPF programs don't redirect it, so it would silently receive no traffic.
+func CheckPortRedirection(config *Sidecar) error {
+	if config.PortRedirection != PortRedirectionEBPF {
+		return nil
+	}
+	for _, cc := range config.Containers {
+		for _, ic := range cc.Intercepts {
+			if ic.Headless && !ic.TargetPortNumeric {
+				return fmt.Errorf("%s %s.%s can't be intercepted using eBPF port redirection, because the headless service "+
+					"%s targets the symbolic port %s of container %s. Use a numeric targetPort, or the %q port redirection",
+					config.WorkloadKind, config.WorkloadName, config.Namespace, ic.ServiceName, ic.ContainerPortName, cc.Name,
+					PortRedirectionIPTables)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package agentconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func TestEBPFRedirectedIntercepts(t *testing.T) {
+	numeric := &Intercept{ContainerPort: 8080, AgentPort: 9900, Protocol: core.ProtocolTCP, TargetPortNumeric: true}
+	headless := &Intercept{ContainerPortName: "http", AgentPort: 9901, Protocol: core.ProtocolTCP, Headless: true}
+	config := &Sidecar{
+		AgentImage:   "tel2",
+		WorkloadKind: "Deployment",
+		WorkloadName: "echo",
+		Namespace:    "default",
+		Containers: []*Container{{
+			Name:       "echo",
+			Intercepts: []*Intercept{numeric, headless},
+		}},
+	}
+	assert.Empty(t, EBPFRedirectedIntercepts(config))
+	assert.True(t, NeedsInitContainer(config))
+
+	require.NoError(t, CheckPortRedirection(config))
+
+	config.PortRedirection = PortRedirectionEBPF
+	assert.Equal(t, []*Intercept{numeric}, EBPFRedirectedIntercepts(config))
+	assert.True(t, NeedsInitContainer(config))
+
+	// The init container attaches the programs, so only it gets the capabilities.
+	pod := &core.Pod{Spec: core.PodSpec{Containers: []core.Container{{
+		Name:  "echo",
+		Ports: []core.ContainerPort{{Name: "http", ContainerPort: 8081}},
+	}}}}
+	ac := AgentContainer(context.Background(), pod, config)
+	require.NotNil(t, ac)
+	assert.Nil(t, ac.SecurityContext)
+	assert.Equal(t, EBPFCapabilities, InitContainer(config).SecurityContext.Capabilities.Add)
+
+	config.PodSecurity = &PodSecurity{Standard: PodSecurityBaseline}
+	assert.ErrorContains(t, CheckPodSecurity(config), "requires the BPF and NET_ADMIN capabilities")
+
+	config.Containers[0].Intercepts = []*Intercept{headless}
+	assert.False(t, NeedsInitContainer(config))
+	require.NoError(t, CheckPodSecurity(config))
+}
+
+func TestCheckPortRedirection(t *testing.T) {
+	tests := []struct {
+		name       string
+		redirect   string
+		intercepts []*Intercept
+		wantErr    string
+	}{
+		{
+			name:       "iptables headless symbolic",
+			redirect:   PortRedirectionIPTables,
+			intercepts: []*Intercept{{ServiceName: "echo", ContainerPortName: "http", Headless: true}},
+		},
+		{
+			name:       "ebpf numeric",
+			redirect:   PortRedirectionEBPF,
+			intercepts: []*Intercept{{ServiceName: "echo", ContainerPort: 8080, TargetPortNumeric: true}},
+		},
+		{
+			name:       "ebpf headless numeric",
+			redirect:   PortRedirectionEBPF,
+			intercepts: []*Intercept{{ServiceName: "echo", ContainerPort: 8080, TargetPortNumeric: true, Headless: true}},
+		},
+		{
+			name:       "ebpf symbolic",
+			redirect:   PortRedirectionEBPF,
+			intercepts: []*Intercept{{ServiceName: "echo", ContainerPortName: "http"}},
+		},
+		{
+			name:     "ebpf headless symbolic",
+			redirect: PortRedirectionEBPF,
+			intercepts: []*Intercept{
+				{ServiceName: "echo", ContainerPort: 8080, TargetPortNumeric: true},
+				{ServiceName: "echo-headless", ContainerPortName: "http", Headless: true},
+			},
+			wantErr: "the headless service echo-headless targets the symbolic port http of container echo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Sidecar{
+				WorkloadKind:    "Deployment",
+				WorkloadName:    "echo",
+				Namespace:       "default",
+				PortRedirection: tt.redirect,
+				Containers:      []*Container{{Name: "echo", Intercepts: tt.intercepts}},
+			}
+			err := CheckPortRedirection(config)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePortRedirection(t *testing.T) {
+	require.NoError(t, ValidatePortRedirection(PortRedirectionIPTables))
+	require.NoError(t, ValidatePortRedirection(PortRedirectionEBPF))
+	assert.Error(t, ValidatePortRedirection("nftables"))
+}
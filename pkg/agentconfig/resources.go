@@ -0,0 +1,76 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Pod template annotations that override the resources of the traffic-agent container of a workload. Their
+// values are quantities such as "250m" or "128Mi".
+const (
+	CPURequestAnnotation    = DomainPrefix + "agent-cpu-request"
+	CPULimitAnnotation      = DomainPrefix + "agent-cpu-limit"
+	MemoryRequestAnnotation = DomainPrefix + "agent-memory-request"
+	MemoryLimitAnnotation   = DomainPrefix + "agent-memory-limit"
+)
+
+type resourceOverride struct {
+	annotation string
+	name       core.ResourceName
+	limit      bool
+}
+
+var resourceOverrides = []resourceOverride{ //nolint:gochecknoglobals // constant
+	{CPURequestAnnotation, core.ResourceCPU, false},
+	{CPULimitAnnotation, core.ResourceCPU, true},
+	{MemoryRequestAnnotation, core.ResourceMemory, false},
+	{MemoryLimitAnnotation, core.ResourceMemory, true},
+}
+
+// AgentResources returns the given resources of the traffic-agent container with the overrides of the resource
+// annotations in the given pod template annotations applied. The given resources are returned unchanged when
+// there are no such annotations, and they are never modified.
+func AgentResources(base *core.ResourceRequirements, annotations map[string]string) (*core.ResourceRequirements, error) {
+	var rr *core.ResourceRequirements
+	for _, ro := range resourceOverrides {
+		v, ok := annotations[ro.annotation]
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(v)
+		if err == nil && q.Sign() < 0 {
+			err = fmt.Errorf("quantity %s is negative", v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for annotation %s: %w", v, ro.annotation, err)
+		}
+		if rr == nil {
+			if base != nil {
+				rr = base.DeepCopy()
+			} else {
+				rr = &core.ResourceRequirements{}
+			}
+		}
+		rl := &rr.Requests
+		if ro.limit {
+			rl = &rr.Limits
+		}
+		if *rl == nil {
+			*rl = make(core.ResourceList)
+		}
+		(*rl)[ro.name] = q
+	}
+	if rr == nil {
+		return base, nil
+	}
+	for _, n := range []core.ResourceName{core.ResourceCPU, core.ResourceMemory} {
+		rq, hasRq := rr.Requests[n]
+		lq, hasLq := rr.Limits[n]
+		if hasRq && hasLq && rq.Cmp(lq) > 0 {
+			return nil, fmt.Errorf("the %s request %s of the %s exceeds its limit %s", n, rq.String(), ContainerName, lq.String())
+		}
+	}
+	return rr, nil
+}
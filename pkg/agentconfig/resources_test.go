@@ -0,0 +1,56 @@
+package agentconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAgentResources(t *testing.T) {
+	base := &core.ResourceRequirements{
+		Requests: core.ResourceList{core.ResourceCPU: resource.MustParse("50m")},
+		Limits:   core.ResourceList{core.ResourceCPU: resource.MustParse("100m")},
+	}
+
+	rr, err := AgentResources(base, map[string]string{"other": "x"})
+	require.NoError(t, err)
+	assert.Same(t, base, rr)
+
+	rr, err = AgentResources(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, rr)
+
+	rr, err = AgentResources(base, map[string]string{
+		CPULimitAnnotation:      "2",
+		MemoryRequestAnnotation: "64Mi",
+		MemoryLimitAnnotation:   "256Mi",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &core.ResourceRequirements{
+		Requests: core.ResourceList{
+			core.ResourceCPU:    resource.MustParse("50m"),
+			core.ResourceMemory: resource.MustParse("64Mi"),
+		},
+		Limits: core.ResourceList{
+			core.ResourceCPU:    resource.MustParse("2"),
+			core.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}, rr)
+	assert.Equal(t, resource.MustParse("100m"), base.Limits[core.ResourceCPU], "base was modified")
+
+	rr, err = AgentResources(nil, map[string]string{CPURequestAnnotation: "10m"})
+	require.NoError(t, err)
+	assert.Equal(t, &core.ResourceRequirements{Requests: core.ResourceList{core.ResourceCPU: resource.MustParse("10m")}}, rr)
+
+	_, err = AgentResources(base, map[string]string{CPULimitAnnotation: "lots"})
+	assert.ErrorContains(t, err, "invalid value \"lots\" for annotation "+CPULimitAnnotation)
+
+	_, err = AgentResources(base, map[string]string{MemoryLimitAnnotation: "-1Mi"})
+	assert.ErrorContains(t, err, "is negative")
+
+	_, err = AgentResources(base, map[string]string{CPURequestAnnotation: "200m"})
+	assert.ErrorContains(t, err, "cpu request 200m of the traffic-agent exceeds its limit 100m")
+}
@@ -0,0 +1,35 @@
+package agentconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionAnnotation is a pod template annotation that overrides the retention period of the traffic-agent of a
+// workload, i.e. how long the traffic-manager keeps the agent while the workload isn't intercepted. Its value is
+// parsed using ParseRetention.
+const RetentionAnnotation = DomainPrefix + "agent-retention"
+
+// ParseRetention parses a retention period. It's either a duration such as "36h", or a number of days such as
+// "7d". Zero means that the traffic-agent is retained forever.
+func ParseRetention(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n float64
+		if n, err = strconv.ParseFloat(days, 64); err == nil {
+			d = time.Duration(n * float64(24*time.Hour))
+		}
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err == nil && d < 0 {
+		err = fmt.Errorf("retention period %q is negative", s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention period %q, expected a duration such as 36h, or a number of days such as 7d", s)
+	}
+	return d, nil
+}
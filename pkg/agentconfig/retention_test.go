@@ -0,0 +1,27 @@
+package agentconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetention(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"0":    0,
+		"36h":  36 * time.Hour,
+		"90m":  90 * time.Minute,
+		"7d":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	} {
+		got, err := ParseRetention(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	for _, s := range []string{"", "d", "week", "-1h", "-2d"} {
+		_, err := ParseRetention(s)
+		assert.Error(t, err, s)
+	}
+}
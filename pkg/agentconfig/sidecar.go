@@ -34,6 +34,7 @@ const (
 	EnvPrefix                = "_TEL_"
 	EnvPrefixAgent           = EnvPrefix + "AGENT_"
 	EnvPrefixApp             = EnvPrefix + "APP_"
+	EnvPrefixSecret          = EnvPrefix + "SEC_"
 
 	// EnvInterceptContainer intercepted container propagated to client during intercept.
 	EnvInterceptContainer = "TELEPRESENCE_CONTAINER"
@@ -55,6 +56,16 @@ const (
 	WorkloadKindLabel                    = "telepresence.io/workloadKind"
 	WorkloadEnabledLabel                 = "telepresence.io/workloadEnabled"
 	K8SCreatedByLabel                    = "app.kubernetes.io/created-by"
+
+	// PodDaemonContainerName is the name of the sidecar that is injected into pods that enable it using the
+	// InjectPodDaemonAnnotation. The sidecar intercepts the workload declared by the PodDaemonWorkloadAnnotation
+	// using the port of the pod that is declared by the PodDaemonPortAnnotation.
+	PodDaemonContainerName      = "tel-pod-daemon"
+	InjectPodDaemonAnnotation   = DomainPrefix + "inject-" + PodDaemonContainerName
+	PodDaemonWorkloadAnnotation = DomainPrefix + "pod-daemon-workload"
+	PodDaemonPortAnnotation     = DomainPrefix + "pod-daemon-port"
+	PodDaemonNameAnnotation     = DomainPrefix + "pod-daemon-intercept-name"
+	PodDaemonServiceAnnotation  = DomainPrefix + "pod-daemon-service"
 )
 
 type ReplacePolicy bool
@@ -184,6 +195,9 @@ type Sidecar struct {
 	// The port used by the agent's GRPC tracing server
 	TracingPort uint16 `json:"tracingPort,omitzero"`
 
+	// The propagations, in addition to plain headers, that may carry the headers that identify an intercept
+	Propagation []string `json:"propagation,omitempty"`
+
 	// Resources for the sidecar
 	Resources *core.ResourceRequirements `json:"resources,omitempty"`
 
@@ -195,6 +209,15 @@ type Sidecar struct {
 
 	// SecurityContext for the sidecar
 	SecurityContext *core.SecurityContext `json:"securityContext,omitempty"`
+
+	// Patch is applied to the injected sidecar and its pod
+	Patch *SidecarPatch `json:"patch,omitempty"`
+
+	// PodSecurity controls how the sidecar complies with the Pod Security Standard of its namespace
+	PodSecurity *PodSecurity `json:"podSecurity,omitempty"`
+
+	// PortRedirection is PortRedirectionIPTables or PortRedirectionEBPF. Empty means PortRedirectionIPTables
+	PortRedirection string `json:"portRedirection,omitzero"`
 }
 
 func (s *Sidecar) AgentConfig() *Sidecar {
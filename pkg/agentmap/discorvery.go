@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"slices"
 	"sort"
+	"strings"
 
 	core "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -20,6 +21,7 @@ import (
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 )
 
 var ReplicaSetNameRx = regexp.MustCompile(`\A(.+)-[a-f0-9]+\z`)
@@ -61,15 +63,37 @@ func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object, supportedWorkload
 	return nil, fmt.Errorf("unable to find workload owner for %s.%s", obj.GetName(), obj.GetNamespace())
 }
 
+// GetWorkload returns the workload with the given name, namespace, and kind. Kinds that aren't supported
+// natively are resolved using a registered WorkloadResolver. When the kind is empty, the built-in kinds are
+// searched first, followed by the kinds of the registered resolvers.
 func GetWorkload(ctx context.Context, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
 	dlog.Debugf(ctx, "GetWorkload(%s,%s,%s)", name, namespace, workloadKind)
-	i := informer.GetFactory(ctx, namespace)
-	if i == nil {
+	if r := GetWorkloadResolver(workloadKind); r != nil {
+		return r.GetWorkload(ctx, name, namespace)
+	}
+	if workloadKind == k8sworkload.PodKind {
+		// The pod informers strip the owner references, so bare pods are always fetched directly.
+		return k8sworkload.GetPod(ctx, name, namespace)
+	}
+	if i := informer.GetFactory(ctx, namespace); i == nil {
 		dlog.Debugf(ctx, "fetching %s %s.%s using direct API call", workloadKind, name, namespace)
-		return k8sapi.GetWorkload(ctx, name, namespace, workloadKind)
+		obj, err = k8sworkload.GetWorkload(ctx, name, namespace, workloadKind)
+	} else {
+		ai, ri := i.GetK8sInformerFactory().Apps().V1(), i.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1().Rollouts()
+		obj, err = getWorkload(ai, ri, name, namespace, workloadKind)
 	}
-	ai, ri := i.GetK8sInformerFactory().Apps().V1(), i.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1().Rollouts()
-	return getWorkload(ai, ri, name, namespace, workloadKind)
+	if workloadKind == "" && k8sErrors.IsNotFound(err) {
+		for _, r := range WorkloadResolvers() {
+			if obj, err = r.GetWorkload(ctx, name, namespace); err == nil {
+				return obj, nil
+			}
+			if !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		err = k8sErrors.NewNotFound(core.Resource("workload"), name+"."+namespace)
+	}
+	return obj, err
 }
 
 func getWorkload(ai apps.Interface, ri argorollouts.RolloutInformer, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
@@ -82,8 +106,10 @@ func getWorkload(ai apps.Interface, ri argorollouts.RolloutInformer, name, names
 		return getStatefulSet(ai, name, namespace)
 	case "Rollout":
 		return getRollout(ri, name, namespace)
+	case "DaemonSet":
+		return getDaemonSet(ai, name, namespace)
 	case "":
-		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet", "Rollout"} {
+		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet", "Rollout", "DaemonSet"} {
 			if obj, err = getWorkload(ai, ri, name, namespace, wk); err == nil {
 				return obj, nil
 			}
@@ -132,6 +158,14 @@ func getStatefulSet(ai apps.Interface, name, namespace string) (k8sapi.Workload,
 	return k8sapi.StatefulSet(ss), nil
 }
 
+func getDaemonSet(ai apps.Interface, name, namespace string) (k8sapi.Workload, error) {
+	ds, err := ai.DaemonSets().Lister().DaemonSets(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return k8sworkload.DaemonSet(ds), nil
+}
+
 func FindServicesForPod(ctx context.Context, pod *core.PodTemplateSpec, svcName string) ([]k8sapi.Object, error) {
 	switch {
 	case svcName != "":
@@ -295,9 +329,13 @@ func IsPodRunning(pod *core.Pod) bool {
 	return false
 }
 
-// AgentContainer returns the pod's traffic-agent container, or nil if the pod doesn't have a traffic-agent.
+// AgentContainer returns the pod's traffic-agent container, or nil if the pod doesn't have a traffic-agent. The
+// traffic-agent is an init container when it's injected as a native sidecar.
 func AgentContainer(pod *core.Pod) *core.Container {
-	return containerByName(agentconfig.ContainerName, pod.Spec.Containers)
+	if cn := containerByName(agentconfig.ContainerName, pod.Spec.Containers); cn != nil {
+		return cn
+	}
+	return containerByName(agentconfig.ContainerName, pod.Spec.InitContainers)
 }
 
 // InitContainer returns the pod's tel-agent-init init-container, or nil if the pod doesn't have a tel-agent-init.
@@ -314,3 +352,132 @@ func containerByName(name string, cns []core.Container) *core.Container {
 	}
 	return nil
 }
+
+// GetService returns the service with the given name in the given namespace.
+func GetService(ctx context.Context, name, namespace string) (*core.Service, error) {
+	if f := informer.GetK8sFactory(ctx, namespace); f != nil {
+		return f.Core().V1().Services().Lister().Services(namespace).Get(name)
+	}
+	dlog.Debugf(ctx, "fetching service %s.%s using direct API call", name, namespace)
+	return k8sapi.GetK8sInterface(ctx).CoreV1().Services(namespace).Get(ctx, name, meta.GetOptions{})
+}
+
+// ExternalNameService returns the name and namespace of the service that the given external name refers to,
+// e.g. "my-svc.my-ns.svc.cluster.local" or "my-svc.my-ns.svc". The returned bool is false when the external
+// name refers to a host outside the cluster.
+func ExternalNameService(externalName string) (name, namespace string, ok bool) {
+	parts := strings.Split(strings.TrimSuffix(externalName, "."), ".")
+	if len(parts) < 3 || parts[2] != "svc" || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// FindWorkloadsForService returns the workloads of the given kinds that have a pod template that is selected by
+// the given service. ReplicaSets that are controlled by another workload are excluded. The workloads are sorted
+// by kind and name.
+func FindWorkloadsForService(ctx context.Context, svc *core.Service, workloadKinds []string) ([]k8sapi.Workload, error) {
+	if len(svc.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	sel := labels.SelectorFromValidatedSet(svc.Spec.Selector)
+	var ms []k8sapi.Workload
+	for _, wk := range workloadKinds {
+		wls, err := listWorkloads(ctx, svc.Namespace, wk)
+		if err != nil {
+			return nil, err
+		}
+		for _, wl := range wls {
+			if wk == "ReplicaSet" && slices.ContainsFunc(wl.GetOwnerReferences(), func(or meta.OwnerReference) bool {
+				return or.Controller != nil && *or.Controller
+			}) {
+				continue
+			}
+			if sel.Matches(labels.Set(wl.GetPodTemplate().GetLabels())) {
+				ms = append(ms, wl)
+			}
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ki, kj := ms[i].GetKind(), ms[j].GetKind(); ki != kj {
+			return ki < kj
+		}
+		return ms[i].GetName() < ms[j].GetName()
+	})
+	return ms, nil
+}
+
+func listWorkloads(ctx context.Context, namespace, workloadKind string) ([]k8sapi.Workload, error) {
+	if r := GetWorkloadResolver(workloadKind); r != nil {
+		return r.ListWorkloads(ctx, namespace)
+	}
+	i := informer.GetFactory(ctx, namespace)
+	if i == nil {
+		dlog.Debugf(ctx, "listing %s in %s using direct API call", workloadKind, namespace)
+		switch workloadKind {
+		case "Deployment":
+			return k8sapi.Deployments(ctx, namespace, nil)
+		case "ReplicaSet":
+			return k8sapi.ReplicaSets(ctx, namespace, nil)
+		case "StatefulSet":
+			return k8sapi.StatefulSets(ctx, namespace, nil)
+		case "Rollout":
+			return k8sapi.Rollouts(ctx, namespace, nil)
+		case "DaemonSet":
+			return k8sworkload.DaemonSets(ctx, namespace, nil)
+		default:
+			return nil, k8sapi.UnsupportedWorkloadKindError(workloadKind)
+		}
+	}
+	var wls []k8sapi.Workload
+	ai := i.GetK8sInformerFactory().Apps().V1()
+	switch workloadKind {
+	case "Deployment":
+		ls, err := ai.Deployments().Lister().Deployments(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ls {
+			wls = append(wls, k8sapi.Deployment(o))
+		}
+	case "ReplicaSet":
+		ls, err := ai.ReplicaSets().Lister().ReplicaSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ls {
+			wls = append(wls, k8sapi.ReplicaSet(o))
+		}
+	case "StatefulSet":
+		ls, err := ai.StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ls {
+			wls = append(wls, k8sapi.StatefulSet(o))
+		}
+	case "DaemonSet":
+		ls, err := ai.DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ls {
+			wls = append(wls, k8sworkload.DaemonSet(o))
+		}
+	case "Rollout":
+		ri := i.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1().Rollouts()
+		if ri == nil {
+			return nil, k8sapi.UnsupportedWorkloadKindError(workloadKind)
+		}
+		ls, err := ri.Lister().Rollouts(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range ls {
+			wls = append(wls, k8sapi.Rollout(o))
+		}
+	default:
+		return nil, k8sapi.UnsupportedWorkloadKindError(workloadKind)
+	}
+	return wls, nil
+}
@@ -0,0 +1,32 @@
+package agentmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalNameService(t *testing.T) {
+	tests := []struct {
+		externalName string
+		name         string
+		namespace    string
+		ok           bool
+	}{
+		{"echo.default.svc.cluster.local", "echo", "default", true},
+		{"echo.default.svc.cluster.local.", "echo", "default", true},
+		{"echo.other.svc", "echo", "other", true},
+		{"echo.default", "", "", false},
+		{"api.example.com", "", "", false},
+		{"my.api.example.com", "", "", false},
+		{".default.svc", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.externalName, func(t *testing.T) {
+			name, namespace, ok := ExternalNameService(tt.externalName)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.namespace, namespace)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -52,6 +52,10 @@ type BasicGeneratorConfig struct {
 	PullSecrets         []core.LocalObjectReference
 	AppProtocolStrategy k8sapi.AppProtocolStrategy
 	SecurityContext     *core.SecurityContext
+	Propagation         []string
+	Patch               *agentconfig.SidecarPatch
+	PodSecurity         *agentconfig.PodSecurity
+	PortRedirection     string
 }
 
 func portsFromAnnotation(wl k8sapi.Workload, annotation string) (ports []agentconfig.PortIdentifier, err error) {
@@ -133,14 +137,19 @@ func (cfg *BasicGeneratorConfig) Generate(
 	if err != nil {
 		return nil, err
 	}
-	if len(ports) == 0 {
-		if len(ccs) == 0 {
-			return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s", pod.Name, pod.Namespace)
-		}
-	} else {
+	switch {
+	case len(ports) > 0:
 		if ccs, err = appendServiceLessAgentContainerConfigs(ctx, pod, ports, agentPortNumberFunc, ccs, existingConfig, cfg.AppProtocolStrategy, ignoredVolumeMounts); err != nil {
 			return nil, err
 		}
+	case len(ccs) == 0:
+		// No service exposes the pod, so the ports that its containers declare are intercepted directly, i.e.
+		// when they are reached using the IP of the pod.
+		ccs = appendDeclaredPortAgentContainerConfigs(ctx, pod, agentPortNumberFunc, ccs, existingConfig, cfg.AppProtocolStrategy, ignoredVolumeMounts)
+		if len(ccs) == 0 {
+			return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s, and no container declares a port",
+				pod.Name, pod.Namespace)
+		}
 	}
 
 	// Append other containers even though they aren't directly interceptable. They might be fronted by a
@@ -161,6 +170,11 @@ func (cfg *BasicGeneratorConfig) Generate(
 		}
 	}
 
+	resources, err := agentconfig.AgentResources(cfg.Resources, pod.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
+
 	ag := &agentconfig.Sidecar{
 		AgentImage:      cfg.QualifiedAgentImage,
 		AgentName:       wl.GetName(),
@@ -174,10 +188,17 @@ func (cfg *BasicGeneratorConfig) Generate(
 		TracingPort:     cfg.TracingPort,
 		Containers:      ccs,
 		InitResources:   cfg.InitResources,
-		Resources:       cfg.Resources,
+		Resources:       resources,
 		PullPolicy:      cfg.PullPolicy,
 		PullSecrets:     cfg.PullSecrets,
 		SecurityContext: cfg.SecurityContext,
+		Propagation:     cfg.Propagation,
+		Patch:           cfg.Patch,
+		PodSecurity:     cfg.PodSecurity,
+		PortRedirection: cfg.PortRedirection,
+	}
+	if err = agentconfig.CheckPortRedirection(ag); err != nil {
+		return nil, err
 	}
 	ag.RecordInSpan(span)
 	return ag, nil
@@ -218,6 +239,7 @@ nextSvcPort:
 			ServicePortName:   port.Name,
 			ServicePort:       uint16(port.Port),
 			TargetPortNumeric: port.TargetPort.Type == intstr.Int,
+			Headless:          svc.Spec.ClusterIP == core.ClusterIPNone,
 			Protocol:          port.Protocol,
 			AppProtocol:       k8sapi.GetAppProto(ctx, aps, &port),
 			AgentPort:         agentPortNumberFunc(appPort.ContainerPort),
@@ -273,7 +295,6 @@ func appendServiceLessAgentContainerConfigs(
 ) ([]*agentconfig.Container, error) {
 	cns := pod.Spec.Containers
 	anonNameIndex := uint64(0)
-nextContainerPort:
 	for _, p := range portAnnotations {
 		cn, appPort := findContainerPort(cns, p)
 		if appPort == nil {
@@ -283,6 +304,19 @@ nextContainerPort:
 				// We can only synthesize given a numeric port.
 				return nil, fmt.Errorf("found no container port that matches port annotation %s", p)
 			}
+			// A synthesized port must belong to a container, and we can only tell which one if there's just one.
+			for i := range cns {
+				if cns[i].Name == agentconfig.ContainerName {
+					continue
+				}
+				if cn != nil {
+					return nil, fmt.Errorf("found no container port that matches port annotation %s, and the pod has more than one container", p)
+				}
+				cn = &cns[i]
+			}
+			if cn == nil {
+				return nil, fmt.Errorf("found no container port that matches port annotation %s", p)
+			}
 			appPort = &core.ContainerPort{
 				Name:          fmt.Sprintf("port-%s", Base26(anonNameIndex)),
 				ContainerPort: int32(num),
@@ -290,42 +324,79 @@ nextContainerPort:
 			}
 			anonNameIndex++
 		}
-		ic := &agentconfig.Intercept{
-			TargetPortNumeric: true,
-			Protocol:          appPort.Protocol,
-			AgentPort:         agentPortNumberFunc(appPort.ContainerPort),
-			AppProtocol:       getContainerPortAppProtocol(ctx, aps, appPort.Name),
-			ContainerPortName: appPort.Name,
-			ContainerPort:     uint16(appPort.ContainerPort),
+		ccs = appendServiceLessIntercept(ctx, cn, appPort, agentPortNumberFunc, ccs, existingConfig, aps, ignoredVolumeMounts)
+	}
+	return ccs, nil
+}
+
+// appendDeclaredPortAgentContainerConfigs adds a service-less intercept for each port that is declared by a
+// container in the given pod.
+func appendDeclaredPortAgentContainerConfigs(
+	ctx context.Context,
+	pod *core.PodTemplateSpec,
+	agentPortNumberFunc func(int32) uint16,
+	ccs []*agentconfig.Container,
+	existingConfig agentconfig.SidecarExt,
+	aps k8sapi.AppProtocolStrategy,
+	ignoredVolumeMounts agentconfig.IgnoredVolumeMounts,
+) []*agentconfig.Container {
+	cns := pod.Spec.Containers
+	for n := range cns {
+		cn := &cns[n]
+		if cn.Name == agentconfig.ContainerName {
+			continue
 		}
+		for i := range cn.Ports {
+			ccs = appendServiceLessIntercept(ctx, cn, &cn.Ports[i], agentPortNumberFunc, ccs, existingConfig, aps, ignoredVolumeMounts)
+		}
+	}
+	return ccs
+}
 
-		// The container might already have intercepts declared
-		for _, cc := range ccs {
-			if cc.Name == cn.Name {
-				// Don't add service less intercept if an intercept with a service is present
-				cnFound := false
-				for _, eic := range cc.Intercepts {
-					if eic.ContainerPort == ic.ContainerPort {
-						cnFound = true
-						break
-					}
-				}
-				if !cnFound {
-					cc.Intercepts = append(cc.Intercepts, ic)
+func appendServiceLessIntercept(
+	ctx context.Context,
+	cn *core.Container,
+	appPort *core.ContainerPort,
+	agentPortNumberFunc func(int32) uint16,
+	ccs []*agentconfig.Container,
+	existingConfig agentconfig.SidecarExt,
+	aps k8sapi.AppProtocolStrategy,
+	ignoredVolumeMounts agentconfig.IgnoredVolumeMounts,
+) []*agentconfig.Container {
+	proto := appPort.Protocol
+	if proto == "" {
+		proto = core.ProtocolTCP
+	}
+	ic := &agentconfig.Intercept{
+		TargetPortNumeric: true,
+		Protocol:          proto,
+		AgentPort:         agentPortNumberFunc(appPort.ContainerPort),
+		AppProtocol:       getContainerPortAppProtocol(ctx, aps, appPort.Name),
+		ContainerPortName: appPort.Name,
+		ContainerPort:     uint16(appPort.ContainerPort),
+	}
+
+	// The container might already have intercepts declared
+	for _, cc := range ccs {
+		if cc.Name == cn.Name {
+			// Don't add service less intercept if an intercept with a service is present
+			for _, eic := range cc.Intercepts {
+				if eic.ContainerPort == ic.ContainerPort && eic.Protocol == ic.Protocol {
+					return ccs
 				}
-				continue nextContainerPort
 			}
+			cc.Intercepts = append(cc.Intercepts, ic)
+			return ccs
 		}
-		ccs = append(ccs, &agentconfig.Container{
-			Name:       cn.Name,
-			EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
-			MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
-			Mounts:     containerMounts(cn, ignoredVolumeMounts),
-			Intercepts: []*agentconfig.Intercept{ic},
-			Replace:    containerReplacePolicy(existingConfig, cn),
-		})
 	}
-	return ccs, nil
+	return append(ccs, &agentconfig.Container{
+		Name:       cn.Name,
+		EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
+		MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
+		Mounts:     containerMounts(cn, ignoredVolumeMounts),
+		Intercepts: []*agentconfig.Intercept{ic},
+		Replace:    containerReplacePolicy(existingConfig, cn),
+	})
 }
 
 func containerReplacePolicy(existingConfig agentconfig.SidecarExt, cn *core.Container) agentconfig.ReplacePolicy {
@@ -0,0 +1,80 @@
+package agentmap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// WorkloadResolver resolves workloads of a kind that Telepresence doesn't support natively, such as a custom
+// resource whose controller owns pods. A registered resolver makes its kind interceptable once that kind is
+// enabled in the traffic-manager.
+//
+// The workloads returned by a resolver must return the resolver's kind from GetKind(), and the pod template
+// returned from GetPodTemplate() must be the template that the controller uses when creating pods. A rollout
+// is triggered by patching an annotation in that template, so the controller must replace its pods when the
+// template changes.
+type WorkloadResolver interface {
+	// Kind returns the kind of the workloads that this resolver resolves, e.g. "CronTab".
+	Kind() string
+
+	// GetWorkload returns the workload with the given name in the given namespace. A k8s NotFound error
+	// must be returned when no such workload exists.
+	GetWorkload(ctx context.Context, name, namespace string) (k8sapi.Workload, error)
+
+	// ListWorkloads returns all workloads in the given namespace.
+	ListWorkloads(ctx context.Context, namespace string) ([]k8sapi.Workload, error)
+}
+
+// builtinWorkloadKinds are the kinds that cannot be replaced by a WorkloadResolver.
+var builtinWorkloadKinds = []string{"Deployment", "ReplicaSet", "StatefulSet", "Rollout", "DaemonSet", "Pod"} //nolint:gochecknoglobals // constant
+
+var (
+	resolversLock     sync.RWMutex       //nolint:gochecknoglobals // extension point
+	workloadResolvers []WorkloadResolver //nolint:gochecknoglobals // extension point
+)
+
+// RegisterWorkloadResolver registers a resolver for workloads of a custom kind. An error is returned if the
+// kind is one of the built-in kinds or if a resolver for the kind has already been registered.
+func RegisterWorkloadResolver(r WorkloadResolver) error {
+	kind := r.Kind()
+	if kind == "" || slices.Contains(builtinWorkloadKinds, kind) {
+		return fmt.Errorf("unable to register a workload resolver for kind %q", kind)
+	}
+	resolversLock.Lock()
+	defer resolversLock.Unlock()
+	if slices.ContainsFunc(workloadResolvers, func(er WorkloadResolver) bool { return er.Kind() == kind }) {
+		return fmt.Errorf("a workload resolver for kind %q is already registered", kind)
+	}
+	workloadResolvers = append(workloadResolvers, r)
+	return nil
+}
+
+// UnregisterWorkloadResolver removes the resolver for the given kind.
+func UnregisterWorkloadResolver(kind string) {
+	resolversLock.Lock()
+	workloadResolvers = slices.DeleteFunc(workloadResolvers, func(r WorkloadResolver) bool { return r.Kind() == kind })
+	resolversLock.Unlock()
+}
+
+// GetWorkloadResolver returns the resolver registered for the given kind, or nil if no such resolver exists.
+func GetWorkloadResolver(kind string) WorkloadResolver {
+	resolversLock.RLock()
+	defer resolversLock.RUnlock()
+	for _, r := range workloadResolvers {
+		if r.Kind() == kind {
+			return r
+		}
+	}
+	return nil
+}
+
+// WorkloadResolvers returns the registered resolvers in the order that they were registered.
+func WorkloadResolvers() []WorkloadResolver {
+	resolversLock.RLock()
+	defer resolversLock.RUnlock()
+	return slices.Clone(workloadResolvers)
+}
@@ -0,0 +1,113 @@
+package agentmap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fakeargorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned/fake"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// testResolver resolves "Echo" workloads by wrapping deployments, which is enough to exercise
+// the resolver plumbing.
+type testResolver struct {
+	wls []*apps.Deployment
+}
+
+func (r *testResolver) Kind() string {
+	return "Echo"
+}
+
+func (r *testResolver) GetWorkload(_ context.Context, name, namespace string) (k8sapi.Workload, error) {
+	for _, d := range r.wls {
+		if d.Name == name && d.Namespace == namespace {
+			return k8sapi.Deployment(d), nil
+		}
+	}
+	return nil, k8sErrors.NewNotFound(schema.GroupResource{Group: "example.com", Resource: "echos"}, name)
+}
+
+func (r *testResolver) ListWorkloads(_ context.Context, namespace string) ([]k8sapi.Workload, error) {
+	var wls []k8sapi.Workload
+	for _, d := range r.wls {
+		if d.Namespace == namespace {
+			wls = append(wls, k8sapi.Deployment(d))
+		}
+	}
+	return wls, nil
+}
+
+type kindResolver string
+
+func (r kindResolver) Kind() string {
+	return string(r)
+}
+
+func (r kindResolver) GetWorkload(context.Context, string, string) (k8sapi.Workload, error) {
+	return nil, nil
+}
+
+func (r kindResolver) ListWorkloads(context.Context, string) ([]k8sapi.Workload, error) {
+	return nil, nil
+}
+
+func TestRegisterWorkloadResolver(t *testing.T) {
+	assert.Error(t, RegisterWorkloadResolver(kindResolver("")))
+	assert.Error(t, RegisterWorkloadResolver(kindResolver("Deployment")))
+	assert.Error(t, RegisterWorkloadResolver(kindResolver("DaemonSet")))
+
+	require.NoError(t, RegisterWorkloadResolver(kindResolver("Foo")))
+	defer UnregisterWorkloadResolver("Foo")
+	assert.Error(t, RegisterWorkloadResolver(kindResolver("Foo")))
+	require.NoError(t, RegisterWorkloadResolver(kindResolver("Bar")))
+	defer UnregisterWorkloadResolver("Bar")
+
+	assert.Equal(t, kindResolver("Foo"), GetWorkloadResolver("Foo"))
+	assert.Nil(t, GetWorkloadResolver("Baz"))
+	assert.Equal(t, []WorkloadResolver{kindResolver("Foo"), kindResolver("Bar")}, WorkloadResolvers())
+
+	UnregisterWorkloadResolver("Foo")
+	assert.Nil(t, GetWorkloadResolver("Foo"))
+}
+
+func TestGetWorkload_resolver(t *testing.T) {
+	echo := &apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+		Spec: apps.DeploymentSpec{
+			Template: core.PodTemplateSpec{ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": "echo"}}},
+		},
+	}
+	require.NoError(t, RegisterWorkloadResolver(&testResolver{wls: []*apps.Deployment{echo}}))
+	defer UnregisterWorkloadResolver("Echo")
+
+	ctx := k8sapi.WithJoinedClientSetInterface(context.Background(), fake.NewSimpleClientset(), fakeargorollouts.NewSimpleClientset())
+
+	wl, err := GetWorkload(ctx, "echo", "default", "Echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", wl.GetName())
+
+	// The built-in kinds are searched first, and then the registered resolvers.
+	wl, err = GetWorkload(ctx, "echo", "default", "")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", wl.GetName())
+
+	_, err = GetWorkload(ctx, "missing", "default", "")
+	assert.True(t, k8sErrors.IsNotFound(err))
+
+	wls, err := FindWorkloadsForService(ctx, &core.Service{
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+		Spec:       core.ServiceSpec{Selector: map[string]string{"app": "echo"}},
+	}, []string{"Deployment", "Echo"})
+	require.NoError(t, err)
+	require.Len(t, wls, 1)
+	assert.Equal(t, "echo", wls[0].GetName())
+}
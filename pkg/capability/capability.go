@@ -0,0 +1,99 @@
+// Package capability defines the optional features that a traffic-manager reports using its GetCapabilities
+// call, along with the versions of the traffic-manager that introduced them.
+package capability
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/blang/semver/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The names of the optional features of the traffic-manager.
+const (
+	UDP               = "udp"
+	Replace           = "replace"
+	ProxyVia          = "proxy-via"
+	Metrics           = "metrics"
+	WorkloadWatch     = "workload-watch"
+	InterceptSharing  = "intercept-sharing"
+	HeaderPropagation = "header-propagation"
+	PublishedServices = "published-services"
+	RegistryProxy     = "registry-proxy"
+	AdminAPI          = "admin-api"
+	WorkloadDescribe  = "workload-describe"
+	AgentEvents       = "agent-events"
+	AgentProgress     = "agent-arrival-progress"
+	InterceptDryRun   = "intercept-dry-run"
+)
+
+// Feature describes an optional feature of the traffic-manager.
+type Feature struct {
+	// Description is used in error messages, e.g. "sharing of intercepts".
+	Description string
+
+	// Since is the first version of the traffic-manager that supports the feature.
+	Since semver.Version
+
+	// HelmValue is the Helm chart value that enables the feature. It's empty for features that are
+	// always enabled.
+	HelmValue string
+}
+
+// RPCVersion is the first version of the traffic-manager that implements the GetCapabilities call.
+var RPCVersion = semver.MustParse("2.21.0") //nolint:gochecknoglobals // constant
+
+// Features are the known features, keyed by name.
+var Features = map[string]*Feature{ //nolint:gochecknoglobals // constant
+	UDP:               {Description: "intercepts of UDP ports", Since: semver.MustParse("2.6.8")},
+	Replace:           {Description: "replacing containers", Since: semver.MustParse("2.14.0")},
+	ProxyVia:          {Description: "routing subnets through workloads", Since: semver.MustParse("2.18.0")},
+	Metrics:           {Description: "Prometheus metrics", Since: semver.MustParse("2.14.0"), HelmValue: "prometheus.port"},
+	WorkloadWatch:     {Description: "watching workloads", Since: RPCVersion},
+	InterceptSharing:  {Description: "sharing of intercepts", Since: RPCVersion},
+	HeaderPropagation: {Description: "verification of header propagation", Since: RPCVersion},
+	PublishedServices: {Description: "publishing of services", Since: RPCVersion, HelmValue: "publishedServices.enabled"},
+	RegistryProxy:     {Description: "exposing registries", Since: RPCVersion, HelmValue: "registryProxy.enabled"},
+	AdminAPI:          {Description: "eviction of sessions and intercepts", Since: RPCVersion, HelmValue: "adminApi.enabled"},
+	WorkloadDescribe:  {Description: "describing workloads", Since: RPCVersion},
+	AgentEvents:       {Description: "streaming of agent events", Since: RPCVersion},
+	AgentProgress:     {Description: "streaming of agent arrival progress", Since: RPCVersion},
+	InterceptDryRun:   {Description: "intercept dry runs", Since: RPCVersion},
+}
+
+// FromVersion returns the names of the features that a traffic-manager of the given version supports, unless
+// its configuration disables them. It's used for traffic-managers that predate the GetCapabilities call.
+func FromVersion(v semver.Version) []string {
+	var names []string
+	for name, f := range Features {
+		if v.GTE(f.Since) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Check returns an Unimplemented error when the named feature isn't among the supported features of a
+// traffic-manager of the given version. The error tells whether the traffic-manager must be upgraded, or
+// whether the feature must be enabled in its configuration.
+func Check(name string, supported []string, managerVersion semver.Version) error {
+	if slices.Contains(supported, name) {
+		return nil
+	}
+	f, ok := Features[name]
+	var msg string
+	switch {
+	case !ok:
+		msg = fmt.Sprintf("the traffic-manager doesn't support %s", name)
+	case managerVersion.LT(f.Since):
+		msg = fmt.Sprintf("your traffic-manager is v%s and doesn't support %s, upgrade it to v%s or later", managerVersion, f.Description, f.Since)
+	case f.HelmValue != "":
+		msg = fmt.Sprintf("%s is disabled in the traffic-manager, enable it using the Helm value %s", f.Description, f.HelmValue)
+	default:
+		msg = fmt.Sprintf("the traffic-manager doesn't support %s", f.Description)
+	}
+	return status.Error(codes.Unimplemented, msg)
+}
@@ -0,0 +1,35 @@
+package capability
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromVersion(t *testing.T) {
+	assert.Equal(t, []string{Metrics, ProxyVia, Replace, UDP}, FromVersion(semver.MustParse("2.20.3")))
+	assert.Empty(t, FromVersion(semver.MustParse("2.5.0")))
+	assert.Len(t, FromVersion(RPCVersion), len(Features))
+}
+
+func TestCheck(t *testing.T) {
+	v := semver.MustParse("2.20.3")
+	assert.NoError(t, Check(Replace, FromVersion(v), v))
+
+	err := Check(InterceptSharing, FromVersion(v), v)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Equal(t,
+		"your traffic-manager is v2.20.3 and doesn't support sharing of intercepts, upgrade it to v2.21.0 or later",
+		status.Convert(err).Message())
+
+	err = Check(PublishedServices, []string{InterceptSharing}, RPCVersion)
+	assert.Equal(t,
+		"publishing of services is disabled in the traffic-manager, enable it using the Helm value publishedServices.enabled",
+		status.Convert(err).Message())
+
+	err = Check("teleport", nil, RPCVersion)
+	assert.Equal(t, "the traffic-manager doesn't support teleport", status.Convert(err).Message())
+}
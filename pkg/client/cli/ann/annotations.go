@@ -7,12 +7,14 @@ const (
 	Session           = "session"
 	VersionCheck      = "versionCheck"
 	UpdateCheckFormat = "updateCheckFormat"
+	LifecycleEvents   = "lifecycleEvents"
 )
 
 // -- Annotation values
 
 const (
-	Optional = "optional"
-	Required = "required"
-	Tel2     = "https://%s/download/tel2/%s/%s/stable.txt"
+	Optional  = "optional"
+	Required  = "required"
+	Supported = "supported"
+	Tel2      = "https://%s/download/tel2/%s/%s/stable.txt"
 )
@@ -0,0 +1,123 @@
+package ci
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// Step is the outcome of one step of a run.
+type Step struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	Skipped bool    `json:"skipped,omitzero"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all steps of a run, in the order that they were made.
+type Report struct {
+	Name  string  `json:"name"`
+	Steps []*Step `json:"steps"`
+}
+
+// Add adds a step with the given outcome to the report, and returns the given error.
+func (r *Report) Add(name string, elapsed time.Duration, err error) error {
+	s := &Step{Name: name, Seconds: elapsed.Seconds()}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, s)
+	return err
+}
+
+// Skip adds a step that wasn't made to the report.
+func (r *Report) Skip(name string) {
+	r.Steps = append(r.Steps, &Step{Name: name, Skipped: true})
+}
+
+// Time runs the given function and adds its outcome as a step to the report. The error of the function is returned.
+func (r *Report) Time(name string, f func() error) error {
+	start := time.Now()
+	err := f()
+	return r.Add(name, time.Since(start), err)
+}
+
+// Failures returns the number of steps that failed.
+func (r *Report) Failures() int {
+	n := 0
+	for _, s := range r.Steps {
+		if s.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteJSON writes the report as JSON to the given writer.
+func (r *Report) WriteJSON(w io.Writer) error {
+	return json.MarshalWrite(w, r, jsontext.WithIndent("  "))
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite, with one test case for each step, to the given writer.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	seconds := func(s float64) string {
+		return strconv.FormatFloat(s, 'f', 3, 64)
+	}
+	suite := junitSuite{Name: r.Name, Tests: len(r.Steps), Cases: make([]junitTestCase, len(r.Steps))}
+	total := 0.0
+	for i, s := range r.Steps {
+		total += s.Seconds
+		tc := junitTestCase{Name: s.Name, ClassName: r.Name, Time: seconds(s.Seconds)}
+		switch {
+		case s.Skipped:
+			suite.Skipped++
+			tc.Skipped = &struct{}{}
+		case s.Error != "":
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: s.Error, Text: s.Error}
+		}
+		suite.Cases[i] = tc
+	}
+	suite.Time = seconds(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,53 @@
+package ci
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	r := &Report{Name: "e2e"}
+	_ = r.Add("connect", 1500*time.Millisecond, nil)
+	_ = r.Add("intercept orders", 250*time.Millisecond, errors.New(`no such workload "orders"`))
+	r.Skip("run")
+	return r
+}
+
+func TestReport_WriteJUnit(t *testing.T) {
+	r := testReport()
+	assert.Equal(t, 1, r.Failures())
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJUnit(&buf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="e2e" tests="3" failures="1" skipped="1" time="1.750">
+    <testcase name="connect" classname="e2e" time="1.500"></testcase>
+    <testcase name="intercept orders" classname="e2e" time="0.250">
+      <failure message="no such workload &#34;orders&#34;">no such workload &#34;orders&#34;</failure>
+    </testcase>
+    <testcase name="run" classname="e2e" time="0.000">
+      <skipped></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testReport().WriteJSON(&buf))
+	assert.JSONEq(t, `{
+  "name": "e2e",
+  "steps": [
+    {"name": "connect", "seconds": 1.5},
+    {"name": "intercept orders", "seconds": 0.25, "error": "no such workload \"orders\""},
+    {"name": "run", "seconds": 0, "skipped": true}
+  ]
+}`, buf.String())
+}
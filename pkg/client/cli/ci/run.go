@@ -0,0 +1,124 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// Execute makes the run that the given spec describes, and returns a report of each step. The connection request
+// of the given command must be committed. Kubernetes flags of the request take precedence over those of the spec.
+// The error of the first step that failed is returned together with the report.
+func Execute(cmd *cobra.Command, spec *Spec) (*Report, error) {
+	g, err := spec.Group()
+	if err != nil {
+		return nil, err
+	}
+	kubeFlags, err := spec.KubeFlags()
+	if err != nil {
+		return nil, err
+	}
+	cr := daemon.GetRequest(cmd.Context())
+	for k, v := range kubeFlags {
+		if _, ok := cr.KubeFlags[k]; !ok {
+			cr.KubeFlags[k] = v
+		}
+	}
+	if cr.ManagerNamespace == "" {
+		cr.ManagerNamespace = spec.ManagerNamespace()
+	}
+
+	r := &Report{Name: spec.Name}
+	skipRest := func(from int) {
+		for _, m := range spec.Intercepts[from:] {
+			r.Skip("intercept " + m.Name)
+		}
+		r.Skip("run")
+	}
+
+	if err = r.Time("connect", func() error { return connect.InitCommand(cmd) }); err != nil {
+		skipRest(0)
+		return r, err
+	}
+	ctx := dos.WithStdio(cmd.Context(), cmd)
+	if daemon.GetSession(ctx).Started {
+		defer func() {
+			_ = r.Time("quit", func() error {
+				connect.Quit(ctx)
+				return nil
+			})
+		}()
+	}
+
+	var sg *intercept.StartedGroup
+	if g != nil {
+		created := 0
+		sg, err = intercept.StartGroupFunc(ctx, g, func(name string, elapsed time.Duration, err error) {
+			created++
+			_ = r.Add("intercept "+name, elapsed, err)
+		})
+		if err != nil {
+			skipRest(created)
+			return r, err
+		}
+	}
+
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+	var handlersDone chan error
+	if sg != nil {
+		if g.HasHandlers() {
+			// The command is cancelled if a handler exits before it.
+			handlersDone = make(chan error, 1)
+			go func() {
+				handlersDone <- sg.RunHandlers(runCtx)
+				cancelRun()
+			}()
+		} else {
+			defer func() {
+				_ = r.Time("remove intercepts", func() error {
+					sg.Remove(ctx)
+					return nil
+				})
+			}()
+		}
+	}
+
+	err = r.Time("run", func() error { return spec.run(runCtx) })
+	if handlersDone != nil {
+		cancelRun()
+		err = errors.Join(err, r.Time("intercept handlers", func() error { return <-handlersDone }))
+	}
+	return r, err
+}
+
+// run runs the command of the spec, and waits for it to exit.
+func (s *Spec) run(ctx context.Context) error {
+	t := s.Timeout()
+	if t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c := s.Run.Command
+	cmd, err := proc.StartInDir(ctx, s.Dir(), s.Run.Env, c[0], c[1:]...)
+	if err != nil {
+		return err
+	}
+	err = proc.Wait(ctx, cancel, cmd)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", t, err)
+	}
+	return err
+}
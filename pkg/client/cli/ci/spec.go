@@ -0,0 +1,155 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// Spec describes a non-interactive run. A connection is established, the intercepts are created, and the command
+// is run. Everything is then torn down again.
+type Spec struct {
+	// Name is the name of the run. It's used as the name of the JUnit test suite. Defaults to "telepresence".
+	Name       string                   `json:"name,omitempty"`
+	Connect    *Connect                 `json:"connect,omitempty"`
+	Intercepts []*intercept.GroupMember `json:"intercepts,omitempty"`
+	Run        *Run                     `json:"run"`
+
+	// dir is the directory of the spec file. Relative paths are relative to this directory.
+	dir string
+}
+
+// Connect describes the connection to the cluster. The Server, CertificateAuthority, and TokenFile fields make it
+// possible to connect using the identity of a service account, without a kubeconfig.
+type Connect struct {
+	Context              string            `json:"context,omitempty"`
+	Namespace            string            `json:"namespace,omitempty"`
+	ManagerNamespace     string            `json:"managerNamespace,omitempty"`
+	Server               string            `json:"server,omitempty"`
+	CertificateAuthority string            `json:"certificateAuthority,omitempty"`
+	TokenFile            string            `json:"tokenFile,omitempty"`
+	KubeFlags            map[string]string `json:"kubeFlags,omitempty"`
+}
+
+// Run describes the command that runs while the intercepts are active.
+type Run struct {
+	Command    []string          `json:"command"`
+	WorkingDir string            `json:"workingDir,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	Timeout    string            `json:"timeout,omitempty"`
+
+	timeout time.Duration
+}
+
+var envRefRx = regexp.MustCompile(`\$\{(\w+)}`) //nolint:gochecknoglobals // constant
+
+// LoadSpec reads the spec in the given file. References on the form ${NAME} are replaced with the value of the
+// environment variable NAME, so that secrets can be passed from the CI environment.
+func LoadSpec(file string) (*Spec, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errcat.User.Newf("unable to read ci spec: %w", err)
+	}
+	data = envRefRx.ReplaceAllFunc(data, func(ref []byte) []byte {
+		return []byte(os.Getenv(string(ref[2 : len(ref)-1])))
+	})
+	var spec Spec
+	if err = yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, errcat.User.Newf("unable to parse ci spec in %s: %w", file, err)
+	}
+	if spec.dir, err = filepath.Abs(filepath.Dir(file)); err != nil {
+		return nil, err
+	}
+	if err = spec.validate(); err != nil {
+		return nil, errcat.User.Newf("invalid ci spec in %s: %w", file, err)
+	}
+	return &spec, nil
+}
+
+func (s *Spec) validate() error {
+	if s.Name == "" {
+		s.Name = "telepresence"
+	}
+	r := s.Run
+	if r == nil || len(r.Command) == 0 {
+		return errors.New("run.command is required")
+	}
+	if r.Timeout != "" {
+		var err error
+		if r.timeout, err = time.ParseDuration(r.Timeout); err != nil {
+			return fmt.Errorf("invalid run.timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+// Group returns the intercepts of the spec as an intercept group, or nil if the spec has no intercepts.
+func (s *Spec) Group() (*intercept.Group, error) {
+	if len(s.Intercepts) == 0 {
+		return nil, nil
+	}
+	return intercept.NewGroup(s.Name, s.dir, s.Intercepts)
+}
+
+// KubeFlags returns the Kubernetes flags that correspond to the connect section of the spec.
+func (s *Spec) KubeFlags() (map[string]string, error) {
+	c := s.Connect
+	if c == nil {
+		return nil, nil
+	}
+	flags := make(map[string]string, len(c.KubeFlags)+5)
+	maps.Copy(flags, c.KubeFlags)
+	set := func(k, v string) {
+		if v != "" {
+			flags[k] = v
+		}
+	}
+	set("context", c.Context)
+	set("namespace", c.Namespace)
+	set("server", c.Server)
+	set("certificate-authority", s.abs(c.CertificateAuthority))
+	if c.TokenFile != "" {
+		token, err := os.ReadFile(s.abs(c.TokenFile))
+		if err != nil {
+			return nil, errcat.User.Newf("unable to read token: %w", err)
+		}
+		flags["token"] = strings.TrimSpace(string(token))
+	}
+	return flags, nil
+}
+
+// ManagerNamespace returns the namespace of the traffic-manager declared in the spec, if any.
+func (s *Spec) ManagerNamespace() string {
+	if s.Connect == nil {
+		return ""
+	}
+	return s.Connect.ManagerNamespace
+}
+
+// Dir returns the working directory of the command, or an empty string when the command runs in the current
+// directory.
+func (s *Spec) Dir() string {
+	return s.abs(s.Run.WorkingDir)
+}
+
+// Timeout returns the maximum time that the command may run, or zero if there's no limit.
+func (s *Spec) Timeout() time.Duration {
+	return s.Run.timeout
+}
+
+func (s *Spec) abs(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
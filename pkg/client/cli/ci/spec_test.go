@@ -0,0 +1,105 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("secret-token\n"), 0o600))
+	t.Setenv("CI_NAMESPACE", "staging")
+
+	write := func(t *testing.T, data string) string {
+		file := filepath.Join(dir, "ci.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(data), 0o600))
+		return file
+	}
+
+	t.Run("full", func(t *testing.T) {
+		spec, err := LoadSpec(write(t, `
+name: e2e
+connect:
+  namespace: ${CI_NAMESPACE}
+  managerNamespace: ambassador
+  server: https://k8s.example.com
+  certificateAuthority: ca.crt
+  tokenFile: token
+  kubeFlags:
+    insecure-skip-tls-verify: "false"
+intercepts:
+  - name: orders
+    port: "8080"
+run:
+  command: [go, test, ./e2e/...]
+  workingDir: src
+  timeout: 10m
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "e2e", spec.Name)
+		assert.Equal(t, "ambassador", spec.ManagerNamespace())
+		assert.Equal(t, filepath.Join(dir, "src"), spec.Dir())
+		assert.Equal(t, 10*time.Minute, spec.Timeout())
+
+		flags, err := spec.KubeFlags()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"namespace":                "staging",
+			"server":                   "https://k8s.example.com",
+			"certificate-authority":    filepath.Join(dir, "ca.crt"),
+			"token":                    "secret-token",
+			"insecure-skip-tls-verify": "false",
+		}, flags)
+
+		g, err := spec.Group()
+		require.NoError(t, err)
+		require.Len(t, g.Intercepts, 1)
+		assert.Equal(t, "orders", g.Intercepts[0].Name)
+	})
+
+	t.Run("minimal", func(t *testing.T) {
+		spec, err := LoadSpec(write(t, `
+run:
+  command: [make, e2e]
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "telepresence", spec.Name)
+		assert.Empty(t, spec.Dir())
+		flags, err := spec.KubeFlags()
+		require.NoError(t, err)
+		assert.Empty(t, flags)
+		g, err := spec.Group()
+		require.NoError(t, err)
+		assert.Nil(t, g)
+	})
+
+	t.Run("no command", func(t *testing.T) {
+		_, err := LoadSpec(write(t, `
+intercepts:
+  - name: orders
+`))
+		require.ErrorContains(t, err, "run.command is required")
+	})
+
+	t.Run("bad timeout", func(t *testing.T) {
+		_, err := LoadSpec(write(t, `
+run:
+  command: [make]
+  timeout: forever
+`))
+		require.ErrorContains(t, err, "invalid run.timeout")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := LoadSpec(write(t, `
+run:
+  cmd: [make]
+`))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func adminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Administer the traffic-manager",
+	}
+	cmd.AddCommand(adminEvict())
+	return cmd
+}
+
+func adminEvict() *cobra.Command {
+	rq := &manager.EvictRequest{}
+	cmd := &cobra.Command{
+		Use: "evict [flags]",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if rq.User == "" && rq.Namespace == "" && rq.Workload == "" {
+				return errcat.User.New("at least one of --user, --namespace, and --workload must be given")
+			}
+			return cobra.NoArgs(cmd, args)
+		},
+
+		Short: "Forcibly remove the sessions and intercepts of other clients",
+		Long: `Forcibly remove the sessions and intercepts of other clients.
+
+The intercepts in the given namespace, or of the given workload, are removed, optionally only those of the given
+user. The sessions of the user are removed, together with all their intercepts, when neither a namespace nor a
+workload is given. A user is given as the user part of the client name, e.g. "jane", or as the full client name,
+e.g. "jane@laptop".
+
+The traffic-manager's admin API must be enabled using the Helm chart value adminApi.enabled. The request is
+authenticated using the bearer token of the current kubeconfig context, and the user that it belongs to must be
+allowed to delete sessions.telepresence.io in the namespace, or cluster-wide when no namespace is given.`,
+		Example: `  # Remove all sessions of jane, together with their intercepts
+  telepresence admin evict --user jane
+
+  # Remove all intercepts of the echo workload in the staging namespace
+  telepresence admin evict --workload echo --namespace staging`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return evict(cmd, rq)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&rq.User, "user", "", "Remove the sessions, or only the intercepts, of this user")
+	flags.StringVarP(&rq.Namespace, "namespace", "n", "", "Remove the intercepts in this namespace")
+	flags.StringVarP(&rq.Workload, "workload", "w", "", "Remove the intercepts of this workload")
+	return cmd
+}
+
+func evict(cmd *cobra.Command, rq *manager.EvictRequest) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	result, err := daemon.GetUserClient(ctx).Evict(ctx, rq)
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument, codes.FailedPrecondition, codes.Unavailable, codes.Unimplemented,
+				codes.Unauthenticated, codes.PermissionDenied:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, result, false)
+		return nil
+	}
+	out := output.Out(ctx)
+	if len(result.SessionIds) == 0 && len(result.InterceptIds) == 0 {
+		fmt.Fprintln(out, "Nothing was evicted")
+		return nil
+	}
+	for _, id := range result.SessionIds {
+		fmt.Fprintf(out, "Evicted session %s\n", id)
+	}
+	for _, id := range result.InterceptIds {
+		fmt.Fprintf(out, "Evicted intercept %s\n", id)
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blang/semver/v4"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	mgrcap "github.com/telepresenceio/telepresence/v2/pkg/capability"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// The components that take part in providing a capability.
+const (
+	compClient         = "client"
+	compRootDaemon     = "root daemon"
+	compUserDaemon     = "user daemon"
+	compTrafficManager = "traffic-manager"
+	compTrafficAgent   = "traffic-agent"
+)
+
+var capabilityComponents = []string{compClient, compRootDaemon, compUserDaemon, compTrafficManager, compTrafficAgent} //nolint:gochecknoglobals // constant
+
+// capability is a feature that requires a minimum version of some of the components.
+type capability struct {
+	name        string
+	description string
+	minVersions map[string]semver.Version
+
+	// feature is the name of the feature that the traffic-manager reports when it supports the capability.
+	feature string
+
+	// notInBuild is non-empty when no version of this build supports the capability.
+	notInBuild string
+}
+
+var capabilities = []*capability{ //nolint:gochecknoglobals // constant
+	{
+		name:        "udp",
+		feature:     mgrcap.UDP,
+		description: "Intercept UDP ports and forward them using --to-pod",
+		minVersions: map[string]semver.Version{
+			compUserDaemon:     semver.MustParse("2.6.8"),
+			compTrafficManager: semver.MustParse("2.6.8"),
+			compTrafficAgent:   semver.MustParse("2.6.8"),
+		},
+	},
+	{
+		name:        "replace",
+		feature:     mgrcap.Replace,
+		description: "Replace the intercepted container using intercept --replace",
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.14.0"),
+			compUserDaemon:     semver.MustParse("2.14.0"),
+			compTrafficManager: semver.MustParse("2.14.0"),
+			compTrafficAgent:   semver.MustParse("2.14.0"),
+		},
+	},
+	{
+		name:        "ftp",
+		description: "Mount remote volumes using the embedded FTP client",
+		minVersions: map[string]semver.Version{
+			compUserDaemon:   semver.MustParse("2.7.4"),
+			compTrafficAgent: semver.MustParse("2.7.4"),
+		},
+	},
+	{
+		name:        "proxy-via",
+		feature:     mgrcap.ProxyVia,
+		description: "Route subnets through a workload using connect --proxy-via",
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.18.0"),
+			compRootDaemon:     semver.MustParse("2.18.0"),
+			compUserDaemon:     semver.MustParse("2.18.0"),
+			compTrafficManager: semver.MustParse("2.18.0"),
+		},
+	},
+	{
+		name:        "intercept-sharing",
+		description: "Share intercepts with teammates using intercept share and intercept join",
+		feature:     mgrcap.InterceptSharing,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "header-propagation",
+		description: "Verify the propagation of intercept headers using verify-propagation",
+		feature:     mgrcap.HeaderPropagation,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "publish",
+		description: "Publish local services to the cluster using publish",
+		feature:     mgrcap.PublishedServices,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "registry-proxy",
+		description: "Expose local registries to the cluster using registry expose",
+		feature:     mgrcap.RegistryProxy,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "admin-evict",
+		description: "Evict sessions and intercepts using admin evict",
+		feature:     mgrcap.AdminAPI,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "describe",
+		description: "Describe how the traffic-manager sees a workload using describe",
+		feature:     mgrcap.WorkloadDescribe,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "events",
+		description: "Stream the Kubernetes events of agent injection using events",
+		feature:     mgrcap.AgentEvents,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "dry-run",
+		description: "Analyze the impact of an intercept without creating it using intercept --dry-run",
+		feature:     mgrcap.InterceptDryRun,
+		minVersions: map[string]semver.Version{
+			compClient:         semver.MustParse("2.21.0"),
+			compUserDaemon:     semver.MustParse("2.21.0"),
+			compTrafficManager: semver.MustParse("2.21.0"),
+		},
+	},
+	{
+		name:        "h2",
+		description: "Terminate TLS and probe headers of HTTP/2 intercepted traffic",
+		notInBuild:  "the traffic-agent only understands HTTP/1.x. HTTP/2 traffic is intercepted as plain TCP",
+	},
+	{
+		name:        "ingest",
+		description: "Ingest a container without intercepting its traffic",
+		notInBuild:  "not implemented by this version of Telepresence",
+	},
+}
+
+// CapabilityInfo is the outcome of checking one capability against the versions of the components.
+type CapabilityInfo struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description" yaml:"description"`
+	Available   bool              `json:"available" yaml:"available"`
+	Components  map[string]string `json:"components,omitempty" yaml:"components,omitempty"`
+	Reasons     []string          `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+// CapabilitiesInfo is the output of the capabilities command.
+type CapabilitiesInfo struct {
+	Versions     map[string]string `json:"versions" yaml:"versions"`
+	Capabilities []*CapabilityInfo `json:"capabilities" yaml:"capabilities"`
+}
+
+func capabilitiesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "capabilities",
+		Args:  cobra.NoArgs,
+		Short: "Show which features the client, daemons, traffic-manager, and traffic-agents support",
+		Long: `Show which features the client, daemons, traffic-manager, and traffic-agents support.
+
+The versions of all components are compared to the versions that introduced each feature, and a matrix is
+printed that shows which components prevent a feature from being used. Components that aren't running or
+can't be reached are shown as unknown.`,
+		Annotations: map[string]string{
+			ann.UserDaemon: ann.Optional,
+		},
+		SilenceUsage: true,
+		RunE:         printCapabilities,
+	}
+}
+
+func printCapabilities(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	versions := componentVersions(ctx)
+	info := &CapabilitiesInfo{
+		Versions:     make(map[string]string, len(versions)),
+		Capabilities: checkCapabilities(versions),
+	}
+	for comp, v := range versions {
+		info.Versions[comp] = "v" + v.String()
+	}
+	if mv, ok := versions[compTrafficManager]; ok {
+		if mc, err := managerCapabilities(ctx); err == nil {
+			checkManagerFeatures(info.Capabilities, mc.Features, mv)
+		}
+	}
+	checkInstallOptions(ctx, info.Capabilities)
+
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, info, false)
+		return nil
+	}
+	return writeCapabilities(cmd, info)
+}
+
+// componentVersions returns the versions of the components that could be reached.
+func componentVersions(ctx context.Context) map[string]semver.Version {
+	versions := map[string]semver.Version{compClient: client.Semver()}
+	addVersion := func(comp, v string) {
+		if sv, err := semver.ParseTolerant(v); err == nil {
+			versions[comp] = sv
+		}
+	}
+	userD := daemon.GetUserClient(ctx)
+	if userD == nil {
+		return versions
+	}
+	versions[compUserDaemon] = userD.Semver()
+	if userD.Containerized() {
+		if vi, err := userD.RootDaemonVersion(ctx, &empty.Empty{}); err == nil {
+			addVersion(compRootDaemon, vi.Version)
+		}
+	} else if vi, err := daemonVersion(ctx); err == nil {
+		addVersion(compRootDaemon, vi.Version)
+	}
+	if vi, err := managerVersion(ctx); err == nil {
+		addVersion(compTrafficManager, vi.Version)
+		if af, err := trafficAgentFQN(ctx); err == nil {
+			if i := strings.LastIndexByte(af.FQN, ':'); i > 0 {
+				addVersion(compTrafficAgent, af.FQN[i+1:])
+			}
+		}
+	}
+	return versions
+}
+
+// checkCapabilities checks all capabilities against the given component versions. A capability is
+// unavailable when one of its components is older than required, or when its version is unknown.
+func checkCapabilities(versions map[string]semver.Version) []*CapabilityInfo {
+	infos := make([]*CapabilityInfo, len(capabilities))
+	for i, c := range capabilities {
+		ci := &CapabilityInfo{Name: c.name, Description: c.description, Available: true}
+		infos[i] = ci
+		if c.notInBuild != "" {
+			ci.Available = false
+			ci.Reasons = []string{c.notInBuild}
+			continue
+		}
+		ci.Components = make(map[string]string, len(c.minVersions))
+		for _, comp := range capabilityComponents {
+			minV, ok := c.minVersions[comp]
+			if !ok {
+				continue
+			}
+			v, ok := versions[comp]
+			switch {
+			case !ok:
+				ci.Available = false
+				ci.Components[comp] = "unknown"
+				ci.Reasons = append(ci.Reasons, fmt.Sprintf("the %s version is unknown", comp))
+			case v.LT(minV):
+				ci.Available = false
+				ci.Components[comp] = "no"
+				ci.Reasons = append(ci.Reasons, fmt.Sprintf("the %s is v%s, but v%s or later is required", comp, v, minV))
+			default:
+				ci.Components[comp] = "yes"
+			}
+		}
+	}
+	return infos
+}
+
+// managerCapabilities returns the features that the traffic-manager reports.
+func managerCapabilities(ctx context.Context) (*manager.Capabilities, error) {
+	if userD := daemon.GetUserClient(ctx); userD != nil {
+		return userD.TrafficManagerCapabilities(ctx, &empty.Empty{})
+	}
+	return nil, connect.ErrNoUserDaemon
+}
+
+// checkManagerFeatures marks capabilities that the versions support, but that the traffic-manager doesn't report,
+// typically because its configuration disables them.
+func checkManagerFeatures(infos []*CapabilityInfo, features []string, managerVersion semver.Version) {
+	for i, ci := range infos {
+		c := capabilities[i]
+		if c.feature == "" || !ci.Available {
+			continue
+		}
+		if err := mgrcap.Check(c.feature, features, managerVersion); err != nil {
+			ci.Available = false
+			ci.Reasons = append(ci.Reasons, status.Convert(err).Message())
+		}
+	}
+}
+
+// checkInstallOptions marks capabilities that the versions support, but that the configuration disables.
+func checkInstallOptions(ctx context.Context, infos []*CapabilityInfo) {
+	for _, ci := range infos {
+		if ci.Name != "ftp" || !ci.Available {
+			continue
+		}
+		if !client.GetConfig(ctx).Intercept().UseFtp {
+			ci.Available = false
+			ci.Reasons = append(ci.Reasons, "disabled by the client configuration intercept.useFtp")
+			continue
+		}
+		if userD := daemon.GetUserClient(ctx); userD != nil {
+			r, err := userD.RemoteMountAvailability(ctx, &empty.Empty{})
+			if err == nil {
+				err = errcat.FromResult(r)
+			}
+			if err != nil {
+				ci.Available = false
+				ci.Reasons = append(ci.Reasons, err.Error())
+			}
+		}
+	}
+}
+
+func writeCapabilities(cmd *cobra.Command, info *CapabilitiesInfo) error {
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "FEATURE")
+	for _, comp := range capabilityComponents {
+		v, ok := info.Versions[comp]
+		if !ok {
+			v = "unknown"
+		}
+		fmt.Fprintf(tw, "\t%s (%s)", strings.ToUpper(comp), v)
+	}
+	fmt.Fprintln(tw, "\tAVAILABLE")
+	for _, ci := range info.Capabilities {
+		fmt.Fprint(tw, ci.Name)
+		for _, comp := range capabilityComponents {
+			s, ok := ci.Components[comp]
+			if !ok {
+				s = "-"
+			}
+			fmt.Fprintf(tw, "\t%s", s)
+		}
+		if ci.Available {
+			fmt.Fprintln(tw, "\tyes")
+		} else {
+			fmt.Fprintln(tw, "\tno")
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for _, ci := range info.Capabilities {
+		if !ci.Available {
+			fmt.Fprintf(out, "\n%s (%s) is unavailable:\n", ci.Name, ci.Description)
+			for _, r := range ci.Reasons {
+				fmt.Fprintf(out, "  - %s\n", r)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkCapabilities(t *testing.T) {
+	v := semver.MustParse
+	infos := checkCapabilities(map[string]semver.Version{
+		compClient:         v("2.21.0"),
+		compRootDaemon:     v("2.21.0"),
+		compUserDaemon:     v("2.21.0"),
+		compTrafficManager: v("2.17.1"),
+	})
+	byName := make(map[string]*CapabilityInfo, len(infos))
+	for _, ci := range infos {
+		byName[ci.Name] = ci
+	}
+
+	udp := byName["udp"]
+	require.NotNil(t, udp)
+	assert.False(t, udp.Available)
+	assert.Equal(t, map[string]string{compUserDaemon: "yes", compTrafficManager: "yes", compTrafficAgent: "unknown"}, udp.Components)
+	assert.Equal(t, []string{"the traffic-agent version is unknown"}, udp.Reasons)
+
+	pv := byName["proxy-via"]
+	require.NotNil(t, pv)
+	assert.False(t, pv.Available)
+	assert.Equal(t, "no", pv.Components[compTrafficManager])
+	assert.Equal(t, []string{"the traffic-manager is v2.17.1, but v2.18.0 or later is required"}, pv.Reasons)
+
+	h2 := byName["h2"]
+	require.NotNil(t, h2)
+	assert.False(t, h2.Available)
+	assert.Empty(t, h2.Components)
+	assert.Len(t, h2.Reasons, 1)
+
+	infos = checkCapabilities(map[string]semver.Version{
+		compClient:         v("2.21.0"),
+		compRootDaemon:     v("2.21.0"),
+		compUserDaemon:     v("2.21.0"),
+		compTrafficManager: v("2.21.0"),
+		compTrafficAgent:   v("2.21.0"),
+	})
+	for _, ci := range infos {
+		assert.Equal(t, capabilityByName(ci.Name).notInBuild == "", ci.Available, ci.Name)
+	}
+
+	// Features that the traffic-manager doesn't report are unavailable.
+	checkManagerFeatures(infos, []string{"udp", "replace", "proxy-via", "intercept-sharing", "header-propagation", "workload-describe", "agent-events", "agent-arrival-progress", "intercept-dry-run"}, v("2.21.0"))
+	for _, ci := range infos {
+		switch ci.Name {
+		case "publish":
+			assert.False(t, ci.Available)
+			assert.Equal(t, []string{
+				"publishing of services is disabled in the traffic-manager, enable it using the Helm value publishedServices.enabled",
+			}, ci.Reasons)
+		case "registry-proxy", "admin-evict", "h2", "ingest":
+			assert.False(t, ci.Available, ci.Name)
+		default:
+			assert.True(t, ci.Available, ci.Name)
+		}
+	}
+}
+
+func capabilityByName(name string) *capability {
+	for _, c := range capabilities {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
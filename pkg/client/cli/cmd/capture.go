@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type captureCommand struct {
+	output             string
+	port               uint16
+	maxRequests        int
+	duration           time.Duration
+	includeCredentials bool
+}
+
+func captureCmd() *cobra.Command {
+	cc := &captureCommand{}
+	cmd := &cobra.Command{
+		Use:  "capture [flags] <workload>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Capture the HTTP requests that a workload receives, so that they can be replayed",
+		Long: `Capture the HTTP requests that a workload receives, so that they can be replayed.
+
+The traffic-agents of the workload record the HTTP/1.x requests that arrive on connections that are established
+while the capture is in progress. The workload is not intercepted, so the requests are still served by the
+workload. A traffic-agent is injected into the workload if it doesn't have one.
+
+The requests are written to a file with one JSON object per line, and can later be replayed against the handler
+of an intercept using "telepresence replay". The capture stops when the command is interrupted, or when the
+--duration or --max-requests limit is reached.
+
+The values of the Authorization, Proxy-Authorization, and Cookie headers are replaced with REDACTED unless
+--include-credentials is used. Credentials can only be captured while the workload is intercepted by this client.`,
+		Example: `  # Capture the requests that the echo workload receives during one minute
+  telepresence capture echo -o requests.jsonl --duration 1m
+
+  # Capture the first 100 requests to container port 8080
+  telepresence capture echo -o requests.jsonl --port 8080 --max-requests 100`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return cc.run(cmd, strings.TrimSpace(args[0]))
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&cc.output, "output", "o", "", "The file to write the captured requests to")
+	flags.Uint16Var(&cc.port, "port", 0, "The container port to capture requests for. Defaults to all ports")
+	flags.IntVar(&cc.maxRequests, "max-requests", 0, "Stop when this number of requests has been captured")
+	flags.DurationVar(&cc.duration, "duration", 0, "Stop when the requests have been captured for this long")
+	flags.BoolVar(&cc.includeCredentials, "include-credentials", false,
+		"Don't redact the values of the Authorization, Proxy-Authorization, and Cookie headers")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (cc *captureCommand) run(cmd *cobra.Command, workload string) error {
+	f, err := os.Create(cc.output)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if cc.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cc.duration)
+		defer cancel()
+	}
+	stream, err := daemon.GetUserClient(ctx).Capture(ctx, &connector.CaptureRequest{
+		Workload:           workload,
+		Port:               int32(cc.port),
+		IncludeCredentials: cc.includeCredentials,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Capturing the requests to workload %s in %s. Press Ctrl-C to stop\n", workload, cc.output)
+
+	count := 0
+	for cc.maxRequests == 0 || count < cc.maxRequests {
+		cr, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled || status.Code(err) == codes.DeadlineExceeded {
+				break
+			}
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.NotFound, codes.FailedPrecondition, codes.Unauthenticated, codes.PermissionDenied:
+					return errcat.User.New(st.Message())
+				}
+			}
+			return err
+		}
+		data, err := protojson.Marshal(cr)
+		if err != nil {
+			return err
+		}
+		if _, err = bw.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		count++
+	}
+	cancel()
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Captured %d requests to %s\n", count, cc.output)
+	return f.Close()
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ci"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type ciRunCommand struct {
+	file  string
+	junit string
+	json  string
+}
+
+func ciCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Use telepresence non-interactively in continuous integration pipelines",
+	}
+	cmd.AddCommand(ciRun())
+	return cmd
+}
+
+func ciRun() *cobra.Command {
+	var request *daemon.CobraRequest
+	cr := &ciRunCommand{}
+	cmd := &cobra.Command{
+		Use:   "run [flags]",
+		Args:  cobra.NoArgs,
+		Short: "Connect, create intercepts, run a command, and tear everything down",
+		Long: `Connect, create intercepts, run a command, and tear everything down.
+
+The run is described by a spec file. References on the form ${NAME} in the file are replaced with the value of the
+environment variable NAME. The connect section can declare a server, certificate authority, and token file, so that
+the run uses the identity of a service account instead of a kubeconfig. The intercepts are described using the same
+fields as the intercepts of an intercept group, e.g.
+
+name: checkout-e2e
+connect:
+  namespace: staging
+  server: https://k8s.example.com
+  certificateAuthority: ca.crt
+  tokenFile: ${RUNNER_TEMP}/token
+intercepts:
+  - name: orders
+    port: "8080"
+    handler:
+      command: [./orders-server]
+run:
+  command: [go, test, ./e2e/...]
+  timeout: 10m
+
+The outcome of each step is written as a JUnit or JSON report, and the command fails if any step fails.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			spec, err := ci.LoadSpec(cr.file)
+			if err != nil {
+				return err
+			}
+			if err = request.CommitFlags(cmd); err != nil {
+				return err
+			}
+			report, err := ci.Execute(cmd, spec)
+			if report != nil {
+				err = errors.Join(err, cr.writeReports(report))
+			}
+			return err
+		},
+	}
+	request = daemon.InitRequest(cmd)
+	flags := cmd.Flags()
+	flags.StringVarP(&cr.file, "file", "f", "", "The spec file that describes the run")
+	flags.StringVar(&cr.junit, "junit", "", "Write a JUnit XML report of the run to this file")
+	flags.StringVar(&cr.json, "json", "", "Write a JSON report of the run to this file")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func (cr *ciRunCommand) writeReports(report *ci.Report) error {
+	write := func(file string, enc func(*bytes.Buffer) error) error {
+		if file == "" {
+			return nil
+		}
+		var buf bytes.Buffer
+		if err := enc(&buf); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			return errcat.User.Newf("unable to write report: %w", err)
+		}
+		return nil
+	}
+	if err := write(cr.junit, func(b *bytes.Buffer) error { return report.WriteJUnit(b) }); err != nil {
+		return err
+	}
+	return write(cr.json, func(b *bytes.Buffer) error { return report.WriteJSON(b) })
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type composeExportCommand struct {
+	intercept.ComposeOptions
+	file string
+}
+
+func composeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compose",
+		Short: "Integrate intercepts with docker compose",
+	}
+	cmd.AddCommand(composeExport())
+	return cmd
+}
+
+func composeExport() *cobra.Command {
+	ce := &composeExportCommand{}
+	cmd := &cobra.Command{
+		Use:  "export [flags] <intercept_name>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Print a docker compose fragment with a service that handles an intercept",
+		Long: `Print a docker compose fragment with a service that handles an intercept.
+
+The environment of the intercept is written to an env file that the service loads, the remote volumes of the
+intercept are mounted in the service, and the service is connected to the network of the daemon container, or,
+when the daemon runs on the host, to the telepresence network with the intercepted port published. Combine the
+fragment with the compose files of a project using "docker compose -f compose.yaml -f <fragment> up".`,
+		Example: `  # Write a fragment for the echo intercept, which is handled by the echo-dev image, to echo.compose.yaml
+  telepresence compose export echo --image echo-dev --file echo.compose.yaml`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return ce.run(cmd, strings.TrimSpace(args[0]))
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&ce.Service, "service", "", "Name of the compose service. Defaults to the name of the intercept")
+	flags.StringVar(&ce.Image, "image", "", "Image of the compose service")
+	flags.StringVar(&ce.Build, "build", "", "Build context of the compose service, used instead of --image")
+	flags.StringVar(&ce.EnvFile, "env-file", "", "File to write the environment of the intercept to. Defaults to <intercept_name>.env")
+	flags.Uint16Var(&ce.ContainerPort, "container-port", 0, "Port that the service listens to. Defaults to the local port of the intercept")
+	flags.StringVarP(&ce.file, "file", "f", "", "File to write the fragment to. Defaults to standard output")
+	return cmd
+}
+
+func (ce *composeExportCommand) run(cmd *cobra.Command, name string) error {
+	if (ce.Image == "") == (ce.Build == "") {
+		return errcat.User.New("exactly one of --image or --build must be given")
+	}
+	ctx := cmd.Context()
+	ud := daemon.GetUserClient(ctx)
+	ii, err := ud.GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errcat.User.Newf("found no intercept named %s", name)
+		}
+		return err
+	}
+	if ud.Containerized() {
+		ce.DaemonContainer = ud.DaemonID().ContainerName()
+	}
+
+	// Relative paths in a compose file are relative to the file, so absolute paths are used.
+	if ce.EnvFile == "" {
+		ce.EnvFile = name + ".env"
+	}
+	if ce.EnvFile, err = filepath.Abs(ce.EnvFile); err != nil {
+		return err
+	}
+	if ce.Build != "" && !docker.IsRemoteContext(ce.Build) {
+		if ce.Build, err = filepath.Abs(ce.Build); err != nil {
+			return err
+		}
+	}
+	if err = intercept.WriteComposeEnvFile(ce.EnvFile, ii.Environment); err != nil {
+		return errcat.User.Newf("unable to write environment file: %w", err)
+	}
+	data, err := intercept.ComposeOverlay(ii, &ce.ComposeOptions)
+	if err != nil {
+		return err
+	}
+	if ce.file == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err = os.WriteFile(ce.file, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote compose fragment for intercept %s to %s\n", name, ce.file)
+	return nil
+}
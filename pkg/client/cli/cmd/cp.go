@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/tarstream"
+)
+
+// putFilesChunkSize is the maximum size of the data in each message sent by the cp command.
+const putFilesChunkSize = 64 * 1024
+
+func cp() *cobra.Command {
+	var dest string
+	cmd := &cobra.Command{
+		Use:  "cp [flags] <intercept_name> <local path>...",
+		Args: cobra.MinimumNArgs(2),
+
+		Short: "Copy local files into the remote volumes of an intercept",
+		Long: `Copy local files into the remote volumes of an intercept.
+
+The files and directories are copied into the --dest directory of the intercepted container, which must be the
+mount path of a volume, or a directory below one. The copies are visible to all containers of the intercepted
+pod that mount the volume, so that an intercept handler can produce artifacts that are consumed by other
+containers. Existing files are overwritten. The files are copied through the traffic-agent, so no remote mount
+is needed.`,
+		Example: `  # Copy the local reports directory into the /data volume of the intercepted container
+  telepresence cp my-intercept ./reports --dest /data`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return runCp(cmd, strings.TrimSpace(args[0]), args[1:], dest)
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	cmd.Flags().StringVar(&dest, "dest", "", "The remote directory to copy the files into")
+	_ = cmd.MarkFlagRequired("dest")
+	return cmd
+}
+
+func runCp(cmd *cobra.Command, name string, paths []string, dest string) error {
+	ctx := cmd.Context()
+	stream, err := daemon.GetUserClient(ctx).PutFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(tarstream.WriteFiles(ctx, pw, paths))
+	}()
+	defer pr.Close()
+
+	rq := &connector.PutFilesRequest{Name: name, Dest: dest}
+	buf := make([]byte, putFilesChunkSize)
+	for {
+		n, rErr := io.ReadFull(pr, buf)
+		if n > 0 || rq.Name != "" {
+			rq.Data = buf[:n]
+			if err = stream.Send(rq); err != nil {
+				// The error is revealed by CloseAndRecv.
+				break
+			}
+			rq = &connector.PutFilesRequest{}
+		}
+		if rErr != nil {
+			if !errors.Is(rErr, io.EOF) && !errors.Is(rErr, io.ErrUnexpectedEOF) {
+				_ = stream.CloseSend()
+				return errcat.User.New(rErr)
+			}
+			break
+		}
+	}
+	result, err := stream.CloseAndRecv()
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound, codes.FailedPrecondition, codes.InvalidArgument:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Copied %d files into %s\n", result.Count, dest)
+	return nil
+}
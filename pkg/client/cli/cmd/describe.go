@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+type describeCommand struct {
+	namespace string
+}
+
+func describe() *cobra.Command {
+	dc := &describeCommand{}
+	cmd := &cobra.Command{
+		Use:  "describe [flags] <workload>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Describe how the traffic-manager sees a workload when it is intercepted",
+		Long: `Describe how the traffic-manager sees a workload when it is intercepted.
+
+The description contains the workload's entry in the telepresence-agents ConfigMap, the interceptable ports
+of each container, the Telepresence annotations of the pod template, the traffic-agents that have arrived,
+the current intercepts, and the conditions that prevent the workload from being intercepted. When the
+workload has no entry in the ConfigMap, the entry that the first intercept would create is shown instead.
+The command never changes the workload or its entry. Use pod/<name> to describe a bare pod.`,
+		Example: `  # Describe the deployment echo in the connected namespace
+  telepresence describe echo`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage:      true,
+		RunE:              dc.run,
+		ValidArgsFunction: (&intercept.Command{}).ValidArgs,
+	}
+	cmd.Flags().StringVarP(&dc.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
+	return cmd
+}
+
+func (dc *describeCommand) run(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	kind, name, err := intercept.ParseWorkloadName(strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	d, err := daemon.GetUserClient(ctx).DescribeWorkload(ctx, &manager.DescribeWorkloadRequest{
+		Name:         name,
+		Namespace:    dc.namespace,
+		WorkloadKind: kind,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound, codes.InvalidArgument, codes.Unimplemented:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, d, false)
+	} else {
+		printWorkloadDescription(output.Out(ctx), d)
+	}
+	return nil
+}
+
+func printWorkloadDescription(out io.Writer, d *manager.WorkloadDescription) {
+	kvf := ioutil.DefaultKeyValueFormatter()
+	kvf.Add("Workload", fmt.Sprintf("%s %s.%s", d.Kind, d.Name, d.Namespace))
+	switch {
+	case d.AgentConfigStored:
+		kvf.Add("Agent config", "stored in the telepresence-agents ConfigMap")
+	case d.AgentConfig != "":
+		kvf.Add("Agent config", "not stored, the first intercept creates the config shown below")
+	default:
+		kvf.Add("Agent config", "none")
+	}
+	if d.StaleReason != "" {
+		kvf.Add("Stale config", d.StaleReason+". The next intercept regenerates the config")
+	}
+	if len(d.AgentPods) > 0 {
+		kvf.Add("Traffic-agents", strings.Join(d.AgentPods, ", "))
+	} else {
+		kvf.Add("Traffic-agents", "none")
+	}
+	if len(d.BlockingConditions) == 0 {
+		kvf.Add("Blocking conditions", "none")
+	}
+	kvf.Println(out)
+
+	if len(d.BlockingConditions) > 0 {
+		fmt.Fprintln(out, "\nBlocking conditions:")
+		for _, bc := range d.BlockingConditions {
+			fmt.Fprintf(out, "    %s\n", bc)
+		}
+	}
+	if len(d.Annotations) > 0 {
+		fmt.Fprintln(out, "\nAnnotations:")
+		ks := make([]string, 0, len(d.Annotations))
+		for k := range d.Annotations {
+			ks = append(ks, k)
+		}
+		slices.Sort(ks)
+		for _, k := range ks {
+			fmt.Fprintf(out, "    %s=%s\n", k, d.Annotations[k])
+		}
+	}
+	if len(d.Containers) > 0 {
+		fmt.Fprintln(out, "\nInterceptable ports:")
+		for _, c := range d.Containers {
+			if c.Replace {
+				fmt.Fprintf(out, "    container %s, replaced by the traffic-agent during intercepts\n", c.Name)
+			} else {
+				fmt.Fprintf(out, "    container %s\n", c.Name)
+			}
+			for _, p := range c.Ports {
+				fmt.Fprintf(out, "        %s\n", describePort(p))
+			}
+		}
+	}
+	if len(d.Intercepts) > 0 {
+		fmt.Fprintln(out, "\nIntercepts:")
+		for _, ii := range d.Intercepts {
+			fmt.Fprintf(out, "    %-30s %-12s %s\n", ii.Spec.Name, ii.Disposition, ii.Spec.Client)
+		}
+	}
+	if d.AgentConfig != "" {
+		fmt.Fprintln(out, "\nAgent config:")
+		for _, line := range strings.Split(strings.TrimRight(d.AgentConfig, "\n"), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+// describePort returns a one-line description of how the given port is intercepted.
+func describePort(p *manager.WorkloadDescription_Port) string {
+	portString := func(name string, number int32) string {
+		if name == "" {
+			return strconv.Itoa(int(number))
+		}
+		return fmt.Sprintf("%s (%d)", name, number)
+	}
+	cp := fmt.Sprintf("container port %s/%s", portString(p.ContainerPortName, p.ContainerPort), p.Protocol)
+	switch {
+	case p.ServiceName == "":
+		return cp + ", no service"
+	case p.Headless:
+		return fmt.Sprintf("headless service %s, port %s -> %s", p.ServiceName, portString(p.ServicePortName, p.ServicePort), cp)
+	default:
+		return fmt.Sprintf("service %s, port %s -> %s", p.ServiceName, portString(p.ServicePortName, p.ServicePort), cp)
+	}
+}
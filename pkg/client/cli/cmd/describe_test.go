@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_printWorkloadDescription(t *testing.T) {
+	d := &manager.WorkloadDescription{
+		Name:              "echo",
+		Namespace:         "default",
+		Kind:              "Deployment",
+		Annotations:       map[string]string{"telepresence.getambassador.io/inject-traffic-agent": "enabled"},
+		AgentConfig:       "agentName: echo\nnamespace: default\n",
+		AgentConfigStored: true,
+		Containers: []*manager.WorkloadDescription_Container{{
+			Name:    "echo",
+			Replace: true,
+			Ports: []*manager.WorkloadDescription_Port{
+				{ServiceName: "echo", ServicePortName: "http", ServicePort: 80, ContainerPort: 8080, Protocol: "TCP"},
+				{ContainerPortName: "metrics", ContainerPort: 9090, Protocol: "TCP"},
+			},
+		}},
+		AgentPods: []string{"echo-5f8b-abcde"},
+		Intercepts: []*manager.InterceptInfo{{
+			Spec:        &manager.InterceptSpec{Name: "echo", Client: "alice@laptop"},
+			Disposition: manager.InterceptDispositionType_ACTIVE,
+		}},
+	}
+	sb := &strings.Builder{}
+	printWorkloadDescription(sb, d)
+	assert.Equal(t, `Workload           : Deployment echo.default
+Agent config       : stored in the telepresence-agents ConfigMap
+Traffic-agents     : echo-5f8b-abcde
+Blocking conditions: none
+
+Annotations:
+    telepresence.getambassador.io/inject-traffic-agent=enabled
+
+Interceptable ports:
+    container echo, replaced by the traffic-agent during intercepts
+        service echo, port http (80) -> container port 8080/TCP
+        container port metrics (9090)/TCP, no service
+
+Intercepts:
+    echo                           ACTIVE       alice@laptop
+
+Agent config:
+    agentName: echo
+    namespace: default
+`, sb.String())
+
+	sb.Reset()
+	printWorkloadDescription(sb, &manager.WorkloadDescription{
+		Name:               "db",
+		Namespace:          "default",
+		Kind:               "StatefulSet",
+		BlockingConditions: []string{"StatefulSet db.default is not interceptable"},
+	})
+	assert.Equal(t, `Workload      : StatefulSet db.default
+Agent config  : none
+Traffic-agents: none
+
+Blocking conditions:
+    StatefulSet db.default is not interceptable
+`, sb.String())
+}
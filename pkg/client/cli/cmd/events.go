@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func events() *cobra.Command {
+	return &cobra.Command{
+		Use:  "events",
+		Args: cobra.NoArgs,
+
+		Short: "Stream the events of the current session",
+		Long: `Stream the events of the current session until interrupted.
+
+The events include the lifecycle of intercepts, their mounts and handlers, and the Kubernetes events that
+the traffic-manager observes while it waits for a traffic-agent to arrive, such as image pull failures,
+scheduling problems, and Pod Security violations. Run the command in a separate terminal to follow the
+progress of the agent injection of an intercept. Use --output json-stream to get one JSON object per event.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         runEvents,
+	}
+}
+
+func runEvents(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	stream := output.WantsStream(cmd)
+	if output.WantsFormatted(cmd) && !stream {
+		return errcat.User.New("the events command only supports the json-stream output format")
+	}
+	ctx := cmd.Context()
+	ec, err := daemon.GetUserClient(ctx).WatchEvents(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	out := output.Out(ctx)
+	for {
+		ev, err := ec.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errcat.NoDaemonLogs.Newf("%v", err)
+		}
+		if stream {
+			output.Object(ctx, ev, true)
+		} else {
+			fmt.Fprintln(out, formatEvent(ev))
+		}
+	}
+}
+
+// formatEvent returns a one-line, human-readable description of the given event.
+func formatEvent(ev *connector.Event) string {
+	sb := strings.Builder{}
+	if ev.Time != nil {
+		sb.WriteString(ev.Time.AsTime().Local().Format(time.TimeOnly))
+		sb.WriteByte(' ')
+	}
+	subject := func() string {
+		if ev.InterceptName != "" {
+			return "intercept " + ev.InterceptName
+		}
+		return "intercept " + ev.InterceptId
+	}
+	switch ev.Type {
+	case connector.Event_SESSION_CONNECTED:
+		fmt.Fprintf(&sb, "connected to context %s, namespace %s", ev.Context, ev.Namespace)
+	case connector.Event_SESSION_DISCONNECTED:
+		sb.WriteString("disconnected")
+	case connector.Event_INTERCEPT_DISPOSITION:
+		fmt.Fprintf(&sb, "%s: %s", subject(), ev.Disposition)
+		if ev.Message != "" {
+			fmt.Fprintf(&sb, ": %s", ev.Message)
+		}
+	case connector.Event_INTERCEPT_REMOVED:
+		fmt.Fprintf(&sb, "%s: removed", subject())
+	case connector.Event_MOUNT_READY:
+		fmt.Fprintf(&sb, "%s: remote volumes mounted at %s", subject(), ev.MountPoint)
+	case connector.Event_MOUNT_FAILED:
+		fmt.Fprintf(&sb, "%s: mount failed: %s", subject(), ev.Message)
+	case connector.Event_HANDLER_STARTED:
+		if ev.ContainerName != "" {
+			fmt.Fprintf(&sb, "%s: handler started in container %s", subject(), ev.ContainerName)
+		} else {
+			fmt.Fprintf(&sb, "%s: handler started with pid %d", subject(), ev.Pid)
+		}
+		if ev.Restarts > 0 {
+			fmt.Fprintf(&sb, " (restart %d)", ev.Restarts)
+		}
+	case connector.Event_HANDLER_EXITED:
+		fmt.Fprintf(&sb, "%s: handler exited with code %d", subject(), ev.ExitCode)
+	case connector.Event_AGENT_EVENT:
+		ae := ev.AgentEvent
+		fmt.Fprintf(&sb, "%s %s/%s.%s: %s", ae.GetReason(), strings.ToLower(ae.GetObjectKind()), ae.GetObjectName(), ev.Namespace, ae.GetNote())
+		if ae.GetSeverity() == manager.AgentEvent_FATAL {
+			sb.WriteString(" (fatal)")
+		}
+	case connector.Event_AGENT_PROGRESS:
+		ap := ev.AgentProgress
+		fmt.Fprintf(&sb, "%s.%s: %s", ap.GetWorkloadName(), ev.Namespace, intercept.DescribeAgentProgress(ap))
+	default:
+		sb.WriteString(ev.Type.String())
+		if ev.Message != "" {
+			fmt.Fprintf(&sb, ": %s", ev.Message)
+		}
+	}
+	return sb.String()
+}
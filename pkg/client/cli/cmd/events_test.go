@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_formatEvent(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		ev   *connector.Event
+		want string
+	}{
+		{
+			name: "time",
+			ev:   &connector.Event{Type: connector.Event_SESSION_DISCONNECTED, Time: timestamppb.New(now)},
+			want: now.Format(time.TimeOnly) + " disconnected",
+		},
+		{
+			name: "disposition",
+			ev: &connector.Event{
+				Type:          connector.Event_INTERCEPT_DISPOSITION,
+				InterceptName: "echo",
+				Disposition:   "AGENT_ERROR",
+				Message:       "traffic-agent did not arrive",
+			},
+			want: "intercept echo: AGENT_ERROR: traffic-agent did not arrive",
+		},
+		{
+			name: "handler",
+			ev:   &connector.Event{Type: connector.Event_HANDLER_STARTED, InterceptId: "id-1", Pid: 42, Restarts: 2},
+			want: "intercept id-1: handler started with pid 42 (restart 2)",
+		},
+		{
+			name: "agent event",
+			ev: &connector.Event{
+				Type:      connector.Event_AGENT_EVENT,
+				Namespace: "default",
+				AgentEvent: &manager.AgentEvent{
+					Severity:   manager.AgentEvent_FATAL,
+					Reason:     "BackOff",
+					Note:       "Back-off restarting failed container traffic-agent",
+					ObjectKind: "Pod",
+					ObjectName: "echo-7b8f8c9c4-xk2vq",
+				},
+			},
+			want: "BackOff pod/echo-7b8f8c9c4-xk2vq.default: Back-off restarting failed container traffic-agent (fatal)",
+		},
+		{
+			name: "agent progress",
+			ev: &connector.Event{
+				Type:      connector.Event_AGENT_PROGRESS,
+				Namespace: "default",
+				AgentProgress: &manager.AgentArrivalProgress{
+					Stage:        manager.AgentArrivalProgress_POD_SCHEDULED,
+					WorkloadName: "echo",
+					Namespace:    "default",
+					PodName:      "echo-7b8f8c9c4-xk2vq",
+				},
+			},
+			want: "echo.default: pod echo-7b8f8c9c4-xk2vq scheduled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatEvent(tt.ev))
+		})
+	}
+}
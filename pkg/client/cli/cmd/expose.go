@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/expose"
+)
+
+func exposeCmd() *cobra.Command {
+	ec := &expose.Command{}
+	cmd := &cobra.Command{
+		Use:  "expose [flags] <intercept_name>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Expose the handler of an intercept through a local reverse proxy",
+		Long: `Expose the handler of an intercept through a local reverse proxy.
+
+The proxy terminates TLS and adds the headers that the intercept matches on to each request before it's
+forwarded to the intercept handler. This makes it possible to point a browser at a realistic HTTPS origin
+during development. The proxy runs until it's interrupted.
+
+Use "telepresence publish" to publish a service that only exists on this workstation to the cluster.`,
+		Example: `  # Expose the handler of my-intercept at https://localhost:8443 using a self-signed certificate
+  telepresence expose my-intercept
+
+  # Use port 443 and add a header that the intercept matches using a regular expression
+  telepresence expose my-intercept --listen 443 --header x-user=jane`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE:              ec.Run,
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	ec.AddFlags(cmd)
+	return cmd
+}
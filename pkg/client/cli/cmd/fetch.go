@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/tarstream"
+)
+
+func fetch() *cobra.Command {
+	var dest string
+	cmd := &cobra.Command{
+		Use:  "fetch [flags] <intercept_name> <path>...",
+		Args: cobra.MinimumNArgs(2),
+
+		Short: "Fetch files from the remote volumes of an intercept",
+		Long: `Fetch files from the remote volumes of an intercept.
+
+The files and directories are copied from the intercepted container into a local directory, where they are
+stored read-only at the same relative path as in the container. This is useful when the remote volumes cannot
+be mounted, e.g. when no FUSE implementation is available.`,
+		Example: `  # Fetch the service account token of the intercepted container into ./secrets
+  telepresence fetch my-intercept /var/run/secrets/kubernetes.io/serviceaccount --dest ./secrets`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return runFetch(cmd, strings.TrimSpace(args[0]), args[1:], dest)
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	cmd.Flags().StringVar(&dest, "dest", ".", "The local directory to store the fetched files in")
+	return cmd
+}
+
+// fileChunkReader is an io.Reader that reads the FileChunks of a FetchFiles stream.
+type fileChunkReader struct {
+	stream connector.Connector_FetchFilesClient
+	data   []byte
+	err    error
+}
+
+func (r *fileChunkReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		var chunk *agent.FileChunk
+		chunk, r.err = r.stream.Recv()
+		if chunk != nil {
+			r.data = chunk.Data
+		}
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func runFetch(cmd *cobra.Command, name string, paths []string, dest string) error {
+	ctx := cmd.Context()
+	stream, err := daemon.GetUserClient(ctx).FetchFiles(ctx, &connector.FetchFilesRequest{Name: name, Paths: paths})
+	if err != nil {
+		return err
+	}
+	r := &fileChunkReader{stream: stream}
+	count, err := tarstream.Extract(r, dest)
+	if err != nil {
+		if r.err != nil && !errors.Is(r.err, io.EOF) {
+			// Prefer the error from the stream over the error from the archive reader.
+			err = r.err
+		}
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound, codes.FailedPrecondition, codes.InvalidArgument:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Fetched %d files into %s\n", count, dest)
+	return nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/flags"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 )
 
@@ -145,7 +146,7 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	}
 
 	scheme := runtime.NewScheme()
-	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{}, &apps.DaemonSet{})
 	codecFactory := serializer.NewCodecFactory(scheme)
 	deserializer := codecFactory.UniversalDeserializer()
 
@@ -153,9 +154,9 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	if err != nil {
 		return nil, errcat.User.Newf("unable to parse yaml in %s: %w", i.inputFile, err)
 	}
-	wl, err := k8sapi.WrapWorkload(obj)
+	wl, err := k8sworkload.WrapWorkload(obj)
 	if err != nil {
-		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, or StatefulSet", kind)
+		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, StatefulSet, or DaemonSet", kind)
 	}
 	if wl.GetNamespace() == "" {
 		if d, ok := k8sapi.DeploymentImpl(wl); ok {
@@ -164,6 +165,8 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 			r.Namespace = i.namespace
 		} else if s, ok := k8sapi.StatefulSetImpl(wl); ok {
 			s.Namespace = i.namespace
+		} else if ds, ok := k8sworkload.DaemonSetImpl(wl); ok {
+			ds.Namespace = i.namespace
 		}
 	}
 	return wl, nil
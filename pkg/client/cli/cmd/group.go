@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+type groupCommand struct {
+	file string
+}
+
+func groupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group",
+		Short: "Start or stop a named group of intercepts",
+		Long: `Start or stop a named group of intercepts.
+
+Intercept groups are defined in a file, by default the groups.yml file in the telepresence config directory. Each
+intercept in a group is described using the same fields as the flags of the intercept command, e.g.
+
+groups:
+  - name: backend
+    intercepts:
+      - name: orders
+        port: "8080"
+      - name: payments
+        workload: payments-v2
+        port: 9090:http
+        mount: "false"`,
+	}
+	cmd.AddCommand(groupStart(), groupStop())
+	return cmd
+}
+
+func (gc *groupCommand) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&gc.file, "file", "f", "", "File that defines the intercept groups. Defaults to groups.yml in the telepresence config directory")
+}
+
+func (gc *groupCommand) load(cmd *cobra.Command, name string) (*intercept.Group, error) {
+	if err := connect.InitCommand(cmd); err != nil {
+		return nil, err
+	}
+	file := gc.file
+	if file == "" {
+		file = intercept.DefaultGroupsFile(cmd.Context())
+	}
+	return intercept.LoadGroup(file, name)
+}
+
+func groupStart() *cobra.Command {
+	gc := &groupCommand{}
+	cmd := &cobra.Command{
+		Use:   "start [flags] <group_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Create all intercepts of a group, or none of them",
+		Long: `Create all intercepts of a group, or none of them.
+
+The intercepts are created in the order that they are listed. If one of them can't be created, the intercepts
+that were already created are removed again.
+
+When intercepts in the group have handlers, the command keeps running until one of the handlers exits, or it's
+interrupted, and then removes all intercepts of the group.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := gc.load(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			ctx := dos.WithStdio(cmd.Context(), cmd)
+			if output.WantsFormatted(cmd) {
+				ctx = dos.WithStdout(ctx, cmd.ErrOrStderr())
+			}
+			sg, err := intercept.StartGroup(ctx, g)
+			if err != nil {
+				return err
+			}
+			if output.WantsFormatted(cmd) {
+				output.Object(ctx, sg.Infos(), false)
+			}
+			if g.HasHandlers() {
+				return sg.RunHandlers(ctx)
+			}
+			return nil
+		},
+	}
+	gc.addFlags(cmd)
+	return cmd
+}
+
+func groupStop() *cobra.Command {
+	gc := &groupCommand{}
+	cmd := &cobra.Command{
+		Use:   "stop [flags] <group_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Remove all intercepts of a group",
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := gc.load(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			return intercept.StopGroup(dos.WithStdio(cmd.Context(), cmd), g, removeGroupMember)
+		},
+	}
+	gc.addFlags(cmd)
+	return cmd
+}
+
+// removeGroupMember removes the intercept with the given name, and returns false if no such intercept exists.
+func removeGroupMember(ctx context.Context, name string) (bool, error) {
+	_, err := daemon.GetUserClient(ctx).GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, removeIntercept(ctx, name)
+}
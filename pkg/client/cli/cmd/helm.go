@@ -15,7 +15,7 @@ func helmCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use: "helm",
 	}
-	cmd.AddCommand(helmInstall(), helmUpgrade(), helmUninstall())
+	cmd.AddCommand(helmInstall(), helmUpgrade(), helmUninstall(), helmTemplate())
 	return cmd
 }
 
@@ -56,6 +56,8 @@ func helmInstall() *cobra.Command {
 	flags.BoolVarP(&upgrade, "upgrade", "u", false, "replace the traffic manager if it already exists")
 	flags.BoolVar(&ha.CreateNamespace, "create-namespace", true, "create a namespace for the traffic-manager if not present")
 	ha.addValueSettingFlags(flags)
+	ha.addChartFlags(flags)
+	ha.addDiffFlag(flags)
 	ha.addCRDsFlags(flags)
 	uf := flags.Lookup("upgrade")
 	uf.Hidden = true
@@ -79,6 +81,8 @@ func helmUpgrade() *cobra.Command {
 
 	flags := cmd.Flags()
 	ha.addValueSettingFlags(flags)
+	ha.addChartFlags(flags)
+	ha.addDiffFlag(flags)
 	ha.addCRDsFlags(flags)
 	flags.BoolVarP(&ha.NoHooks, "no-hooks", "", false, "disable pre/post upgrade hooks")
 	flags.BoolVarP(&ha.ResetValues, "reset-values", "", false, "when upgrading, reset the values to the ones built into the chart")
@@ -105,6 +109,18 @@ func (ha *HelmCommand) addValueSettingFlags(flags *pflag.FlagSet) {
 	}
 }
 
+func (ha *HelmCommand) addChartFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&ha.Chart, "chart", "",
+		"use a chart directory or archive, or an oci:// chart reference, instead of the built-in chart")
+	flags.StringVar(&ha.ChartVersion, "chart-version", "",
+		"version of the oci:// chart. The most recent version is used when empty")
+}
+
+func (ha *HelmCommand) addDiffFlag(flags *pflag.FlagSet) {
+	flags.BoolVar(&ha.Diff, "diff", false,
+		"render the chart and show how its manifests differ from the installed release, without applying them")
+}
+
 func (ha *HelmCommand) addCRDsFlags(flags *pflag.FlagSet) {
 	if HelmExtendFlagsFunc != nil {
 		HelmExtendFlagsFunc(flags)
@@ -130,6 +146,37 @@ func helmUninstall() *cobra.Command {
 	return cmd
 }
 
+func helmTemplate() *cobra.Command {
+	ha := &HelmCommand{
+		Request: helm.Request{
+			Type: helm.Template,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:   "template",
+		Args:  cobra.NoArgs,
+		Short: "Render the telepresence traffic manager chart",
+		Long: `Render the manifests of the telepresence traffic manager chart locally, without contacting the cluster.
+
+The manifests are printed unless --bundle is used. A bundle is a directory with the manifests in manifests.yaml,
+and the images that they require, pinned to the digests of the images, in images.txt. The images include the
+traffic-agent image and the client image. Platform teams use a bundle to mirror the images and to apply the
+manifests using their own pipelines, e.g. in air-gapped environments.`,
+		Example: `  # Create a bundle for a traffic manager that uses a private registry
+  telepresence helm template --set image.registry=registry.example.com/telepresence --bundle ./bundle`,
+		RunE: ha.run,
+	}
+	flags := cmd.Flags()
+	ha.addValueSettingFlags(flags)
+	ha.addChartFlags(flags)
+	ha.addCRDsFlags(flags)
+	flags.StringVar(&ha.Bundle, "bundle", "",
+		"write the manifests, and the digest pinned images that they require, to this directory")
+	flags.BoolVarP(&ha.NoHooks, "no-hooks", "", false, "exclude the manifests of hooks")
+	ha.rq = daemon.InitRequest(cmd)
+	return cmd
+}
+
 func (ha *HelmCommand) Type() helm.RequestType {
 	return ha.Request.Type
 }
@@ -141,6 +188,9 @@ func (ha *HelmCommand) run(cmd *cobra.Command, _ []string) (err error) {
 	ctx := cmd.Context()
 	ctx = scout.NewReporter(ctx, "cli")
 	defer func() {
+		if ha.Type() == helm.Template {
+			return
+		}
 		if err == nil {
 			if ha.Type() == helm.Uninstall {
 				scout.Report(ctx, "helm_uninstall_success")
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
 )
 
@@ -16,6 +19,7 @@ func interceptCmd() *cobra.Command {
 		Annotations: map[string]string{
 			ann.Session:           ann.Required,
 			ann.UpdateCheckFormat: ann.Tel2,
+			ann.LifecycleEvents:   ann.Supported,
 		},
 		SilenceUsage:      true,
 		SilenceErrors:     true,
@@ -23,5 +27,90 @@ func interceptCmd() *cobra.Command {
 		ValidArgsFunction: ic.ValidArgs,
 	}
 	ic.AddFlags(cmd)
+	cmd.AddCommand(interceptRefreshEnvCmd(), interceptShareCmd(), interceptJoinCmd())
+	return cmd
+}
+
+func interceptRefreshEnvCmd() *cobra.Command {
+	ic := &intercept.Command{}
+	cmd := &cobra.Command{
+		Use:   "refresh-env [flags] <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Refresh the environment of an intercept after it has changed in the cluster",
+		Long: `Refresh the environment of an intercept after it has changed in the cluster.
+
+The environment drift reported for the intercept is cleared, and the current environment is written
+to the files given by --env-file and --env-json.`,
+		Annotations: map[string]string{
+			ann.Session:         ann.Required,
+			ann.LifecycleEvents: ann.Supported,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ic.Name = strings.TrimSpace(args[0])
+			return intercept.RefreshEnvironment(cmd.Context(), ic)
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	flagSet := cmd.Flags()
+	flagSet.StringVarP(&ic.EnvFile, "env-file", "e", "", ``+
+		`Write the current environment to a file. The syntax used in the file can be determined using flag --env-syntax`)
+	flagSet.Var(&ic.EnvSyntax, "env-syntax", `Syntax used for env-file. One of `+intercept.EnvSyntaxUsage())
+	flagSet.StringVarP(&ic.EnvJSON, "env-json", "j", "", `Write the current environment to a file as a JSON blob.`)
+	ic.AddEnvRuleFlags(flagSet)
+	return cmd
+}
+
+func interceptShareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "share <intercept_name>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Print a token that a teammate can use to join an intercept",
+		Long: `Print a token that a teammate can use to join an intercept.
+
+A teammate that is connected to the same cluster joins the intercept using "telepresence intercept join <token>".
+The intercept keeps its header filter, preview URL, and metadata, and its connections are then distributed between
+the workstations of everyone that joined it, so that a pair can debug the same traffic. The token is valid until
+the intercept ends.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return intercept.Share(cmd, strings.TrimSpace(args[0]))
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+}
+
+func interceptJoinCmd() *cobra.Command {
+	var leave bool
+	cmd := &cobra.Command{
+		Use:   "join [flags] <token>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Join an intercept that a teammate has shared",
+		Long: `Join an intercept that a teammate has shared using "telepresence intercept share".
+
+Some of the intercept's connections are routed to this workstation. They are sent to the same local address as
+on the workstation of the teammate, so the handler must listen to the same port. The intercept is left when
+--leave is used, or when the session ends.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return intercept.Join(cmd, strings.TrimSpace(args[0]), leave)
+		},
+	}
+	cmd.Flags().BoolVar(&leave, "leave", false, "Leave the intercept that the token was issued for")
 	return cmd
 }
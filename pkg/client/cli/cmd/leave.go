@@ -16,8 +16,10 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
 
 func leave() *cobra.Command {
@@ -27,7 +29,8 @@ func leave() *cobra.Command {
 
 		Short: "Remove existing intercept",
 		Annotations: map[string]string{
-			ann.Session: ann.Required,
+			ann.Session:         ann.Required,
+			ann.LifecycleEvents: ann.Supported,
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := connect.InitCommand(cmd); err != nil {
@@ -35,36 +38,39 @@ func leave() *cobra.Command {
 			}
 			return removeIntercept(cmd.Context(), strings.TrimSpace(args[0]))
 		},
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			shellCompDir := cobra.ShellCompDirectiveNoFileComp
-			if len(args) != 0 {
-				return nil, shellCompDir
-			}
-			if err := connect.InitCommand(cmd); err != nil {
-				return nil, shellCompDir | cobra.ShellCompDirectiveError
-			}
-			ctx := cmd.Context()
-			userD := daemon.GetUserClient(ctx)
-			resp, err := userD.List(ctx, &connector.ListRequest{Filter: connector.ListRequest_INTERCEPTS})
-			if err != nil {
-				return nil, shellCompDir | cobra.ShellCompDirectiveError
-			}
-			if len(resp.Workloads) == 0 {
-				return nil, shellCompDir
-			}
+		ValidArgsFunction: interceptNameCompletion,
+	}
+}
+
+// interceptNameCompletion completes the name of an intercept when it is the first argument.
+func interceptNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	shellCompDir := cobra.ShellCompDirectiveNoFileComp
+	if len(args) != 0 {
+		return nil, shellCompDir
+	}
+	if err := connect.InitCommand(cmd); err != nil {
+		return nil, shellCompDir | cobra.ShellCompDirectiveError
+	}
+	ctx := cmd.Context()
+	userD := daemon.GetUserClient(ctx)
+	resp, err := userD.List(ctx, &connector.ListRequest{Filter: connector.ListRequest_INTERCEPTS})
+	if err != nil {
+		return nil, shellCompDir | cobra.ShellCompDirectiveError
+	}
+	if len(resp.Workloads) == 0 {
+		return nil, shellCompDir
+	}
 
-			var completions []string
-			for _, intercept := range resp.Workloads {
-				for _, ii := range intercept.InterceptInfos {
-					name := ii.Spec.Name
-					if strings.HasPrefix(name, toComplete) {
-						completions = append(completions, name)
-					}
-				}
+	var completions []string
+	for _, intercept := range resp.Workloads {
+		for _, ii := range intercept.InterceptInfos {
+			name := ii.Spec.Name
+			if strings.HasPrefix(name, toComplete) {
+				completions = append(completions, name)
 			}
-			return completions, shellCompDir
-		},
+		}
 	}
+	return completions, shellCompDir
 }
 
 func removeIntercept(ctx context.Context, name string) error {
@@ -86,11 +92,18 @@ func removeIntercept(ctx context.Context, name string) error {
 			dlog.Error(ctx, err)
 		}
 	}
-	if err := intercept.Result(userD.RemoveIntercept(ctx, &manager.RemoveInterceptRequest2{Name: name})); err != nil {
+	r, err := userD.RemoveIntercept(ctx, &manager.RemoveInterceptRequest2{Name: name})
+	if r != nil && r.HandlerStopError != "" {
+		ioutil.Printf(output.Err(ctx), "intercept handler of %s failed to stop: %s\n", name, r.HandlerStopError)
+	}
+	if err = intercept.Result(r, err); err != nil {
 		if stopContainer && strings.Contains(err.Error(), fmt.Sprintf("%q not found", name)) {
 			// race condition between stopping the intercept handler, which causes the intercept to leave, and this call
 			err = nil
 		}
 	}
+	if err == nil {
+		intercept.EmitRemoved(ctx, ic.Id, name, r.GetHandlerStopError())
+	}
 	return err
 }
@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/go-json-experiment/json"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
@@ -25,9 +29,11 @@ type listCommand struct {
 	onlyIntercepts    bool
 	onlyAgents        bool
 	onlyInterceptable bool
+	others            bool
 	debug             bool
 	namespace         string
 	watch             bool
+	minInterval       time.Duration
 }
 
 type workloadJSONOutput struct {
@@ -51,8 +57,11 @@ func list() *cobra.Command {
 	flags.BoolVarP(&s.onlyIntercepts, "intercepts", "i", false, "intercepts only")
 	flags.BoolVarP(&s.onlyAgents, "agents", "a", false, "with installed agents only")
 	flags.BoolVarP(&s.onlyInterceptable, "only-interceptable", "o", true, "interceptable workloads only")
+	flags.BoolVar(&s.others, "others", false, "include the intercepts of other clients, and who owns them")
 	flags.BoolVar(&s.debug, "debug", false, "include debugging information")
 	flags.StringVarP(&s.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
+	flags.DurationVar(&s.minInterval, "min-interval", 0,
+		"minimum interval between two snapshots when streaming. Changes within the interval are coalesced into one snapshot")
 
 	flags.BoolVarP(&s.watch, "watch", "w", false, "watch a namespace. --agents and --intercepts are disabled if this flag is set")
 	wf := flags.Lookup("watch")
@@ -116,7 +125,7 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 
 	formattedOutput := output.WantsFormatted(cmd)
 	if !output.WantsStream(cmd) {
-		r, err := userD.List(ctx, &connector.ListRequest{Filter: filter, Namespace: s.namespace}, grpc.MaxCallRecvMsgSize(int(maxRecSize)))
+		r, err := userD.List(ctx, &connector.ListRequest{Filter: filter, Namespace: s.namespace, IncludeOthers: s.others}, grpc.MaxCallRecvMsgSize(int(maxRecSize)))
 		if err != nil {
 			return err
 		}
@@ -124,7 +133,11 @@ func (s *listCommand) list(cmd *cobra.Command, _ []string) error {
 		return nil
 	}
 
-	stream, streamErr := userD.WatchWorkloads(ctx, &connector.WatchWorkloadsRequest{Namespaces: []string{s.namespace}}, grpc.MaxCallRecvMsgSize(int(maxRecSize)))
+	stream, streamErr := userD.WatchWorkloads(ctx, &connector.WatchWorkloadsRequest{
+		Namespaces:    []string{s.namespace},
+		MinInterval:   durationpb.New(s.minInterval),
+		IncludeOthers: s.others,
+	}, grpc.MaxCallRecvMsgSize(int(maxRecSize)))
 	if streamErr != nil {
 		return streamErr
 	}
@@ -165,14 +178,21 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 		if formattedOut {
 			output.Object(ctx, []struct{}{}, false)
 		} else {
-			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, ReplicaSets, or Rollouts)")
+			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, ReplicaSets, DaemonSets, or Rollouts)")
 		}
 		return
 	}
 
 	state := func(workload *connector.WorkloadInfo) string {
 		if iis := workload.InterceptInfos; len(iis) > 0 {
-			return intercept.DescribeIntercepts(ctx, iis, "", s.debug)
+			desc := intercept.DescribeIntercepts(ctx, iis, "", s.debug)
+			if ois := workload.OtherIntercepts; len(ois) > 0 {
+				desc += "\nalso " + describeOtherIntercepts(ois)
+			}
+			return desc
+		}
+		if ois := workload.OtherIntercepts; len(ois) > 0 {
+			return describeOtherIntercepts(ois)
 		}
 		ai := workload.Sidecar
 		if workload.NotInterceptableReason == "Progressing" {
@@ -251,3 +271,20 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 		}
 	}
 }
+
+// describeOtherIntercepts describes intercepts that are owned by other clients, so that it's clear who is using a
+// workload, and what traffic they intercept.
+func describeOtherIntercepts(ois []*manager.WorkloadInfo_Intercept) string {
+	sb := strings.Builder{}
+	sb.WriteString("intercepted by other clients")
+	for _, oi := range ois {
+		fmt.Fprintf(&sb, "\n    %s by %s", oi.Name, oi.Client)
+		if oi.MechanismArgsDesc != "" {
+			fmt.Fprintf(&sb, ": %s", oi.MechanismArgsDesc)
+		}
+		if len(oi.MechanismArgs) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(oi.MechanismArgs, " "))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type mountCommand struct {
+	container string
+	readWrite bool
+	fuse      client.FuseOptions
+}
+
+func mountCmd() *cobra.Command {
+	mc := &mountCommand{}
+	cmd := &cobra.Command{
+		Use:  "mount [flags] <workload> [<mount point>]",
+		Args: cobra.RangeArgs(1, 2),
+
+		Short: "Mount the volumes of a workload without intercepting it",
+		Long: `Mount the volumes of a workload without intercepting it.
+
+The volumes of a container in one of the workload's pods are mounted in a local directory, or on a drive letter
+on Windows, using the FTP or SFTP server of the traffic-agent. This is useful for quick inspection of config maps,
+secrets, and data volumes. The volumes are mounted read-only unless --read-write is used. A temporary directory
+is used when no mount point is given. A traffic-agent is injected into the workload if it doesn't have one.
+
+The volumes remain mounted until the command is interrupted.`,
+		Example: `  # Mount the volumes of the echo workload in a temporary directory
+  telepresence mount echo
+
+  # Mount the volumes of the sidecar container of the echo workload in ./echo-volumes with write access
+  telepresence mount echo ./echo-volumes --container sidecar --read-write`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			mountPoint := ""
+			if len(args) > 1 {
+				mountPoint = args[1]
+			}
+			return mc.run(cmd, strings.TrimSpace(args[0]), mountPoint)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&mc.container, "container", "", ``+
+		`Name of the container whose volumes are mounted. Defaults to the first container that the traffic-agent is configured for`)
+	flags.BoolVar(&mc.readWrite, "read-write", false, "Mount the volumes with write access")
+	intercept.AddFuseFlags(flags, &mc.fuse)
+	return cmd
+}
+
+func (mc *mountCommand) run(cmd *cobra.Command, workload, mountPoint string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	tempDir := mountPoint == ""
+	if mountPoint, err = intercept.PrepareMount(cwd, mountPoint); err != nil {
+		return errcat.User.New(err)
+	}
+	if tempDir && mountPoint != remotefs.AutoDriveLetter {
+		dir := mountPoint
+		defer func() {
+			_ = os.Remove(dir)
+		}()
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	stream, err := daemon.GetUserClient(ctx).Mount(ctx, &connector.MountRequest{
+		Workload:     workload,
+		Container:    mc.container,
+		MountPoint:   mountPoint,
+		ReadWrite:    mc.readWrite,
+		MountOptions: mc.fuse.MountOptions(),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		mi, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+				break
+			}
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.NotFound, codes.FailedPrecondition:
+					return errcat.User.New(st.Message())
+				}
+			}
+			return err
+		}
+		// On Windows, the drive letter may be picked by the user daemon.
+		mountPoint = mi.MountPoint
+		access := "read-only"
+		if mi.ReadWrite {
+			access = "read-write"
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Mounted the volumes of container %s in pod %s %s at %s. Press Ctrl-C to unmount\n",
+			mi.Container, mi.PodName, access, mi.MountPoint)
+		for _, m := range mi.Mounts {
+			fmt.Fprintf(out, "    %s\n", filepath.Join(mi.MountPoint, m))
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Unmounted %s\n", mountPoint)
+	return nil
+}
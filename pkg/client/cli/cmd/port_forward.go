@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type portForwardCommand struct {
+	namespace string
+	stop      bool
+}
+
+func portForwardCmd() *cobra.Command {
+	pc := &portForwardCommand{}
+	cmd := &cobra.Command{
+		Use:  "port-forward [flags] [<target> [<local port>:]<remote port>...]",
+		Args: pc.args,
+
+		Short: "Forward local ports to a service or pod in the cluster",
+		Long: `Forward local ports to a service or pod in the cluster.
+
+The target is a service, given as <name> or svc/<name>, or a pod, given as pod/<name>. Connections to the local
+ports on localhost are forwarded through the traffic-manager's tunnel, so kubectl isn't needed, and the target is
+reachable even when its subnet is excluded using never-proxy. A remote port that is given without a local port is
+forwarded from the same local port.
+
+The forwards are managed by the session. They remain until they are stopped using --stop, or until the session
+ends. The forwards of the session are listed when no target is given.`,
+		Example: `  # Forward localhost:8080 to port 80 of the echo service
+  telepresence port-forward svc/echo 8080:80
+
+  # Forward localhost:5432 to port 5432 of a pod in the staging namespace
+  telepresence port-forward pod/postgres-0 5432 --namespace staging
+
+  # List the forwards of the session
+  telepresence port-forward
+
+  # Stop all forwards to the echo service
+  telepresence port-forward svc/echo --stop`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         pc.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&pc.namespace, "namespace", "n", "", "The namespace of the target. Defaults to the namespace of the session")
+	flags.BoolVar(&pc.stop, "stop", false, "Stop the forwards to the target, or only those from the given local ports")
+	return cmd
+}
+
+func (pc *portForwardCommand) args(_ *cobra.Command, args []string) error {
+	switch {
+	case pc.stop && len(args) == 0:
+		return errcat.User.New("--stop requires a target")
+	case !pc.stop && len(args) == 1:
+		return errcat.User.Newf("no ports to forward to %s", args[0])
+	}
+	return nil
+}
+
+func (pc *portForwardCommand) run(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	uc := daemon.GetUserClient(ctx)
+	var (
+		list *connector.PortForwardList
+		err  error
+	)
+	if len(args) == 0 {
+		list, err = uc.ListPortForwards(ctx, &empty.Empty{})
+	} else {
+		rq := &connector.PortForwardRequest{Target: args[0], Namespace: pc.namespace}
+		if rq.Ports, err = parsePortForwards(args[1:]); err != nil {
+			return err
+		}
+		if pc.stop {
+			list, err = uc.RemovePortForwards(ctx, rq)
+		} else {
+			list, err = uc.AddPortForwards(ctx, rq)
+		}
+	}
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument, codes.FailedPrecondition, codes.AlreadyExists:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, list, false)
+		return nil
+	}
+	out := output.Out(ctx)
+	if len(list.Forwards) == 0 {
+		if len(args) == 0 {
+			fmt.Fprintln(out, "No port forwards")
+		} else {
+			fmt.Fprintf(out, "No forwards to %s were found\n", args[0])
+		}
+		return nil
+	}
+	verb := "Forwarding"
+	if pc.stop {
+		verb = "Stopped forwarding"
+	}
+	for _, pf := range list.Forwards {
+		fmt.Fprintf(out, "%s localhost:%d to %s.%s:%d (%s)\n", verb, pf.LocalPort, pf.Target, pf.Namespace, pf.RemotePort, pf.Ip)
+	}
+	return nil
+}
+
+// parsePortForwards parses port forwards given as [<local port>:]<remote port>.
+func parsePortForwards(args []string) ([]*connector.PortForward, error) {
+	pfs := make([]*connector.PortForward, len(args))
+	for i, arg := range args {
+		local, remote, ok := strings.Cut(arg, ":")
+		if !ok {
+			local, remote = "", arg
+		}
+		pf := &connector.PortForward{}
+		rp, err := strconv.ParseUint(remote, 10, 16)
+		if err == nil && rp == 0 {
+			err = strconv.ErrRange
+		}
+		if err == nil && local != "" {
+			var lp uint64
+			if lp, err = strconv.ParseUint(local, 10, 16); err == nil {
+				pf.LocalPort = int32(lp)
+			}
+		}
+		if err != nil {
+			return nil, errcat.User.Newf("invalid port forward %q. Use [<local port>:]<remote port>", arg)
+		}
+		pf.RemotePort = int32(rp)
+		pfs[i] = pf
+	}
+	return pfs, nil
+}
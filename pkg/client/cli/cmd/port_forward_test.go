@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parsePortForwards(t *testing.T) {
+	pfs, err := parsePortForwards([]string{"8080:80", "5432"})
+	require.NoError(t, err)
+	require.Len(t, pfs, 2)
+	assert.Equal(t, int32(8080), pfs[0].LocalPort)
+	assert.Equal(t, int32(80), pfs[0].RemotePort)
+	assert.Equal(t, int32(0), pfs[1].LocalPort)
+	assert.Equal(t, int32(5432), pfs[1].RemotePort)
+
+	for _, arg := range []string{"http", "8080:", "0", "70000", "x:80"} {
+		_, err = parsePortForwards([]string{arg})
+		assert.Error(t, err, arg)
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type publishCommand struct {
+	namespace   string // --namespace
+	registerDNS bool   // --register-dns
+}
+
+func (pc *publishCommand) addFlags(cmd *cobra.Command) {
+	flagSet := cmd.Flags()
+	flagSet.StringVarP(&pc.namespace, "namespace", "n", "", ``+
+		`The namespace of the service. Defaults to the namespace of the session`)
+	flagSet.BoolVar(&pc.registerDNS, "register-dns", false, ``+
+		`Use a service that is named after the user, in the namespace of the traffic-manager, instead of a named service`)
+	cmd.MarkFlagsMutuallyExclusive("namespace", "register-dns")
+}
+
+func publishCmd() *cobra.Command {
+	pc := &publishCommand{}
+	cmd := &cobra.Command{
+		Use: "publish [flags] (<name> | --register-dns) [<service port>:]<local port>...",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if pc.registerDNS {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+
+		Short: "Publish a service on this workstation to the cluster",
+		Long: `Publish a service on this workstation to the cluster.
+
+A service that only exists on this workstation is published to the cluster, without intercepting anything. The
+traffic-manager creates a Service with the given name, and forwards its connections through the session's tunnel
+to the given local ports, so that pods and teammates can call it. The Service is deleted by "telepresence unpublish",
+or when the session ends. Publishing must be enabled using the Helm chart value publishedServices.enabled, and the
+user must be allowed to create Services in the namespace.
+
+With --register-dns, the Service is named after the user of this workstation and created in the namespace of the
+traffic-manager, so that teammates can reach it as <user>.<namespace>.svc during pairing sessions.`,
+		Example: `  # Publish the service on localhost:8080 to the cluster as the service payments-dev with port 80
+  telepresence publish payments-dev 80:8080
+
+  # Make localhost:3000 reachable by teammates as <user>.ambassador.svc:3000
+  telepresence publish --register-dns 3000`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         pc.publish,
+	}
+	pc.addFlags(cmd)
+	return cmd
+}
+
+func unpublishCmd() *cobra.Command {
+	pc := &publishCommand{}
+	cmd := &cobra.Command{
+		Use: "unpublish [flags] (<name> | --register-dns)",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if pc.registerDNS {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Short: "Stop publishing a service on this workstation to the cluster",
+		Example: `  # Stop publishing the service payments-dev
+  telepresence unpublish payments-dev
+
+  # Remove the service that is named after the user
+  telepresence unpublish --register-dns`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE:         pc.unpublish,
+	}
+	pc.addFlags(cmd)
+	return cmd
+}
+
+func (pc *publishCommand) request(args []string) *manager.PublishServiceRequest {
+	rq := &manager.PublishServiceRequest{Namespace: pc.namespace, UserDns: pc.registerDNS}
+	if !pc.registerDNS {
+		rq.Name = args[0]
+	}
+	return rq
+}
+
+func (pc *publishCommand) publish(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	rq := pc.request(args)
+	if !pc.registerDNS {
+		args = args[1:]
+	}
+	var err error
+	if rq.Ports, err = parsePublishedPorts(args); err != nil {
+		return err
+	}
+	info, err := daemon.GetUserClient(ctx).PublishService(ctx, rq)
+	if err != nil {
+		return publishError(err)
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, info, false)
+		return nil
+	}
+	out := output.Out(ctx)
+	if pc.registerDNS {
+		fmt.Fprintf(out, "Registered %s.%s.svc at %s\n", info.Name, info.Namespace, info.ClusterIp)
+	} else {
+		fmt.Fprintf(out, "Service %s.%s is published at %s\n", info.Name, info.Namespace, info.ClusterIp)
+	}
+	for _, p := range info.Ports {
+		fmt.Fprintf(out, "    Port %d is forwarded to localhost:%d\n", p.Port, p.TargetPort)
+	}
+	return nil
+}
+
+func (pc *publishCommand) unpublish(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if _, err := daemon.GetUserClient(ctx).UnpublishService(ctx, pc.request(args)); err != nil {
+		return publishError(err)
+	}
+	if pc.registerDNS {
+		fmt.Fprintln(output.Out(ctx), "The DNS name of the user is no longer registered")
+	} else {
+		fmt.Fprintf(output.Out(ctx), "Service %s is no longer published\n", args[0])
+	}
+	return nil
+}
+
+func publishError(err error) error {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.InvalidArgument, codes.FailedPrecondition, codes.AlreadyExists, codes.NotFound, codes.Unavailable, codes.Unimplemented,
+			codes.Unauthenticated, codes.PermissionDenied:
+			return errcat.User.New(st.Message())
+		}
+	}
+	return err
+}
+
+// parsePublishedPorts parses the ports of a published service, given as [<service port>:]<local port>. The
+// service port defaults to the local port.
+func parsePublishedPorts(args []string) ([]*manager.PublishedPort, error) {
+	pps := make([]*manager.PublishedPort, len(args))
+	for i, arg := range args {
+		svcPort, localPort, ok := strings.Cut(arg, ":")
+		if !ok {
+			svcPort, localPort = arg, arg
+		}
+		sp, err := strconv.ParseUint(svcPort, 10, 16)
+		var lp uint64
+		if err == nil {
+			lp, err = strconv.ParseUint(localPort, 10, 16)
+		}
+		if err != nil || sp == 0 || lp == 0 {
+			return nil, errcat.User.Newf("invalid port %q. Use [<service port>:]<local port>", arg)
+		}
+		pps[i] = &manager.PublishedPort{Port: int32(sp), TargetPort: int32(lp)}
+	}
+	return pps, nil
+}
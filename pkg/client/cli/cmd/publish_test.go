@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parsePublishedPorts(t *testing.T) {
+	pps, err := parsePublishedPorts([]string{"80:8080", "9090"})
+	require.NoError(t, err)
+	require.Len(t, pps, 2)
+	assert.Equal(t, int32(80), pps[0].Port)
+	assert.Equal(t, int32(8080), pps[0].TargetPort)
+	assert.Equal(t, int32(9090), pps[1].Port)
+	assert.Equal(t, int32(9090), pps[1].TargetPort)
+
+	for _, arg := range []string{"http", "80:", "0", "70000:80"} {
+		_, err = parsePublishedPorts([]string{arg})
+		assert.Error(t, err, arg)
+	}
+}
+
+func Test_publishArgs(t *testing.T) {
+	// None of these get past the validation of arguments and flags, so they never reach the daemon.
+	tests := []struct {
+		cmd  func() *cobra.Command
+		args []string
+	}{
+		{publishCmd, []string{"payments-dev"}},
+		{publishCmd, []string{"--register-dns"}},
+		{publishCmd, []string{"--register-dns", "--namespace", "dev", "3000"}},
+		{unpublishCmd, []string{}},
+		{unpublishCmd, []string{"--register-dns", "payments-dev"}},
+		{unpublishCmd, []string{"--register-dns", "--namespace", "dev"}},
+	}
+	for _, tt := range tests {
+		cmd := tt.cmd()
+		cmd.SetArgs(tt.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Error(t, cmd.Execute(), "%s %v", cmd.Name(), tt.args)
+	}
+}
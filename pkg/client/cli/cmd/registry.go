@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func registryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Expose a container image registry on this workstation to the cluster",
+	}
+	cmd.AddCommand(registryExpose(), registryUnexpose())
+	return cmd
+}
+
+func registryExpose() *cobra.Command {
+	return &cobra.Command{
+		Use:  "expose <host:port>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Let the cluster pull images from a registry on this workstation",
+		Long: `Let the cluster pull images from a registry on this workstation.
+
+The traffic-manager's registry proxy forwards the connections that it receives, through the session's tunnel, to
+the given registry. Container runtimes in the cluster can then pull images that were pushed to the registry,
+e.g. images built using "intercept --docker-build", from the address that this command prints, using the printed
+credentials. The proxy never forwards pushes. The registry proxy must be enabled using the Helm chart value
+registryProxy.enabled. The exposure, and the validity of the credentials, ends when the session ends.`,
+		Example: `  # Expose a registry that was started using "docker run -d -p 5000:5000 registry:2"
+  telepresence registry expose localhost:5000`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exposeRegistry(cmd, args[0])
+		},
+	}
+}
+
+func registryUnexpose() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unexpose",
+		Args:  cobra.NoArgs,
+		Short: "Stop exposing the registry on this workstation to the cluster",
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exposeRegistry(cmd, "")
+		},
+	}
+}
+
+func exposeRegistry(cmd *cobra.Command, address string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	info, err := daemon.GetUserClient(ctx).ExposeRegistry(ctx, &manager.RegistryProxyRequest{Address: address})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.InvalidArgument, codes.FailedPrecondition, codes.Unavailable, codes.Unimplemented:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	switch {
+	case output.WantsFormatted(cmd):
+		output.Object(ctx, info, false)
+	case info.Address != "":
+		out := output.Out(ctx)
+		fmt.Fprintf(out, "Registry %s is exposed to the cluster. Images are pulled from %s\n", address, info.Address)
+		fmt.Fprintf(out, "Pulls must use the user %q and the password %q, e.g. in an imagePullSecret:\n", info.Username, info.Password)
+		fmt.Fprintf(out, "  kubectl create secret docker-registry telepresence-registry --docker-server=%s --docker-username=%s --docker-password=%s\n",
+			info.Address, info.Username, info.Password)
+	default:
+		fmt.Fprintln(output.Out(ctx), "Registry is no longer exposed to the cluster")
+	}
+	return nil
+}
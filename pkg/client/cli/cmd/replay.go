@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+type replayCommand struct {
+	realtime bool
+	timeout  time.Duration
+}
+
+func replayCmd() *cobra.Command {
+	rc := &replayCommand{}
+	cmd := &cobra.Command{
+		Use:  "replay [flags] <intercept_name> <file>",
+		Args: cobra.ExactArgs(2),
+
+		Short: "Replay captured HTTP requests against the handler of an intercept",
+		Long: `Replay captured HTTP requests against the handler of an intercept.
+
+The requests in the file, as written by "telepresence capture", are sent to the local handler of the intercept
+one at a time, in the order that they were captured. The status of each response is printed. The requests are
+sent as fast as possible, unless --realtime is used.`,
+		Example: `  # Replay the requests in requests.jsonl against the handler of the echo intercept
+  telepresence replay echo requests.jsonl`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return rc.run(cmd, strings.TrimSpace(args[0]), args[1])
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&rc.realtime, "realtime", false, "Wait between the requests so that they are sent with the same intervals as when they were captured")
+	flags.DurationVar(&rc.timeout, "timeout", 30*time.Second, "The max time to wait for the response to a request")
+	return cmd
+}
+
+func (rc *replayCommand) run(cmd *cobra.Command, name, file string) error {
+	ctx := cmd.Context()
+	crs, err := readCapturedRequests(file)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	ii, err := daemon.GetUserClient(ctx).GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errcat.User.Newf("found no intercept named %s", name)
+		}
+		return err
+	}
+	addr := iputil.JoinHostPort(ii.Spec.TargetHost, uint16(ii.Spec.TargetPort))
+	hc := &http.Client{
+		Timeout: rc.timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	out := cmd.OutOrStdout()
+	failed := 0
+	for i, cr := range crs {
+		if rc.realtime && i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(cr.Time.AsTime().Sub(crs[i-1].Time.AsTime())):
+			}
+		}
+		start := time.Now()
+		rs, err := replayRequest(ctx, hc, addr, cr)
+		if err != nil {
+			fmt.Fprintf(out, "%s %s: %v\n", cr.Method, cr.Uri, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s %s -> %s (%s)\n", cr.Method, cr.Uri, rs, time.Since(start).Round(time.Millisecond))
+	}
+	fmt.Fprintf(out, "Replayed %d requests to %s, %d failed\n", len(crs), addr, failed)
+	return nil
+}
+
+// readCapturedRequests reads the requests of a file written by the capture command.
+func readCapturedRequests(file string) ([]*agent.CapturedRequest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var crs []*agent.CapturedRequest
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		cr := &agent.CapturedRequest{}
+		if err = protojson.Unmarshal(sc.Bytes(), cr); err != nil {
+			return nil, fmt.Errorf("%s, line %d: %w", file, line, err)
+		}
+		crs = append(crs, cr)
+	}
+	return crs, sc.Err()
+}
+
+// replayRequest sends the captured request to the given address and returns the status of the response. The body
+// of the response is discarded.
+func replayRequest(ctx context.Context, hc *http.Client, addr string, cr *agent.CapturedRequest) (string, error) {
+	rq, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(cr.Data)))
+	if err != nil {
+		return "", fmt.Errorf("unable to parse captured request: %w", err)
+	}
+	rq.RequestURI = ""
+	rq.URL.Scheme = "http"
+	rq.URL.Host = addr
+	rs, err := hc.Do(rq.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	_, _ = io.Copy(io.Discard, rs.Body)
+	_ = rs.Body.Close()
+	return rs.Status, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+)
+
+func TestReplayCapturedRequests(t *testing.T) {
+	now := time.Now()
+	crs := []*agent.CapturedRequest{
+		{
+			Time:   timestamppb.New(now),
+			Port:   8080,
+			Method: "POST",
+			Uri:    "/orders?id=7",
+			Data:   []byte("POST /orders?id=7 HTTP/1.1\r\nHost: orders\r\nX-Trace: abc\r\nContent-Length: 5\r\n\r\nhello"),
+		},
+		{
+			Time:   timestamppb.New(now.Add(time.Second)),
+			Port:   8080,
+			Method: "GET",
+			Uri:    "/health",
+			Data:   []byte("GET /health HTTP/1.1\r\nHost: orders\r\n\r\n"),
+		},
+	}
+	file := filepath.Join(t.TempDir(), "requests.jsonl")
+	var sb strings.Builder
+	for _, cr := range crs {
+		data, err := protojson.Marshal(cr)
+		require.NoError(t, err)
+		sb.Write(data)
+		sb.WriteString("\n\n")
+	}
+	require.NoError(t, os.WriteFile(file, []byte(sb.String()), 0o600))
+
+	read, err := readCapturedRequests(file)
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	assert.Equal(t, crs[1].Data, read[1].Data)
+
+	type received struct {
+		method, uri, host, trace, body string
+	}
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, received{r.Method, r.RequestURI, r.Host, r.Header.Get("X-Trace"), string(body)})
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	ctx := context.Background()
+	status, err := replayRequest(ctx, srv.Client(), addr, read[0])
+	require.NoError(t, err)
+	assert.Equal(t, "201 Created", status)
+	status, err = replayRequest(ctx, srv.Client(), addr, read[1])
+	require.NoError(t, err)
+	assert.Equal(t, "200 OK", status)
+	assert.Equal(t, []received{
+		{"POST", "/orders?id=7", "orders", "abc", "hello"},
+		{"GET", "/health", "orders", "", ""},
+	}, got)
+
+	_, err = replayRequest(ctx, srv.Client(), addr, &agent.CapturedRequest{Data: []byte("garbage")})
+	assert.Error(t, err)
+}
+
+func TestReadCapturedRequests_invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "requests.jsonl")
+	require.NoError(t, os.WriteFile(file, []byte("{\"method\":\"GET\"}\nnot json\n"), 0o600))
+	_, err := readCapturedRequests(file)
+	assert.ErrorContains(t, err, "line 2")
+}
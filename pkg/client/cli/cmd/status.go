@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/go-json-experiment/json"
@@ -45,6 +46,7 @@ type RootDaemonStatus struct {
 	Version    string           `json:"version,omitempty"`
 	APIVersion int32            `json:"api_version,omitempty"`
 	DNS        *client.DNSSnake `json:"dns,omitempty"`
+	Networking string           `json:"networking,omitempty"`
 	*client.RoutingSnake
 }
 
@@ -325,6 +327,9 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 			if err := client.UnmarshalJSON(obc.ClientConfig, rootCfg, true); err == nil {
 				rs.DNS = rootCfg.DNS().ToSnake()
 				rs.RoutingSnake = rootCfg.Routing().ToSnake()
+				if cc := rootCfg.Cluster(); cc.UserspaceNetworking {
+					rs.Networking = fmt.Sprintf("user-space, TCP only through SOCKS5 proxy on %s (reduced performance)", cc.SOCKSProxyAddress)
+				}
 			}
 		}
 	}
@@ -443,6 +448,9 @@ func (ds *RootDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 		kvf.Prefix = "  "
 		kvf.Indent = "  "
 		kvf.Add("Version", ds.Version)
+		if ds.Networking != "" {
+			kvf.Add("Networking", ds.Networking)
+		}
 		if ds.DNS != nil {
 			printDNS(kvf, ds.DNS)
 		}
@@ -500,6 +508,9 @@ func printRouting(kvf *ioutil.KeyValueFormatter, r *client.RoutingSnake) {
 	printSubnets("Also Proxy", r.AlsoProxy)
 	printSubnets("Never Proxy", r.NeverProxy)
 	printSubnets("Allow conflicts for", r.AllowConflicting)
+	if r.MTU > 0 {
+		kvf.Add("MTU", strconv.Itoa(r.MTU))
+	}
 }
 
 func (cs *UserDaemonStatus) WriteTo(out io.Writer) (int64, error) {
@@ -14,6 +14,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker/kubeauth"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/podd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd"
 	userDaemon "github.com/telepresenceio/telepresence/v2/pkg/client/userd/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -134,14 +135,14 @@ func OnlySubcommands(cmd *cobra.Command, args []string) error {
 
 func WithSubCommands(ctx context.Context) context.Context {
 	return MergeSubCommands(ctx,
-		configCmd(), connectCmd(), currentClusterId(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
-		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), statusCmd(),
-		testVPN(), uninstall(), uploadTraces(), version(), listNamespaces(), listContexts(),
+		adminCmd(), capabilitiesCmd(), captureCmd(), ciCmd(), composeCmd(), configCmd(), connectCmd(), cp(), currentClusterId(), describe(), events(), exposeCmd(), fetch(), gatherLogs(), gatherTraces(), genYAML(), groupCmd(), helmCmd(),
+		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), mountCmd(), portForwardCmd(), publishCmd(), quit(), registryCmd(), replayCmd(), statusCmd(),
+		testVPN(), uninstall(), unpublishCmd(), upgradeCmd(), uploadTraces(), verifyPropagation(), version(), wiretapCmd(), listNamespaces(), listContexts(),
 	)
 }
 
 func WithDaemonSubCommands(ctx context.Context) context.Context {
-	return MergeSubCommands(ctx, kubeauth.Command(), userDaemon.Command(), rootd.Command())
+	return MergeSubCommands(ctx, kubeauth.Command(), userDaemon.Command(), rootd.Command(), podd.Command())
 }
 
 type subCommandsKey struct{}
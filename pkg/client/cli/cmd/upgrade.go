@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/selfupdate"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func upgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade telepresence",
+	}
+	cmd.AddCommand(upgradeSelf())
+	return cmd
+}
+
+type upgradeSelfCommand struct {
+	channel string
+	check   bool
+}
+
+func upgradeSelf() *cobra.Command {
+	us := &upgradeSelfCommand{}
+	cmd := &cobra.Command{
+		Use:  "self",
+		Args: cobra.NoArgs,
+
+		Short: "Replace this telepresence binary with the most recent release",
+		Long: `Replace this telepresence binary with the most recent release of a release channel.
+
+The "stable" channel contains the most recent release. The "latest" channel also contains pre-releases. The
+binary is verified using the SHA-256 checksums that are published with the release before it replaces the
+current binary. The command can be disabled using the client configuration upgrade.disabled.`,
+		Example: `  # Check if a new stable release is available
+  telepresence upgrade self --check
+
+  # Upgrade to the most recent pre-release
+  telepresence upgrade self --channel latest`,
+		Annotations: map[string]string{
+			ann.UserDaemon: ann.Optional,
+		},
+		SilenceUsage: true,
+		RunE:         us.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&us.channel, "channel", "",
+		fmt.Sprintf("Release channel, %q or %q. Defaults to the client configuration upgrade.channel",
+			selfupdate.ChannelStable, selfupdate.ChannelLatest))
+	flags.BoolVar(&us.check, "check", false, "Only check if a new release is available")
+	return cmd
+}
+
+func (us *upgradeSelfCommand) run(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	cfg := client.GetConfig(ctx).Upgrade()
+	if cfg.Disabled {
+		return errcat.User.New("upgrades are disabled by the client configuration upgrade.disabled")
+	}
+	channel := us.channel
+	if channel == "" {
+		channel = cfg.Channel
+	}
+	rel, err := selfupdate.LatestRelease(ctx, cfg.URL, channel)
+	if err != nil {
+		return err
+	}
+	current := client.Semver()
+	out := output.Out(ctx)
+	if !rel.Version.GT(current) {
+		fmt.Fprintf(out, "Telepresence v%s is up to date with the %s channel\n", current, channel)
+		return nil
+	}
+	if us.check {
+		fmt.Fprintf(out, "Telepresence v%s is available in the %s channel, you have v%s\n", rel.Version, channel, current)
+		return nil
+	}
+
+	if runtime.GOOS == "windows" {
+		// A running executable cannot be replaced on Windows.
+		return errcat.User.Newf("telepresence v%s is available, please use the installer to upgrade", rel.Version)
+	}
+	exe, err := client.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if mechanism, _ := client.GetMechanismFromPath(exe); mechanism == "brew" {
+		return errcat.User.Newf("telepresence v%s is available, please use \"brew upgrade\" to upgrade", rel.Version)
+	}
+	if err = selfupdate.Install(ctx, rel, selfupdate.AssetName(runtime.GOOS, runtime.GOARCH), exe); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return errcat.User.Newf("no permission to replace %s, please rerun the command using sudo", exe)
+		}
+		return err
+	}
+	fmt.Fprintf(out, "Telepresence upgraded from v%s to v%s\n", current, rel.Version)
+	if daemon.GetUserClient(ctx) != nil {
+		fmt.Fprintln(out, `Run "telepresence quit -s" to restart the daemons using the new version`)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+const headerPropagationDocs = "https://telepresence.io/docs/reference/intercepts/header-propagation"
+
+type verifyPropagationCommand struct {
+	chain   []string
+	header  string
+	url     string
+	timeout time.Duration
+}
+
+func verifyPropagation() *cobra.Command {
+	vc := &verifyPropagationCommand{}
+	cmd := &cobra.Command{
+		Use:  "verify-propagation [flags] <intercept_name> --chain <workload>[,<workload>...]",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Verify that the header of an intercept is propagated through a chain of workloads",
+		Long: `Verify that the header of an intercept is propagated through a chain of workloads.
+
+When requests reach the intercepted workload through other workloads, each of those workloads must propagate
+the header that the intercept matches on to its outgoing requests. This command sends a probe request with the
+header to the first workload in the chain, and the traffic-agents of the workloads then report whether they
+received it. The workloads in the chain must have a traffic-agent, and the header must be sent using HTTP/1.x.`,
+		Example: `  # Verify that the header of my-intercept is propagated from frontend, through orders, to the intercepted workload
+  telepresence verify-propagation my-intercept --chain frontend,orders`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage:      true,
+		RunE:              vc.run,
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	flags := cmd.Flags()
+	flags.StringSliceVar(&vc.chain, "chain", nil, ``+
+		`The workloads, in the order that requests flow through them, that lead up to the intercepted workload`)
+	flags.StringVar(&vc.header, "header", "", ``+
+		`The header to verify. Defaults to the first header that the intercept matches using a literal value`)
+	flags.StringVar(&vc.url, "url", "", ``+
+		`The URL that the probe request is sent to. Defaults to http://<first workload in chain>.<namespace>/`)
+	flags.DurationVar(&vc.timeout, "timeout", 5*time.Second, "How long to wait for the probe to propagate")
+	_ = cmd.MarkFlagRequired("chain")
+	return cmd
+}
+
+func (vc *verifyPropagationCommand) run(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	name := strings.TrimSpace(args[0])
+	result, err := daemon.GetUserClient(ctx).VerifyHeaderPropagation(ctx, &manager.HeaderPropagationRequest{
+		Name:    name,
+		Header:  vc.header,
+		Chain:   vc.chain,
+		Url:     vc.url,
+		Timeout: durationpb.New(vc.timeout),
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			switch st.Code() {
+			case codes.NotFound, codes.InvalidArgument, codes.Unimplemented:
+				return errcat.User.New(st.Message())
+			}
+		}
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, result, false)
+	} else {
+		printHeaderPropagation(output.Out(ctx), result)
+	}
+	return nil
+}
+
+func printHeaderPropagation(out io.Writer, result *manager.HeaderPropagationResult) {
+	fmt.Fprintf(out, "Propagation of header %s:\n", result.Header)
+	for _, hop := range result.Hops {
+		switch {
+		case hop.Error != "":
+			fmt.Fprintf(out, "    %-30s unknown: %s\n", hop.Workload, hop.Error)
+		case hop.Received:
+			fmt.Fprintf(out, "    %-30s received\n", hop.Workload)
+		default:
+			fmt.Fprintf(out, "    %-30s not received\n", hop.Workload)
+		}
+	}
+	if result.ProbeError != "" {
+		fmt.Fprintf(out, "\nThe probe request failed: %s\n", result.ProbeError)
+	}
+
+	var missed string
+	for _, hop := range result.Hops {
+		if hop.Error == "" && !hop.Received {
+			missed = hop.Workload
+			break
+		}
+	}
+	switch {
+	case missed == "":
+		return
+	case result.DroppedBy == "":
+		fmt.Fprintf(out, "\nThe probe request never reached %s. Use --url to send it to a port that %s serves.\n", missed, missed)
+	default:
+		fmt.Fprintf(out, `
+The header was dropped by %s. Make sure that %s copies the %s header from its incoming requests to the
+requests that it sends to %s, e.g. by using OpenTelemetry baggage or a middleware in its HTTP framework.
+See %s for more information.
+`, result.DroppedBy, result.DroppedBy, result.Header, missed, headerPropagationDocs)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/wiretap"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+type wiretapCommand struct {
+	output   string
+	format   string
+	maxSize  string
+	duration time.Duration
+}
+
+func wiretapCmd() *cobra.Command {
+	wc := &wiretapCommand{}
+	cmd := &cobra.Command{
+		Use:  "wiretap [flags] <intercept_name>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Capture the traffic of an intercept to a pcap or HAR file",
+		Long: `Capture the traffic of an intercept to a pcap or HAR file.
+
+The traffic that flows between the cluster and the intercept handler is recorded until the command is
+interrupted, or until the --duration or --max-size limit is reached. Only connections that are established
+after the capture has started are recorded.
+
+The pcap format records all TCP and UDP traffic with synthesized IP packets, so that it can be inspected with
+tools like Wireshark. The HAR format records the HTTP/1.x requests and responses, and is written when the
+capture ends.`,
+		Example: `  # Capture one minute of HTTP traffic
+  telepresence wiretap my-intercept -o traffic.har --duration 1m
+
+  # Capture at most 10 MiB of traffic
+  telepresence wiretap my-intercept -o traffic.pcap --max-size 10Mi`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			return wc.run(cmd, strings.TrimSpace(args[0]))
+		},
+		ValidArgsFunction: interceptNameCompletion,
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&wc.output, "output", "o", "", "The file to write the captured traffic to")
+	flags.StringVar(&wc.format, "format", "",
+		`The format of the file, "pcap" or "har". Defaults to the format that corresponds to the extension of the file`)
+	flags.StringVar(&wc.maxSize, "max-size", "", "Stop when this amount of traffic has been captured, e.g. 10Mi")
+	flags.DurationVar(&wc.duration, "duration", 0, "Stop when the traffic has been captured for this long")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func (wc *wiretapCommand) run(cmd *cobra.Command, name string) error {
+	format := wc.format
+	if format == "" {
+		if format = wiretap.FormatFromFile(wc.output); format == "" {
+			return errcat.User.Newf("unable to determine the format of %s, please use --format", wc.output)
+		}
+	}
+	var maxSize int64
+	if wc.maxSize != "" {
+		q, err := resource.ParseQuantity(wc.maxSize)
+		if err != nil {
+			return errcat.User.Newf("invalid --max-size %q: %v", wc.maxSize, err)
+		}
+		maxSize = q.Value()
+	}
+
+	f, err := os.Create(wc.output)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	w, err := wiretap.NewWriter(format, bw)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if wc.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, wc.duration)
+		defer cancel()
+	}
+	stream, err := daemon.GetUserClient(ctx).Wiretap(ctx, &connector.WiretapRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Capturing the traffic of intercept %s to %s. Press Ctrl-C to stop\n", name, wc.output)
+
+	var size int64
+	conns := make(map[string]struct{})
+	for maxSize == 0 || size < maxSize {
+		e, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled || status.Code(err) == codes.DeadlineExceeded {
+				break
+			}
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.NotFound, codes.FailedPrecondition:
+					return errcat.User.New(st.Message())
+				}
+			}
+			return err
+		}
+		conns[string(e.ConnId)] = struct{}{}
+		size += int64(len(e.Data))
+		if err = w.Write(e); err != nil {
+			return err
+		}
+	}
+	cancel()
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Captured %d bytes in %d connections to %s\n", size, len(conns), wc.output)
+	return f.Close()
+}
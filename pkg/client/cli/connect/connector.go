@@ -18,6 +18,7 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -105,6 +106,12 @@ func quitDockerDaemons(ctx context.Context) {
 func ExistingDaemon(ctx context.Context, info *daemon.Info) (context.Context, error) {
 	var err error
 	var conn *grpc.ClientConn
+	if info.Isolated {
+		if conn, err = dialIsolatedDaemon(ctx, info.DaemonID(), false); err != nil {
+			return ctx, err
+		}
+		return newUserDaemon(ctx, conn, info.DaemonID())
+	}
 	if info.InDocker && !proc.RunningInContainer() {
 		// The host relies on that the daemon has exposed a port to localhost
 		conn, err = docker.ConnectDaemon(ctx, fmt.Sprintf(":%d", info.DaemonPort))
@@ -116,7 +123,37 @@ func ExistingDaemon(ctx context.Context, info *daemon.Info) (context.Context, er
 	return ExistingHostDaemon(ctx, info.DaemonID())
 }
 
+// dialIsolatedDaemon creates a connection to an isolated host daemon. Such a daemon listens to a socket of its
+// own in a directory that only the user can access, instead of the well-known socket.
+func dialIsolatedDaemon(ctx context.Context, daemonID *daemon.Identifier, waitForSocket bool) (*grpc.ClientConn, error) {
+	return socket.Dial(ctx, socket.IsolatedUserDaemonPath(ctx, daemonID.Name), waitForSocket)
+}
+
+// dialRemoteDaemon creates a connection to a user daemon that listens to the given TCP address, e.g. a daemon
+// in a devcontainer or on a remote VM. The connection uses TLS with the client certificate of the
+// grpc.remoteControl config, and an error is returned when that config has no certificate.
+func dialRemoteDaemon(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	rc := client.GetConfig(ctx).Grpc().RemoteControl
+	if !rc.HasTLS() {
+		return nil, errcat.Config.Newf(
+			"TELEPRESENCE_USER_DAEMON_ADDRESS is set to %s, but grpc.remoteControl has no certificate for the TLS connection to the daemon", addr)
+	}
+	tlsConfig, err := rc.ClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), grpc.WithNoProxy())
+}
+
 func ExistingHostDaemon(ctx context.Context, id *daemon.Identifier) (context.Context, error) {
+	if addr := client.GetEnv(ctx).UserDaemonAddress; addr != "" {
+		conn, err := dialRemoteDaemon(ctx, addr)
+		if err != nil {
+			return ctx, err
+		}
+		return newUserDaemon(ctx, conn, id)
+	}
+
 	// Try dialing the host daemon using the well-known socket.
 	socketName := socket.UserDaemonPath(ctx)
 	conn, err := socket.Dial(ctx, socketName, false)
@@ -147,9 +184,12 @@ func Disconnect(ctx context.Context) {
 	if ud := daemon.GetUserClient(ctx); ud == nil {
 		ioutil.Println(output.Out(ctx), "Not connected")
 	} else {
-		_, err := ud.Disconnect(ctx, &emptypb.Empty{})
+		dr, err := ud.Disconnect(ctx, &emptypb.Empty{})
 		switch {
 		case err == nil:
+			for _, f := range dr.HandlerStopFailures {
+				ioutil.Printf(output.Err(ctx), "intercept handler of %s failed to stop: %s\n", f.Intercept, f.Error)
+			}
 			ioutil.Println(output.Out(ctx), "Disconnected")
 		case status.Code(err) == codes.Unavailable:
 			ioutil.Println(output.Out(ctx), "Not connected")
@@ -180,10 +220,15 @@ func DiscoverDaemon(ctx context.Context, match *regexp.Regexp, daemonID *daemon.
 	if match == nil && !cr.Implicit {
 		match = regexp.MustCompile(`\A` + regexp.QuoteMeta(daemonID.Name) + `\z`)
 	}
+	if client.GetEnv(ctx).UserDaemonAddress != "" {
+		// The daemon is controlled remotely, and is never launched by this client.
+		return ExistingHostDaemon(ctx, daemonID)
+	}
 	info, err := daemon.LoadMatchingInfo(ctx, match)
 	if err != nil {
-		if os.IsNotExist(err) && !cr.Docker {
-			// Try dialing the host daemon using the well-known socket.
+		if os.IsNotExist(err) && !(cr.Docker || cr.Isolated) {
+			// Try dialing the host daemon using the well-known socket. An isolated daemon is never found
+			// that way, and it must not use the daemon that is.
 			if conn, sockErr := socket.Dial(ctx, socket.UserDaemonPath(ctx), false); sockErr == nil {
 				return newUserDaemon(ctx, conn, daemonID)
 			}
@@ -203,6 +248,8 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 	if err != nil {
 		return ctx, err
 	}
+	// Containerized daemons are always isolated from each other.
+	daemonID.Isolated = cr.Isolated && !daemonID.Containerized
 
 	// Try dialing the host daemon using the well known socket.
 	ctx, err = DiscoverDaemon(ctx, cr.Use, daemonID)
@@ -257,7 +304,14 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 		if cr.UserDaemonProfilingPort > 0 {
 			args = append(args, "--pprof", strconv.Itoa(int(cr.UserDaemonProfilingPort)))
 		}
-		if proc.IsAdmin() {
+		if daemonID.Isolated {
+			// The isolated daemon listens to a socket that is derived from its name so that it doesn't conflict
+			// with the daemon that listens to the well-known socket, or with other isolated daemons.
+			args = append(args,
+				"--name", "isolated-"+daemonID.Name,
+				"--embed-network",
+				"--isolated")
+		} else if proc.IsAdmin() {
 			// No use having multiple daemons when running as root.
 			hn, err := os.Hostname()
 			if err != nil {
@@ -265,16 +319,19 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 			}
 			args = append(args, "--embed-network")
 			args = append(args, "--name", "docker-"+hn)
+		} else if client.GetConfig(ctx).Cluster().UserspaceNetworking {
+			// The network is handled by the user daemon itself, using a SOCKS5 proxy instead of a TUN-device.
+			args = append(args, "--embed-network")
 		}
 		err = daemon.SaveInfo(ctx,
 			&daemon.Info{
 				InDocker:     cliInContainer,
-				DaemonPort:   0,
 				Name:         daemonID.Name,
 				KubeContext:  daemonID.KubeContext,
 				Namespace:    daemonID.Namespace,
 				ExposedPorts: cr.ExposedPorts,
 				Hostname:     cr.Hostname,
+				Isolated:     daemonID.Isolated,
 			}, daemonID.InfoFileName())
 		if err != nil {
 			return ctx, err
@@ -288,7 +345,11 @@ func launchConnectorDaemon(ctx context.Context, connectorDaemon string, required
 		if err = proc.StartInBackground(false, args...); err != nil {
 			return ctx, errcat.NoDaemonLogs.Newf("failed to launch the connector service: %w", err)
 		}
-		conn, err = socket.Dial(ctx, socket.UserDaemonPath(ctx), true)
+		if daemonID.Isolated {
+			conn, err = dialIsolatedDaemon(ctx, daemonID, true)
+		} else {
+			conn, err = socket.Dial(ctx, socket.UserDaemonPath(ctx), true)
+		}
 	}
 	if err != nil {
 		return ctx, err
@@ -333,7 +394,8 @@ func newUserDaemon(ctx context.Context, conn *grpc.ClientConn, daemonID *daemon.
 
 func EnsureUserDaemon(ctx context.Context, required bool) (rc context.Context, err error) {
 	defer func() {
-		if err == nil && required && !(proc.IsAdmin() || daemon.GetUserClient(rc).Containerized()) {
+		if err == nil && required && !(proc.IsAdmin() || daemon.GetUserClient(rc).Containerized() || daemon.GetUserClient(rc).DaemonID().Isolated ||
+			client.GetConfig(ctx).Cluster().UserspaceNetworking) {
 			// The RootDaemon must be started if the UserDaemon was started
 			err = ensureRootDaemonRunning(ctx)
 		}
@@ -380,6 +442,7 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 			KubeContext:   ci.ClusterContext,
 			Namespace:     ci.Namespace,
 			Containerized: userD.Containerized(),
+			Isolated:      userD.DaemonID().Isolated,
 		})
 		return &daemon.Session{
 			UserClient: userD,
@@ -439,7 +502,7 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 		case connector.ConnectInfo_ALREADY_CONNECTED:
 			return session(ci, false), nil
 		case connector.ConnectInfo_MUST_RESTART:
-			msg = "Cluster configuration changed, please quit telepresence and reconnect"
+			msg = "Cluster configuration changed, please quit telepresence and reconnect, or use --isolated to create a separate connection"
 		default:
 			msg = ci.ErrorText
 			if ci.ErrorCategory != 0 {
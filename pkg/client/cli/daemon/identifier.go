@@ -13,6 +13,10 @@ type Identifier struct {
 	KubeContext   string
 	Namespace     string
 	Containerized bool
+
+	// Isolated is true when the daemon runs on the host, but listens to a socket of its own instead of the
+	// well-known socket, and uses user-space networking instead of the root daemon.
+	Isolated bool
 }
 
 func NewIdentifier(name, contextName, namespace string, containerized bool) (*Identifier, error) {
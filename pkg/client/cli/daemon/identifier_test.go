@@ -76,3 +76,14 @@ func TestSafeContainerName(t *testing.T) {
 		})
 	}
 }
+
+func TestIsolatedDaemonID(t *testing.T) {
+	info := &daemon.Info{Name: "ctx-ns1", KubeContext: "ctx", Namespace: "ns1", Isolated: true}
+	id := info.DaemonID()
+	if !id.Isolated || id.Containerized {
+		t.Fatalf("expected an isolated host daemon, got %+v", id)
+	}
+	if id.InfoFileName() != "ctx-ns1.json" {
+		t.Fatalf("isolation must not affect the info file name, got %s", id.InfoFileName())
+	}
+}
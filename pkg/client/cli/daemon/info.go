@@ -26,10 +26,14 @@ type Info struct {
 	DaemonPort   int               `json:"daemon_port,omitempty"`
 	ExposedPorts []string          `json:"exposed_ports,omitempty"`
 	Hostname     string            `json:"hostname,omitempty"`
+	Isolated     bool              `json:"isolated,omitempty"`
 }
 
 func (info *Info) DaemonID() *Identifier {
 	id, _ := NewIdentifier(info.Name, info.KubeContext, info.Namespace, info.InDocker)
+	if id != nil {
+		id.Isolated = info.Isolated
+	}
 	return id
 }
 
@@ -40,6 +40,10 @@ type Request struct {
 	// Hostname used by a containerized daemon. Only valid when Docker == true
 	Hostname string
 
+	// If set, then start a separate user daemon on the host for the connection, instead of using the
+	// daemon that listens to the well-known socket.
+	Isolated bool
+
 	// Match expression to use when finding an existing connection by name
 	Use *regexp.Regexp
 
@@ -97,6 +101,10 @@ func InitRequest(cmd *cobra.Command) *CobraRequest {
 	nwFlags.StringVar(&cr.Hostname,
 		"hostname", "", ``+
 			`Hostname used by a containerized daemon`)
+	nwFlags.BoolVar(&cr.Isolated,
+		"isolated", false, ``+
+			`Start a separate daemon that uses user-space networking, so that the connection can coexist with other `+
+			`connections on this host`)
 
 	flags.AddFlagSet(nwFlags)
 
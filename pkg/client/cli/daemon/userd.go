@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"io"
+	"net"
 	"strconv"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 )
 
 type UserClient interface {
@@ -40,6 +42,20 @@ func NewUserClient(conn *grpc.ClientConn, daemonID *Identifier, version semver.V
 	return &userClient{ConnectorClient: connector.NewConnectorClient(conn), conn: conn, daemonID: daemonID, version: version, name: name, executable: executable}
 }
 
+// RemoteHost returns the host of the user daemon when the CLI controls a user daemon on another host, e.g. on a
+// dev VM, and an empty string when it doesn't.
+func RemoteHost(ctx context.Context) string {
+	env := client.GetEnv(ctx)
+	if env == nil || env.UserDaemonAddress == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(env.UserDaemonAddress)
+	if err != nil {
+		return env.UserDaemonAddress
+	}
+	return host
+}
+
 type Session struct {
 	UserClient
 	Info    *connector.ConnectInfo
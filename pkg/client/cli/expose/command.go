@@ -0,0 +1,158 @@
+package expose
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+const (
+	TLSSelfSigned = "self-signed"
+	TLSNone       = "none"
+)
+
+type Command struct {
+	Listen      string   // --listen
+	TLS         string   // --tls
+	TLSCertFile string   // --tls-cert-file
+	TLSKeyFile  string   // --tls-key-file
+	Headers     []string // --header
+}
+
+func (c *Command) AddFlags(cmd *cobra.Command) {
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&c.Listen, "listen", "8443", ``+
+		`The local port, or address and port, that the proxy listens to. The address defaults to 127.0.0.1`)
+	flagSet.StringVar(&c.TLS, "tls", TLSSelfSigned, ``+
+		`How to terminate TLS. "`+TLSSelfSigned+`" uses a generated certificate, and "`+TLSNone+`" serves plain HTTP. `+
+		`Can't be used together with --tls-cert-file and --tls-key-file`)
+	flagSet.StringVar(&c.TLSCertFile, "tls-cert-file", "", "A PEM encoded certificate to use when terminating TLS")
+	flagSet.StringVar(&c.TLSKeyFile, "tls-key-file", "", "A PEM encoded private key to use when terminating TLS")
+	flagSet.StringArrayVar(&c.Headers, "header", nil, ``+
+		`An additional header in the form <name>=<value> to add to each request. Replaces a header with the same name `+
+		`that is derived from the intercept. Can be repeated`)
+	cmd.MarkFlagsMutuallyExclusive("tls", "tls-cert-file")
+	cmd.MarkFlagsMutuallyExclusive("tls", "tls-key-file")
+	cmd.MarkFlagsRequiredTogether("tls-cert-file", "tls-key-file")
+}
+
+// listenAddress returns the address to listen to and the host to use in the URL that is presented to the user.
+func (c *Command) listenAddress() (addr, host string, err error) {
+	host, port, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		host, port = "127.0.0.1", c.Listen
+	}
+	if _, err = strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", errcat.User.Newf("--listen %s is not a valid port or address and port", c.Listen)
+	}
+	addr = net.JoinHostPort(host, port)
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		host = "localhost"
+	}
+	return addr, net.JoinHostPort(host, port), nil
+}
+
+func (c *Command) tlsConfig(listenHost string) (*tls.Config, error) {
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+			return nil, errcat.User.New("--tls-cert-file and --tls-key-file must be used together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, errcat.User.New(err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+	}
+	switch c.TLS {
+	case TLSNone:
+		return nil, nil
+	case TLSSelfSigned:
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if h, _, err := net.SplitHostPort(listenHost); err == nil && !slices.Contains(hosts, h) {
+			hosts = append(hosts, h)
+		}
+		cert, err := SelfSignedCertificate(hosts)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+	default:
+		return nil, errcat.User.Newf("--tls must be either %q or %q", TLSSelfSigned, TLSNone)
+	}
+}
+
+func (c *Command) Run(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	name := strings.TrimSpace(args[0])
+	ii, err := daemon.GetUserClient(ctx).GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return errcat.User.Newf("Intercept named %q not found", name)
+		}
+		return err
+	}
+
+	headers, regexHeaders := InterceptHeaders(ii.Headers)
+	extraHeaders, err := ParseHeaders(c.Headers)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	for k, vs := range extraHeaders {
+		headers[k] = vs
+	}
+	for _, h := range regexHeaders {
+		if _, ok := headers[http.CanonicalHeaderKey(h)]; !ok {
+			fmt.Fprintf(cmd.ErrOrStderr(), "The intercept matches header %s using a regular expression. Use --header to provide a value\n", h)
+		}
+	}
+
+	addr, host, err := c.listenAddress()
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := c.tlsConfig(host)
+	if err != nil {
+		return err
+	}
+	spec := ii.Spec
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort(spec.TargetHost, strconv.Itoa(int(spec.TargetPort)))}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	defer ln.Close()
+
+	scheme := "https"
+	if tlsConfig == nil {
+		scheme = "http"
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Exposing intercept %s at %s://%s, forwarding to %s\n", name, scheme, host, target.Host)
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	for _, k := range names {
+		fmt.Fprintf(out, "    Adding header %s: %s\n", k, strings.Join(headers[k], ", "))
+	}
+	fmt.Fprintln(out, "Press Ctrl-C to stop")
+	return (&Proxy{Target: target, Headers: headers, TLSConfig: tlsConfig}).Serve(ctx, ln)
+}
@@ -0,0 +1,139 @@
+package expose
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+// Proxy is a reverse proxy that forwards requests to the handler of an intercept, and adds the headers that
+// the intercept matches on, so that the handler sees the requests just like it would when they arrive
+// through the intercept.
+type Proxy struct {
+	// Target is the URL of the intercept handler.
+	Target *url.URL
+
+	// Headers are added to each forwarded request, replacing any headers with the same name.
+	Headers http.Header
+
+	// TLSConfig enables TLS termination when set.
+	TLSConfig *tls.Config
+}
+
+// Handler returns the http.Handler that performs the forwarding.
+func (p *Proxy) Handler(ctx context.Context) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(p.Target)
+			r.SetXForwarded()
+			// Retain the host used by the browser, so that the handler sees the realistic origin.
+			r.Out.Host = r.In.Host
+			for k, vs := range p.Headers {
+				r.Out.Header[k] = vs
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			dlog.Errorf(ctx, "%s %s: %v", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+// Serve serves the proxy on the given listener until the context is cancelled.
+func (p *Proxy) Serve(ctx context.Context, ln net.Listener) error {
+	sc := &dhttp.ServerConfig{Handler: p.Handler(ctx), TLSConfig: p.TLSConfig}
+	var err error
+	if p.TLSConfig != nil {
+		err = sc.ServeTLS(ctx, ln, "", "")
+	} else {
+		err = sc.Serve(ctx, ln)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// InterceptHeaders returns the headers to inject based on the given header matchers of an intercept. Matchers
+// for paths are ignored. The names of headers that match using a regular expression are returned separately,
+// because no value can be derived for them.
+func InterceptHeaders(matchers map[string]string) (headers http.Header, regexHeaders []string) {
+	headers = make(http.Header, len(matchers))
+	for k, v := range matchers {
+		if strings.HasPrefix(k, ":") {
+			continue
+		}
+		if regexp.QuoteMeta(v) != v {
+			regexHeaders = append(regexHeaders, k)
+			continue
+		}
+		headers.Set(k, v)
+	}
+	return headers, regexHeaders
+}
+
+// ParseHeaders parses headers in the form <name>=<value>.
+func ParseHeaders(hs []string) (http.Header, error) {
+	headers := make(http.Header, len(hs))
+	for _, h := range hs {
+		k, v, ok := strings.Cut(h, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("header %q is not in the form <name>=<value>", h)
+		}
+		headers[textproto.CanonicalMIMEHeaderKey(k)] = append(headers[textproto.CanonicalMIMEHeaderKey(k)], v)
+	}
+	return headers, nil
+}
+
+// SelfSignedCertificate generates a self-signed certificate that is valid for the given hosts, which can be
+// host names or IP addresses.
+func SelfSignedCertificate(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Telepresence"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tpl.IPAddresses = append(tpl.IPAddresses, ip)
+		} else {
+			tpl.DNSNames = append(tpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
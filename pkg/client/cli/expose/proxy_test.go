@@ -0,0 +1,87 @@
+package expose
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestInterceptHeaders(t *testing.T) {
+	headers, regexHeaders := InterceptHeaders(map[string]string{
+		":path-prefix:":  "/api",
+		"x-telepresence": "jane",
+		"x-user":         "jane|john",
+	})
+	assert.Equal(t, http.Header{"X-Telepresence": {"jane"}}, headers)
+	assert.Equal(t, []string{"x-user"}, regexHeaders)
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"x-user=jane", "X-User=john", "x-empty="})
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{"X-User": {"jane", "john"}, "X-Empty": {""}}, headers)
+
+	_, err = ParseHeaders([]string{"x-user"})
+	assert.Error(t, err)
+	_, err = ParseHeaders([]string{"=jane"})
+	assert.Error(t, err)
+}
+
+func TestProxy(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	handler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+" "+r.Header.Get("X-Telepresence")+" "+r.Header.Get("X-Forwarded-Proto"))
+	}))
+	defer handler.Close()
+	target, err := url.Parse(handler.URL)
+	require.NoError(t, err)
+
+	cert, err := SelfSignedCertificate([]string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	p := &Proxy{
+		Target:    target,
+		Headers:   http.Header{"X-Telepresence": {"jane"}},
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Serve(ctx, ln)
+	}()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	host := net.JoinHostPort("localhost", port)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+"/", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Telepresence", "john")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, host+" jane https", string(body))
+
+	cancel()
+	assert.NoError(t, <-done)
+}
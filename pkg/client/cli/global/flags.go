@@ -23,6 +23,6 @@ func Flags(hasKubeFlags bool) *pflag.FlagSet {
 	}
 	flags.Bool(FlagNoReport, false, "Turn off anonymous crash reports and log submission on failure")
 	flags.String(FlagUse, "", "Match expression that uniquely identifies the daemon container")
-	flags.String(FlagOutput, "default", "Set the output format, supported values are 'json', 'yaml', and 'default'")
+	flags.String(FlagOutput, "default", "Set the output format, supported values are 'json', 'yaml', 'json-stream', 'jsonl-events', and 'default'")
 	return flags
 }
@@ -2,11 +2,16 @@ package helm
 
 import (
 	"bytes"
+	"io"
 
+	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
 
 	telcharts "github.com/telepresenceio/telepresence/v2/charts"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 )
 
 func loadCoreChart(version string) (*chart.Chart, error) {
@@ -24,3 +29,33 @@ func loadCRDChart(version string) (*chart.Chart, error) {
 	}
 	return loader.LoadArchive(&buf)
 }
+
+// loadChartRef loads a chart that replaces the built-in chart. The ref is either the path of a chart directory or
+// archive, or an oci:// reference to a chart in an OCI registry, in which case the given version is pulled, or
+// the most recent version when the version is empty. The credentials of "helm registry login" are used.
+func loadChartRef(ref, version string) (*chart.Chart, error) {
+	settings := cli.New()
+	cpo := action.NewInstall(&action.Configuration{})
+	cpo.Version = version
+	if registry.IsOCI(ref) {
+		rc, err := registry.NewClient(
+			registry.ClientOptCredentialsFile(settings.RegistryConfig),
+			registry.ClientOptWriter(io.Discard),
+		)
+		if err != nil {
+			return nil, err
+		}
+		cpo.SetRegistryClient(rc)
+	} else if version != "" {
+		return nil, errcat.User.New("--chart-version can only be used with an oci:// chart")
+	}
+	path, err := cpo.LocateChart(ref, settings)
+	if err != nil {
+		return nil, errcat.User.Newf("unable to locate chart %s: %w", ref, err)
+	}
+	chrt, err := loader.Load(path)
+	if err != nil {
+		return nil, errcat.User.Newf("unable to load chart %s: %w", ref, err)
+	}
+	return chrt, nil
+}
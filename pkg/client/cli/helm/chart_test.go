@@ -0,0 +1,31 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	telcharts "github.com/telepresenceio/telepresence/v2/charts"
+)
+
+func TestLoadChartRef(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "telepresence-2.21.0.tgz")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	require.NoError(t, telcharts.WriteChart(telcharts.DirTypeTelepresence, f, "telepresence", "2.21.0"))
+	require.NoError(t, f.Close())
+
+	chrt, err := loadChartRef(archive, "")
+	require.NoError(t, err)
+	assert.Equal(t, "telepresence-oss", chrt.Name())
+	assert.Equal(t, "2.21.0", chrt.Metadata.Version)
+
+	_, err = loadChartRef(archive, "2.20.0")
+	assert.ErrorContains(t, err, "--chart-version can only be used with an oci:// chart")
+
+	_, err = loadChartRef(filepath.Join(t.TempDir(), "missing"), "")
+	assert.ErrorContains(t, err, "unable to locate chart")
+}
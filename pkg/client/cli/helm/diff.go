@@ -0,0 +1,63 @@
+package helm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// printDiff prints the difference between the manifests of the existing release, which is nil when the release
+// isn't installed, and the manifests of the given dry-run release, in the unified diff format.
+func printDiff(ctx context.Context, releaseName string, existing, dryRun *release.Release) {
+	diff, err := manifestDiff(existing, dryRun)
+	if err != nil {
+		// Not expected to happen since the diff is written to a string.
+		dlog.Errorf(ctx, "unable to compute diff of %s: %v", releaseName, err)
+		return
+	}
+	out := dos.Stdout(ctx)
+	if diff == "" {
+		ioutil.Printf(out, "No changes to %s\n", releaseName)
+		return
+	}
+	ioutil.Printf(out, "%s", diff)
+}
+
+func manifestDiff(existing, dryRun *release.Release) (string, error) {
+	ud := difflib.UnifiedDiff{
+		A:        manifestLines(existing),
+		B:        manifestLines(dryRun),
+		FromFile: "current",
+		ToFile:   "proposed",
+		Context:  3,
+	}
+	if existing == nil {
+		ud.FromFile = "/dev/null"
+	}
+	return difflib.GetUnifiedDiffString(ud)
+}
+
+// manifestLines returns the lines of the manifests of the given release, including the manifests of its hooks.
+func manifestLines(rel *release.Release) []string {
+	if rel == nil {
+		return nil
+	}
+	sb := strings.Builder{}
+	sb.WriteString(strings.TrimSpace(rel.Manifest))
+	sb.WriteByte('\n')
+	for _, h := range rel.Hooks {
+		sb.WriteString("---\n# Source: ")
+		sb.WriteString(h.Path)
+		sb.WriteByte('\n')
+		sb.WriteString(strings.TrimSpace(h.Manifest))
+		sb.WriteByte('\n')
+	}
+	lines := strings.SplitAfter(sb.String(), "\n")
+	return lines[:len(lines)-1] // drop the empty string that follows the last newline
+}
@@ -0,0 +1,35 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestManifestDiff(t *testing.T) {
+	existing := &release.Release{
+		Manifest: "---\n# Source: a.yaml\nkind: Service\nport: 80\n",
+		Hooks:    []*release.Hook{{Path: "hook.yaml", Manifest: "kind: Job\n"}},
+	}
+	proposed := &release.Release{
+		Manifest: "---\n# Source: a.yaml\nkind: Service\nport: 8080\n",
+		Hooks:    []*release.Hook{{Path: "hook.yaml", Manifest: "kind: Job\n"}},
+	}
+
+	diff, err := manifestDiff(existing, proposed)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- current\n+++ proposed\n")
+	assert.Contains(t, diff, "-port: 80\n+port: 8080\n")
+	assert.NotContains(t, diff, "+kind: Job")
+
+	diff, err = manifestDiff(existing, existing)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = manifestDiff(nil, proposed)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- /dev/null\n")
+	assert.Contains(t, diff, "+---\n+# Source: hook.yaml\n+kind: Job\n")
+}
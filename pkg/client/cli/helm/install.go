@@ -42,6 +42,7 @@ const (
 	Install RequestType = iota
 	Upgrade
 	Uninstall
+	Template
 )
 
 type Request struct {
@@ -53,6 +54,19 @@ type Request struct {
 	CreateNamespace bool
 	Crds            bool
 	NoHooks         bool
+
+	// Chart is the path of a chart directory or archive, or an oci:// reference to a chart in an OCI registry, that
+	// replaces the chart that is built into the binary. ChartVersion is the version of an oci:// chart.
+	Chart        string
+	ChartVersion string
+
+	// Bundle is the directory that a Template request writes the rendered manifests, and the digest pinned images
+	// that they require, to. The manifests are printed when it's empty.
+	Bundle string
+
+	// Diff prevents that the release is installed or upgraded. The difference between the manifests of the existing
+	// release and the manifests that would be applied is printed instead.
+	Diff bool
 }
 
 func (hr *Request) Run(ctx context.Context, cr *connector.ConnectRequest) error {
@@ -78,6 +92,9 @@ func (hr *Request) Run(ctx context.Context, cr *connector.ConnectRequest) error
 	if err != nil {
 		return err
 	}
+	if hr.Type == Template {
+		return hr.template(ctx, cr.ManagerNamespace)
+	}
 
 	var config *client.Kubeconfig
 	ctx, config, err = client.DaemonKubeconfig(ctx, cr)
@@ -98,7 +115,7 @@ func (hr *Request) Run(ctx context.Context, cr *connector.ConnectRequest) error
 		dlog.Debug(ctx, "ensuring that traffic-manager exists")
 		err = EnsureTrafficManager(cluster.WithJoinedClientSetInterface(ctx), cluster.Kubeconfig, mgrNs, hr)
 	}
-	if err != nil {
+	if err != nil || hr.Diff {
 		return err
 	}
 
@@ -224,23 +241,31 @@ func installNew(
 	install.Wait = true
 	install.CreateNamespace = req.CreateNamespace
 	install.DisableHooks = req.NoHooks
-	return timedRun(ctx, func(timeout time.Duration) error {
+	if req.Diff {
+		install.DryRunOption = "server"
+	}
+	var rel *release.Release
+	err := timedRun(ctx, func(timeout time.Duration) (err error) {
 		install.Timeout = timeout
-		_, err := install.Run(chrt, values)
+		rel, err = install.Run(chrt, values)
 		return err
 	})
+	if err == nil && req.Diff {
+		printDiff(ctx, releaseName, nil, rel)
+	}
+	return err
 }
 
 func upgradeExisting(
 	ctx context.Context,
-	existingVer string,
+	existing *release.Release,
 	chrt *chart.Chart,
 	helmConfig *action.Configuration,
 	releaseName, ns string,
 	req *Request,
 	values map[string]any,
 ) error {
-	dlog.Infof(ctx, "Existing Traffic Manager %s found in namespace %s, upgrading to %s...", existingVer, ns, client.Version())
+	dlog.Infof(ctx, "Existing Traffic Manager %s found in namespace %s, upgrading to %s...", releaseVer(existing), ns, client.Version())
 	upgrade := action.NewUpgrade(helmConfig)
 	upgrade.Atomic = true
 	upgrade.Wait = true
@@ -248,11 +273,19 @@ func upgradeExisting(
 	upgrade.ResetValues = req.ResetValues
 	upgrade.ReuseValues = req.ReuseValues
 	upgrade.DisableHooks = req.NoHooks
-	return timedRun(ctx, func(timeout time.Duration) error {
+	if req.Diff {
+		upgrade.DryRunOption = "server"
+	}
+	var rel *release.Release
+	err := timedRun(ctx, func(timeout time.Duration) (err error) {
 		upgrade.Timeout = timeout
-		_, err := upgrade.Run(releaseName, chrt, values)
+		rel, err = upgrade.Run(releaseName, chrt, values)
 		return err
 	})
+	if err == nil && req.Diff {
+		printDiff(ctx, releaseName, existing, rel)
+	}
+	return err
 }
 
 func uninstallExisting(ctx context.Context, helmConfig *action.Configuration, releaseName, namespace string, req *Request) error {
@@ -331,6 +364,10 @@ func ensureIsInstalled(
 	releaseName, namespace string, req *Request,
 ) error {
 	cleanFailedState := func(helmConfig *action.Configuration) error {
+		if req.Diff {
+			return errcat.User.Newf("%s in namespace %s is pending or failed and must be removed first, run the command without --diff",
+				releaseName, namespace)
+		}
 		urq := Request{
 			Type:    Uninstall,
 			NoHooks: true,
@@ -370,35 +407,14 @@ func ensureIsInstalled(
 	}
 
 	// OK, now install things.
-	var providedVals map[string]any
-	if len(req.ValuesJson) > 0 {
-		if err := json.Unmarshal(req.ValuesJson, &providedVals); err != nil {
-			return errcat.User.Newf("unable to parse values JSON: %w", err)
-		}
-	}
-
-	var vals map[string]any
-	if len(providedVals) > 0 {
-		vals = chartutil.CoalesceTables(providedVals, GetValuesFunc(ctx))
-	} else {
-		// No values were provided. This means that an upgrade should retain existing values unless
-		// reset-values is true.
-		if req.Type == Upgrade && !req.ResetValues {
-			req.ReuseValues = true
-		}
-		vals = GetValuesFunc(ctx)
+	vals, err := req.values(ctx)
+	if err != nil {
+		return err
 	}
-
 	version := getTrafficManagerVersion(vals)
-
-	var chrt *chart.Chart
-	if crd {
-		chrt, err = loadCRDChart(version)
-	} else {
-		chrt, err = loadCoreChart(version)
-	}
+	chrt, err := req.loadChart(ctx, crd, version)
 	if err != nil {
-		return fmt.Errorf("unable to load built-in helm chart: %w", err)
+		return err
 	}
 
 	switch {
@@ -410,7 +426,7 @@ func ensureIsInstalled(
 	case req.Type == Upgrade: // replace existing install
 		dlog.Infof(ctx, "ensureIsInstalled(namespace=%q): replacing %s from %q to %q...",
 			namespace, releaseName, releaseVer(existing), version)
-		err = upgradeExisting(ctx, releaseVer(existing), chrt, helmConfig, releaseName, namespace, req, vals)
+		err = upgradeExisting(ctx, existing, chrt, helmConfig, releaseName, namespace, req, vals)
 	default:
 		err = errcat.User.Newf(
 			"%s version %q is already installed, use 'telepresence helm upgrade' instead to replace it",
@@ -419,6 +435,45 @@ func ensureIsInstalled(
 	return err
 }
 
+// values returns the values provided by the request, coalesced with the values of the GetValuesFunc.
+func (hr *Request) values(ctx context.Context) (map[string]any, error) {
+	var providedVals map[string]any
+	if len(hr.ValuesJson) > 0 {
+		if err := json.Unmarshal(hr.ValuesJson, &providedVals); err != nil {
+			return nil, errcat.User.Newf("unable to parse values JSON: %w", err)
+		}
+	}
+	if len(providedVals) > 0 {
+		return chartutil.CoalesceTables(providedVals, GetValuesFunc(ctx)), nil
+	}
+	// No values were provided. This means that an upgrade should retain existing values unless
+	// reset-values is true.
+	if hr.Type == Upgrade && !hr.ResetValues {
+		hr.ReuseValues = true
+	}
+	return GetValuesFunc(ctx), nil
+}
+
+// loadChart loads the chart of the request, or the built-in chart of the given version.
+func (hr *Request) loadChart(ctx context.Context, crd bool, version string) (chrt *chart.Chart, err error) {
+	switch {
+	case hr.Chart != "":
+		if chrt, err = loadChartRef(hr.Chart, hr.ChartVersion); err != nil {
+			return nil, err
+		}
+		dlog.Infof(ctx, "using chart %s version %s from %s", chrt.Name(), chrt.Metadata.Version, hr.Chart)
+		return chrt, nil
+	case crd:
+		chrt, err = loadCRDChart(version)
+	default:
+		chrt, err = loadCoreChart(version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load built-in helm chart: %w", err)
+	}
+	return chrt, nil
+}
+
 // DeleteTrafficManager deletes the traffic manager.
 func DeleteTrafficManager(
 	ctx context.Context, clientGetter genericclioptions.RESTClientGetter, namespace string, errOnFail bool, req *Request,
@@ -0,0 +1,178 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"sigs.k8s.io/yaml"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/imageref"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// The names of the files of a bundle.
+const (
+	bundleManifests = "manifests.yaml"
+	bundleImages    = "images.txt"
+)
+
+// template renders the chart of the request without contacting the cluster. The manifests are printed, or, when
+// a bundle directory is given, written to that directory together with the digest pinned images that they require.
+func (hr *Request) template(ctx context.Context, namespace string) error {
+	vals, err := hr.values(ctx)
+	if err != nil {
+		return err
+	}
+	chrt, err := hr.loadChart(ctx, hr.Crds, getTrafficManagerVersion(vals))
+	if err != nil {
+		return err
+	}
+	install := action.NewInstall(&action.Configuration{})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = trafficManagerReleaseName
+	if hr.Crds {
+		install.ReleaseName = crdReleaseName
+	}
+	install.Namespace = namespace
+	install.DisableHooks = hr.NoHooks
+	rel, err := install.RunWithContext(ctx, chrt, vals)
+	if err != nil {
+		return err
+	}
+	// Like "helm template --skip-tests", because tests aren't applied by pipelines.
+	rel.Hooks = slices.DeleteFunc(rel.Hooks, func(h *release.Hook) bool {
+		return slices.Contains(h.Events, release.HookTest)
+	})
+	manifests := strings.Join(manifestLines(rel), "")
+	if hr.Bundle == "" {
+		ioutil.Printf(dos.Stdout(ctx), "%s", manifests)
+		return nil
+	}
+
+	images, err := bundleImageRefs(ctx, chrt, vals, manifests, !hr.Crds)
+	if err != nil {
+		return err
+	}
+	r := imageref.Resolver{}
+	pinned := make([]string, len(images))
+	for i, img := range images {
+		dg, err := r.Digest(ctx, img)
+		if err != nil {
+			return err
+		}
+		pinned[i] = img + "@" + dg
+	}
+
+	if err = os.MkdirAll(hr.Bundle, 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(hr.Bundle, bundleManifests), []byte(manifests), 0o644); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(hr.Bundle, bundleImages), []byte(strings.Join(pinned, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+	out := dos.Stdout(ctx)
+	ioutil.Printf(out, "Wrote %s and %s to %s. The required images are:\n", bundleManifests, bundleImages, hr.Bundle)
+	for _, p := range pinned {
+		ioutil.Printf(out, "  %s\n", p)
+	}
+	return nil
+}
+
+// bundleImageRefs returns the qualified references of the images that the given manifests require, sorted and
+// without duplicates. When withTelepresence is set, the traffic-agent image that the traffic-manager injects, and
+// the client image that "telepresence connect --docker" uses, are included.
+func bundleImageRefs(ctx context.Context, chrt *chart.Chart, vals map[string]any, manifests string, withTelepresence bool) ([]string, error) {
+	images, err := manifestImages(manifests)
+	if err != nil {
+		return nil, err
+	}
+	if withTelepresence {
+		cvs, err := chartutil.CoalesceValues(chrt, vals)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, agentImage(cvs, chrt.AppVersion()), docker.ClientImage(ctx))
+	}
+	for i, img := range images {
+		if images[i], err = imageref.Qualify(img); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(images)
+	return slices.Compact(images), nil
+}
+
+// manifestImages returns the images of all containers of the given manifests.
+func manifestImages(manifests string) ([]string, error) {
+	var images []string
+	var collect func(any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, e := range v {
+				if cs, ok := e.([]any); ok && (k == "containers" || k == "initContainers") {
+					for _, c := range cs {
+						if cm, ok := c.(map[string]any); ok {
+							if img, ok := cm["image"].(string); ok && img != "" {
+								images = append(images, img)
+							}
+						}
+					}
+				}
+				collect(e)
+			}
+		case []any:
+			for _, e := range v {
+				collect(e)
+			}
+		}
+	}
+	for _, doc := range strings.Split(manifests, "\n---\n") {
+		var obj map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("unable to parse rendered manifest: %w", err)
+		}
+		collect(obj)
+	}
+	return images, nil
+}
+
+// agentImage returns the traffic-agent image that the traffic-manager injects, given the coalesced chart values.
+// The legacy agentInjector.agentImage values take precedence over the agent.image values, and the registry and
+// tag default to those of the traffic-manager image.
+func agentImage(vals chartutil.Values, appVersion string) string {
+	value := func(paths ...string) string {
+		for _, path := range paths {
+			if v, err := vals.PathValue(path); err == nil {
+				if s, ok := v.(string); ok && s != "" {
+					return s
+				}
+			}
+		}
+		return ""
+	}
+	registry := value("agentInjector.agentImage.registry", "agent.image.registry", "image.registry")
+	name := value("agentInjector.agentImage.name", "agent.image.name")
+	if name == "" {
+		name = "tel2"
+	}
+	tag := value("agentInjector.agentImage.tag", "agent.image.tag", "image.tag")
+	if tag == "" {
+		tag = appVersion
+	}
+	return registry + "/" + name + ":" + tag
+}
@@ -0,0 +1,40 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestTemplateImages(t *testing.T) {
+	chrt, err := loadCoreChart("2.21.0")
+	require.NoError(t, err)
+
+	render := func(vals map[string]any) (string, chartutil.Values) {
+		install := action.NewInstall(&action.Configuration{})
+		install.DryRun = true
+		install.ClientOnly = true
+		install.ReleaseName = trafficManagerReleaseName
+		install.Namespace = "ambassador"
+		rel, err := install.Run(chrt, vals)
+		require.NoError(t, err)
+		cvs, err := chartutil.CoalesceValues(chrt, vals)
+		require.NoError(t, err)
+		return strings.Join(manifestLines(rel), ""), cvs
+	}
+
+	manifests, cvs := render(map[string]any{"image": map[string]any{"registry": "registry.example.com/tp"}})
+	images, err := manifestImages(manifests)
+	require.NoError(t, err)
+	assert.Contains(t, images, "registry.example.com/tp/tel2:2.21.0")
+	assert.Equal(t, "registry.example.com/tp/tel2:2.21.0", agentImage(cvs, chrt.AppVersion()))
+
+	_, cvs = render(map[string]any{
+		"agent": map[string]any{"image": map[string]any{"registry": "agents.example.com", "name": "agent", "tag": "1.0"}},
+	})
+	assert.Equal(t, "agents.example.com/agent:1.0", agentImage(cvs, chrt.AppVersion()))
+}
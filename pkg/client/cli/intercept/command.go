@@ -1,45 +1,95 @@
 package intercept
 
 import (
+	"path"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 type Command struct {
 	Name           string // Command[0] || `${Command[0]}-${--namespace}` // which depends on a combinationof --workload and --namespace
 	AgentName      string // --workload || Command[0] // only valid if !localOnly
+	WorkloadKind   string // "Pod" when the workload is given as pod/<name>, otherwise empty
 	Port           string // --port
 	ServiceName    string // --service
 	ContainerName  string // --container
+	PodSelector    string // --pod-selector
+	PodOrdinals    []int  // --pod-ordinal
+	ReplicaSet     string // --replicaset
+	Revision       int64  // --revision
 	Address        string // --address
 	LocalMountPort uint16 // --local-mount-port
 
 	Replace bool // whether --replace was passed
 
+	TerminateTLS bool // --terminate-tls
+
+	HTTPHeaders   []string // --http-header
+	HTTPBodyJSON  []string // --http-body-json
+	HTTPBodyXPath []string // --http-body-xpath
+	HTTPBodyLimit string   // --http-body-limit
+
+	InjectLatency   time.Duration // --inject-latency
+	InjectErrorRate float64       // --inject-error-rate
+	BandwidthLimit  string        // --bandwidth-limit
+
+	IncludeSecrets bool // --include-secrets
+
+	Fuse client.FuseOptions // --mount-attr-timeout, --mount-entry-timeout, --mount-kernel-cache, --mount-option
+
+	EnvExclude []string          // --env-exclude
+	EnvRename  map[string]string // --env-rename
+	EnvPrefix  string            // --env-prefix
+
 	EnvFile   string // --env-file
 	EnvSyntax EnvironmentSyntax
 	EnvJSON   string   // --env-json
 	Mount     string   // --mount // "true", "false", or desired mount point // only valid if !localOnly
+	MountMode string   // --mount-mode
 	MountSet  bool     // whether --mount was passed
 	ToPod     []string // --to-pod
 
-	DockerRun          bool     // --docker-run
-	DockerBuild        string   // --docker-build DIR | URL
-	DockerBuildOptions []string // --docker-build-opt key=value, // Optional flag to docker build can be repeated (but not comma separated)
-	DockerDebug        string   // --docker-debug DIR | URL
-	DockerMount        string   // --docker-mount // where to mount in a docker container. Defaults to mount unless mount is "true" or "false".
-	Cmdline            []string // Command[1:]
+	MountReadOnly      []string               // --mount-read-only
+	MountReadOnlyRules remotefs.ReadOnlyRules // parsed from MountReadOnly
+
+	DockerRun          bool          // --docker-run
+	DockerBuild        string        // --docker-build DIR | URL
+	DockerBuildOptions []string      // --docker-build-opt key=value, // Optional flag to docker build can be repeated (but not comma separated)
+	DockerBuildSecrets []string      // --docker-build-secret
+	DockerCacheFrom    []string      // --docker-build-cache-from
+	DockerCacheTo      []string      // --docker-build-cache-to
+	DockerDebug        string        // --docker-debug DIR | URL
+	DockerMount        string        // --docker-mount // where to mount in a docker container. Defaults to mount unless mount is "true" or "false".
+	DockerGPUs         string        // --docker-gpus
+	DockerDevices      []string      // --docker-device
+	DockerPrivileged   bool          // --docker-privileged
+	DockerAttach       string        // --docker-attach
+	Cmdline            []string      // Command[1:]
+	Restart            RestartPolicy // --restart
+	Watch              []string      // --watch
+	AwaitHandlerExit   bool          // --await-handler-exit
+	HandOff            bool          // --hand-off
+	DryRun             bool          // --dry-run
+	WorkingDir         string        // working directory of the command after --. Only set from group specs
 
 	Mechanism       string // --mechanism tcp
 	MechanismArgs   []string
@@ -52,7 +102,7 @@ type Command struct {
 
 func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet := cmd.Flags()
-	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet) to intercept, if different from <name>")
+	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet) to intercept, if different from <name>. Use pod/<name> to intercept a bare pod")
 	flagSet.StringVarP(&a.Port, "port", "p", "", ``+
 		`Local port to forward to. If intercepting a service with multiple ports, `+
 		`use <local port>:<svcPortIdentifier>, where the identifier is the port name or port number. `+
@@ -77,10 +127,29 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 
 	flagSet.StringVarP(&a.EnvJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
 
+	a.AddEnvRuleFlags(flagSet)
+
+	flagSet.BoolVar(&a.IncludeSecrets, "include-secrets", false, ``+
+		`Include environment variables that the intercepted container gets from secrets. Requires permission to get `+
+		`secrets in the namespace of the intercept`)
+
 	flagSet.StringVar(&a.Mount, "mount", "true", ``+
 		`The absolute path for the root directory where volumes will be mounted, $TELEPRESENCE_ROOT. Use "true" to `+
 		`have Telepresence pick a random mount point (default). Use "false" to disable filesystem mounting entirely.`)
 
+	flagSet.StringVar(&a.MountMode, "mount-mode", remotefs.MountModeFuse, ``+
+		`How the remote volumes are made available at the mount point. One of "fuse", "sync", "sync-pull", or "webdav". `+
+		`The sync modes mirror the volumes to a local directory instead of mounting them, and don't need a FUSE `+
+		`implementation. "sync" syncs changes in both directions, "sync-pull" only syncs remote changes. "webdav" mounts `+
+		`the volumes using the native WebDAV client of macOS or Windows`)
+
+	AddFuseFlags(flagSet, &a.Fuse)
+
+	flagSet.StringSliceVar(&a.MountReadOnly, "mount-read-only", nil, ``+
+		`Make the remote volumes read-only. Use <path>=<bool> rules, e.g. --mount-read-only /=true,/data=false, to `+
+		`decide per path, relative to the mount point, where the longest matching path wins`)
+	flagSet.Lookup("mount-read-only").NoOptDefVal = "true"
+
 	flagSet.StringSliceVar(&a.ToPod, "to-pod", []string{}, ``+
 		`An additional port to forward from the intercepted pod, will be made available at localhost:PORT `+
 		`Use this to, for example, access proxy/helper sidecars in the intercepted pod. The default protocol is TCP. `+
@@ -100,15 +169,62 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.StringArrayVar(&a.DockerBuildOptions, "docker-build-opt", nil,
 		`Option to docker-build in the form key=value, e.g. --docker-build-opt tag=mytag. Can be repeated`)
 
+	flagSet.StringArrayVar(&a.DockerBuildSecrets, "docker-build-secret", nil, ``+
+		`A secret that the Dockerfile of --docker-build or --docker-debug can mount using RUN --mount=type=secret, `+
+		`e.g. id=npmrc,src=$HOME/.npmrc. Can be repeated`)
+
+	flagSet.StringArrayVar(&a.DockerCacheFrom, "docker-build-cache-from", nil, ``+
+		`An external cache source for --docker-build or --docker-debug, e.g. type=registry,ref=registry.example.com/app:cache. `+
+		`Can be repeated`)
+
+	flagSet.StringArrayVar(&a.DockerCacheTo, "docker-build-cache-to", nil, ``+
+		`A cache export destination for --docker-build or --docker-debug, e.g. type=local,dest=.cache. Can be repeated`)
+
 	flagSet.StringVar(&a.DockerMount, "docker-mount", "", ``+
 		`The volume mount point in docker. Defaults to same as "--mount"`)
 
+	flagSet.StringVar(&a.DockerGPUs, "docker-gpus", "", ``+
+		`GPUs to add to the container of --docker-run, --docker-build, or --docker-debug, passed to 'docker run --gpus', `+
+		`e.g. "all" or "device=0,1"`)
+
+	flagSet.StringArrayVar(&a.DockerDevices, "docker-device", nil, ``+
+		`A host device to add to the container of --docker-run, --docker-build, or --docker-debug, passed to `+
+		`'docker run --device', e.g. /dev/dri or nvidia.com/gpu=all. Can be repeated`)
+
+	flagSet.BoolVar(&a.DockerPrivileged, "docker-privileged", false, ``+
+		`Give extended privileges, including access to all host devices, to the container of --docker-run, `+
+		`--docker-build, or --docker-debug`)
+
+	flagSet.StringVar(&a.DockerAttach, "docker-attach", "", ``+
+		`Name or ID of a running container, e.g. one started by docker compose, that becomes the intercept handler. `+
+		`The intercepted traffic is routed to the container's port given by --port, and the container is stopped when `+
+		`the intercept ends`)
+
 	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
 
 	flagSet.StringVar(&a.Mechanism, "mechanism", "tcp", "Which extension `mechanism` to use")
 
 	flagSet.StringVar(&a.WaitMessage, "wait-message", "", "Message to print when intercept handler has started")
 
+	flagSet.Var(&a.Restart, "restart", ``+
+		`Restart policy for the intercept handler started by the command after --. One of `+RestartPolicyUsage()+`. `+
+		`Restarts are delayed using an exponential backoff, and the handler is never restarted once the intercept has ended`)
+
+	flagSet.StringSliceVar(&a.Watch, "watch", nil, ``+
+		`Files or directories to watch for changes. The intercept handler started by the command after -- is restarted `+
+		`when they change. With --docker-build or --docker-debug, the image is rebuilt before the container is replaced`)
+
+	flagSet.BoolVar(&a.AwaitHandlerExit, "await-handler-exit", false, ``+
+		`Wait for the intercept handler to terminate, remove the intercept, and exit with the exit code of the handler`)
+
+	flagSet.BoolVar(&a.HandOff, "hand-off", false, ``+
+		`Hand off the intercepted traffic that reaches a user daemon on another host to the local port on this host. `+
+		`Runs until the command after -- exits, or until interrupted when no command is given`)
+
+	flagSet.BoolVar(&a.DryRun, "dry-run", false, ``+
+		`Show what container, port, and service would be intercepted, whether the pods of the workload would be `+
+		`replaced, and the current intercepts that would conflict with the intercept, without creating it`)
+
 	flagSet.BoolVar(&a.DetailedOutput, "detailed-output", false,
 		`Provide very detailed info about the intercept when used together with --output=json or --output=yaml'`)
 
@@ -118,13 +234,76 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.BoolVarP(&a.Replace, "replace", "", false,
 		`Indicates if the traffic-agent should replace application containers in workload pods. `+
 			`The default behavior is for the agent sidecar to be installed alongside existing containers.`)
+
+	flagSet.BoolVar(&a.TerminateTLS, "terminate-tls", false, ``+
+		`Let the traffic-agent terminate TLS, including mutual TLS, and forward plaintext HTTP/1.x requests with the identity `+
+		`of the client certificate in the x-forwarded-client-cert header. Requires that the workload is annotated with `+
+		agentconfig.TerminatingTLSSecretAnnotation)
+
+	flagSet.StringVar(&a.PodSelector, "pod-selector", "", ``+
+		`Only intercept the pods of the workload that this label selector selects, e.g. tier=canary. The traffic-agents `+
+		`of other pods continue to route all traffic to their containers`)
+
+	flagSet.IntSliceVar(&a.PodOrdinals, "pod-ordinal", nil, ``+
+		`Only intercept the StatefulSet pod with this ordinal, e.g. 0 for the pod my-set-0. Can be repeated`)
+
+	flagSet.StringVar(&a.ReplicaSet, "replicaset", "", ``+
+		`Only intercept the pods of this ReplicaSet of a Deployment or Argo Rollout, e.g. the canary during a rollout. `+
+		`The traffic-agents of the pods of other ReplicaSets continue to route all traffic to their containers`)
+
+	flagSet.Int64Var(&a.Revision, "revision", 0, ``+
+		`Only intercept the pods of the ReplicaSet with this revision of a Deployment or Argo Rollout, e.g. the canary `+
+		`during a rollout. The traffic-agents of the pods of other revisions continue to route all traffic to their containers`)
+
+	flagSet.StringArrayVar(&a.HTTPHeaders, "http-header", nil, ``+
+		`Only route the HTTP requests that have a header with this name and value to the intercept handler, `+
+		`e.g. x-dev=alice. The value may be a regular expression. All other requests are routed to the intercepted `+
+		`container. Use :path-equal:, :path-prefix:, or :path-regex: as name to also match the path. Connections `+
+		`that the traffic-agent doesn't detect as HTTP/1.x or HTTP/2 are routed to the intercept handler in full. Can be repeated`)
+
+	flagSet.StringArrayVar(&a.HTTPBodyJSON, "http-body-json", nil, ``+
+		`Only route the HTTP requests with a JSON body where this field equals this value to the intercept handler, `+
+		`e.g. order.items.0.sku=A-1. Non-string fields are compared in their JSON form, e.g. order.express=true. `+
+		`Can be repeated, but not combined with --http-body-xpath`)
+
+	flagSet.StringArrayVar(&a.HTTPBodyXPath, "http-body-xpath", nil, ``+
+		`Only route the HTTP requests with an XML body where this path selects this value to the intercept handler, `+
+		`e.g. //order/type=created or /order/@type=express. Supports absolute paths, paths starting with //, `+
+		`the * wildcard, and a final @attribute. Can be repeated, but not combined with --http-body-json`)
+
+	flagSet.StringVar(&a.HTTPBodyLimit, "http-body-limit", "", ``+
+		`The max size of a request body that the traffic-agent reads to match --http-body-json or --http-body-xpath, `+
+		`e.g. 1Mi. Requests with larger bodies are routed to the intercepted container. Defaults to 64Ki`)
+
+	flagSet.DurationVar(&a.InjectLatency, "inject-latency", 0, ``+
+		`Let the traffic-agent delay each HTTP/1.x request that is routed to the intercept handler by this duration`)
+
+	flagSet.Float64Var(&a.InjectErrorRate, "inject-error-rate", 0, ``+
+		`Let the traffic-agent fail this percentage (0-100) of the HTTP/1.x requests that are routed to the intercept `+
+		`handler with a 503 Service Unavailable`)
+
+	flagSet.StringVar(&a.BandwidthLimit, "bandwidth-limit", "", ``+
+		`Let the traffic-agent limit the bytes per second that are routed in each direction between the intercepted `+
+		`port and the intercept handler, e.g. 256Ki`)
 }
 
 func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	if len(positional) > 1 && cmd.Flags().ArgsLenAtDash() != 1 {
 		return errcat.User.New("commands to be run with intercept must come after options")
 	}
-	a.Name = positional[0]
+	var err error
+	if a.WorkloadKind, a.Name, err = ParseWorkloadName(positional[0]); err != nil {
+		return err
+	}
+	if a.AgentName != "" {
+		var kind string
+		if kind, a.AgentName, err = ParseWorkloadName(a.AgentName); err != nil {
+			return err
+		}
+		if kind != "" {
+			a.WorkloadKind = kind
+		}
+	}
 	a.Cmdline = positional[1:]
 	a.FormattedOutput = output.WantsFormatted(cmd)
 
@@ -140,6 +319,37 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 		a.Port = strconv.Itoa(client.GetConfig(cmd.Context()).Intercept().DefaultPort)
 	}
 	a.MountSet = cmd.Flag("mount").Changed
+	switch a.MountMode {
+	case remotefs.MountModeFuse, remotefs.MountModeSync, remotefs.MountModeSyncPull:
+	case remotefs.MountModeWebDAV:
+		if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+			return errcat.User.New("--mount-mode webdav is only supported on macOS and Windows")
+		}
+	default:
+		return errcat.User.Newf("invalid --mount-mode %q, must be one of %q, %q, %q, or %q",
+			a.MountMode, remotefs.MountModeFuse, remotefs.MountModeSync, remotefs.MountModeSyncPull, remotefs.MountModeWebDAV)
+	}
+	if a.MountMode != remotefs.MountModeFuse {
+		if a.LocalMountPort > 0 {
+			return errcat.User.Newf("--local-mount-port cannot be used with --mount-mode %s", a.MountMode)
+		}
+		if !a.Fuse.IsZero() {
+			return errcat.User.Newf("FUSE options cannot be used with --mount-mode %s", a.MountMode)
+		}
+	}
+	rules, err := remotefs.ParseReadOnlyRules(a.MountReadOnly)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	if len(rules) > 0 {
+		if a.LocalMountPort > 0 {
+			return errcat.User.New("--mount-read-only cannot be used with --local-mount-port")
+		}
+		if a.MountMode == remotefs.MountModeWebDAV {
+			return errcat.User.Newf("--mount-read-only cannot be used with --mount-mode %s", a.MountMode)
+		}
+	}
+	a.MountReadOnlyRules = rules
 	drCount := 0
 	if a.DockerRun {
 		drCount++
@@ -154,6 +364,75 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 		return errcat.User.New("only one of --docker-run, --docker-build, or --docker-debug can be used")
 	}
 	a.DockerRun = drCount == 1
+	if a.DockerAttach != "" && (a.DockerRun || len(a.Cmdline) > 0) {
+		return errcat.User.New("--docker-attach cannot be used with --docker-run, --docker-build, --docker-debug, or a command after --")
+	}
+	if !a.DockerRun && a.hasDockerDevices() {
+		return errcat.User.New("--docker-gpus, --docker-device, and --docker-privileged require --docker-run, --docker-build, or --docker-debug")
+	}
+	if a.DockerBuild == "" && a.DockerDebug == "" && a.hasDockerBuildExtras() {
+		return errcat.User.New("--docker-build-secret, --docker-build-cache-from, and --docker-build-cache-to require --docker-build or --docker-debug")
+	}
+	if a.Restart.Mode != RestartNever && len(a.Cmdline) == 0 {
+		return errcat.User.New("--restart can only be used when a command is given after --")
+	}
+	if len(a.Watch) > 0 && len(a.Cmdline) == 0 && a.DockerBuild == "" && a.DockerDebug == "" {
+		return errcat.User.New("--watch can only be used when a command is given after --")
+	}
+	if a.AwaitHandlerExit && len(a.Cmdline) == 0 && !a.DockerRun && a.DockerAttach == "" {
+		return errcat.User.New("--await-handler-exit requires an intercept handler, i.e. a command after --, --docker-run, or --docker-attach")
+	}
+	if a.DryRun {
+		if len(a.Cmdline) > 0 || a.DockerRun || a.DockerAttach != "" || a.HandOff {
+			return errcat.User.New("--dry-run cannot be used with --docker-run, --docker-build, --docker-debug, --docker-attach, --hand-off, or a command after --")
+		}
+		// A dry run doesn't mount anything.
+		a.Mount = "false"
+		a.MountSet = true
+		a.LocalMountPort = 0
+	}
+	if a.InjectLatency < 0 {
+		return errcat.User.New("--inject-latency must not be negative")
+	}
+	if a.InjectErrorRate < 0 || a.InjectErrorRate > 100 {
+		return errcat.User.New("--inject-error-rate must be a percentage between 0 and 100")
+	}
+	if _, err := a.bandwidthLimit(); err != nil {
+		return err
+	}
+	if _, err := labels.Parse(a.PodSelector); err != nil {
+		return errcat.User.Newf("invalid --pod-selector %q: %w", a.PodSelector, err)
+	}
+	for _, o := range a.PodOrdinals {
+		if o < 0 {
+			return errcat.User.Newf("invalid --pod-ordinal %d, must not be negative", o)
+		}
+	}
+	if a.Revision < 0 {
+		return errcat.User.Newf("invalid --revision %d, must not be negative", a.Revision)
+	}
+	if (a.ReplicaSet != "" || a.Revision != 0) && a.WorkloadKind == k8sworkload.PodKind {
+		return errcat.User.New("--replicaset and --revision cannot be used when intercepting a bare pod")
+	}
+	if _, err := a.httpHeaders(); err != nil {
+		return err
+	}
+	if _, _, err := a.httpBodyFilters(); err != nil {
+		return err
+	}
+	if _, err := a.httpBodyLimit(); err != nil {
+		return err
+	}
+	for _, p := range a.EnvExclude {
+		if _, err := path.Match(p, ""); err != nil {
+			return errcat.User.Newf("invalid --env-exclude pattern %q: %w", p, err)
+		}
+	}
+	for k, v := range a.EnvRename {
+		if k == "" || v == "" {
+			return errcat.User.Newf("invalid --env-rename %s=%s, must be in the form OLD=NEW", k, v)
+		}
+	}
 	if a.DockerRun {
 		if err := a.ValidateDockerArgs(); err != nil {
 			return err
@@ -162,6 +441,86 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	return nil
 }
 
+// podOrdinals returns the --pod-ordinal values, or nil when the intercept isn't limited to pods with given ordinals.
+func (a *Command) podOrdinals() []int32 {
+	if len(a.PodOrdinals) == 0 {
+		return nil
+	}
+	os := make([]int32, len(a.PodOrdinals))
+	for i, o := range a.PodOrdinals {
+		os[i] = int32(o)
+	}
+	return os
+}
+
+// bandwidthLimit returns the --bandwidth-limit in bytes per second, or zero when the bandwidth is unlimited.
+func (a *Command) bandwidthLimit() (int64, error) {
+	if a.BandwidthLimit == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(a.BandwidthLimit)
+	if err != nil || q.Sign() <= 0 {
+		return 0, errcat.User.Newf("invalid --bandwidth-limit %q, must be a positive number of bytes per second, e.g. 256Ki", a.BandwidthLimit)
+	}
+	return q.Value(), nil
+}
+
+// httpHeaders returns the --http-header filters as a map, or nil when there are no filters.
+func (a *Command) httpHeaders() (map[string]string, error) {
+	hs, err := filterMap("http-header", "NAME", a.HTTPHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := matcher.NewRequestFromMap(hs); err != nil {
+		return nil, errcat.User.Newf("invalid --http-header: %w", err)
+	}
+	return hs, nil
+}
+
+// httpBodyFilters returns the --http-body-json and --http-body-xpath filters as maps, or nil when there are no filters.
+func (a *Command) httpBodyFilters() (map[string]string, map[string]string, error) {
+	jf, err := filterMap("http-body-json", "FIELD", a.HTTPBodyJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	xp, err := filterMap("http-body-xpath", "PATH", a.HTTPBodyXPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := matcher.NewBody(jf, xp); err != nil {
+		return nil, nil, errcat.User.Newf("invalid --http-body-json or --http-body-xpath: %w", err)
+	}
+	return jf, xp, nil
+}
+
+// httpBodyLimit returns the --http-body-limit in bytes, or zero when the traffic-agent's default is used.
+func (a *Command) httpBodyLimit() (int64, error) {
+	if a.HTTPBodyLimit == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(a.HTTPBodyLimit)
+	if err != nil || q.Sign() <= 0 {
+		return 0, errcat.User.Newf("invalid --http-body-limit %q, must be a positive number of bytes, e.g. 1Mi", a.HTTPBodyLimit)
+	}
+	return q.Value(), nil
+}
+
+// filterMap returns the KEY=VALUE pairs of the given flag as a map, or nil when there are none.
+func filterMap(flag, key string, pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || k == "" {
+			return nil, errcat.User.Newf("invalid --%s %q, must be in the form %s=VALUE", flag, p, key)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
 func (a *Command) Run(cmd *cobra.Command, positional []string) error {
 	if err := a.Validate(cmd, positional); err != nil {
 		return err
@@ -170,6 +529,10 @@ func (a *Command) Run(cmd *cobra.Command, positional []string) error {
 		return err
 	}
 	ctx := dos.WithStdio(cmd.Context(), cmd)
+	if output.WantsEvents(cmd) {
+		// Keep stdout clean for the lifecycle events.
+		ctx = dos.WithStdout(ctx, cmd.ErrOrStderr())
+	}
 	_, err := NewState(a).Run(ctx)
 	return err
 }
@@ -183,6 +546,48 @@ func (a *Command) ValidateDockerArgs() error {
 	return nil
 }
 
+// dockerBuildArgs returns the options to pass to docker build.
+func (a *Command) dockerBuildArgs() []string {
+	var args []string
+	for _, opt := range a.DockerBuildOptions {
+		args = append(args, "--"+opt)
+	}
+	for _, v := range a.DockerBuildSecrets {
+		args = append(args, "--secret", v)
+	}
+	for _, v := range a.DockerCacheFrom {
+		args = append(args, "--cache-from", v)
+	}
+	for _, v := range a.DockerCacheTo {
+		args = append(args, "--cache-to", v)
+	}
+	return args
+}
+
+// hasDockerBuildExtras returns true if build secrets or external caches are given for the image build.
+func (a *Command) hasDockerBuildExtras() bool {
+	return len(a.DockerBuildSecrets) > 0 || len(a.DockerCacheFrom) > 0 || len(a.DockerCacheTo) > 0
+}
+
+// hasDockerDevices returns true if the container of the intercept handler is given GPUs, devices, or privileges.
+func (a *Command) hasDockerDevices() bool {
+	return a.DockerGPUs != "" || len(a.DockerDevices) > 0 || a.DockerPrivileged
+}
+
+// ParseWorkloadName parses a workload name that may be prefixed with "pod/", which denotes a bare pod. It
+// returns the kind of the workload, which is empty unless the prefix was present, and the name without
+// the prefix.
+func ParseWorkloadName(name string) (kind, wlName string, err error) {
+	k, n, ok := strings.Cut(name, "/")
+	if !ok {
+		return "", name, nil
+	}
+	if k != "pod" || n == "" {
+		return "", "", errcat.User.Newf("invalid workload %q. Only pod/<name> can be used to declare the kind of a workload", name)
+	}
+	return k8sworkload.PodKind, n, nil
+}
+
 func (a *Command) ValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) > 0 {
 		// Not completing the name of the workload
@@ -0,0 +1,33 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkloadName(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		wlName string
+		err    string
+	}{
+		{name: "echo", wlName: "echo"},
+		{name: "pod/scratch", kind: "Pod", wlName: "scratch"},
+		{name: "pod/", err: `invalid workload "pod/"`},
+		{name: "deployment/echo", err: `invalid workload "deployment/echo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, wlName, err := ParseWorkloadName(tt.name)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.wlName, wlName)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package intercept
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ComposeOptions controls the docker compose fragment that is created by ComposeOverlay.
+type ComposeOptions struct {
+	// Service is the name of the compose service. Defaults to the name of the intercept.
+	Service string
+
+	// Image is the image of the service. Exclusive with Build.
+	Image string
+
+	// Build is the build context of the service. Exclusive with Image.
+	Build string
+
+	// EnvFile is the path of the file that contains the environment of the intercept.
+	EnvFile string
+
+	// DaemonContainer is the name of the daemon container, or empty when the daemon runs on the host.
+	DaemonContainer string
+
+	// ContainerPort is the port that the handler listens to in its container. Defaults to the target port of the
+	// intercept.
+	ContainerPort uint16
+}
+
+type composeFile struct {
+	Services map[string]*composeService `json:"services"`
+	Networks map[string]*composeNetwork `json:"networks,omitempty"`
+}
+
+type composeService struct {
+	Image       string                            `json:"image,omitempty"`
+	Build       string                            `json:"build,omitempty"`
+	EnvFile     []string                          `json:"env_file,omitempty"`
+	Volumes     []string                          `json:"volumes,omitempty"`
+	NetworkMode string                            `json:"network_mode,omitempty"`
+	Ports       []string                          `json:"ports,omitempty"`
+	DNSSearch   []string                          `json:"dns_search,omitempty"`
+	Networks    map[string]*composeServiceNetwork `json:"networks,omitempty"`
+}
+
+type composeServiceNetwork struct {
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+type composeNetwork struct {
+	Name string `json:"name"`
+}
+
+// ComposeOverlay returns a docker compose fragment with a service that acts as the handler of the given
+// intercept. The service loads the environment of the intercept from the env file, mounts the remote volumes, and
+// is connected to the network of the daemon container, or to the telepresence network when the daemon runs on the
+// host.
+func ComposeOverlay(ii *manager.InterceptInfo, opts *ComposeOptions) ([]byte, error) {
+	spec := ii.Spec
+	name := opts.Service
+	if name == "" {
+		name = spec.Name
+	}
+	svc := &composeService{
+		Image: opts.Image,
+		Build: opts.Build,
+	}
+	if opts.EnvFile != "" {
+		svc.EnvFile = []string{opts.EnvFile}
+	}
+	cf := &composeFile{Services: map[string]*composeService{name: svc}}
+
+	if opts.DaemonContainer != "" {
+		// The handler shares the network of the daemon container. The daemon bridges the remote volumes using
+		// the Docker volume plugin of the handler container that it starts, so they cannot be declared here.
+		svc.NetworkMode = "container:" + opts.DaemonContainer
+		return yaml.Marshal(cf)
+	}
+
+	if ii.ClientMountPoint != "" {
+		if tpMounts := ii.Environment["TELEPRESENCE_MOUNTS"]; tpMounts != "" {
+			// This is a Unix path, so we cannot use filepath.SplitList
+			for _, m := range strings.Split(tpMounts, ":") {
+				svc.Volumes = append(svc.Volumes, path.Join(filepath.ToSlash(ii.ClientMountPoint), m)+":"+m)
+			}
+		}
+	}
+	containerPort := opts.ContainerPort
+	if containerPort == 0 {
+		containerPort = uint16(spec.TargetPort)
+	}
+	svc.Ports = []string{strconv.Itoa(int(spec.TargetPort)) + ":" + strconv.Itoa(int(containerPort))}
+	svc.DNSSearch = []string{"tel2-search"}
+	svc.Networks = map[string]*composeServiceNetwork{"telepresence": {Aliases: networkAliases(spec)}}
+	cf.Networks = map[string]*composeNetwork{"telepresence": {Name: "telepresence"}}
+	return yaml.Marshal(cf)
+}
+
+// WriteComposeEnvFile writes the given environment to the given file using the syntax of a docker compose env
+// file.
+func WriteComposeEnvFile(file string, env map[string]string) (err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	w := bufio.NewWriter(f)
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		r, err := envSyntaxCompose.WriteEnv(k, env[k])
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintln(w, r); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
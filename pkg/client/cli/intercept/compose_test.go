@@ -0,0 +1,65 @@
+package intercept
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestComposeOverlay(t *testing.T) {
+	ii := &manager.InterceptInfo{
+		Spec: &manager.InterceptSpec{
+			Name:        "orders",
+			ServiceName: "orders",
+			Namespace:   "shop",
+			TargetPort:  8080,
+		},
+		ClientMountPoint: "/tmp/telfs-1",
+		Environment:      map[string]string{"TELEPRESENCE_MOUNTS": "/var/run/secrets/kubernetes.io:/data"},
+	}
+
+	data, err := ComposeOverlay(ii, &ComposeOptions{Image: "orders-dev", EnvFile: "/work/orders.env", ContainerPort: 80})
+	require.NoError(t, err)
+	assert.Equal(t, `networks:
+  telepresence:
+    name: telepresence
+services:
+  orders:
+    dns_search:
+    - tel2-search
+    env_file:
+    - /work/orders.env
+    image: orders-dev
+    networks:
+      telepresence:
+        aliases:
+        - orders
+        - orders.shop
+    ports:
+    - 8080:80
+    volumes:
+    - /tmp/telfs-1/var/run/secrets/kubernetes.io:/var/run/secrets/kubernetes.io
+    - /tmp/telfs-1/data:/data
+`, string(data))
+
+	data, err = ComposeOverlay(ii, &ComposeOptions{Service: "handler", Build: "/work/orders", DaemonContainer: "tp-default"})
+	require.NoError(t, err)
+	assert.Equal(t, `services:
+  handler:
+    build: /work/orders
+    network_mode: container:tp-default
+`, string(data))
+}
+
+func TestWriteComposeEnvFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "orders.env")
+	require.NoError(t, WriteComposeEnvFile(file, map[string]string{"B": "2", "A": "1"}))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\nB=2\n", string(data))
+}
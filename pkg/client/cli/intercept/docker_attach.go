@@ -0,0 +1,68 @@
+package intercept
+
+import (
+	"context"
+	"os/signal"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// attachTarget makes the container of --docker-attach the target of the intercept. The port parsed from --port
+// is the container's port.
+func (s *state) attachTarget(ctx context.Context, spec *manager.InterceptSpec) error {
+	ud := daemon.GetUserClient(ctx)
+	daemonName := ""
+	if ud.Containerized() {
+		daemonName = ud.DaemonID().ContainerName()
+	}
+	name, addr, err := docker.AttachTarget(docker.EnableClient(ctx), s.DockerAttach, daemonName, s.localPort)
+	if err != nil {
+		return errcat.User.Newf("unable to attach to container %s: %w", s.DockerAttach, err)
+	}
+	dlog.Debugf(ctx, "intercepted traffic is routed to container %s at %s", name, addr)
+	s.attached = name
+	spec.TargetHost = addr.Addr().String()
+	spec.TargetPort = int32(addr.Port())
+	return nil
+}
+
+// runAttached registers the container of --docker-attach as the intercept handler, and waits until it stops or
+// the command is interrupted.
+func (s *state) runAttached(ctx context.Context) error {
+	ctx = docker.EnableClient(ctx)
+	if _, err := s.addInterceptorToDaemon(ctx, nil, s.attached, 0); err != nil {
+		return err
+	}
+	if s.WaitMessage != "" {
+		ioutil.Println(dos.Stdout(ctx), s.WaitMessage)
+	}
+
+	// Ensure that the wait ends if the daemon quits
+	waitCtx, cancel := signal.NotifyContext(ctx, proc.SignalsToForward...)
+	defer cancel()
+	go func() {
+		if err := daemon.CancelWhenRmFromCache(waitCtx, cancel, daemon.GetUserClient(ctx).DaemonID().InfoFileName()); err != nil {
+			dlog.Error(ctx, err)
+		}
+	}()
+
+	code, err := docker.WaitContainer(waitCtx, s.attached)
+	if waitCtx.Err() != nil {
+		// The intercept ends, and the daemon stops the container, when the command is interrupted.
+		return nil
+	}
+	if err != nil {
+		return errcat.NoDaemonLogs.New(err)
+	}
+	if code != 0 {
+		return errcat.NoDaemonLogs.Newf("container %s %w", s.attached, proc.ExitCodeError(code))
+	}
+	return nil
+}
@@ -12,11 +12,13 @@ import (
 
 	"github.com/datawire/dlib/dexec"
 	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/flags"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/spinner"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
@@ -48,24 +50,41 @@ func (s *state) prepareDockerRun(ctx context.Context) error {
 			`the string "IMAGE", acting as a placeholder for image ID, must be included after "--" when using "--docker-build", so ` +
 			`that flags intended for docker run can be distinguished from the command and arguments intended for the container.`)
 	}
-	opts := make([]string, len(s.DockerBuildOptions))
-	for i, opt := range s.DockerBuildOptions {
-		opts[i] = "--" + opt
+	if idx < 0 {
+		s.Cmdline = []string{"IMAGE"}
+		idx = 0
+	}
+	s.imageIdx = idx
+	return s.buildImage(ctx)
+}
+
+// buildImage builds the image of --docker-build or --docker-debug, and assigns its ID to the image argument
+// of the command line.
+func (s *state) buildImage(ctx context.Context) error {
+	buildContext := s.DockerBuild
+	if buildContext == "" {
+		buildContext = s.DockerDebug
 	}
 	spin := spinner.New(ctx, "building docker image")
-	imageID, err := docker.BuildImage(ctx, buildContext, opts)
+	imageID, err := docker.BuildImage(ctx, buildContext, s.dockerBuildArgs())
 	if err != nil {
 		return spin.Error(err)
 	}
-	if idx < 0 {
-		s.Cmdline = []string{imageID}
-	} else {
-		s.Cmdline[idx] = imageID
-	}
+	s.Cmdline[s.imageIdx] = imageID
 	spin.DoneMsg("image built successfully")
 	return nil
 }
 
+// rebuildImage rebuilds the image of --docker-build or --docker-debug after a source change. It returns false,
+// so that the running container is kept, when the build fails.
+func (s *state) rebuildImage(ctx context.Context) bool {
+	if err := s.buildImage(ctx); err != nil {
+		ioutil.Printf(dos.Stderr(ctx), "Rebuild failed, keeping the current intercept handler: %v\n", err)
+		return false
+	}
+	return true
+}
+
 var dockerBoolFlags = map[string]bool{ //nolint:gochecknoglobals // this is a constant
 	"--detach":           true,
 	"--init":             true,
@@ -107,10 +126,11 @@ func firstDockerArg(args []string) (string, int) {
 }
 
 type dockerRun struct {
-	cmd     *dexec.Cmd
-	err     error
-	name    string
-	volumes []string
+	cmd       *dexec.Cmd
+	err       error
+	name      string
+	volumes   []string
+	signalled bool // true when the container was stopped because of a signal or a cancelled context
 }
 
 func (dr *dockerRun) wait(ctx context.Context) error {
@@ -154,8 +174,9 @@ func (dr *dockerRun) wait(ctx context.Context) error {
 	}()
 
 	err := dr.cmd.Wait()
+	dr.signalled = signalled.Load()
 	if err != nil {
-		if signalled.Load() {
+		if dr.signalled {
 			// Errors caused by context or signal termination doesn't count.
 			err = nil
 		} else {
@@ -177,6 +198,33 @@ func (s *state) getContainerName(args []string) (string, []string, error) {
 	return name, args, nil
 }
 
+// networkAliases returns the names that other containers use to reach the intercept handler, i.e. the name of the
+// intercepted service, with and without its namespace. The workload name is used when there's no service.
+func networkAliases(spec *manager.InterceptSpec) []string {
+	name := spec.ServiceName
+	if name == "" {
+		name = spec.Agent
+	}
+	if name == "" {
+		return nil
+	}
+	aliases := []string{name}
+	if spec.Namespace != "" {
+		aliases = append(aliases, name+"."+spec.Namespace)
+	}
+	return aliases
+}
+
+// userDefinedNetwork returns true if the given value of the docker run --network flag is a user-defined network,
+// which is required for network aliases.
+func userDefinedNetwork(network string) bool {
+	switch network {
+	case "", "default", "bridge", "host", "none":
+		return false
+	}
+	return !strings.HasPrefix(network, "container:")
+}
+
 func (s *state) startInDocker(ctx context.Context, name, envFile string, args []string) *dockerRun {
 	ourArgs := []string{
 		"run",
@@ -187,6 +235,15 @@ func (s *state) startInDocker(ctx context.Context, name, envFile string, args []
 	if s.DockerDebug != "" {
 		ourArgs = append(ourArgs, "--security-opt", "apparmor=unconfined", "--cap-add", "SYS_PTRACE")
 	}
+	if s.DockerGPUs != "" {
+		ourArgs = append(ourArgs, "--gpus", s.DockerGPUs)
+	}
+	for _, d := range s.DockerDevices {
+		ourArgs = append(ourArgs, "--device", d)
+	}
+	if s.DockerPrivileged {
+		ourArgs = append(ourArgs, "--privileged")
+	}
 
 	// "--rm" is mandatory when using --docker-run, because without it, the name cannot be reused and
 	// the volumes cannot be removed.
@@ -202,6 +259,16 @@ func (s *state) startInDocker(ctx context.Context, name, envFile string, args []
 	ud := daemon.GetUserClient(ctx)
 	if !ud.Containerized() {
 		ourArgs = append(ourArgs, "--dns-search", "tel2-search")
+		network, err := flags.GetUnparsedValue(args, "--network")
+		if err != nil {
+			dr.err = err
+			return dr
+		}
+		if userDefinedNetwork(network) {
+			for _, a := range s.aliases {
+				ourArgs = append(ourArgs, "--network-alias", a)
+			}
+		}
 		if s.dockerPort != 0 {
 			ourArgs = append(ourArgs, "-p", fmt.Sprintf("%d:%d", s.localPort, s.dockerPort))
 		}
@@ -212,6 +279,9 @@ func (s *state) startInDocker(ctx context.Context, name, envFile string, args []
 			}
 		}
 		if dockerMount != "" {
+			if dr.err = docker.CheckSharedDir(ctx, s.mountPoint); dr.err != nil {
+				return dr
+			}
 			ourArgs = append(ourArgs, "-v", fmt.Sprintf("%s:%s", s.mountPoint, dockerMount))
 		}
 	} else {
@@ -224,21 +294,27 @@ func (s *state) startInDocker(ctx context.Context, name, envFile string, args []
 				pluginName, err := docker.EnsureVolumePlugin(ctx)
 				if err != nil {
 					ioutil.Printf(output.Err(ctx), "Remote mount disabled: %s\n", err)
-				}
-				container := s.env["TELEPRESENCE_CONTAINER"]
-				dlog.Infof(ctx, "Mounting %v from container %s", m.Mounts, container)
-				dr.volumes, dr.err = docker.StartVolumeMounts(ctx, pluginName, daemonName, container, m.Port, m.Mounts, nil)
-				if dr.err != nil {
-					return dr
-				}
-				for i, vol := range dr.volumes {
-					ourArgs = append(ourArgs, "-v", fmt.Sprintf("%s:%s", vol, m.Mounts[i]))
+				} else {
+					container := s.env["TELEPRESENCE_CONTAINER"]
+					dlog.Infof(ctx, "Mounting %v from container %s", m.Mounts, container)
+					dr.volumes, dr.err = docker.StartVolumeMounts(ctx, pluginName, daemonName, container, m.Port, m.Mounts, nil)
+					if dr.err != nil {
+						return dr
+					}
+					for i, vol := range dr.volumes {
+						ourArgs = append(ourArgs, "-v", fmt.Sprintf("%s:%s", vol, m.Mounts[i]))
+					}
 				}
 			}
 		}
 	}
 
+	rt, err := docker.GetRuntime(ctx)
+	if err != nil {
+		dr.err = err
+		return dr
+	}
 	args = append(ourArgs, args...)
-	dr.cmd, dr.err = proc.Start(context.WithoutCancel(ctx), nil, "docker", args...)
+	dr.cmd, dr.err = proc.Start(context.WithoutCancel(ctx), nil, rt.Name(), args...)
 	return dr
 }
@@ -0,0 +1,26 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestNetworkAliases(t *testing.T) {
+	assert.Equal(t, []string{"orders", "orders.shop"}, networkAliases(&manager.InterceptSpec{
+		ServiceName: "orders", Agent: "orders-v2", Namespace: "shop",
+	}))
+	assert.Equal(t, []string{"orders-v2", "orders-v2.shop"}, networkAliases(&manager.InterceptSpec{Agent: "orders-v2", Namespace: "shop"}))
+	assert.Equal(t, []string{"orders"}, networkAliases(&manager.InterceptSpec{ServiceName: "orders"}))
+	assert.Nil(t, networkAliases(&manager.InterceptSpec{}))
+}
+
+func TestUserDefinedNetwork(t *testing.T) {
+	assert.True(t, userDefinedNetwork("telepresence"))
+	assert.True(t, userDefinedNetwork("myapp_default"))
+	for _, n := range []string{"", "default", "bridge", "host", "none", "container:db"} {
+		assert.False(t, userDefinedNetwork(n), n)
+	}
+}
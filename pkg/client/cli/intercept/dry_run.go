@@ -0,0 +1,74 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// dryRun asks the traffic-manager how the intercept would be created, and prints what it would intercept,
+// whether the pods of the workload would be replaced, and the current intercepts that would conflict with it.
+// Nothing is created, injected, or mounted.
+func (s *state) dryRun(ctx context.Context) error {
+	ir, err := s.self.CreateRequest(ctx)
+	if err != nil {
+		return errcat.NoDaemonLogs.New(err)
+	}
+	ir.DryRun = true
+	r, err := daemon.GetUserClient(ctx).CreateIntercept(ctx, ir)
+	if err = Result(r, err); err != nil {
+		return fmt.Errorf("connector.CreateIntercept: %w", err)
+	}
+	pi := r.PreparedIntercept
+	if pi == nil {
+		return errors.New("the user daemon didn't perform a dry run")
+	}
+	if s.FormattedOutput {
+		output.Object(ctx, pi, false)
+	} else {
+		printDryRun(dos.Stdout(ctx), s.Replace, pi)
+	}
+	return nil
+}
+
+func printDryRun(out io.Writer, replace bool, pi *manager.PreparedIntercept) {
+	kvf := ioutil.DefaultKeyValueFormatter()
+	kvf.Add("Workload", fmt.Sprintf("%s %s.%s", pi.WorkloadKind, pi.WorkloadName, pi.Namespace))
+	kvf.Add("Container", pi.ContainerName)
+	kvf.Add("Container port", fmt.Sprintf("%d/%s", pi.ContainerPort, pi.Protocol))
+	switch {
+	case pi.ServiceName == "":
+		kvf.Add("Service", "none")
+	case pi.ServicePortName != "":
+		kvf.Add("Service", fmt.Sprintf("%s, port %s (%d)", pi.ServiceName, pi.ServicePortName, pi.ServicePort))
+	default:
+		kvf.Add("Service", fmt.Sprintf("%s, port %d", pi.ServiceName, pi.ServicePort))
+	}
+	if pi.ReplicaSet != "" {
+		kvf.Add("ReplicaSet", fmt.Sprintf("%s (revision %d)", pi.ReplicaSet, pi.Revision))
+	}
+	kvf.Add("Replace container", fmt.Sprintf("%t", replace))
+	if pi.PodRestartReason != "" {
+		kvf.Add("Pods restart", "yes, "+pi.PodRestartReason)
+	} else {
+		kvf.Add("Pods restart", "no")
+	}
+	if len(pi.Conflicts) == 0 {
+		kvf.Add("Conflicts", "none")
+	}
+	kvf.Println(out)
+	if len(pi.Conflicts) > 0 {
+		fmt.Fprintln(out, "\nConflicting intercepts of the same container port:")
+		for _, ii := range pi.Conflicts {
+			fmt.Fprintf(out, "    %-30s %-12s %s\n", ii.Spec.Name, ii.Disposition, ii.Spec.Client)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package intercept
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_printDryRun(t *testing.T) {
+	pi := &manager.PreparedIntercept{
+		Namespace:       "default",
+		WorkloadKind:    "Deployment",
+		WorkloadName:    "echo",
+		ContainerName:   "echo",
+		ContainerPort:   8080,
+		Protocol:        "TCP",
+		ServiceName:     "echo",
+		ServicePortName: "http",
+		ServicePort:     80,
+	}
+	sb := &strings.Builder{}
+	printDryRun(sb, false, pi)
+	assert.Equal(t, `Workload         : Deployment echo.default
+Container        : echo
+Container port   : 8080/TCP
+Service          : echo, port http (80)
+Replace container: false
+Pods restart     : no
+Conflicts        : none
+`, sb.String())
+
+	pi.ServicePortName = ""
+	pi.PodRestartReason = "the traffic-agent replaces container echo"
+	pi.Conflicts = []*manager.InterceptInfo{{
+		Spec:        &manager.InterceptSpec{Name: "echo", Client: "alice@laptop"},
+		Disposition: manager.InterceptDispositionType_ACTIVE,
+	}}
+	sb.Reset()
+	printDryRun(sb, true, pi)
+	assert.Equal(t, `Workload         : Deployment echo.default
+Container        : echo
+Container port   : 8080/TCP
+Service          : echo, port 80
+Replace container: true
+Pods restart     : yes, the traffic-agent replaces container echo
+
+Conflicting intercepts of the same container port:
+    echo                           ACTIVE       alice@laptop
+`, sb.String())
+}
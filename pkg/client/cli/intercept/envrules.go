@@ -0,0 +1,76 @@
+package intercept
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+// AddEnvRuleFlags adds the flags that exclude, rename, or prefix the variables of the intercept environment.
+func (a *Command) AddEnvRuleFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&a.EnvExclude, "env-exclude", nil, ``+
+		`Names of remote environment variables to exclude from the env-file and the environment of the intercept `+
+		`handler. Wildcards like KUBERNETES_* are allowed. Adds to the intercept.env.exclude config`)
+
+	flagSet.StringToStringVar(&a.EnvRename, "env-rename", nil, ``+
+		`Rename a remote environment variable, e.g. --env-rename PATH=REMOTE_PATH. Adds to the intercept.env.rename config`)
+
+	flagSet.StringVar(&a.EnvPrefix, "env-prefix", "", ``+
+		`Prefix to prepend to the names of all remote environment variables that aren't renamed. Overrides the `+
+		`intercept.env.prefix config`)
+}
+
+// envRules returns the rules from the intercept.env config, amended with the rules from the command flags.
+func (a *Command) envRules(ctx context.Context) *client.InterceptEnv {
+	cr := &client.GetConfig(ctx).Intercept().Env
+	r := &client.InterceptEnv{
+		Exclude: append(append([]string(nil), cr.Exclude...), a.EnvExclude...),
+		Rename:  make(map[string]string, len(cr.Rename)+len(a.EnvRename)),
+		Prefix:  cr.Prefix,
+	}
+	for k, v := range cr.Rename {
+		r.Rename[k] = v
+	}
+	for k, v := range a.EnvRename {
+		r.Rename[k] = v
+	}
+	if a.EnvPrefix != "" {
+		r.Prefix = a.EnvPrefix
+	}
+	return r
+}
+
+// applyEnvRules returns a new environment, where variables in the given env have been excluded, renamed, or
+// prefixed according to the given rules. Variables that Telepresence adds, i.e. those with the prefix
+// "TELEPRESENCE_", are not subject to the rules.
+func applyEnvRules(env map[string]string, rules *client.InterceptEnv) map[string]string {
+	result := make(map[string]string, len(env))
+	excluded := func(k string) bool {
+		for _, p := range rules.Exclude {
+			if m, err := path.Match(p, k); err == nil && m {
+				return true
+			}
+		}
+		return false
+	}
+	for k, v := range env {
+		if strings.HasPrefix(k, "TELEPRESENCE_") {
+			result[k] = v
+			continue
+		}
+		if excluded(k) {
+			continue
+		}
+		if rn, ok := rules.Rename[k]; ok {
+			k = rn
+		} else {
+			k = rules.Prefix + k
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package intercept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func Test_applyEnvRules(t *testing.T) {
+	env := map[string]string{
+		"PATH":                    "/usr/bin",
+		"HOSTNAME":                "echo-7d9f8",
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"KUBERNETES_SERVICE_PORT": "443",
+		"DB_HOST":                 "db",
+		"TELEPRESENCE_CONTAINER":  "echo",
+	}
+	tests := []struct {
+		name  string
+		rules client.InterceptEnv
+		want  map[string]string
+	}{
+		{
+			"no rules",
+			client.InterceptEnv{},
+			env,
+		},
+		{
+			"exclude",
+			client.InterceptEnv{Exclude: []string{"HOSTNAME", "KUBERNETES_*"}},
+			map[string]string{"PATH": "/usr/bin", "DB_HOST": "db", "TELEPRESENCE_CONTAINER": "echo"},
+		},
+		{
+			"rename and prefix",
+			client.InterceptEnv{
+				Exclude: []string{"KUBERNETES_*", "TELEPRESENCE_*"},
+				Rename:  map[string]string{"PATH": "REMOTE_PATH"},
+				Prefix:  "R_",
+			},
+			map[string]string{"REMOTE_PATH": "/usr/bin", "R_HOSTNAME": "echo-7d9f8", "R_DB_HOST": "db", "TELEPRESENCE_CONTAINER": "echo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, applyEnvRules(env, &tt.rules))
+		})
+	}
+}
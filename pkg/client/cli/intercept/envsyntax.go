@@ -2,9 +2,16 @@ package intercept
 
 import (
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
 	"github.com/telepresenceio/telepresence/v2/pkg/shellquote"
 )
 
@@ -20,6 +27,9 @@ const (
 	envSyntaxPS
 	envSyntaxPSExport
 	envSyntaxCmd
+	envSyntaxJSON
+	envSyntaxSecret
+	envSyntaxConfigMap
 )
 
 var envSyntaxNames = []string{ //nolint:gochecknoglobals // constant
@@ -32,10 +42,13 @@ var envSyntaxNames = []string{ //nolint:gochecknoglobals // constant
 	"ps",
 	"ps:export",
 	"cmd",
+	"json",
+	"secret",
+	"configmap",
 }
 
 func EnvSyntaxUsage() string {
-	return `"docker", "compose", "sh", "csh", "cmd", and "ps"; where "sh", "csh", and "ps" can be suffixed with ":export"`
+	return `"docker", "compose", "sh", "csh", "cmd", "ps", "json", "secret", and "configmap"; where "sh", "csh", and "ps" can be suffixed with ":export"`
 }
 
 // Set uses a pointer receiver intentionally, even though the internal type is int, because
@@ -50,7 +63,7 @@ func (e *EnvironmentSyntax) Set(n string) error {
 }
 
 func (e EnvironmentSyntax) String() string {
-	if e >= 0 && e <= envSyntaxCmd {
+	if e >= 0 && e <= envSyntaxConfigMap {
 		return envSyntaxNames[e]
 	}
 	return "unknown"
@@ -60,6 +73,46 @@ func (e EnvironmentSyntax) Type() string {
 	return "string"
 }
 
+// IsDocument returns true if the syntax describes a document that must be written as a whole, rather than a
+// sequence of lines with one variable each.
+func (e EnvironmentSyntax) IsDocument() bool {
+	return e >= envSyntaxJSON
+}
+
+// WriteDocument writes the given environment as a JSON object, or as a Kubernetes Secret or ConfigMap manifest
+// with the given name and namespace.
+func (e EnvironmentSyntax) WriteDocument(w io.Writer, name, namespace string, env map[string]string) error {
+	var data []byte
+	var err error
+	switch e {
+	case envSyntaxJSON:
+		data, err = json.Marshal(env, json.Deterministic(true), jsontext.WithIndent("  "))
+		if err == nil {
+			data = append(data, '\n')
+		}
+	case envSyntaxSecret:
+		data, err = yaml.Marshal(&core.Secret{
+			TypeMeta:   meta.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       core.SecretTypeOpaque,
+			StringData: env,
+		})
+	case envSyntaxConfigMap:
+		data, err = yaml.Marshal(&core.ConfigMap{
+			TypeMeta:   meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       env,
+		})
+	default:
+		return fmt.Errorf("env syntax %s is not a document syntax", e)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
 // WriteEnv will write the environment variable in a form that will make the target shell parse it correctly and verbatim.
 func (e EnvironmentSyntax) WriteEnv(k, v string) (r string, err error) {
 	switch e {
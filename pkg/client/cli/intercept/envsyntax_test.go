@@ -1,6 +1,7 @@
 package intercept
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -114,3 +115,59 @@ func TestEnvironmentSyntax_WriteEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvironmentSyntax_WriteDocument(t *testing.T) {
+	env := map[string]string{"A": "B C", "D": "E\nF"}
+	tests := []struct {
+		name string
+		e    EnvironmentSyntax
+		want string
+	}{
+		{
+			"json",
+			envSyntaxJSON,
+			"{\n  \"A\": \"B C\",\n  \"D\": \"E\\nF\"\n}\n",
+		},
+		{
+			"secret",
+			envSyntaxSecret,
+			`apiVersion: v1
+kind: Secret
+metadata:
+  creationTimestamp: null
+  name: echo
+  namespace: default
+stringData:
+  A: B C
+  D: |-
+    E
+    F
+type: Opaque
+`,
+		},
+		{
+			"configmap",
+			envSyntaxConfigMap,
+			`apiVersion: v1
+data:
+  A: B C
+  D: |-
+    E
+    F
+kind: ConfigMap
+metadata:
+  creationTimestamp: null
+  name: echo
+  namespace: default
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.True(t, tt.e.IsDocument())
+			sb := strings.Builder{}
+			require.NoError(t, tt.e.WriteDocument(&sb, "echo", "default", env))
+			require.Equal(t, tt.want, sb.String())
+		})
+	}
+}
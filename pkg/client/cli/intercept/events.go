@@ -0,0 +1,31 @@
+package intercept
+
+import (
+	"context"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+)
+
+// Lifecycle events printed by the intercept and leave commands when `--output=jsonl-events` is used.
+const (
+	EventPrepared       = "prepared"
+	EventAgentProgress  = "agent-progress"
+	EventCreated        = "created"
+	EventActive         = "active"
+	EventMountsReady    = "mounts-ready"
+	EventHandlerStarted = "handler-started"
+	EventRemoved        = "removed"
+
+	EventEnvironmentDrift     = "environment-drift"
+	EventEnvironmentRefreshed = "environment-refreshed"
+)
+
+// EmitRemoved prints the removed event for the intercept with the given id and name. The handlerStopError is
+// included when the intercept handler failed to stop gracefully.
+func EmitRemoved(ctx context.Context, id, name, handlerStopError string) {
+	var attrs map[string]any
+	if handlerStopError != "" {
+		attrs = map[string]any{"handlerStopError": handlerStopError}
+	}
+	output.Event(ctx, &output.LifecycleEvent{Event: EventRemoved, ID: id, Name: name, Attrs: attrs})
+}
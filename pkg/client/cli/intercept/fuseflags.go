@@ -0,0 +1,22 @@
+package intercept
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+// AddFuseFlags adds the flags that tune the FUSE file system of a remote mount to the given flag set.
+func AddFuseFlags(flagSet *pflag.FlagSet, fo *client.FuseOptions) {
+	flagSet.DurationVar(&fo.AttrTimeout, "mount-attr-timeout", 0, ``+
+		`Time that the kernel caches the attributes of remote files and directories. Adds to the intercept.fuse config`)
+
+	flagSet.DurationVar(&fo.EntryTimeout, "mount-entry-timeout", 0, ``+
+		`Time that the kernel caches name lookups of remote files and directories. Adds to the intercept.fuse config`)
+
+	flagSet.BoolVar(&fo.KernelCache, "mount-kernel-cache", false, ``+
+		`Let the kernel cache the content of remote files between opens. Adds to the intercept.fuse config`)
+
+	flagSet.StringSliceVar(&fo.Options, "mount-option", nil, ``+
+		`A FUSE option to pass to the mount using -o, e.g. --mount-option max_read=131072. Adds to the intercept.fuse config`)
+}
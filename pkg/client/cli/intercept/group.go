@@ -0,0 +1,398 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// GroupsFile is the name of the file in the user's config directory where intercept groups are defined unless
+// another file is given.
+const GroupsFile = "groups.yml"
+
+// GroupMember describes one intercept in a Group. The fields correspond to the flags of the intercept command.
+type GroupMember struct {
+	Name           string        `json:"name"`
+	Workload       string        `json:"workload,omitempty"`
+	Port           string        `json:"port,omitempty"`
+	Service        string        `json:"service,omitempty"`
+	Container      string        `json:"container,omitempty"`
+	Address        string        `json:"address,omitempty"`
+	Mount          string        `json:"mount,omitempty"`
+	LocalMountPort uint16        `json:"localMountPort,omitempty"`
+	ToPod          []string      `json:"toPod,omitempty"`
+	Replace        bool          `json:"replace,omitempty"`
+	EnvFile        string        `json:"envFile,omitempty"`
+	EnvJSON        string        `json:"envJson,omitempty"`
+	EnvSyntax      string        `json:"envSyntax,omitempty"`
+	Mechanism      string        `json:"mechanism,omitempty"`
+	Handler        *GroupHandler `json:"handler,omitempty"`
+}
+
+// GroupHandler describes the intercept handler of a GroupMember. The fields correspond to the command given after
+// "--" and the flags that control it.
+type GroupHandler struct {
+	// Command is the command to run, or the arguments to "docker run" when one of the docker fields is used.
+	Command            []string          `json:"command,omitempty"`
+	WorkingDir         string            `json:"workingDir,omitempty"`
+	DockerRun          bool              `json:"dockerRun,omitempty"`
+	DockerBuild        string            `json:"dockerBuild,omitempty"`
+	DockerDebug        string            `json:"dockerDebug,omitempty"`
+	DockerBuildArgs    map[string]string `json:"dockerBuildArgs,omitempty"`
+	DockerBuildOptions []string          `json:"dockerBuildOptions,omitempty"`
+	DockerBuildSecrets []string          `json:"dockerBuildSecrets,omitempty"`
+	DockerCacheFrom    []string          `json:"dockerBuildCacheFrom,omitempty"`
+	DockerCacheTo      []string          `json:"dockerBuildCacheTo,omitempty"`
+	DockerMount        string            `json:"dockerMount,omitempty"`
+	DockerGPUs         string            `json:"dockerGpus,omitempty"`
+	DockerDevices      []string          `json:"dockerDevices,omitempty"`
+	DockerPrivileged   bool              `json:"dockerPrivileged,omitempty"`
+	DockerAttach       string            `json:"dockerAttach,omitempty"`
+	Restart            string            `json:"restart,omitempty"`
+	Watch              []string          `json:"watch,omitempty"`
+}
+
+// Group is a named set of intercepts that are started and stopped together.
+type Group struct {
+	Name       string         `json:"name"`
+	Intercepts []*GroupMember `json:"intercepts"`
+
+	// dir is the directory of the file that defines the group. Relative paths are relative to this directory.
+	dir string
+}
+
+// GroupSpec is the contents of a file that defines intercept groups.
+type GroupSpec struct {
+	Groups []*Group `json:"groups"`
+}
+
+// DefaultGroupsFile returns the path of the file where intercept groups are defined by default.
+func DefaultGroupsFile(ctx context.Context) string {
+	return filepath.Join(filelocation.AppUserConfigDir(ctx), GroupsFile)
+}
+
+// LoadGroup reads the given file and returns the group with the given name.
+func LoadGroup(file, name string) (*Group, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errcat.User.Newf("unable to read intercept groups: %w", err)
+	}
+	var spec GroupSpec
+	if err = yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, errcat.User.Newf("unable to parse intercept groups in %s: %w", file, err)
+	}
+	for _, g := range spec.Groups {
+		if g.Name == name {
+			if g.dir, err = filepath.Abs(filepath.Dir(file)); err != nil {
+				return nil, err
+			}
+			if err = g.validate(); err != nil {
+				return nil, errcat.User.Newf("invalid intercept group %q in %s: %w", name, file, err)
+			}
+			return g, nil
+		}
+	}
+	return nil, errcat.User.Newf("intercept group %q not found in %s", name, file)
+}
+
+// NewGroup returns a group with the given intercepts. Relative paths in the intercepts are relative to the given
+// directory.
+func NewGroup(name, dir string, intercepts []*GroupMember) (*Group, error) {
+	g := &Group{Name: name, Intercepts: intercepts, dir: dir}
+	if err := g.validate(); err != nil {
+		return nil, errcat.User.Newf("invalid intercept group %q: %w", name, err)
+	}
+	return g, nil
+}
+
+// HasHandlers returns true if at least one of the intercepts in the group has a handler.
+func (g *Group) HasHandlers() bool {
+	for _, m := range g.Intercepts {
+		if m.Handler != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Group) validate() error {
+	if len(g.Intercepts) == 0 {
+		return errors.New("the group has no intercepts")
+	}
+	names := make(map[string]struct{}, len(g.Intercepts))
+	for i, m := range g.Intercepts {
+		if m == nil || m.Name == "" {
+			return fmt.Errorf("intercept %d has no name", i)
+		}
+		if _, dup := names[m.Name]; dup {
+			return fmt.Errorf("intercept name %q is used more than once", m.Name)
+		}
+		names[m.Name] = struct{}{}
+	}
+	return nil
+}
+
+// command returns the intercept command that corresponds to the given member. Defaults are the same as those of
+// the intercept command's flags, and relative paths are made relative to the given directory.
+func (m *GroupMember) command(ctx context.Context, dir string) (*Command, error) {
+	abs := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	c := &Command{
+		Name:           m.Name,
+		AgentName:      m.Workload,
+		Port:           m.Port,
+		ServiceName:    m.Service,
+		ContainerName:  m.Container,
+		Address:        m.Address,
+		Mount:          m.Mount,
+		MountSet:       m.Mount != "",
+		LocalMountPort: m.LocalMountPort,
+		ToPod:          m.ToPod,
+		Replace:        m.Replace,
+		EnvFile:        abs(m.EnvFile),
+		EnvJSON:        abs(m.EnvJSON),
+		Mechanism:      m.Mechanism,
+		Silent:         true,
+	}
+	if m.EnvSyntax != "" {
+		if err := c.EnvSyntax.Set(m.EnvSyntax); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := strconv.ParseBool(c.Mount); err != nil && c.Mount != "" {
+		c.Mount = abs(c.Mount)
+	}
+	if c.AgentName == "" {
+		c.AgentName = c.Name
+	}
+	if c.Port == "" {
+		c.Port = strconv.Itoa(client.GetConfig(ctx).Intercept().DefaultPort)
+	}
+	if c.Address == "" {
+		c.Address = "127.0.0.1"
+	}
+	if c.Mount == "" {
+		c.Mount = "true"
+	}
+	if c.Mechanism == "" {
+		c.Mechanism = "tcp"
+	}
+	if h := m.Handler; h != nil {
+		if err := h.apply(c, abs); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// apply adds the handler to the given command, using the given function to make relative paths absolute.
+func (h *GroupHandler) apply(c *Command, abs func(string) string) error {
+	c.Cmdline = h.Command
+	c.WorkingDir = abs(h.WorkingDir)
+	c.DockerMount = h.DockerMount
+	c.DockerGPUs = h.DockerGPUs
+	c.DockerDevices = slices.Clone(h.DockerDevices)
+	c.DockerPrivileged = h.DockerPrivileged
+	c.DockerAttach = h.DockerAttach
+	if h.DockerBuild != "" && !docker.IsRemoteContext(h.DockerBuild) {
+		c.DockerBuild = abs(h.DockerBuild)
+	} else {
+		c.DockerBuild = h.DockerBuild
+	}
+	if h.DockerDebug != "" && !docker.IsRemoteContext(h.DockerDebug) {
+		c.DockerDebug = abs(h.DockerDebug)
+	} else {
+		c.DockerDebug = h.DockerDebug
+	}
+	c.DockerBuildOptions = slices.Clone(h.DockerBuildOptions)
+	c.DockerBuildSecrets = slices.Clone(h.DockerBuildSecrets)
+	c.DockerCacheFrom = slices.Clone(h.DockerCacheFrom)
+	c.DockerCacheTo = slices.Clone(h.DockerCacheTo)
+	for _, k := range slices.Sorted(maps.Keys(h.DockerBuildArgs)) {
+		c.DockerBuildOptions = append(c.DockerBuildOptions, "build-arg="+k+"="+h.DockerBuildArgs[k])
+	}
+	for _, w := range h.Watch {
+		c.Watch = append(c.Watch, abs(w))
+	}
+
+	drCount := 0
+	for _, used := range []bool{h.DockerRun, c.DockerBuild != "", c.DockerDebug != ""} {
+		if used {
+			drCount++
+		}
+	}
+	if drCount > 1 {
+		return errors.New("only one of dockerRun, dockerBuild, or dockerDebug can be used")
+	}
+	c.DockerRun = drCount == 1
+	if c.WorkingDir != "" && c.DockerRun {
+		return errors.New("workingDir cannot be used together with dockerRun, dockerBuild, or dockerDebug")
+	}
+	if !c.DockerRun && c.hasDockerDevices() {
+		return errors.New("dockerGpus, dockerDevices, and dockerPrivileged require dockerRun, dockerBuild, or dockerDebug")
+	}
+	if c.DockerBuild == "" && c.DockerDebug == "" && c.hasDockerBuildExtras() {
+		return errors.New("dockerBuildSecrets, dockerBuildCacheFrom, and dockerBuildCacheTo require dockerBuild or dockerDebug")
+	}
+	if c.DockerAttach != "" {
+		if c.DockerRun || len(c.Cmdline) > 0 {
+			return errors.New("dockerAttach cannot be used together with command, dockerRun, dockerBuild, or dockerDebug")
+		}
+	} else if len(c.Cmdline) == 0 && c.DockerBuild == "" && c.DockerDebug == "" {
+		return errors.New("the handler has no command")
+	}
+	if h.Restart != "" {
+		if err := c.Restart.Set(h.Restart); err != nil {
+			return err
+		}
+	}
+	if c.DockerRun {
+		return c.ValidateDockerArgs()
+	}
+	return nil
+}
+
+// StartedGroup is a Group whose intercepts have been created.
+type StartedGroup struct {
+	group  *Group
+	states []*state
+}
+
+// StartGroup creates the intercepts of the given group, one by one. If one of them fails, then the intercepts
+// that were created are removed again, so that either all intercepts of the group are created, or none of them.
+// Images of handlers that use dockerBuild or dockerDebug are built, and images of handlers that use dockerRun
+// are pulled, before any intercept is created.
+func StartGroup(ctx context.Context, g *Group) (*StartedGroup, error) {
+	return StartGroupFunc(ctx, g, nil)
+}
+
+// StartGroupFunc is like StartGroup, but also calls the given function, unless it's nil, with the outcome of each
+// attempt to create an intercept, and the time that the attempt took.
+func StartGroupFunc(ctx context.Context, g *Group, created func(name string, elapsed time.Duration, err error)) (*StartedGroup, error) {
+	if created == nil {
+		created = func(string, time.Duration, error) {}
+	}
+	states := make([]*state, len(g.Intercepts))
+	for i, m := range g.Intercepts {
+		c, err := m.command(ctx, g.dir)
+		if err != nil {
+			return nil, errcat.User.Newf("intercept group %s: invalid intercept %s: %w", g.Name, m.Name, err)
+		}
+		s := NewState(c).(*state)
+		if c.DockerRun {
+			if err = s.prepareDockerRun(docker.EnableClient(ctx)); err != nil {
+				return nil, fmt.Errorf("intercept group %s: unable to prepare handler of intercept %s: %w", g.Name, m.Name, err)
+			}
+		}
+		states[i] = s
+	}
+
+	sg := &StartedGroup{group: g, states: make([]*state, 0, len(states))}
+	for _, s := range states {
+		start := time.Now()
+		_, err := s.create(ctx)
+		created(s.Name(), time.Since(start), err)
+		if err != nil {
+			sg.Remove(ctx)
+			return nil, fmt.Errorf("intercept group %s: unable to start intercept %s: %w", g.Name, s.Name(), err)
+		}
+		sg.states = append(sg.states, s)
+		ioutil.Printf(dos.Stdout(ctx), "Intercept %s started\n", s.Name())
+	}
+	return sg, nil
+}
+
+// Infos returns the info of the intercepts of the started group.
+func (sg *StartedGroup) Infos() []*Info {
+	infos := make([]*Info, len(sg.states))
+	for i, s := range sg.states {
+		infos[i] = s.info
+	}
+	return infos
+}
+
+// RunHandlers runs the handlers of the intercepts in the started group, and waits until one of them exits or
+// the context is cancelled. All intercepts of the group are then removed. The error of the first handler that
+// exits is returned.
+func (sg *StartedGroup) RunHandlers(ctx context.Context) error {
+	defer sg.Remove(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		firstErr error
+		errOnce  sync.Once
+	)
+	for _, s := range sg.states {
+		if !s.RunAndLeave() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.runCommand(ctx)
+			s.handlerExited(ctx, err)
+			errOnce.Do(func() {
+				if err != nil && ctx.Err() == nil {
+					firstErr = fmt.Errorf("intercept group %s: handler of intercept %s: %w", sg.group.Name, s.Name(), err)
+				}
+				cancel()
+			})
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Remove removes the intercepts of the started group, in reverse order.
+func (sg *StartedGroup) Remove(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	for i := len(sg.states) - 1; i >= 0; i-- {
+		s := sg.states[i]
+		if err := s.leave(ctx); err != nil {
+			dlog.Errorf(ctx, "intercept group %s: unable to remove intercept %s: %v", sg.group.Name, s.Name(), err)
+		} else {
+			ioutil.Printf(dos.Stdout(ctx), "Intercept %s removed\n", s.Name())
+		}
+	}
+	sg.states = nil
+}
+
+// StopGroup removes the intercepts of the given group, in reverse order, using the given remove function, which
+// returns false when the intercept doesn't exist. All intercepts are removed even if some of them fail, and the
+// errors are then returned together.
+func StopGroup(ctx context.Context, g *Group, remove func(context.Context, string) (bool, error)) error {
+	var errs []error
+	for i := len(g.Intercepts) - 1; i >= 0; i-- {
+		name := g.Intercepts[i].Name
+		removed, err := remove(ctx, name)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("unable to stop intercept %s: %w", name, err))
+		case removed:
+			ioutil.Printf(dos.Stdout(ctx), "Intercept %s removed\n", name)
+		}
+	}
+	return errors.Join(errs...)
+}
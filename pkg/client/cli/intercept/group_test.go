@@ -0,0 +1,197 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+const testGroups = `
+groups:
+  - name: backend
+    intercepts:
+      - name: orders
+      - name: payments
+        workload: payments-v2
+        port: 9090:http
+        mount: "false"
+  - name: dev
+    intercepts:
+      - name: orders
+        envFile: orders.env
+        envSyntax: compose
+        localMountPort: 2049
+        handler:
+          command: ["go", "run", "./cmd/orders"]
+          workingDir: services/orders
+          restart: on-failure:3
+          watch: [services/orders]
+      - name: payments
+        mount: mnt/payments
+        handler:
+          dockerBuild: services/payments
+          dockerBuildArgs:
+            VERSION: "1.2"
+            DEBUG: "true"
+          dockerBuildOptions: [tag=payments]
+          dockerBuildSecrets: ["id=npmrc,src=.npmrc"]
+          dockerBuildCacheFrom: ["type=registry,ref=example.com/payments:cache"]
+          dockerGpus: all
+          dockerDevices: [/dev/dri]
+          command: ["-it", "IMAGE"]
+  - name: bad-handler
+    intercepts:
+      - name: orders
+        handler:
+          dockerRun: true
+          dockerBuild: services/orders
+  - name: bad-devices
+    intercepts:
+      - name: orders
+        handler:
+          command: ["go", "run", "./cmd/orders"]
+          dockerPrivileged: true
+  - name: bad-build-extras
+    intercepts:
+      - name: orders
+        handler:
+          command: ["-it", "orders"]
+          dockerRun: true
+          dockerBuildCacheTo: [type=inline]
+  - name: compose
+    intercepts:
+      - name: orders
+        handler:
+          dockerAttach: orders-1
+  - name: bad-attach
+    intercepts:
+      - name: orders
+        handler:
+          dockerAttach: orders-1
+          command: ["-it", "orders"]
+          dockerRun: true
+  - name: empty
+    intercepts: []
+  - name: dup
+    intercepts:
+      - name: orders
+      - name: orders
+`
+
+func TestLoadGroup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), GroupsFile)
+	require.NoError(t, os.WriteFile(file, []byte(testGroups), 0o644))
+
+	g, err := LoadGroup(file, "backend")
+	require.NoError(t, err)
+	require.Len(t, g.Intercepts, 2)
+
+	ctx := client.WithConfig(dlog.NewTestContext(t, false), client.GetDefaultConfig())
+	c, err := g.Intercepts[0].command(ctx, g.dir)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", c.AgentName)
+	assert.Equal(t, "8080", c.Port)
+	assert.Equal(t, "127.0.0.1", c.Address)
+	assert.Equal(t, "tcp", c.Mechanism)
+	assert.False(t, c.MountSet)
+
+	c, err = g.Intercepts[1].command(ctx, g.dir)
+	require.NoError(t, err)
+	assert.Equal(t, "payments-v2", c.AgentName)
+	assert.Equal(t, "9090:http", c.Port)
+	assert.True(t, c.MountSet)
+	enabled, _ := c.GetMountPoint()
+	assert.False(t, enabled)
+
+	g, err = LoadGroup(file, "dev")
+	require.NoError(t, err)
+	assert.True(t, g.HasHandlers())
+	dir := filepath.Dir(file)
+	c, err = g.Intercepts[0].command(ctx, g.dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "orders.env"), c.EnvFile)
+	assert.Equal(t, "compose", c.EnvSyntax.String())
+	assert.Equal(t, uint16(2049), c.LocalMountPort)
+	assert.Equal(t, []string{"go", "run", "./cmd/orders"}, c.Cmdline)
+	assert.Equal(t, filepath.Join(dir, "services", "orders"), c.WorkingDir)
+	assert.Equal(t, RestartPolicy{Mode: RestartOnFailure, MaxRetries: 3}, c.Restart)
+	assert.Equal(t, []string{filepath.Join(dir, "services", "orders")}, c.Watch)
+	assert.False(t, c.DockerRun)
+	assert.True(t, NewState(c).RunAndLeave())
+
+	c, err = g.Intercepts[1].command(ctx, g.dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "mnt", "payments"), c.Mount)
+	assert.True(t, c.DockerRun)
+	assert.Equal(t, filepath.Join(dir, "services", "payments"), c.DockerBuild)
+	assert.Equal(t, []string{"tag=payments", "build-arg=DEBUG=true", "build-arg=VERSION=1.2"}, c.DockerBuildOptions)
+	assert.Equal(t, []string{
+		"--tag=payments", "--build-arg=DEBUG=true", "--build-arg=VERSION=1.2",
+		"--secret", "id=npmrc,src=.npmrc", "--cache-from", "type=registry,ref=example.com/payments:cache",
+	}, c.dockerBuildArgs())
+	assert.Equal(t, "all", c.DockerGPUs)
+	assert.Equal(t, []string{"/dev/dri"}, c.DockerDevices)
+	assert.False(t, c.DockerPrivileged)
+
+	g, err = LoadGroup(file, "bad-handler")
+	require.NoError(t, err)
+	_, err = g.Intercepts[0].command(ctx, g.dir)
+	assert.ErrorContains(t, err, "only one of dockerRun, dockerBuild, or dockerDebug can be used")
+
+	g, err = LoadGroup(file, "bad-devices")
+	require.NoError(t, err)
+	_, err = g.Intercepts[0].command(ctx, g.dir)
+	assert.ErrorContains(t, err, "dockerGpus, dockerDevices, and dockerPrivileged require dockerRun")
+
+	g, err = LoadGroup(file, "bad-build-extras")
+	require.NoError(t, err)
+	_, err = g.Intercepts[0].command(ctx, g.dir)
+	assert.ErrorContains(t, err, "dockerBuildSecrets, dockerBuildCacheFrom, and dockerBuildCacheTo require dockerBuild")
+
+	g, err = LoadGroup(file, "compose")
+	require.NoError(t, err)
+	c, err = g.Intercepts[0].command(ctx, g.dir)
+	require.NoError(t, err)
+	assert.Equal(t, "orders-1", c.DockerAttach)
+	assert.False(t, c.DockerRun)
+	assert.True(t, NewState(c).RunAndLeave())
+
+	g, err = LoadGroup(file, "bad-attach")
+	require.NoError(t, err)
+	_, err = g.Intercepts[0].command(ctx, g.dir)
+	assert.ErrorContains(t, err, "dockerAttach cannot be used together with command")
+
+	_, err = LoadGroup(file, "empty")
+	assert.ErrorContains(t, err, "the group has no intercepts")
+	_, err = LoadGroup(file, "dup")
+	assert.ErrorContains(t, err, `intercept name "orders" is used more than once`)
+	_, err = LoadGroup(file, "frontend")
+	assert.ErrorContains(t, err, `intercept group "frontend" not found`)
+
+	require.NoError(t, os.WriteFile(file, []byte("groups:\n  - name: x\n    members: []\n"), 0o644))
+	_, err = LoadGroup(file, "x")
+	assert.ErrorContains(t, err, "unable to parse intercept groups")
+}
+
+func TestStopGroup(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	g := &Group{Name: "backend", Intercepts: []*GroupMember{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	var removed []string
+	err := StopGroup(ctx, g, func(_ context.Context, name string) (bool, error) {
+		removed = append(removed, name)
+		if name == "b" {
+			return false, errors.New("boom")
+		}
+		return true, nil
+	})
+	assert.Equal(t, []string{"c", "b", "a"}, removed)
+	assert.EqualError(t, err, "unable to stop intercept b: boom")
+}
@@ -0,0 +1,75 @@
+package intercept
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/handoff"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// handingOff returns a function that runs the given function while the connections that the user daemon accepts
+// on the target port of the intercept are handed off to this host. The given function is cancelled, and the error
+// of the hand-off is returned, when the hand-off fails.
+func (s *state) handingOff(run func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		hoErr := make(chan error, 1)
+		go func() {
+			err := s.handOff(ctx)
+			if err != nil {
+				cancel()
+			}
+			hoErr <- err
+		}()
+		err := run(ctx)
+		cancel()
+		if hErr := <-hoErr; hErr != nil {
+			return hErr
+		}
+		return err
+	}
+}
+
+// handOff connects each connection that the user daemon accepts on the target port of the intercept to the
+// address and port of the intercept on this host, until the context is cancelled. One HandOff call always waits
+// for the next connection, so that connections are handed off without delay.
+func (s *state) handOff(ctx context.Context) error {
+	ud := daemon.GetUserClient(ctx)
+	addr := iputil.JoinHostPort(s.Address, s.localPort)
+	for ctx.Err() == nil {
+		cctx, cancel := context.WithCancel(ctx)
+		stream, err := ud.HandOff(cctx)
+		if err == nil {
+			if err = stream.Send(&connector.HandOffMessage{Port: int32(s.localPort)}); err == nil {
+				// Wait until the daemon has accepted a connection.
+				_, err = stream.Recv()
+			}
+		}
+		if err != nil {
+			cancel()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("unable to hand off connections to %s: %w", addr, err)
+		}
+		go func() {
+			defer cancel()
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				dlog.Errorf(ctx, "unable to hand off connection to %s: %v", addr, err)
+				return
+			}
+			defer conn.Close()
+			if err = handoff.Pipe(cctx, conn, stream); err != nil {
+				dlog.Debugf(ctx, "hand-off of connection to %s ended: %v", addr, err)
+			}
+		}()
+	}
+	return nil
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 )
@@ -22,6 +24,7 @@ type Ingress struct {
 
 type Mount struct {
 	LocalDir  string   `json:"local_dir,omitempty"     yaml:"local_dir,omitempty"`
+	Host      string   `json:"host,omitempty"          yaml:"host,omitempty"` // Host of LocalDir, when it's not the host of the CLI
 	RemoteDir string   `json:"remote_dir,omitempty"    yaml:"remote_dir,omitempty"`
 	Error     string   `json:"error,omitempty"         yaml:"error,omitempty"`
 	PodIP     string   `json:"pod_ip,omitempty"        yaml:"pod_ip,omitempty"`
@@ -35,6 +38,7 @@ type Info struct {
 	Disposition   string            `json:"disposition,omitempty"     yaml:"disposition,omitempty"`
 	Message       string            `json:"message,omitempty"         yaml:"message,omitempty"`
 	WorkloadKind  string            `json:"workload_kind,omitempty"   yaml:"workload_kind,omitempty"`
+	Pods          string            `json:"pods,omitempty"            yaml:"pods,omitempty"`
 	TargetHost    string            `json:"target_host,omitempty"     yaml:"target_host,omitempty"`
 	TargetPort    int32             `json:"target_port,omitempty"     yaml:"target_port,omitempty"`
 	ServiceUID    string            `json:"service_uid,omitempty"     yaml:"service_uid,omitempty"`
@@ -46,10 +50,13 @@ type Info struct {
 	FilterDesc    string            `json:"filter_desc,omitempty"     yaml:"filter_desc,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"        yaml:"metadata,omitempty"`
 	HttpFilter    []string          `json:"http_filter,omitempty"     yaml:"http_filter,omitempty"`
+	HttpHeaders   map[string]string `json:"http_headers,omitempty"    yaml:"http_headers,omitempty"`
 	Global        bool              `json:"global,omitempty"          yaml:"global,omitempty"`
 	PreviewURL    string            `json:"preview_url,omitempty"     yaml:"preview_url,omitempty"`
 	Ingress       *Ingress          `json:"ingress,omitempty"         yaml:"ingress,omitempty"`
 	PodIP         string            `json:"pod_ip,omitempty"          yaml:"pod_ip,omitempty"`
+	EnvDrift      string            `json:"env_drift,omitempty"       yaml:"env_drift,omitempty"`
+	AgentImage    string            `json:"agent_image,omitempty"     yaml:"agent_image,omitempty"`
 	debug         bool
 }
 
@@ -69,6 +76,35 @@ func NewIngress(ps *manager.PreviewSpec) *Ingress {
 	}
 }
 
+// PodScope returns a description of the pods that the given intercept is limited to, or an empty string when
+// the intercept applies to all pods of its workload.
+func PodScope(spec *manager.InterceptSpec) string {
+	var parts []string
+	if os := spec.PodOrdinals; len(os) > 0 {
+		ss := make([]string, len(os))
+		for i, o := range os {
+			ss[i] = strconv.Itoa(int(o))
+		}
+		if len(os) == 1 {
+			parts = append(parts, "ordinal "+ss[0])
+		} else {
+			parts = append(parts, "ordinals "+strings.Join(ss, ", "))
+		}
+	}
+	switch {
+	case spec.ReplicaSet != "" && spec.Revision != 0:
+		parts = append(parts, fmt.Sprintf("replica set %s (revision %d)", spec.ReplicaSet, spec.Revision))
+	case spec.ReplicaSet != "":
+		parts = append(parts, "replica set "+spec.ReplicaSet)
+	case spec.Revision != 0:
+		parts = append(parts, fmt.Sprintf("revision %d", spec.Revision))
+	}
+	if spec.PodSelector != "" {
+		parts = append(parts, "selected by "+spec.PodSelector)
+	}
+	return strings.Join(parts, " and ")
+}
+
 func PreviewURL(pu string) string {
 	if !(pu == "" || strings.HasPrefix(pu, "https://") || strings.HasPrefix(pu, "http://")) {
 		pu = "https://" + pu
@@ -94,6 +130,7 @@ func NewMount(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		}
 		return &Mount{
 			LocalDir:  ii.ClientMountPoint,
+			Host:      daemon.RemoteHost(ctx),
 			RemoteDir: ii.MountPoint,
 			PodIP:     ii.PodIp,
 			Port:      port,
@@ -111,6 +148,7 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		Disposition:   ii.Disposition.String(),
 		Message:       ii.Message,
 		WorkloadKind:  spec.WorkloadKind,
+		Pods:          PodScope(spec),
 		TargetHost:    spec.TargetHost,
 		TargetPort:    spec.TargetPort,
 		Mount:         NewMount(ctx, ii, mountError),
@@ -122,9 +160,12 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		FilterDesc:    ii.MechanismArgsDesc,
 		Metadata:      ii.Metadata,
 		HttpFilter:    spec.MechanismArgs,
+		HttpHeaders:   spec.HttpHeaders,
 		Global:        spec.Mechanism == "tcp",
 		PreviewURL:    PreviewURL(ii.PreviewDomain),
 		Ingress:       NewIngress(ii.PreviewSpec),
+		EnvDrift:      ii.EnvironmentDrift,
+		AgentImage:    ii.AgentImage,
 	}
 	if spec.ServiceUid != "" {
 		// For backward compatibility in JSON output
@@ -149,6 +190,12 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 		return msg
 	}())
 	kvf.Add("Workload kind", ii.WorkloadKind)
+	if ii.Pods != "" {
+		kvf.Add("Pods", ii.Pods)
+	}
+	if ii.EnvDrift != "" {
+		kvf.Add("Environment drift", ii.EnvDrift)
+	}
 
 	if ii.debug {
 		kvf.Add("ID", ii.ID)
@@ -174,10 +221,15 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 		kvf.Add("Mechanism", m)
 		kvf.Add("Mechanism Command", fmt.Sprintf("%q", ii.FilterDesc))
 		kvf.Add("Metadata", fmt.Sprintf("%q", ii.Metadata))
+		if ii.AgentImage != "" {
+			kvf.Add("Agent Image", ii.AgentImage)
+		}
 	}
 
 	if m := ii.Mount; m != nil {
-		if m.LocalDir != "" {
+		if m.LocalDir != "" && m.Host != "" {
+			kvf.Add("Volume Mount Point", fmt.Sprintf("%s on %s", m.LocalDir, m.Host))
+		} else if m.LocalDir != "" {
 			kvf.Add("Volume Mount Point", m.LocalDir)
 		} else if m.Error != "" {
 			kvf.Add("Volume Mount Error", m.Error)
@@ -0,0 +1,29 @@
+package intercept
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestPodScope(t *testing.T) {
+	assert.Equal(t, "", PodScope(&manager.InterceptSpec{}))
+	assert.Equal(t, "ordinal 0", PodScope(&manager.InterceptSpec{PodOrdinals: []int32{0}}))
+	assert.Equal(t, "ordinals 0, 2", PodScope(&manager.InterceptSpec{PodOrdinals: []int32{0, 2}}))
+	assert.Equal(t, "selected by tier=canary", PodScope(&manager.InterceptSpec{PodSelector: "tier=canary"}))
+	assert.Equal(t, "ordinal 1 and selected by tier=canary",
+		PodScope(&manager.InterceptSpec{PodOrdinals: []int32{1}, PodSelector: "tier=canary"}))
+	assert.Equal(t, "replica set echo-5f8b (revision 4)", PodScope(&manager.InterceptSpec{ReplicaSet: "echo-5f8b", Revision: 4}))
+	assert.Equal(t, "revision 4 and selected by tier=canary", PodScope(&manager.InterceptSpec{Revision: 4, PodSelector: "tier=canary"}))
+
+	ii := &Info{Name: "db", Disposition: "ACTIVE", Pods: "ordinal 0"}
+	sb := strings.Builder{}
+	_, err := ii.WriteTo(&sb)
+	require.NoError(t, err)
+	assert.Contains(t, sb.String(), "Pods")
+	assert.Contains(t, sb.String(), ": ordinal 0")
+}
@@ -1,34 +1,29 @@
 package intercept
 
 import (
-	"fmt"
-	"os"
+	"strings"
 
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 )
 
+// PrepareMount validates the given mount point, which must be a drive letter, optionally followed by a colon
+// and a backslash, or a UNC path of the form \\server\share. An empty mount point, or "*", lets the user daemon
+// pick a free drive letter at the time of the mount.
 func PrepareMount(_ string, mountPoint string) (string, error) {
-	var err error
-	if mountPoint == "" {
-		// Find a free drive letter. Background at T, loop around and skip C and D,
-		// A and B aren't often used nowadays. No floppy-disks.
-		for _, c := range "TUVXYZABEFGHIJKLMNOPQR" {
-			_, err = os.Stat(fmt.Sprintf(`%c:\`, c))
-			if os.IsNotExist(err) {
-				return fmt.Sprintf(`%c:`, c), nil
-			}
-		}
-		return "", errcat.User.New("found no available drive to use as mount point")
+	if mountPoint == "" || mountPoint == remotefs.AutoDriveLetter {
+		return remotefs.AutoDriveLetter, nil
+	}
+	if remotefs.IsUNCPath(mountPoint) {
+		return strings.TrimSuffix(mountPoint, `\`), nil
 	}
 
 	// Mount point must be a drive letter
-	ok := len(mountPoint) == 2 && mountPoint[1] == ':'
-	if ok {
-		dl := mountPoint[0]
-		ok = dl >= 'A' && dl <= 'Z' || dl >= 'a' && dl <= 'z'
-	}
-	if !ok {
-		err = errcat.User.New("mount point must be a drive letter followed by a colon")
+	dl := strings.TrimSuffix(strings.TrimSuffix(mountPoint, `\`), ":")
+	if len(dl) == 1 {
+		if c := dl[0]; c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			return strings.ToUpper(dl) + ":", nil
+		}
 	}
-	return mountPoint, err
+	return "", errcat.User.New(`mount point must be a drive letter followed by a colon, or a UNC path like \\server\share`)
 }
@@ -0,0 +1,116 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/spinner"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+// DescribeAgentProgress returns a short description of the stage that the given progress concerns.
+func DescribeAgentProgress(ap *manager.AgentArrivalProgress) string {
+	switch ap.Stage {
+	case manager.AgentArrivalProgress_CONFIG_UPDATED:
+		return "agent config updated, replacing pods"
+	case manager.AgentArrivalProgress_POD_TERMINATING:
+		return fmt.Sprintf("pod %s terminating", ap.PodName)
+	case manager.AgentArrivalProgress_POD_SCHEDULED:
+		return fmt.Sprintf("pod %s scheduled", ap.PodName)
+	case manager.AgentArrivalProgress_AGENT_STARTED:
+		return fmt.Sprintf("traffic-agent started in pod %s", ap.PodName)
+	case manager.AgentArrivalProgress_AGENT_ARRIVED:
+		return "traffic-agent arrived"
+	default:
+		return ap.Stage.String()
+	}
+}
+
+// watchAgentArrival shows the stages that the given workload reaches while the traffic-manager waits for its
+// traffic-agent to arrive, using a spinner when stdout is a terminal, and lifecycle events when they are
+// requested. The spinner isn't shown unless the workload reaches a stage. The returned function stops the
+// watch, and must be called with the result of the creation of the intercept.
+func (s *state) watchAgentArrival(ctx context.Context, workload, namespace string) func(error) {
+	if workload == "" {
+		// Local-only intercepts have no traffic-agent.
+		return func(error) {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ec, err := daemon.GetUserClient(ctx).WatchEvents(ctx, &empty.Empty{})
+	if err != nil {
+		cancel()
+		dlog.Debugf(ctx, "unable to watch the arrival of the traffic-agent: %v", err)
+		return func(error) {}
+	}
+	if !(s.Silent || s.FormattedOutput) {
+		if f, ok := dos.Stdout(ctx).(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			ctx = spinner.WithProvider(ctx, spinner.NewTerminalProvider(f))
+		}
+	}
+
+	var spin spinner.Spinner
+	arrived := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			ev, err := ec.Recv()
+			if err != nil {
+				return
+			}
+			var msg string
+			switch ev.Type {
+			case connector.Event_AGENT_PROGRESS:
+				ap := ev.AgentProgress
+				if ap.GetWorkloadName() != workload || namespace != "" && ap.GetNamespace() != namespace {
+					continue
+				}
+				s.event(ctx, EventAgentProgress, map[string]any{"stage": ap.Stage.String(), "pod": ap.PodName})
+				msg = DescribeAgentProgress(ap)
+				arrived = ap.Stage == manager.AgentArrivalProgress_AGENT_ARRIVED
+			case connector.Event_AGENT_EVENT:
+				ae := ev.AgentEvent
+				if !(ae.GetObjectName() == workload || strings.HasPrefix(ae.GetObjectName(), workload+"-")) ||
+					namespace != "" && ev.Namespace != namespace {
+					continue
+				}
+				msg = fmt.Sprintf("%s %s/%s", ae.Reason, strings.ToLower(ae.ObjectKind), ae.ObjectName)
+			default:
+				continue
+			}
+			if spin == nil {
+				if arrived {
+					// The agent was already present, so there was nothing to wait for.
+					return
+				}
+				spin = spinner.New(ctx, "traffic-agent of "+workload)
+			}
+			if arrived {
+				spin.DoneMsg(msg)
+				return
+			}
+			spin.Message(msg)
+		}
+	}()
+	return func(err error) {
+		cancel()
+		<-done
+		if spin != nil && !arrived {
+			if err == nil {
+				spin.DoneMsg(DescribeAgentProgress(&manager.AgentArrivalProgress{Stage: manager.AgentArrivalProgress_AGENT_ARRIVED}))
+			} else {
+				_ = spin.Error(errors.New("no traffic-agent arrived"))
+			}
+		}
+	}
+}
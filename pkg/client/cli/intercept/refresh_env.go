@@ -0,0 +1,77 @@
+package intercept
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// envDriftPollInterval is how often a running intercept handler checks for drift in the remote environment.
+const envDriftPollInterval = 15 * time.Second
+
+// RefreshEnvironment clears the environment drift of the named intercept and writes its current environment
+// to the files given by the env-file and env-json fields of the given command.
+func RefreshEnvironment(ctx context.Context, c *Command) error {
+	ii, err := daemon.GetUserClient(ctx).RefreshInterceptEnvironment(ctx, &manager.GetInterceptRequest{Name: c.Name})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return errcat.User.Newf("Intercept named %q not found", c.Name)
+		}
+		return err
+	}
+	s := &state{Command: c, env: applyEnvRules(ii.Environment, c.envRules(ctx))}
+	s.env["TELEPRESENCE_INTERCEPT_ID"] = ii.Id
+	s.env["TELEPRESENCE_ROOT"] = ii.ClientMountPoint
+	if s.EnvFile != "" {
+		if err = s.writeEnvFile(ii.Spec.Namespace); err != nil {
+			return err
+		}
+	}
+	if s.EnvJSON != "" {
+		if err = s.writeEnvJSON(); err != nil {
+			return err
+		}
+	}
+	s.event(ctx, EventEnvironmentRefreshed, nil)
+	return nil
+}
+
+// watchEnvironmentDrift polls the user daemon for drift in the environment of the intercept until the given
+// context is cancelled, and reports the drift once.
+func (s *state) watchEnvironmentDrift(ctx context.Context) {
+	ud := daemon.GetUserClient(ctx)
+	ticker := time.NewTicker(envDriftPollInterval)
+	defer ticker.Stop()
+	reported := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ii, err := ud.GetIntercept(ctx, &manager.GetInterceptRequest{Name: s.Name()})
+		if err != nil {
+			if ctx.Err() == nil {
+				dlog.Debugf(ctx, "unable to check environment drift: %v", err)
+			}
+			continue
+		}
+		if drift := ii.EnvironmentDrift; drift != reported {
+			reported = drift
+			if drift != "" {
+				ioutil.Printf(output.Err(ctx), "Intercept %s: %s. Use \"telepresence intercept refresh-env %s\" to refresh it\n",
+					s.Name(), drift, s.Name())
+				s.event(ctx, EventEnvironmentDrift, map[string]any{"reason": drift})
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+const (
+	// restartBackoffMin is the delay before the first restart of an intercept handler.
+	restartBackoffMin = time.Second
+
+	// restartBackoffMax is the longest delay between two restarts of an intercept handler.
+	restartBackoffMax = 30 * time.Second
+
+	// restartBackoffReset is how long an intercept handler must run before the delay is reset to restartBackoffMin.
+	restartBackoffReset = time.Minute
+)
+
+type RestartMode int
+
+const (
+	RestartNever RestartMode = iota
+	RestartOnFailure
+	RestartAlways
+)
+
+var restartModeNames = []string{ //nolint:gochecknoglobals // constant
+	"never",
+	"on-failure",
+	"always",
+}
+
+// RestartPolicy controls if, and how many times, an intercept handler is restarted when it exits.
+type RestartPolicy struct {
+	Mode RestartMode
+
+	// MaxRetries is the maximum number of restarts when the Mode is RestartOnFailure. Zero means no limit.
+	MaxRetries int
+}
+
+func RestartPolicyUsage() string {
+	return `"never", "on-failure[:max-retries]", or "always"`
+}
+
+func (p *RestartPolicy) Set(s string) error {
+	mode, retries, hasRetries := strings.Cut(s, ":")
+	for i, n := range restartModeNames {
+		if n == mode {
+			p.Mode = RestartMode(i)
+			p.MaxRetries = 0
+			if !hasRetries {
+				return nil
+			}
+			if p.Mode != RestartOnFailure {
+				return fmt.Errorf("max-retries can only be used with on-failure: %s", s)
+			}
+			r, err := strconv.Atoi(retries)
+			if err != nil || r < 0 {
+				return fmt.Errorf("invalid max-retries: %s", s)
+			}
+			p.MaxRetries = r
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid restart policy: %s", s)
+}
+
+func (p *RestartPolicy) String() string {
+	if p.Mode < 0 || int(p.Mode) >= len(restartModeNames) {
+		return "unknown"
+	}
+	s := restartModeNames[p.Mode]
+	if p.MaxRetries > 0 {
+		s += ":" + strconv.Itoa(p.MaxRetries)
+	}
+	return s
+}
+
+func (p *RestartPolicy) Type() string {
+	return "string"
+}
+
+// shouldRestart returns true if an intercept handler that exited with the given error, after having been
+// restarted the given number of times, should be restarted.
+func (p *RestartPolicy) shouldRestart(exitErr error, restarts int) bool {
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitErr != nil && (p.MaxRetries == 0 || restarts < p.MaxRetries)
+	default:
+		return false
+	}
+}
+
+// handlerExitCode returns the exit code of an intercept handler that exited with the given error.
+func handlerExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ec interface{ ExitCode() int }
+	if errors.As(err, &ec) && ec.ExitCode() > 0 {
+		return ec.ExitCode()
+	}
+	return 1
+}
+
+// restartBackoff returns the delay before the next restart of an intercept handler, given the previous delay and
+// how long the handler ran before it exited.
+func restartBackoff(prev, uptime time.Duration) time.Duration {
+	if prev == 0 || uptime >= restartBackoffReset {
+		return restartBackoffMin
+	}
+	return min(2*prev, restartBackoffMax)
+}
+
+// awaitRestart returns true when the intercept handler that was added to the user daemon as the given
+// interceptor, and that exited with the given error, should be restarted. The restart is delayed using an
+// exponential backoff. Handlers that were stopped by the user daemon, e.g. because the intercept ended,
+// are never restarted.
+func (s *state) awaitRestart(ctx context.Context, ior *connector.Interceptor, exitErr error, uptime time.Duration) bool {
+	restarts := int(ior.Restarts)
+	if ctx.Err() != nil || !s.Restart.shouldRestart(exitErr, restarts) {
+		return false
+	}
+	ar, err := daemon.GetUserClient(ctx).IsInterceptorAttached(ctx, ior)
+	if err != nil {
+		dlog.Debugf(ctx, "unable to check if the interceptor is attached: %v", err)
+		return false
+	}
+	if !ar.Attached {
+		return false
+	}
+	s.restartDelay = restartBackoff(s.restartDelay, uptime)
+	reason := "exited"
+	if exitErr != nil {
+		reason = exitErr.Error()
+	}
+	ioutil.Printf(dos.Stderr(ctx), "Intercept handler %s. Restarting in %s (restart %d)\n", reason, s.restartDelay, restarts+1)
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(s.restartDelay):
+		return true
+	}
+}
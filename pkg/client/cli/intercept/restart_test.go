@@ -0,0 +1,90 @@
+package intercept
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+func TestRestartPolicy_Set(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RestartPolicy
+		wantErr bool
+	}{
+		{value: "never", want: RestartPolicy{Mode: RestartNever}},
+		{value: "always", want: RestartPolicy{Mode: RestartAlways}},
+		{value: "on-failure", want: RestartPolicy{Mode: RestartOnFailure}},
+		{value: "on-failure:3", want: RestartPolicy{Mode: RestartOnFailure, MaxRetries: 3}},
+		{value: "on-failure:x", wantErr: true},
+		{value: "on-failure:-1", wantErr: true},
+		{value: "always:3", wantErr: true},
+		{value: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var p RestartPolicy
+			err := p.Set(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+			assert.Equal(t, tt.value, p.String())
+		})
+	}
+}
+
+func TestRestartPolicy_shouldRestart(t *testing.T) {
+	failed := errors.New("exit status 1")
+	never := RestartPolicy{Mode: RestartNever}
+	assert.False(t, never.shouldRestart(failed, 0))
+
+	always := RestartPolicy{Mode: RestartAlways}
+	assert.True(t, always.shouldRestart(nil, 0))
+	assert.True(t, always.shouldRestart(failed, 100))
+
+	onFailure := RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2}
+	assert.False(t, onFailure.shouldRestart(nil, 0))
+	assert.True(t, onFailure.shouldRestart(failed, 1))
+	assert.False(t, onFailure.shouldRestart(failed, 2))
+
+	unlimited := RestartPolicy{Mode: RestartOnFailure}
+	assert.True(t, unlimited.shouldRestart(failed, 100))
+}
+
+func Test_restartBackoff(t *testing.T) {
+	d := restartBackoff(0, 0)
+	assert.Equal(t, restartBackoffMin, d)
+	d = restartBackoff(d, time.Second)
+	assert.Equal(t, 2*time.Second, d)
+	for range 10 {
+		d = restartBackoff(d, time.Second)
+	}
+	assert.Equal(t, restartBackoffMax, d)
+
+	// A handler that ran for a while resets the backoff.
+	assert.Equal(t, restartBackoffMin, restartBackoff(d, restartBackoffReset))
+}
+
+func Test_handlerExitCode(t *testing.T) {
+	assert.Equal(t, 0, handlerExitCode(nil))
+	assert.Equal(t, 1, handlerExitCode(errors.New("failed")))
+	err := errcat.NoDaemonLogs.New(fmt.Errorf("./run-tests: %w", proc.ExitCodeError(3)))
+	assert.Equal(t, 3, handlerExitCode(err))
+	assert.Equal(t, "./run-tests: exited with 3", err.Error())
+
+	err = errcat.WithExitCode(err, handlerExitCode(err))
+	assert.Equal(t, 3, errcat.GetExitCode(err))
+	assert.Equal(t, errcat.NoDaemonLogs, errcat.GetCategory(err))
+	assert.Equal(t, 1, errcat.GetExitCode(errors.New("failed")))
+	assert.Equal(t, 0, errcat.GetExitCode(nil))
+}
@@ -0,0 +1,80 @@
+package intercept
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+)
+
+// Share prints a token that a teammate can use to join the named intercept.
+func Share(cmd *cobra.Command, name string) error {
+	ctx := cmd.Context()
+	st, err := daemon.GetUserClient(ctx).ShareIntercept(ctx, &manager.GetInterceptRequest{Name: name})
+	if err != nil {
+		return shareError(err)
+	}
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, st, false)
+		return nil
+	}
+	fmt.Fprintf(output.Out(ctx), "Intercept %s can be joined using:\n\n    telepresence intercept join %s\n", name, st.Token)
+	return nil
+}
+
+// Join joins the intercept that the given token was issued for, or leaves it.
+func Join(cmd *cobra.Command, token string, leave bool) error {
+	ctx := cmd.Context()
+	uc := daemon.GetUserClient(ctx)
+	rq := &manager.JoinInterceptRequest{Token: token}
+	if leave {
+		if _, err := uc.LeaveIntercept(ctx, rq); err != nil {
+			return shareError(err)
+		}
+		fmt.Fprintln(output.Out(ctx), "Left the shared intercept")
+		return nil
+	}
+	ii, err := uc.JoinIntercept(ctx, rq)
+	if err != nil {
+		return shareError(err)
+	}
+
+	// The mounts and the environment belong to the client that created the intercept.
+	ii.MountPoint = ""
+	ii.Environment = nil
+	info := NewInfo(ctx, ii, "")
+	if output.WantsFormatted(cmd) {
+		output.Object(ctx, info, false)
+		return nil
+	}
+	// The traffic-manager only returns the route of this workstation.
+	var route string
+	for _, r := range ii.SharedSessionRoutes {
+		route = r
+	}
+	spec := ii.Spec
+	out := output.Out(ctx)
+	fmt.Fprintf(out, "Joined intercept %s of %s. Its HTTP requests that carry the header \"%s: %s\" are now routed to %s "+
+		"on this workstation\n", spec.Name, spec.Client, restapi.HeaderRoute, route, iputil.JoinHostPort(spec.TargetHost, uint16(spec.TargetPort)))
+	_, err = info.WriteTo(out)
+	return err
+}
+
+func shareError(err error) error {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.InvalidArgument, codes.FailedPrecondition, codes.NotFound, codes.Unimplemented,
+			codes.Unauthenticated, codes.PermissionDenied:
+			return errcat.User.New(st.Message())
+		}
+	}
+	return err
+}
@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-json-experiment/json"
 	"github.com/go-json-experiment/json/jsontext"
@@ -29,6 +32,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/spinner"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -52,7 +56,11 @@ type state struct {
 	localPort     uint16 // the parsed <local port>
 	dockerPort    uint16
 	status        *connector.ConnectInfo
-	info          *Info // Info from the created intercept
+	info          *Info         // Info from the created intercept
+	restartDelay  time.Duration // the delay before the last restart of the intercept handler
+	imageIdx      int           // index of the image built by --docker-build or --docker-debug in the Cmdline
+	attached      string        // name of the container of --docker-attach
+	aliases       []string      // network aliases of a containerized intercept handler
 
 	// Possibly extended version of the state. Use when calling interface methods.
 	self State
@@ -74,8 +82,12 @@ func (s *state) SetSelf(self State) {
 
 func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRequest, error) {
 	spec := &manager.InterceptSpec{
-		Name:    s.Name(),
-		Replace: s.Replace,
+		Name:            s.Name(),
+		Replace:         s.Replace,
+		TerminateTls:    s.TerminateTLS,
+		InjectLatency:   int64(s.InjectLatency),
+		InjectErrorRate: float32(s.InjectErrorRate),
+		IncludeSecrets:  s.IncludeSecrets,
 	}
 	ir := &connector.CreateInterceptRequest{
 		Spec:         spec,
@@ -90,9 +102,14 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 
 	spec.ServiceName = s.ServiceName
 	spec.ContainerName = s.ContainerName
+	spec.PodSelector = s.PodSelector
+	spec.PodOrdinals = s.podOrdinals()
+	spec.ReplicaSet = s.ReplicaSet
+	spec.Revision = s.Revision
 	spec.Mechanism = s.Mechanism
 	spec.MechanismArgs = s.MechanismArgs
 	spec.Agent = s.AgentName
+	spec.WorkloadKind = s.WorkloadKind
 	spec.TargetHost = "127.0.0.1"
 
 	ud := daemon.GetUserClient(ctx)
@@ -104,15 +121,50 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 		return nil, err
 	}
 	spec.TargetPort = int32(s.localPort)
+	if spec.HttpHeaders, err = s.httpHeaders(); err != nil {
+		return nil, err
+	}
+	if spec.HttpBodyJson, spec.HttpBodyXpath, err = s.httpBodyFilters(); err != nil {
+		return nil, err
+	}
+	if spec.HttpBodyLimit, err = s.httpBodyLimit(); err != nil {
+		return nil, err
+	}
+	if spec.BandwidthLimit, err = s.bandwidthLimit(); err != nil {
+		return nil, err
+	}
 	if iputil.Parse(s.Address) == nil {
 		return nil, fmt.Errorf("--address %s is not a valid IP address", s.Address)
 	}
 	spec.TargetHost = s.Address
+	remoteHost := daemon.RemoteHost(ctx)
+	if remoteHost != "" {
+		if s.DockerRun || s.DockerAttach != "" {
+			return nil, errcat.User.Newf("--docker-run and --docker-attach cannot be used when the user daemon runs on %s", remoteHost)
+		}
+		if s.HandOff {
+			// The user daemon sends the traffic to its own localhost, where it's picked up and handed off to the
+			// address of this host.
+			spec.TargetHost = "127.0.0.1"
+		}
+	} else if s.HandOff {
+		return nil, errcat.User.New("--hand-off can only be used when the user daemon runs on another host")
+	}
+	if s.DockerAttach != "" {
+		if err = s.attachTarget(ctx, spec); err != nil {
+			return nil, err
+		}
+	}
 
 	mountEnabled, mountPoint := s.GetMountPoint()
 	if !mountEnabled {
 		s.mountDisabled = true
 	} else {
+		syncMode := remotefs.IsSyncMode(s.MountMode)
+		fuseMode := s.MountMode == "" || s.MountMode == remotefs.MountModeFuse
+		if !fuseMode && ud.Containerized() {
+			return nil, errcat.User.Newf("--mount-mode %s cannot be used when the daemon runs in a container", s.MountMode)
+		}
 		if ud.Containerized() && ir.LocalMountPort == 0 {
 			// No use having the remote container actually mount, so let's have it create a bridge
 			// to the remote sftp server instead.
@@ -124,26 +176,47 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 			mountPoint = ""
 		}
 
-		if err = s.checkMountCapability(ctx); err != nil {
-			err = fmt.Errorf("remote volume mounts are disabled: %w", err)
-			if mountPoint != "" {
-				return nil, err
+		// Syncing and WebDAV don't need FUSE.
+		if fuseMode {
+			if err = s.checkMountCapability(ctx); err != nil {
+				err = fmt.Errorf("remote volume mounts are disabled: %w", err)
+				if mountPoint != "" {
+					return nil, err
+				}
+				// Log a warning and disable, but continue
+				s.mountDisabled = true
+				dlog.Warningf(ctx, `%v. Files can still be fetched using "telepresence fetch %s <path>"`, err, s.Name())
 			}
-			// Log a warning and disable, but continue
-			s.mountDisabled = true
-			dlog.Warning(ctx, err)
 		}
 
 		if !s.mountDisabled {
 			ir.LocalMountPort = int32(s.LocalMountPort)
-			if ir.LocalMountPort == 0 {
+			if ir.LocalMountPort == 0 && remoteHost != "" {
+				// The user daemon mounts on its own host, so it must prepare the mount point.
+				if mountPoint != "" && !path.IsAbs(mountPoint) {
+					return nil, errcat.User.Newf("mount point %s must be an absolute path when the user daemon runs on %s", mountPoint, remoteHost)
+				}
+				ir.MountPoint = mountPoint
+				ir.PrepareMountPoint = true
+				ir.MountMode = s.MountMode
+				ir.MountOptions = s.Fuse.MountOptions()
+				ir.MountReadOnly = s.MountReadOnlyRules
+			} else if ir.LocalMountPort == 0 {
 				var cwd string
 				if cwd, err = os.Getwd(); err != nil {
 					return nil, err
 				}
-				if ir.MountPoint, err = PrepareMount(cwd, mountPoint); err != nil {
+				if syncMode {
+					ir.MountPoint, err = prepareSyncDir(cwd, mountPoint)
+				} else {
+					ir.MountPoint, err = PrepareMount(cwd, mountPoint)
+				}
+				if err != nil {
 					return nil, err
 				}
+				ir.MountMode = s.MountMode
+				ir.MountOptions = s.Fuse.MountOptions()
+				ir.MountReadOnly = s.MountReadOnlyRules
 			}
 		}
 	}
@@ -176,7 +249,7 @@ func (s *state) Name() string {
 }
 
 func (s *state) RunAndLeave() bool {
-	return len(s.Cmdline) > 0 || s.DockerRun
+	return len(s.Cmdline) > 0 || s.DockerRun || s.DockerAttach != "" || s.HandOff
 }
 
 func (s *state) Run(ctx context.Context) (*Info, error) {
@@ -184,6 +257,9 @@ func (s *state) Run(ctx context.Context) (*Info, error) {
 	scout.Start(ctx)
 	defer scout.Close(ctx)
 
+	if s.DryRun {
+		return nil, s.dryRun(ctx)
+	}
 	if !s.RunAndLeave() {
 		err := client.WithEnsuredState(ctx, s.create, nil, nil)
 		if err != nil {
@@ -198,7 +274,24 @@ func (s *state) Run(ctx context.Context) (*Info, error) {
 			return nil, err
 		}
 	}
-	err := client.WithEnsuredState(ctx, s.create, s.runCommand, s.leave)
+	run := func(ctx context.Context) error {
+		err := s.runCommand(ctx)
+		s.handlerExited(ctx, err)
+		if s.AwaitHandlerExit {
+			err = errcat.WithExitCode(err, handlerExitCode(err))
+		}
+		return err
+	}
+	if s.HandOff {
+		if len(s.Cmdline) == 0 {
+			run = func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}
+		}
+		run = s.handingOff(run)
+	}
+	err := client.WithEnsuredState(ctx, s.create, run, s.leave)
 	if err != nil {
 		return nil, err
 	}
@@ -224,8 +317,9 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		scout.Report(ctx, "intercept_validation_fail", scout.Entry{Key: "error", Value: err.Error()})
 		return false, errcat.NoDaemonLogs.New(err)
 	}
+	s.event(ctx, EventPrepared, map[string]any{"workload": ir.Spec.Agent, "mountPoint": ir.MountPoint})
 
-	if ir.MountPoint != "" {
+	if ir.MountPoint != "" && !ir.PrepareMountPoint {
 		defer func() {
 			if !acquired && runtime.GOOS != "windows" {
 				// remove if empty
@@ -243,9 +337,12 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		}
 	}()
 
-	// Submit the request
+	// Submit the request, and show the progress of the traffic-agent's arrival while waiting for the result
+	stopProgress := s.watchAgentArrival(ctx, ir.Spec.Agent, ir.Spec.Namespace)
 	r, err := ud.CreateIntercept(ctx, ir)
-	if err = Result(r, err); err != nil {
+	err = Result(r, err)
+	stopProgress(err)
+	if err != nil {
 		return false, fmt.Errorf("connector.CreateIntercept: %w", err)
 	}
 
@@ -269,15 +366,19 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	scout.SetMetadatum(ctx, "service_namespace", r.GetInterceptInfo().GetSpec().GetNamespace())
 	intercept = r.InterceptInfo
 	scout.SetMetadatum(ctx, "intercept_id", intercept.Id)
-
-	s.env = intercept.Environment
-	if s.env == nil {
-		s.env = make(map[string]string)
-	}
+	output.Event(ctx, &output.LifecycleEvent{
+		Event: EventCreated,
+		ID:    intercept.Id,
+		Name:  intercept.Spec.Name,
+		Attrs: map[string]any{"workload": intercept.Spec.Agent, "workloadKind": r.WorkloadKind, "namespace": intercept.Spec.Namespace},
+	})
+
+	s.env = applyEnvRules(intercept.Environment, s.envRules(ctx))
 	s.env["TELEPRESENCE_INTERCEPT_ID"] = intercept.Id
 	s.env["TELEPRESENCE_ROOT"] = intercept.ClientMountPoint
+	s.aliases = networkAliases(intercept.Spec)
 	if s.EnvFile != "" {
-		if err = s.writeEnvFile(); err != nil {
+		if err = s.writeEnvFile(intercept.Spec.Namespace); err != nil {
 			return true, err
 		}
 	}
@@ -288,6 +389,10 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 	}
 
 	var volumeMountProblem error
+	if r.LocalMountPort != 0 {
+		// The user daemon shares one mount bridge between all intercepts of a pod.
+		ir.LocalMountPort = r.LocalMountPort
+	}
 	if ir.LocalMountPort != 0 {
 		intercept.PodIp = "127.0.0.1"
 		intercept.SftpPort = ir.LocalMountPort
@@ -302,6 +407,12 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		mountError = volumeMountProblem.Error()
 	}
 	s.info = NewInfo(ctx, intercept, mountError)
+
+	// The user daemon doesn't return from CreateIntercept until the intercept is active and its mounts are ready.
+	s.event(ctx, EventActive, map[string]any{"disposition": intercept.Disposition.String()})
+	if mountError == "" && (intercept.ClientMountPoint != "" || ir.LocalMountPort != 0) {
+		s.event(ctx, EventMountsReady, map[string]any{"mountPoint": intercept.ClientMountPoint, "localMountPort": ir.LocalMountPort})
+	}
 	if !s.Silent {
 		if detailedOutput {
 			output.Object(ctx, s.info, true)
@@ -325,39 +436,76 @@ func (s *state) leave(ctx context.Context) error {
 	if err != nil {
 		dlog.Errorf(ctx, "Leaving intercept ended with error %v", err)
 	}
-	return Result(r, err)
+	if err = Result(r, err); err == nil {
+		EmitRemoved(ctx, s.env["TELEPRESENCE_INTERCEPT_ID"], n, r.GetHandlerStopError())
+	}
+	return err
 }
 
 func (s *state) runCommand(ctx context.Context) error {
+	driftCtx, stopDriftWatch := context.WithCancel(ctx)
+	defer stopDriftWatch()
+	go s.watchEnvironmentDrift(driftCtx)
+
+	if s.DockerAttach != "" {
+		return s.runAttached(ctx)
+	}
+
 	// start the interceptor process
 	ud := daemon.GetUserClient(ctx)
+	changed, err := s.watchSources(ctx)
+	if err != nil {
+		return errcat.User.Newf("unable to watch sources: %w", err)
+	}
 	if !s.DockerRun {
-		cmd, err := proc.Start(ctx, s.env, s.Cmdline[0], s.Cmdline[1:]...)
-		if err != nil {
-			dlog.Errorf(ctx, "error interceptor starting process: %v", err)
-			return errcat.NoDaemonLogs.New(err)
-		}
-		if cmd == nil {
-			return nil
-		}
-		if err = s.addInterceptorToDaemon(ctx, cmd, ""); err != nil {
-			return err
-		}
+		for restarts := int32(0); ; {
+			cmd, err := proc.StartInDir(ctx, s.WorkingDir, s.env, s.Cmdline[0], s.Cmdline[1:]...)
+			if err != nil {
+				dlog.Errorf(ctx, "error interceptor starting process: %v", err)
+				return errcat.NoDaemonLogs.New(err)
+			}
+			if cmd == nil {
+				return nil
+			}
+			ior, err := s.addInterceptorToDaemon(ctx, cmd, "", restarts)
+			if err != nil {
+				return err
+			}
 
-		// The external command will not output anything to the logs. An error here
-		// is likely caused by the user hitting <ctrl>-C to terminate the process.
-		return errcat.NoDaemonLogs.New(proc.Wait(ctx, func() {}, cmd))
+			// The external command will not output anything to the logs. An error here
+			// is likely caused by the user hitting <ctrl>-C to terminate the process.
+			started := time.Now()
+			done := make(chan struct{})
+			replaced := stopOnChange(ctx, changed, done, nil, func() { _ = proc.Terminate(cmd.Process) })
+			sigCtx, sigCancel := context.WithCancel(ctx)
+			err = proc.Wait(sigCtx, sigCancel, cmd)
+			signalled := sigCtx.Err() != nil
+			sigCancel()
+			close(done)
+			if replaced() && ctx.Err() == nil {
+				continue
+			}
+			if signalled || !s.awaitRestart(ctx, ior, err, time.Since(started)) {
+				return errcat.NoDaemonLogs.New(err)
+			}
+			restarts++
+		}
 	}
 
 	envFile := s.EnvFile
-	if envFile == "" {
+	if envFile == "" || s.EnvSyntax.IsDocument() {
+		// Document syntaxes cannot be passed to the handler, so a temporary file is used instead.
+		syntax := s.EnvSyntax
+		if syntax.IsDocument() {
+			syntax = envSyntaxDocker
+		}
 		file, err := os.CreateTemp("", "tel-*.env")
 		if err != nil {
 			return fmt.Errorf("failed to create temporary environment file. %w", err)
 		}
 		defer os.Remove(file.Name())
 
-		if err = s.writeEnvToFileAndClose(file); err != nil {
+		if err = s.writeEnvToFileAndClose(file, syntax); err != nil {
 			return err
 		}
 		envFile = file.Name()
@@ -381,19 +529,6 @@ func (s *state) runCommand(ctx context.Context) error {
 		return errcat.User.New(err)
 	}
 
-	spin := spinner.New(ctx, "container "+name)
-	spin.Message("starting")
-	dr := s.startInDocker(procCtx, name, envFile, args)
-	if dr.err == nil {
-		dr.err = s.addInterceptorToDaemon(ctx, dr.cmd, dr.name)
-		spin.Message("started")
-		spin.DoneMsg(s.WaitMessage)
-		if s.WaitMessage != "" && spin.IsNoOp() {
-			ioutil.Println(dos.Stdout(ctx), s.WaitMessage)
-		}
-	} else {
-		_ = spin.Error(dr.err)
-	}
 	go func() {
 		_, _ = io.Copy(dos.Stdout(ctx), outRdr)
 	}()
@@ -401,34 +536,119 @@ func (s *state) runCommand(ctx context.Context) error {
 		_, _ = io.Copy(dos.Stderr(ctx), errRdr)
 	}()
 
-	if err := dr.wait(procCtx); err != nil {
-		return spin.Error(err)
+	if ud.Containerized() && len(s.aliases) > 0 {
+		// The handler shares the network of the daemon container, so other containers reach it using aliases
+		// of the daemon container.
+		if err := docker.AddNetworkAliases(docker.EnableClient(ctx), "telepresence", ud.DaemonID().ContainerName(), s.aliases); err != nil {
+			dlog.Warnf(ctx, "unable to add network aliases %v: %v", s.aliases, err)
+		}
+	}
+
+	var rebuild func() bool
+	if s.DockerBuild != "" || s.DockerDebug != "" {
+		rebuild = func() bool { return s.rebuildImage(ctx) }
+	}
+	for restarts := int32(0); ; {
+		spin := spinner.New(ctx, "container "+name)
+		spin.Message("starting")
+		dr := s.startInDocker(procCtx, name, envFile, args)
+		var ior *connector.Interceptor
+		if dr.err == nil {
+			ior, dr.err = s.addInterceptorToDaemon(ctx, dr.cmd, dr.name, restarts)
+			spin.Message("started")
+			spin.DoneMsg(s.WaitMessage)
+			if s.WaitMessage != "" && spin.IsNoOp() {
+				ioutil.Println(dos.Stdout(ctx), s.WaitMessage)
+			}
+		} else {
+			_ = spin.Error(dr.err)
+		}
+
+		started := time.Now()
+		done := make(chan struct{})
+		replaced := stopOnChange(procCtx, changed, done, rebuild, func() { _ = docker.StopContainer(docker.EnableClient(procCtx), dr.name) })
+		err := dr.wait(procCtx)
+		close(done)
+		if ior != nil && replaced() && procCtx.Err() == nil {
+			spin.Done()
+			if _, args, err = s.getContainerName(s.Cmdline); err != nil {
+				return errcat.User.New(err)
+			}
+			continue
+		}
+		if ior == nil || dr.signalled || !s.awaitRestart(procCtx, ior, err, time.Since(started)) {
+			if err != nil {
+				return spin.Error(err)
+			}
+			spin.Done()
+			return nil
+		}
+		restarts++
 	}
-	spin.Done()
-	return nil
 }
 
-func (s *state) addInterceptorToDaemon(ctx context.Context, cmd *dexec.Cmd, containerName string) error {
-	// setup cleanup for the interceptor process
-	ior := connector.Interceptor{
+func (s *state) addInterceptorToDaemon(ctx context.Context, cmd *dexec.Cmd, containerName string, restarts int32) (*connector.Interceptor, error) {
+	// setup cleanup for the interceptor process. The cmd is nil when the container of --docker-attach
+	// was started by someone else.
+	ior := &connector.Interceptor{
 		InterceptId:   s.env["TELEPRESENCE_INTERCEPT_ID"],
-		Pid:           int32(cmd.Process.Pid),
 		ContainerName: containerName,
+		Restarts:      restarts,
+	}
+	if cmd != nil {
+		ior.Pid = int32(cmd.Process.Pid)
 	}
 
 	// Send info about the pid and intercept id to the traffic-manager so that it kills
 	// the process if it receives a leave of quit call.
-	if _, err := daemon.GetUserClient(ctx).AddInterceptor(ctx, &ior); err != nil {
+	if _, err := daemon.GetUserClient(ctx).AddInterceptor(ctx, ior); err != nil {
 		if grpcStatus.Code(err) == grpcCodes.Canceled {
 			// Deactivation was caused by a disconnect
 			err = nil
 		} else {
 			dlog.Errorf(ctx, "error adding process with pid %d as interceptor: %v", ior.Pid, err)
 		}
-		_ = cmd.Process.Kill()
-		return err
+		if cmd != nil {
+			_ = cmd.Process.Kill()
+		}
+		return nil, err
 	}
-	return nil
+	attrs := map[string]any{"pid": ior.Pid, "restarts": ior.Restarts}
+	if containerName != "" {
+		attrs["containerName"] = containerName
+	}
+	s.event(ctx, EventHandlerStarted, attrs)
+	return ior, nil
+}
+
+// handlerExited tells the user daemon that the intercept handler has exited for good, so that it can publish
+// the exit code to the clients that watch its events.
+func (s *state) handlerExited(ctx context.Context, exitErr error) {
+	id := s.env["TELEPRESENCE_INTERCEPT_ID"]
+	if id == "" {
+		return
+	}
+	ior := &connector.Interceptor{InterceptId: id, ExitCode: int32(handlerExitCode(exitErr))}
+	if _, err := daemon.GetUserClient(ctx).RemoveInterceptor(context.WithoutCancel(ctx), ior); err != nil {
+		dlog.Debugf(ctx, "unable to report exit of the handler of intercept %s: %v", s.Name(), err)
+	}
+}
+
+// event prints a lifecycle event for the intercept when `--output=jsonl-events` is used.
+func (s *state) event(ctx context.Context, event string, attrs map[string]any) {
+	output.Event(ctx, &output.LifecycleEvent{Event: event, ID: s.env["TELEPRESENCE_INTERCEPT_ID"], Name: s.Name(), Attrs: attrs})
+}
+
+// prepareSyncDir returns the local directory that the remote volumes are synced with, after ensuring that it
+// exists. Unlike a mount point, it is always a directory, also on Windows.
+func prepareSyncDir(cwd string, dir string) (string, error) {
+	if dir == "" {
+		return os.MkdirTemp("", "telsync-")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Clean(filepath.Join(cwd, dir))
+	}
+	return dir, os.MkdirAll(dir, 0o700)
 }
 
 func (s *state) checkMountCapability(ctx context.Context) error {
@@ -439,15 +659,19 @@ func (s *state) checkMountCapability(ctx context.Context) error {
 	return errcat.FromResult(r)
 }
 
-func (s *state) writeEnvFile() error {
+func (s *state) writeEnvFile(namespace string) error {
 	file, err := os.Create(s.EnvFile)
 	if err != nil {
 		return errcat.NoDaemonLogs.Newf("failed to create environment file %q: %w", s.EnvFile, err)
 	}
-	return s.writeEnvToFileAndClose(file)
+	if s.EnvSyntax.IsDocument() {
+		defer file.Close()
+		return s.EnvSyntax.WriteDocument(file, s.Name(), namespace, s.env)
+	}
+	return s.writeEnvToFileAndClose(file, s.EnvSyntax)
 }
 
-func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
+func (s *state) writeEnvToFileAndClose(file *os.File, syntax EnvironmentSyntax) (err error) {
 	defer file.Close()
 	w := bufio.NewWriter(file)
 
@@ -460,7 +684,7 @@ func (s *state) writeEnvToFileAndClose(file *os.File) (err error) {
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		r, err := s.EnvSyntax.WriteEnv(k, s.env[k])
+		r, err := syntax.WriteEnv(k, s.env[k])
 		if err != nil {
 			return err
 		}
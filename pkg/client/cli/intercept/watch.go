@@ -0,0 +1,154 @@
+package intercept
+
+import (
+	"context"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// sourceChangeDelay is how long the source watcher waits for more changes before it reports a change.
+const sourceChangeDelay = 300 * time.Millisecond
+
+// watchSources watches the files and directories given with --watch, and returns a channel that receives
+// a value when they change. Directories are watched recursively, except for hidden directories such as
+// .git. A nil channel is returned when no paths were given.
+func (s *state) watchSources(ctx context.Context) (<-chan struct{}, error) {
+	if len(s.Watch) == 0 {
+		return nil, nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Single files are watched using their directory, because editors often replace the file
+	// when it's saved, and a watch that follows the original file would then be lost.
+	files := make(map[string]struct{})
+	dirs := make(map[string]struct{})
+	for _, path := range s.Watch {
+		if path, err = filepath.Abs(path); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		if fi.IsDir() {
+			err = addWatchDirs(watcher, dirs, path)
+		} else {
+			files[path] = struct{}{}
+			err = watcher.Add(filepath.Dir(path))
+		}
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	isOfInterest := func(path string) bool {
+		if isHidden(filepath.Base(path)) {
+			return false
+		}
+		if _, ok := files[path]; ok {
+			return true
+		}
+		_, ok := dirs[filepath.Dir(path)]
+		return ok
+	}
+
+	changed := make(chan struct{}, 1)
+	delay := time.AfterFunc(time.Duration(math.MaxInt64), func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	go func() {
+		defer watcher.Close()
+		defer delay.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-watcher.Errors:
+				dlog.Errorf(ctx, "source watcher: %v", err)
+			case event := <-watcher.Events:
+				if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 || !isOfInterest(event.Name) {
+					continue
+				}
+				if event.Op&fsnotify.Create != 0 {
+					if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+						if err = addWatchDirs(watcher, dirs, event.Name); err != nil {
+							dlog.Errorf(ctx, "source watcher: %v", err)
+						}
+					}
+				}
+				delay.Reset(sourceChangeDelay)
+			}
+		}
+	}()
+	return changed, nil
+}
+
+// addWatchDirs adds the given directory, and all its subdirectories that aren't hidden, to the watcher
+// and to the given set of watched directories.
+func addWatchDirs(watcher *fsnotify.Watcher, dirs map[string]struct{}, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && isHidden(d.Name()) {
+			return filepath.SkipDir
+		}
+		dirs[path] = struct{}{}
+		return watcher.Add(path)
+	})
+}
+
+func isHidden(name string) bool {
+	return len(name) > 1 && strings.HasPrefix(name, ".")
+}
+
+// stopOnChange calls stop when the sources change before done is closed. If prepare is non-nil, it's called
+// first, and stop is only called when prepare returns true. The returned function reports whether stop
+// was called.
+func stopOnChange(ctx context.Context, changed <-chan struct{}, done <-chan struct{}, prepare func() bool, stop func()) func() bool {
+	var stopped atomic.Bool
+	if changed == nil {
+		return stopped.Load
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-changed:
+				if prepare != nil && !prepare() {
+					continue
+				}
+				ioutil.Println(dos.Stderr(ctx), "Sources changed. Restarting intercept handler")
+				stopped.Store(true)
+				stop()
+				return
+			}
+		}
+	}()
+	return stopped.Load
+}
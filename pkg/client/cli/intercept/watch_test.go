@@ -0,0 +1,93 @@
+package intercept
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func Test_watchSources(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "pkg"), 0o755))
+	s := &state{Command: &Command{Watch: []string{dir}}}
+	changed, err := s.watchSources(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, changed)
+
+	expectChange := func(want bool) {
+		t.Helper()
+		select {
+		case <-changed:
+			assert.True(t, want, "unexpected change")
+		case <-time.After(3 * sourceChangeDelay):
+			assert.False(t, want, "no change was reported")
+		}
+	}
+
+	// Changes in hidden directories are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "index"), []byte("x"), 0o644))
+	expectChange(false)
+
+	// Changes in subdirectories are reported, and several changes are coalesced into one.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "a.go"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "b.go"), []byte("b"), 0o644))
+	expectChange(true)
+	expectChange(false)
+
+	// Directories that are created after the watch started are watched too.
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "cmd"), 0o755))
+	expectChange(true)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "main.go"), []byte("main"), 0o644))
+	expectChange(true)
+}
+
+func Test_watchSourcesNone(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := &state{Command: &Command{}}
+	changed, err := s.watchSources(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, changed)
+	assert.False(t, stopOnChange(ctx, changed, nil, nil, func() { t.Fatal("stop called") })())
+}
+
+func Test_stopOnChange(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	changed := make(chan struct{}, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	builds := 0
+	prepare := func() bool {
+		builds++
+		return builds > 1
+	}
+	replaced := stopOnChange(ctx, changed, done, prepare, func() { close(stopped) })
+
+	// A failed prepare keeps the handler running.
+	changed <- struct{}{}
+	select {
+	case <-stopped:
+		t.Fatal("stop called after failed prepare")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.False(t, replaced())
+
+	changed <- struct{}{}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop was never called")
+	}
+	assert.True(t, replaced())
+	close(done)
+}
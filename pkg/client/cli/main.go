@@ -12,6 +12,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/podd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	userDaemon "github.com/telepresenceio/telepresence/v2/pkg/client/userd/daemon"
@@ -37,6 +38,10 @@ func InitContext(ctx context.Context) context.Context {
 		}
 		ctx = userd.WithNewServiceFunc(ctx, userDaemon.NewService)
 		ctx = userd.WithNewSessionFunc(ctx, trafficmgr.NewSession)
+	case podd.ProcessName:
+		client.DisplayName = "OSS Pod Daemon"
+		ctx = userd.WithNewServiceFunc(ctx, userDaemon.NewService)
+		ctx = userd.WithNewSessionFunc(ctx, trafficmgr.NewSession)
 	case rootd.ProcessName:
 		client.DisplayName = "OSS Root Daemon"
 		ctx = rootd.WithNewServiceFunc(ctx, rootd.NewService)
@@ -72,7 +77,7 @@ func Main(ctx context.Context) {
 	} else {
 		if cmd, fmtOutput, err := output.Execute(cmd.Telepresence(ctx)); err != nil {
 			if fmtOutput {
-				os.Exit(1)
+				os.Exit(errcat.GetExitCode(err))
 			}
 			fmt.Fprintf(cmd.ErrOrStderr(), "%s: error: %v\n", cmd.CommandPath(), err)
 			if errcat.GetCategory(err) > errcat.NoDaemonLogs {
@@ -86,7 +91,7 @@ func Main(ctx context.Context) {
 						"https://github.com/telepresenceio/telepresence/issues/new?template=Bug_report.md .")
 				}
 			}
-			os.Exit(1)
+			os.Exit(errcat.GetExitCode(err))
 		}
 	}
 }
@@ -1,5 +1,5 @@
 // Package output provides structured output for *cobra.Command.
-// Formatted output is enabled by setting the --output=[json|yaml] flag.
+// Formatted output is enabled by setting the --output=[json|yaml|json-stream|jsonl-events] flag.
 package output
 
 import (
@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-json-experiment/json"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
 
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -83,6 +86,33 @@ func Object(ctx context.Context, obj any, override bool) {
 	}
 }
 
+// LifecycleEvent is a line in the output of a command that was started with `--output=jsonl-events`.
+type LifecycleEvent struct {
+	Event string         `json:"event"`
+	Time  time.Time      `json:"time"`
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+	Err   string         `json:"err,omitempty"`
+}
+
+// Event prints the given event as a single line of JSON on stdout when lifecycle events are requested
+// using the `--output=jsonl-events` flag. Otherwise, this function does nothing. The Time of the event
+// is set to the current time unless it's already set.
+func Event(ctx context.Context, ev *LifecycleEvent) {
+	if cmd, ok := ctx.Value(key{}).(*cobra.Command); ok {
+		if o, ok := cmd.OutOrStdout().(*output); ok && o.format == formatJSONLEvents {
+			o.writeEvent(ev)
+		}
+	}
+}
+
+// WantsEvents returns true if the value of the global `--output` flag is set to "jsonl-events".
+func WantsEvents(cmd *cobra.Command) bool {
+	f, _ := validateFlag(cmd)
+	return f == formatJSONLEvents
+}
+
 // DefaultYAML is a PersistentPRERunE function that will change the default output
 // format to "yaml" for the command that invokes it.
 func DefaultYAML(cmd *cobra.Command, _ []string) error {
@@ -158,6 +188,10 @@ func Execute(cmd *cobra.Command) (*cobra.Command, bool, error) {
 			panic(encErr)
 		}
 	case formatJSONStream:
+	case formatJSONLEvents:
+		if err != nil {
+			o.writeEvent(&LifecycleEvent{Event: "error", Err: err.Error()})
+		}
 	default:
 		fmt.Fprintf(o.originalStdout, "%+v", obj)
 	}
@@ -173,13 +207,19 @@ func setFormat(cmd *cobra.Command) {
 		if err != nil {
 			return err
 		}
+		if fmt == formatJSONLEvents && cmd.Annotations[ann.LifecycleEvents] != ann.Supported {
+			return errcat.User.Newf("output format \"jsonl-events\" is not supported by the %s command", cmd.Name())
+		}
 		if fmt != formatDefault {
 			o := output{
 				format:         fmt,
 				originalStdout: cmd.OutOrStdout(),
 			}
 			cmd.SetOut(&o)
-			cmd.SetErr(&bytes.Buffer{})
+			if fmt != formatJSONLEvents {
+				// Lifecycle events are interleaved with live output on stderr.
+				cmd.SetErr(&bytes.Buffer{})
+			}
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 		}
@@ -211,6 +251,8 @@ func validateFlag(cmd *cobra.Command) (format, error) {
 			return formatJSON, nil
 		case "json-stream":
 			return formatJSONStream, nil
+		case "jsonl-events":
+			return formatJSONLEvents, nil
 		case "default":
 			return formatDefault, nil
 		default:
@@ -229,6 +271,7 @@ type (
 		obj            any
 		override       bool
 		originalStdout io.Writer
+		eventLock      sync.Mutex
 	}
 	object struct {
 		Cmd    string `json:"cmd"`
@@ -243,6 +286,7 @@ const (
 	formatJSON
 	formatYAML
 	formatJSONStream
+	formatJSONLEvents
 )
 
 func (o *output) Write(data []byte) (int, error) {
@@ -252,6 +296,19 @@ func (o *output) Write(data []byte) (int, error) {
 	return o.Buffer.Write(data)
 }
 
+func (o *output) writeEvent(ev *LifecycleEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		panic(err)
+	}
+	o.eventLock.Lock()
+	defer o.eventLock.Unlock()
+	_, _ = o.originalStdout.Write(append(data, '\n'))
+}
+
 func (o *object) hasCmdOnly() bool {
 	return o.Stdout == nil && o.Stderr == nil && o.Err == ""
 }
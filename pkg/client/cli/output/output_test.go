@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/yaml"
 
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
 	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
 )
@@ -215,4 +216,46 @@ func TestWithOutput(t *testing.T) {
 		require.Empty(t, m["stderr"], "did not get empty stderr")
 		require.Equal(t, m["err"], "this went south")
 	})
+
+	t.Run("jsonl-events output", func(t *testing.T) {
+		cmd, outBuf, errBuf := newCmdWithBufs()
+		cmd.Annotations = map[string]string{ann.LifecycleEvents: ann.Supported}
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			Event(ctx, &LifecycleEvent{Event: "created", ID: "abc", Name: "hello"})
+			fmt.Fprintln(cmd.OutOrStdout(), "hello")
+			fmt.Fprintln(cmd.ErrOrStderr(), "progress")
+			Event(ctx, &LifecycleEvent{Event: "removed", ID: "abc", Name: "hello"})
+			return errors.New("this went south")
+		}
+		cmd.SetArgs([]string{"--output=jsonl-events"})
+		_, _, err := Execute(cmd)
+		require.Error(t, err)
+
+		lines := strings.Split(strings.TrimSpace(outBuf.String()), "\n")
+		require.Len(t, lines, 3)
+		var events []LifecycleEvent
+		for _, line := range lines {
+			var ev LifecycleEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &ev), "did not get json line, got: %s", line)
+			require.False(t, ev.Time.IsZero())
+			events = append(events, ev)
+		}
+		require.Equal(t, "created", events[0].Event)
+		require.Equal(t, "abc", events[0].ID)
+		require.Equal(t, "hello", events[0].Name)
+		require.Equal(t, "removed", events[1].Event)
+		require.Equal(t, "error", events[2].Event)
+		require.Equal(t, "this went south", events[2].Err)
+
+		// stderr is not buffered
+		require.Equal(t, "progress\n", errBuf.String())
+	})
+
+	t.Run("jsonl-events output not supported", func(t *testing.T) {
+		cmd, _, _ := newCmdWithBufs()
+		cmd.SetArgs([]string{"--output=jsonl-events"})
+		_, _, err := Execute(cmd)
+		require.ErrorContains(t, err, `output format "jsonl-events" is not supported`)
+	})
 }
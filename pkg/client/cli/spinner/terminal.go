@@ -0,0 +1,131 @@
+package spinner
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// frames are the characters that a terminal spinner cycles through while it spins.
+var frames = []rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'} //nolint:gochecknoglobals // constant
+
+const frameInterval = 100 * time.Millisecond
+
+// NewTerminalProvider returns a Provider of spinners that redraw a single line on the given writer, which
+// must be a terminal.
+func NewTerminalProvider(out io.Writer) Provider {
+	return terminalProvider{out: out}
+}
+
+type terminalProvider struct {
+	out io.Writer
+}
+
+func (p terminalProvider) New(job string) Spinner {
+	return &terminal{out: p.out, job: job}
+}
+
+type terminal struct {
+	sync.Mutex
+	out   io.Writer
+	job   string
+	msg   string
+	frame int
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func (t *terminal) Helper() {
+}
+
+func (t *terminal) Start() {
+	t.Lock()
+	defer t.Unlock()
+	if t.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	t.stop, t.done = stop, done
+	t.draw(string(frames[0]), "")
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(frameInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				t.Lock()
+				t.frame = (t.frame + 1) % len(frames)
+				t.draw(string(frames[t.frame]), "")
+				t.Unlock()
+			}
+		}
+	}()
+}
+
+func (t *terminal) Done() {
+	t.finish("✔", "")
+}
+
+func (t *terminal) IsNoOp() bool {
+	return false
+}
+
+func (t *terminal) DoneMsg(msg string) {
+	t.Lock()
+	if msg != "" {
+		t.msg = msg
+	}
+	t.Unlock()
+	t.finish("✔", "")
+}
+
+func (t *terminal) Error(err error) error {
+	t.finish("✘", err.Error())
+	return err
+}
+
+func (t *terminal) Message(msg string) {
+	t.Lock()
+	defer t.Unlock()
+	t.msg = msg
+	if t.stop != nil {
+		t.draw(string(frames[t.frame]), "")
+	}
+}
+
+// finish stops the spinner and replaces it with the given icon, followed by the job, the current message,
+// and the given error message, and a newline.
+func (t *terminal) finish(icon, errMsg string) {
+	t.Lock()
+	stop, done := t.stop, t.done
+	t.stop = nil
+	t.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+	t.Lock()
+	t.draw(icon, errMsg)
+	fmt.Fprintln(t.out)
+	t.Unlock()
+}
+
+// draw overwrites the current line with the given icon, the job, the current message, and the given error
+// message. It must be called with the lock held.
+func (t *terminal) draw(icon, errMsg string) {
+	line := icon + " " + t.job
+	if t.msg != "" {
+		line += ": " + t.msg
+	}
+	if errMsg != "" {
+		line += ": " + errMsg
+	}
+	// Return to the start of the line, and clear to its end after writing.
+	fmt.Fprintf(t.out, "\r%s\x1b[K", line)
+}
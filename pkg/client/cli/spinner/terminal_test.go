@@ -0,0 +1,54 @@
+package spinner
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	sync.Mutex
+	strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Builder.Write(p)
+}
+
+// lastLine returns the text that a terminal shows on the last line that was terminated by a newline.
+func (b *syncBuffer) lastLine() string {
+	b.Lock()
+	defer b.Unlock()
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	line := lines[len(lines)-1]
+	line = line[strings.LastIndexByte(line, '\r')+1:]
+	return strings.TrimSuffix(line, "\x1b[K")
+}
+
+func TestTerminal(t *testing.T) {
+	out := &syncBuffer{}
+	p := NewTerminalProvider(out)
+
+	spin := p.New("traffic-agent of echo")
+	assert.False(t, spin.IsNoOp())
+	spin.Start()
+	spin.Message("pod echo-abcde scheduled")
+	spin.DoneMsg("traffic-agent arrived")
+	assert.Equal(t, "✔ traffic-agent of echo: traffic-agent arrived", out.lastLine())
+
+	// A stopped spinner doesn't print anything.
+	spin.Done()
+	assert.Equal(t, "✔ traffic-agent of echo: traffic-agent arrived", out.lastLine())
+
+	spin = p.New("traffic-agent of db")
+	spin.Start()
+	spin.Message("pod db-0 terminating")
+	err := errors.New("no traffic-agent arrived")
+	assert.Equal(t, err, spin.Error(err))
+	assert.Equal(t, "✘ traffic-agent of db: pod db-0 terminating: no traffic-agent arrived", out.lastLine())
+}
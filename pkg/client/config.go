@@ -109,6 +109,7 @@ type Config interface {
 	Cluster() *Cluster
 	DNS() *DNS
 	Routing() *Routing
+	Upgrade() *Upgrade
 	DestructiveMerge(Config)
 	Merge(priority Config) Config
 }
@@ -125,6 +126,7 @@ type BaseConfig struct {
 	ClusterV         Cluster         `json:"cluster,omitzero"`
 	DNSV             DNS             `json:"dns,omitzero"`
 	RoutingV         Routing         `json:"routing,omitzero"`
+	UpgradeV         Upgrade         `json:"upgrade,omitzero"`
 }
 
 func (c *BaseConfig) OSSpecific() *OSSpecificConfig {
@@ -171,6 +173,10 @@ func (c *BaseConfig) Routing() *Routing {
 	return &c.RoutingV
 }
 
+func (c *BaseConfig) Upgrade() *Upgrade {
+	return &c.UpgradeV
+}
+
 func (c *BaseConfig) MarshalYAML() ([]byte, error) {
 	data, err := MarshalJSON(c)
 	if err == nil {
@@ -251,6 +257,7 @@ func (c *BaseConfig) DestructiveMerge(lc Config) {
 	c.ClusterV.merge(lc.Cluster())
 	c.DNSV.merge(lc.DNS())
 	c.RoutingV.merge(lc.Routing())
+	c.UpgradeV.merge(lc.Upgrade())
 }
 
 func (c *BaseConfig) Merge(lc Config) Config {
@@ -339,6 +346,10 @@ type Timeouts struct {
 	PrivateFtpReadWrite time.Duration `json:"ftpReadWrite"`
 	// PrivateFtpShutdown max time to wait for the fuseftp client to complete pending operations before forcing termination.
 	PrivateFtpShutdown time.Duration `json:"ftpShutdown"`
+	// PrivateSessionResume is how long the traffic-manager may be unreachable before the session is replaced by a new one.
+	PrivateSessionResume time.Duration `json:"sessionResume"`
+	// PrivateHandlerStop is how long to wait for an intercept handler to stop, including the time spent in its pre-stop hook.
+	PrivateHandlerStop time.Duration `json:"handlerStop"`
 }
 
 type TimeoutID int
@@ -355,6 +366,8 @@ const (
 	TimeoutTrafficManagerConnect
 	TimeoutFtpReadWrite
 	TimeoutFtpShutdown
+	TimeoutSessionResume
+	TimeoutHandlerStop
 )
 
 type timeoutContext struct {
@@ -401,6 +414,10 @@ func (t *Timeouts) Get(timeoutID TimeoutID) time.Duration {
 		timeoutVal = t.PrivateFtpReadWrite
 	case TimeoutFtpShutdown:
 		timeoutVal = t.PrivateFtpShutdown
+	case TimeoutSessionResume:
+		timeoutVal = t.PrivateSessionResume
+	case TimeoutHandlerStop:
+		timeoutVal = t.PrivateHandlerStop
 	default:
 		panic("should not happen")
 	}
@@ -461,6 +478,12 @@ func (e timeoutError) Error() string {
 	case TimeoutFtpShutdown:
 		yamlName = "ftpShutdown"
 		humanName = "FTP client shutdown grace period"
+	case TimeoutSessionResume:
+		yamlName = "sessionResume"
+		humanName = "traffic manager session resumption"
+	case TimeoutHandlerStop:
+		yamlName = "handlerStop"
+		humanName = "intercept handler stop"
 	default:
 		panic("should not happen")
 	}
@@ -491,6 +514,8 @@ const (
 	defaultTimeoutsTrafficManagerConnect = 60 * time.Second
 	defaultTimeoutsFtpReadWrite          = 1 * time.Minute
 	defaultTimeoutsFtpShutdown           = 2 * time.Minute
+	defaultTimeoutsSessionResume         = 2 * time.Minute
+	defaultTimeoutsHandlerStop           = 10 * time.Second
 )
 
 var defaultTimeouts = Timeouts{ //nolint:gochecknoglobals // constant
@@ -505,6 +530,8 @@ var defaultTimeouts = Timeouts{ //nolint:gochecknoglobals // constant
 	PrivateTrafficManagerConnect: defaultTimeoutsTrafficManagerConnect,
 	PrivateFtpReadWrite:          defaultTimeoutsFtpReadWrite,
 	PrivateFtpShutdown:           defaultTimeoutsFtpShutdown,
+	PrivateSessionResume:         defaultTimeoutsSessionResume,
+	PrivateHandlerStop:           defaultTimeoutsHandlerStop,
 }
 
 func (t *Timeouts) defaults() DefaultsAware {
@@ -650,6 +677,18 @@ type Grpc struct {
 	// MaxReceiveSize is the maximum message size in bytes the client can receive in a gRPC call or stream message.
 	// Overrides the gRPC default of 4MB.
 	MaxReceiveSizeV resource.Quantity `json:"maxReceiveSize"`
+
+	// TunnelCompression is the list of compression algorithms that the client offers to the traffic-manager for
+	// its tunnels, in order of preference. Valid algorithms are "zstd" and "gzip". No compression is used when empty.
+	TunnelCompression []string `json:"tunnelCompression,omitempty"`
+
+	// GatewayPort is the localhost port of an HTTP+JSON gateway to the connector API that the user daemon serves.
+	// The gateway is disabled when the port is zero.
+	GatewayPort int `json:"gatewayPort,omitempty"`
+
+	// RemoteControl configures a TLS-protected TCP listener that lets a CLI on another host control the user
+	// daemon, and the TLS of such a CLI.
+	RemoteControl RemoteControl `json:"remoteControl,omitzero"`
 }
 
 func (g *Grpc) MaxReceiveSize() int64 {
@@ -665,11 +704,18 @@ func (g *Grpc) merge(o *Grpc) {
 	if !o.MaxReceiveSizeV.IsZero() {
 		g.MaxReceiveSizeV = o.MaxReceiveSizeV
 	}
+	if o.TunnelCompression != nil {
+		g.TunnelCompression = o.TunnelCompression
+	}
+	if o.GatewayPort != 0 {
+		g.GatewayPort = o.GatewayPort
+	}
+	g.RemoteControl.merge(&o.RemoteControl)
 }
 
 // IsZero controls whether this element will be included in marshalled output.
 func (g *Grpc) IsZero() bool {
-	return g == nil || g.MaxReceiveSizeV.IsZero()
+	return g == nil || g.MaxReceiveSizeV.IsZero() && len(g.TunnelCompression) == 0 && g.GatewayPort == 0 && g.RemoteControl == RemoteControl{}
 }
 
 type TelepresenceAPI struct {
@@ -712,6 +758,74 @@ type Intercept struct {
 	DefaultPort         int                        `json:"defaultPort"`
 	UseFtp              bool                       `json:"useFtp"`
 	Telemount           DockerImage                `json:"telemount,omitzero"`
+
+	// Propagation lists the tracing standards that, in addition to plain headers, may carry the headers that
+	// identify a personal intercept. Valid values are "baggage", "traceparent", and "b3".
+	Propagation []string `json:"propagation"`
+
+	// PreStopHook is a command, with arguments, that is run before an intercept handler is stopped when its
+	// intercept is removed, or when the session ends. The handler is stopped when the hook returns.
+	PreStopHook []string `json:"preStopHook"`
+
+	// DashboardPort is the localhost port of a web dashboard that the user daemon serves while connected. The
+	// dashboard shows the session, its intercepts, and the requests matched by the Telepresence API. It is
+	// disabled when the port is zero.
+	DashboardPort int `json:"dashboardPort"`
+
+	// Env contains rules that are applied to the environment of an intercept before it is written to an
+	// env-file or passed to the intercept handler.
+	Env InterceptEnv `json:"env,omitzero"`
+
+	// Fuse contains options that tune the FUSE file systems of the remote mounts.
+	Fuse FuseOptions `json:"fuse,omitzero"`
+}
+
+// InterceptEnv contains rules that exclude, rename, or prefix the environment variables of an intercept.
+type InterceptEnv struct {
+	// Exclude lists names of variables that are removed from the environment. A name may contain the
+	// wildcards understood by path.Match, e.g. "KUBERNETES_*".
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Rename maps the names of variables to the names that they are given in the environment.
+	Rename map[string]string `json:"rename,omitempty"`
+
+	// Prefix is prepended to the names of all variables that aren't renamed.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// FuseOptions tunes the FUSE file systems that are used when the remote volumes of an intercept are mounted.
+type FuseOptions struct {
+	// AttrTimeout is the time that the kernel caches the attributes of files and directories.
+	AttrTimeout time.Duration `json:"attrTimeout,omitzero"`
+
+	// EntryTimeout is the time that the kernel caches the results of name lookups.
+	EntryTimeout time.Duration `json:"entryTimeout,omitzero"`
+
+	// KernelCache makes the kernel keep the content of files cached between opens.
+	KernelCache bool `json:"kernelCache,omitempty"`
+
+	// Options are additional options that are passed verbatim using "-o", e.g. "max_read=131072".
+	Options []string `json:"options,omitempty"`
+}
+
+// IsZero returns true if no options have been set.
+func (f *FuseOptions) IsZero() bool {
+	return f.AttrTimeout == 0 && f.EntryTimeout == 0 && !f.KernelCache && len(f.Options) == 0
+}
+
+// MountOptions returns the options in the form that they are given to a FUSE mount using "-o".
+func (f *FuseOptions) MountOptions() []string {
+	var opts []string
+	if f.AttrTimeout > 0 {
+		opts = append(opts, fmt.Sprintf("attr_timeout=%g", f.AttrTimeout.Seconds()))
+	}
+	if f.EntryTimeout > 0 {
+		opts = append(opts, fmt.Sprintf("entry_timeout=%g", f.EntryTimeout.Seconds()))
+	}
+	if f.KernelCache {
+		opts = append(opts, "kernel_cache")
+	}
+	return append(opts, f.Options...)
 }
 
 func (ic *Intercept) defaults() DefaultsAware {
@@ -725,7 +839,7 @@ func (ic *Intercept) merge(o *Intercept) {
 
 // IsZero controls whether this element will be included in marshalled output.
 func (ic *Intercept) IsZero() bool {
-	return ic == nil || *ic == defaultIntercept
+	return ic == nil || isDefault(ic)
 }
 
 func (ic *Intercept) MarshalJSONV2(out *jsontext.Encoder, opts json.Options) error {
@@ -748,17 +862,47 @@ type Cluster struct {
 	ForceSPDY               bool     `json:"forceSPDY"`
 	AgentPortForward        bool     `json:"agentPortForward"`
 	VirtualIPSubnet         string   `json:"virtualIPSubnet"`
+	UserspaceNetworking     bool     `json:"userspaceNetworking"`
+	SOCKSProxyAddress       string   `json:"socksProxyAddress"`
+
+	// ManagerWebSocketURL is a wss:// URL that reaches the traffic-manager's gRPC API over WebSocket. When
+	// set, it is used instead of a port-forward to the traffic-manager, and port-forwards to traffic-agents are
+	// disabled.
+	ManagerWebSocketURL string `json:"managerWebSocketURL"`
+
+	// ManagerEndpoint is the host and optional port, 443 by default, of an Ingress or Gateway API route that exposes
+	// the traffic-manager's gRPC API using TLS. When set, it is used instead of a port-forward to the traffic-manager,
+	// and port-forwards to traffic-agents are disabled.
+	ManagerEndpoint string `json:"managerEndpoint"`
+
+	// ManagerCAFile is a PEM file with the certificate authorities used to verify the certificate of the
+	// ManagerEndpoint. The system's certificate authorities are used when it's empty.
+	ManagerCAFile string `json:"managerCAFile"`
+
+	// ManagerServerName overrides the host name that is sent using SNI, and verified against the certificate
+	// of the ManagerEndpoint.
+	ManagerServerName string `json:"managerServerName"`
+}
+
+// ExternalManager returns true when the traffic-manager is reached without a port-forward, using either
+// the ManagerEndpoint or the ManagerWebSocketURL.
+func (cc *Cluster) ExternalManager() bool {
+	return cc.ManagerEndpoint != "" || cc.ManagerWebSocketURL != ""
 }
 
 // This is used by a different config -- the k8s_config, which needs to be able to tell if it's overridden at a cluster or environment variable level.
 // Hence, we don't default to "ambassador" but to empty, so that it can check that no default has been given.
 const defaultDefaultManagerNamespace = ""
 
+// defaultSOCKSProxyAddress is the address that the SOCKS5 proxy listens to when userspaceNetworking is enabled.
+const defaultSOCKSProxyAddress = "127.0.0.1:1080"
+
 var defaultCluster = Cluster{ //nolint:gochecknoglobals // constant
 	DefaultManagerNamespace: defaultDefaultManagerNamespace,
 	ConnectFromRootDaemon:   true,
 	AgentPortForward:        true,
 	VirtualIPSubnet:         defaultVirtualIPSubnet,
+	SOCKSProxyAddress:       defaultSOCKSProxyAddress,
 }
 
 func (cc *Cluster) defaults() DefaultsAware {
@@ -801,6 +945,9 @@ func (r *Routing) merge(o *Routing) {
 	if len(o.Subnets) > 0 {
 		r.Subnets = o.Subnets
 	}
+	if o.MTU > 0 {
+		r.MTU = o.MTU
+	}
 }
 
 func (d *DNS) Equal(o *DNS) bool {
@@ -855,6 +1002,51 @@ func (d *DNS) UnmarshalJSONV2(in *jsontext.Decoder, opts json.Options) error {
 	return json.UnmarshalDecode(in, &wp, opts)
 }
 
+// Upgrade controls the "telepresence upgrade self" command.
+type Upgrade struct {
+	// Disabled prevents that the binary is replaced. Use it in environments where telepresence is managed
+	// by a package manager or by an administrator.
+	Disabled bool `json:"disabled"`
+
+	// Channel is the release channel, "stable" or "latest", that the binary is upgraded from.
+	Channel string `json:"channel"`
+
+	// URL is the URL of the releases of a GitHub repository in the GitHub REST API.
+	URL string `json:"url"`
+}
+
+var defaultUpgrade = Upgrade{ //nolint:gochecknoglobals // constant
+	Channel: "stable",
+	URL:     "https://api.github.com/repos/telepresenceio/telepresence/releases",
+}
+
+func (u *Upgrade) defaults() DefaultsAware {
+	return &defaultUpgrade
+}
+
+// merge merges this instance with the non-zero values of the given argument. The argument values take priority.
+func (u *Upgrade) merge(o *Upgrade) {
+	mergeNonDefaults(u, o)
+}
+
+// IsZero controls whether this element will be included in marshalled output.
+func (u *Upgrade) IsZero() bool {
+	return u == nil || isDefault(u)
+}
+
+func (u *Upgrade) MarshalJSONV2(out *jsontext.Encoder, opts json.Options) error {
+	return json.MarshalEncode(out, mapWithoutDefaults(u), opts)
+}
+
+func (u *Upgrade) UnmarshalJSONV2(in *jsontext.Decoder, opts json.Options) error {
+	// Prevent that the original object is cleared when an empty object is decoded by passing the address
+	// of the pointer to the object. The unmarshal will then instead clear the pointer (wp becomes nil) and
+	// leave the underlying object intact. In other words, this code achieves "omitempty" during unmarshal.
+	type wt Upgrade
+	wp := (*wt)(u)
+	return json.UnmarshalDecode(in, &wp, opts)
+}
+
 type configKey struct{}
 
 // WithConfig returns a context with the given Config.
@@ -908,6 +1100,7 @@ var defaultConfig = BaseConfig{ //nolint:gochecknoglobals // constant
 	ClusterV:         defaultCluster,
 	DNSV:             defaultDNS,
 	RoutingV:         Routing{},
+	UpgradeV:         defaultUpgrade,
 }
 
 // GetDefaultBaseConfig returns the default configuration settings.
@@ -944,6 +1137,9 @@ func LoadConfig(c context.Context) (cfg Config, err error) {
 		if err != nil {
 			return err
 		}
+		if err = fileConfig.Routing().validate(); err != nil {
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
 		cfg.DestructiveMerge(fileConfig)
 		return nil
 	}
@@ -968,6 +1164,26 @@ type Routing struct {
 	AlsoProxy        []netip.Prefix `json:"alsoProxy,omitempty"`
 	NeverProxy       []netip.Prefix `json:"neverProxy,omitempty"`
 	AllowConflicting []netip.Prefix `json:"allowConflicting,omitempty"`
+
+	// MTU of the TUN-device. The MTU of the network interface used when connecting to the
+	// cluster is used when this value is zero, unless that MTU is larger than the default 1500.
+	// A non-zero value must be between MinMTU and MaxMTU.
+	MTU int `json:"mtu,omitempty"`
+}
+
+const (
+	// MinMTU is the smallest MTU that every IPv4 host must be able to receive.
+	MinMTU = 576
+
+	// MaxMTU is the largest MTU that an IP packet can make use of.
+	MaxMTU = 65535
+)
+
+func (r *Routing) validate() error {
+	if r.MTU != 0 && (r.MTU < MinMTU || r.MTU > MaxMTU) {
+		return fmt.Errorf("routing.mtu %d is not between %d and %d", r.MTU, MinMTU, MaxMTU)
+	}
+	return nil
 }
 
 func (r *Routing) ToRPC() *daemon.Routing {
@@ -994,6 +1210,7 @@ type RoutingSnake struct {
 	AlsoProxy        []netip.Prefix `json:"also_proxy_subnets"`
 	NeverProxy       []netip.Prefix `json:"never_proxy_subnets"`
 	AllowConflicting []netip.Prefix `json:"allow_conflicting_subnets"`
+	MTU              int            `json:"mtu,omitempty"`
 }
 
 type DNS struct {
@@ -1094,6 +1311,7 @@ func (r *Routing) ToSnake() *RoutingSnake {
 		AlsoProxy:        r.AlsoProxy,
 		NeverProxy:       r.NeverProxy,
 		AllowConflicting: r.AllowConflicting,
+		MTU:              r.MTU,
 	}
 }
 
@@ -31,6 +31,8 @@ timeouts:
   connectivityCheck: 0ms
 logLevels:
   userDaemon: debug
+routing:
+  mtu: 1400
 `,
 		/* user */ `
 timeouts:
@@ -91,6 +93,21 @@ cluster:
 	assert.True(t, cfg.Intercept().UseFtp)                                                       // from user
 	assert.Equal(t, cfg.Cluster().DefaultManagerNamespace, "hello")                              // from sys1
 	assert.Equal(t, cfg.Cluster().VirtualIPSubnet, "192.169.0.0/16")                             // from user
+	assert.Equal(t, 1400, cfg.Routing().MTU)                                                     // from sys2
+}
+
+func TestLoadConfig_mtu(t *testing.T) {
+	for _, mtu := range []string{"-1", "575", "65536", "4294968796"} {
+		t.Run(mtu, func(t *testing.T) {
+			user := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(user, ConfigFile), []byte("routing:\n  mtu: "+mtu+"\n"), 0o600))
+			c := dlog.NewTestContext(t, false)
+			c = filelocation.WithAppSystemConfigDirs(c, nil)
+			c = filelocation.WithAppUserConfigDir(c, user)
+			_, err := LoadConfig(c)
+			assert.ErrorContains(t, err, "routing.mtu")
+		})
+	}
 }
 
 func Test_ConfigMarshalYAML(t *testing.T) {
@@ -103,10 +120,16 @@ func Test_ConfigMarshalYAML(t *testing.T) {
 	cfg.Timeouts().PrivateTrafficManagerAPI = defaultTimeoutsTrafficManagerAPI + 20*time.Second
 	cfg.LogLevels().UserDaemon = logrus.TraceLevel
 	cfg.Grpc().MaxReceiveSizeV, _ = resource.ParseQuantity("20Mi")
+	cfg.Grpc().TunnelCompression = []string{"zstd", "gzip"}
 	cfg.TelepresenceAPI().Port = 4567
 	cfg.Intercept().AppProtocolStrategy = k8sapi.PortName
 	cfg.Intercept().DefaultPort = 9080
+	cfg.Intercept().Propagation = []string{"baggage", "b3"}
 	cfg.Cluster().DefaultManagerNamespace = "hello-there"
+	cfg.Cluster().ManagerWebSocketURL = "wss://traffic-manager.example.com/"
+	cfg.Cluster().ManagerEndpoint = "traffic-manager.example.com:443"
+	cfg.Upgrade().Disabled = true
+	cfg.Upgrade().Channel = "latest"
 	cfgBytes, err := cfg.MarshalYAML()
 	require.NoError(t, err)
 
@@ -179,3 +202,22 @@ logLevels:
 	require.NoError(t, err)
 	require.Equal(t, cfg.LogLevels().UserDaemon, logrus.DebugLevel)
 }
+
+func Test_ConfigUnmarshalInterceptFuse(t *testing.T) {
+	cfg, err := ParseConfigYAML(dlog.NewTestContext(t, true), "", []byte(`
+intercept:
+  fuse:
+    attrTimeout: 30s
+    entryTimeout: 1500ms
+    kernelCache: true
+    options:
+      - max_read=131072
+`))
+	require.NoError(t, err)
+	fo := cfg.Intercept().Fuse
+	require.Equal(t, []string{"attr_timeout=30", "entry_timeout=1.5", "kernel_cache", "max_read=131072"}, fo.MountOptions())
+
+	cfgBytes, err := cfg.MarshalYAML()
+	require.NoError(t, err)
+	require.Contains(t, string(cfgBytes), "attrTimeout: 30s")
+}
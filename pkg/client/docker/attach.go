@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// daemonNetwork is the network of a containerized daemon.
+const daemonNetwork = "telepresence"
+
+// AttachTarget returns the name of the given running container and the address where a daemon reaches the given
+// port of that container. When the daemon runs in the container daemonName, the port is reached on localhost if
+// the container shares the daemon's network namespace. Otherwise, the container is connected to the daemon's
+// network unless it is connected already. When the daemon runs on the host, the port must be published.
+func AttachTarget(ctx context.Context, nameOrID, daemonName string, port uint16) (string, netip.AddrPort, error) {
+	cli, err := GetClient(ctx)
+	if err != nil {
+		return "", netip.AddrPort{}, err
+	}
+	cn, err := cli.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return "", netip.AddrPort{}, err
+	}
+	name := strings.TrimPrefix(cn.Name, "/")
+	if cn.State == nil || !cn.State.Running {
+		return "", netip.AddrPort{}, fmt.Errorf("container %s is not running", name)
+	}
+
+	if daemonName == "" {
+		addr, err := publishedPort(cn, port)
+		return name, addr, err
+	}
+
+	if nm := cn.HostConfig.NetworkMode; nm.IsContainer() {
+		dc, err := cli.ContainerInspect(ctx, daemonName)
+		if err != nil {
+			return "", netip.AddrPort{}, err
+		}
+		if c := nm.ConnectedContainer(); c == dc.ID || c == daemonName {
+			return name, netip.AddrPortFrom(netip.AddrFrom4([4]byte{127, 0, 0, 1}), port), nil
+		}
+		return "", netip.AddrPort{}, fmt.Errorf("container %s uses the network of another container", name)
+	}
+
+	if _, ok := cn.NetworkSettings.Networks[daemonNetwork]; !ok {
+		dlog.Debugf(ctx, "connecting container %s to network %s", name, daemonNetwork)
+		if err = cli.NetworkConnect(ctx, daemonNetwork, cn.ID, nil); err != nil {
+			return "", netip.AddrPort{}, fmt.Errorf("unable to connect container %s to network %s: %w", name, daemonNetwork, err)
+		}
+		if cn, err = cli.ContainerInspect(ctx, cn.ID); err != nil {
+			return "", netip.AddrPort{}, err
+		}
+	}
+	if es, ok := cn.NetworkSettings.Networks[daemonNetwork]; ok {
+		if ip, err := netip.ParseAddr(es.IPAddress); err == nil {
+			return name, netip.AddrPortFrom(ip, port), nil
+		}
+	}
+	return "", netip.AddrPort{}, fmt.Errorf("container %s has no address on network %s", name, daemonNetwork)
+}
+
+// publishedPort returns the host address of the given TCP port of the given container.
+func publishedPort(cn types.ContainerJSON, port uint16) (netip.AddrPort, error) {
+	want := strconv.Itoa(int(port)) + "/tcp"
+	for p, bs := range cn.NetworkSettings.Ports {
+		if string(p) != want {
+			continue
+		}
+		for _, b := range bs {
+			hp, err := strconv.ParseUint(b.HostPort, 10, 16)
+			if err != nil {
+				continue
+			}
+			ip, err := netip.ParseAddr(b.HostIP)
+			if err != nil || ip.IsUnspecified() {
+				ip = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+			}
+			return netip.AddrPortFrom(ip, uint16(hp)), nil
+		}
+	}
+	return netip.AddrPort{}, fmt.Errorf("container %s doesn't publish port %d", strings.TrimPrefix(cn.Name, "/"), port)
+}
+
+// WaitContainer waits until the given container stops, and returns its exit code.
+func WaitContainer(ctx context.Context, nameOrID string) (int64, error) {
+	cli, err := GetClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rc, errC := cli.ContainerWait(ctx, nameOrID, container.WaitConditionNotRunning)
+	select {
+	case r := <-rc:
+		if r.Error != nil {
+			return r.StatusCode, errors.New(r.Error.Message)
+		}
+		return r.StatusCode, nil
+	case err = <-errC:
+		return 0, err
+	}
+}
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"context"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 )
@@ -13,3 +14,13 @@ func StopContainer(ctx context.Context, nameOrID string) error {
 	}
 	return err
 }
+
+// StopContainerWithin stops the given container, and kills it unless it stops within the given grace period.
+func StopContainerWithin(ctx context.Context, nameOrID string, grace time.Duration) error {
+	cli, err := GetClient(ctx)
+	if err == nil {
+		secs := max(int(grace/time.Second), 1)
+		err = cli.ContainerStop(ctx, nameOrID, container.StopOptions{Timeout: &secs})
+	}
+	return err
+}
@@ -2,33 +2,50 @@ package docker
 
 import (
 	"context"
-	"fmt"
-	"strings"
 	"sync"
 
 	"github.com/docker/docker/client"
-
-	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
 
 type clientKey struct{}
 
 type clientHandle struct {
 	sync.Mutex
-	cli *client.Client
+	cli     *client.Client
+	runtime Runtime
+}
+
+func (h *clientHandle) GetRuntime(ctx context.Context) (Runtime, error) {
+	h.Lock()
+	defer h.Unlock()
+	return h.getRuntimeLocked(ctx)
+}
+
+func (h *clientHandle) getRuntimeLocked(ctx context.Context) (Runtime, error) {
+	if h.runtime == nil {
+		rt, err := newRuntime(ctx)
+		if err != nil {
+			return nil, err
+		}
+		h.runtime = rt
+	}
+	return h.runtime, nil
 }
 
 func (h *clientHandle) GetClient(ctx context.Context) (*client.Client, error) {
 	h.Lock()
 	defer h.Unlock()
 	if h.cli == nil {
-		cmd := proc.CommandContext(ctx, "docker", "context", "inspect", "--format", "{{.Endpoints.docker.Host}}")
-		stdout, err := proc.CaptureErr(cmd)
-		opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+		rt, err := h.getRuntimeLocked(ctx)
+		if err != nil {
+			return nil, err
+		}
+		host, err := rt.Host(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve docker context: %v", err)
+			return nil, err
 		}
-		if host := strings.TrimSpace(string(stdout)); host != "" {
+		opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+		if host != "" {
 			opts = append(opts, client.WithHost(host))
 		}
 		cli, err := client.NewClientWithOpts(opts...)
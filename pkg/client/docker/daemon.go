@@ -70,14 +70,28 @@ func DaemonOptions(ctx context.Context, daemonID *daemon.Identifier) ([]string,
 		return nil, nil, err
 	}
 	addr := as[0]
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range []string{filelocation.AppUserConfigDir(ctx), filelocation.AppUserCacheDir(ctx), filelocation.AppUserLogDir(ctx)} {
+		if err = CheckSharedDir(ctx, dir); err != nil {
+			return nil, nil, err
+		}
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if rt.Rootless() {
+		// The root of the container is the current user, so files created by root are owned by the user.
+		uid, gid = 0, 0
+	}
 	opts := []string{
 		"--name", daemonID.ContainerName(),
 		"--network", "telepresence",
 		"--cap-add", "NET_ADMIN",
 		"--sysctl", "net.ipv6.conf.all.disable_ipv6=0",
 		"--device", "/dev/net/tun:/dev/net/tun",
-		"-e", fmt.Sprintf("TELEPRESENCE_UID=%d", os.Getuid()),
-		"-e", fmt.Sprintf("TELEPRESENCE_GID=%d", os.Getgid()),
+		"-e", fmt.Sprintf("TELEPRESENCE_UID=%d", uid),
+		"-e", fmt.Sprintf("TELEPRESENCE_GID=%d", gid),
 		"-p", fmt.Sprintf("%s:%d", addr, addr.Port),
 		"-v", fmt.Sprintf("%s:%s:ro", filelocation.AppUserConfigDir(ctx), dockerTpConfig),
 		"-v", fmt.Sprintf("%s:%s", filelocation.AppUserCacheDir(ctx), TpCache),
@@ -94,6 +108,9 @@ func DaemonOptions(ctx context.Context, daemonID *daemon.Identifier) ([]string,
 	if err != nil {
 		return nil, nil, err
 	}
+	if !rt.HostGatewayAlias() {
+		opts = append(opts, "--add-host", "host.docker.internal:host-gateway")
+	}
 	env := client.GetEnv(ctx)
 	if env.ScoutDisable {
 		opts = append(opts, "-e", "SCOUT_DISABLE=1")
@@ -235,7 +252,11 @@ func enableK8SAuthenticator(ctx context.Context, daemonID *daemon.Identifier) er
 			// Default is localhost in caller, but it is overridden when using WSL because "host.docker.internal" will
 			// be the Windows host
 			kubeAuthHost := "host.docker.internal"
-			if proc.RunningInWSL() {
+			rt, err := GetRuntime(ctx)
+			if err != nil {
+				return "", "", err
+			}
+			if proc.RunningInWSL() || !rt.HostGatewayReachesHost() {
 				r, err := routing.DefaultRoute(ctx)
 				if err != nil {
 					return "", "", err
@@ -557,9 +578,14 @@ func detectKind(ctx context.Context, cns []types.ContainerJSON, hostAddrPort net
 }
 
 func stopContainer(ctx context.Context, daemonID *daemon.Identifier) {
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		dlog.Warn(ctx, err)
+		return
+	}
 	args := []string{"stop", daemonID.ContainerName()}
-	dlog.Debug(ctx, shellquote.ShellString("docker", args))
-	if _, err := proc.CaptureErr(dexec.CommandContext(ctx, "docker", args...)); err != nil {
+	dlog.Debug(ctx, shellquote.ShellString(rt.Name(), args))
+	if _, err := proc.CaptureErr(dexec.CommandContext(ctx, rt.Name(), args...)); err != nil {
 		dlog.Warn(ctx, err)
 	}
 }
@@ -567,8 +593,12 @@ func stopContainer(ctx context.Context, daemonID *daemon.Identifier) {
 func tryLaunch(ctx context.Context, daemonID *daemon.Identifier, port int, args []string) (string, error) {
 	stdErr := bytes.Buffer{}
 	stdOut := bytes.Buffer{}
-	dlog.Debug(ctx, shellquote.ShellString("docker", args))
-	cmd := proc.CommandContext(ctx, "docker", args...)
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return "", err
+	}
+	dlog.Debug(ctx, shellquote.ShellString(rt.Name(), args))
+	cmd := proc.CommandContext(ctx, rt.Name(), args...)
 	cmd.DisableLogging = true
 	cmd.Stderr = &stdErr
 	cmd.Stdout = &stdOut
@@ -577,6 +607,11 @@ func tryLaunch(ctx context.Context, daemonID *daemon.Identifier, port int, args
 		if errStr == "" {
 			errStr = err.Error()
 		}
+		if strings.Contains(errStr, "/dev/net/tun") {
+			return "", errcat.User.Newf("launch of daemon container failed, because %s cannot add the TUN device "+
+				"/dev/net/tun to it: %s. Ensure that the tun kernel module is loaded, e.g. using \"sudo modprobe tun\", "+
+				"and that the device is accessible to the user that runs the container engine", rt.Name(), errStr)
+		}
 		return "", fmt.Errorf("launch of daemon container failed: %s", errStr)
 	}
 	cid := strings.TrimSpace(stdOut.String())
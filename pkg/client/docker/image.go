@@ -12,10 +12,25 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
 
+// IsRemoteContext returns true if the given build context is a URL, e.g. of a Git repository or a tarball, rather
+// than a local directory or Dockerfile.
+func IsRemoteContext(context string) bool {
+	return strings.Contains(context, "://") || strings.HasPrefix(context, "git@") || strings.HasPrefix(context, "github.com/")
+}
+
 // BuildImage builds an image from source. Stdout is silenced during those operations. The
-// image ID is returned.
+// image ID is returned. The context can be a local directory or Dockerfile, or a remote context.
+// BuildKit is used, so that build secrets and cache imports and exports can be passed in
+// the buildArgs.
 func BuildImage(ctx context.Context, context string, buildArgs []string) (string, error) {
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return "", err
+	}
 	args := append([]string{"build", "--quiet"}, buildArgs...)
+	if IsRemoteContext(context) {
+		return runBuild(ctx, rt, append(args, context))
+	}
 	st, err := os.Stat(context)
 	if err != nil {
 		return "", err
@@ -34,7 +49,14 @@ func BuildImage(ctx context.Context, context string, buildArgs []string) (string
 		context = dir
 		args = append(args, "--file", fn)
 	}
-	cmd := proc.StdCommand(ctx, "docker", append(args, context)...)
+	return runBuild(ctx, rt, append(args, context))
+}
+
+func runBuild(ctx context.Context, rt Runtime, args []string) (string, error) {
+	cmd := proc.StdCommand(ctx, rt.Name(), args...)
+	if rt.Name() == RuntimeDocker {
+		cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	}
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	if err := cmd.Run(); err != nil {
@@ -55,7 +77,11 @@ func PullImage(ctx context.Context, image string) error {
 		// Image exists in the local cache, so don't bother pulling it.
 		return nil
 	}
-	cmd := proc.StdCommand(ctx, "docker", "pull", image)
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return err
+	}
+	cmd := proc.StdCommand(ctx, rt.Name(), "pull", image)
 	// Docker run will put the pull logs in stderr, but docker pull will put them in stdout.
 	// We discard them here, so they don't spam the user. They'll get errors through stderr if it comes to it.
 	cmd.Stdout = io.Discard
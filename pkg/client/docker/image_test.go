@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRemoteContext(t *testing.T) {
+	tests := map[string]bool{
+		".":                                     false,
+		"./services/orders":                     false,
+		"/home/user/src/orders/Dockerfile":      false,
+		"https://github.com/example/orders.git": true,
+		"https://example.com/context.tar.gz":    true,
+		"git@github.com:example/orders.git":     true,
+		"github.com/example/orders":             true,
+	}
+	for ctx, remote := range tests {
+		assert.Equal(t, remote, IsRemoteContext(ctx), ctx)
+	}
+}
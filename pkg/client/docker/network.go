@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"slices"
 	"strings"
 
 	"github.com/docker/docker/api/types/network"
@@ -114,3 +115,49 @@ func EnsureNetwork(ctx context.Context, name string) error {
 	}
 	return err
 }
+
+// AddNetworkAliases ensures that the given container is connected to the given network with the given aliases,
+// so that other containers on the network can reach it using those names. Aliases can only be assigned when a
+// container is connected, so a container that is connected without some of the aliases is reconnected.
+func AddNetworkAliases(ctx context.Context, name, container string, aliases []string) error {
+	cli, err := GetClient(ctx)
+	if err != nil {
+		return err
+	}
+	cn, err := cli.ContainerInspect(ctx, container)
+	if err != nil {
+		return err
+	}
+	var current []string
+	es, connected := cn.NetworkSettings.Networks[name]
+	if connected {
+		current = es.Aliases
+	}
+	all, added := mergeAliases(current, aliases)
+	if !added {
+		return nil
+	}
+	if connected {
+		if err = cli.NetworkDisconnect(ctx, name, cn.ID, false); err != nil {
+			return fmt.Errorf("docker network disconnect %s %s: %w", name, container, err)
+		}
+	}
+	dlog.Debugf(ctx, "connecting container %s to network %s with aliases %v", container, name, all)
+	if err = cli.NetworkConnect(ctx, name, cn.ID, &network.EndpointSettings{Aliases: all}); err != nil {
+		return fmt.Errorf("docker network connect %s %s: %w", name, container, err)
+	}
+	return nil
+}
+
+// mergeAliases returns the current aliases with the given aliases appended, and true if any of them was added.
+func mergeAliases(current, aliases []string) ([]string, bool) {
+	all := slices.Clone(current)
+	added := false
+	for _, a := range aliases {
+		if !slices.Contains(all, a) {
+			all = append(all, a)
+			added = true
+		}
+	}
+	return all, added
+}
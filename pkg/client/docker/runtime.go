@@ -0,0 +1,461 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// Runtime is a container runtime with a Docker compatible CLI and API.
+type Runtime interface {
+	// Name returns the name of the runtime, which is also the name of its CLI executable.
+	Name() string
+
+	// Host returns the address of the runtime's API endpoint, or an empty string when the defaults of the
+	// Docker client apply.
+	Host(ctx context.Context) (string, error)
+
+	// Rootless returns true when the containers run in a user namespace where root is the current user.
+	Rootless() bool
+
+	// HostGatewayAlias returns true if the runtime resolves host.docker.internal in all containers without
+	// an --add-host option.
+	HostGatewayAlias() bool
+
+	// SupportsVolumePlugins returns true if the runtime can install the Docker volume plugin that is used for
+	// remote mounts when the daemon runs in a container.
+	SupportsVolumePlugins() bool
+
+	// BridgeGateway returns the gateway IP of the runtime's default bridge network.
+	BridgeGateway(ctx context.Context) (net.IP, error)
+
+	// HostGatewayReachesHost returns false if the host gateway, i.e. host.docker.internal, doesn't reach the
+	// network interfaces of the host, so that containers must use the host's IP instead.
+	HostGatewayReachesHost() bool
+
+	// SharedDirs returns the host directories that can be bind mounted into containers when the runtime runs in
+	// a virtual machine that only has access to those directories. Nil is returned when all directories can be
+	// mounted.
+	SharedDirs() []string
+}
+
+// Docker engines that need special treatment.
+const (
+	providerDockerDesktop  = "Docker Desktop"
+	providerColima         = "Colima"
+	providerRancherDesktop = "Rancher Desktop"
+)
+
+// GetRuntime returns the container runtime that is named by the TELEPRESENCE_CONTAINER_RUNTIME environment
+// variable. When the variable is unset, Docker is used unless only Podman is installed, or the docker command
+// is Podman's Docker emulation.
+func GetRuntime(ctx context.Context) (Runtime, error) {
+	if h, ok := ctx.Value(clientKey{}).(*clientHandle); ok {
+		return h.GetRuntime(ctx)
+	}
+	return newRuntime(ctx)
+}
+
+func newRuntime(ctx context.Context) (Runtime, error) {
+	name := ""
+	if env := client.GetEnv(ctx); env != nil {
+		name = env.ContainerRuntime
+	}
+	if name == "" {
+		name = detectRuntime(ctx)
+	}
+	switch name {
+	case RuntimeDocker:
+		return newDockerRuntime(ctx), nil
+	case RuntimePodman:
+		return newPodmanRuntime(ctx)
+	default:
+		return nil, fmt.Errorf("invalid container runtime %q, must be %q or %q", name, RuntimeDocker, RuntimePodman)
+	}
+}
+
+func detectRuntime(ctx context.Context) string {
+	if _, err := exec.LookPath(RuntimeDocker); err != nil {
+		if _, err := exec.LookPath(RuntimePodman); err == nil {
+			return RuntimePodman
+		}
+		return RuntimeDocker
+	}
+	cmd := proc.CommandContext(ctx, RuntimeDocker, "--version")
+	cmd.DisableLogging = true
+	if out, err := proc.CaptureErr(cmd); err == nil && strings.Contains(strings.ToLower(string(out)), RuntimePodman) {
+		return RuntimePodman
+	}
+	return RuntimeDocker
+}
+
+type dockerRuntime struct {
+	host     string
+	hostErr  error
+	provider string // one of the provider constants, or empty for a plain Docker engine
+	rootless bool
+}
+
+// dockerInfo is the subset of the output from "docker info" that Telepresence uses.
+type dockerInfo struct {
+	Name            string   `json:"Name"`
+	OperatingSystem string   `json:"OperatingSystem"`
+	SecurityOptions []string `json:"SecurityOptions"`
+}
+
+func (di *dockerInfo) provider() string {
+	switch {
+	case di.OperatingSystem == providerDockerDesktop:
+		return providerDockerDesktop
+	case di.Name == "colima" || strings.HasPrefix(di.Name, "colima-"):
+		return providerColima
+	case strings.Contains(di.OperatingSystem, providerRancherDesktop) || di.Name == "lima-rancher-desktop":
+		return providerRancherDesktop
+	}
+	return ""
+}
+
+func (di *dockerInfo) rootless() bool {
+	for _, o := range di.SecurityOptions {
+		if strings.Contains(o, "name=rootless") {
+			return true
+		}
+	}
+	return false
+}
+
+// newDockerRuntime returns the Docker runtime after discovering where its API is, and what engine it is. The
+// engine isn't required to be running, because that is reported when it's used.
+func newDockerRuntime(ctx context.Context) Runtime {
+	r := &dockerRuntime{}
+	r.host, r.hostErr = findDockerHost(ctx)
+	args := []string{"info", "--format", "{{json .}}"}
+	if r.host != "" {
+		args = append([]string{"--host", r.host}, args...)
+	}
+	cmd := proc.CommandContext(ctx, RuntimeDocker, args...)
+	cmd.DisableLogging = true
+	out, err := proc.CaptureErr(cmd)
+	if err != nil {
+		dlog.Debugf(ctx, "unable to retrieve docker info: %v", err)
+		return r
+	}
+	var info dockerInfo
+	if err = json.Unmarshal(out, &info); err != nil {
+		dlog.Debugf(ctx, "unable to parse docker info: %v", err)
+		return r
+	}
+	r.provider, r.rootless = info.provider(), info.rootless()
+	dlog.Debugf(ctx, "Using docker engine %q, rootless %t", r.provider, r.rootless)
+	return r
+}
+
+func findDockerHost(ctx context.Context) (string, error) {
+	cmd := proc.CommandContext(ctx, RuntimeDocker, "context", "inspect", "--format", "{{.Endpoints.docker.Host}}")
+	stdout, err := proc.CaptureErr(cmd)
+	host := ""
+	if err != nil {
+		err = fmt.Errorf("unable to retrieve docker context: %v", err)
+	} else {
+		host = strings.TrimSpace(string(stdout))
+	}
+	if runtime.GOOS == "windows" {
+		return host, err
+	}
+	home, _ := os.UserHomeDir()
+	found := dockerSocket(host, home, os.Getenv("XDG_RUNTIME_DIR"), os.Getuid(), func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	if found != host {
+		dlog.Infof(ctx, "Docker socket of context not found, using %s", found)
+		// The docker CLI, which runs the containers, must use the same socket.
+		if err := os.Setenv("DOCKER_HOST", found); err != nil {
+			return "", err
+		}
+		return found, nil
+	}
+	return host, err
+}
+
+// dockerSocket returns the given host unless it is empty or the default socket, and the default socket doesn't
+// exist. The first existing socket out of the sockets of Colima, Rancher Desktop, and a rootless Docker engine
+// is then returned instead.
+func dockerSocket(host, home, runtimeDir string, uid int, exists func(string) bool) string {
+	const defaultSocket = "/var/run/docker.sock"
+	if (host != "" && host != "unix://"+defaultSocket) || exists(defaultSocket) {
+		return host
+	}
+	var candidates []string
+	if home != "" {
+		candidates = append(candidates,
+			path.Join(home, ".colima", "default", "docker.sock"),
+			path.Join(home, ".colima", "docker.sock"),
+			path.Join(home, ".rd", "docker.sock"))
+	}
+	if runtimeDir != "" {
+		candidates = append(candidates, path.Join(runtimeDir, "docker.sock"))
+	}
+	candidates = append(candidates, path.Join("/run", "user", strconv.Itoa(uid), "docker.sock"))
+	for _, c := range candidates {
+		if exists(c) {
+			return "unix://" + c
+		}
+	}
+	return host
+}
+
+func (r *dockerRuntime) Name() string {
+	return RuntimeDocker
+}
+
+func (r *dockerRuntime) Host(context.Context) (string, error) {
+	return r.host, r.hostErr
+}
+
+func (r *dockerRuntime) Rootless() bool {
+	return r.rootless
+}
+
+func (r *dockerRuntime) HostGatewayAlias() bool {
+	switch r.provider {
+	case providerDockerDesktop, providerRancherDesktop:
+		return true
+	case providerColima:
+		return false
+	}
+	return runtime.GOOS != "linux"
+}
+
+func (r *dockerRuntime) SupportsVolumePlugins() bool {
+	// Plugins cannot be installed in a rootless engine.
+	return !r.rootless
+}
+
+func (r *dockerRuntime) BridgeGateway(ctx context.Context) (net.IP, error) {
+	return inspectGateway(ctx, RuntimeDocker, "bridge", "{{(index .IPAM.Config 0).Gateway}}")
+}
+
+func (r *dockerRuntime) HostGatewayReachesHost() bool {
+	// The host gateway of a rootless engine is the gateway of its user-mode network, which only reaches the
+	// host's loopback interface, and only when the engine allows it.
+	return !r.rootless
+}
+
+func (r *dockerRuntime) SharedDirs() []string {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	switch r.provider {
+	case providerColima:
+		return []string{home, "/tmp/colima"}
+	case providerRancherDesktop:
+		return []string{home, "/Volumes", "/var/folders", "/tmp/rancher-desktop"}
+	}
+	return nil
+}
+
+type podmanRuntime struct {
+	// socket is the path of the API socket. Only used on Linux, where Podman runs natively.
+	socket   string
+	rootless bool
+}
+
+// podmanInfo is the subset of the output from "podman info" that Telepresence uses.
+type podmanInfo struct {
+	Host struct {
+		RemoteSocket struct {
+			Path   string `json:"path"`
+			Exists bool   `json:"exists"`
+		} `json:"remoteSocket"`
+		Security struct {
+			Rootless bool `json:"rootless"`
+		} `json:"security"`
+	} `json:"host"`
+}
+
+func newPodmanRuntime(ctx context.Context) (Runtime, error) {
+	if runtime.GOOS != "linux" {
+		// Podman runs in a virtual machine, and the API is forwarded to the host.
+		return &podmanRuntime{}, nil
+	}
+	cmd := proc.CommandContext(ctx, RuntimePodman, "info", "--format", "json")
+	cmd.DisableLogging = true
+	out, err := proc.CaptureErr(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve podman info: %w", err)
+	}
+	var info podmanInfo
+	if err = json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("unable to parse podman info: %w", err)
+	}
+	remote := ""
+	if rs := info.Host.RemoteSocket; rs.Exists {
+		remote = rs.Path
+	}
+	socket := podmanSocket(remote, os.Getenv("XDG_RUNTIME_DIR"), os.Getuid(), func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	dlog.Debugf(ctx, "Using podman socket %q, rootless %t", socket, info.Host.Security.Rootless)
+	return &podmanRuntime{socket: socket, rootless: info.Host.Security.Rootless}, nil
+}
+
+// podmanSocket returns the first existing socket out of the remote socket reported by podman info, the socket of
+// a rootless Podman service, and the socket of the system-wide Podman service. An empty string is returned when
+// no socket exists.
+func podmanSocket(remote, runtimeDir string, uid int, exists func(string) bool) string {
+	candidates := []string{remote}
+	if runtimeDir != "" {
+		candidates = append(candidates, path.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates,
+		path.Join("/run", "user", strconv.Itoa(uid), "podman", "podman.sock"),
+		path.Join("/run", "podman", "podman.sock"))
+	for _, c := range candidates {
+		if c != "" && exists(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+func (p *podmanRuntime) Name() string {
+	return RuntimePodman
+}
+
+func (p *podmanRuntime) Host(ctx context.Context) (string, error) {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host, nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		if p.socket == "" {
+			return "", fmt.Errorf("found no podman API socket. Start one using %q",
+				"systemctl --user enable --now podman.socket")
+		}
+		return "unix://" + p.socket, nil
+	case "windows":
+		path, err := p.machineInspect(ctx, "{{.ConnectionInfo.PodmanPipe.Path}}")
+		if err != nil {
+			return "", err
+		}
+		return "npipe://" + filepath.ToSlash(path), nil
+	default:
+		path, err := p.machineInspect(ctx, "{{.ConnectionInfo.PodmanSocket.Path}}")
+		if err != nil {
+			return "", err
+		}
+		return "unix://" + path, nil
+	}
+}
+
+func (p *podmanRuntime) machineInspect(ctx context.Context, format string) (string, error) {
+	cmd := proc.CommandContext(ctx, RuntimePodman, "machine", "inspect", "--format", format)
+	out, err := proc.CaptureErr(cmd)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the API endpoint of the podman machine: %w", err)
+	}
+	path := strings.TrimSpace(string(out))
+	if path == "" || path == "<no value>" {
+		return "", fmt.Errorf("the podman machine has no API endpoint. Start it using %q", "podman machine start")
+	}
+	return path, nil
+}
+
+func (p *podmanRuntime) Rootless() bool {
+	return p.rootless
+}
+
+func (p *podmanRuntime) HostGatewayAlias() bool {
+	// Podman adds host.containers.internal and host.docker.internal to the /etc/hosts of all containers.
+	return true
+}
+
+func (p *podmanRuntime) SupportsVolumePlugins() bool {
+	return false
+}
+
+func (p *podmanRuntime) BridgeGateway(ctx context.Context) (net.IP, error) {
+	return inspectGateway(ctx, RuntimePodman, "podman", "{{(index .Subnets 0).Gateway}}")
+}
+
+func (p *podmanRuntime) HostGatewayReachesHost() bool {
+	// Podman maps host.containers.internal and host.docker.internal to an IP of the host.
+	return true
+}
+
+func (p *podmanRuntime) SharedDirs() []string {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{home, "/private", "/var/folders"}
+}
+
+// CheckSharedDir returns an error if the given host directory cannot be bind mounted into containers, because the
+// container runtime runs in a virtual machine that the directory isn't shared with.
+func CheckSharedDir(ctx context.Context, dir string) error {
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return err
+	}
+	if rd, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = rd
+	}
+	return checkSharedDir(dir, rt.SharedDirs())
+}
+
+func checkSharedDir(dir string, shared []string) error {
+	if len(shared) == 0 {
+		return nil
+	}
+	for _, s := range shared {
+		if dir == s || strings.HasPrefix(dir, s+"/") {
+			return nil
+		}
+	}
+	return errcat.User.Newf("directory %s cannot be mounted into containers, because it isn't shared with the "+
+		"virtual machine of the container runtime. Use a directory below one of %s", dir, strings.Join(shared, ", "))
+}
+
+func inspectGateway(ctx context.Context, exe, network, format string) (net.IP, error) {
+	cmd := proc.CommandContext(ctx, exe, "network", "inspect", network, "--format", format)
+	cmd.DisableLogging = true
+	out, err := proc.CaptureErr(cmd)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimSpace(string(out))
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid gateway %q of %s network %s", s, exe, network)
+	}
+	return ip, nil
+}
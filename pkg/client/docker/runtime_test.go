@@ -0,0 +1,158 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func TestPodmanSocket(t *testing.T) {
+	tests := []struct {
+		name     string
+		remote   string
+		existing []string
+		want     string
+	}{
+		{
+			name:     "remote socket",
+			remote:   "/run/user/1000/podman/podman.sock",
+			existing: []string{"/run/user/1000/podman/podman.sock", "/run/podman/podman.sock"},
+			want:     "/run/user/1000/podman/podman.sock",
+		},
+		{
+			name:     "runtime dir",
+			existing: []string{"/tmp/runtime/podman/podman.sock", "/run/user/1000/podman/podman.sock"},
+			want:     "/tmp/runtime/podman/podman.sock",
+		},
+		{
+			name:     "rootless",
+			existing: []string{"/run/user/1000/podman/podman.sock", "/run/podman/podman.sock"},
+			want:     "/run/user/1000/podman/podman.sock",
+		},
+		{
+			name:     "rootful",
+			existing: []string{"/run/podman/podman.sock"},
+			want:     "/run/podman/podman.sock",
+		},
+		{
+			name: "none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists := func(p string) bool {
+				for _, e := range tt.existing {
+					if e == p {
+						return true
+					}
+				}
+				return false
+			}
+			assert.Equal(t, tt.want, podmanSocket(tt.remote, "/tmp/runtime", 1000, exists))
+		})
+	}
+}
+
+func TestDockerSocket(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		existing []string
+		want     string
+	}{
+		{
+			name:     "context host",
+			host:     "unix:///home/me/.colima/work/docker.sock",
+			existing: []string{"/home/me/.rd/docker.sock"},
+			want:     "unix:///home/me/.colima/work/docker.sock",
+		},
+		{
+			name:     "default socket exists",
+			host:     "unix:///var/run/docker.sock",
+			existing: []string{"/var/run/docker.sock", "/home/me/.rd/docker.sock"},
+			want:     "unix:///var/run/docker.sock",
+		},
+		{
+			name:     "colima",
+			host:     "unix:///var/run/docker.sock",
+			existing: []string{"/home/me/.colima/default/docker.sock"},
+			want:     "unix:///home/me/.colima/default/docker.sock",
+		},
+		{
+			name:     "rancher desktop",
+			existing: []string{"/home/me/.rd/docker.sock"},
+			want:     "unix:///home/me/.rd/docker.sock",
+		},
+		{
+			name:     "rootless",
+			host:     "unix:///var/run/docker.sock",
+			existing: []string{"/run/user/1000/docker.sock"},
+			want:     "unix:///run/user/1000/docker.sock",
+		},
+		{
+			name: "none",
+			host: "unix:///var/run/docker.sock",
+			want: "unix:///var/run/docker.sock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists := func(p string) bool {
+				for _, e := range tt.existing {
+					if e == p {
+						return true
+					}
+				}
+				return false
+			}
+			assert.Equal(t, tt.want, dockerSocket(tt.host, "/home/me", "", 1000, exists))
+		})
+	}
+}
+
+func TestDockerInfo(t *testing.T) {
+	tests := []struct {
+		info     dockerInfo
+		provider string
+		rootless bool
+	}{
+		{info: dockerInfo{Name: "docker-desktop", OperatingSystem: "Docker Desktop"}, provider: providerDockerDesktop},
+		{info: dockerInfo{Name: "colima", OperatingSystem: "Ubuntu 24.04 LTS"}, provider: providerColima},
+		{info: dockerInfo{Name: "colima-work", OperatingSystem: "Ubuntu 24.04 LTS"}, provider: providerColima},
+		{info: dockerInfo{Name: "lima-rancher-desktop", OperatingSystem: "Alpine Linux v3.20"}, provider: providerRancherDesktop},
+		{info: dockerInfo{Name: "laptop", OperatingSystem: "Rancher Desktop WSL Distribution"}, provider: providerRancherDesktop},
+		{
+			info:     dockerInfo{Name: "laptop", OperatingSystem: "Fedora Linux 40", SecurityOptions: []string{"name=seccomp,profile=builtin", "name=rootless"}},
+			rootless: true,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.provider, tt.info.provider(), tt.info.Name)
+		assert.Equal(t, tt.rootless, tt.info.rootless(), tt.info.Name)
+	}
+}
+
+func TestCheckSharedDir(t *testing.T) {
+	assert.NoError(t, checkSharedDir("/tmp/telfs-1", nil))
+	shared := []string{"/Users/me", "/tmp/colima"}
+	assert.NoError(t, checkSharedDir("/Users/me/Library/Logs/telepresence", shared))
+	assert.NoError(t, checkSharedDir("/tmp/colima", shared))
+	assert.ErrorContains(t, checkSharedDir("/tmp/telfs-1", shared), "isn't shared with the virtual machine")
+	assert.Error(t, checkSharedDir("/Users/meg", shared))
+}
+
+func TestGetRuntime(t *testing.T) {
+	ctx := client.WithEnv(context.Background(), &client.Env{ContainerRuntime: RuntimeDocker})
+	rt, err := GetRuntime(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, RuntimeDocker, rt.Name())
+	assert.True(t, rt.SupportsVolumePlugins())
+
+	ctx = client.WithEnv(context.Background(), &client.Env{ContainerRuntime: "containerd"})
+	_, err = GetRuntime(ctx)
+	assert.ErrorContains(t, err, `invalid container runtime "containerd"`)
+}
@@ -27,6 +27,13 @@ import (
 // EnsureVolumePlugin checks if the telemount plugin is installed and installs it if that is
 // not the case. The plugin is also enabled.
 func EnsureVolumePlugin(ctx context.Context) (string, error) {
+	rt, err := GetRuntime(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !rt.SupportsVolumePlugins() {
+		return "", fmt.Errorf("%s doesn't support the docker volume plugins that remote mounts require", rt.Name())
+	}
 	cli, err := GetClient(ctx)
 	if err != nil {
 		return "", err
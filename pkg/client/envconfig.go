@@ -21,6 +21,10 @@ type Env struct {
 	// This environment variable becomes the default for the images.clientImage
 	ClientImage string `env:"TELEPRESENCE_CLIENT_IMAGE,                   parser=possibly-empty-string,default="`
 
+	// The container runtime, "docker" or "podman", used by --docker-run, --docker-build, and connect --docker.
+	// Detected when empty.
+	ContainerRuntime string `env:"TELEPRESENCE_CONTAINER_RUNTIME, parser=possibly-empty-string,default="`
+
 	// The address that the user daemon is listening to (unless it is started by the client and uses a named pipe or unix socket).
 	UserDaemonAddress string `env:"TELEPRESENCE_USER_DAEMON_ADDRESS, parser=possibly-empty-string,default="`
 	ScoutDisable      bool   `env:"SCOUT_DISABLE, parser=strconv.ParseBool, default=0"`
@@ -0,0 +1,63 @@
+// Package handoff pipes TCP connections over the HandOff stream of the connector API, so that a CLI can serve
+// connections that a user daemon on another host accepts.
+package handoff
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+)
+
+// Stream is implemented by both the client and the server side of a HandOff call.
+type Stream interface {
+	Send(*connector.HandOffMessage) error
+	Recv() (*connector.HandOffMessage, error)
+}
+
+// Pipe copies data in both directions between the given connection and stream until either of them ends, or the
+// context is cancelled. The caller is responsible for closing the connection and ending the call.
+func Pipe(ctx context.Context, conn net.Conn, s Stream) error {
+	errCh := make(chan error, 2)
+	go func() {
+		for {
+			m, err := s.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if _, err = conn.Write(m.Data); err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		// The message is marshalled by Send, so the buffer can be reused.
+		buf := make([]byte, 0x8000)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if err := s.Send(&connector.HandOffMessage{Data: buf[:n]}); err != nil {
+					errCh <- err
+					return
+				}
+			}
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-errCh:
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
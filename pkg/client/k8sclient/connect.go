@@ -2,26 +2,45 @@ package k8sclient
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	empty "google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/client-go/rest"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/agent"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/portforward"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/wsconn"
 )
 
 func ConnectToManager(ctx context.Context, namespace string) (*grpc.ClientConn, manager.ManagerClient, *manager.VersionInfo2, error) {
-	grpcAddr := net.JoinHostPort("svc/traffic-manager."+namespace, "api")
-	conn, err := dialClusterGRPC(ctx, grpcAddr)
+	var conn *grpc.ClientConn
+	var err error
+	cc := client.GetConfig(ctx).Cluster()
+	throttle := grpc.WithChainUnaryInterceptor(retryThrottled)
+	switch {
+	case cc.ManagerEndpoint != "":
+		conn, err = dialTLSGRPC(ctx, cc.ManagerEndpoint, cc.ManagerCAFile, cc.ManagerServerName, portforward.GetRestConfig(ctx), throttle)
+	case cc.ManagerWebSocketURL != "":
+		conn, err = dialWebSocketGRPC(ctx, cc.ManagerWebSocketURL, portforward.GetRestConfig(ctx), throttle)
+	default:
+		conn, err = dialClusterGRPC(ctx, net.JoinHostPort("svc/traffic-manager."+namespace, "api"), throttle)
+	}
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -57,11 +76,91 @@ func ConnectToAgent(
 	return conn, mClient, vi, err
 }
 
-func dialClusterGRPC(ctx context.Context, address string) (*grpc.ClientConn, error) {
-	return grpc.NewClient(portforward.K8sPFScheme+":///"+address, grpc.WithContextDialer(portforward.Dialer(ctx)),
+func dialClusterGRPC(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.NewClient(portforward.K8sPFScheme+":///"+address, append([]grpc.DialOption{
+		grpc.WithContextDialer(portforward.Dialer(ctx)),
 		grpc.WithResolvers(portforward.NewResolver(ctx)),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}, opts...)...)
+}
+
+// bearerTokenCredentials adds the bearer token of a kubeconfig to each call. The traffic-manager requires it from
+// clients that connect without a port-forward, and reviews it with the API server.
+type bearerTokenCredentials struct {
+	config *rest.Config
+
+	// tunneled is true when the transport that gRPC sees is insecure, because it's tunneled through a connection
+	// that is secured by other means.
+	tunneled bool
+}
+
+func (c bearerTokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := BearerToken(ctx, c.config)
+	if err != nil {
+		if errors.Is(err, ErrNoBearerToken) {
+			err = errcat.User.Newf("connecting to the traffic-manager without a port-forward requires a bearer token: %v", err)
+		}
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (c bearerTokenCredentials) RequireTransportSecurity() bool {
+	return !c.tunneled
+}
+
+// dialTLSGRPC creates a gRPC connection that uses TLS to connect to the given endpoint, which is expected to be
+// an Ingress or Gateway API route that exposes the traffic-manager. Each call is authenticated using the bearer
+// token of the given config.
+func dialTLSGRPC(ctx context.Context, endpoint, caFile, serverName string, config *rest.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if config == nil {
+		return nil, errors.New("no kubeconfig to authenticate with the traffic-manager")
+	}
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		endpoint = net.JoinHostPort(endpoint, "443")
+	}
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errcat.User.Newf("unable to read managerCAFile: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errcat.User.Newf("managerCAFile %s contains no PEM encoded certificates", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	dlog.Debugf(ctx, "Connecting to the traffic-manager using endpoint %s", endpoint)
+	return grpc.NewClient("dns:///"+endpoint, append([]grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithPerRPCCredentials(bearerTokenCredentials{config: config}),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}, opts...)...)
+}
+
+// dialWebSocketGRPC creates a gRPC connection that tunnels through WebSocket connections to the given wss:// URL.
+// Each call is authenticated using the bearer token of the given config, which is why the URL must use TLS.
+func dialWebSocketGRPC(ctx context.Context, url string, config *rest.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if !strings.HasPrefix(strings.ToLower(url), "wss://") {
+		return nil, errcat.User.Newf("managerWebSocketURL %q must use wss://, because the connection carries credentials", url)
+	}
+	if config == nil {
+		return nil, errors.New("no kubeconfig to authenticate with the traffic-manager")
+	}
+	dlog.Debugf(ctx, "Connecting to the traffic-manager using WebSocket URL %s", url)
+	return grpc.NewClient("passthrough:///traffic-manager", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return wsconn.Dial(ctx, url)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(bearerTokenCredentials{config: config, tunneled: true}),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}, opts...)...)
 }
 
 func getVersion(ctx context.Context, gc versionAPI) (*manager.VersionInfo2, error) {
@@ -0,0 +1,107 @@
+package k8sclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"k8s.io/client-go/rest"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func selfSignedCert(t *testing.T, host string) (tls.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func Test_dialTLSGRPC(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	cert, caPEM := selfSignedCert(t, "traffic-manager.example.com")
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	var token string
+	gs := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
+		grpc.UnaryInterceptor(func(ctx context.Context, rq any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			if md, ok := metadata.FromIncomingContext(ctx); ok {
+				if v := md.Get("authorization"); len(v) > 0 {
+					token = v[0]
+				}
+			}
+			return h(ctx, rq)
+		}))
+	grpc_health_v1.RegisterHealthServer(gs, health.NewServer())
+	go func() {
+		_ = gs.Serve(ln)
+	}()
+	defer gs.Stop()
+	endpoint := net.JoinHostPort("127.0.0.1", strconv.Itoa(ln.Addr().(*net.TCPAddr).Port))
+
+	cfg := &rest.Config{Host: "https://example.com", BearerToken: "abc123"}
+	check := func(caFile, serverName string) error {
+		conn, err := dialTLSGRPC(ctx, endpoint, caFile, serverName, cfg)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		return err
+	}
+	assert.NoError(t, check(caFile, "traffic-manager.example.com"))
+	assert.Equal(t, "Bearer abc123", token)
+	assert.Error(t, check(caFile, "other.example.com"))
+	assert.Error(t, check("", "traffic-manager.example.com"))
+
+	_, err = dialTLSGRPC(ctx, endpoint, filepath.Join(t.TempDir(), "missing.pem"), "", cfg)
+	assert.Error(t, err)
+
+	// A kubeconfig that doesn't authenticate using a bearer token can't be used.
+	cfg = &rest.Config{Host: "https://example.com", Username: "admin", Password: "secret"}
+	assert.Error(t, check(caFile, "traffic-manager.example.com"))
+}
+
+func Test_dialWebSocketGRPC(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	cfg := &rest.Config{Host: "https://example.com", BearerToken: "abc123"}
+	_, err := dialWebSocketGRPC(ctx, "ws://traffic-manager.example.com/", cfg)
+	assert.Error(t, err)
+	_, err = dialWebSocketGRPC(ctx, "wss://traffic-manager.example.com/", nil)
+	assert.Error(t, err)
+	conn, err := dialWebSocketGRPC(ctx, "wss://traffic-manager.example.com/", cfg)
+	require.NoError(t, err)
+	_ = conn.Close()
+}
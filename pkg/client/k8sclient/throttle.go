@@ -0,0 +1,66 @@
+package k8sclient
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// maxThrottledAttempts is the number of times that retryThrottled makes a call that the traffic-manager throttles.
+const maxThrottledAttempts = 5
+
+// RetryDelay returns the delay that the traffic-manager asks for when it rejects a call because the session has
+// exceeded its rate limit, and true. False is returned when the error isn't such a rejection.
+func RetryDelay(err error) (time.Duration, bool) {
+	return retryInfoDelay(err, codes.ResourceExhausted)
+}
+
+// ReconnectDelay returns the delay after which the traffic-manager asks its clients to reconnect when it rejects a
+// call because it is shutting down, and true. False is returned when the error isn't such a rejection.
+func ReconnectDelay(err error) (time.Duration, bool) {
+	return retryInfoDelay(err, codes.Unavailable)
+}
+
+func retryInfoDelay(err error, code codes.Code) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			return ri.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// retryThrottled is a unary client interceptor that retries the calls that the traffic-manager rejects because
+// the session has exceeded its rate limit, once the delay that the traffic-manager asks for has passed.
+func retryThrottled(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		delay, ok := RetryDelay(err)
+		if !ok || attempt == maxThrottledAttempts {
+			return err
+		}
+		dlog.Debugf(ctx, "%s was throttled by the traffic-manager, retrying in %s", method, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
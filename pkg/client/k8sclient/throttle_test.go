@@ -0,0 +1,81 @@
+package k8sclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func throttledError(t *testing.T, delay time.Duration) error {
+	st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Err()
+}
+
+func TestRetryDelay(t *testing.T) {
+	delay, ok := RetryDelay(throttledError(t, time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+
+	_, ok = RetryDelay(status.Error(codes.ResourceExhausted, "message too large"))
+	assert.False(t, ok)
+	_, ok = RetryDelay(errors.New("boom"))
+	assert.False(t, ok)
+	_, ok = RetryDelay(nil)
+	assert.False(t, ok)
+}
+
+func TestReconnectDelay(t *testing.T) {
+	st, err := status.New(codes.Unavailable, "shutting down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(20 * time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delay, ok := ReconnectDelay(fmt.Errorf("traffic-manager unavailable: %w", st.Err()))
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, delay)
+
+	_, ok = ReconnectDelay(throttledError(t, time.Second))
+	assert.False(t, ok)
+	_, ok = ReconnectDelay(status.Error(codes.Unavailable, "connection refused"))
+	assert.False(t, ok)
+}
+
+func TestRetryThrottled(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	invoker := func(failures int) grpc.UnaryInvoker {
+		calls = 0
+		return func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			calls++
+			if calls <= failures {
+				return throttledError(t, time.Millisecond)
+			}
+			return nil
+		}
+	}
+	assert.NoError(t, retryThrottled(ctx, "/test/Call", nil, nil, nil, invoker(2)))
+	assert.Equal(t, 3, calls)
+
+	err := retryThrottled(ctx, "/test/Call", nil, nil, nil, invoker(maxThrottledAttempts))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, maxThrottledAttempts, calls)
+
+	failing := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Internal, "boom")
+	}
+	calls = 0
+	assert.Equal(t, codes.Internal, status.Code(retryThrottled(ctx, "/test/Call", nil, nil, nil, failing)))
+	assert.Equal(t, 1, calls)
+}
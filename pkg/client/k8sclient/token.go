@@ -0,0 +1,54 @@
+package k8sclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// ErrNoBearerToken is returned by BearerToken when the config doesn't authenticate using a bearer token, e.g.
+// because it uses a client certificate.
+var ErrNoBearerToken = errors.New("the kubeconfig doesn't authenticate using a bearer token")
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(rq *http.Request) (*http.Response, error) {
+	return f(rq)
+}
+
+// BearerToken returns the bearer token that requests made using the given config are authenticated with. Tokens
+// that are obtained from exec or auth-provider plugins are included, because the token is captured from a request
+// that passes through the same wrappers as requests to the API server, without sending it anywhere.
+func BearerToken(ctx context.Context, cfg *rest.Config) (string, error) {
+	tc, err := cfg.TransportConfig()
+	if err != nil {
+		return "", err
+	}
+	var token string
+	rt, err := transport.HTTPWrappersForConfig(tc, roundTripperFunc(func(rq *http.Request) (*http.Response, error) {
+		if t, ok := strings.CutPrefix(rq.Header.Get("Authorization"), "Bearer "); ok {
+			token = t
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: rq}, nil
+	}))
+	if err != nil {
+		return "", err
+	}
+	rq, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://localhost/", nil)
+	if err != nil {
+		return "", err
+	}
+	rs, err := rt.RoundTrip(rq)
+	if err != nil {
+		return "", err
+	}
+	_ = rs.Body.Close()
+	if token == "" {
+		return "", ErrNoBearerToken
+	}
+	return token, nil
+}
@@ -0,0 +1,20 @@
+package k8sclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestBearerToken(t *testing.T) {
+	ctx := context.Background()
+	token, err := BearerToken(ctx, &rest.Config{Host: "https://example.com", BearerToken: "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	_, err = BearerToken(ctx, &rest.Config{Host: "https://example.com", Username: "admin", Password: "secret"})
+	assert.ErrorIs(t, err, ErrNoBearerToken)
+}
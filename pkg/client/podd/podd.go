@@ -0,0 +1,166 @@
+package podd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/log"
+)
+
+// ProcessName is the name of the pod daemon. It runs as a sidecar in pods that have the
+// agentconfig.InjectPodDaemonAnnotation, and makes the pod the handler of an intercept.
+const ProcessName = "pod-daemon"
+
+type podDaemon struct {
+	name             string
+	workload         string
+	port             string
+	service          string
+	namespace        string
+	managerNamespace string
+}
+
+func Command() *cobra.Command {
+	p := &podDaemon{}
+	c := &cobra.Command{
+		Use:    ProcessName + "-foreground",
+		Short:  "Launch Telepresence Pod Daemon in the foreground",
+		Args:   cobra.ExactArgs(0),
+		Hidden: true,
+		Long: `The Telepresence Pod Daemon runs as a sidecar in a pod. It connects to the traffic-manager using the
+service account of the pod, and intercepts a workload using a port of the pod as the intercept handler.
+The intercept ends when the pod terminates.`,
+		RunE: p.run,
+	}
+	flags := c.Flags()
+	flags.StringVar(&p.workload, "workload", "", "Name of the workload to intercept")
+	flags.StringVar(&p.port, "port", "", "Port of the pod that handles the intercept, optionally followed by :<svcPortIdentifier>")
+	flags.StringVar(&p.name, "name", "", "Name of the intercept. Defaults to the name of the workload")
+	flags.StringVar(&p.service, "service", "", "Name of the service to intercept")
+	flags.StringVar(&p.namespace, "namespace", "", "Namespace of the workload. Defaults to the namespace of the pod")
+	flags.StringVar(&p.managerNamespace, "manager-namespace", "", "Namespace of the traffic-manager")
+	return c
+}
+
+func (p *podDaemon) run(cmd *cobra.Command, _ []string) error {
+	if p.workload == "" || p.port == "" {
+		return errcat.User.New("--workload and --port are required")
+	}
+	spec, err := p.interceptSpec()
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	cfg, err := client.LoadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = client.WithConfig(ctx, cfg)
+	ctx = dgroup.WithGoroutineName(ctx, "/"+ProcessName)
+	log.SetLevel(ctx, cfg.LogLevels().UserDaemon.String())
+	dlog.Infof(ctx, "Telepresence %s %s starting...", client.DisplayName, client.DisplayVersion())
+
+	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		SoftShutdownTimeout:  5 * time.Second,
+		EnableSignalHandling: true,
+	})
+
+	// A service without a gRPC server runs the root session in-process.
+	si, err := userd.GetNewServiceFunc(ctx)(ctx, g, cfg, nil)
+	if err != nil {
+		return err
+	}
+	var s rpc.ConnectorServer
+	si.As(&s)
+
+	g.Go("sessions", si.ManageSessions)
+	g.Go("intercept", func(ctx context.Context) error {
+		return p.intercept(ctx, s, spec)
+	})
+	return g.Wait()
+}
+
+// interceptSpec returns the spec of the intercept that this daemon creates.
+func (p *podDaemon) interceptSpec() (*manager.InterceptSpec, error) {
+	local, svcPortID, err := parsePort(p.port)
+	if err != nil {
+		return nil, err
+	}
+	name := p.name
+	if name == "" {
+		name = p.workload
+	}
+	return &manager.InterceptSpec{
+		Name:           name,
+		Agent:          p.workload,
+		ServiceName:    p.service,
+		Namespace:      p.namespace,
+		Mechanism:      "tcp",
+		TargetHost:     "127.0.0.1",
+		TargetPort:     int32(local),
+		PortIdentifier: svcPortID,
+	}, nil
+}
+
+// intercept connects to the traffic-manager and creates the intercept. The intercept is removed, and the
+// session is ended, when the given context is cancelled.
+func (p *podDaemon) intercept(ctx context.Context, s rpc.ConnectorServer, spec *manager.InterceptSpec) error {
+	ci, err := s.Connect(ctx, &rpc.ConnectRequest{
+		KubeFlags:        p.kubeFlags(),
+		IsPodDaemon:      true,
+		ManagerNamespace: p.managerNamespace,
+	})
+	if err != nil {
+		return err
+	}
+	if ci.Error != rpc.ConnectInfo_UNSPECIFIED && ci.Error != rpc.ConnectInfo_ALREADY_CONNECTED {
+		return errors.New(ci.ErrorText)
+	}
+	dlog.Infof(ctx, "Connected to traffic-manager in namespace %s", ci.ManagerNamespace)
+
+	defer func() {
+		if _, err := s.Disconnect(context.WithoutCancel(ctx), &emptypb.Empty{}); err != nil {
+			dlog.Error(ctx, err)
+		}
+	}()
+	if err = intercept.Result(s.CreateIntercept(ctx, &rpc.CreateInterceptRequest{Spec: spec})); err != nil {
+		return err
+	}
+	dlog.Infof(ctx, "Intercept %s of workload %s routes traffic to port %d", spec.Name, spec.Agent, spec.TargetPort)
+	<-ctx.Done()
+	return nil
+}
+
+func (p *podDaemon) kubeFlags() map[string]string {
+	if p.namespace == "" {
+		return nil
+	}
+	return map[string]string{"namespace": p.namespace}
+}
+
+// parsePort parses a port declaration on the form <local-port>[:<svcPortIdentifier>].
+func parsePort(portSpec string) (local uint16, svcPortID string, err error) {
+	pp, svcPortID, ok := strings.Cut(portSpec, ":")
+	if local, err = agentconfig.ParseNumericPort(pp); err == nil && ok {
+		err = agentconfig.ValidatePort(svcPortID)
+	}
+	if err != nil {
+		return 0, "", errcat.User.Newf("port %q must be of the format <local-port>[:<svcPortIdentifier>]", portSpec)
+	}
+	return local, svcPortID, nil
+}
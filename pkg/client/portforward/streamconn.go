@@ -66,6 +66,15 @@ func WithRestConfig(ctx context.Context, restConfig *rest.Config) context.Contex
 	})
 }
 
+// GetRestConfig returns the rest.Config that was added to the context using WithRestConfig, or nil if no
+// such config exists.
+func GetRestConfig(ctx context.Context) *rest.Config {
+	if cfg, ok := ctx.Value(dialerKey{}).(*config); ok {
+		return cfg.restConfig
+	}
+	return nil
+}
+
 func Dialer(ctx context.Context) func(ctx context.Context, address string) (net.Conn, error) {
 	cfg, ok := ctx.Value(dialerKey{}).(*config)
 	return func(grpcCtx context.Context, address string) (net.Conn, error) {
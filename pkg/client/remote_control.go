@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// RemoteControl configures the TLS-protected TCP listener that lets a CLI on another host, e.g. the host of a
+// devcontainer or of a remote VM, control the user daemon. The certificate, key, and CA of the CLI that
+// controls such a daemon are configured using the same fields.
+type RemoteControl struct {
+	// ListenAddress is the TCP address, e.g. "0.0.0.0:8443", that the user daemon serves its gRPC API on in
+	// addition to the local socket. Remote control is disabled when empty.
+	ListenAddress string `json:"listenAddress,omitempty"`
+
+	// CertFile is the PEM encoded certificate that the daemon, or the CLI, presents.
+	CertFile string `json:"certFile,omitempty"`
+
+	// KeyFile is the PEM encoded private key of the certificate.
+	KeyFile string `json:"keyFile,omitempty"`
+
+	// CAFile is the PEM encoded certificate of the CA that must have issued the certificate of the other party.
+	CAFile string `json:"caFile,omitempty"`
+}
+
+func (r *RemoteControl) merge(o *RemoteControl) {
+	if o.ListenAddress != "" {
+		r.ListenAddress = o.ListenAddress
+	}
+	if o.CertFile != "" {
+		r.CertFile = o.CertFile
+	}
+	if o.KeyFile != "" {
+		r.KeyFile = o.KeyFile
+	}
+	if o.CAFile != "" {
+		r.CAFile = o.CAFile
+	}
+}
+
+// HasTLS returns true if a certificate is configured.
+func (r *RemoteControl) HasTLS() bool {
+	return r.CertFile != ""
+}
+
+// ServerTLSConfig returns the TLS config of the listener of the user daemon. Clients must present a certificate
+// that was issued by the CA.
+func (r *RemoteControl) ServerTLSConfig() (*tls.Config, error) {
+	cert, pool, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTLSConfig returns the TLS config of a CLI that controls a remote user daemon. The daemon must present a
+// certificate that was issued by the CA.
+func (r *RemoteControl) ClientTLSConfig() (*tls.Config, error) {
+	cert, pool, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func (r *RemoteControl) load() (tls.Certificate, *x509.CertPool, error) {
+	if r.CertFile == "" || r.KeyFile == "" || r.CAFile == "" {
+		return tls.Certificate{}, nil, errcat.Config.New("grpc.remoteControl requires a certFile, a keyFile, and a caFile")
+	}
+	cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, errcat.Config.Newf("unable to load the grpc.remoteControl certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(r.CAFile)
+	if err != nil {
+		return tls.Certificate{}, nil, errcat.Config.Newf("unable to load the grpc.remoteControl CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, errcat.Config.Newf("no certificates found in %s", r.CAFile)
+	}
+	return cert, pool, nil
+}
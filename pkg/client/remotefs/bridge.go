@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dlog"
@@ -15,6 +16,10 @@ import (
 )
 
 type bridgeMounter struct {
+	sync.Mutex
+	podIP         net.IP
+	port          uint16
+	listening     bool
 	localPort     uint16
 	sessionID     string
 	managerClient manager.ManagerClient
@@ -28,8 +33,17 @@ func NewBridgeMounter(sessionID string, managerClient manager.ManagerClient, loc
 	}
 }
 
+// Start starts listening on the local port, and forwards all connections to the given podIP and port. Subsequent
+// calls just switch the address that new connections are forwarded to.
 func (m *bridgeMounter) Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error {
-	ctx = dgroup.WithGoroutineName(ctx, iputil.JoinIpPort(podIP, port))
+	m.Lock()
+	defer m.Unlock()
+	if m.listening {
+		dlog.Infof(ctx, "Switching remote address of mount bridge at port %d to %s", m.localPort, iputil.JoinIpPort(podIP, port))
+		m.podIP, m.port = podIP, port
+		return nil
+	}
+	ctx = dgroup.WithGoroutineName(ctx, fmt.Sprintf("/mount-bridge:%d", m.localPort))
 	lc := &net.ListenConfig{}
 	la := fmt.Sprintf(":%d", m.localPort)
 	l, err := lc.Listen(ctx, "tcp", la)
@@ -37,16 +51,25 @@ func (m *bridgeMounter) Start(ctx context.Context, id, clientMountPoint, mountPo
 		return err
 	}
 	dlog.Debugf(ctx, "Remote mount bridge listening at %s, will forward to %s", la, iputil.JoinIpPort(podIP, port))
+	m.podIP, m.port, m.listening = podIP, port, true
+	context.AfterFunc(ctx, func() {
+		_ = l.Close()
+	})
 	go func() {
 		for {
 			conn, err := l.Accept()
 			if err != nil {
-				dlog.Errorf(ctx, "mount listener failed: %v", err)
+				if ctx.Err() == nil {
+					dlog.Errorf(ctx, "mount listener failed: %v", err)
+				}
 				return
 			}
 			if ctx.Err() != nil {
 				return
 			}
+			m.Lock()
+			podIP, port := m.podIP, m.port
+			m.Unlock()
 			go func() {
 				if err := m.dispatchToTunnel(ctx, conn, podIP, port); err != nil {
 					dlog.Error(ctx, err)
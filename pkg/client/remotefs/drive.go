@@ -0,0 +1,57 @@
+package remotefs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AutoDriveLetter is the mount point that a client uses on Windows when it wants the user daemon to pick a
+// free drive letter at the time of the mount.
+const AutoDriveLetter = "*"
+
+// driveLetters are the drive letters that are assigned by this process and not yet released.
+var driveLetters = struct { //nolint:gochecknoglobals // protected by mutex
+	sync.Mutex
+	assigned map[byte]struct{}
+}{assigned: make(map[byte]struct{})}
+
+// AssignDriveLetter returns a drive letter, followed by a colon, that is neither in use, nor assigned and not
+// yet released. The letter must be released using ReleaseDriveLetter when it's no longer used.
+func AssignDriveLetter() (string, error) {
+	driveLetters.Lock()
+	defer driveLetters.Unlock()
+
+	// Begin at T and loop around, skipping C and D. A and B are rarely used nowadays. No floppy-disks.
+	for _, c := range []byte("TUVXYZABEFGHIJKLMNOPQR") {
+		if _, ok := driveLetters.assigned[c]; ok {
+			continue
+		}
+		if _, err := os.Stat(fmt.Sprintf(`%c:\`, c)); os.IsNotExist(err) {
+			driveLetters.assigned[c] = struct{}{}
+			return fmt.Sprintf("%c:", c), nil
+		}
+	}
+	return "", errors.New("found no available drive to use as mount point")
+}
+
+// ReleaseDriveLetter releases a drive letter that was returned by AssignDriveLetter.
+func ReleaseDriveLetter(dl string) {
+	if len(dl) == 2 && dl[1] == ':' {
+		driveLetters.Lock()
+		delete(driveLetters.assigned, dl[0])
+		driveLetters.Unlock()
+	}
+}
+
+// IsUNCPath returns true if the given path is a UNC path of the form \\server\share, optionally followed
+// by more path elements.
+func IsUNCPath(p string) bool {
+	if !strings.HasPrefix(p, `\\`) {
+		return false
+	}
+	parts := strings.Split(p[2:], `\`)
+	return len(parts) >= 2 && parts[0] != "" && parts[1] != ""
+}
@@ -0,0 +1,47 @@
+package remotefs
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUNCPath(t *testing.T) {
+	tests := map[string]bool{
+		`\\server\share`:         true,
+		`\\server\share\dir`:     true,
+		`\\server`:               false,
+		`\\server\`:              false,
+		`\\\share`:               false,
+		`T:`:                     false,
+		`C:\Users`:               false,
+		`/tmp/telfs-1234`:        false,
+		`\server\share`:          false,
+		`\\192.168.0.10\volumes`: true,
+	}
+	for p, want := range tests {
+		assert.Equal(t, want, IsUNCPath(p), p)
+	}
+}
+
+func TestAssignDriveLetter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the drive letters in use depend on the machine")
+	}
+	first, err := AssignDriveLetter()
+	require.NoError(t, err)
+	assert.Equal(t, "T:", first)
+
+	// An assigned letter isn't assigned again until it's released.
+	second, err := AssignDriveLetter()
+	require.NoError(t, err)
+	assert.Equal(t, "U:", second)
+	ReleaseDriveLetter(first)
+	again, err := AssignDriveLetter()
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	ReleaseDriveLetter(again)
+	ReleaseDriveLetter(second)
+}
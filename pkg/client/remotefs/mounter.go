@@ -5,10 +5,30 @@ import (
 	"net"
 )
 
+// The modes in which the remote volumes of an intercept can be made available locally.
+const (
+	// MountModeFuse mounts the remote volumes using FUSE. This is the default.
+	MountModeFuse = "fuse"
+
+	// MountModeSync mirrors the remote volumes to a local directory, and syncs changes in both directions.
+	MountModeSync = "sync"
+
+	// MountModeSyncPull mirrors the remote volumes to a local directory, and syncs remote changes only.
+	MountModeSyncPull = "sync-pull"
+
+	// MountModeWebDAV mounts the remote volumes using the native WebDAV client of the operating system.
+	MountModeWebDAV = "webdav"
+)
+
+// IsSyncMode returns true if the given mount mode mirrors the remote volumes to a local directory.
+func IsSyncMode(mode string) bool {
+	return mode == MountModeSync || mode == MountModeSyncPull
+}
+
 // A Mounter is responsible for mounting a remote filesystem in a local directory or drive letter.
 type Mounter interface {
 	// Start mounts the remote directory given by mountPoint on the local directory or drive letter
-	// given ty clientMountPoint. The podIP and port is the address to the remote FTP or SFTP server.
+	// given ty clientMountPoint. The podIP and port is the address to the remote FTP, SFTP, or WebDAV server.
 	// The id is just used for logging purposes.
 	Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error
 }
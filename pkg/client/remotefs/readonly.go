@@ -0,0 +1,72 @@
+package remotefs
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ReadOnlyRules maps slash separated paths, relative to the root of a remote mount, to true when they are
+// read-only and to false when they are writable. A rule applies to the path and everything below it, and the
+// rule with the longest matching path wins. The rule for "/" is the default. Paths that no rule matches are
+// writable.
+type ReadOnlyRules map[string]bool
+
+// ParseReadOnlyRules parses rules in the form "<path>=<bool>". A rule without a path, i.e. just "<bool>", sets
+// the default.
+func ParseReadOnlyRules(rules []string) (ReadOnlyRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	rs := make(ReadOnlyRules, len(rules))
+	for _, r := range rules {
+		p, v, ok := strings.Cut(r, "=")
+		if !ok {
+			p, v = "/", r
+		}
+		ro, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid read-only rule %q, must be <bool> or <path>=<bool>", r)
+		}
+		rs[cleanRulePath(p)] = ro
+	}
+	return rs, nil
+}
+
+func cleanRulePath(p string) string {
+	return path.Clean("/" + strings.TrimSpace(p))
+}
+
+// IsReadOnly returns true if the given path, relative to the root of the remote mount, is read-only.
+func (rs ReadOnlyRules) IsReadOnly(p string) bool {
+	for p = cleanRulePath(p); ; p = path.Dir(p) {
+		if ro, ok := rs[p]; ok {
+			return ro
+		}
+		if p == "/" {
+			return false
+		}
+	}
+}
+
+// AllReadOnly returns true if everything is read-only, in which case the rules can be enforced by a read-only
+// mount.
+func (rs ReadOnlyRules) AllReadOnly() bool {
+	for _, ro := range rs {
+		if !ro {
+			return false
+		}
+	}
+	return rs["/"]
+}
+
+// Mixed returns true if the rules make some paths writable and others read-only.
+func (rs ReadOnlyRules) Mixed() bool {
+	for _, ro := range rs {
+		if ro {
+			return !rs.AllReadOnly()
+		}
+	}
+	return false
+}
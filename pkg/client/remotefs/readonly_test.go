@@ -0,0 +1,48 @@
+package remotefs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadOnlyRules(t *testing.T) {
+	rules, err := ParseReadOnlyRules(nil)
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	rules, err = ParseReadOnlyRules([]string{"true", "data=false", "/var/run/secrets/=true", "/data/cache=true"})
+	require.NoError(t, err)
+	assert.Equal(t, ReadOnlyRules{"/": true, "/data": false, "/var/run/secrets": true, "/data/cache": true}, rules)
+
+	_, err = ParseReadOnlyRules([]string{"/data=rw"})
+	assert.Error(t, err)
+}
+
+func TestReadOnlyRules(t *testing.T) {
+	rules := ReadOnlyRules{"/": true, "/data": false, "/data/cache": true}
+	tests := map[string]bool{
+		"":                  true,
+		"/":                 true,
+		"/etc/config":       true,
+		"/data":             false,
+		"data/db.json":      false,
+		"/database":         true,
+		"/data/cache":       true,
+		"/data/cache/x/y":   true,
+		"/data/cached.json": false,
+	}
+	for p, ro := range tests {
+		assert.Equal(t, ro, rules.IsReadOnly(p), p)
+	}
+	assert.True(t, rules.Mixed())
+	assert.False(t, rules.AllReadOnly())
+
+	assert.False(t, ReadOnlyRules{"/secrets": true}.AllReadOnly())
+	assert.True(t, ReadOnlyRules{"/secrets": true}.Mixed())
+	assert.True(t, ReadOnlyRules{"/": true, "/secrets": true}.AllReadOnly())
+	assert.False(t, ReadOnlyRules{"/": true}.Mixed())
+	assert.False(t, ReadOnlyRules{"/": false, "/data": false}.Mixed())
+	assert.False(t, ReadOnlyRules(nil).IsReadOnly("/data"))
+}
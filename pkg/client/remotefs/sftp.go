@@ -2,12 +2,16 @@ package remotefs
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"runtime"
 	"sync"
 	"time"
 
+	"github.com/pkg/sftp"
+
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
@@ -20,10 +24,15 @@ type sftpMounter struct {
 	sync.Mutex
 	iceptWG *sync.WaitGroup
 	podWG   *sync.WaitGroup
+	options []string
+	rules   ReadOnlyRules
 }
 
-func NewSFTPMounter(iceptWG, podWG *sync.WaitGroup) Mounter {
-	return &sftpMounter{iceptWG: iceptWG, podWG: podWG}
+// NewSFTPMounter returns a Mounter that mounts the remote directory using sshfs. The given options, e.g. "ro" or
+// "attr_timeout=30", are passed to sshfs using "-o". The mount is read-only when the rules make everything
+// read-only. When the rules mix read-only and writable paths, sshfs talks to an SFTP proxy that enforces them.
+func NewSFTPMounter(iceptWG, podWG *sync.WaitGroup, options []string, rules ReadOnlyRules) Mounter {
+	return &sftpMounter{iceptWG: iceptWG, podWG: podWG, options: options, rules: rules}
 }
 
 func (m *sftpMounter) Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error {
@@ -59,20 +68,33 @@ func (m *sftpMounter) Start(ctx context.Context, id, clientMountPoint, mountPoin
 				"-o", "follow_symlinks",
 				"-o", "allow_root", // needed to make --docker-run work as docker runs as root
 			}
+			for _, opt := range m.options {
+				sshfsArgs = append(sshfsArgs, "-o", opt)
+			}
+			if m.rules.AllReadOnly() {
+				sshfsArgs = append(sshfsArgs, "-o", "ro")
+			}
+			mp := clientMountPoint
+			if runtime.GOOS == "windows" && IsUNCPath(mp) {
+				// WinFsp makes the file system available as a network share with the given UNC prefix.
+				sshfsArgs = append(sshfsArgs, "-o", "VolumePrefix="+mp[1:])
+				mp = AutoDriveLetter
+			}
 
-			useIPv6 := len(podIP) == 16
-			if useIPv6 {
-				// Must use stdin/stdout because sshfs is not capable of connecting with IPv6
+			// Must use stdin/stdout when the connection is proxied, or because sshfs is not capable of
+			// connecting with IPv6
+			useStdio := m.rules.Mixed() || len(podIP) == 16
+			if useStdio {
 				sshfsArgs = append(sshfsArgs,
 					"-o", "slave",
 					fmt.Sprintf("localhost:%s", mountPoint),
-					clientMountPoint, // where to mount it
+					mp, // where to mount it
 				)
 			} else {
 				sshfsArgs = append(sshfsArgs,
 					"-o", fmt.Sprintf("directport=%d", port),
 					fmt.Sprintf("%s:%s", podIP.String(), mountPoint), // what to mount
-					clientMountPoint, // where to mount it
+					mp, // where to mount it
 				)
 			}
 
@@ -83,9 +105,9 @@ func (m *sftpMounter) Start(ctx context.Context, id, clientMountPoint, mountPoin
 				exe = "sshfs-win"
 			}
 			var err error
-			if useIPv6 {
-				var conn net.Conn
-				if conn, err = net.Dial("tcp6", iputil.JoinIpPort(podIP, port)); err == nil {
+			if useStdio {
+				var conn io.ReadWriteCloser
+				if conn, err = m.dial(ctx, podIP, port, mountPoint); err == nil {
 					defer conn.Close()
 					err = dpipe.DPipe(ctx, conn, exe, sshfsArgs...)
 				}
@@ -108,3 +130,28 @@ func (m *sftpMounter) Start(ctx context.Context, id, clientMountPoint, mountPoin
 	}()
 	return nil
 }
+
+// dial returns a connection to the SFTP server of the pod. The connection goes through a proxy that enforces the
+// read-only rules when they mix read-only and writable paths.
+func (m *sftpMounter) dial(ctx context.Context, podIP net.IP, port uint16, mountPoint string) (io.ReadWriteCloser, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", iputil.JoinIpPort(podIP, port))
+	if err != nil || !m.rules.Mixed() {
+		return conn, err
+	}
+	sc, err := sftp.NewClientPipe(conn, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	local, remote := net.Pipe()
+	srv := newReadOnlyProxy(remote, sc, mountPoint, m.rules)
+	go func() {
+		if err := srv.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			dlog.Errorf(ctx, "SFTP proxy failed: %v", err)
+		}
+		_ = srv.Close()
+		_ = sc.Close()
+		_ = conn.Close()
+	}()
+	return local, nil
+}
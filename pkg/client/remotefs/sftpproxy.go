@@ -0,0 +1,203 @@
+package remotefs
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// readOnlyProxy is a set of SFTP request handlers that forward all requests to a remote SFTP server, but deny
+// requests that would modify a path that the rules declare read-only.
+type readOnlyProxy struct {
+	client *sftp.Client
+	root   string
+	rules  ReadOnlyRules
+}
+
+// newReadOnlyProxy returns an SFTP server that serves the given connection by forwarding to the given client.
+// The rules apply to paths relative to root.
+func newReadOnlyProxy(conn io.ReadWriteCloser, client *sftp.Client, root string, rules ReadOnlyRules) *sftp.RequestServer {
+	p := &readOnlyProxy{client: client, root: root, rules: rules}
+	return sftp.NewRequestServer(conn, sftp.Handlers{FileGet: p, FilePut: p, FileCmd: p, FileList: p})
+}
+
+func (p *readOnlyProxy) checkWritable(paths ...string) error {
+	for _, fp := range paths {
+		rel, ok := strings.CutPrefix(fp, p.root)
+		if !ok || rel != "" && rel[0] != '/' {
+			// Paths outside the root aren't covered by the rules.
+			continue
+		}
+		if p.rules.IsReadOnly(rel) {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+	}
+	return nil
+}
+
+func openFlags(pf sftp.FileOpenFlags) int {
+	var flags int
+	switch {
+	case pf.Read && pf.Write:
+		flags = os.O_RDWR
+	case pf.Write:
+		flags = os.O_WRONLY
+	default:
+		flags = os.O_RDONLY
+	}
+	if pf.Append {
+		flags |= os.O_APPEND
+	}
+	if pf.Creat {
+		flags |= os.O_CREATE
+	}
+	if pf.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pf.Excl {
+		flags |= os.O_EXCL
+	}
+	return flags
+}
+
+func (p *readOnlyProxy) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	return p.client.Open(r.Filepath)
+}
+
+func (p *readOnlyProxy) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return p.OpenFile(r)
+}
+
+func (p *readOnlyProxy) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	if err := p.checkWritable(r.Filepath); err != nil {
+		return nil, err
+	}
+	return p.client.OpenFile(r.Filepath, openFlags(r.Pflags()))
+}
+
+func (p *readOnlyProxy) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		if err := p.checkWritable(r.Filepath); err != nil {
+			return err
+		}
+		return p.setstat(r)
+	case "Rename":
+		if err := p.checkWritable(r.Filepath, r.Target); err != nil {
+			return err
+		}
+		return p.client.Rename(r.Filepath, r.Target)
+	case "Rmdir":
+		if err := p.checkWritable(r.Filepath); err != nil {
+			return err
+		}
+		return p.client.RemoveDirectory(r.Filepath)
+	case "Remove":
+		if err := p.checkWritable(r.Filepath); err != nil {
+			return err
+		}
+		return p.client.Remove(r.Filepath)
+	case "Mkdir":
+		if err := p.checkWritable(r.Filepath); err != nil {
+			return err
+		}
+		return p.client.Mkdir(r.Filepath)
+	case "Link":
+		if err := p.checkWritable(r.Target); err != nil {
+			return err
+		}
+		return p.client.Link(r.Filepath, r.Target)
+	case "Symlink":
+		// The link is created at r.Target and points to r.Filepath.
+		if err := p.checkWritable(r.Target); err != nil {
+			return err
+		}
+		return p.client.Symlink(r.Filepath, r.Target)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (p *readOnlyProxy) setstat(r *sftp.Request) error {
+	af := r.AttrFlags()
+	attrs := r.Attributes()
+	if af.Size {
+		if err := p.client.Truncate(r.Filepath, int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+	if af.Permissions {
+		if err := p.client.Chmod(r.Filepath, attrs.FileMode()); err != nil {
+			return err
+		}
+	}
+	if af.UidGid {
+		if err := p.client.Chown(r.Filepath, int(attrs.UID), int(attrs.GID)); err != nil {
+			return err
+		}
+	}
+	if af.Acmodtime {
+		at := time.Unix(int64(attrs.Atime), 0)
+		mt := time.Unix(int64(attrs.Mtime), 0)
+		if err := p.client.Chtimes(r.Filepath, at, mt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *readOnlyProxy) PosixRename(r *sftp.Request) error {
+	if err := p.checkWritable(r.Filepath, r.Target); err != nil {
+		return err
+	}
+	return p.client.PosixRename(r.Filepath, r.Target)
+}
+
+func (p *readOnlyProxy) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	return p.client.StatVFS(r.Filepath)
+}
+
+func (p *readOnlyProxy) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		fis, err := p.client.ReadDir(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt(fis), nil
+	case "Stat":
+		fi, err := p.client.Stat(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{fi}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+func (p *readOnlyProxy) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	fi, err := p.client.Lstat(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return listerAt{fi}, nil
+}
+
+func (p *readOnlyProxy) Readlink(fp string) (string, error) {
+	return p.client.ReadLink(fp)
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(fis []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(fis, l[offset:])
+	if n < len(fis) {
+		return n, io.EOF
+	}
+	return n, nil
+}
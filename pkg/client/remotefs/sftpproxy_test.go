@@ -0,0 +1,69 @@
+package remotefs
+
+import (
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyProxy(t *testing.T) {
+	root := filepath.ToSlash(t.TempDir())
+	writeFile(t, root, "config/app.yaml", "key: value\n", time.Now())
+	require.NoError(t, os.Mkdir(filepath.Join(root, "data"), 0o755))
+
+	// The remote SFTP server.
+	serverConn, remoteConn := net.Pipe()
+	server, err := sftp.NewServer(serverConn)
+	require.NoError(t, err)
+	go func() { _ = server.Serve() }()
+	remote, err := sftp.NewClientPipe(remoteConn, remoteConn)
+	require.NoError(t, err)
+	defer remote.Close()
+
+	// The proxy, and a client that talks to it.
+	proxyConn, clientConn := net.Pipe()
+	proxy := newReadOnlyProxy(proxyConn, remote, root, ReadOnlyRules{"/": true, "/data": false})
+	go func() { _ = proxy.Serve() }()
+	sc, err := sftp.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+	defer sc.Close()
+
+	// Everything can be read.
+	f, err := sc.Open(path.Join(root, "config", "app.yaml"))
+	require.NoError(t, err)
+	data := make([]byte, 64)
+	n, _ := f.Read(data)
+	assert.Equal(t, "key: value\n", string(data[:n]))
+	require.NoError(t, f.Close())
+	fis, err := sc.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, fis, 2)
+
+	// Only the data directory can be modified.
+	f, err = sc.Create(path.Join(root, "data", "db.json"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("{}\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "{}\n", readFile(t, root, "data/db.json"))
+	require.NoError(t, sc.Mkdir(path.Join(root, "data", "sub")))
+
+	denied := func(err error) {
+		t.Helper()
+		assert.ErrorIs(t, err, os.ErrPermission)
+	}
+	_, err = sc.Create(path.Join(root, "config", "app.yaml"))
+	denied(err)
+	denied(sc.Remove(path.Join(root, "config", "app.yaml")))
+	denied(sc.Mkdir(path.Join(root, "other")))
+	denied(sc.Chmod(path.Join(root, "config"), 0o700))
+	denied(sc.Rename(path.Join(root, "data", "db.json"), path.Join(root, "config", "db.json")))
+	assert.Equal(t, "key: value\n", readFile(t, root, "config/app.yaml"))
+}
@@ -0,0 +1,405 @@
+package remotefs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// syncInterval is the time between two synchronizations of a synced directory.
+const syncInterval = 2 * time.Second
+
+// maxSyncDepth guards against symbolic links that point to a parent directory.
+const maxSyncDepth = 32
+
+type syncMounter struct {
+	sync.Mutex
+	iceptWG  *sync.WaitGroup
+	podWG    *sync.WaitGroup
+	pullOnly bool
+	rules    ReadOnlyRules
+}
+
+// NewSyncMounter returns a Mounter that, instead of mounting the remote directory, mirrors it to a local directory
+// using the SFTP server of the traffic-agent. Changes are synchronized in both directions unless pullOnly is true,
+// in which case the local directory is a one-way mirror of the remote directory. Paths that the rules declare
+// read-only are always mirrored one-way. No FUSE implementation is needed.
+func NewSyncMounter(iceptWG, podWG *sync.WaitGroup, pullOnly bool, rules ReadOnlyRules) Mounter {
+	return &syncMounter{iceptWG: iceptWG, podWG: podWG, pullOnly: pullOnly, rules: rules}
+}
+
+func (m *syncMounter) Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error {
+	ctx = dgroup.WithGoroutineName(ctx, iputil.JoinIpPort(podIP, port))
+	m.podWG.Add(1)
+	m.iceptWG.Add(1)
+	go func() {
+		defer m.iceptWG.Done()
+		defer m.podWG.Done()
+
+		// One pod must be done syncing before the next one starts.
+		m.Lock()
+		defer m.Unlock()
+
+		dlog.Infof(ctx, "Syncing remote directory of intercept %q (pod %s) with %q", id, podIP, clientMountPoint)
+		defer dlog.Infof(ctx, "Stopped syncing remote directory of intercept %q (pod %s) with %q", id, podIP, clientMountPoint)
+
+		// The volumes of a new pod may differ, so nothing is known about what was synced before.
+		s := &syncer{local: localSyncFS(clientMountPoint), pullOnly: m.pullOnly, rules: m.rules}
+		err := client.Retry(ctx, "sync", func(ctx context.Context) error {
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", iputil.JoinIpPort(podIP, port))
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			sc, err := sftp.NewClientPipe(conn, conn)
+			if err != nil {
+				return err
+			}
+			defer sc.Close()
+			s.remote = &remoteSyncFS{client: sc, root: mountPoint}
+
+			ticker := time.NewTicker(syncInterval)
+			defer ticker.Stop()
+			for {
+				if err = s.sync(ctx); err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		}, 3*time.Second, 6*time.Second)
+		if err != nil {
+			dlog.Error(ctx, err)
+		}
+	}()
+	return nil
+}
+
+// fileState is the state of a synced file or directory.
+type fileState struct {
+	dir     bool
+	size    int64
+	modTime time.Time
+	perm    fs.FileMode
+}
+
+func newFileState(fi fs.FileInfo) fileState {
+	// SFTP uses a precision of one second.
+	st := fileState{dir: fi.IsDir(), perm: fi.Mode().Perm()}
+	if !st.dir {
+		st.size = fi.Size()
+		st.modTime = fi.ModTime().Truncate(time.Second)
+	}
+	return st
+}
+
+func (a fileState) differs(b fileState) bool {
+	return a.dir != b.dir || a.size != b.size || !a.modTime.Equal(b.modTime)
+}
+
+// syncFS is the file system on one side of a sync. All names are slash separated and relative to the
+// root of the synced directory.
+type syncFS interface {
+	readDir(name string) ([]fs.FileInfo, error)
+	stat(name string) (fs.FileInfo, error)
+	open(name string) (io.ReadCloser, error)
+	create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	mkdirAll(name string) error
+	remove(name string) error
+	chtimes(name string, modTime time.Time) error
+}
+
+type localSyncFS string
+
+func (l localSyncFS) path(name string) string {
+	return filepath.Join(string(l), filepath.FromSlash(name))
+}
+
+func (l localSyncFS) readDir(name string) ([]fs.FileInfo, error) {
+	des, err := os.ReadDir(l.path(name))
+	if err != nil {
+		return nil, err
+	}
+	fis := make([]fs.FileInfo, 0, len(des))
+	for _, de := range des {
+		if fi, err := de.Info(); err == nil {
+			fis = append(fis, fi)
+		}
+	}
+	return fis, nil
+}
+
+func (l localSyncFS) stat(name string) (fs.FileInfo, error) {
+	return os.Stat(l.path(name))
+}
+
+func (l localSyncFS) open(name string) (io.ReadCloser, error) {
+	return os.Open(l.path(name))
+}
+
+func (l localSyncFS) create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(l.path(name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+}
+
+func (l localSyncFS) mkdirAll(name string) error {
+	return os.MkdirAll(l.path(name), 0o755)
+}
+
+func (l localSyncFS) remove(name string) error {
+	return os.Remove(l.path(name))
+}
+
+func (l localSyncFS) chtimes(name string, modTime time.Time) error {
+	return os.Chtimes(l.path(name), modTime, modTime)
+}
+
+type remoteSyncFS struct {
+	client *sftp.Client
+	root   string
+}
+
+func (r *remoteSyncFS) path(name string) string {
+	return path.Join(r.root, name)
+}
+
+func (r *remoteSyncFS) readDir(name string) ([]fs.FileInfo, error) {
+	return r.client.ReadDir(r.path(name))
+}
+
+func (r *remoteSyncFS) stat(name string) (fs.FileInfo, error) {
+	return r.client.Stat(r.path(name))
+}
+
+func (r *remoteSyncFS) open(name string) (io.ReadCloser, error) {
+	return r.client.Open(r.path(name))
+}
+
+func (r *remoteSyncFS) create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	p := r.path(name)
+	f, err := r.client.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.client.Chmod(p, perm); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *remoteSyncFS) mkdirAll(name string) error {
+	return r.client.MkdirAll(r.path(name))
+}
+
+func (r *remoteSyncFS) remove(name string) error {
+	return r.client.Remove(r.path(name))
+}
+
+func (r *remoteSyncFS) chtimes(name string, modTime time.Time) error {
+	return r.client.Chtimes(r.path(name), modTime, modTime)
+}
+
+// walk returns the state of all files and directories below the root of the given file system. Symbolic links
+// are followed, and entries with names that start with ".." are skipped, because Kubernetes uses them for the
+// bookkeeping of ConfigMap, Secret, and projected volumes.
+func walk(ctx context.Context, fsys syncFS) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	var walkDir func(dir string, depth int) error
+	walkDir = func(dir string, depth int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fis, err := fsys.readDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			if strings.HasPrefix(fi.Name(), "..") {
+				continue
+			}
+			name := path.Join(dir, fi.Name())
+			if fi.Mode()&fs.ModeSymlink != 0 {
+				if fi, err = fsys.stat(name); err != nil {
+					// Dangling link
+					continue
+				}
+			}
+			switch {
+			case fi.IsDir():
+				states[name] = newFileState(fi)
+				if depth < maxSyncDepth {
+					if err = walkDir(name, depth+1); err != nil {
+						return err
+					}
+				}
+			case fi.Mode().IsRegular():
+				states[name] = newFileState(fi)
+			}
+		}
+		return nil
+	}
+	if err := walkDir("", 0); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// syncedState is the state that a file had on both sides when it was last synced.
+type syncedState struct {
+	remote fileState
+	local  fileState
+}
+
+// A syncer synchronizes a remote and a local directory. Changes on either side are detected by comparing the
+// current state of a file with its state when it was last synced. The remote side wins when a file has changed
+// on both sides.
+type syncer struct {
+	remote   syncFS
+	local    syncFS
+	pullOnly bool
+	rules    ReadOnlyRules
+	synced   map[string]syncedState
+}
+
+func (s *syncer) sync(ctx context.Context) error {
+	rs, err := walk(ctx, s.remote)
+	if err != nil {
+		return fmt.Errorf("unable to read remote directory: %w", err)
+	}
+	ls, err := walk(ctx, s.local)
+	if err != nil {
+		return fmt.Errorf("unable to read local directory: %w", err)
+	}
+	if s.synced == nil {
+		s.synced = make(map[string]syncedState, len(rs))
+	}
+
+	names := make([]string, 0, len(rs)+len(ls))
+	for name := range rs {
+		names = append(names, name)
+	}
+	for name := range ls {
+		if _, ok := rs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var removals []func() error
+	for _, name := range names {
+		r, rok := rs[name]
+		l, lok := ls[name]
+		prev, synced := s.synced[name]
+		pullOnly := s.pullOnly || s.rules.IsReadOnly(name)
+		var err error
+		switch {
+		case rok && lok:
+			rChanged := !synced || prev.remote.differs(r)
+			lChanged := synced && prev.local.differs(l)
+			switch {
+			case r.dir != l.dir:
+				err = fmt.Errorf("%s is a directory on one side only", name)
+			case r.dir || !synced && !r.differs(l):
+				s.synced[name] = syncedState{remote: r, local: l}
+			case lChanged && !rChanged && !pullOnly:
+				err = s.copy(name, l, s.local, s.remote)
+			case rChanged || lChanged:
+				err = s.copy(name, r, s.remote, s.local)
+			}
+		case rok:
+			if synced && !pullOnly {
+				// Deleted locally
+				removals = append(removals, s.remover(name, s.remote))
+			} else {
+				err = s.copy(name, r, s.remote, s.local)
+			}
+		default:
+			if synced || pullOnly {
+				// Deleted remotely, or not present in a one-way mirror
+				removals = append(removals, s.remover(name, s.local))
+			} else {
+				err = s.copy(name, l, s.local, s.remote)
+			}
+		}
+		if err != nil {
+			dlog.Debugf(ctx, "unable to sync %s: %v", name, err)
+		}
+	}
+
+	// Remove in reverse order, so that directories are emptied before they are removed.
+	for i := len(removals) - 1; i >= 0; i-- {
+		if err := removals[i](); err != nil {
+			dlog.Debugf(ctx, "unable to sync removal: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *syncer) remover(name string, fsys syncFS) func() error {
+	return func() error {
+		delete(s.synced, name)
+		return fsys.remove(name)
+	}
+}
+
+// copy copies the named file or directory, with the given state, from one side to the other, and records the
+// state of both sides.
+func (s *syncer) copy(name string, st fileState, from, to syncFS) error {
+	if st.dir {
+		if err := to.mkdirAll(name); err != nil {
+			return err
+		}
+	} else {
+		if err := to.mkdirAll(path.Dir(name)); err != nil {
+			return err
+		}
+		src, err := from.open(name)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := to.create(name, st.perm)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		if cErr := dst.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			return err
+		}
+		if err = to.chtimes(name, st.modTime); err != nil {
+			return err
+		}
+	}
+	rfi, err := s.remote.stat(name)
+	if err != nil {
+		return err
+	}
+	lfi, err := s.local.stat(name)
+	if err != nil {
+		return err
+	}
+	s.synced[name] = syncedState{remote: newFileState(rfi), local: newFileState(lfi)}
+	return nil
+}
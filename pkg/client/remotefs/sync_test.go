@@ -0,0 +1,100 @@
+package remotefs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(p, modTime, modTime))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestSyncer(t *testing.T) {
+	ctx := context.Background()
+	then := time.Now().Add(-time.Hour).Truncate(time.Second)
+	later := then.Add(time.Minute)
+
+	remote := t.TempDir()
+	writeFile(t, remote, "config/app.yaml", "key: value\n", then)
+	writeFile(t, remote, "data/db.json", "{}\n", then)
+	require.NoError(t, os.Mkdir(filepath.Join(remote, "..data"), 0o755))
+
+	t.Run("bidirectional", func(t *testing.T) {
+		local := t.TempDir()
+		s := &syncer{remote: localSyncFS(remote), local: localSyncFS(local)}
+
+		// Initial sync pulls everything except the Kubernetes bookkeeping.
+		require.NoError(t, s.sync(ctx))
+		assert.Equal(t, "key: value\n", readFile(t, local, "config/app.yaml"))
+		assert.Equal(t, "{}\n", readFile(t, local, "data/db.json"))
+		assert.NoDirExists(t, filepath.Join(local, "..data"))
+
+		// Local changes and new local files are pushed.
+		writeFile(t, local, "data/db.json", "{\"a\":1}\n", later)
+		writeFile(t, local, "data/new.txt", "new\n", later)
+		require.NoError(t, s.sync(ctx))
+		assert.Equal(t, "{\"a\":1}\n", readFile(t, remote, "data/db.json"))
+		assert.Equal(t, "new\n", readFile(t, remote, "data/new.txt"))
+
+		// Remote changes are pulled.
+		writeFile(t, remote, "config/app.yaml", "key: other\n", later)
+		require.NoError(t, s.sync(ctx))
+		assert.Equal(t, "key: other\n", readFile(t, local, "config/app.yaml"))
+
+		// Local removals are pushed, remote removals are pulled.
+		require.NoError(t, os.Remove(filepath.Join(local, "data", "new.txt")))
+		require.NoError(t, s.sync(ctx))
+		assert.NoFileExists(t, filepath.Join(remote, "data", "new.txt"))
+	})
+
+	t.Run("pull only", func(t *testing.T) {
+		local := t.TempDir()
+		s := &syncer{remote: localSyncFS(remote), local: localSyncFS(local), pullOnly: true}
+		require.NoError(t, s.sync(ctx))
+		original := readFile(t, local, "data/db.json")
+
+		// Local changes are overwritten, and local files are removed.
+		writeFile(t, local, "data/db.json", "changed\n", later.Add(time.Minute))
+		writeFile(t, local, "data/local.txt", "local\n", later)
+		require.NoError(t, s.sync(ctx))
+		assert.Equal(t, original, readFile(t, local, "data/db.json"))
+		assert.Equal(t, original, readFile(t, remote, "data/db.json"))
+		assert.NoFileExists(t, filepath.Join(local, "data", "local.txt"))
+		assert.NoFileExists(t, filepath.Join(remote, "data", "local.txt"))
+	})
+
+	t.Run("read-only rules", func(t *testing.T) {
+		local := t.TempDir()
+		rules := ReadOnlyRules{"/": true, "/data": false}
+		s := &syncer{remote: localSyncFS(remote), local: localSyncFS(local), rules: rules}
+		require.NoError(t, s.sync(ctx))
+
+		// Changes to writable paths are pushed, changes to read-only paths are overwritten.
+		writeFile(t, local, "config/app.yaml", "changed\n", later.Add(2*time.Minute))
+		writeFile(t, local, "data/rw.txt", "rw\n", later)
+		writeFile(t, local, "config/ro.txt", "ro\n", later)
+		require.NoError(t, s.sync(ctx))
+		assert.Equal(t, "key: other\n", readFile(t, local, "config/app.yaml"))
+		assert.Equal(t, "key: other\n", readFile(t, remote, "config/app.yaml"))
+		assert.Equal(t, "rw\n", readFile(t, remote, "data/rw.txt"))
+		assert.NoFileExists(t, filepath.Join(local, "config", "ro.txt"))
+		assert.NoFileExists(t, filepath.Join(remote, "config", "ro.txt"))
+	})
+}
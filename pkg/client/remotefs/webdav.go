@@ -0,0 +1,71 @@
+package remotefs
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+type webdavMounter struct {
+	sync.Mutex
+	iceptWG *sync.WaitGroup
+	podWG   *sync.WaitGroup
+}
+
+// NewWebDAVMounter returns a Mounter that mounts the remote directory using the WebDAV server of the
+// traffic-agent and the native WebDAV client of the operating system, which is available on macOS and
+// Windows. No FUSE implementation is needed.
+func NewWebDAVMounter(iceptWG, podWG *sync.WaitGroup) Mounter {
+	return &webdavMounter{iceptWG: iceptWG, podWG: podWG}
+}
+
+func (m *webdavMounter) Start(ctx context.Context, id, clientMountPoint, mountPoint string, podIP net.IP, port uint16) error {
+	ctx = dgroup.WithGoroutineName(ctx, iputil.JoinIpPort(podIP, port))
+	u := webdavURL(podIP, port, mountPoint)
+
+	// The mount is terminated and restarted when the intercept pod changes, so we
+	// must set up a wait/done pair here to ensure that this happens synchronously
+	m.podWG.Add(1)
+	m.iceptWG.Add(1)
+	go func() {
+		defer m.iceptWG.Done()
+		defer m.podWG.Done()
+
+		// One pod must be unmounted before the next one is mounted.
+		m.Lock()
+		defer m.Unlock()
+
+		dlog.Infof(ctx, "Mounting WebDAV file system %s for intercept %q at %q", u, id, clientMountPoint)
+		if err := webdavMount(ctx, u, clientMountPoint); err != nil {
+			dlog.Errorf(ctx, "WebDAV mount of %s at %q failed: %v", u, clientMountPoint, err)
+			return
+		}
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		dlog.Infof(ctx, "Unmounting WebDAV file system for intercept %q at %q", id, clientMountPoint)
+		if err := webdavUnmount(ctx, clientMountPoint); err != nil {
+			dlog.Errorf(ctx, "Unmount of %s failed: %v", clientMountPoint, err)
+		}
+	}()
+	return nil
+}
+
+// webdavURL returns the URL of the given mount point on the WebDAV server of a traffic-agent. The server
+// serves the agentconfig.ExportsMountPoint directory.
+func webdavURL(podIP net.IP, port uint16, mountPoint string) string {
+	p := strings.TrimPrefix(mountPoint, agentconfig.ExportsMountPoint)
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return (&url.URL{Scheme: "http", Host: iputil.JoinIpPort(podIP, port), Path: p}).String()
+}
@@ -0,0 +1,16 @@
+package remotefs
+
+import (
+	"context"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+func webdavMount(ctx context.Context, u, mountPoint string) error {
+	// -S suppresses the dialogs that the finder otherwise shows when the server becomes unreachable.
+	return proc.Run(ctx, nil, "mount_webdav", "-S", "-v", "telepresence", u, mountPoint)
+}
+
+func webdavUnmount(ctx context.Context, mountPoint string) error {
+	return proc.Run(ctx, nil, "umount", mountPoint)
+}
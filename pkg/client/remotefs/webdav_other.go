@@ -0,0 +1,16 @@
+//go:build !darwin && !windows
+
+package remotefs
+
+import (
+	"context"
+	"errors"
+)
+
+func webdavMount(context.Context, string, string) error {
+	return errors.New("WebDAV mounts are only supported on macOS and Windows")
+}
+
+func webdavUnmount(context.Context, string) error {
+	return nil
+}
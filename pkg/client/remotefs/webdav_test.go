@@ -0,0 +1,13 @@
+package remotefs
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebdavURL(t *testing.T) {
+	assert.Equal(t, "http://10.1.2.3:8080/echo/", webdavURL(net.ParseIP("10.1.2.3").To4(), 8080, "/tel_app_exports/echo"))
+	assert.Equal(t, "http://[fd00::3]:8080/echo/", webdavURL(net.ParseIP("fd00::3"), 8080, "/tel_app_exports/echo/"))
+}
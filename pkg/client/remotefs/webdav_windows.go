@@ -0,0 +1,20 @@
+package remotefs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+func webdavMount(ctx context.Context, u, mountPoint string) error {
+	if IsUNCPath(mountPoint) {
+		return errors.New("WebDAV mounts require a drive letter")
+	}
+	// The WebClient service of Windows maps http URLs to drive letters.
+	return proc.Run(ctx, nil, "net", "use", mountPoint, u, "/persistent:no")
+}
+
+func webdavUnmount(ctx context.Context, mountPoint string) error {
+	return proc.Run(ctx, nil, "net", "use", mountPoint, "/delete", "/y")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/dlib/dtime"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
@@ -180,11 +181,14 @@ func (s *Server) dnsListeners(c context.Context) ([]net.PacketConn, error) {
 		return listeners, nil
 	}
 
-	// This is the default docker bridge. We need to listen here because the nat logic we use to intercept
-	// dns packets will divert the packet to the interface it originates from, which in the case of
-	// containers is the docker bridge. Without this dns won't work from inside containers.
-	output, err := dexec.CommandContext(c, "docker", "inspect", "bridge",
-		"-f", "{{(index .IPAM.Config 0).Gateway}}").Output()
+	// This is the default bridge of the container runtime. We need to listen here because the nat logic we use
+	// to intercept dns packets will divert the packet to the interface it originates from, which in the case of
+	// containers is the bridge. Without this dns won't work from inside containers.
+	var dockerGatewayIP net.IP
+	rt, err := docker.GetRuntime(c)
+	if err == nil {
+		dockerGatewayIP, err = rt.BridgeGateway(c)
+	}
 	if err != nil {
 		dlog.Info(c, "not listening on docker bridge")
 		return listeners, nil
@@ -195,8 +199,7 @@ func (s *Server) dnsListeners(c context.Context) ([]net.PacketConn, error) {
 		return nil, err
 	}
 
-	dockerGatewayIP := net.ParseIP(strings.TrimSpace(string(output)))
-	if dockerGatewayIP == nil || dockerGatewayIP.Equal(localAddr.IP) {
+	if dockerGatewayIP.Equal(localAddr.IP) {
 		return listeners, nil
 	}
 
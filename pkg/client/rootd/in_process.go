@@ -112,6 +112,11 @@ func (rd *InProcSession) WaitForAgentIP(ctx context.Context, request *rpc.WaitFo
 	return rd.waitForAgentIP(ctx, request)
 }
 
+func (rd *InProcSession) Wiretap(context.Context, *rpc.WiretapRequest, ...grpc.CallOption) (rpc.Daemon_WiretapClient, error) {
+	// The in-process session shares the taps of the user daemon.
+	return nil, status.Error(codes.Unimplemented, "the root daemon runs in the user daemon's process")
+}
+
 // NewInProcSession returns a root daemon session suitable to use in-process (from the user daemon) and is primarily intended for
 // when the user daemon runs in a docker container with NET_ADMIN capabilities.
 func NewInProcSession(
@@ -0,0 +1,80 @@
+package rootd
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"net/url"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/portforward"
+	"github.com/telepresenceio/telepresence/v2/pkg/routing"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+// minMTU is the smallest MTU that an IPv6 capable link may have. Interface MTUs below this value are ignored.
+const minMTU = 1280
+
+// tunMTU returns the MTU to use for the TUN-device. An MTU configured using routing.mtu always takes
+// precedence. When no MTU is configured, the MTU of the local network interface that routes traffic to the
+// Kubernetes API server is used, because that's where all traffic to and from the traffic-manager leaves the
+// workstation. This prevents large payloads from stalling when that interface has a smaller MTU, e.g. when
+// connected using WireGuard or PPPoE.
+//
+// No path MTU discovery is performed, so a smaller MTU further along the path, e.g. a VPN that is terminated
+// by a router on the local network, isn't detected. Such setups must use routing.mtu.
+func (s *Session) tunMTU(ctx context.Context) int {
+	return chooseMTU(ctx, client.GetConfig(ctx).Routing().MTU, apiServerInterfaceMTU)
+}
+
+// chooseMTU returns the configured MTU when it is set, the detected MTU when it is between minMTU and
+// vif.DefaultMTU, and vif.DefaultMTU otherwise.
+func chooseMTU(ctx context.Context, configured int, detect func(context.Context) (int, error)) int {
+	if configured > 0 {
+		return configured
+	}
+	mtu, err := detect(ctx)
+	if err != nil {
+		dlog.Debugf(ctx, "unable to determine the MTU of the interface used for the Kubernetes API server: %v", err)
+		return vif.DefaultMTU
+	}
+	if mtu < minMTU || mtu > vif.DefaultMTU {
+		return vif.DefaultMTU
+	}
+	dlog.Infof(ctx, "Using MTU %d of the interface used for the Kubernetes API server", mtu)
+	return mtu
+}
+
+// apiServerInterfaceMTU returns the MTU of the network interface that is used when connecting to the
+// Kubernetes API server.
+func apiServerInterfaceMTU(ctx context.Context) (int, error) {
+	rc := portforward.GetRestConfig(ctx)
+	if rc == nil {
+		return 0, nil
+	}
+	u, err := url.Parse(rc.Host)
+	if err != nil {
+		return 0, err
+	}
+	host := u.Hostname()
+	if host == "" {
+		host = rc.Host
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return 0, err
+		}
+		if len(ips) == 0 {
+			return 0, nil
+		}
+		ip = ips[0]
+	}
+	if ip.IsLoopback() {
+		// Loopback MTUs are huge and unrelated to the path to the cluster.
+		return 0, nil
+	}
+	return routing.InterfaceMTU(ctx, ip.Unmap())
+}
@@ -0,0 +1,38 @@
+package rootd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+func Test_chooseMTU(t *testing.T) {
+	detected := func(mtu int, err error) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) { return mtu, err }
+	}
+	tests := []struct {
+		name       string
+		configured int
+		detect     func(context.Context) (int, error)
+		want       int
+	}{
+		{"configured", 1380, detected(1420, nil), 1380},
+		{"configured larger than default", 9000, detected(1420, nil), 9000},
+		{"detected", 0, detected(1420, nil), 1420},
+		{"detected minimum", 0, detected(minMTU, nil), minMTU},
+		{"detected too small", 0, detected(minMTU-1, nil), vif.DefaultMTU},
+		{"detected too large", 0, detected(65536, nil), vif.DefaultMTU},
+		{"nothing detected", 0, detected(0, nil), vif.DefaultMTU},
+		{"detection failed", 0, detected(1420, errors.New("no route")), vif.DefaultMTU},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, chooseMTU(dlog.NewTestContext(t, false), tt.configured, tt.detect))
+		})
+	}
+}
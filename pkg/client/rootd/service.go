@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"sync"
@@ -28,6 +29,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/wiretap"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
@@ -277,6 +279,16 @@ func (s *Service) WaitForAgentIP(ctx context.Context, request *rpc.WaitForAgentI
 	return rsp, err
 }
 
+func (s *Service) Wiretap(request *rpc.WiretapRequest, stream rpc.Daemon_WiretapServer) error {
+	ip, ok := netip.AddrFromSlice(request.Ip)
+	if !ok {
+		return status.Error(codes.InvalidArgument, "invalid handler IP")
+	}
+	ctx := stream.Context()
+	dlog.Debugf(ctx, "Wiretap %s", netip.AddrPortFrom(ip, uint16(request.Port)))
+	return wiretap.Stream(ctx, netip.AddrPortFrom(ip, uint16(request.Port)), stream.Send)
+}
+
 func (s *Service) SetLogLevel(ctx context.Context, request *manager.LogLevelRequest) (*emptypb.Empty, error) {
 	duration := time.Duration(0)
 	if request.Duration != nil {
@@ -195,6 +195,10 @@ type Session struct {
 
 	// daemon runs as part of a pod-daemon setup.
 	podDaemon bool
+
+	// userspace is true when the session runs without a TUN-device and without configuring the
+	// system DNS. Connections to the cluster are then made through a local SOCKS5 proxy.
+	userspace bool
 }
 
 type NewSessionFunc func(context.Context, *rpc.NetworkConfig) (context.Context, *Session, error)
@@ -230,6 +234,7 @@ func connectToManager(
 	namespace string,
 	kubeFlags map[string]string,
 	kubeData []byte,
+	tunnelCompression string,
 ) (
 	context.Context,
 	*grpc.ClientConn,
@@ -277,7 +282,7 @@ func connectToManager(
 		conn.Close()
 		return ctx, nil, nil, mgrVer, fmt.Errorf("failed to parse manager version %q: %w", verStr, err)
 	}
-	return ctx, conn, &userdToManagerShortcut{mc}, mgrVer, nil
+	return ctx, conn, &userdToManagerShortcut{tunnel.CompressingManagerClient(mc, tunnelCompression)}, mgrVer, nil
 }
 
 // connectToUserDaemon is like connectToManager but the port-forward will be established from the user-daemon
@@ -335,7 +340,7 @@ func NewSession(c context.Context, mi *rpc.NetworkConfig) (context.Context, *Ses
 		return c, nil, err
 	}
 	c = client.WithConfig(c, cfg)
-	c, conn, mc, ver, err := connectToManager(c, cfg.Cluster().DefaultManagerNamespace, mi.KubeFlags, mi.KubeconfigData)
+	c, conn, mc, ver, err := connectToManager(c, cfg.Cluster().DefaultManagerNamespace, mi.KubeFlags, mi.KubeconfigData, mi.Session.GetTunnelCompression())
 	if mc == nil || err != nil {
 		return c, nil, err
 	}
@@ -370,6 +375,7 @@ func newSession(c context.Context, mi *rpc.NetworkConfig, mc connector.ManagerPr
 		virtualIPs:            xsync.NewMapOf[netip.Addr, agentVIP](),
 	}
 	cfg := client.GetConfig(c)
+	s.userspace = cfg.Cluster().UserspaceNetworking
 	rt := cfg.Routing()
 	var err error
 	s.alsoProxySubnets, err = validateSubnets("also-proxy", rt.AlsoProxy, s.alsoProxyVia)
@@ -483,6 +489,7 @@ func (s *Session) getNetworkConfig(ctx context.Context) *rpc.NetworkConfig {
 		curSubnets := s.tunVif.Router.GetRoutedSubnets()
 		r.Subnets = make([]netip.Prefix, len(curSubnets))
 		copy(r.Subnets, curSubnets)
+		r.MTU = int(s.tunVif.Device.MTU())
 	}
 	if len(s.neverProxySubnets) > 0 {
 		r.NeverProxy = make([]netip.Prefix, len(s.neverProxySubnets))
@@ -560,7 +567,7 @@ func (s *Session) networkReady(ctx context.Context) <-chan error {
 		case err, ok := <-s.vifReady:
 			if ok {
 				rdy <- err
-			} else {
+			} else if !s.userspace {
 				select {
 				case <-ctx.Done():
 				case <-s.dnsServer.Ready():
@@ -767,9 +774,9 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		dnsRouted = true
 	}
 
-	if len(subnets) > 0 && s.tunVif == nil {
+	if len(subnets) > 0 && s.tunVif == nil && !s.userspace {
 		var err error
-		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator()); err != nil {
+		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator(), s.tunMTU(ctx)); err != nil {
 			return fmt.Errorf("NewTunnelVIF: %w", err)
 		}
 	}
@@ -1017,7 +1024,7 @@ func (s *Session) run(c context.Context, initErrs chan error) error {
 func (s *Session) Start(c context.Context, g *dgroup.Group) error {
 	if rmc, ok := s.managerClient.(interface{ RealManagerClient() manager.ManagerClient }); ok {
 		clusterCfg := client.GetConfig(c).Cluster()
-		if clusterCfg.AgentPortForward && clusterCfg.ConnectFromRootDaemon {
+		if clusterCfg.AgentPortForward && clusterCfg.ConnectFromRootDaemon && !clusterCfg.ExternalManager() {
 			if k8sclient.CanPortForward(c, s.namespace) {
 				s.agentClients = agentpf.NewClients(s.session)
 				g.Go("agentPods", func(ctx context.Context) error {
@@ -1070,6 +1077,12 @@ func (s *Session) Start(c context.Context, g *dgroup.Group) error {
 		}
 	}
 
+	if s.userspace {
+		// No TUN-device and no DNS configuration. Clients must use the SOCKS5 proxy.
+		g.Go("socks", s.serveSOCKS)
+		return nil
+	}
+
 	// Start the router and the DNS Service and wait for the context
 	// to be done. Then shut things down in order. The following happens:
 	// 1. The DNS worker terminates (it needs the TUN device to be alive while doing that)
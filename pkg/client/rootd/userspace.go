@@ -0,0 +1,86 @@
+package rootd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	dns2 "github.com/miekg/dns"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/socks"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// serveSOCKS runs the SOCKS5 proxy that replaces the TUN-device when the session uses user-space networking.
+// Host names sent by the SOCKS clients are resolved in the cluster, so the system DNS is never modified. This
+// isn't a user-space network stack. Only the TCP connections that the SOCKS clients ask for are proxied.
+func (s *Session) serveSOCKS(ctx context.Context) error {
+	defer s.stop(ctx)
+	addr := client.GetConfig(ctx).Cluster().SOCKSProxyAddress
+	l, err := socks.Listen(ctx, addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen to SOCKS5 proxy address %s: %w", addr, err)
+	}
+	dlog.Warnf(ctx, "Using user-space networking. Performance is reduced and only clients using the SOCKS5 proxy at %s can reach the cluster", l.Addr())
+	srv := socks.Server{
+		Resolve: s.socksResolve,
+		Connect: s.socksConnect,
+	}
+	return srv.Serve(ctx, l)
+}
+
+// socksResolve resolves the given name using the cluster's DNS. Single label names are qualified
+// with the connected namespace.
+func (s *Session) socksResolve(ctx context.Context, name string) (netip.Addr, error) {
+	name = strings.TrimSuffix(name, ".")
+	var names []string
+	if !strings.ContainsRune(name, '.') {
+		names = append(names, name+"."+s.namespace)
+	}
+	names = append(names, name)
+	for _, n := range names {
+		for _, qType := range []uint16{dns2.TypeA, dns2.TypeAAAA} {
+			rrs, rCode, err := s.clusterLookup(ctx, &dns2.Question{Name: n + ".", Qtype: qType, Qclass: dns2.ClassINET})
+			if err != nil {
+				return netip.Addr{}, err
+			}
+			if rCode != dns2.RcodeSuccess {
+				continue
+			}
+			for _, rr := range rrs {
+				switch rr := rr.(type) {
+				case *dns2.A:
+					if a, ok := netip.AddrFromSlice(rr.A); ok {
+						return a.Unmap(), nil
+					}
+				case *dns2.AAAA:
+					if a, ok := netip.AddrFromSlice(rr.AAAA); ok {
+						return a, nil
+					}
+				}
+			}
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no such host %q", name)
+}
+
+// socksConnect creates a tunnel stream to the given destination, using the same stream creator as the TUN-device.
+func (s *Session) socksConnect(ctx context.Context, src, dst netip.AddrPort) (socks.Relay, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	id := tunnel.NewConnID(ipproto.TCP, src.Addr().AsSlice(), dst.Addr().AsSlice(), src.Port(), dst.Port())
+	stream, err := s.streamCreator()(ctx, id)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return func(_ context.Context, conn net.Conn) {
+		ep := tunnel.NewConnEndpoint(stream, conn, cancel, nil, nil)
+		ep.Start(ctx)
+		<-ep.Done()
+	}, nil
+}
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// InterceptOptions control how Intercept creates an intercept.
+type InterceptOptions struct {
+	// Workload is the name of the workload to intercept. Defaults to the name of the intercept.
+	Workload string
+
+	// Port is the local port that intercepted traffic is sent to, optionally followed by a colon and the name or
+	// number of the service port to intercept, i.e. <local port>[:<svcPortIdentifier>]. Required.
+	Port string
+
+	// Address is the local address that intercepted traffic is sent to. Defaults to 127.0.0.1.
+	Address string
+
+	// Service is the name of the service to intercept. Needed when the workload is exposed by several services.
+	Service string
+
+	// Container is the name of the container that provides the environment and mounts of the intercept.
+	Container string
+
+	// MountPoint is the local directory where the remote volumes are mounted. No volumes are mounted when empty.
+	MountPoint string
+
+	// Replace makes the traffic-agent replace the intercepted container instead of running alongside it.
+	Replace bool
+}
+
+// Intercept creates an intercept with the given name, and returns it when it's active. The intercept remains until
+// it's removed using Leave, or until the connection ends.
+func (c *Client) Intercept(ctx context.Context, name string, opts *InterceptOptions) (*manager.InterceptInfo, error) {
+	if opts == nil {
+		opts = &InterceptOptions{}
+	}
+	ir, err := opts.request(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.ud.CreateIntercept(ctx, ir)
+	if err = intercept.Result(r, err); err != nil {
+		return nil, err
+	}
+	return r.InterceptInfo, nil
+}
+
+// request returns the request that creates an intercept with the given name.
+func (o *InterceptOptions) request(name string) (*connector.CreateInterceptRequest, error) {
+	if name == "" {
+		return nil, errcat.User.New("the intercept must have a name")
+	}
+	spec := &manager.InterceptSpec{
+		Name:          name,
+		Agent:         o.Workload,
+		ServiceName:   o.Service,
+		ContainerName: o.Container,
+		Mechanism:     "tcp",
+		TargetHost:    "127.0.0.1",
+		Replace:       o.Replace,
+	}
+	if spec.Agent == "" {
+		spec.Agent = name
+	}
+
+	pp, svcPortID, ok := strings.Cut(o.Port, ":")
+	local, err := agentconfig.ParseNumericPort(pp)
+	if err == nil && ok {
+		err = agentconfig.ValidatePort(svcPortID)
+	}
+	if err != nil {
+		return nil, errcat.User.Newf("port %q must be of the format <local port>[:<svcPortIdentifier>]", o.Port)
+	}
+	spec.TargetPort = int32(local)
+	spec.PortIdentifier = svcPortID
+
+	if o.Address != "" {
+		if iputil.Parse(o.Address) == nil {
+			return nil, errcat.User.Newf("address %s is not a valid IP address", o.Address)
+		}
+		spec.TargetHost = o.Address
+	}
+
+	ir := &connector.CreateInterceptRequest{Spec: spec}
+	if o.MountPoint != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if ir.MountPoint, err = intercept.PrepareMount(cwd, o.MountPoint); err != nil {
+			return nil, fmt.Errorf("unable to prepare mount point: %w", err)
+		}
+	}
+	return ir, nil
+}
+
+// Leave removes the intercept with the given name.
+func (c *Client) Leave(ctx context.Context, name string) error {
+	r, err := c.ud.RemoveIntercept(ctx, &manager.RemoveInterceptRequest2{Name: name})
+	return intercept.Result(r, err)
+}
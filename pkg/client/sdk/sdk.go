@@ -0,0 +1,222 @@
+// Package sdk is a Go API for programmatic control of Telepresence. It talks to the user daemon using the same gRPC
+// API as the telepresence CLI, so that IDE plugins and other tools can connect, intercept, and list workloads without
+// running the CLI and parsing its output.
+//
+// The daemons are launched using the telepresence executable when they aren't already running, so the executable
+// must be installed. Results are returned as the message types of the connector gRPC API.
+package sdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"os/exec"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// ConnectOptions control how Connect establishes the connection to the cluster. The zero value connects using
+// the current context and namespace of the kubeconfig.
+type ConnectOptions struct {
+	// Executable is the telepresence executable that is used to launch the daemons. Defaults to the
+	// "telepresence" executable found in the PATH.
+	Executable string
+
+	// Context is the Kubernetes context to use. Defaults to the current context of the kubeconfig.
+	Context string
+
+	// Namespace is the namespace that intercepts are created in, and that is listed, by default.
+	Namespace string
+
+	// ManagerNamespace is the namespace of the traffic-manager.
+	ManagerNamespace string
+
+	// MappedNamespaces limits the namespaces that are made available to the workstation.
+	MappedNamespaces []string
+
+	// KubeFlags are additional Kubernetes flags, e.g. "kubeconfig" or "token", without leading dashes.
+	KubeFlags map[string]string
+
+	// Name is the name of the connection. Defaults to a name derived from the context and namespace.
+	Name string
+
+	// Docker makes the connection use a daemon that runs in a container.
+	Docker bool
+
+	// Isolated makes the connection use a separate daemon on the host, instead of the one that listens
+	// to the well-known socket.
+	Isolated bool
+
+	// Output receives the messages that the CLI would print, e.g. when a daemon is launched. Discarded if nil.
+	Output io.Writer
+}
+
+// Client controls one connection to a cluster.
+type Client struct {
+	ud   daemon.UserClient
+	info *connector.ConnectInfo
+}
+
+type executable string
+
+func (e executable) Executable() (string, error) {
+	return string(e), nil
+}
+
+// Connect ensures that the daemons are running, connects them to the cluster, and returns a Client that controls
+// the connection. An existing connection is reused when it matches the options.
+func Connect(ctx context.Context, opts *ConnectOptions) (*Client, error) {
+	if opts == nil {
+		opts = &ConnectOptions{}
+	}
+	ctx, err := opts.context(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx, err = connect.EnsureUserDaemon(ctx, true); err != nil {
+		return nil, err
+	}
+	ud := daemon.GetUserClient(ctx)
+	if ctx, err = connect.EnsureSession(ctx, "sdk", true); err != nil {
+		_ = ud.Close()
+		return nil, err
+	}
+	return &Client{ud: ud, info: daemon.GetSession(ctx).Info}, nil
+}
+
+// context returns a context that holds the config, the executable, and the connection request that the CLI
+// packages use to launch and connect the daemons.
+func (o *ConnectOptions) context(ctx context.Context) (context.Context, error) {
+	env, err := client.LoadEnv()
+	if err != nil {
+		return nil, err
+	}
+	ctx = client.WithEnv(ctx, env)
+	cfg, err := client.LoadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = client.WithConfig(ctx, cfg)
+
+	exe := o.Executable
+	if exe == "" {
+		if exe, err = exec.LookPath("telepresence"); err != nil {
+			return nil, errcat.User.Newf("unable to find the telepresence executable: %w", err)
+		}
+	}
+	ctx = dos.WithExe(ctx, executable(exe))
+
+	out := o.Output
+	if out == nil {
+		out = io.Discard
+	}
+	ctx = dos.WithStdout(ctx, out)
+	ctx = dos.WithStderr(ctx, out)
+
+	cr := daemon.NewDefaultRequest()
+	maps.Copy(cr.KubeFlags, o.KubeFlags)
+	if o.Context != "" {
+		cr.KubeFlags["context"] = o.Context
+	}
+	if o.Namespace != "" {
+		cr.KubeFlags["namespace"] = o.Namespace
+	}
+	cr.ManagerNamespace = o.ManagerNamespace
+	cr.MappedNamespaces = o.MappedNamespaces
+	cr.Name = o.Name
+	cr.Docker = o.Docker
+	cr.Isolated = o.Isolated
+	return daemon.WithRequest(ctx, cr), nil
+}
+
+// Info returns the information about the connection that was obtained when it was established.
+func (c *Client) Info() *connector.ConnectInfo {
+	return c.info
+}
+
+// Status returns the current status of the connection.
+func (c *Client) Status(ctx context.Context) (*connector.ConnectInfo, error) {
+	return c.ud.Status(ctx, &emptypb.Empty{})
+}
+
+// ListOptions control what List returns. The zero value lists all workloads in the connected namespace.
+type ListOptions struct {
+	// Namespace to list. Defaults to the connected namespace.
+	Namespace string
+
+	// Filter limits the workloads that are listed. Defaults to connector.ListRequest_EVERYTHING.
+	Filter connector.ListRequest_Filter
+}
+
+// List returns the workloads of a namespace, together with their intercepts and traffic-agents.
+func (c *Client) List(ctx context.Context, opts *ListOptions) ([]*connector.WorkloadInfo, error) {
+	rq := &connector.ListRequest{Filter: connector.ListRequest_EVERYTHING}
+	if opts != nil {
+		rq.Namespace = opts.Namespace
+		if opts.Filter != connector.ListRequest_UNSPECIFIED {
+			rq.Filter = opts.Filter
+		}
+	}
+	r, err := c.ud.List(ctx, rq)
+	if err != nil {
+		return nil, err
+	}
+	return r.Workloads, nil
+}
+
+// WatchEvents returns a channel that receives the events of the user daemon, i.e. session state changes, intercept
+// dispositions, mount health, and handler exits. The channel is closed when the context is cancelled or the
+// stream ends.
+func (c *Client) WatchEvents(ctx context.Context) (<-chan *connector.Event, error) {
+	stream, err := c.ud.WatchEvents(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *connector.Event)
+	go func() {
+		defer close(ch)
+		for {
+			ev, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Disconnect ends the connection to the cluster. All intercepts are removed. The daemons keep running.
+func (c *Client) Disconnect(ctx context.Context) error {
+	dr, err := c.ud.Disconnect(ctx, &emptypb.Empty{})
+	if err != nil {
+		if status.Code(err) == codes.Unavailable {
+			return nil
+		}
+		return err
+	}
+	errs := make([]error, len(dr.HandlerStopFailures))
+	for i, f := range dr.HandlerStopFailures {
+		errs[i] = errcat.NoDaemonLogs.Newf("intercept handler of %s failed to stop: %s", f.Intercept, f.Error)
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the gRPC connection to the user daemon. The connection to the cluster is not affected.
+func (c *Client) Close() error {
+	return c.ud.Close()
+}
@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+)
+
+func TestConnectOptions_context(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	opts := &ConnectOptions{
+		Executable:       "/usr/local/bin/telepresence",
+		Context:          "staging",
+		Namespace:        "orders",
+		ManagerNamespace: "ambassador",
+		KubeFlags:        map[string]string{"kubeconfig": "/tmp/kubeconfig", "namespace": "ignored"},
+		Isolated:         true,
+	}
+	ctx, err := opts.context(ctx)
+	require.NoError(t, err)
+
+	exe, err := dos.Executable(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/telepresence", exe)
+
+	cr := daemon.GetRequest(ctx)
+	require.NotNil(t, cr)
+	assert.Equal(t, "staging", cr.KubeFlags["context"])
+	assert.Equal(t, "orders", cr.KubeFlags["namespace"])
+	assert.Equal(t, "/tmp/kubeconfig", cr.KubeFlags["kubeconfig"])
+	assert.Equal(t, "ambassador", cr.ManagerNamespace)
+	assert.True(t, cr.Isolated)
+	assert.False(t, cr.Docker)
+}
+
+func TestInterceptOptions_request(t *testing.T) {
+	ir, err := (&InterceptOptions{Port: "8080:http", Service: "echo-svc"}).request("echo")
+	require.NoError(t, err)
+	spec := ir.Spec
+	assert.Equal(t, "echo", spec.Name)
+	assert.Equal(t, "echo", spec.Agent)
+	assert.Equal(t, "echo-svc", spec.ServiceName)
+	assert.Equal(t, "127.0.0.1", spec.TargetHost)
+	assert.Equal(t, int32(8080), spec.TargetPort)
+	assert.Equal(t, "http", spec.PortIdentifier)
+	assert.Empty(t, ir.MountPoint)
+
+	ir, err = (&InterceptOptions{Workload: "echo-v2", Port: "9090", Address: "10.0.0.2"}).request("echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo-v2", ir.Spec.Agent)
+	assert.Equal(t, "10.0.0.2", ir.Spec.TargetHost)
+	assert.Empty(t, ir.Spec.PortIdentifier)
+
+	_, err = (&InterceptOptions{Port: "http"}).request("echo")
+	assert.ErrorContains(t, err, "must be of the format")
+	_, err = (&InterceptOptions{Port: "8080", Address: "localhost"}).request("echo")
+	assert.ErrorContains(t, err, "not a valid IP address")
+	_, err = (&InterceptOptions{Port: "8080"}).request("")
+	assert.Error(t, err)
+}
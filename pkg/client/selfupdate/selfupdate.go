@@ -0,0 +1,191 @@
+// Package selfupdate finds Telepresence releases and replaces the running binary with the binary of a release.
+package selfupdate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// The release channels.
+const (
+	// ChannelStable is the channel of the most recent release that isn't a pre-release.
+	ChannelStable = "stable"
+
+	// ChannelLatest is the channel of the most recent release, including pre-releases.
+	ChannelLatest = "latest"
+)
+
+// ChecksumsAsset is the name of the release asset that contains the SHA-256 checksums of the other assets, in the
+// format produced by sha256sum.
+const ChecksumsAsset = "checksums.txt"
+
+// Release is a release of Telepresence.
+type Release struct {
+	Version semver.Version
+
+	// Assets are the download URLs of the release assets, keyed by asset name.
+	Assets map[string]string
+}
+
+// githubRelease is the subset of a release of the GitHub REST API that is needed here.
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// AssetName returns the name of the release asset that contains the binary for the given platform.
+func AssetName(goos, goarch string) string {
+	return fmt.Sprintf("telepresence-%s-%s", goos, goarch)
+}
+
+// LatestRelease returns the most recent release of the given channel. The releasesURL is the URL of the releases
+// of a GitHub repository in the GitHub REST API.
+func LatestRelease(ctx context.Context, releasesURL, channel string) (*Release, error) {
+	var url string
+	switch channel {
+	case ChannelStable:
+		url = releasesURL + "/latest"
+	case ChannelLatest:
+		url = releasesURL + "?per_page=20"
+	default:
+		return nil, fmt.Errorf("invalid release channel %q, must be %q or %q", channel, ChannelStable, ChannelLatest)
+	}
+	body, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var grs []*githubRelease
+	if channel == ChannelStable {
+		gr := &githubRelease{}
+		err = json.NewDecoder(body).Decode(gr)
+		grs = append(grs, gr)
+	} else {
+		err = json.NewDecoder(body).Decode(&grs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode releases from %s: %w", url, err)
+	}
+	for _, gr := range grs {
+		if gr.Draft || gr.Prerelease && channel == ChannelStable {
+			continue
+		}
+		v, err := semver.ParseTolerant(gr.TagName)
+		if err != nil {
+			continue
+		}
+		rel := &Release{Version: v, Assets: make(map[string]string, len(gr.Assets))}
+		for _, a := range gr.Assets {
+			rel.Assets[a.Name] = a.URL
+		}
+		return rel, nil
+	}
+	return nil, fmt.Errorf("no release found in channel %s", channel)
+}
+
+// Install downloads the named binary asset of the given release, verifies its SHA-256 checksum, and then
+// atomically replaces the file at the given path with it. The file at the path is left untouched when the
+// download or the verification fails.
+func Install(ctx context.Context, rel *Release, asset, path string) error {
+	url, ok := rel.Assets[asset]
+	if !ok {
+		return fmt.Errorf("release v%s has no binary for this platform (%s)", rel.Version, asset)
+	}
+	want, err := checksum(ctx, rel, asset)
+	if err != nil {
+		return err
+	}
+
+	// The temporary file must be in the same directory as the destination for the rename to be atomic.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	body, err := get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, h), body); err != nil {
+		return fmt.Errorf("unable to download %s: %w", url, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset, want, got)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o755)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checksum returns the hex encoded SHA-256 checksum of the named asset, as listed by the checksums asset of the
+// given release.
+func checksum(ctx context.Context, rel *Release, asset string) (string, error) {
+	url, ok := rel.Assets[ChecksumsAsset]
+	if !ok {
+		return "", fmt.Errorf("release v%s has no %s, so its binaries can't be verified", rel.Version, ChecksumsAsset)
+	}
+	body, err := get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	sc := bufio.NewScanner(body)
+	for sc.Scan() {
+		// Each line is "<checksum>  <name>", where the name is prefixed with '*' in binary mode.
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s of release v%s has no checksum for %s", ChecksumsAsset, rel.Version, asset)
+}
+
+func get(ctx context.Context, url string) (io.ReadCloser, error) {
+	rq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := http.DefaultClient.Do(rq)
+	if err != nil {
+		return nil, err
+	}
+	if rs.StatusCode != http.StatusOK {
+		rs.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, rs.Status)
+	}
+	return rs.Body, nil
+}
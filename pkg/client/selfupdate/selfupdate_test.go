@@ -0,0 +1,99 @@
+package selfupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func releaseServer(t *testing.T, binary, checksums string) *httptest.Server {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	release := func(tag string, prerelease bool) string {
+		return fmt.Sprintf(`{"tag_name":%q,"prerelease":%t,"assets":[
+{"name":"telepresence-linux-amd64","browser_download_url":"%s/download/%s/bin"},
+{"name":"checksums.txt","browser_download_url":"%s/download/%s/checksums.txt"}]}`, tag, prerelease, srv.URL, tag, srv.URL, tag)
+	}
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(release("v2.21.0", false)))
+	})
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[" + release("v2.22.0-rc.1", true) + "," + release("v2.21.0", false) + "]"))
+	})
+	mux.HandleFunc("/download/v2.21.0/bin", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(binary))
+	})
+	mux.HandleFunc("/download/v2.21.0/checksums.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(checksums))
+	})
+	return srv
+}
+
+func TestLatestRelease(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	srv := releaseServer(t, "", "")
+
+	rel, err := LatestRelease(ctx, srv.URL+"/releases", ChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("2.21.0"), rel.Version)
+	assert.Contains(t, rel.Assets, AssetName("linux", "amd64"))
+
+	rel, err = LatestRelease(ctx, srv.URL+"/releases", ChannelLatest)
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("2.22.0-rc.1"), rel.Version)
+
+	_, err = LatestRelease(ctx, srv.URL+"/releases", "nightly")
+	assert.ErrorContains(t, err, "invalid release channel")
+}
+
+func TestInstall(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	const binary = "new binary"
+	sum := sha256.Sum256([]byte(binary))
+	asset := AssetName("linux", "amd64")
+
+	install := func(t *testing.T, checksums string) (string, error) {
+		srv := releaseServer(t, binary, checksums)
+		rel, err := LatestRelease(ctx, srv.URL+"/releases", ChannelStable)
+		require.NoError(t, err)
+		exe := filepath.Join(t.TempDir(), "telepresence")
+		require.NoError(t, os.WriteFile(exe, []byte("old binary"), 0o755))
+		err = Install(ctx, rel, asset, exe)
+		data, rErr := os.ReadFile(exe)
+		require.NoError(t, rErr)
+		entries, rErr := os.ReadDir(filepath.Dir(exe))
+		require.NoError(t, rErr)
+		assert.Len(t, entries, 1, "temporary file was not removed")
+		return string(data), err
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		data, err := install(t, hex.EncodeToString(sum[:])+" *"+asset+"\n")
+		require.NoError(t, err)
+		assert.Equal(t, binary, data)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		data, err := install(t, hex.EncodeToString(make([]byte, 32))+"  "+asset+"\n")
+		assert.ErrorContains(t, err, "checksum mismatch")
+		assert.Equal(t, "old binary", data)
+	})
+
+	t.Run("no checksum", func(t *testing.T) {
+		data, err := install(t, hex.EncodeToString(sum[:])+"  telepresence-darwin-arm64\n")
+		assert.ErrorContains(t, err, "has no checksum for "+asset)
+		assert.Equal(t, "old binary", data)
+	})
+}
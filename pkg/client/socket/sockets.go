@@ -2,17 +2,22 @@ package socket
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
 )
 
 // UserDaemonPath is the path used when communicating to the user daemon process.
@@ -20,6 +25,14 @@ func UserDaemonPath(ctx context.Context) string {
 	return userDaemonPath(ctx)
 }
 
+// IsolatedUserDaemonPath is the path used when communicating to the isolated user daemon with the given name. The
+// socket is in a directory of the user's cache that only the user can access. The file name is a hash of the daemon
+// name, because names that are derived from a context name may exceed the max length of a socket path.
+func IsolatedUserDaemonPath(ctx context.Context, name string) string {
+	h := sha256.Sum256([]byte(name))
+	return filepath.Join(filelocation.AppUserCacheDir(ctx), "sockets", "isolated-"+hex.EncodeToString(h[:8])+".socket")
+}
+
 // RootDaemonPath is the path used when communicating to the root daemon process.
 func RootDaemonPath(ctx context.Context) string {
 	return rootDaemonPath(ctx)
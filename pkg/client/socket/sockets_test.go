@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,3 +81,12 @@ func TestDialSocket(t *testing.T) {
 		assert.Contains(t, err.Error(), "this usually means that the process is not running")
 	})
 }
+
+func TestIsolatedUserDaemonPath(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	p1 := socket.IsolatedUserDaemonPath(ctx, "ctx-ns1")
+	assert.Equal(t, p1, socket.IsolatedUserDaemonPath(ctx, "ctx-ns1"))
+	assert.NotEqual(t, p1, socket.IsolatedUserDaemonPath(ctx, "ctx-ns2"))
+	assert.NotEqual(t, socket.UserDaemonPath(ctx), p1)
+	assert.Less(t, len(filepath.Base(socket.IsolatedUserDaemonPath(ctx, strings.Repeat("x", 200)))), 40)
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+)
+
+// eventBufferSize is the number of events that are buffered for each WatchEvents subscriber. Events are dropped
+// for subscribers that fall this far behind.
+const eventBufferSize = 64
+
+// eventHub distributes published events to the current WatchEvents subscribers.
+type eventHub struct {
+	sync.Mutex
+	subscribers map[uuid.UUID]chan *rpc.Event
+}
+
+func (h *eventHub) subscribe() (uuid.UUID, <-chan *rpc.Event) {
+	id := uuid.New()
+	ch := make(chan *rpc.Event, eventBufferSize)
+	h.Lock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[uuid.UUID]chan *rpc.Event)
+	}
+	h.subscribers[id] = ch
+	h.Unlock()
+	return id, ch
+}
+
+func (h *eventHub) unsubscribe(id uuid.UUID) {
+	h.Lock()
+	delete(h.subscribers, id)
+	h.Unlock()
+}
+
+// publish sends the given event to all subscribers without blocking. The event is dropped for subscribers that
+// have fallen behind.
+func (h *eventHub) publish(ev *rpc.Event) {
+	h.Lock()
+	defer h.Unlock()
+	for _, ch := range h.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// PublishEvent sends the given event to the WatchEvents subscribers. The time of the event is set unless it's
+// already set.
+func (s *service) PublishEvent(ev *rpc.Event) {
+	if ev.Time == nil {
+		ev.Time = timestamppb.Now()
+	}
+	s.events.publish(ev)
+}
+
+func (s *service) WatchEvents(_ *emptypb.Empty, stream rpc.Connector_WatchEventsServer) error {
+	ctx := stream.Context()
+	id, ch := s.events.subscribe()
+	defer s.events.unsubscribe(id)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-ch:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+)
+
+func Test_eventHub(t *testing.T) {
+	var h eventHub
+	id1, ch1 := h.subscribe()
+	_, ch2 := h.subscribe()
+
+	h.publish(&rpc.Event{Type: rpc.Event_SESSION_CONNECTED})
+	for _, ch := range []<-chan *rpc.Event{ch1, ch2} {
+		require.Len(t, ch, 1)
+		assert.Equal(t, rpc.Event_SESSION_CONNECTED, (<-ch).Type)
+	}
+
+	// Unsubscribed subscribers get no more events.
+	h.unsubscribe(id1)
+	h.publish(&rpc.Event{Type: rpc.Event_SESSION_DISCONNECTED})
+	assert.Empty(t, ch1)
+	require.Len(t, ch2, 1)
+	<-ch2
+
+	// Publishing never blocks. Events are dropped for subscribers that have fallen behind.
+	for i := 0; i < eventBufferSize+10; i++ {
+		h.publish(&rpc.Event{Type: rpc.Event_HANDLER_EXITED, ExitCode: int32(i)})
+	}
+	require.Len(t, ch2, eventBufferSize)
+	assert.Equal(t, int32(0), (<-ch2).ExitCode)
+}
+
+func Test_PublishEvent(t *testing.T) {
+	s := &service{}
+	_, ch := s.events.subscribe()
+	s.PublishEvent(&rpc.Event{Type: rpc.Event_MOUNT_READY, MountPoint: "/tmp/mnt"})
+	ev := <-ch
+	assert.Equal(t, "/tmp/mnt", ev.MountPoint)
+	assert.NotNil(t, ev.Time)
+}
@@ -0,0 +1,314 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+// GatewayTokenFile is the name of the file in the user cache directory that holds the bearer token that
+// clients of the gateway must present. A new token is written each time the gateway starts.
+const GatewayTokenFile = "gateway-token"
+
+// gateway is an HTTP+JSON gateway to the connector gRPC API, for editors and scripts that have no gRPC tooling.
+// Request and response bodies are the protojson encodings of the messages of the API.
+type gateway struct {
+	cc    rpc.ConnectorClient
+	token string
+}
+
+// serveGateway serves the gateway on the given localhost port until the context is cancelled. The gateway makes
+// its calls using the given connection to the connector's own gRPC server, so that they are handled exactly like
+// the calls of the CLI. A gateway that can't be served is logged as an error but doesn't affect the daemon.
+func serveGateway(ctx context.Context, port int, conn *grpc.ClientConn) error {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		dlog.Errorf(ctx, "unable to serve the gateway: %v", err)
+		return nil
+	}
+	defer ln.Close()
+	token, err := writeGatewayToken(ctx)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to serve the gateway: %v", err)
+		return nil
+	}
+	defer func() {
+		_ = os.Remove(filepath.Join(filelocation.AppUserCacheDir(ctx), GatewayTokenFile))
+	}()
+	gw := &gateway{cc: rpc.NewConnectorClient(conn), token: token}
+	server := &dhttp.ServerConfig{Handler: gw.handler(ctx)}
+	info := fmt.Sprintf("Gateway on http://%s", ln.Addr())
+	dlog.Infof(ctx, "%s started", info)
+	defer dlog.Infof(ctx, "%s ended", info)
+	if err = server.Serve(ctx, ln); err != nil && err != ctx.Err() {
+		dlog.Errorf(ctx, "%s stopped: %v", info, err)
+	}
+	return nil
+}
+
+func (gw *gateway) handler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/version", func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := gw.cc.Version(r.Context(), &emptypb.Empty{})
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := gw.cc.Status(r.Context(), &emptypb.Empty{})
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("POST /v1/connect", func(w http.ResponseWriter, r *http.Request) {
+		var cr rpc.ConnectRequest
+		if readMessage(w, r, &cr) {
+			rsp, err := gw.cc.Connect(r.Context(), &cr)
+			writeResult(ctx, w, rsp, err)
+		}
+	})
+	mux.HandleFunc("POST /v1/disconnect", func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := gw.cc.Disconnect(r.Context(), &emptypb.Empty{})
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("GET /v1/workloads", func(w http.ResponseWriter, r *http.Request) {
+		lr := &rpc.ListRequest{Namespace: r.URL.Query().Get("namespace"), Filter: rpc.ListRequest_EVERYTHING}
+		if f := r.URL.Query().Get("filter"); f != "" {
+			v, ok := rpc.ListRequest_Filter_value[f]
+			if !ok {
+				http.Error(w, fmt.Sprintf("invalid filter %q", f), http.StatusBadRequest)
+				return
+			}
+			lr.Filter = rpc.ListRequest_Filter(v)
+		}
+		rsp, err := gw.cc.List(r.Context(), lr)
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("POST /v1/intercepts", func(w http.ResponseWriter, r *http.Request) {
+		var ir rpc.CreateInterceptRequest
+		if readMessage(w, r, &ir) {
+			rsp, err := gw.cc.CreateIntercept(r.Context(), &ir)
+			writeResult(ctx, w, rsp, err)
+		}
+	})
+	mux.HandleFunc("GET /v1/intercepts/{name}", func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := gw.cc.GetIntercept(r.Context(), &manager.GetInterceptRequest{Name: r.PathValue("name")})
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("DELETE /v1/intercepts/{name}", func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := gw.cc.RemoveIntercept(r.Context(), &manager.RemoveInterceptRequest2{Name: r.PathValue("name")})
+		writeResult(ctx, w, rsp, err)
+	})
+	mux.HandleFunc("GET /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		gw.streamWorkloads(ctx, w, r)
+	})
+	mux.HandleFunc("GET /v1/daemon-events", func(w http.ResponseWriter, r *http.Request) {
+		gw.streamEvents(ctx, w, r)
+	})
+
+	// Only accept requests that are addressed to localhost, so that a web page can't use DNS rebinding to
+	// drive the daemon, and that don't come from a web page of another origin. A web page could otherwise
+	// send a "simple" cross-origin POST without a preflight. Requests must also present the token of the
+	// daemon, and requests with a body must declare it as JSON.
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLocalHost(r.Host) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isLocalHost(u.Host) {
+				http.Error(w, "cross-origin requests are forbidden", http.StatusForbidden)
+				return
+			}
+		}
+		if !gw.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+				http.Error(w, "the Content-Type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized returns true if the request presents the token of the gateway as a bearer token.
+func (gw *gateway) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || auth[:len(prefix)] != prefix {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(gw.token)) == 1
+}
+
+// isLocalHost returns true if the given host, with an optional port, is a name or address of localhost.
+func isLocalHost(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1", "[::1]":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeGatewayToken generates a new random token and writes it to the GatewayTokenFile, which only the
+// user can read.
+func writeGatewayToken(ctx context.Context) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	dir := filelocation.AppUserCacheDir(ctx)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	// Remove any previous file, because WriteFile doesn't change the permissions of an existing file.
+	path := filepath.Join(dir, GatewayTokenFile)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// streamWorkloads writes the workload snapshots of the namespaces given by the namespace query parameters as
+// server-sent events, until the request is cancelled or the session ends.
+func (gw *gateway) streamWorkloads(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	stream, err := gw.cc.WatchWorkloads(r.Context(), &rpc.WatchWorkloadsRequest{Namespaces: r.URL.Query()["namespace"]})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeEvents(ctx, w, stream.Recv, func(*rpc.WorkloadInfoSnapshot) string { return "workloads" })
+}
+
+// streamEvents writes the events of the daemon as server-sent events named after the event type, until the
+// request is cancelled.
+func (gw *gateway) streamEvents(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	stream, err := gw.cc.WatchEvents(r.Context(), &emptypb.Empty{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeEvents(ctx, w, stream.Recv, func(ev *rpc.Event) string { return ev.Type.String() })
+}
+
+// writeEvents writes the messages received from a gRPC stream as server-sent events until the stream ends.
+func writeEvents[T proto.Message](ctx context.Context, w http.ResponseWriter, recv func() (T, error), name func(T) string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		m, err := recv()
+		if err != nil {
+			if err != io.EOF && status.Code(err) != codes.Canceled {
+				dlog.Debugf(ctx, "gateway event stream ended: %v", err)
+			}
+			return
+		}
+		data, err := protojson.Marshal(m)
+		if err != nil {
+			dlog.Errorf(ctx, "unable to encode gateway event: %v", err)
+			return
+		}
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name(m), data); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// readMessage decodes the protojson body of the request into the given message. An error response is written,
+// and false is returned, when that fails.
+func readMessage(w http.ResponseWriter, r *http.Request, m proto.Message) bool {
+	data, err := io.ReadAll(r.Body)
+	if err == nil && len(data) > 0 {
+		err = protojson.Unmarshal(data, m)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeResult writes the result of a gRPC call as the response.
+func writeResult(ctx context.Context, w http.ResponseWriter, m proto.Message, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to encode gateway response: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// writeError writes the given gRPC error as a response with a corresponding HTTP status.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	var code int
+	switch st.Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		code = http.StatusConflict
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.Unimplemented:
+		code = http.StatusNotImplemented
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	default:
+		code = http.StatusInternalServerError
+	}
+	http.Error(w, st.Message(), code)
+}
@@ -0,0 +1,199 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+)
+
+type fakeConnectorClient struct {
+	rpc.ConnectorClient
+	listRequest      *rpc.ListRequest
+	interceptRequest *rpc.CreateInterceptRequest
+}
+
+func (f *fakeConnectorClient) Status(context.Context, *emptypb.Empty, ...grpc.CallOption) (*rpc.ConnectInfo, error) {
+	return &rpc.ConnectInfo{Error: rpc.ConnectInfo_ALREADY_CONNECTED, Namespace: "default"}, nil
+}
+
+func (f *fakeConnectorClient) List(_ context.Context, lr *rpc.ListRequest, _ ...grpc.CallOption) (*rpc.WorkloadInfoSnapshot, error) {
+	f.listRequest = lr
+	return &rpc.WorkloadInfoSnapshot{Workloads: []*rpc.WorkloadInfo{{Name: "echo", WorkloadResourceType: "Deployment"}}}, nil
+}
+
+func (f *fakeConnectorClient) CreateIntercept(_ context.Context, ir *rpc.CreateInterceptRequest, _ ...grpc.CallOption) (*rpc.InterceptResult, error) {
+	f.interceptRequest = ir
+	return &rpc.InterceptResult{InterceptInfo: &manager.InterceptInfo{Id: "id-1", Spec: ir.Spec}}, nil
+}
+
+func (f *fakeConnectorClient) GetIntercept(_ context.Context, rq *manager.GetInterceptRequest, _ ...grpc.CallOption) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.NotFound, "intercept %s not found", rq.Name)
+}
+
+type fakeEventsClient struct {
+	grpc.ClientStream
+	events []*rpc.Event
+}
+
+func (f *fakeEventsClient) Recv() (*rpc.Event, error) {
+	if len(f.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := f.events[0]
+	f.events = f.events[1:]
+	return ev, nil
+}
+
+func (f *fakeConnectorClient) WatchEvents(context.Context, *emptypb.Empty, ...grpc.CallOption) (rpc.Connector_WatchEventsClient, error) {
+	return &fakeEventsClient{events: []*rpc.Event{
+		{Type: rpc.Event_HANDLER_STARTED, InterceptId: "id-1", Pid: 42},
+		{Type: rpc.Event_HANDLER_EXITED, InterceptId: "id-1", ExitCode: 3},
+	}}, nil
+}
+
+func Test_gateway(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	fc := &fakeConnectorClient{}
+	handler := (&gateway{cc: fc, token: "secret"}).handler(ctx)
+
+	send := func(method, url, body string, header map[string]string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest(method, url, strings.NewReader(body))
+		for k, v := range header {
+			rq.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, rq)
+		return rec
+	}
+	call := func(method, url, body string) *httptest.ResponseRecorder {
+		return send(method, url, body, map[string]string{"Authorization": "Bearer secret", "Content-Type": "application/json"})
+	}
+
+	rec := call(http.MethodGet, "http://localhost:8282/v1/status", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var ci rpc.ConnectInfo
+	require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &ci))
+	assert.Equal(t, rpc.ConnectInfo_ALREADY_CONNECTED, ci.Error)
+	assert.Equal(t, "default", ci.Namespace)
+
+	rec = call(http.MethodGet, "http://localhost:8282/v1/workloads?namespace=staging&filter=INTERCEPTABLE", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "staging", fc.listRequest.Namespace)
+	assert.Equal(t, rpc.ListRequest_INTERCEPTABLE, fc.listRequest.Filter)
+	var ws rpc.WorkloadInfoSnapshot
+	require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &ws))
+	require.Len(t, ws.Workloads, 1)
+	assert.Equal(t, "echo", ws.Workloads[0].Name)
+
+	rec = call(http.MethodGet, "http://localhost:8282/v1/workloads?filter=SOME", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = call(http.MethodPost, "http://127.0.0.1:8282/v1/intercepts",
+		`{"spec": {"name": "echo", "agent": "echo", "targetHost": "127.0.0.1", "targetPort": 8080}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int32(8080), fc.interceptRequest.Spec.TargetPort)
+	var ir rpc.InterceptResult
+	require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &ir))
+	assert.Equal(t, "id-1", ir.InterceptInfo.Id)
+
+	rec = call(http.MethodPost, "http://localhost:8282/v1/intercepts", `{"spec": 1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = call(http.MethodGet, "http://localhost:8282/v1/intercepts/echo", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "intercept echo not found")
+
+	rec = call(http.MethodGet, "http://localhost:8282/v1/daemon-events", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	require.Len(t, events, 2)
+	assert.True(t, strings.HasPrefix(events[0], "event: HANDLER_STARTED\ndata: "))
+	assert.True(t, strings.HasPrefix(events[1], "event: HANDLER_EXITED\ndata: "))
+	var ev rpc.Event
+	require.NoError(t, protojson.Unmarshal([]byte(strings.TrimPrefix(events[1], "event: HANDLER_EXITED\ndata: ")), &ev))
+	assert.Equal(t, int32(3), ev.ExitCode)
+
+	// Requests that aren't addressed to localhost are rejected.
+	rec = call(http.MethodGet, "http://attacker.example.com:8282/v1/status", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// A web page on another site can't drive the daemon, even when it knows the token.
+	fc.interceptRequest = nil
+	body := `{"spec": {"name": "echo", "agent": "echo"}}`
+	rec = send(http.MethodPost, "http://localhost:8282/v1/intercepts", body, map[string]string{
+		"Origin":        "https://attacker.example.com",
+		"Content-Type":  "text/plain",
+		"Authorization": "Bearer secret",
+	})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = send(http.MethodGet, "http://localhost:8282/v1/status", "", map[string]string{
+		"Origin":        "http://localhost.attacker.example.com",
+		"Authorization": "Bearer secret",
+	})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = send(http.MethodGet, "http://localhost:8282/v1/status", "", map[string]string{
+		"Origin":        "http://localhost:3000",
+		"Authorization": "Bearer secret",
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The token is required.
+	rec = send(http.MethodGet, "http://localhost:8282/v1/status", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = send(http.MethodGet, "http://localhost:8282/v1/status", "", map[string]string{"Authorization": "Bearer wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// A body that isn't declared as JSON is rejected.
+	rec = send(http.MethodPost, "http://localhost:8282/v1/intercepts", body, map[string]string{
+		"Content-Type":  "text/plain",
+		"Authorization": "Bearer secret",
+	})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	rec = send(http.MethodPost, "http://localhost:8282/v1/disconnect", "", map[string]string{"Authorization": "Bearer secret"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Nil(t, fc.interceptRequest)
+}
+
+func Test_writeGatewayToken(t *testing.T) {
+	dir := t.TempDir()
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), dir)
+	path := filepath.Join(dir, GatewayTokenFile)
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+
+	token, err := writeGatewayToken(ctx)
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, token, string(data))
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+
+	next, err := writeGatewayToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, next)
+}
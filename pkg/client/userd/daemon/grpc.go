@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/netip"
+	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -26,7 +30,9 @@ import (
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
@@ -132,15 +138,16 @@ func (s *service) Connect(ctx context.Context, cr *rpc.ConnectRequest) (result *
 	return result, err
 }
 
-func (s *service) Disconnect(ctx context.Context, ex *empty.Empty) (*empty.Empty, error) {
+func (s *service) Disconnect(ctx context.Context, ex *empty.Empty) (*rpc.DisconnectResult, error) {
+	result := &rpc.DisconnectResult{}
 	s.LogCall(ctx, "Disconnect", func(ctx context.Context) {
-		s.cancelSession()
+		result.HandlerStopFailures = s.cancelSession()
 		_ = s.withRootDaemon(ctx, func(ctx context.Context, rd daemon.DaemonClient) error {
 			_, err := rd.Disconnect(ctx, ex)
 			return err
 		})
 	})
-	return &empty.Empty{}, nil
+	return result, nil
 }
 
 func (s *service) Status(ctx context.Context, ex *empty.Empty) (result *rpc.ConnectInfo, err error) {
@@ -266,7 +273,17 @@ func (s *service) CreateIntercept(c context.Context, ir *rpc.CreateInterceptRequ
 	err = s.WithSession(c, "CreateIntercept", func(c context.Context, session userd.Session) error {
 		span := trace.SpanFromContext(c)
 		tracing.RecordInterceptSpec(span, ir.Spec)
+		if ir.PrepareMountPoint {
+			var err error
+			if ir.MountPoint, err = prepareMountPoint(ir.MountPoint); err != nil {
+				return fmt.Errorf("unable to prepare mount point: %w", err)
+			}
+		}
 		result = session.AddIntercept(c, ir)
+		if ir.PrepareMountPoint && (result == nil || result.Error != common.InterceptError_UNSPECIFIED) {
+			// remove if empty
+			_ = os.Remove(ir.MountPoint)
+		}
 		if result != nil && result.InterceptInfo != nil {
 			tracing.RecordInterceptInfo(span, result.InterceptInfo)
 		}
@@ -297,7 +314,11 @@ func (s *service) RemoveIntercept(c context.Context, rr *manager.RemoveIntercept
 			result.WorkloadKind = spec.WorkloadKind
 		}
 		if err := session.RemoveIntercept(c, rr.Name); err != nil {
-			if status.Code(err) == codes.NotFound {
+			var hse *userd.HandlerStopError
+			if errors.As(err, &hse) {
+				// The intercept was removed.
+				result.HandlerStopError = hse.Err.Error()
+			} else if status.Code(err) == codes.NotFound {
 				result.Error = common.InterceptError_NOT_FOUND
 				result.ErrorText = rr.Name
 				result.ErrorCategory = int32(errcat.User)
@@ -322,20 +343,52 @@ func (s *service) UpdateIntercept(c context.Context, rr *manager.UpdateIntercept
 }
 
 func (s *service) AddInterceptor(ctx context.Context, interceptor *rpc.Interceptor) (*empty.Empty, error) {
-	return &empty.Empty{}, s.WithSession(ctx, "AddInterceptor", func(_ context.Context, session userd.Session) error {
-		return session.AddInterceptor(interceptor.InterceptId, interceptor)
+	return &empty.Empty{}, s.WithSession(ctx, "AddInterceptor", func(ctx context.Context, session userd.Session) error {
+		if interceptor.Restarts > 0 {
+			dlog.Infof(ctx, "interceptor for intercept %s restarted with pid %d (restart %d)",
+				interceptor.InterceptId, interceptor.Pid, interceptor.Restarts)
+		}
+		if err := session.AddInterceptor(interceptor.InterceptId, interceptor); err != nil {
+			return err
+		}
+		s.self.PublishEvent(&rpc.Event{
+			Type:          rpc.Event_HANDLER_STARTED,
+			InterceptId:   interceptor.InterceptId,
+			Pid:           interceptor.Pid,
+			ContainerName: interceptor.ContainerName,
+			Restarts:      interceptor.Restarts,
+		})
+		return nil
+	})
+}
+
+func (s *service) IsInterceptorAttached(ctx context.Context, interceptor *rpc.Interceptor) (result *rpc.InterceptorAttachedResult, err error) {
+	err = s.WithSession(ctx, "IsInterceptorAttached", func(_ context.Context, session userd.Session) error {
+		result = &rpc.InterceptorAttachedResult{Attached: session.IsInterceptorAttached(interceptor)}
+		return nil
 	})
+	return result, err
 }
 
 func (s *service) RemoveInterceptor(ctx context.Context, interceptor *rpc.Interceptor) (*empty.Empty, error) {
 	return &empty.Empty{}, s.WithSession(ctx, "RemoveInterceptor", func(_ context.Context, session userd.Session) error {
-		return session.RemoveInterceptor(interceptor.InterceptId)
+		if err := session.RemoveInterceptor(interceptor.InterceptId); err != nil {
+			return err
+		}
+		s.self.PublishEvent(&rpc.Event{
+			Type:          rpc.Event_HANDLER_EXITED,
+			InterceptId:   interceptor.InterceptId,
+			Pid:           interceptor.Pid,
+			ContainerName: interceptor.ContainerName,
+			ExitCode:      interceptor.ExitCode,
+		})
+		return nil
 	})
 }
 
 func (s *service) List(c context.Context, lr *rpc.ListRequest) (result *rpc.WorkloadInfoSnapshot, err error) {
 	err = s.WithSession(c, "List", func(c context.Context, session userd.Session) error {
-		result, err = session.WorkloadInfoSnapshot(c, []string{lr.Namespace}, lr.Filter)
+		result, err = session.WorkloadInfoSnapshot(c, []string{lr.Namespace}, lr.Filter, lr.IncludeOthers)
 		return err
 	})
 	return
@@ -375,6 +428,291 @@ func (s *service) WatchWorkloads(wr *rpc.WatchWorkloadsRequest, stream rpc.Conne
 	return session.WatchWorkloads(sessionCtx, wr, stream)
 }
 
+func (s *service) FetchFiles(fr *rpc.FetchFilesRequest, stream rpc.Connector_FetchFilesServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+
+	err := s.WithSession(stream.Context(), "FetchFiles", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The fetch ends when either the session or the caller's stream ends.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	return session.FetchFiles(ctx, fr, stream)
+}
+
+func (s *service) PutFiles(stream rpc.Connector_PutFilesServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+
+	err := s.WithSession(stream.Context(), "PutFiles", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The upload ends when either the session or the caller's stream ends.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	result, err := session.PutFiles(ctx, stream)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(result)
+}
+
+func (s *service) Wiretap(rq *rpc.WiretapRequest, stream rpc.Connector_WiretapServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+
+	err := s.WithSession(stream.Context(), "Wiretap", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The wiretap ends when either the session or the caller's stream ends.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	return session.Wiretap(ctx, rq, stream)
+}
+
+func (s *service) Capture(rq *rpc.CaptureRequest, stream rpc.Connector_CaptureServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+
+	err := s.WithSession(stream.Context(), "Capture", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The capture ends when either the session or the caller's stream ends.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	return session.Capture(ctx, rq, stream)
+}
+
+func (s *service) Mount(rq *rpc.MountRequest, stream rpc.Connector_MountServer) error {
+	var sessionCtx context.Context
+	var session userd.Session
+
+	err := s.WithSession(stream.Context(), "Mount", func(c context.Context, s userd.Session) error {
+		session, sessionCtx = s, c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The volumes remain mounted until either the session or the caller's stream ends.
+	ctx, cancel := context.WithCancel(sessionCtx)
+	defer cancel()
+	stop := context.AfterFunc(stream.Context(), cancel)
+	defer stop()
+	return session.Mount(ctx, rq, stream)
+}
+
+func (s *service) VerifyHeaderPropagation(c context.Context, rq *manager.HeaderPropagationRequest) (result *manager.HeaderPropagationResult, err error) {
+	err = s.WithSession(c, "VerifyHeaderPropagation", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.HeaderPropagation, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().VerifyHeaderPropagation(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) DescribeWorkload(c context.Context, rq *manager.DescribeWorkloadRequest) (result *manager.WorkloadDescription, err error) {
+	err = s.WithSession(c, "DescribeWorkload", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.WorkloadDescribe, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		ns := session.ActualNamespace(rq.Namespace)
+		if ns == "" {
+			return status.Errorf(codes.InvalidArgument, "namespace %s is not accessible", rq.Namespace)
+		}
+		rq.Namespace = ns
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().DescribeWorkload(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) ExposeRegistry(c context.Context, rq *manager.RegistryProxyRequest) (result *manager.RegistryProxyInfo, err error) {
+	err = s.WithSession(c, "ExposeRegistry", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.RegistryProxy, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		if rq.Address != "" {
+			// The registry is dialed from this daemon, so the address must be resolved here.
+			if rq.Address, err = resolveRegistryAddress(c, rq.Address); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().ExposeRegistry(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) PublishService(c context.Context, rq *manager.PublishServiceRequest) (result *manager.PublishedServiceInfo, err error) {
+	err = s.WithSession(c, "PublishService", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.PublishedServices, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		rq.Session = session.SessionInfo()
+		if rq.BearerToken, err = k8sclient.BearerToken(c, session.GetRestConfig()); err != nil {
+			if errors.Is(err, k8sclient.ErrNoBearerToken) {
+				err = status.Error(codes.FailedPrecondition, "publishing a service requires a kubeconfig that authenticates using a bearer token")
+			}
+			return err
+		}
+		result, err = session.ManagerClient().PublishService(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) UnpublishService(c context.Context, rq *manager.PublishServiceRequest) (result *empty.Empty, err error) {
+	err = s.WithSession(c, "UnpublishService", func(c context.Context, session userd.Session) error {
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().UnpublishService(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) ShareIntercept(c context.Context, rq *manager.GetInterceptRequest) (result *manager.InterceptShareToken, err error) {
+	err = s.WithSession(c, "ShareIntercept", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.InterceptSharing, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().ShareIntercept(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) JoinIntercept(c context.Context, rq *manager.JoinInterceptRequest) (result *manager.InterceptInfo, err error) {
+	err = s.WithSession(c, "JoinIntercept", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.InterceptSharing, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		rq.Session = session.SessionInfo()
+		if rq.BearerToken, err = k8sclient.BearerToken(c, session.GetRestConfig()); err != nil {
+			if errors.Is(err, k8sclient.ErrNoBearerToken) {
+				err = status.Error(codes.FailedPrecondition, "joining an intercept requires a kubeconfig that authenticates using a bearer token")
+			}
+			return err
+		}
+		result, err = session.ManagerClient().JoinIntercept(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) LeaveIntercept(c context.Context, rq *manager.JoinInterceptRequest) (result *empty.Empty, err error) {
+	err = s.WithSession(c, "LeaveIntercept", func(c context.Context, session userd.Session) error {
+		rq.Session = session.SessionInfo()
+		result, err = session.ManagerClient().LeaveIntercept(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) Evict(c context.Context, rq *manager.EvictRequest) (result *manager.ReapResult, err error) {
+	err = s.WithSession(c, "Evict", func(c context.Context, session userd.Session) error {
+		if err = capability.Check(capability.AdminAPI, session.ManagerCapabilities(), session.ManagerVersion()); err != nil {
+			return err
+		}
+		if rq.BearerToken, err = k8sclient.BearerToken(c, session.GetRestConfig()); err != nil {
+			if errors.Is(err, k8sclient.ErrNoBearerToken) {
+				err = status.Error(codes.FailedPrecondition, "the admin API requires a kubeconfig that authenticates using a bearer token")
+			}
+			return err
+		}
+		result, err = session.ManagerClient().Evict(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) AddPortForwards(c context.Context, rq *rpc.PortForwardRequest) (result *rpc.PortForwardList, err error) {
+	err = s.WithSession(c, "AddPortForwards", func(c context.Context, session userd.Session) error {
+		result, err = session.AddPortForwards(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) RemovePortForwards(c context.Context, rq *rpc.PortForwardRequest) (result *rpc.PortForwardList, err error) {
+	err = s.WithSession(c, "RemovePortForwards", func(c context.Context, session userd.Session) error {
+		result, err = session.RemovePortForwards(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) ListPortForwards(c context.Context, _ *empty.Empty) (result *rpc.PortForwardList, err error) {
+	err = s.WithSession(c, "ListPortForwards", func(c context.Context, session userd.Session) error {
+		result = session.PortForwards()
+		return nil
+	})
+	return
+}
+
+// resolveRegistryAddress resolves the host of the given host:port address to an IP, preferring IPv4.
+func resolveRegistryAddress(c context.Context, address string) (string, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid port in %q", address)
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(c, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("unable to resolve %q", host)
+	}
+	ip := ips[0]
+	for _, a := range ips {
+		if a.Unmap().Is4() {
+			ip = a.Unmap()
+			break
+		}
+	}
+	return netip.AddrPortFrom(ip, uint16(port)).String(), nil
+}
+
 func (s *service) Uninstall(c context.Context, ur *rpc.UninstallRequest) (result *common.Result, err error) {
 	err = s.WithSession(c, "Uninstall", func(c context.Context, session userd.Session) error {
 		result, err = session.Uninstall(c, ur)
@@ -468,7 +806,12 @@ func (s *service) RemoteMountAvailability(ctx context.Context, _ *empty.Empty) (
 		return errcat.ToResult(nil), nil
 	}
 	if client.GetConfig(ctx).Intercept().UseFtp {
-		return errcat.ToResult(s.FuseFTPError()), nil
+		err := s.FuseFTPError()
+		if err == nil {
+			return errcat.ToResult(nil), nil
+		}
+		// Mounts will fall back to SFTP when it's available.
+		dlog.Warnf(ctx, "FTP mounts are unavailable: %v", err)
 	}
 
 	// Use CombinedOutput to include stderr which has information about whether they
@@ -536,6 +879,14 @@ func (s *service) TrafficManagerVersion(ctx context.Context, _ *empty.Empty) (vi
 	return
 }
 
+func (s *service) TrafficManagerCapabilities(ctx context.Context, _ *empty.Empty) (mc *manager.Capabilities, err error) {
+	err = s.WithSession(ctx, "TrafficManagerCapabilities", func(ctx context.Context, session userd.Session) error {
+		mc = &manager.Capabilities{Features: session.ManagerCapabilities()}
+		return nil
+	})
+	return
+}
+
 func (s *service) RootDaemonVersion(ctx context.Context, empty *empty.Empty) (vi *common.VersionInfo, err error) {
 	err = s.withRootDaemon(ctx, func(ctx context.Context, rd daemon.DaemonClient) error {
 		vi, err = rd.Version(ctx, empty)
@@ -595,6 +946,17 @@ func (s *service) GetIntercept(ctx context.Context, request *manager.GetIntercep
 	return ii, err
 }
 
+func (s *service) RefreshInterceptEnvironment(ctx context.Context, request *manager.GetInterceptRequest) (ii *manager.InterceptInfo, err error) {
+	err = s.WithSession(ctx, "RefreshInterceptEnvironment", func(ctx context.Context, session userd.Session) error {
+		ii = session.RefreshInterceptEnvironment(request.Name)
+		if ii == nil {
+			return status.Errorf(codes.NotFound, "found no intercept named %s", request.Name)
+		}
+		return nil
+	})
+	return ii, err
+}
+
 func (s *service) SetDNSExcludes(ctx context.Context, req *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error) {
 	err := s.WithSession(ctx, "SetDNSExcludes", func(ctx context.Context, session userd.Session) error {
 		_, err := session.RootDaemon().SetDNSExcludes(ctx, req)
@@ -625,3 +987,12 @@ func (s *service) withRootDaemon(ctx context.Context, f func(ctx context.Context
 	}
 	return err
 }
+
+// prepareMountPoint creates the mount point of an intercept that is created by a client on another host. A
+// temporary directory is created when the mount point is empty.
+func prepareMountPoint(mountPoint string) (string, error) {
+	if mountPoint == "" {
+		return os.MkdirTemp("", "telfs-")
+	}
+	return mountPoint, os.MkdirAll(mountPoint, 0o700)
+}
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/handoff"
+)
+
+// handOffListener accepts the connections of a hand-off port, and passes them on to the HandOff calls that
+// wait for them.
+type handOffListener struct {
+	ln    net.Listener
+	conns chan net.Conn
+	done  chan struct{}
+	refs  int
+}
+
+// handOffs keeps track of the listeners of the hand-off ports. A listener remains open as long as HandOff calls
+// use it, i.e. while the client is waiting for connections or serving them.
+type handOffs struct {
+	sync.Mutex
+	listeners map[int32]*handOffListener
+}
+
+func (h *handOffs) acquire(port int32) (*handOffListener, error) {
+	h.Lock()
+	defer h.Unlock()
+	if l, ok := h.listeners[port]; ok {
+		l.refs++
+		return l, nil
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))))
+	if err != nil {
+		return nil, err
+	}
+	l := &handOffListener{ln: ln, conns: make(chan net.Conn), done: make(chan struct{}), refs: 1}
+	if h.listeners == nil {
+		h.listeners = make(map[int32]*handOffListener)
+	}
+	h.listeners[port] = l
+	go l.acceptLoop()
+	return l, nil
+}
+
+func (h *handOffs) release(port int32) {
+	h.Lock()
+	defer h.Unlock()
+	if l, ok := h.listeners[port]; ok {
+		if l.refs--; l.refs == 0 {
+			delete(h.listeners, port)
+			close(l.done)
+			_ = l.ln.Close()
+		}
+	}
+}
+
+func (l *handOffListener) acceptLoop() {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			return
+		}
+		select {
+		case l.conns <- conn:
+		case <-l.done:
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+func (s *service) HandOff(stream rpc.Connector_HandOffServer) error {
+	ctx := stream.Context()
+	m, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	port := m.Port
+	if port <= 0 || port > 0xffff {
+		return status.Errorf(codes.InvalidArgument, "invalid hand-off port %d", port)
+	}
+	l, err := s.handOffs.acquire(port)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "unable to hand off port %d: %v", port, err)
+	}
+	defer s.handOffs.release(port)
+
+	var conn net.Conn
+	select {
+	case <-ctx.Done():
+		return nil
+	case conn = <-l.conns:
+	}
+	defer conn.Close()
+	if err = stream.Send(&rpc.HandOffMessage{}); err != nil {
+		return err
+	}
+	dlog.Debugf(ctx, "handing off connection from %s on port %d", conn.RemoteAddr(), port)
+	return handoff.Pipe(ctx, conn, stream)
+}
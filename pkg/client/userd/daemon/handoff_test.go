@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/handoff"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func Test_HandOff(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	s := &service{}
+	rpc.RegisterConnectorServer(srv, s)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	as, err := client.FreePortsTCP(1)
+	require.NoError(t, err)
+	port := uint16(as[0].Port)
+
+	stream, err := rpc.NewConnectorClient(conn).HandOff(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&rpc.HandOffMessage{Port: int32(port)}))
+
+	// Connections are accepted once the daemon listens to the port.
+	var dc net.Conn
+	require.Eventually(t, func() bool {
+		dc, err = net.Dial("tcp", iputil.JoinHostPort("127.0.0.1", port))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	defer dc.Close()
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// The connection on this side is an in-memory pipe.
+	local, remote := net.Pipe()
+	go func() {
+		_ = handoff.Pipe(ctx, remote, stream)
+		_ = remote.Close()
+	}()
+
+	_, err = dc.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(local, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	_, err = local.Write([]byte("pong"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(dc, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(buf))
+
+	// The listener is closed when no calls use it.
+	_ = local.Close()
+	cancel()
+	assert.Eventually(t, func() bool {
+		s.handOffs.Lock()
+		defer s.handOffs.Unlock()
+		return len(s.handOffs.listeners) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
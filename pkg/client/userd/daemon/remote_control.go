@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+// serveRemoteControl serves the gRPC API of the daemon on the TLS-protected TCP address of the given config
+// until the context is cancelled, so that a CLI on another host can control the daemon. Only clients that
+// present a certificate issued by the configured CA are accepted. A listener that can't be served is logged as
+// an error but doesn't affect the daemon.
+func serveRemoteControl(ctx context.Context, rc *client.RemoteControl, handler http.Handler) error {
+	tlsConfig, err := rc.ServerTLSConfig()
+	if err != nil {
+		dlog.Errorf(ctx, "unable to serve remote control: %v", err)
+		return nil
+	}
+	ln, err := net.Listen("tcp", rc.ListenAddress)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to serve remote control: %v", err)
+		return nil
+	}
+	sc := &dhttp.ServerConfig{Handler: handler, TLSConfig: tlsConfig}
+	dlog.Infof(ctx, "Remote control on %s started", ln.Addr())
+	defer dlog.Infof(ctx, "Remote control on %s ended", ln.Addr())
+	if err = sc.ServeTLS(ctx, ln, "", ""); err != nil && ctx.Err() == nil {
+		dlog.Errorf(ctx, "remote control stopped: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+// writeCert writes a certificate and key, signed by the given parent, to files in dir, and returns the
+// certificate and key so that they can sign other certificates.
+func writeCert(t *testing.T, dir, name string, tpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return cert, key
+}
+
+func Test_serveRemoteControl(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	ca, caKey := writeCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "userd"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, ca, caKey)
+	writeCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "cli"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, ca, caKey)
+
+	as, err := client.FreePortsTCP(1)
+	require.NoError(t, err)
+	addr := as[0].String()
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = serveRemoteControl(ctx, &client.RemoteControl{
+			ListenAddress: addr,
+			CertFile:      filepath.Join(dir, "server.crt"),
+			KeyFile:       filepath.Join(dir, "server.key"),
+			CAFile:        filepath.Join(dir, "ca.crt"),
+		}, srv)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	check := func(ctx context.Context, tlsConfig *tls.Config) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.WaitForReady(true))
+		return err
+	}
+
+	rc := &client.RemoteControl{
+		CertFile: filepath.Join(dir, "client.crt"),
+		KeyFile:  filepath.Join(dir, "client.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	tlsConfig, err := rc.ClientTLSConfig()
+	require.NoError(t, err)
+	require.NoError(t, check(ctx, tlsConfig))
+
+	// A client without a certificate is rejected.
+	tlsConfig.Certificates = nil
+	tcCtx, tcCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer tcCancel()
+	assert.Error(t, check(tcCtx, tlsConfig))
+
+	// An incomplete config is rejected.
+	_, err = (&client.RemoteControl{CertFile: rc.CertFile}).ClientTLSConfig()
+	assert.ErrorContains(t, err, "requires a certFile, a keyFile, and a caFile")
+}
@@ -16,6 +16,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
 	"github.com/datawire/dlib/dgroup"
@@ -89,8 +93,20 @@ type service struct {
 	// The TCP address that the daemon listens to. Will be nil if the daemon listens to a unix socket.
 	daemonAddress *net.TCPAddr
 
+	// The unix socket that the daemon listens to. Will be empty if the daemon listens to a TCP address.
+	socketPath string
+
 	// Possibly extended version of the service. Use when calling interface methods.
 	self userd.Service
+
+	// The events that are streamed by WatchEvents.
+	events eventHub
+
+	// The listeners of the ports that are handed off to remote clients.
+	handOffs handOffs
+
+	// The gRPC health service. Nil when no gRPC server is used.
+	health *health.Server
 }
 
 func NewService(ctx context.Context, _ *dgroup.Group, cfg client.Config, srv *grpc.Server) (userd.Service, error) {
@@ -112,6 +128,13 @@ func NewService(ctx context.Context, _ *dgroup.Group, cfg client.Config, srv *gr
 			return nil, err
 		}
 		common.RegisterTracingServer(srv, tracer)
+
+		// The standard health and reflection services let tools like grpc-health-probe and grpcurl probe the
+		// daemon and discover its API without a copy of its protos.
+		s.health = health.NewServer()
+		s.health.SetServingStatus(rpc.Connector_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+		grpc_health_v1.RegisterHealthServer(srv, s.health)
+		reflection.Register(srv)
 	} else {
 		s.rootSessionInProc = true
 		s.quit = func() {}
@@ -134,7 +157,7 @@ func (s *service) ListenerAddress(ctx context.Context) string {
 	if s.daemonAddress != nil {
 		return s.daemonAddress.String()
 	}
-	return "unix:" + socket.UserDaemonPath(ctx)
+	return "unix:" + s.socketPath
 }
 
 func (s *service) SetSelf(self userd.Service) {
@@ -179,6 +202,7 @@ const (
 	nameFlag         = "name"
 	addressFlag      = "address"
 	embedNetworkFlag = "embed-network"
+	isolatedFlag     = "isolated"
 	pprofFlag        = "pprof"
 )
 
@@ -196,10 +220,26 @@ func Command() *cobra.Command {
 	flags.String(nameFlag, userd.ProcessName, "Daemon name")
 	flags.String(addressFlag, "", "Address to listen to. Defaults to "+socket.UserDaemonPath(context.Background()))
 	flags.Bool(embedNetworkFlag, false, "Embed network functionality in the user daemon. Requires capability NET_ADMIN")
+	flags.Bool(isolatedFlag, false, "Coexist with other daemons on the host by using user-space networking and a separate log file")
 	flags.Uint16(pprofFlag, 0, "start pprof server on the given port")
 	return c
 }
 
+// isolateConfig modifies the given config so that the daemon can coexist with other daemons on the host. An
+// isolated daemon can't share the root daemon or the virtual network interface with other daemons, so it uses
+// user-space networking with a SOCKS5 proxy on an ephemeral port, and connects to the traffic-manager directly.
+func isolateConfig(cfg client.Config) error {
+	as, err := client.FreePortsTCP(1)
+	if err != nil {
+		return err
+	}
+	cc := cfg.Cluster()
+	cc.UserspaceNetworking = true
+	cc.SOCKSProxyAddress = as[0].String()
+	cc.ConnectFromRootDaemon = true
+	return nil
+}
+
 func (s *service) configReload(c context.Context) error {
 	// Ensure that the directory to watch exists.
 	if err := os.MkdirAll(filepath.Dir(client.GetConfigFile(c)), 0o755); err != nil {
@@ -241,6 +281,17 @@ func (s *service) ManageSessions(c context.Context) error {
 }
 
 func (s *service) startSession(parentCtx context.Context, cr userd.ConnectRequest, wg *sync.WaitGroup) *rpc.ConnectInfo {
+	// A connect request for an existing session only needs a read lock. Acquiring the write lock here
+	// would block until all long-running calls, such as a slow intercept creation, have returned, and
+	// all other calls would be blocked while waiting for it.
+	s.sessionLock.RLock()
+	if s.session != nil {
+		defer s.sessionLock.RUnlock()
+		// UpdateStatus sets rpc.ConnectInfo_ALREADY_CONNECTED if successful
+		return s.session.UpdateStatus(s.sessionContext, cr)
+	}
+	s.sessionLock.RUnlock()
+
 	s.sessionLock.Lock() // Locked during creation
 	defer s.sessionLock.Unlock()
 
@@ -293,29 +344,37 @@ func (s *service) startSession(parentCtx context.Context, cr userd.ConnectReques
 		cancel()
 		<-session.Done()
 	}
+	s.self.PublishEvent(&rpc.Event{Type: rpc.Event_SESSION_CONNECTED, Context: rsp.ClusterContext, Namespace: rsp.Namespace})
 
 	// Run the session asynchronously. We must be able to respond to connect (with UpdateStatus) while
 	// the session is running. The s.sessionCancel is called from Disconnect
 	wg.Add(1)
 	go func(cr userd.ConnectRequest) {
+		resume := false
 		defer func() {
 			s.sessionLock.Lock()
-			s.self.SetManagerClient(nil)
-			s.session = nil
-			s.sessionCancel = nil
+			if s.session == session {
+				s.self.SetManagerClient(nil)
+				s.session = nil
+				s.sessionCancel = nil
+			}
 			s.sessionLock.Unlock()
+			s.self.PublishEvent(&rpc.Event{Type: rpc.Event_SESSION_DISCONNECTED, Context: rsp.ClusterContext, Namespace: rsp.Namespace})
 			_ = client.ReloadDaemonLogLevel(parentCtx, false)
+			if resume {
+				s.resumeSession(parentCtx, cr, wg)
+			}
 			wg.Done()
 		}()
 		if err := session.RunSession(s.sessionContext); err != nil {
-			if errors.Is(err, trafficmgr.ErrSessionExpired) {
-				// Session has expired. We need to cancel the owner session and reconnect
+			if errors.Is(err, trafficmgr.ErrSessionExpired) && parentCtx.Err() == nil {
+				// The session has expired, or the traffic-manager has been unavailable for longer than the
+				// resume timeout, in which case the traffic-manager may still hold the session. Its intercepts
+				// are not cleared. Instead, the new session resumes the session if the traffic-manager still
+				// holds it, or departs from it and re-creates its intercepts from the persisted intercept state.
 				dlog.Info(ctx, "refreshing session")
-				s.cancelSession()
-				select {
-				case <-ctx.Done():
-				case s.connectRequest <- cr:
-				}
+				resume = true
+				cancel()
 				return
 			}
 
@@ -329,6 +388,30 @@ func (s *service) startSession(parentCtx context.Context, cr userd.ConnectReques
 	return rsp
 }
 
+// resumeSession replaces an expired session with a new one. The new session re-creates the intercepts of the
+// expired session. New attempts are made with increasing delays until a session is established or the given
+// context is cancelled.
+func (s *service) resumeSession(ctx context.Context, cr userd.ConnectRequest, wg *sync.WaitGroup) {
+	backoff := time.Second
+	for {
+		rsp := s.startSession(ctx, cr, wg)
+		if rsp.Error == rpc.ConnectInfo_UNSPECIFIED || rsp.Error == rpc.ConnectInfo_ALREADY_CONNECTED {
+			return
+		}
+		if s.rootSessionInProc {
+			// The daemon quits when its only session can't be established.
+			return
+		}
+		dlog.Errorf(ctx, "unable to resume session, retrying in %s: %s", backoff, rsp.ErrorText)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
 func runAliveAndCancellation(ctx context.Context, cancel context.CancelFunc, daemonID *daemon.Identifier) {
 	daemonInfoFile := daemonID.InfoFileName()
 	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
@@ -352,21 +435,26 @@ func runAliveAndCancellation(ctx context.Context, cancel context.CancelFunc, dae
 	}
 }
 
-func (s *service) cancelSessionReadLocked() {
+// cancelSessionReadLocked clears the intercepts of the current session and cancels it. The intercept handlers
+// that failed to stop gracefully are returned.
+func (s *service) cancelSessionReadLocked() (failures []*rpc.HandlerStopFailure) {
 	if s.sessionCancel != nil {
-		if err := s.session.ClearIntercepts(s.sessionContext); err != nil {
+		var err error
+		if failures, err = s.session.ClearIntercepts(s.sessionContext); err != nil {
 			dlog.Errorf(s.sessionContext, "failed to clear intercepts: %v", err)
 		}
 		s.sessionCancel()
 	}
+	return failures
 }
 
-func (s *service) cancelSession() {
+// cancelSession cancels the current session. The intercept handlers that failed to stop gracefully are returned.
+func (s *service) cancelSession() (failures []*rpc.HandlerStopFailure) {
 	if !atomic.CompareAndSwapInt32(&s.sessionQuitting, 0, 1) {
-		return
+		return nil
 	}
 	s.sessionLock.RLock()
-	s.cancelSessionReadLocked()
+	failures = s.cancelSessionReadLocked()
 	s.sessionLock.RUnlock()
 
 	// We have to cancel the session before we can acquire this write-lock, because we need any long-running RPCs
@@ -376,6 +464,7 @@ func (s *service) cancelSession() {
 	s.sessionCancel = nil
 	atomic.StoreInt32(&s.sessionQuitting, 0)
 	s.sessionLock.Unlock()
+	return failures
 }
 
 // run is the main function when executing as the connector.
@@ -407,12 +496,23 @@ func run(cmd *cobra.Command, _ []string) error {
 		name = name[:di]
 	}
 	c = dgroup.WithGoroutineName(c, "/"+name)
-	c, err = logging.InitContext(c, userd.ProcessName, logging.RotateDaily, true)
+	logName := userd.ProcessName
+	isolated, _ := flags.GetBool(isolatedFlag)
+	if isolated {
+		logName += "-" + sessionName
+	}
+	c, err = logging.InitContext(c, logName, logging.RotateDaily, true)
 	if err != nil {
 		return err
 	}
+	if isolated {
+		if err = isolateConfig(cfg); err != nil {
+			return err
+		}
+	}
 	rootSessionInProc, _ := flags.GetBool(embedNetworkFlag)
 	var daemonAddress *net.TCPAddr
+	var socketPath string
 	if addr, _ := flags.GetString(addressFlag); addr != "" {
 		lc := net.ListenConfig{}
 		if grpcListener, err = lc.Listen(c, "tcp", addr); err != nil {
@@ -423,7 +523,14 @@ func run(cmd *cobra.Command, _ []string) error {
 			_ = grpcListener.Close()
 		}()
 	} else {
-		socketPath := socket.UserDaemonPath(c)
+		socketPath = socket.UserDaemonPath(c)
+		if isolated {
+			// An isolated daemon can't use the well-known socket, which is shared by all users of the host.
+			socketPath = socket.IsolatedUserDaemonPath(c, sessionName)
+			if err = os.MkdirAll(filepath.Dir(socketPath), 0o700); err != nil {
+				return err
+			}
+		}
 		dlog.Infof(c, "Starting socket listener for %s", socketPath)
 		if grpcListener, err = socket.Listen(c, userd.ProcessName, socketPath); err != nil {
 			dlog.Errorf(c, "socket listener for %s failed: %v", socketPath, err)
@@ -482,6 +589,7 @@ func run(cmd *cobra.Command, _ []string) error {
 	si.As(&s)
 	s.rootSessionInProc = rootSessionInProc
 	s.daemonAddress = daemonAddress
+	s.socketPath = socketPath
 
 	if err := logging.LoadTimedLevelFromCache(c, s.timedLogLevel, userd.ProcessName); err != nil {
 		return err
@@ -499,6 +607,10 @@ func run(cmd *cobra.Command, _ []string) error {
 
 	g.Go("server-grpc", func(c context.Context) (err error) {
 		sc := &dhttp.ServerConfig{Handler: s.srv}
+		if s.health != nil {
+			// Report NOT_SERVING while the server drains its connections.
+			context.AfterFunc(c, s.health.Shutdown)
+		}
 		dlog.Info(c, "gRPC server started")
 		if err = sc.Serve(c, grpcListener); err != nil && c.Err() != nil {
 			err = nil // Normal shutdown
@@ -511,6 +623,29 @@ func run(cmd *cobra.Command, _ []string) error {
 		return err
 	})
 
+	if port := cfg.Grpc().GatewayPort; port > 0 {
+		g.Go("gateway", func(c context.Context) error {
+			var conn *grpc.ClientConn
+			if daemonAddress != nil {
+				conn, err = grpc.NewClient(daemonAddress.String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithNoProxy())
+			} else {
+				conn, err = socket.Dial(c, socketPath, true)
+			}
+			if err != nil {
+				dlog.Errorf(c, "unable to connect the gateway: %v", err)
+				return nil
+			}
+			defer conn.Close()
+			return serveGateway(c, port, conn)
+		})
+	}
+
+	if rc := cfg.Grpc().RemoteControl; rc.ListenAddress != "" {
+		g.Go("remote-control", func(c context.Context) error {
+			return serveRemoteControl(c, &rc, s.srv)
+		})
+	}
+
 	g.Go("config-reload", s.configReload)
 	g.Go(sessionName, func(c context.Context) error {
 		c, cancel := context.WithCancel(c)
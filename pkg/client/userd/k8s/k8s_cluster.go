@@ -40,7 +40,7 @@ type Cluster struct {
 	// Argo Rollouts
 	ari argorollouts.Interface
 
-	// nsLock protects namespaceWatcherSnapshot, currentMappedNamespaces and namespaceListeners
+	// nsLock protects MappedNamespaces, namespaceWatcherSnapshot, currentMappedNamespaces and namespaceListeners
 	nsLock sync.Mutex
 
 	// snapshot maintained by the namespaces watcher.
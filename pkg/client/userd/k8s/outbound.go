@@ -170,12 +170,16 @@ func sortedStringSlicesEqual(as, bs []string) bool {
 
 func (kc *Cluster) SetMappedNamespaces(c context.Context, namespaces []string) bool {
 	sort.Strings(namespaces)
-	if !sortedStringSlicesEqual(namespaces, kc.MappedNamespaces) {
+	kc.nsLock.Lock()
+	changed := !sortedStringSlicesEqual(namespaces, kc.MappedNamespaces)
+	if changed {
 		kc.MappedNamespaces = namespaces
+	}
+	kc.nsLock.Unlock()
+	if changed {
 		kc.refreshNamespaces(c)
-		return true
 	}
-	return false
+	return changed
 }
 
 func (kc *Cluster) AddNamespaceListener(c context.Context, nsListener userd.NamespaceListener) {
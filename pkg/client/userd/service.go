@@ -42,6 +42,9 @@ type Service interface {
 	ReadConnectResponse(context.Context) (*rpc.ConnectInfo, error)
 
 	ManageSessions(c context.Context) error
+
+	// PublishEvent sends the given event to the clients that watch events.
+	PublishEvent(*rpc.Event)
 }
 
 type NewServiceFunc func(context.Context, *dgroup.Group, client.Config, *grpc.Server) (Service, error)
@@ -71,3 +74,11 @@ func GetService(ctx context.Context) Service {
 	}
 	panic("No User daemon Service has been registered")
 }
+
+// PublishEvent sends the given event to the clients that watch the events of the Service of the given context.
+// It does nothing when the context has no Service.
+func PublishEvent(ctx context.Context, ev *rpc.Event) {
+	if s, ok := ctx.Value(serviceKey{}).(Service); ok {
+		s.PublishEvent(ev)
+	}
+}
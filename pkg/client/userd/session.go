@@ -2,6 +2,7 @@ package userd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/blang/semver/v4"
 	"google.golang.org/grpc"
@@ -10,6 +11,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/datawire/dlib/dgroup"
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
 	"github.com/telepresenceio/telepresence/rpc/v2/common"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
@@ -29,6 +31,26 @@ type WatchWorkloadsStream interface {
 	Context() context.Context
 }
 
+type FetchFilesStream interface {
+	Send(*agent.FileChunk) error
+}
+
+type PutFilesStream interface {
+	Recv() (*rpc.PutFilesRequest, error)
+}
+
+type WiretapStream interface {
+	Send(*rootdRpc.WiretapEvent) error
+}
+
+type CaptureStream interface {
+	Send(*agent.CapturedRequest) error
+}
+
+type MountStream interface {
+	Send(*rpc.MountInfo) error
+}
+
 type InterceptInfo interface {
 	InterceptResult() *rpc.InterceptResult
 	PreparedIntercept() *manager.PreparedIntercept
@@ -42,6 +64,21 @@ type KubeConfig interface {
 
 type NamespaceListener func(context.Context)
 
+// HandlerStopError is returned by Session.RemoveIntercept when the intercept was removed, but its intercept
+// handler failed to stop gracefully.
+type HandlerStopError struct {
+	Intercept string
+	Err       error
+}
+
+func (e *HandlerStopError) Error() string {
+	return fmt.Sprintf("intercept handler of %s failed to stop: %v", e.Intercept, e.Err)
+}
+
+func (e *HandlerStopError) Unwrap() error {
+	return e.Err
+}
+
 type Session interface {
 	restapi.AgentState
 	KubeConfig
@@ -55,16 +92,27 @@ type Session interface {
 
 	AddInterceptor(string, *rpc.Interceptor) error
 	RemoveInterceptor(string) error
-	ClearIntercepts(context.Context) error
+	IsInterceptorAttached(*rpc.Interceptor) bool
+	ClearIntercepts(context.Context) ([]*rpc.HandlerStopFailure, error)
 
 	GetInterceptInfo(string) *manager.InterceptInfo
+	RefreshInterceptEnvironment(string) *manager.InterceptInfo
 	GetInterceptSpec(string) *manager.InterceptSpec
 	InterceptsForWorkload(string, string) []*manager.InterceptSpec
+	FetchFiles(context.Context, *rpc.FetchFilesRequest, FetchFilesStream) error
+	PutFiles(context.Context, PutFilesStream) (*agent.PutFilesResult, error)
+	Wiretap(context.Context, *rpc.WiretapRequest, WiretapStream) error
+	Capture(context.Context, *rpc.CaptureRequest, CaptureStream) error
+	Mount(context.Context, *rpc.MountRequest, MountStream) error
+	AddPortForwards(context.Context, *rpc.PortForwardRequest) (*rpc.PortForwardList, error)
+	RemovePortForwards(context.Context, *rpc.PortForwardRequest) (*rpc.PortForwardList, error)
+	PortForwards() *rpc.PortForwardList
 
 	ManagerClient() manager.ManagerClient
 	ManagerConn() *grpc.ClientConn
 	ManagerName() string
 	ManagerVersion() semver.Version
+	ManagerCapabilities() []string
 	NewRemainRequest() *manager.RemainRequest
 
 	Status(context.Context) *rpc.ConnectInfo
@@ -73,7 +121,7 @@ type Session interface {
 	Uninstall(context.Context, *rpc.UninstallRequest) (*common.Result, error)
 
 	WatchWorkloads(context.Context, *rpc.WatchWorkloadsRequest, WatchWorkloadsStream) error
-	WorkloadInfoSnapshot(context.Context, []string, rpc.ListRequest_Filter) (*rpc.WorkloadInfoSnapshot, error)
+	WorkloadInfoSnapshot(ctx context.Context, namespaces []string, filter rpc.ListRequest_Filter, includeOthers bool) (*rpc.WorkloadInfoSnapshot, error)
 
 	GetCurrentNamespaces(forClientAccess bool) []string
 	ActualNamespace(string) string
@@ -0,0 +1,75 @@
+package trafficmgr
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+)
+
+// agentEventsWatcher publishes the Kubernetes events that the traffic-manager observes while it waits for the
+// traffic-agents of this session's intercepts to arrive.
+func (s *session) agentEventsWatcher(ctx context.Context) error {
+	if err := capability.Check(capability.AgentEvents, s.managerCapabilities, s.managerVersion); err != nil {
+		dlog.Debug(ctx, err)
+		return nil
+	}
+	return runWithRetry(ctx, s._agentEventsWatcher)
+}
+
+func (s *session) _agentEventsWatcher(ctx context.Context) error {
+	stream, err := s.managerClient.WatchAgentEvents(ctx, s.sessionInfo)
+	if err != nil {
+		return err
+	}
+	for {
+		ae, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		userd.PublishEvent(ctx, &rpc.Event{
+			Type:       rpc.Event_AGENT_EVENT,
+			Time:       ae.CreateTime,
+			Namespace:  ae.ObjectNamespace,
+			Message:    ae.Note,
+			AgentEvent: ae,
+		})
+	}
+}
+
+// agentProgressWatcher publishes the stages that workloads reach while the traffic-manager waits for the
+// traffic-agents of this session's intercepts to arrive.
+func (s *session) agentProgressWatcher(ctx context.Context) error {
+	if err := capability.Check(capability.AgentProgress, s.managerCapabilities, s.managerVersion); err != nil {
+		dlog.Debug(ctx, err)
+		return nil
+	}
+	return runWithRetry(ctx, s._agentProgressWatcher)
+}
+
+func (s *session) _agentProgressWatcher(ctx context.Context) error {
+	stream, err := s.managerClient.WatchAgentArrivalProgress(ctx, s.sessionInfo)
+	if err != nil {
+		return err
+	}
+	for {
+		ap, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		userd.PublishEvent(ctx, &rpc.Event{
+			Type:          rpc.Event_AGENT_PROGRESS,
+			Time:          ap.Time,
+			Namespace:     ap.Namespace,
+			AgentProgress: ap,
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package trafficmgr
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// mountBridge is a local port that is bridged to the SFTP server of a traffic-agent.
+type mountBridge struct {
+	remotefs.Mounter
+	podIP     string
+	localPort int32
+	cancel    context.CancelFunc
+
+	// users counts the acquisitions of this bridge per intercept ID. An intercept acquires the bridge twice
+	// for a short while when its pod is replaced.
+	users map[string]int
+}
+
+// mountBridges keeps track of the mount bridges of a session. A daemon that runs in a container can't mount the
+// remote volumes of an intercept itself, so it bridges a local port to the SFTP server of the traffic-agent
+// instead. The bridges are keyed by pod, so that all intercepts that target containers in the same pod share one
+// bridge, and hence one local port, regardless of what port they asked for. A bridge follows its intercepts when
+// the intercepted pod is replaced, so that its local port remains valid.
+type mountBridges struct {
+	ctx           context.Context
+	sessionID     string
+	managerClient manager.ManagerClient
+	bridges       map[string]*mountBridge
+}
+
+func newMountBridges(ctx context.Context, sessionID string, managerClient manager.ManagerClient) *mountBridges {
+	return &mountBridges{
+		ctx:           ctx,
+		sessionID:     sessionID,
+		managerClient: managerClient,
+		bridges:       make(map[string]*mountBridge),
+	}
+}
+
+// acquire ensures that a bridge to the given pod exists, and assigns its local port to the given intercept. It
+// returns nil if no bridge could be established.
+func (mb *mountBridges) acquire(ic *intercept, podIP string) *mountBridge {
+	ctx := mb.ctx
+	if b, ok := mb.bridges[podIP]; ok {
+		b.users[ic.Id]++
+		if b.localPort != ic.localMountPort {
+			dlog.Debugf(ctx, "Intercept %q shares the mount bridge to pod %s at port %d", ic.Spec.Name, podIP, b.localPort)
+			ic.localMountPort = b.localPort
+		}
+		return b
+	}
+	if ic.SftpPort == 0 {
+		dlog.Errorf(ctx, "Client is configured to perform remote mounts using SFTP, but only FTP is provided by the traffic-agent. %s", ic.fetchHint())
+		return nil
+	}
+	addr := iputil.Parse(ic.PodIp)
+	for oldIP, b := range mb.bridges {
+		if b.users[ic.Id] > 0 {
+			// The intercepted pod was replaced. Let the bridge forward to the new pod.
+			if err := b.Start(ctx, ic.Id, "", ic.MountPoint, addr, uint16(ic.SftpPort)); err != nil {
+				dlog.Errorf(ctx, "%v. %s", err, ic.fetchHint())
+				return nil
+			}
+			delete(mb.bridges, oldIP)
+			b.podIP = podIP
+			b.users[ic.Id]++
+			ic.localMountPort = b.localPort
+			mb.bridges[podIP] = b
+			return b
+		}
+	}
+
+	// The bridge must survive the intercept that created it, so it's controlled by the session context.
+	ctx, cancel := context.WithCancel(ctx)
+	m := remotefs.NewBridgeMounter(mb.sessionID, mb.managerClient, uint16(ic.localMountPort))
+	if err := m.Start(ctx, ic.Id, "", ic.MountPoint, addr, uint16(ic.SftpPort)); err != nil {
+		cancel()
+		if mb.ctx.Err() == nil {
+			dlog.Errorf(ctx, "%v. %s", err, ic.fetchHint())
+		}
+		return nil
+	}
+	b := &mountBridge{Mounter: m, podIP: podIP, localPort: ic.localMountPort, cancel: cancel, users: map[string]int{ic.Id: 1}}
+	mb.bridges[podIP] = b
+	return b
+}
+
+// release releases the given bridge that was acquired for the intercept with the given ID, and closes the
+// bridge when it's no longer used.
+func (mb *mountBridges) release(b *mountBridge, id string) {
+	b.users[id]--
+	if b.users[id] <= 0 {
+		delete(b.users, id)
+	}
+	if len(b.users) == 0 {
+		dlog.Debugf(mb.ctx, "Closing mount bridge to pod %s at port %d", b.podIP, b.localPort)
+		b.cancel()
+		if mb.bridges[b.podIP] == b {
+			delete(mb.bridges, b.podIP)
+		}
+	}
+}
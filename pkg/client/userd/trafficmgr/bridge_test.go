@@ -0,0 +1,70 @@
+package trafficmgr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func testBridgeIntercept(id, name string, localPort int) *intercept {
+	spec := testSpec(name)
+	return &intercept{
+		InterceptInfo: &manager.InterceptInfo{
+			Id:         id,
+			Spec:       spec,
+			PodIp:      "127.0.0.1",
+			SftpPort:   2222,
+			MountPoint: "/tel_app_exports/" + name,
+		},
+		localMountPort: int32(localPort),
+	}
+}
+
+func Test_mountBridges(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	ports, err := client.FreePortsTCP(2)
+	require.NoError(t, err)
+
+	mb := newMountBridges(ctx, "session", nil)
+	one := testBridgeIntercept("id-1", "one", ports[0].Port)
+	two := testBridgeIntercept("id-2", "two", ports[1].Port)
+
+	// Intercepts of the same pod share the bridge, and hence its port.
+	b1 := mb.acquire(one, "10.0.0.1")
+	require.NotNil(t, b1)
+	b2 := mb.acquire(two, "10.0.0.1")
+	assert.Same(t, b1, b2)
+	assert.Equal(t, int32(ports[0].Port), two.localMountPort)
+
+	// The bridge follows its intercepts to a new pod.
+	b3 := mb.acquire(one, "10.0.0.2")
+	assert.Same(t, b1, b3)
+	assert.Equal(t, "10.0.0.2", b1.podIP)
+	mb.release(b1, one.Id)
+	assert.Same(t, b1, mb.acquire(two, "10.0.0.2"))
+	mb.release(b2, two.Id)
+
+	// The bridge is closed when it's no longer used.
+	mb.release(b1, one.Id)
+	assert.Len(t, mb.bridges, 1)
+	mb.release(b1, two.Id)
+	assert.Empty(t, mb.bridges)
+	assert.Eventually(t, func() bool {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", ports[0].Port))
+		if err != nil {
+			return false
+		}
+		_ = l.Close()
+		return true
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,123 @@
+package trafficmgr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+)
+
+// Capture streams the requests that the traffic-agents of a workload receive. A traffic-agent is injected into
+// the workload if it doesn't have one, but the workload is never intercepted. The capture talks directly to the
+// traffic-agent of each pod of the workload, and ends when the context is cancelled or when all pods are gone.
+func (s *session) Capture(ctx context.Context, request *connector.CaptureRequest, stream userd.CaptureStream) error {
+	_, err := s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{Session: s.sessionInfo, Name: request.Workload})
+	if err != nil {
+		return err
+	}
+	agents, err := s.workloadAgents(ctx, request.Workload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var firstErr error
+	send := func(cr *agent.CapturedRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(cr)
+	}
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	acr := &agent.CaptureRequest{
+		Port:               request.Port,
+		ClientSessionId:    s.sessionInfo.SessionId,
+		IncludeCredentials: request.IncludeCredentials,
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(agents))
+	for _, ai := range agents {
+		go func() {
+			defer wg.Done()
+			if err := captureAgent(ctx, ai, acr, send); err != nil && ctx.Err() == nil {
+				fail(err)
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// workloadAgents returns the traffic-agents of the given workload in the namespace of the session.
+func (s *session) workloadAgents(ctx context.Context, workload string) ([]*manager.AgentInfo, error) {
+	wCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ws, err := s.managerClient.WatchAgentsNS(wCtx, &manager.AgentsRequest{Session: s.sessionInfo, Namespaces: []string{s.Namespace}})
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := ws.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var agents []*manager.AgentInfo
+	for _, ai := range snapshot.Agents {
+		if ai.Name == workload && ai.PodName != "" && ai.ApiPort != 0 {
+			agents = append(agents, ai)
+		}
+	}
+	if len(agents) == 0 {
+		return nil, status.Errorf(codes.NotFound, "found no traffic-agent for workload %s.%s", workload, s.Namespace)
+	}
+	return agents, nil
+}
+
+func captureAgent(ctx context.Context, ai *manager.AgentInfo, acr *agent.CaptureRequest, send func(*agent.CapturedRequest) error) error {
+	conn, ac, _, err := k8sclient.ConnectToAgent(ctx, ai.PodName, ai.Namespace, uint16(ai.ApiPort))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	cs, err := ac.Capture(ctx, acr)
+	if err == nil {
+		var cr *agent.CapturedRequest
+		for {
+			if cr, err = cs.Recv(); err != nil {
+				break
+			}
+			if err = send(cr); err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case errors.Is(err, io.EOF):
+		dlog.Debugf(ctx, "capture of pod %s.%s ended", ai.PodName, ai.Namespace)
+		return nil
+	case status.Code(err) == codes.Unimplemented:
+		return status.Errorf(codes.FailedPrecondition,
+			"the traffic-agent of pod %s.%s is too old to capture requests", ai.PodName, ai.Namespace)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,230 @@
+package trafficmgr
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/k8s"
+)
+
+// dashboardRecentRequests is the number of recent requests that are kept for each intercept.
+const dashboardRecentRequests = 50
+
+// matchedRequest is a request that the Telepresence API matched against the header matchers of an intercept.
+type matchedRequest struct {
+	Time    time.Time         `json:"time"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Matched bool              `json:"matched"`
+}
+
+type dashboardSession struct {
+	ID               string `json:"id"`
+	Context          string `json:"context"`
+	Server           string `json:"server"`
+	Namespace        string `json:"namespace"`
+	Manager          string `json:"manager"`
+	ManagerNamespace string `json:"managerNamespace"`
+}
+
+type dashboardIntercept struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Workload    string            `json:"workload"`
+	Namespace   string            `json:"namespace"`
+	Disposition string            `json:"disposition"`
+	Message     string            `json:"message,omitempty"`
+	Matchers    map[string]string `json:"matchers,omitempty"`
+	Matched     int               `json:"matched"`
+	Unmatched   int               `json:"unmatched"`
+	Recent      []*matchedRequest `json:"recent,omitempty"`
+}
+
+type dashboardState struct {
+	Session    dashboardSession      `json:"session"`
+	Intercepts []*dashboardIntercept `json:"intercepts"`
+}
+
+// record counts a request that was matched against this matcher, and adds it to the recent requests. Only the
+// headers that the matcher considers are retained. Must be called with the currentInterceptsLock held.
+func (am *apiMatcher) record(path string, headers http.Header, matched bool) {
+	if matched {
+		am.matched++
+	} else {
+		am.unmatched++
+	}
+	mr := &matchedRequest{Time: time.Now(), Path: path, Matched: matched}
+	for k := range am.requestMatcher.Headers().HeaderMap() {
+		if v := headers.Get(k); v != "" {
+			if mr.Headers == nil {
+				mr.Headers = make(map[string]string)
+			}
+			mr.Headers[k] = v
+		}
+	}
+	if len(am.recent) >= dashboardRecentRequests {
+		am.recent = slices.Delete(am.recent, 0, len(am.recent)-dashboardRecentRequests+1)
+	}
+	am.recent = append(am.recent, mr)
+}
+
+func (s *session) dashboardState(ctx context.Context) *dashboardState {
+	ds := &dashboardState{
+		Session: dashboardSession{
+			ID:               s.sessionInfo.GetSessionId(),
+			Manager:          s.managerName,
+			ManagerNamespace: k8s.GetManagerNamespace(ctx),
+		},
+	}
+	if s.Cluster != nil && s.Kubeconfig != nil {
+		ds.Session.Context = s.Kubeconfig.Context
+		ds.Session.Server = s.Kubeconfig.Server
+		ds.Session.Namespace = s.Kubeconfig.Namespace
+	}
+
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	ds.Intercepts = make([]*dashboardIntercept, 0, len(s.currentIntercepts))
+	for id, ic := range s.currentIntercepts {
+		spec := ic.Spec
+		di := &dashboardIntercept{
+			ID:          id,
+			Name:        spec.Name,
+			Workload:    spec.Agent,
+			Namespace:   spec.Namespace,
+			Disposition: ic.Disposition.String(),
+			Message:     ic.Message,
+			Matchers:    ic.Headers,
+		}
+		if am, ok := s.currentMatchers[id]; ok {
+			di.Matched = am.matched
+			di.Unmatched = am.unmatched
+			// Newest first.
+			di.Recent = make([]*matchedRequest, len(am.recent))
+			for i, mr := range am.recent {
+				di.Recent[len(am.recent)-1-i] = mr
+			}
+		}
+		ds.Intercepts = append(ds.Intercepts, di)
+	}
+	slices.SortFunc(ds.Intercepts, func(a, b *dashboardIntercept) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return ds
+}
+
+// serveDashboard serves the dashboard on the localhost port given by the intercept.dashboardPort config until the
+// session ends. A dashboard that can't be served is logged as an error but doesn't affect the session.
+func (s *session) serveDashboard(ctx context.Context) error {
+	port := client.GetConfig(ctx).Intercept().DashboardPort
+	if port == 0 {
+		return nil
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		dlog.Errorf(ctx, "unable to serve the dashboard: %v", err)
+		return nil
+	}
+	server := &dhttp.ServerConfig{Handler: s.dashboardHandler(ctx)}
+	info := fmt.Sprintf("Dashboard on http://%s", ln.Addr())
+	dlog.Infof(ctx, "%s started", info)
+	defer dlog.Infof(ctx, "%s ended", info)
+	if err = server.Serve(ctx, ln); err != nil && err != ctx.Err() {
+		dlog.Errorf(ctx, "%s stopped: %v", info, err)
+	}
+	return nil
+}
+
+func (s *session) dashboardHandler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, s.dashboardState(ctx)); err != nil {
+			dlog.Errorf(ctx, "unable to render the dashboard: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /api/state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.MarshalWrite(w, s.dashboardState(ctx)); err != nil {
+			dlog.Errorf(ctx, "unable to write the dashboard state: %v", err)
+		}
+	})
+
+	// Only accept requests that are addressed to localhost, so that a web page can't use DNS rebinding to
+	// read the dashboard.
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		switch host {
+		case "localhost", "127.0.0.1", "::1":
+			mux.ServeHTTP(w, r)
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	})
+}
+
+//nolint:gochecknoglobals // constant
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>Telepresence</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.matched { color: #080; }
+.unmatched { color: #888; }
+</style>
+</head>
+<body>
+<h1>Telepresence</h1>
+<table>
+<tr><th>Session</th><td>{{.Session.ID}}</td></tr>
+<tr><th>Context</th><td>{{.Session.Context}} ({{.Session.Server}})</td></tr>
+<tr><th>Namespace</th><td>{{.Session.Namespace}}</td></tr>
+<tr><th>Manager</th><td>{{.Session.Manager}} in {{.Session.ManagerNamespace}}</td></tr>
+</table>
+{{range .Intercepts}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Workload</th><td>{{.Workload}}.{{.Namespace}}</td></tr>
+<tr><th>Disposition</th><td>{{.Disposition}}{{with .Message}}: {{.}}{{end}}</td></tr>
+<tr><th>Matchers</th><td>{{range $k, $v := .Matchers}}{{$k}}={{$v}}<br>{{else}}all requests{{end}}</td></tr>
+<tr><th>Requests</th><td>{{.Matched}} matched, {{.Unmatched}} unmatched</td></tr>
+</table>
+{{with .Recent}}
+<table>
+<tr><th>Time</th><th>Path</th><th>Headers</th><th>Result</th></tr>
+{{range .}}
+<tr class="{{if .Matched}}matched{{else}}unmatched{{end}}">
+<td>{{.Time.Format "15:04:05.000"}}</td>
+<td>{{.Path}}</td>
+<td>{{range $k, $v := .Headers}}{{$k}}: {{$v}}<br>{{end}}</td>
+<td>{{if .Matched}}matched{{else}}unmatched{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+{{else}}
+<p>No intercepts.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,86 @@
+package trafficmgr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+func Test_dashboard(t *testing.T) {
+	ctx := client.WithConfig(dlog.NewTestContext(t, false), client.GetDefaultConfig())
+	headers := map[string]string{"x-telepresence-id": "jane"}
+	s := &session{
+		sessionInfo: &manager.SessionInfo{SessionId: "session-1"},
+		managerName: "traffic-manager",
+		currentIntercepts: map[string]*intercept{
+			"id-1": {InterceptInfo: &manager.InterceptInfo{
+				Id:            "id-1",
+				Spec:          &manager.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default", Client: "jane@laptop"},
+				Disposition:   manager.InterceptDispositionType_ACTIVE,
+				Headers:       headers,
+				PreviewDomain: "echo-jane.preview.example.com",
+			}},
+		},
+	}
+	s.newMatcher(ctx, s.currentIntercepts["id-1"].InterceptInfo)
+
+	for i := range dashboardRecentRequests + 2 {
+		h := http.Header{"Authorization": {"secret"}}
+		if i%2 == 0 {
+			h.Set("X-Telepresence-Id", "jane")
+		}
+		ii, err := s.InterceptInfo(ctx, "id-1", "/api/"+strconv.Itoa(i), 0, h)
+		require.NoError(t, err)
+		assert.Equal(t, i%2 == 0, ii.Intercepted)
+		if ii.Intercepted {
+			assert.Equal(t, "id-1", ii.ID)
+			assert.Equal(t, "jane@laptop", ii.Client)
+			assert.Equal(t, "echo-jane.preview.example.com", ii.PreviewDomain)
+		} else {
+			assert.Empty(t, ii.ID)
+		}
+	}
+
+	handler := s.dashboardHandler(ctx)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8181/api/state", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var ds dashboardState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ds))
+	assert.Equal(t, "session-1", ds.Session.ID)
+	require.Len(t, ds.Intercepts, 1)
+	di := ds.Intercepts[0]
+	assert.Equal(t, "echo", di.Name)
+	assert.Equal(t, "ACTIVE", di.Disposition)
+	assert.Equal(t, headers, di.Matchers)
+	assert.Equal(t, 26, di.Matched)
+	assert.Equal(t, 26, di.Unmatched)
+	require.Len(t, di.Recent, dashboardRecentRequests)
+	assert.Equal(t, "/api/51", di.Recent[0].Path)
+	assert.False(t, di.Recent[0].Matched)
+	assert.Empty(t, di.Recent[0].Headers)
+	assert.Equal(t, "/api/50", di.Recent[1].Path)
+	assert.True(t, di.Recent[1].Matched)
+	assert.Equal(t, map[string]string{"X-Telepresence-Id": "jane"}, di.Recent[1].Headers)
+	assert.Equal(t, "/api/2", di.Recent[dashboardRecentRequests-1].Path)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8181/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<h2>echo</h2>")
+	assert.Contains(t, rec.Body.String(), "26 matched, 26 unmatched")
+	assert.NotContains(t, rec.Body.String(), "secret")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://attacker.example.com:8181/api/state", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,191 @@
+package trafficmgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+// envDriftCheckInterval is the interval between two checks of the environment of an intercepted workload.
+const envDriftCheckInterval = 30 * time.Second
+
+// handlerContainerNameEnv is added to the environment by the user daemon, and is therefore not subject to drift.
+const handlerContainerNameEnv = "TELEPRESENCE_HANDLER_CONTAINER_NAME"
+
+// environmentChanged returns true if the environment b differs from environment a.
+func environmentChanged(a, b map[string]string) bool {
+	n := 0
+	for k, av := range a {
+		if k == handlerContainerNameEnv {
+			continue
+		}
+		if bv, ok := b[k]; !ok || av != bv {
+			return true
+		}
+		n++
+	}
+	for k := range b {
+		if k != handlerContainerNameEnv {
+			n--
+		}
+	}
+	return n != 0
+}
+
+// checkEnvironmentDrift compares the environment in the given snapshot of the intercept with the environment
+// that the intercept had when it became active. It must be called with the currentInterceptsLock held.
+func (ic *intercept) checkEnvironmentDrift(ctx context.Context, ii *manager.InterceptInfo) {
+	if ii.Disposition != manager.InterceptDispositionType_ACTIVE || len(ii.Environment) == 0 {
+		return
+	}
+	if ic.environment == nil {
+		ic.environment = make(map[string]string, len(ii.Environment))
+		for k, v := range ii.Environment {
+			if k != handlerContainerNameEnv {
+				ic.environment[k] = v
+			}
+		}
+		return
+	}
+	if ii.EnvironmentDrift == "" && environmentChanged(ic.environment, ii.Environment) {
+		ii.EnvironmentDrift = "the environment of the intercepted pod has changed"
+		dlog.Warningf(ctx, "Intercept %s: %s", ii.Spec.Name, ii.EnvironmentDrift)
+	}
+}
+
+// watchEnvironmentDrift periodically checks if the environment declared by the pod template of the intercepted
+// workload, or the config maps and secrets that it references, have changed since the intercept was created.
+func (s *session) watchEnvironmentDrift(ic *intercept) {
+	defer ic.wg.Done()
+	ctx := ic.ctx
+	ticker := time.NewTicker(envDriftCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.currentInterceptsLock.Lock()
+		spec := ic.Spec
+		container := spec.ContainerName
+		if container == "" {
+			container = ic.Environment[agentconfig.EnvInterceptContainer]
+		}
+		baseline := ic.envFingerprint
+		s.currentInterceptsLock.Unlock()
+
+		if fp, err := workloadEnvFingerprint(ctx, spec.Agent, spec.Namespace, spec.WorkloadKind, container); err != nil {
+			if ctx.Err() == nil {
+				dlog.Debugf(ctx, "unable to check environment of intercept %s: %v", spec.Name, err)
+			}
+		} else {
+			s.currentInterceptsLock.Lock()
+			switch {
+			case baseline == "":
+				ic.envFingerprint = fp
+			case fp != baseline && ic.EnvironmentDrift == "":
+				ic.EnvironmentDrift = fmt.Sprintf("the environment of %s %s.%s has changed", spec.WorkloadKind, spec.Agent, spec.Namespace)
+				dlog.Warningf(ctx, "Intercept %s: %s", spec.Name, ic.EnvironmentDrift)
+			}
+			s.currentInterceptsLock.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshInterceptEnvironment clears the environment drift of the named intercept, so that its current environment
+// becomes the one that later changes are compared with. The current InterceptInfo is returned, or nil when no
+// intercept with the given name exists.
+func (s *session) RefreshInterceptEnvironment(name string) *manager.InterceptInfo {
+	ic := s.getInterceptByName(name)
+	if ic == nil {
+		return nil
+	}
+	s.currentInterceptsLock.Lock()
+	ic.EnvironmentDrift = ""
+	ic.environment = nil
+	ic.envFingerprint = ""
+	ic.checkEnvironmentDrift(ic.ctx, ic.InterceptInfo)
+	s.currentInterceptsLock.Unlock()
+	return s.GetInterceptInfo(name)
+}
+
+// workloadEnvFingerprint returns a hash of the environment that the given container of a workload's pod template
+// declares, and of the versions of the config maps and secrets that the environment references. Config maps and
+// secrets that cannot be read contribute with their name only.
+func workloadEnvFingerprint(ctx context.Context, name, namespace, kind, container string) (string, error) {
+	wl, err := k8sworkload.GetWorkload(ctx, name, namespace, kind)
+	if err != nil {
+		return "", err
+	}
+	var cn *core.Container
+	cns := wl.GetPodTemplate().Spec.Containers
+	for i := range cns {
+		if cns[i].Name == container {
+			cn = &cns[i]
+			break
+		}
+	}
+	if cn == nil {
+		return "", fmt.Errorf("container %q not found in %s %s.%s", container, kind, name, namespace)
+	}
+
+	h := sha256.New()
+	data, err := json.Marshal(struct {
+		Env     []core.EnvVar
+		EnvFrom []core.EnvFromSource
+	}{cn.Env, cn.EnvFrom})
+	if err != nil {
+		return "", err
+	}
+	h.Write(data)
+
+	ci := k8sapi.GetK8sInterface(ctx).CoreV1()
+	addVersion := func(kind, name string, get func() (meta.Object, error)) {
+		_, _ = fmt.Fprintf(h, "%s/%s", kind, name)
+		if obj, err := get(); err == nil {
+			_, _ = fmt.Fprintf(h, "@%s", obj.GetResourceVersion())
+		}
+	}
+	configMap := func(name string) {
+		addVersion("configmap", name, func() (meta.Object, error) {
+			return ci.ConfigMaps(namespace).Get(ctx, name, meta.GetOptions{})
+		})
+	}
+	secret := func(name string) {
+		addVersion("secret", name, func() (meta.Object, error) {
+			return ci.Secrets(namespace).Get(ctx, name, meta.GetOptions{})
+		})
+	}
+	for _, e := range cn.Env {
+		if vf := e.ValueFrom; vf != nil {
+			switch {
+			case vf.ConfigMapKeyRef != nil:
+				configMap(vf.ConfigMapKeyRef.Name)
+			case vf.SecretKeyRef != nil:
+				secret(vf.SecretKeyRef.Name)
+			}
+		}
+	}
+	for _, e := range cn.EnvFrom {
+		switch {
+		case e.ConfigMapRef != nil:
+			configMap(e.ConfigMapRef.Name)
+		case e.SecretRef != nil:
+			secret(e.SecretRef.Name)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,41 @@
+package trafficmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_environmentChanged(t *testing.T) {
+	a := map[string]string{"A": "1", "B": "2"}
+	assert.False(t, environmentChanged(a, map[string]string{"A": "1", "B": "2"}))
+	assert.False(t, environmentChanged(a, map[string]string{"A": "1", "B": "2", handlerContainerNameEnv: "x"}))
+	assert.True(t, environmentChanged(a, map[string]string{"A": "1", "B": "3"}))
+	assert.True(t, environmentChanged(a, map[string]string{"A": "1"}))
+	assert.True(t, environmentChanged(a, map[string]string{"A": "1", "B": "2", "C": "3"}))
+}
+
+func Test_checkEnvironmentDrift(t *testing.T) {
+	ctx := context.Background()
+	ii := func(env map[string]string) *manager.InterceptInfo {
+		return &manager.InterceptInfo{
+			Spec:        &manager.InterceptSpec{Name: "echo"},
+			Disposition: manager.InterceptDispositionType_ACTIVE,
+			Environment: env,
+		}
+	}
+	ic := &intercept{}
+	ic.checkEnvironmentDrift(ctx, ii(map[string]string{"A": "1"}))
+	assert.Equal(t, map[string]string{"A": "1"}, ic.environment)
+
+	same := ii(map[string]string{"A": "1"})
+	ic.checkEnvironmentDrift(ctx, same)
+	assert.Empty(t, same.EnvironmentDrift)
+
+	changed := ii(map[string]string{"A": "2"})
+	ic.checkEnvironmentDrift(ctx, changed)
+	assert.NotEmpty(t, changed.EnvironmentDrift)
+}
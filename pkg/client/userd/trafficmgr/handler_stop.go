@@ -0,0 +1,96 @@
+package trafficmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+	"github.com/telepresenceio/telepresence/v2/pkg/shellquote"
+)
+
+// handlerPollInterval is how often a terminated intercept handler process is checked for liveness.
+const handlerPollInterval = 100 * time.Millisecond
+
+// stopInterceptor stops the intercept handler of the intercept with the given name. The configured pre-stop hook
+// is run first. The container of the handler is then stopped, or its process terminated, and the handler is
+// given until the handlerStop timeout to exit before it's killed. An error is returned when the handler
+// didn't stop gracefully.
+func stopInterceptor(ctx context.Context, name string, pid int, containerName string) error {
+	if containerName == "" && pid == 0 {
+		return nil
+	}
+
+	// No use trying to kill processes when using a container based daemon, unless
+	// that container based daemon runs as a normal user daemon with separate root daemon.
+	// Some users run a standard telepresence client together with intercepts in one
+	// single container.
+	if proc.RunningInContainer() && userd.GetService(ctx).RootSessionInProcess() {
+		return nil
+	}
+
+	runPreStopHook(ctx, name, pid, containerName)
+	grace := client.GetConfig(ctx).Timeouts().Get(client.TimeoutHandlerStop)
+	if containerName != "" {
+		dlog.Debugf(ctx, "stopping interceptor for intercept %s in container %s", name, containerName)
+		if err := docker.StopContainerWithin(docker.EnableClient(ctx), containerName, grace); err != nil {
+			return fmt.Errorf("unable to stop container %s: %w", containerName, err)
+		}
+		return nil
+	}
+	return terminateProcess(ctx, name, pid, grace)
+}
+
+// runPreStopHook runs the configured pre-stop hook of the intercept handler of the intercept with the given name.
+// The hook is given until the handlerStop timeout to complete. Failures are logged, but don't prevent the
+// handler from being stopped.
+func runPreStopHook(ctx context.Context, name string, pid int, containerName string) {
+	hook := client.GetConfig(ctx).Intercept().PreStopHook
+	if len(hook) == 0 {
+		return
+	}
+	ctx, cancel := client.GetConfig(ctx).Timeouts().TimeoutContext(ctx, client.TimeoutHandlerStop)
+	defer cancel()
+	cmd := proc.CommandContext(ctx, hook[0], hook[1:]...)
+	cmd.Env = append(os.Environ(),
+		"TELEPRESENCE_INTERCEPT_NAME="+name,
+		"TELEPRESENCE_HANDLER_PID="+strconv.Itoa(pid),
+		"TELEPRESENCE_HANDLER_CONTAINER_NAME="+containerName)
+	dlog.Debugf(ctx, "running pre-stop hook for intercept %s: %s", name, shellquote.ShellString(hook[0], hook[1:]))
+	if _, err := proc.CaptureErr(cmd); err != nil {
+		dlog.Warnf(ctx, "pre-stop hook for intercept %s failed: %v", name, client.CheckTimeout(ctx, err))
+	}
+}
+
+// terminateProcess terminates the intercept handler process with the given pid, and waits for it to exit. The
+// process is killed, and an error is returned, when it's still alive after the given grace period.
+func terminateProcess(ctx context.Context, name string, pid int, grace time.Duration) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("unable to find interceptor with pid %d: %w", pid, err)
+	}
+	dlog.Debugf(ctx, "terminating interceptor for intercept %s with pid %d", name, pid)
+	if err = proc.Terminate(p); err != nil && !proc.IsAlive(pid) {
+		return nil
+	}
+	deadline := time.Now().Add(grace)
+	for proc.IsAlive(pid) {
+		if time.Now().After(deadline) {
+			_ = p.Kill()
+			return fmt.Errorf("interceptor with pid %d did not stop within %s and was killed", pid, grace)
+		}
+		select {
+		case <-ctx.Done():
+			_ = p.Kill()
+			return fmt.Errorf("interceptor with pid %d was killed: %w", pid, ctx.Err())
+		case <-time.After(handlerPollInterval):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package trafficmgr
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+func Test_runPreStopHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.out")
+	cfg := client.GetDefaultConfig()
+	cfg.Intercept().PreStopHook = []string{"sh", "-c", `echo "$TELEPRESENCE_INTERCEPT_NAME $TELEPRESENCE_HANDLER_PID" > ` + out}
+	ctx := client.WithConfig(dlog.NewTestContext(t, false), cfg)
+
+	runPreStopHook(ctx, "hello", 1234, "")
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "hello 1234\n", string(data))
+}
+
+func Test_terminateProcess(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	start := func(script string) int {
+		cmd := dexec.CommandContext(ctx, "sh", "-c", script)
+		cmd.DisableLogging = true
+		require.NoError(t, cmd.Start())
+		go func() { _ = cmd.Wait() }()
+		// Give the shell time to install its traps.
+		time.Sleep(200 * time.Millisecond)
+		return cmd.Process.Pid
+	}
+
+	t.Run("graceful", func(t *testing.T) {
+		pid := start("sleep 30 & wait")
+		require.NoError(t, terminateProcess(ctx, "hello", pid, 5*time.Second))
+		assert.False(t, proc.IsAlive(pid))
+	})
+
+	t.Run("killed", func(t *testing.T) {
+		pid := start(`trap "" TERM; while true; do sleep 0.1; done`)
+		err := terminateProcess(ctx, "hello", pid, 500*time.Millisecond)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pid "+strconv.Itoa(pid)+" did not stop within 500ms and was killed")
+		assert.Eventually(t, func() bool { return !proc.IsAlive(pid) }, 5*time.Second, 50*time.Millisecond)
+	})
+}
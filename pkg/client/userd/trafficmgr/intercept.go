@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"net/netip"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,6 +15,7 @@ import (
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
+	auth "k8s.io/api/authorization/v1"
 
 	"github.com/datawire/dlib/dgroup"
 	"github.com/datawire/dlib/dlog"
@@ -24,8 +24,9 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
-	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -33,7 +34,6 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/maps"
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
-	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 )
 
@@ -69,6 +69,21 @@ type intercept struct {
 
 	// Use bridged ftp/sftp mount through this local port
 	localMountPort int32
+
+	// mountMode is how the remote volumes are made available at the ClientMountPoint, "fuse" when empty.
+	mountMode string
+
+	// mountOptions are FUSE options that are added to those of the intercept.fuse config.
+	mountOptions []string
+
+	// mountReadOnly are the rules that decide which paths of the remote volumes are read-only.
+	mountReadOnly remotefs.ReadOnlyRules
+
+	// environment is the environment that the intercept had when it became active, or when it was last refreshed.
+	environment map[string]string
+
+	// envFingerprint is the fingerprint of the environment declared by the intercepted workload.
+	envFingerprint string
 }
 
 // interceptResult is what gets written to the awaitIntercept's waitCh channel when the
@@ -82,6 +97,10 @@ type interceptResult struct {
 // awaitIntercept is what the traffic-manager is using to notify the watchInterceptsLoop
 // about an expected intercept arrival.
 type awaitIntercept struct {
+	// spec is the spec of the intercept that is being created. It is used when checking for conflicts
+	// with other intercepts that are created concurrently.
+	spec *manager.InterceptSpec
+
 	// mountPoint is the mount point assigned to the InterceptInfo's ClientMountPoint when
 	// it arrives from the traffic-manager.
 	mountPoint string
@@ -90,6 +109,18 @@ type awaitIntercept struct {
 	// the mount to take place in a host
 	mountPort int32
 
+	// mountMode is how the remote volumes are made available at the mountPoint.
+	mountMode string
+
+	// mountOptions are FUSE options that are added to those of the intercept.fuse config.
+	mountOptions []string
+
+	// mountReadOnly are the rules that decide which paths of the remote volumes are read-only.
+	mountReadOnly remotefs.ReadOnlyRules
+
+	// agentImage is the traffic-agent image reported by the traffic-manager when the intercept was prepared.
+	agentImage string
+
 	waitCh chan<- interceptResult
 }
 
@@ -108,6 +139,9 @@ type podInterceptKey struct {
 type podIntercept struct {
 	wg        sync.WaitGroup
 	cancelPod context.CancelFunc
+
+	// bridge is the mount bridge that this podIntercept uses, if any.
+	bridge *mountBridge
 }
 
 // podIntercepts is what the traffic-manager is using to keep track of the chosen pods for
@@ -126,6 +160,9 @@ type podIntercepts struct {
 	// mountsDone contains channels that are closed when the mounts are prepared for the
 	// given id and podIP
 	mountsDone map[podInterceptKey]chan struct{}
+
+	// bridges are the mount bridges that are shared by the intercepts of the same pod.
+	bridges *mountBridges
 }
 
 func (ic *intercept) localPorts() []string {
@@ -178,8 +215,8 @@ func (ic *intercept) workerPortForward(ctx context.Context, port string, wg *syn
 	}
 }
 
-func newPodIntercepts() *podIntercepts {
-	return &podIntercepts{alivePods: make(map[podInterceptKey]*podIntercept)}
+func newPodIntercepts(bridges *mountBridges) *podIntercepts {
+	return &podIntercepts{alivePods: make(map[podInterceptKey]*podIntercept), bridges: bridges}
 }
 
 // start a port forward for the given intercept and remembers that it's alive.
@@ -216,7 +253,7 @@ func (lpf *podIntercepts) start(ctx context.Context, ic *intercept, rd daemon.Da
 		return
 	}
 
-	if client.GetConfig(ctx).Cluster().AgentPortForward {
+	if cc := client.GetConfig(ctx).Cluster(); cc.AgentPortForward && !cc.ExternalManager() {
 		// An agent port-forward to the pod with a designated to the podIP is necessary to
 		// mount or port-forward to localhost.
 		rsp, err := rd.WaitForAgentIP(ctx, &daemon.WaitForAgentIPRequest{
@@ -241,7 +278,11 @@ func (lpf *podIntercepts) start(ctx context.Context, ic *intercept, rd daemon.Da
 	ctx, cancel := context.WithCancel(ic.ctx)
 	lp := &podIntercept{cancelPod: cancel}
 	if ic.shouldMount() {
-		ic.startMount(ctx, &ic.wg, &lp.wg)
+		if ic.localMountPort != 0 {
+			lp.bridge = lpf.bridges.acquire(ic, fk.PodIP)
+		} else {
+			ic.startMount(ctx, &ic.wg, &lp.wg)
+		}
 	}
 	if ic.shouldForward() {
 		ic.startForwards(ctx, &lp.wg)
@@ -279,6 +320,9 @@ func (lpf *podIntercepts) cancelUnwanted(ctx context.Context) {
 				close(md)
 			}
 			lp.wg.Wait()
+			if lp.bridge != nil {
+				lpf.bridges.release(lp.bridge, fk.Id)
+			}
 		}
 	}
 }
@@ -299,7 +343,7 @@ func (s *session) watchInterceptsLoop(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("manager.WatchIntercepts dial: %w", err)
 	}
-	podIcepts := newPodIntercepts()
+	podIcepts := newPodIntercepts(newMountBridges(ctx, s.SessionInfo().SessionId, s.managerClient))
 	for ctx.Err() == nil {
 		snapshot, err := stream.Recv()
 		if err != nil {
@@ -312,6 +356,8 @@ func (s *session) watchInterceptsLoop(ctx context.Context) error {
 			return fmt.Errorf("manager.WatchIntercepts recv: %w", err)
 		}
 		s.handleInterceptSnapshot(ctx, podIcepts, snapshot.Intercepts)
+		s.pruneResumable(snapshot.Intercepts)
+		s.dropUnadopted(ctx)
 	}
 	return nil
 }
@@ -406,9 +452,15 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 	sb.WriteByte('[')
 	for i, ii := range iis {
 		ic, ok := s.currentIntercepts[ii.Id]
+		if !ok || ic.Disposition != ii.Disposition {
+			publishInterceptEvent(ctx, rpc.Event_INTERCEPT_DISPOSITION, ii)
+		}
 		if ok {
-			// retain ClientMountPoint, it's assigned in the client and never passed from the traffic-manager
+			// retain ClientMountPoint, EnvironmentDrift, and AgentImage, they're assigned in the client and never
+			// passed from the traffic-manager
 			ii.ClientMountPoint = ic.ClientMountPoint
+			ii.EnvironmentDrift = ic.EnvironmentDrift
+			ii.AgentImage = ic.AgentImage
 			ic.InterceptInfo = ii
 		} else {
 			ic = &intercept{InterceptInfo: ii}
@@ -416,9 +468,20 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 			dlog.Debugf(ctx, "Received new intercept %s", ic.Spec.Name)
 			if aw, ok := s.interceptWaiters[ii.Spec.Name]; ok {
 				ic.ClientMountPoint = aw.mountPoint
+				ic.AgentImage = aw.agentImage
 				ic.localMountPort = aw.mountPort
+				ic.mountMode = aw.mountMode
+				ic.mountOptions = aw.mountOptions
+				ic.mountReadOnly = aw.mountReadOnly
+			} else {
+				s.adoptLocked(ctx, ic)
+			}
+			if ii.Spec.Agent != "" {
+				ic.wg.Add(1)
+				go s.watchEnvironmentDrift(ic)
 			}
 		}
+		ic.checkEnvironmentDrift(ctx, ii)
 		intercepts[ii.Id] = ic
 		if i > 0 {
 			sb.WriteByte(',')
@@ -435,12 +498,28 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 		if _, ok := intercepts[id]; !ok {
 			dlog.Debugf(ctx, "Cancelling context for intercept %s", ic.Spec.Name)
 			ic.cancel()
+			publishInterceptEvent(ctx, rpc.Event_INTERCEPT_REMOVED, ic.InterceptInfo)
 		}
 	}
 	s.currentIntercepts = intercepts
 	s.reconcileAPIServers(ctx)
 }
 
+// publishInterceptEvent publishes an event of the given type that concerns the given intercept.
+func publishInterceptEvent(ctx context.Context, tp rpc.Event_Type, ii *manager.InterceptInfo) {
+	ev := &rpc.Event{
+		Type:          tp,
+		Namespace:     ii.Spec.Namespace,
+		InterceptId:   ii.Id,
+		InterceptName: ii.Spec.Name,
+	}
+	if tp == rpc.Event_INTERCEPT_DISPOSITION {
+		ev.Disposition = ii.Disposition.String()
+		ev.Message = ii.Message
+	}
+	userd.PublishEvent(ctx, ev)
+}
+
 func InterceptError(tp common.InterceptError, err error) *rpc.InterceptResult {
 	return &rpc.InterceptResult{
 		Error:         tp,
@@ -479,7 +558,20 @@ func (s *interceptInfo) PreparedIntercept() *manager.PreparedIntercept {
 func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc.InterceptResult {
 	s.currentInterceptsLock.Lock()
 	defer s.currentInterceptsLock.Unlock()
+	return s.ensureNoInterceptConflictLocked(ir)
+}
+
+// ensureNoInterceptConflictLocked checks that the given request doesn't conflict with any current intercept, or with
+// any intercept that is in the process of being created. The currentInterceptsLock must be held when calling this
+// function.
+func (s *session) ensureNoInterceptConflictLocked(ir *rpc.CreateInterceptRequest) *rpc.InterceptResult {
 	spec := ir.Spec
+
+	// A drive letter that the user daemon picks at the time of the mount is never busy.
+	mountPoint := ir.MountPoint
+	if mountPoint == remotefs.AutoDriveLetter {
+		mountPoint = ""
+	}
 	for _, iCept := range s.currentIntercepts {
 		switch {
 		case iCept.Spec.Name == spec.Name:
@@ -491,7 +583,7 @@ func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc
 				ErrorCategory: int32(errcat.User),
 				InterceptInfo: iCept.InterceptInfo,
 			}
-		case ir.MountPoint != "" && iCept.ClientMountPoint == ir.MountPoint:
+		case mountPoint != "" && iCept.ClientMountPoint == mountPoint:
 			return &rpc.InterceptResult{
 				Error:         common.InterceptError_MOUNT_POINT_BUSY,
 				ErrorText:     spec.Name,
@@ -500,6 +592,26 @@ func (s *session) ensureNoInterceptConflict(ir *rpc.CreateInterceptRequest) *rpc
 			}
 		}
 	}
+	for _, aw := range s.interceptWaiters {
+		switch {
+		case aw.spec.Name == spec.Name:
+			return InterceptError(common.InterceptError_ALREADY_EXISTS, errcat.User.New(spec.Name))
+		case aw.spec.TargetPort == spec.TargetPort && aw.spec.TargetHost == spec.TargetHost:
+			return &rpc.InterceptResult{
+				Error:         common.InterceptError_LOCAL_TARGET_IN_USE,
+				ErrorText:     spec.Name,
+				ErrorCategory: int32(errcat.User),
+				InterceptInfo: &manager.InterceptInfo{Spec: aw.spec},
+			}
+		case mountPoint != "" && aw.mountPoint == mountPoint:
+			return &rpc.InterceptResult{
+				Error:         common.InterceptError_MOUNT_POINT_BUSY,
+				ErrorText:     aw.spec.Name,
+				ErrorCategory: int32(errcat.User),
+				InterceptInfo: &manager.InterceptInfo{Spec: aw.spec},
+			}
+		}
+	}
 	return nil
 }
 
@@ -521,10 +633,35 @@ func (s *session) CanIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	if spec.Agent == "" {
 		return nil, nil
 	}
+	if spec.IncludeSecrets {
+		ok, err := k8sclient.CanI(c, &auth.ResourceAttributes{
+			Verb:      "get",
+			Resource:  "secrets",
+			Namespace: spec.Namespace,
+		})
+		if !ok {
+			if err == nil {
+				err = errcat.User.Newf("--include-secrets requires permission to get secrets in namespace %s", spec.Namespace)
+			}
+			return nil, InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, err)
+		}
+	}
+	if spec.Replace {
+		if err := capability.Check(capability.Replace, s.managerCapabilities, s.managerVersion); err != nil {
+			return nil, InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, errcat.User.New(grpcStatus.Convert(err).Message()))
+		}
+	}
+	if ir.DryRun {
+		// An older traffic-manager would ignore the dry run flag and inject the agent.
+		if err := capability.Check(capability.InterceptDryRun, s.managerCapabilities, s.managerVersion); err != nil {
+			return nil, InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, errcat.User.New(grpcStatus.Convert(err).Message()))
+		}
+	}
 
 	mgrIr := &manager.CreateInterceptRequest{
 		Session:       s.SessionInfo(),
 		InterceptSpec: spec,
+		DryRun:        ir.DryRun,
 	}
 	if er := self.InterceptProlog(c, mgrIr); er != nil {
 		return nil, er
@@ -560,6 +697,12 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	if iInfo == nil {
 		return &rpc.InterceptResult{Error: common.InterceptError_UNSPECIFIED}
 	}
+	if ir.DryRun {
+		// Nothing is created. The prepared intercept tells what would happen.
+		result = iInfo.InterceptResult()
+		result.PreparedIntercept = iInfo.PreparedIntercept()
+		return result
+	}
 
 	spec.Client = s.clientID
 	if spec.Mechanism == "" {
@@ -583,6 +726,19 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 		}
 		spec.PortIdentifier = pti.String()
 	}
+	if pi.WorkloadName != "" {
+		// The agent might name a service that selects the workload.
+		spec.Agent = pi.WorkloadName
+	}
+	if spec.ReplicaSet != "" || spec.Revision != 0 {
+		if pi.PodTemplateHash == "" {
+			return InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR,
+				errcat.User.New("the traffic-manager is too old to limit an intercept to a replica set or revision"))
+		}
+		spec.ReplicaSet = pi.ReplicaSet
+		spec.Revision = pi.Revision
+		spec.PodTemplateHash = pi.PodTemplateHash
+	}
 	spec.Protocol = pi.Protocol
 	spec.ContainerPort = pi.ContainerPort
 	result = iInfo.InterceptResult()
@@ -601,10 +757,21 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	// should become active within a few seconds.
 	waitCh := make(chan interceptResult, 2) // Need a buffer because reply can come before we're reading the channel,
 	s.currentInterceptsLock.Lock()
+	// Other intercepts may have been created, or started their creation, since CanIntercept was called, so the
+	// conflict check must be repeated while holding the lock.
+	if er := s.ensureNoInterceptConflictLocked(ir); er != nil {
+		s.currentInterceptsLock.Unlock()
+		return er
+	}
 	s.interceptWaiters[spec.Name] = &awaitIntercept{
-		mountPoint: ir.MountPoint,
-		mountPort:  ir.LocalMountPort,
-		waitCh:     waitCh,
+		spec:          spec,
+		mountPoint:    ir.MountPoint,
+		mountPort:     ir.LocalMountPort,
+		mountMode:     ir.MountMode,
+		mountOptions:  ir.MountOptions,
+		mountReadOnly: ir.MountReadOnly,
+		agentImage:    pi.AgentImage,
+		waitCh:        waitCh,
 	}
 	s.currentInterceptsLock.Unlock()
 	defer func() {
@@ -616,7 +783,18 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 		s.currentInterceptsLock.Unlock()
 	}()
 
-	ii, err := mgrClient.CreateIntercept(c, self.NewCreateInterceptRequest(spec))
+	mgrIr := self.NewCreateInterceptRequest(spec)
+	if spec.IncludeSecrets {
+		// The traffic-manager verifies that the user may get secrets before the agent hands them out.
+		var err error
+		if mgrIr.BearerToken, err = k8sclient.BearerToken(c, s.GetRestConfig()); err != nil {
+			if errors.Is(err, k8sclient.ErrNoBearerToken) {
+				err = errcat.User.New("--include-secrets requires a kubeconfig that authenticates using a bearer token")
+			}
+			return InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, err)
+		}
+	}
+	ii, err := mgrClient.CreateIntercept(c, mgrIr)
 	if err != nil {
 		dlog.Debugf(c, "manager responded to CreateIntercept with error %v", err)
 		return InterceptError(common.InterceptError_TRAFFIC_MANAGER_ERROR, err)
@@ -660,10 +838,16 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 				return InterceptError(common.InterceptError_FAILED_TO_ESTABLISH, client.CheckTimeout(c, c.Err()))
 			case <-wr.mountsDone:
 			}
+			if ic.localMountPort != 0 {
+				// The bridge may be shared with another intercept of the same pod.
+				result.LocalMountPort = ic.localMountPort
+				ir.LocalMountPort = ic.localMountPort
+			}
 			if er := self.InterceptEpilog(c, ir, result); er != nil {
 				return er
 			}
 			success = true // Prevent removal in deferred function
+			s.addResumable(ir)
 			return result
 		}
 	}
@@ -688,27 +872,23 @@ func (s *session) RemoveIntercept(c context.Context, name string) error {
 	return s.removeIntercept(c, ii)
 }
 
+// removeIntercept stops the intercept handler of the given intercept, unmounts its filesystems, and tells the
+// traffic-manager to remove it. A *userd.HandlerStopError is returned when the intercept was removed, but its
+// handler failed to stop gracefully.
 func (s *session) removeIntercept(c context.Context, ic *intercept) error {
 	name := ic.Spec.Name
+	s.removeResumable(name)
 
-	// No use trying to kill processes when using a container based daemon, unless
-	// that container based daemon runs as a normal user daemon with separate root daemon.
-	// Some users run a standard telepresence client together with intercepts in one
-	// single container.
-	if !(proc.RunningInContainer() && userd.GetService(c).RootSessionInProcess()) {
-		if ic.containerName != "" {
-			if err := docker.StopContainer(docker.EnableClient(c), ic.containerName); err != nil {
-				dlog.Error(c, err)
-			}
-		} else if ic.pid != 0 {
-			p, err := os.FindProcess(ic.pid)
-			if err != nil {
-				dlog.Errorf(c, "unable to find interceptor for intercept %s with pid %d", name, ic.pid)
-			} else {
-				dlog.Debugf(c, "terminating interceptor for intercept %s with pid %d", name, ic.pid)
-				_ = proc.Terminate(p)
-			}
-		}
+	// Detach the interceptor before stopping it, so that it isn't restarted by its restart policy.
+	s.currentInterceptsLock.Lock()
+	pid, containerName := ic.pid, ic.containerName
+	ic.pid, ic.containerName = 0, ""
+	s.currentInterceptsLock.Unlock()
+
+	var stopErr error
+	if err := stopInterceptor(c, name, pid, containerName); err != nil {
+		dlog.Errorf(c, "failed to stop interceptor for intercept %s: %v", name, err)
+		stopErr = &userd.HandlerStopError{Intercept: name, Err: err}
 	}
 
 	// Unmount filesystems before telling the manager to remove the intercept
@@ -722,6 +902,9 @@ func (s *session) removeIntercept(c context.Context, ic *intercept) error {
 		Session: s.SessionInfo(),
 		Name:    name,
 	})
+	if err == nil {
+		err = stopErr
+	}
 	return err
 }
 
@@ -732,16 +915,26 @@ func (s *session) AddInterceptor(id string, ih *rpc.Interceptor) error {
 	if ci, ok := s.currentIntercepts[id]; ok {
 		ci.pid = int(ih.Pid)
 		ci.containerName = ih.ContainerName
+		s.setResumableInterceptorLocked(ci.Spec.Name, ih)
 	}
 	s.currentInterceptsLock.Unlock()
 	return nil
 }
 
+// IsInterceptorAttached returns true if the given interceptor still serves its intercept.
+func (s *session) IsInterceptorAttached(ih *rpc.Interceptor) bool {
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	ci, ok := s.currentIntercepts[ih.InterceptId]
+	return ok && ci.pid == int(ih.Pid) && ci.containerName == ih.ContainerName
+}
+
 func (s *session) RemoveInterceptor(id string) error {
 	s.currentInterceptsLock.Lock()
 	if ci, ok := s.currentIntercepts[id]; ok {
 		ci.pid = 0
 		ci.containerName = ""
+		s.setResumableInterceptorLocked(ci.Spec.Name, nil)
 	}
 	s.currentInterceptsLock.Unlock()
 	return nil
@@ -794,16 +987,34 @@ func (s *session) InterceptsForWorkload(workloadName, namespace string) []*manag
 	return wlis
 }
 
-// ClearIntercepts removes all intercepts.
-func (s *session) ClearIntercepts(c context.Context) error {
-	for _, ic := range s.getCurrentIntercepts() {
-		dlog.Debugf(c, "Clearing intercept %s", ic.Spec.Name)
-		err := s.removeIntercept(c, ic)
-		if err != nil && grpcStatus.Code(err) != grpcCodes.NotFound {
-			return err
+// ClearIntercepts removes all intercepts. The intercept handlers are stopped concurrently, and the ones that
+// failed to stop gracefully are returned.
+func (s *session) ClearIntercepts(c context.Context) ([]*rpc.HandlerStopFailure, error) {
+	ics := s.getCurrentIntercepts()
+	errs := make([]error, len(ics))
+	var wg sync.WaitGroup
+	wg.Add(len(ics))
+	for i, ic := range ics {
+		go func() {
+			defer wg.Done()
+			dlog.Debugf(c, "Clearing intercept %s", ic.Spec.Name)
+			errs[i] = s.removeIntercept(c, ic)
+		}()
+	}
+	wg.Wait()
+
+	var failures []*rpc.HandlerStopFailure
+	for _, err := range errs {
+		var hse *userd.HandlerStopError
+		switch {
+		case err == nil, grpcStatus.Code(err) == grpcCodes.NotFound:
+		case errors.As(err, &hse):
+			failures = append(failures, &rpc.HandlerStopFailure{Intercept: hse.Intercept, Error: hse.Err.Error()})
+		default:
+			return failures, err
 		}
 	}
-	return nil
+	return failures, nil
 }
 
 // reconcileAPIServers start/stop API servers as needed based on the TELEPRESENCE_API_PORT environment variable
@@ -857,7 +1068,11 @@ func (s *session) reconcileAPIServers(ctx context.Context) {
 }
 
 func (s *session) newAPIServerForPort(ctx context.Context, port int) {
-	svr := restapi.NewServer(s)
+	propagations, err := matcher.ParsePropagations(client.GetConfig(ctx).Intercept().Propagation)
+	if err != nil {
+		dlog.Errorf(ctx, "intercept.propagation: %v", err)
+	}
+	svr := restapi.NewServer(s, propagations...)
 	as := apiServer{Server: svr}
 	ctx, as.cancel = context.WithCancel(ctx)
 	if s.currentAPIServers == nil {
@@ -900,8 +1115,15 @@ func (s *session) InterceptInfo(ctx context.Context, callerID, path string, _ ui
 		dlog.Debugf(ctx, "%s: matcher %s\nmatches path %q and headers\n%s", callerID, am.requestMatcher, path, matcher.HeaderStringer(headers))
 		r.Intercepted = true
 		r.Metadata = am.metadata
+		if ic, ok := s.currentIntercepts[callerID]; ok {
+			r.ID = ic.Id
+			r.Client = ic.Spec.Client
+			r.PreviewDomain = ic.PreviewDomain
+		}
+		am.record(path, headers, true)
 	default:
 		dlog.Debugf(ctx, "%s: matcher %s\nmatches path %q and headers\n%s", callerID, am.requestMatcher, path, matcher.HeaderStringer(headers))
+		am.record(path, headers, false)
 	}
 	return r, nil
 }
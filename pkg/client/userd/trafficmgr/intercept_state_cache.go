@@ -0,0 +1,115 @@
+package trafficmgr
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cache"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+)
+
+func interceptStateFile(daemonID *daemon.Identifier) string {
+	return filepath.Join("intercepts", daemonID.InfoFileName())
+}
+
+// SavedIntercept is the persisted state of an intercept that was created by the user daemon. The
+// request and the interceptor are protojson encoded.
+type SavedIntercept struct {
+	Request     json.RawMessage `json:"request"`
+	Interceptor json.RawMessage `json:"interceptor,omitempty"`
+
+	// InterceptorStart is the start time of the process of the interceptor. It tells the process apart from one
+	// that has reused its pid.
+	InterceptorStart int64 `json:"interceptorStart,omitempty"`
+}
+
+// SavedInterceptState is the persisted state of all intercepts that were created by the user daemon
+// in a session. It enables a user daemon that is restarted after a crash or an upgrade to re-adopt
+// or re-create the intercepts, their mounts, and their intercept handlers.
+type SavedInterceptState struct {
+	KubeContext string            `json:"kubeContext"`
+	Namespace   string            `json:"namespace"`
+	SessionID   string            `json:"sessionId"`
+	Intercepts  []*SavedIntercept `json:"intercepts"`
+}
+
+func newSavedIntercept(ri *resumableIntercept) (*SavedIntercept, error) {
+	rq, err := protojson.Marshal(ri.request)
+	if err != nil {
+		return nil, err
+	}
+	si := &SavedIntercept{Request: rq, InterceptorStart: ri.interceptorStart}
+	if ri.interceptor != nil {
+		if si.Interceptor, err = protojson.Marshal(ri.interceptor); err != nil {
+			return nil, err
+		}
+	}
+	return si, nil
+}
+
+func (si *SavedIntercept) resumableIntercept() (*resumableIntercept, error) {
+	ri := &resumableIntercept{request: &rpc.CreateInterceptRequest{}, interceptorStart: si.InterceptorStart}
+	if err := protojson.Unmarshal(si.Request, ri.request); err != nil {
+		return nil, err
+	}
+	if len(si.Interceptor) > 0 {
+		ri.interceptor = &rpc.Interceptor{}
+		if err := protojson.Unmarshal(si.Interceptor, ri.interceptor); err != nil {
+			return nil, err
+		}
+	}
+	return ri, nil
+}
+
+// saveInterceptStateToUserCache saves the given intercepts of the given session to user cache and returns
+// an error if something goes wrong while marshalling or persisting.
+func saveInterceptStateToUserCache(ctx context.Context, daemonID *daemon.Identifier, sessionID string, ris []*resumableIntercept) error {
+	ss := &SavedInterceptState{
+		KubeContext: daemonID.KubeContext,
+		Namespace:   daemonID.Namespace,
+		SessionID:   sessionID,
+		Intercepts:  make([]*SavedIntercept, len(ris)),
+	}
+	for i, ri := range ris {
+		si, err := newSavedIntercept(ri)
+		if err != nil {
+			return err
+		}
+		ss.Intercepts[i] = si
+	}
+	return cache.SaveToUserCache(ctx, ss, interceptStateFile(daemonID), cache.Public)
+}
+
+// loadInterceptStateFromUserCache gets the intercepts and the ID of the session that they were created
+// in from cache, or returns an error if something goes wrong while loading or unmarshalling.
+func loadInterceptStateFromUserCache(ctx context.Context, daemonID *daemon.Identifier) (string, []*resumableIntercept, error) {
+	var ss *SavedInterceptState
+	err := cache.LoadFromUserCache(ctx, &ss, interceptStateFile(daemonID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return "", nil, err
+	}
+	if ss.KubeContext != daemonID.KubeContext || ss.Namespace != daemonID.Namespace {
+		return "", nil, nil
+	}
+	ris := make([]*resumableIntercept, len(ss.Intercepts))
+	for i, si := range ss.Intercepts {
+		if ris[i], err = si.resumableIntercept(); err != nil {
+			return "", nil, err
+		}
+	}
+	return ss.SessionID, ris, nil
+}
+
+// deleteInterceptStateFromUserCache removes the intercept state cache if existing or returns an error. An
+// attempt to remove a non-existing cache is a no-op and the function returns nil.
+func deleteInterceptStateFromUserCache(ctx context.Context, daemonID *daemon.Identifier) error {
+	return cache.DeleteFromUserCache(ctx, interceptStateFile(daemonID))
+}
@@ -0,0 +1,82 @@
+package trafficmgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/common"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func Test_ensureNoInterceptConflict(t *testing.T) {
+	active := &manager.InterceptSpec{Name: "active", TargetHost: "127.0.0.1", TargetPort: 8080}
+	pending := &manager.InterceptSpec{Name: "pending", TargetHost: "127.0.0.1", TargetPort: 8081}
+	s := &session{
+		currentIntercepts: map[string]*intercept{
+			"id-1": {InterceptInfo: &manager.InterceptInfo{Id: "id-1", Spec: active, ClientMountPoint: "/tmp/active"}},
+		},
+		interceptWaiters: map[string]*awaitIntercept{
+			pending.Name: {spec: pending, mountPoint: "/tmp/pending"},
+		},
+	}
+	request := func(name string, port int32, mountPoint string) *rpc.CreateInterceptRequest {
+		return &rpc.CreateInterceptRequest{
+			Spec:       &manager.InterceptSpec{Name: name, TargetHost: "127.0.0.1", TargetPort: port},
+			MountPoint: mountPoint,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		request  *rpc.CreateInterceptRequest
+		expected common.InterceptError
+		conflict string
+	}{
+		{
+			name:     "active name",
+			request:  request("active", 9000, ""),
+			expected: common.InterceptError_ALREADY_EXISTS,
+		},
+		{
+			name:     "pending name",
+			request:  request("pending", 9000, ""),
+			expected: common.InterceptError_ALREADY_EXISTS,
+		},
+		{
+			name:     "active target",
+			request:  request("other", 8080, ""),
+			expected: common.InterceptError_LOCAL_TARGET_IN_USE,
+			conflict: "active",
+		},
+		{
+			name:     "pending target",
+			request:  request("other", 8081, ""),
+			expected: common.InterceptError_LOCAL_TARGET_IN_USE,
+			conflict: "pending",
+		},
+		{
+			name:     "pending mount point",
+			request:  request("other", 9000, "/tmp/pending"),
+			expected: common.InterceptError_MOUNT_POINT_BUSY,
+			conflict: "pending",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.ensureNoInterceptConflict(tt.request)
+			require.NotNil(t, result)
+			assert.Equal(t, tt.expected, result.Error)
+			if tt.conflict != "" {
+				require.NotNil(t, result.InterceptInfo)
+				assert.Equal(t, tt.conflict, result.InterceptInfo.Spec.Name)
+			}
+		})
+	}
+
+	t.Run("no conflict", func(t *testing.T) {
+		assert.Nil(t, s.ensureNoInterceptConflict(request("other", 9000, "/tmp/other")))
+	})
+}
@@ -2,66 +2,304 @@ package trafficmgr
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"sync"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/go-fuseftp/rpc"
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 )
 
 func (ic *intercept) shouldMount() bool {
-	return (ic.FtpPort > 0 || ic.SftpPort > 0) && (ic.localMountPort > 0 || ic.ClientMountPoint != "")
+	return (ic.FtpPort > 0 || ic.SftpPort > 0 || ic.WebdavPort > 0) && (ic.localMountPort > 0 || ic.ClientMountPoint != "")
 }
 
-// startMount starts the mount for the given podInterceptKey.
+// startMount starts the mount for the given podInterceptKey. Mount bridges are started by the mountBridges.
 // It assumes that the user has called shouldMount and is sure that something will be started.
 func (ic *intercept) startMount(ctx context.Context, iceptWG, podWG *sync.WaitGroup) {
 	var fuseftp rpc.FuseFTPClient
-	useFtp := client.GetConfig(ctx).Intercept().UseFtp
+	syncMode := remotefs.IsSyncMode(ic.mountMode)
+	webdavMode := ic.mountMode == remotefs.MountModeWebDAV
+	options := fuseOptions(ctx, ic.mountOptions)
+	useFtp := client.GetConfig(ctx).Intercept().UseFtp && !syncMode && !webdavMode
 	var port int32
 	mountCtx := ctx
 	if useFtp {
-		if ic.FtpPort == 0 {
-			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but only SFTP is provided by the traffic-agent")
-			return
+		var problem string
+		switch {
+		case ic.FtpPort == 0:
+			problem = "only SFTP is provided by the traffic-agent"
+		case len(options) > 0:
+			problem = "only SFTP can be used with FUSE options"
+		case remotefs.IsUNCPath(ic.ClientMountPoint):
+			problem = "only SFTP can be used with a UNC mount point"
+		case len(ic.mountReadOnly) > 0:
+			problem = "only SFTP can be used with read-only rules"
+		default:
+			if fuseftp = userd.GetService(ctx).FuseFTPMgr().GetFuseFTPClient(ctx); fuseftp == nil {
+				problem = "the fuseftp server was unable to start"
+			}
 		}
-		if ic.localMountPort > 0 {
-			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but only SFTP can be used with --local-mount-port")
-			return
+		if problem != "" {
+			if ic.SftpPort == 0 {
+				ic.mountFailed(ctx, fmt.Errorf("client is configured to perform remote mounts using FTP, but %s", problem))
+				return
+			}
+			dlog.Warnf(ctx, "Client is configured to perform remote mounts using FTP, but %s. Falling back to SFTP", problem)
+			useFtp = false
 		}
+	}
+	switch {
+	case useFtp:
 		// The FTP mounter survives multiple starts for the same intercept. It just resets the address
 		mountCtx = ic.ctx
-		if fuseftp = userd.GetService(ctx).FuseFTPMgr().GetFuseFTPClient(ctx); fuseftp == nil {
-			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but the fuseftp server was unable to start")
+		port = ic.FtpPort
+	case webdavMode:
+		if ic.WebdavPort == 0 {
+			ic.mountFailed(ctx, errors.New("remote mount using WebDAV was requested, but the traffic-agent provides no WebDAV server"))
 			return
 		}
-		port = ic.FtpPort
-	} else {
+		port = ic.WebdavPort
+	default:
 		if ic.SftpPort == 0 {
-			dlog.Errorf(ctx, "Client is configured to perform remote mounts using SFTP, but only FTP is provided by the traffic-agent")
+			ic.mountFailed(ctx, errors.New("client is configured to perform remote mounts using SFTP, but only FTP is provided by the traffic-agent"))
 			return
 		}
 		port = ic.SftpPort
 	}
 
+	if ic.ClientMountPoint == remotefs.AutoDriveLetter {
+		dl, err := remotefs.AssignDriveLetter()
+		if err != nil {
+			ic.mountFailed(ctx, err)
+			return
+		}
+		ic.ClientMountPoint = dl
+		context.AfterFunc(ic.ctx, func() {
+			remotefs.ReleaseDriveLetter(dl)
+		})
+	}
+
 	m := ic.Mounter
 	if m == nil {
 		switch {
-		case ic.localMountPort != 0:
-			session := userd.GetSession(ctx)
-			m = remotefs.NewBridgeMounter(session.SessionInfo().SessionId, session.ManagerClient(), uint16(ic.localMountPort))
+		case syncMode:
+			m = remotefs.NewSyncMounter(iceptWG, podWG, ic.mountMode == remotefs.MountModeSyncPull, ic.mountReadOnly)
+		case webdavMode:
+			m = remotefs.NewWebDAVMounter(iceptWG, podWG)
 		case useFtp:
 			m = remotefs.NewFTPMounter(fuseftp, iceptWG)
 		default:
-			m = remotefs.NewSFTPMounter(iceptWG, podWG)
+			m = remotefs.NewSFTPMounter(iceptWG, podWG, options, ic.mountReadOnly)
 		}
 		ic.Mounter = m
 	}
 	err := m.Start(mountCtx, ic.Id, ic.ClientMountPoint, ic.MountPoint, iputil.Parse(ic.PodIp), uint16(port))
-	if err != nil && ctx.Err() == nil {
-		dlog.Error(ctx, err)
+	switch {
+	case err == nil:
+		userd.PublishEvent(ctx, &connector.Event{
+			Type:          connector.Event_MOUNT_READY,
+			Namespace:     ic.Spec.Namespace,
+			InterceptId:   ic.Id,
+			InterceptName: ic.Spec.Name,
+			MountPoint:    ic.ClientMountPoint,
+		})
+	case ctx.Err() == nil:
+		ic.mountFailed(ctx, err)
+	}
+}
+
+// mountFailed logs that the mount of the intercept failed with the given error, and publishes a corresponding event.
+func (ic *intercept) mountFailed(ctx context.Context, err error) {
+	dlog.Errorf(ctx, "%v. %s", err, ic.fetchHint())
+	userd.PublishEvent(ctx, &connector.Event{
+		Type:          connector.Event_MOUNT_FAILED,
+		Namespace:     ic.Spec.Namespace,
+		InterceptId:   ic.Id,
+		InterceptName: ic.Spec.Name,
+		Message:       err.Error(),
+	})
+}
+
+// fuseOptions returns the FUSE options of the intercept.fuse config followed by the given options.
+func fuseOptions(ctx context.Context, options []string) []string {
+	fo := client.GetConfig(ctx).Intercept().Fuse
+	return append(fo.MountOptions(), options...)
+}
+
+// fetchHint returns a hint about how files can be fetched from the remote volumes when they cannot be mounted.
+func (ic *intercept) fetchHint() string {
+	return fmt.Sprintf(`Files can still be fetched using "telepresence fetch %s <path>"`, ic.Spec.Name)
+}
+
+// FetchFiles streams a tar archive with the requested files from the remote volumes of an intercept. It talks
+// directly to the intercepted pod's traffic-agent, and is therefore usable even when no remote mount can be
+// established.
+func (s *session) FetchFiles(ctx context.Context, request *connector.FetchFilesRequest, stream userd.FetchFilesStream) error {
+	ii := s.GetInterceptInfo(request.Name)
+	if ii == nil {
+		return status.Errorf(codes.NotFound, "found no intercept named %s", request.Name)
+	}
+	if ii.MountPoint == "" || ii.PodName == "" || ii.ApiPort == 0 {
+		return status.Errorf(codes.FailedPrecondition, "intercept %s has no remote volumes", request.Name)
+	}
+	conn, ac, _, err := k8sclient.ConnectToAgent(ctx, ii.PodName, ii.Spec.Namespace, uint16(ii.ApiPort))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	fc, err := ac.FetchFiles(ctx, &agent.FetchFilesRequest{MountPoint: ii.MountPoint, Paths: request.Paths})
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := fc.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return err
+		}
+		if err = stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+// PutFiles uploads a tar archive with files to a directory in the remote volumes of an intercept. The first
+// message of the stream names the intercept and the directory. Like FetchFiles, it talks directly to the
+// intercepted pod's traffic-agent.
+func (s *session) PutFiles(ctx context.Context, stream userd.PutFilesStream) (*agent.PutFilesResult, error) {
+	rq, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	ii := s.GetInterceptInfo(rq.Name)
+	if ii == nil {
+		return nil, status.Errorf(codes.NotFound, "found no intercept named %s", rq.Name)
+	}
+	if ii.MountPoint == "" || ii.PodName == "" || ii.ApiPort == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "intercept %s has no remote volumes", rq.Name)
+	}
+	conn, ac, _, err := k8sclient.ConnectToAgent(ctx, ii.PodName, ii.Spec.Namespace, uint16(ii.ApiPort))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	pc, err := ac.PutFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = pc.Send(&agent.PutFilesRequest{MountPoint: ii.MountPoint, Dest: rq.Dest, Data: rq.Data})
+	for err == nil {
+		if rq, err = stream.Recv(); err == nil {
+			err = pc.Send(&agent.PutFilesRequest{Data: rq.Data})
+		}
+	}
+	if !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	result, err := pc.CloseAndRecv()
+	if status.Code(err) == codes.Unimplemented {
+		err = status.Errorf(codes.FailedPrecondition,
+			"the traffic-agent of pod %s.%s is too old to put files into its volumes", ii.PodName, ii.Spec.Namespace)
+	}
+	return result, err
+}
+
+// Mount mounts the volumes of a container in a pod of the given workload without intercepting it. A
+// traffic-agent is injected into the workload if it doesn't have one. The volumes are unmounted when
+// the context is cancelled.
+func (s *session) Mount(ctx context.Context, request *connector.MountRequest, stream userd.MountStream) error {
+	_, err := s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{Session: s.sessionInfo, Name: request.Workload})
+	if err != nil {
+		return err
+	}
+	agents, err := s.workloadAgents(ctx, request.Workload)
+	if err != nil {
+		return err
+	}
+	ai := agents[0]
+	conn, ac, _, err := k8sclient.ConnectToAgent(ctx, ai.PodName, ai.Namespace, uint16(ai.ApiPort))
+	if err != nil {
+		return err
+	}
+	mi, err := ac.Mounts(ctx, &agent.MountsRequest{Container: request.Container})
+	conn.Close()
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			err = status.Errorf(codes.FailedPrecondition,
+				"the traffic-agent of pod %s.%s is too old to mount volumes without an intercept", ai.PodName, ai.Namespace)
+		}
+		return err
+	}
+	if len(mi.Mounts) == 0 {
+		return status.Errorf(codes.FailedPrecondition, "container %s in pod %s.%s has no volumes", mi.Container, ai.PodName, ai.Namespace)
+	}
+
+	mountPoint := request.MountPoint
+	if mountPoint == remotefs.AutoDriveLetter {
+		if mountPoint, err = remotefs.AssignDriveLetter(); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		defer remotefs.ReleaseDriveLetter(mountPoint)
+	}
+
+	var wg sync.WaitGroup
+	var m remotefs.Mounter
+	var port int32
+	options := fuseOptions(ctx, request.MountOptions)
+	useFtp := request.ReadWrite && len(options) == 0 && !remotefs.IsUNCPath(mountPoint) && mi.FtpPort > 0 &&
+		client.GetConfig(ctx).Intercept().UseFtp
+	if useFtp {
+		if fuseftp := userd.GetService(ctx).FuseFTPMgr().GetFuseFTPClient(ctx); fuseftp != nil {
+			m = remotefs.NewFTPMounter(fuseftp, &wg)
+			port = mi.FtpPort
+		}
+	}
+	if m == nil {
+		if mi.SftpPort == 0 {
+			return status.Errorf(codes.FailedPrecondition,
+				"the traffic-agent of pod %s.%s provides no SFTP server", ai.PodName, ai.Namespace)
+		}
+		var rules remotefs.ReadOnlyRules
+		if !request.ReadWrite {
+			rules = remotefs.ReadOnlyRules{"/": true}
+		}
+		m = remotefs.NewSFTPMounter(&wg, &wg, options, rules)
+		port = mi.SftpPort
+	}
+
+	id := request.Workload + "/" + mi.Container
+	if err = m.Start(ctx, id, mountPoint, mi.MountPoint, iputil.Parse(ai.PodIp), uint16(port)); err != nil {
+		return err
+	}
+	defer wg.Wait()
+
+	err = stream.Send(&connector.MountInfo{
+		PodName:    ai.PodName,
+		Container:  mi.Container,
+		MountPoint: mountPoint,
+		Mounts:     mi.Mounts,
+		ReadWrite:  request.ReadWrite,
+	})
+	if err != nil {
+		return err
 	}
+	<-ctx.Done()
+	return nil
 }
@@ -0,0 +1,272 @@
+package trafficmgr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// portForward is a localhost port that is forwarded to a port of a service or pod in the cluster.
+type portForward struct {
+	*connector.PortForward
+	cancel context.CancelFunc
+}
+
+// dispatchFunc relays a connection that was accepted by a port forward to the given destination.
+type dispatchFunc func(ctx context.Context, conn net.Conn, dst netip.AddrPort) error
+
+// portForwards keeps track of the port forwards of a session. The forwards are keyed by local port. They
+// dispatch their connections to the traffic-manager's tunnel, so they reach the cluster without kubectl, and
+// regardless of how the root daemon routes the cluster's subnets.
+type portForwards struct {
+	sync.Mutex
+	forwards map[int32]*portForward
+	dispatch dispatchFunc
+}
+
+// add starts listening on the local ports of the given forwards. No forward is added unless all of them can be
+// started.
+func (pfs *portForwards) add(ctx context.Context, fwds []*connector.PortForward) error {
+	pfs.Lock()
+	defer pfs.Unlock()
+	for _, fwd := range fwds {
+		if _, ok := pfs.forwards[fwd.LocalPort]; ok {
+			return status.Errorf(codes.AlreadyExists, "local port %d is already forwarded", fwd.LocalPort)
+		}
+	}
+	if pfs.forwards == nil {
+		pfs.forwards = make(map[int32]*portForward)
+	}
+	var started []*portForward
+	for _, fwd := range fwds {
+		pf, err := pfs.start(ctx, fwd)
+		if err != nil {
+			for _, pf := range started {
+				pf.cancel()
+				delete(pfs.forwards, pf.LocalPort)
+			}
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		started = append(started, pf)
+		pfs.forwards[fwd.LocalPort] = pf
+	}
+	return nil
+}
+
+func (pfs *portForwards) start(ctx context.Context, fwd *connector.PortForward) (*portForward, error) {
+	ip, err := netip.ParseAddr(fwd.Ip)
+	if err != nil {
+		return nil, err
+	}
+	dst := netip.AddrPortFrom(ip.Unmap(), uint16(fwd.RemotePort))
+	ctx = dgroup.WithGoroutineName(ctx, fmt.Sprintf("/port-forward:%d", fwd.LocalPort))
+	ctx, cancel := context.WithCancel(ctx)
+	lc := &net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", iputil.JoinHostPort("127.0.0.1", uint16(fwd.LocalPort)))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	context.AfterFunc(ctx, func() {
+		_ = l.Close()
+	})
+	dlog.Infof(ctx, "Forwarding %s to %s %s", l.Addr(), fwd.Target, dst)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					dlog.Errorf(ctx, "port forward listener failed: %v", err)
+				}
+				return
+			}
+			go func() {
+				if err := pfs.dispatch(ctx, conn, dst); err != nil {
+					dlog.Error(ctx, err)
+				}
+			}()
+		}
+	}()
+	return &portForward{PortForward: fwd, cancel: cancel}, nil
+}
+
+// remove stops the forwards to the given target in the given namespace, and returns them. All forwards to the
+// target are stopped when no local ports are given.
+func (pfs *portForwards) remove(target, namespace string, localPorts []int32) []*connector.PortForward {
+	pfs.Lock()
+	defer pfs.Unlock()
+	var removed []*connector.PortForward
+	for port, pf := range pfs.forwards {
+		if pf.Target == target && pf.Namespace == namespace && (len(localPorts) == 0 || slices.Contains(localPorts, port)) {
+			pf.cancel()
+			delete(pfs.forwards, port)
+			removed = append(removed, pf.PortForward)
+		}
+	}
+	sortPortForwards(removed)
+	return removed
+}
+
+func (pfs *portForwards) list() []*connector.PortForward {
+	pfs.Lock()
+	defer pfs.Unlock()
+	fwds := make([]*connector.PortForward, 0, len(pfs.forwards))
+	for _, pf := range pfs.forwards {
+		fwds = append(fwds, pf.PortForward)
+	}
+	sortPortForwards(fwds)
+	return fwds
+}
+
+func sortPortForwards(fwds []*connector.PortForward) {
+	slices.SortFunc(fwds, func(a, b *connector.PortForward) int {
+		return int(a.LocalPort - b.LocalPort)
+	})
+}
+
+// parsePortForwardTarget parses a target given as "<name>", "svc/<name>", or "pod/<name>" into a kind and a
+// name. The kind is either "svc" or "pod".
+func parsePortForwardTarget(target string) (kind, name string, err error) {
+	kind, name, ok := strings.Cut(target, "/")
+	if !ok {
+		kind, name = "svc", target
+	}
+	switch kind {
+	case "svc", "service", "services":
+		kind = "svc"
+	case "po", "pod", "pods":
+		kind = "pod"
+	default:
+		return "", "", fmt.Errorf("unsupported port-forward target %q. Use <name>, svc/<name>, or pod/<name>", target)
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("port-forward target %q has no name", target)
+	}
+	return kind, name, nil
+}
+
+// resolvePortForwardTarget resolves the IP of the given service or pod.
+func resolvePortForwardTarget(ctx context.Context, kind, name, namespace string) (string, error) {
+	api := k8sapi.GetK8sInterface(ctx).CoreV1()
+	if kind == "pod" {
+		pod, err := api.Pods(namespace).Get(ctx, name, meta.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if pod.Status.Phase != core.PodRunning || pod.Status.PodIP == "" {
+			return "", fmt.Errorf("pod %s.%s is not running", name, namespace)
+		}
+		return pod.Status.PodIP, nil
+	}
+	svc, err := api.Services(namespace).Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if ip := svc.Spec.ClusterIP; ip != "" && ip != core.ClusterIPNone {
+		return ip, nil
+	}
+	return "", fmt.Errorf("service %s.%s has no cluster IP. Forward to one of its pods instead", name, namespace)
+}
+
+// dispatchToTunnel relays the given connection through a new tunnel to the traffic-manager, which dials the
+// destination.
+func (s *session) dispatchToTunnel(ctx context.Context, conn net.Conn, dst netip.AddrPort) error {
+	src, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		_ = conn.Close()
+		return fmt.Errorf("address %s is not a TCP address", conn.LocalAddr())
+	}
+	id := tunnel.NewConnID(ipproto.TCP, src.IP, dst.Addr().AsSlice(), uint16(src.Port), dst.Port())
+	ms, err := s.managerClient.Tunnel(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to establish tunnel: %v", err)
+	}
+	tos := client.GetConfig(ctx).Timeouts()
+	ctx, cancel := context.WithCancel(ctx)
+	ts, err := tunnel.NewClientStream(ctx, ms, id, s.sessionInfo.SessionId, tos.PrivateRoundtripLatency, tos.PrivateEndpointDial)
+	if err != nil {
+		cancel()
+		_ = conn.Close()
+		return fmt.Errorf("failed to create stream: %v", err)
+	}
+	ep := tunnel.NewConnEndpoint(ts, conn, cancel, nil, nil)
+	ep.Start(ctx)
+	<-ep.Done()
+	return nil
+}
+
+func (s *session) AddPortForwards(ctx context.Context, rq *connector.PortForwardRequest) (*connector.PortForwardList, error) {
+	kind, name, err := parsePortForwardTarget(rq.Target)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(rq.Ports) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no ports to forward")
+	}
+	ns := s.ActualNamespace(rq.Namespace)
+	if ns == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "namespace %s is not accessible", rq.Namespace)
+	}
+	ip, err := resolvePortForwardTarget(ctx, kind, name, ns)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	target := kind + "/" + name
+	fwds := make([]*connector.PortForward, len(rq.Ports))
+	for i, p := range rq.Ports {
+		if p.RemotePort <= 0 || p.RemotePort > 0xffff || p.LocalPort < 0 || p.LocalPort > 0xffff {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid port forward %d:%d", p.LocalPort, p.RemotePort)
+		}
+		lp := p.LocalPort
+		if lp == 0 {
+			lp = p.RemotePort
+		}
+		fwds[i] = &connector.PortForward{LocalPort: lp, RemotePort: p.RemotePort, Target: target, Namespace: ns, Ip: ip}
+	}
+	if err = s.portForwards.add(ctx, fwds); err != nil {
+		return nil, err
+	}
+	return &connector.PortForwardList{Forwards: fwds}, nil
+}
+
+func (s *session) RemovePortForwards(_ context.Context, rq *connector.PortForwardRequest) (*connector.PortForwardList, error) {
+	kind, name, err := parsePortForwardTarget(rq.Target)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ns := rq.Namespace
+	if ns == "" {
+		ns = s.Namespace
+	}
+	localPorts := make([]int32, len(rq.Ports))
+	for i, p := range rq.Ports {
+		localPorts[i] = p.LocalPort
+		if localPorts[i] == 0 {
+			localPorts[i] = p.RemotePort
+		}
+	}
+	return &connector.PortForwardList{Forwards: s.portForwards.remove(kind+"/"+name, ns, localPorts)}, nil
+}
+
+func (s *session) PortForwards() *connector.PortForwardList {
+	return &connector.PortForwardList{Forwards: s.portForwards.list()}
+}
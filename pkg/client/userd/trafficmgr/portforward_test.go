@@ -0,0 +1,93 @@
+package trafficmgr
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func Test_parsePortForwardTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		kind   string
+		name   string
+		err    bool
+	}{
+		{target: "echo", kind: "svc", name: "echo"},
+		{target: "svc/echo", kind: "svc", name: "echo"},
+		{target: "service/echo", kind: "svc", name: "echo"},
+		{target: "pod/echo-0", kind: "pod", name: "echo-0"},
+		{target: "po/echo-0", kind: "pod", name: "echo-0"},
+		{target: "deploy/echo", err: true},
+		{target: "pod/", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			kind, name, err := parsePortForwardTarget(tt.target)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func Test_portForwards(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	// The fake dispatcher writes the destination to the connection instead of relaying it.
+	pfs := portForwards{dispatch: func(_ context.Context, conn net.Conn, dst netip.AddrPort) error {
+		defer conn.Close()
+		_, err := io.WriteString(conn, dst.String())
+		return err
+	}}
+	as, err := client.FreePortsTCP(2)
+	require.NoError(t, err)
+	lp1, lp2 := int32(as[0].Port), int32(as[1].Port)
+
+	fwd := func(lp, rp int32) *connector.PortForward {
+		return &connector.PortForward{LocalPort: lp, RemotePort: rp, Target: "svc/echo", Namespace: "default", Ip: "10.0.0.1"}
+	}
+	require.NoError(t, pfs.add(ctx, []*connector.PortForward{fwd(lp1, 80), fwd(lp2, 443)}))
+	assert.Error(t, pfs.add(ctx, []*connector.PortForward{fwd(lp1, 8080)}), "local port is already forwarded")
+	require.Len(t, pfs.list(), 2)
+
+	read := func(lp int32) (string, error) {
+		conn, err := net.Dial("tcp", iputil.JoinHostPort("127.0.0.1", uint16(lp)))
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		data, err := io.ReadAll(conn)
+		return string(data), err
+	}
+	dst, err := read(lp2)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:443", dst)
+
+	// Only the forward from the given local port is removed.
+	removed := pfs.remove("svc/echo", "default", []int32{lp1})
+	require.Len(t, removed, 1)
+	assert.Equal(t, lp1, removed[0].LocalPort)
+	_, err = read(lp1)
+	assert.Error(t, err)
+	assert.Empty(t, pfs.remove("svc/echo", "other", nil))
+
+	// All forwards to the target are removed when no ports are given.
+	assert.Len(t, pfs.remove("svc/echo", "default", nil), 1)
+	assert.Empty(t, pfs.list())
+}
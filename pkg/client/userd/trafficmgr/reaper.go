@@ -0,0 +1,27 @@
+package trafficmgr
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// reapStaleSessions asks the traffic-manager to remove the sessions, and their intercepts, that this installation
+// and user left behind, e.g. because a previous client crashed and lost its session info. Failures are logged but
+// otherwise ignored.
+func reapStaleSessions(ctx context.Context, mClient manager.ManagerClient, si *manager.SessionInfo) {
+	r, err := mClient.ReapStaleSessions(ctx, si)
+	if err != nil {
+		if status.Code(err) != codes.Unimplemented {
+			dlog.Warnf(ctx, "unable to reap stale sessions: %v", err)
+		}
+		return
+	}
+	if len(r.SessionIds) > 0 {
+		dlog.Infof(ctx, "Removed %d stale session(s) and %d intercept(s) left behind by a previous client", len(r.SessionIds), len(r.InterceptIds))
+	}
+}
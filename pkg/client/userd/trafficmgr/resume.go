@@ -0,0 +1,324 @@
+package trafficmgr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/common"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+// resumableIntercept is an intercept that is re-created when the session is replaced by a new one, or
+// re-adopted when the user daemon is restarted during the session.
+type resumableIntercept struct {
+	// request is the request that created the intercept.
+	request *rpc.CreateInterceptRequest
+
+	// interceptor is the intercept handler of the intercept, if any.
+	interceptor *rpc.Interceptor
+
+	// interceptorStart is the start time of the process of the intercept handler, as returned by
+	// proc.StartTime, or zero when unknown.
+	interceptorStart int64
+}
+
+// addResumable records the request of an intercept that has been successfully created, so that the intercept
+// can be re-created if the session is replaced by a new one.
+func (s *session) addResumable(ir *rpc.CreateInterceptRequest) {
+	s.currentInterceptsLock.Lock()
+	if s.resumable == nil {
+		s.resumable = make(map[string]*resumableIntercept)
+	}
+	s.resumable[ir.Spec.Name] = &resumableIntercept{request: proto.Clone(ir).(*rpc.CreateInterceptRequest)}
+	s.currentInterceptsLock.Unlock()
+	s.signalStateChanged()
+}
+
+// setResumableInterceptorLocked sets the intercept handler of a resumable intercept. The currentInterceptsLock
+// must be held when calling this function.
+func (s *session) setResumableInterceptorLocked(name string, ih *rpc.Interceptor) {
+	if ri, ok := s.resumable[name]; ok {
+		ri.interceptor = ih
+		ri.interceptorStart = 0
+		if ih != nil && ih.Pid != 0 {
+			ri.interceptorStart, _ = proc.StartTime(int(ih.Pid))
+		}
+		s.signalStateChanged()
+	}
+}
+
+// removeResumable removes the resumable intercept with the given name.
+func (s *session) removeResumable(name string) {
+	s.currentInterceptsLock.Lock()
+	delete(s.resumable, name)
+	s.currentInterceptsLock.Unlock()
+	s.signalStateChanged()
+}
+
+// pruneResumable removes the resumable intercepts that aren't present in the given snapshot from the
+// traffic-manager. Such intercepts have been removed by other means than this session, and must not be
+// re-created.
+func (s *session) pruneResumable(iis []*manager.InterceptInfo) {
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	for name := range s.resumable {
+		if !hasIntercept(iis, name) {
+			delete(s.resumable, name)
+			s.signalStateChanged()
+		}
+	}
+}
+
+// resumableIntercepts returns the intercepts that were created by this session, together with their intercept
+// handlers, so that they can be re-created when the session is replaced by a new one. Intercepts that are
+// waiting to be re-adopted or re-created are included.
+func (s *session) resumableIntercepts() []*resumableIntercept {
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	ris := make([]*resumableIntercept, 0, len(s.resumable)+len(s.adoptable)+len(s.restorable))
+	for _, ri := range s.resumable {
+		ris = append(ris, ri)
+	}
+	for _, ri := range s.adoptable {
+		ris = append(ris, ri)
+	}
+	for _, ri := range s.restorable {
+		// An intercept that is being re-created may already be resumable.
+		if _, ok := s.resumable[ri.request.Spec.Name]; !ok {
+			ris = append(ris, ri)
+		}
+	}
+	return ris
+}
+
+// loadInterceptState loads the intercepts that were persisted by a previous user daemon. Intercepts that
+// were created in the current session are re-adopted when they arrive from the traffic-manager. Intercepts
+// that were created in another session are re-created by restoreIntercepts.
+func (s *session) loadInterceptState(ctx context.Context) {
+	sessionID, ris, err := loadInterceptStateFromUserCache(ctx, s.daemonID)
+	if err != nil {
+		dlog.Errorf(ctx, "failed to load intercept state from user cache: %v", err)
+		return
+	}
+	if len(ris) == 0 {
+		return
+	}
+	if sessionID == s.sessionInfo.SessionId {
+		s.adoptable = make(map[string]*resumableIntercept, len(ris))
+		for _, ri := range ris {
+			s.adoptable[ri.request.Spec.Name] = ri
+		}
+		dlog.Infof(ctx, "re-adopting %d intercepts of session %s", len(ris), sessionID)
+	} else {
+		s.restorable = ris
+		s.restorableSessionID = sessionID
+		dlog.Infof(ctx, "re-creating %d intercepts of expired session %s", len(ris), sessionID)
+	}
+}
+
+// adoptLocked re-adopts the given intercept, that was created by a previous user daemon during the current
+// session, by restoring its mount and its intercept handler. The currentInterceptsLock must be held when
+// calling this function.
+func (s *session) adoptLocked(ctx context.Context, ic *intercept) {
+	name := ic.Spec.Name
+	ri, ok := s.adoptable[name]
+	if !ok {
+		return
+	}
+	delete(s.adoptable, name)
+	ic.ClientMountPoint = ri.request.MountPoint
+	ic.localMountPort = ri.request.LocalMountPort
+	ic.mountMode = ri.request.MountMode
+	ic.mountOptions = ri.request.MountOptions
+	ic.mountReadOnly = ri.request.MountReadOnly
+	if ih := ri.interceptor; ih != nil {
+		if ri.interceptorIsAlive() {
+			ic.pid = int(ih.Pid)
+			ic.containerName = ih.ContainerName
+		} else {
+			ri.interceptor = nil
+		}
+	}
+	if s.resumable == nil {
+		s.resumable = make(map[string]*resumableIntercept)
+	}
+	s.resumable[name] = ri
+	s.signalStateChanged()
+	dlog.Infof(ctx, "intercept %s re-adopted", name)
+}
+
+// dropUnadopted terminates the intercept handlers of the adoptable intercepts that weren't present in the first
+// snapshot from the traffic-manager. Those intercepts have ended while no user daemon was running.
+func (s *session) dropUnadopted(ctx context.Context) {
+	s.currentInterceptsLock.Lock()
+	ris := s.adoptable
+	s.adoptable = nil
+	s.currentInterceptsLock.Unlock()
+	for name, ri := range ris {
+		dlog.Infof(ctx, "intercept %s ended while the user daemon was down", name)
+		if ih := ri.interceptor; ih != nil && ri.interceptorIsAlive() {
+			if err := stopInterceptor(ctx, name, int(ih.Pid), ih.ContainerName); err != nil {
+				dlog.Errorf(ctx, "failed to stop interceptor for intercept %s: %v", name, err)
+			}
+		}
+	}
+	if len(ris) > 0 {
+		s.signalStateChanged()
+	}
+}
+
+// restoreIntercepts re-creates the intercepts that were created in another session, and re-attaches their
+// intercept handlers. The intercept handler of an intercept that can't be re-created is terminated.
+func (s *session) restoreIntercepts(ctx context.Context) error {
+	s.currentInterceptsLock.Lock()
+	ris := s.restorable
+	oldSessionID := s.restorableSessionID
+	s.currentInterceptsLock.Unlock()
+	if len(ris) == 0 {
+		return nil
+	}
+
+	// The traffic-manager may still hold the other session and its intercepts, e.g. when this session replaced
+	// one that couldn't reach the traffic-manager for longer than the resume timeout. The other session must be
+	// gone before its intercepts are re-created, or they will conflict.
+	if err := s.departSession(ctx, oldSessionID); err != nil {
+		dlog.Errorf(ctx, "unable to depart from session %s, its intercepts may conflict with the re-created ones: %v", oldSessionID, err)
+	}
+	for _, ri := range ris {
+		name := ri.request.Spec.Name
+		result := s.self.AddIntercept(ctx, ri.request)
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.currentInterceptsLock.Lock()
+		s.restorable = s.restorable[1:]
+		s.currentInterceptsLock.Unlock()
+
+		ih := ri.interceptor
+		if ih != nil && !ri.interceptorIsAlive() {
+			ih = nil
+		}
+		if result.Error != common.InterceptError_UNSPECIFIED {
+			dlog.Errorf(ctx, "unable to re-create intercept %s: %s: %s", name, result.Error, result.ErrorText)
+			if ih != nil {
+				if err := stopInterceptor(ctx, name, int(ih.Pid), ih.ContainerName); err != nil {
+					dlog.Errorf(ctx, "failed to stop interceptor for intercept %s: %v", name, err)
+				}
+			}
+			s.signalStateChanged()
+			continue
+		}
+		if ih != nil {
+			_ = s.self.AddInterceptor(result.InterceptInfo.Id, ih)
+		}
+		dlog.Infof(ctx, "intercept %s re-created", name)
+	}
+	return nil
+}
+
+// departSession departs from the session with the given ID, and waits until the traffic-manager confirms that the
+// session is gone.
+func (s *session) departSession(ctx context.Context, sessionID string) error {
+	ctx, cancel := client.GetConfig(ctx).Timeouts().TimeoutContext(ctx, client.TimeoutTrafficManagerAPI)
+	defer cancel()
+	si := &manager.SessionInfo{SessionId: sessionID}
+	mc := s.self.ManagerClient()
+	if _, err := mc.Depart(ctx, si); err != nil {
+		return client.CheckTimeout(ctx, err)
+	}
+
+	// The traffic-manager removes the session asynchronously.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		_, err := mc.Remain(ctx, &manager.RemainRequest{Session: si})
+		switch status.Code(err) {
+		case codes.NotFound:
+			dlog.Infof(ctx, "session %s is gone from the traffic-manager", sessionID)
+			return nil
+		case codes.OK:
+		default:
+			return client.CheckTimeout(ctx, err)
+		}
+		select {
+		case <-ctx.Done():
+			return client.CheckTimeout(ctx, fmt.Errorf("session %s is still present in the traffic-manager: %w", sessionID, ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
+
+// signalStateChanged tells the persistStateLoop that the intercept state has changed.
+func (s *session) signalStateChanged() {
+	select {
+	case s.stateChanged <- struct{}{}:
+	default:
+	}
+}
+
+// persistStateLoop persists the intercept state to the user cache each time it changes, so that a user daemon
+// that is restarted after a crash or an upgrade can re-adopt or re-create the intercepts.
+func (s *session) persistStateLoop(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			// Persist the final state. It's empty if the session ended because the intercepts were cleared.
+			s.persistState(context.WithoutCancel(ctx))
+			return nil
+		case <-s.stateChanged:
+			s.persistState(ctx)
+		}
+	}
+}
+
+func (s *session) persistState(ctx context.Context) {
+	var err error
+	if ris := s.resumableIntercepts(); len(ris) > 0 {
+		err = saveInterceptStateToUserCache(ctx, s.daemonID, s.sessionInfo.SessionId, ris)
+	} else {
+		err = deleteInterceptStateFromUserCache(ctx, s.daemonID)
+	}
+	if err != nil {
+		dlog.Errorf(ctx, "failed to persist intercept state to user cache: %v", err)
+	}
+}
+
+// interceptorIsAlive returns false if the process of the intercept handler no longer exists, or if its pid now
+// belongs to another process, i.e. one that was started at another time. An intercept handler that runs in a
+// container is assumed to be alive.
+func (ri *resumableIntercept) interceptorIsAlive() bool {
+	ih := ri.interceptor
+	if ih == nil {
+		return false
+	}
+	if ih.ContainerName != "" {
+		return true
+	}
+	if ih.Pid == 0 || !proc.IsAlive(int(ih.Pid)) {
+		return false
+	}
+	if ri.interceptorStart == 0 {
+		// The start time wasn't known when the intercept handler was attached.
+		return true
+	}
+	st, err := proc.StartTime(int(ih.Pid))
+	return err == nil && st == ri.interceptorStart
+}
+
+func hasIntercept(iis []*manager.InterceptInfo, name string) bool {
+	for _, ii := range iis {
+		if ii.Spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package trafficmgr
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/filelocation"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+func testSpec(name string) *manager.InterceptSpec {
+	return &manager.InterceptSpec{Name: name, TargetHost: "127.0.0.1", TargetPort: 8080}
+}
+
+func Test_resumableIntercepts(t *testing.T) {
+	s := &session{currentIntercepts: map[string]*intercept{
+		"id-1": {InterceptInfo: &manager.InterceptInfo{Id: "id-1", Spec: testSpec("one")}},
+	}}
+	ir := &rpc.CreateInterceptRequest{Spec: testSpec("one"), MountPoint: "/tmp/one"}
+	s.addResumable(ir)
+	s.addResumable(&rpc.CreateInterceptRequest{Spec: testSpec("two")})
+
+	// The recorded request must not be affected by later changes to the original.
+	ir.MountPoint = "/tmp/other"
+	require.NoError(t, s.AddInterceptor("id-1", &rpc.Interceptor{Pid: 1234}))
+
+	ris := s.resumableIntercepts()
+	require.Len(t, ris, 2)
+	for _, ri := range ris {
+		if ri.request.Spec.Name == "one" {
+			assert.Equal(t, "/tmp/one", ri.request.MountPoint)
+			require.NotNil(t, ri.interceptor)
+			assert.Equal(t, int32(1234), ri.interceptor.Pid)
+		} else {
+			assert.Nil(t, ri.interceptor)
+		}
+	}
+
+	// Intercepts that are removed by other means than this session are not resumed.
+	s.pruneResumable([]*manager.InterceptInfo{{Id: "id-1", Spec: testSpec("one")}})
+	ris = s.resumableIntercepts()
+	require.Len(t, ris, 1)
+	assert.Equal(t, "one", ris[0].request.Spec.Name)
+
+	s.removeResumable("one")
+	assert.Empty(t, s.resumableIntercepts())
+}
+
+func Test_interceptorIsAlive(t *testing.T) {
+	pid := os.Getpid()
+	start, err := proc.StartTime(pid)
+	require.NoError(t, err)
+	require.NotZero(t, start)
+
+	s := &session{currentIntercepts: map[string]*intercept{
+		"id-1": {InterceptInfo: &manager.InterceptInfo{Id: "id-1", Spec: testSpec("one")}},
+	}}
+	s.addResumable(&rpc.CreateInterceptRequest{Spec: testSpec("one")})
+	require.NoError(t, s.AddInterceptor("id-1", &rpc.Interceptor{Pid: int32(pid)}))
+	ri := s.resumable["one"]
+	assert.Equal(t, start, ri.interceptorStart)
+	assert.True(t, ri.interceptorIsAlive())
+
+	// A process that reuses the pid is not the intercept handler.
+	ri.interceptorStart = start + 1
+	assert.False(t, ri.interceptorIsAlive())
+
+	// State that was persisted without a start time relies on the pid alone.
+	ri.interceptorStart = 0
+	assert.True(t, ri.interceptorIsAlive())
+
+	ri.interceptor = &rpc.Interceptor{ContainerName: "handler"}
+	assert.True(t, ri.interceptorIsAlive())
+	ri.interceptor = &rpc.Interceptor{}
+	assert.False(t, ri.interceptorIsAlive())
+}
+
+func Test_interceptState(t *testing.T) {
+	ctx := filelocation.WithAppUserCacheDir(dlog.NewTestContext(t, false), t.TempDir())
+	daemonID, err := daemon.NewIdentifier("", "kube", "default", false)
+	require.NoError(t, err)
+
+	newSession := func(sessionID string) *session {
+		s := &session{
+			daemonID:     daemonID,
+			sessionInfo:  &manager.SessionInfo{SessionId: sessionID},
+			stateChanged: make(chan struct{}, 1),
+		}
+		s.self = s
+		return s
+	}
+
+	// The state is persisted when the session ends.
+	s := newSession("session-1")
+	s.addResumable(&rpc.CreateInterceptRequest{Spec: testSpec("one"), MountPoint: "/tmp/one", LocalMountPort: 2222})
+	s.addResumable(&rpc.CreateInterceptRequest{Spec: testSpec("two")})
+	s.currentInterceptsLock.Lock()
+	s.resumable["two"].interceptor = &rpc.Interceptor{ContainerName: "handler"}
+	s.currentInterceptsLock.Unlock()
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, s.persistStateLoop(runCtx))
+
+	// A restarted user daemon that reuses the session re-adopts the intercepts as they arrive.
+	s = newSession("session-1")
+	s.loadInterceptState(ctx)
+	require.Len(t, s.adoptable, 2)
+	assert.Empty(t, s.restorable)
+	ic := &intercept{InterceptInfo: &manager.InterceptInfo{Id: "id-2", Spec: testSpec("two")}}
+	s.currentInterceptsLock.Lock()
+	s.adoptLocked(ctx, ic)
+	s.currentInterceptsLock.Unlock()
+	assert.Equal(t, "handler", ic.containerName)
+	require.Len(t, s.resumable, 1)
+	require.Len(t, s.resumableIntercepts(), 2)
+
+	// Intercepts that didn't arrive are dropped.
+	s.dropUnadopted(ctx)
+	assert.Empty(t, s.adoptable)
+	ris := s.resumableIntercepts()
+	require.Len(t, ris, 1)
+	assert.Equal(t, "two", ris[0].request.Spec.Name)
+
+	// A user daemon with a new session re-creates the intercepts.
+	s = newSession("session-1")
+	s.addResumable(&rpc.CreateInterceptRequest{Spec: testSpec("one"), MountPoint: "/tmp/one", LocalMountPort: 2222})
+	s.persistState(ctx)
+	s = newSession("session-2")
+	s.loadInterceptState(ctx)
+	assert.Empty(t, s.adoptable)
+	require.Len(t, s.restorable, 1)
+	assert.Equal(t, "session-1", s.restorableSessionID)
+	assert.Equal(t, "/tmp/one", s.restorable[0].request.MountPoint)
+	assert.Equal(t, int32(2222), s.restorable[0].request.LocalMountPort)
+
+	// Nothing is left behind when all intercepts are removed.
+	s = newSession("session-2")
+	s.persistState(ctx)
+	s = newSession("session-3")
+	s.loadInterceptState(ctx)
+	assert.Empty(t, s.adoptable)
+	assert.Empty(t, s.restorable)
+}
+
+// departingManager is a traffic-manager that holds one session, which it removes some time after a Depart.
+type departingManager struct {
+	manager.ManagerClient
+	sessionID string
+	remains   atomic.Int32
+	departed  atomic.Bool
+}
+
+func (m *departingManager) Depart(_ context.Context, si *manager.SessionInfo, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	if si.SessionId == m.sessionID {
+		m.departed.Store(true)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (m *departingManager) Remain(_ context.Context, rr *manager.RemainRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	// The session is still present during the first two calls after the Depart.
+	if rr.Session.SessionId != m.sessionID || m.departed.Load() && m.remains.Add(1) > 2 {
+		return nil, status.Error(codes.NotFound, "no such session")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func Test_departSession(t *testing.T) {
+	ctx := client.WithConfig(dlog.NewTestContext(t, false), client.GetDefaultConfig())
+	mc := &departingManager{sessionID: "session-1"}
+	s := &session{managerClient: mc}
+	s.self = s
+
+	require.NoError(t, s.departSession(ctx, "session-1"))
+	assert.True(t, mc.departed.Load())
+	assert.Equal(t, int32(3), mc.remains.Load())
+
+	// A session that has already expired is confirmed to be gone.
+	require.NoError(t, s.departSession(ctx, "session-0"))
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	authGrpc "github.com/telepresenceio/telepresence/v2/pkg/authenticator/grpc"
 	"github.com/telepresenceio/telepresence/v2/pkg/authenticator/patcher"
+	"github.com/telepresenceio/telepresence/v2/pkg/capability"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
@@ -59,6 +60,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
 )
 
@@ -70,6 +72,11 @@ type apiServer struct {
 type apiMatcher struct {
 	requestMatcher matcher.Request
 	metadata       map[string]string
+
+	// matched, unmatched, and recent are shown in the dashboard.
+	matched   int
+	unmatched int
+	recent    []*matchedRequest
 }
 
 type workloadInfoKey struct {
@@ -78,10 +85,10 @@ type workloadInfoKey struct {
 }
 
 type workloadInfo struct {
-	uid              types.UID
-	state            workload.State
-	agentState       manager.WorkloadInfo_AgentState
-	interceptClients []string
+	uid        types.UID
+	state      workload.State
+	agentState manager.WorkloadInfo_AgentState
+	intercepts []*manager.WorkloadInfo_Intercept
 }
 
 type session struct {
@@ -105,6 +112,9 @@ type session struct {
 	// version reported by the manager
 	managerVersion semver.Version
 
+	// managerCapabilities are the names of the optional features that the traffic-manager supports
+	managerCapabilities []string
+
 	// The identifier for this daemon
 	daemonID *daemon.Identifier
 
@@ -118,7 +128,7 @@ type session struct {
 	workloadSubscribers map[uuid.UUID]chan struct{}
 
 	// currentInterceptsLock ensures that all accesses to currentIntercepts, currentMatchers,
-	// currentAPIServers, interceptWaiters, and ingressInfo are synchronized
+	// currentAPIServers, interceptWaiters, resumable, ingressInfo, and subnetViaWorkloads are synchronized
 	//
 	currentInterceptsLock sync.Mutex
 
@@ -138,10 +148,31 @@ type session struct {
 	// are deleted as soon as the intercept arrives and gets stored in currentIntercepts
 	interceptWaiters map[string]*awaitIntercept
 
+	// resumable contains the intercepts that are re-created if the session is replaced by a new
+	// one. Keyed by intercept name.
+	resumable map[string]*resumableIntercept
+
+	// adoptable contains the intercepts that a previous user daemon created in this session, and that
+	// are re-adopted when they arrive from the traffic-manager. Keyed by intercept name.
+	adoptable map[string]*resumableIntercept
+
+	// restorable contains the intercepts that a previous user daemon created in another session, and
+	// that are re-created in this session.
+	restorable []*resumableIntercept
+
+	// restorableSessionID is the ID of the session that the restorable intercepts were created in.
+	restorableSessionID string
+
+	// stateChanged is signalled when the intercept state that is persisted in the user cache changes.
+	stateChanged chan struct{}
+
 	ingressInfo []*manager.IngressInfo
 
 	isPodDaemon bool
 
+	// portForwards are the localhost ports that are forwarded to services and pods in the cluster.
+	portForwards portForwards
+
 	// done is closed when the session ends
 	done chan struct{}
 
@@ -339,6 +370,10 @@ func (s *session) ManagerVersion() semver.Version {
 	return s.managerVersion
 }
 
+func (s *session) ManagerCapabilities() []string {
+	return s.managerCapabilities
+}
+
 // connectMgr returns a session for the given cluster that is connected to the traffic-manager.
 func connectMgr(
 	ctx context.Context,
@@ -412,6 +447,8 @@ func connectMgr(
 			InstallId: installID,
 			Product:   "telepresence",
 			Version:   client.Version(),
+
+			TunnelCompressions: client.GetConfig(ctx).Grpc().TunnelCompression,
 		})
 		if err != nil {
 			return nil, client.CheckTimeout(ctx, fmt.Errorf("manager.ArriveAsClient: %w", err))
@@ -419,6 +456,7 @@ func connectMgr(
 		if err = SaveSessionInfoToUserCache(ctx, daemonID, si); err != nil {
 			return nil, err
 		}
+		reapStaleSessions(ctx, mClient, si)
 	}
 
 	var opts []grpc.CallOption
@@ -426,6 +464,10 @@ func connectMgr(
 	if mz := cfg.Grpc().MaxReceiveSize(); mz > 0 {
 		opts = append(opts, grpc.MaxCallRecvMsgSize(int(mz)))
 	}
+	if si.TunnelCompression != "" {
+		dlog.Infof(ctx, "Using %s compression for tunnels to the traffic-manager", si.TunnelCompression)
+	}
+	mClient = tunnel.CompressingManagerClient(mClient, si.TunnelCompression)
 	svc.SetManagerClient(mClient, opts...)
 
 	managerName := vi.Name
@@ -434,23 +476,37 @@ func connectMgr(
 		managerName = "Traffic Manager"
 	}
 
+	var capabilities []string
+	if mc, err := mClient.GetCapabilities(ctx, &empty.Empty{}); err == nil {
+		capabilities = mc.Features
+	} else {
+		if status.Code(err) != codes.Unimplemented {
+			dlog.Errorf(ctx, "unable to get traffic-manager capabilities: %v", err)
+		}
+		capabilities = capability.FromVersion(managerVersion)
+	}
+
 	sess := &session{
-		Cluster:            cluster,
-		installID:          installID,
-		daemonID:           daemonID,
-		clientID:           clientID,
-		managerClient:      mClient,
-		managerConn:        conn,
-		managerName:        managerName,
-		managerVersion:     managerVersion,
-		sessionInfo:        si,
-		workloads:          make(map[string]map[workloadInfoKey]workloadInfo),
-		interceptWaiters:   make(map[string]*awaitIntercept),
-		isPodDaemon:        cr.IsPodDaemon,
-		done:               make(chan struct{}),
-		subnetViaWorkloads: cr.SubnetViaWorkloads,
+		Cluster:             cluster,
+		installID:           installID,
+		daemonID:            daemonID,
+		clientID:            clientID,
+		managerClient:       mClient,
+		managerConn:         conn,
+		managerName:         managerName,
+		managerVersion:      managerVersion,
+		managerCapabilities: capabilities,
+		sessionInfo:         si,
+		workloads:           make(map[string]map[workloadInfoKey]workloadInfo),
+		interceptWaiters:    make(map[string]*awaitIntercept),
+		isPodDaemon:         cr.IsPodDaemon,
+		done:                make(chan struct{}),
+		stateChanged:        make(chan struct{}, 1),
+		subnetViaWorkloads:  cr.SubnetViaWorkloads,
 	}
 	sess.self = sess
+	sess.portForwards.dispatch = sess.dispatchToTunnel
+	sess.loadInterceptState(ctx)
 	return sess, nil
 }
 
@@ -464,9 +520,14 @@ func (s *session) Remain(ctx context.Context) error {
 	defer cancel()
 	_, err := self.ManagerClient().Remain(ctx, self.NewRemainRequest())
 	if err != nil {
-		if status.Code(err) == codes.NotFound || status.Code(err) == codes.Unavailable {
+		switch status.Code(err) {
+		case codes.NotFound:
 			// The session has expired. We need to cancel the owner session and reconnect.
 			return ErrSessionExpired
+		case codes.Unavailable, codes.DeadlineExceeded:
+			// The traffic-manager can't be reached, typically because the workstation has been asleep
+			// or switched networks.
+			return fmt.Errorf("%w: %w", ErrManagerUnavailable, client.CheckTimeout(ctx, err))
 		}
 		dlog.Errorf(ctx, "error calling Remain: %v", client.CheckTimeout(ctx, err))
 	}
@@ -535,6 +596,11 @@ func (s *session) StartServices(g *dgroup.Group) {
 	g.Go("remain", s.remainLoop)
 	g.Go("intercept-port-forward", s.watchInterceptsHandler)
 	g.Go("dial-request-watcher", s.dialRequestWatcher)
+	g.Go("agent-events", s.agentEventsWatcher)
+	g.Go("agent-progress", s.agentProgressWatcher)
+	g.Go("persist-state", s.persistStateLoop)
+	g.Go("restore-intercepts", s.restoreIntercepts)
+	g.Go("dashboard", s.serveDashboard)
 }
 
 func runWithRetry(ctx context.Context, f func(context.Context) error) error {
@@ -580,6 +646,7 @@ func (s *session) getInfosForWorkloads(
 	iMap map[string][]*manager.InterceptInfo,
 	sMap map[string]*rpc.WorkloadInfo_Sidecar,
 	filter rpc.ListRequest_Filter,
+	includeOthers bool,
 ) []*rpc.WorkloadInfo {
 	wiMap := make(map[string]*rpc.WorkloadInfo)
 	s.eachWorkload(namespaces, func(wlKind manager.WorkloadInfo_Kind, name, namespace string, info workloadInfo) {
@@ -594,8 +661,15 @@ func (s *session) getInfosForWorkloads(
 			wlInfo.NotInterceptableReason = info.state.String()
 		}
 
+		if includeOthers {
+			for _, ic := range info.intercepts {
+				if !ic.Own {
+					wlInfo.OtherIntercepts = append(wlInfo.OtherIntercepts, ic)
+				}
+			}
+		}
 		var ok bool
-		if wlInfo.InterceptInfos, ok = iMap[name]; !ok && filter <= rpc.ListRequest_INTERCEPTS {
+		if wlInfo.InterceptInfos, ok = iMap[name]; !ok && len(wlInfo.OtherIntercepts) == 0 && filter <= rpc.ListRequest_INTERCEPTS {
 			return
 		}
 		if wlInfo.Sidecar, ok = sMap[name]; !ok && filter <= rpc.ListRequest_INSTALLED_AGENTS {
@@ -615,7 +689,7 @@ func (s *session) getInfosForWorkloads(
 
 func (s *session) WatchWorkloads(c context.Context, wr *rpc.WatchWorkloadsRequest, stream userd.WatchWorkloadsStream) error {
 	id := uuid.New()
-	ch := make(chan struct{})
+	ch := make(chan struct{}, 1)
 	s.workloadsLock.Lock()
 	if s.workloadSubscribers == nil {
 		s.workloadSubscribers = make(map[uuid.UUID]chan struct{})
@@ -629,11 +703,13 @@ func (s *session) WatchWorkloads(c context.Context, wr *rpc.WatchWorkloadsReques
 		s.workloadsLock.Unlock()
 	}()
 
+	var lastSend time.Time
 	send := func() error {
-		ws, err := s.WorkloadInfoSnapshot(c, wr.Namespaces, rpc.ListRequest_EVERYTHING)
+		ws, err := s.WorkloadInfoSnapshot(c, wr.Namespaces, rpc.ListRequest_EVERYTHING, wr.IncludeOthers)
 		if err != nil {
 			return err
 		}
+		lastSend = time.Now()
 		return stream.Send(ws)
 	}
 
@@ -641,11 +717,20 @@ func (s *session) WatchWorkloads(c context.Context, wr *rpc.WatchWorkloadsReques
 	if err := send(); err != nil {
 		return err
 	}
+	interval := wr.MinInterval.AsDuration()
 	for {
 		select {
 		case <-c.Done():
 			return nil
 		case <-ch:
+			if d := time.Until(lastSend.Add(interval)); d > 0 {
+				// Coalesce the changes that arrive within the interval into one snapshot.
+				select {
+				case <-c.Done():
+					return nil
+				case <-time.After(d):
+				}
+			}
 			if err := send(); err != nil {
 				return err
 			}
@@ -692,6 +777,7 @@ func (s *session) WorkloadInfoSnapshot(
 	ctx context.Context,
 	namespaces []string,
 	filter rpc.ListRequest_Filter,
+	includeOthers bool,
 ) (*rpc.WorkloadInfoSnapshot, error) {
 	is := s.getCurrentIntercepts()
 
@@ -737,14 +823,18 @@ nextIs:
 		}
 	}
 
-	workloadInfos := s.getInfosForWorkloads(nss, iMap, sMap, filter)
+	workloadInfos := s.getInfosForWorkloads(nss, iMap, sMap, filter, includeOthers)
 	return &rpc.WorkloadInfoSnapshot{Workloads: workloadInfos}, nil
 }
 
-var ErrSessionExpired = errors.New("session expired")
+var (
+	ErrSessionExpired     = errors.New("session expired")
+	ErrManagerUnavailable = errors.New("traffic-manager unavailable")
+)
 
 func (s *session) remainLoop(c context.Context) error {
-	ticker := time.NewTicker(5 * time.Second)
+	const remainInterval = 5 * time.Second
+	ticker := time.NewTicker(remainInterval)
 	defer func() {
 		ticker.Stop()
 		c = dcontext.WithoutCancel(c)
@@ -761,12 +851,39 @@ func (s *session) remainLoop(c context.Context) error {
 		s.managerConn.Close()
 	}()
 
+	// The session is kept while the traffic-manager is unavailable, so that it can be resumed when the
+	// workstation wakes up from sleep or has switched networks. The traffic-manager retains the session
+	// and its intercepts for the duration of its client.connectionTTL.
+	var unavailableSince time.Time
 	for {
 		select {
 		case <-c.Done():
 			return nil
 		case <-ticker.C:
-			if err := s.self.Remain(c); err != nil {
+			err := s.self.Remain(c)
+			switch {
+			case err == nil:
+				if !unavailableSince.IsZero() {
+					dlog.Infof(c, "traffic-manager is available again after %s, session resumed", time.Since(unavailableSince).Round(time.Second))
+					unavailableSince = time.Time{}
+					ticker.Reset(remainInterval)
+				}
+			case errors.Is(err, ErrManagerUnavailable):
+				// A traffic-manager that is shutting down tells when its replacement is expected to
+				// have taken over.
+				delay := remainInterval
+				if rd, ok := k8sclient.ReconnectDelay(err); ok && rd > 0 {
+					delay = rd
+				}
+				ticker.Reset(delay)
+				if unavailableSince.IsZero() {
+					dlog.Warnf(c, "%v, waiting for it to become available", err)
+					unavailableSince = time.Now()
+				} else if time.Since(unavailableSince) > client.GetConfig(c).Timeouts().Get(client.TimeoutSessionResume) {
+					dlog.Errorf(c, "%v for more than %s, the session will be replaced", err, time.Since(unavailableSince).Round(time.Second))
+					return ErrSessionExpired
+				}
+			default:
 				return err
 			}
 		}
@@ -822,7 +939,9 @@ func (s *session) UpdateStatus(c context.Context, cri userd.ConnectRequest) *rpc
 		s.ingressInfo = nil
 		s.currentInterceptsLock.Unlock()
 	}
+	s.currentInterceptsLock.Lock()
 	s.subnetViaWorkloads = cr.SubnetViaWorkloads
+	s.currentInterceptsLock.Unlock()
 	return s.Status(c)
 }
 
@@ -832,6 +951,9 @@ func (s *session) Status(c context.Context) *rpc.ConnectInfo {
 
 func (s *session) status(c context.Context, initial bool) *rpc.ConnectInfo {
 	cfg := s.Kubeconfig
+	s.currentInterceptsLock.Lock()
+	svw := s.subnetViaWorkloads
+	s.currentInterceptsLock.Unlock()
 	ret := &rpc.ConnectInfo{
 		ClusterContext:   cfg.Context,
 		ClusterServer:    cfg.Server,
@@ -847,7 +969,7 @@ func (s *session) status(c context.Context, initial bool) *rpc.ConnectInfo {
 			Version: "v" + s.managerVersion.String(),
 		},
 		ManagerNamespace:   k8s.GetManagerNamespace(c),
-		SubnetViaWorkloads: s.subnetViaWorkloads,
+		SubnetViaWorkloads: svw,
 		Version: &common.VersionInfo{
 			ApiVersion: client.APIVersion,
 			Version:    client.Version(),
@@ -932,7 +1054,7 @@ func (s *session) Uninstall(ctx context.Context, ur *rpc.UninstallRequest) (*com
 		return nil, status.Error(codes.InvalidArgument, "invalid uninstall request")
 	}
 
-	_ = s.ClearIntercepts(ctx)
+	_, _ = s.ClearIntercepts(ctx)
 	clearAgentsConfigMap := func(ns string) error {
 		cm, err := loadAgentConfigMap(ns)
 		if err != nil {
@@ -1079,6 +1201,8 @@ func rpcKind(s string) manager.WorkloadInfo_Kind {
 		return manager.WorkloadInfo_STATEFULSET
 	case "rollout":
 		return manager.WorkloadInfo_ROLLOUT
+	case "daemonset":
+		return manager.WorkloadInfo_DAEMONSET
 	default:
 		return manager.WorkloadInfo_UNSPECIFIED
 	}
@@ -1124,6 +1248,9 @@ func (s *session) localWorkloadsWatcher(ctx context.Context, namespace string, s
 		case manager.WorkloadInfo_STATEFULSET:
 			enabledWorkloadKinds[i] = workload.StatefulSetWorkloadKind
 			workload.StartStatefulSets(ctx, namespace)
+		case manager.WorkloadInfo_DAEMONSET:
+			enabledWorkloadKinds[i] = workload.DaemonSetWorkloadKind
+			workload.StartDaemonSets(ctx, namespace)
 		case manager.WorkloadInfo_ROLLOUT:
 			enabledWorkloadKinds[i] = workload.RolloutWorkloadKind
 			workload.StartRollouts(ctx, namespace)
@@ -1197,7 +1324,22 @@ func (s *session) workloadsWatcher(ctx context.Context, namespace string, synced
 	for ctx.Err() == nil {
 		wls, err := wlc.Recv()
 		if err != nil {
-			return err
+			// The traffic-manager rejects the watch before sending anything when the session has exceeded its rate
+			// limit. The watch is then started again once the delay that the traffic-manager asks for has passed.
+			delay, ok := k8sclient.RetryDelay(err)
+			if !ok {
+				return err
+			}
+			dlog.Debugf(ctx, "WatchWorkloads for namespace %s was throttled, retrying in %s", namespace, delay)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			if wlc, err = s.managerClient.WatchWorkloads(ctx, &manager.WorkloadEventsRequest{SessionInfo: s.sessionInfo, Namespace: namespace}); err != nil {
+				return err
+			}
+			continue
 		}
 
 		s.workloadsLock.Lock()
@@ -1214,19 +1356,12 @@ func (s *session) workloadsWatcher(ctx context.Context, namespace string, synced
 				dlog.Debugf(ctx, "Deleting workload %s/%s.%s", key.kind, key.name, namespace)
 				delete(workloads, key)
 			} else {
-				var clients []string
-				if lc := len(w.InterceptClients); lc > 0 {
-					clients = make([]string, lc)
-					for i, ic := range w.InterceptClients {
-						clients[i] = ic.Client
-					}
-				}
 				dlog.Debugf(ctx, "Adding workload %s/%s.%s", key.kind, key.name, namespace)
 				workloads[key] = workloadInfo{
-					uid:              types.UID(w.Uid),
-					state:            workload.StateFromRPC(w.State),
-					agentState:       w.AgentState,
-					interceptClients: clients,
+					uid:        types.UID(w.Uid),
+					state:      workload.StateFromRPC(w.State),
+					agentState: w.AgentState,
+					intercepts: w.InterceptClients,
 				}
 			}
 		}
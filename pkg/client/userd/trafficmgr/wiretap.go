@@ -0,0 +1,64 @@
+package trafficmgr
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/wiretap"
+)
+
+// Wiretap streams the traffic of the connections to the handler of the named intercept. Those connections are
+// dialed by this daemon when the traffic arrives through the traffic-manager, and by the root daemon when it
+// arrives directly from the traffic-agent, so both daemons are tapped.
+func (s *session) Wiretap(ctx context.Context, request *connector.WiretapRequest, stream userd.WiretapStream) error {
+	ii := s.GetInterceptInfo(request.Name)
+	if ii == nil {
+		return status.Errorf(codes.NotFound, "found no intercept named %s", request.Name)
+	}
+	spec := ii.Spec
+	ip, err := netip.ParseAddr(spec.TargetHost)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "the handler address %q of intercept %s is not an IP", spec.TargetHost, request.Name)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	send := func(e *daemon.WiretapEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(e)
+	}
+
+	// An in-process root daemon shares this process's taps.
+	if !userd.GetService(ctx).RootSessionInProcess() {
+		rs, err := s.rootDaemon.Wiretap(ctx, &daemon.WiretapRequest{Ip: ip.AsSlice(), Port: spec.TargetPort})
+		if err != nil {
+			return err
+		}
+		go func() {
+			for {
+				e, err := rs.Recv()
+				if err != nil {
+					if ctx.Err() == nil {
+						dlog.Errorf(ctx, "wiretap of root daemon ended: %v", err)
+					}
+					return
+				}
+				if err = send(e); err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	return wiretap.Stream(ctx, netip.AddrPortFrom(ip, uint16(spec.TargetPort)), send)
+}
@@ -0,0 +1,353 @@
+package wiretap
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress"`
+	Connection      string      `json:"connection"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+// harMark records the time when the data at an offset of a harStream arrived.
+type harMark struct {
+	offset int
+	time   time.Time
+}
+
+// harStream is the data sent in one direction of a connection.
+type harStream struct {
+	buf   bytes.Buffer
+	marks []harMark
+}
+
+func (s *harStream) add(data []byte, ts time.Time) {
+	s.marks = append(s.marks, harMark{offset: s.buf.Len(), time: ts})
+	s.buf.Write(data)
+}
+
+// timeAt returns the time when the byte at the given offset arrived.
+func (s *harStream) timeAt(offset int) time.Time {
+	i := sort.Search(len(s.marks), func(i int) bool { return s.marks[i].offset > offset })
+	if i == 0 {
+		return time.Time{}
+	}
+	return s.marks[i-1].time
+}
+
+// offsetReader keeps track of the offset of the data consumed from a bufio.Reader.
+type offsetReader struct {
+	*bufio.Reader
+	r *countingReader
+}
+
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+func newOffsetReader(data []byte) *offsetReader {
+	cr := &countingReader{Reader: bytes.NewReader(data)}
+	return &offsetReader{Reader: bufio.NewReader(cr), r: cr}
+}
+
+func (r *offsetReader) offset() int {
+	return r.r.n - r.Buffered()
+}
+
+type harConn struct {
+	id          tunnel.ConnID
+	toHandler   harStream
+	fromHandler harStream
+}
+
+type harWriter struct {
+	out     io.Writer
+	conns   map[tunnel.ConnID]*harConn
+	entries []*harEntry
+}
+
+// newHARWriter returns a Writer that parses the HTTP/1.x requests and responses of the TCP connections, and writes
+// them as an HTTP Archive when it's closed. Connections that don't carry HTTP/1.x are ignored.
+func newHARWriter(out io.Writer) Writer {
+	return &harWriter{out: out, conns: make(map[tunnel.ConnID]*harConn)}
+}
+
+func (w *harWriter) Write(e *daemon.WiretapEvent) error {
+	id := tunnel.ConnID(e.ConnId)
+	if id.Protocol() != ipproto.TCP {
+		return nil
+	}
+	c := w.conns[id]
+	if c == nil {
+		if e.Type == daemon.WiretapEvent_CLOSE {
+			return nil
+		}
+		c = &harConn{id: id}
+		w.conns[id] = c
+	}
+	switch e.Type {
+	case daemon.WiretapEvent_DATA:
+		if e.ToHandler {
+			c.toHandler.add(e.Data, e.Time.AsTime())
+		} else {
+			c.fromHandler.add(e.Data, e.Time.AsTime())
+		}
+	case daemon.WiretapEvent_CLOSE:
+		delete(w.conns, id)
+		w.parse(c)
+	}
+	return nil
+}
+
+func (w *harWriter) Close() error {
+	for _, c := range w.conns {
+		w.parse(c)
+	}
+	w.conns = nil
+	slices.SortStableFunc(w.entries, func(a, b *harEntry) int {
+		return a.StartedDateTime.Compare(b.StartedDateTime)
+	})
+	hl := struct {
+		Log harLog `json:"log"`
+	}{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: client.DisplayName, Version: client.Version()},
+		Entries: w.entries,
+	}}
+	return json.MarshalWrite(w.out, &hl, jsontext.WithIndent("  "))
+}
+
+// parse adds entries for the request and response pairs of the given connection.
+func (w *harWriter) parse(c *harConn) {
+	rqr := newOffsetReader(c.toHandler.buf.Bytes())
+	rsr := newOffsetReader(c.fromHandler.buf.Bytes())
+	server := c.id.Destination().String()
+	conn := iputil.JoinIpPort(c.id.Source(), c.id.SourcePort())
+	for {
+		rqStart := rqr.offset()
+		rq, err := http.ReadRequest(rqr.Reader)
+		if err != nil {
+			return
+		}
+		rqBody, err := io.ReadAll(rq.Body)
+		if err != nil {
+			return
+		}
+		e := &harEntry{
+			StartedDateTime: c.toHandler.timeAt(rqStart),
+			Request:         harRequestOf(rq, rqBody),
+			ServerIPAddress: server,
+			Connection:      conn,
+		}
+		sent := c.toHandler.timeAt(rqr.offset() - 1)
+
+		var rs *http.Response
+		var rsBody []byte
+		rsStart := rsr.offset()
+		for {
+			if rs, err = http.ReadResponse(rsr.Reader, rq); err == nil {
+				rsBody, err = io.ReadAll(rs.Body)
+			}
+			// Skip informational responses, e.g. 100 Continue, that precede the final response.
+			if err != nil || rs.StatusCode >= 200 || rs.StatusCode == http.StatusSwitchingProtocols {
+				break
+			}
+			rsStart = rsr.offset()
+		}
+		if err != nil {
+			// The connection was closed before a response was received.
+			e.Response = harResponse{HTTPVersion: e.Request.HTTPVersion, HeadersSize: -1, BodySize: -1}
+			e.Timings = harTimings{Send: millis(sent.Sub(e.StartedDateTime)), Wait: -1, Receive: -1}
+			e.Time = e.Timings.Send
+			w.entries = append(w.entries, e)
+			return
+		}
+		e.Response = harResponseOf(rs, rsBody)
+		waited := c.fromHandler.timeAt(rsStart)
+		received := c.fromHandler.timeAt(rsr.offset() - 1)
+		e.Timings = harTimings{
+			Send:    millis(sent.Sub(e.StartedDateTime)),
+			Wait:    millis(waited.Sub(sent)),
+			Receive: millis(received.Sub(waited)),
+		}
+		e.Time = millis(received.Sub(e.StartedDateTime))
+		w.entries = append(w.entries, e)
+		if rs.StatusCode == http.StatusSwitchingProtocols {
+			// What follows isn't HTTP/1.x.
+			return
+		}
+	}
+}
+
+func harRequestOf(rq *http.Request, body []byte) harRequest {
+	hr := harRequest{
+		Method:      rq.Method,
+		URL:         "http://" + rq.Host + rq.RequestURI,
+		HTTPVersion: rq.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(rq.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for _, ck := range rq.Cookies() {
+		hr.Cookies = append(hr.Cookies, harNameValue{Name: ck.Name, Value: ck.Value})
+	}
+	q := rq.URL.Query()
+	for _, k := range sortedKeys(q) {
+		for _, v := range q[k] {
+			hr.QueryString = append(hr.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	if len(body) > 0 {
+		text, enc := harText(body)
+		hr.PostData = &harPostData{MimeType: rq.Header.Get("Content-Type"), Text: text, Encoding: enc}
+	}
+	return hr
+}
+
+func harResponseOf(rs *http.Response, body []byte) harResponse {
+	statusText := http.StatusText(rs.StatusCode)
+	if len(rs.Status) > 4 {
+		statusText = rs.Status[4:]
+	}
+	hr := harResponse{
+		Status:      rs.StatusCode,
+		StatusText:  statusText,
+		HTTPVersion: rs.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(rs.Header),
+		RedirectURL: rs.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for _, ck := range rs.Cookies() {
+		hr.Cookies = append(hr.Cookies, harNameValue{Name: ck.Name, Value: ck.Value})
+	}
+	hr.Content = harContent{Size: len(body), MimeType: rs.Header.Get("Content-Type")}
+	if len(body) > 0 {
+		hr.Content.Text, hr.Content.Encoding = harText(body)
+	}
+	return hr
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	hs := make([]harNameValue, 0, len(h))
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			hs = append(hs, harNameValue{Name: k, Value: v})
+		}
+	}
+	return hs
+}
+
+// harText returns the body as text, or base64 encoded when it isn't valid UTF-8.
+func harText(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, cmp.Compare[string])
+	return keys
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,238 @@
+package wiretap
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+const (
+	pcapMagic       = 0xa1b2c3d4
+	pcapSnapLen     = 0x40000
+	pcapLinkTypeRaw = 101
+
+	// maxSegmentSize is the max payload of the synthesized packets. Data that is larger is split into several
+	// packets.
+	maxSegmentSize = 0x8000
+)
+
+// pcapConn keeps track of the sequence numbers of a synthesized TCP connection.
+type pcapConn struct {
+	id  tunnel.ConnID
+	seq uint32 // next sequence number of the peer that sends to the handler
+	ack uint32 // next sequence number of the handler
+}
+
+type pcapWriter struct {
+	out   io.Writer
+	conns map[tunnel.ConnID]*pcapConn
+}
+
+// newPcapWriter returns a Writer that synthesizes IP packets for the events and writes them in the libpcap format.
+// TCP connections get a handshake when they are opened, and a FIN exchange when they are closed.
+func newPcapWriter(out io.Writer) (Writer, error) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(hdr[20:], pcapLinkTypeRaw)
+	if _, err := out.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &pcapWriter{out: out, conns: make(map[tunnel.ConnID]*pcapConn)}, nil
+}
+
+func (w *pcapWriter) Write(e *daemon.WiretapEvent) error {
+	id := tunnel.ConnID(e.ConnId)
+	ts := e.Time.AsTime()
+	if id.Protocol() != ipproto.TCP {
+		if e.Type == daemon.WiretapEvent_DATA {
+			return writeSegments(e.Data, func(data []byte) error {
+				return w.writeUDP(id, e.ToHandler, ts, data)
+			})
+		}
+		return nil
+	}
+
+	c := w.conns[id]
+	switch e.Type {
+	case daemon.WiretapEvent_OPEN:
+		if c == nil {
+			c = &pcapConn{id: id}
+			w.conns[id] = c
+			return w.handshake(c, ts)
+		}
+	case daemon.WiretapEvent_DATA:
+		if c == nil {
+			c = &pcapConn{id: id}
+			w.conns[id] = c
+		}
+		return writeSegments(e.Data, func(data []byte) error {
+			return w.writeTCP(c, e.ToHandler, ts, header.TCPFlagPsh|header.TCPFlagAck, data)
+		})
+	case daemon.WiretapEvent_CLOSE:
+		if c == nil {
+			return nil
+		}
+		delete(w.conns, id)
+		if err := w.writeTCP(c, true, ts, header.TCPFlagFin|header.TCPFlagAck, nil); err != nil {
+			return err
+		}
+		if err := w.writeTCP(c, false, ts, header.TCPFlagFin|header.TCPFlagAck, nil); err != nil {
+			return err
+		}
+		return w.writeTCP(c, true, ts, header.TCPFlagAck, nil)
+	}
+	return nil
+}
+
+func (w *pcapWriter) Close() error {
+	return nil
+}
+
+func (w *pcapWriter) handshake(c *pcapConn, ts time.Time) error {
+	if err := w.writeTCP(c, true, ts, header.TCPFlagSyn, nil); err != nil {
+		return err
+	}
+	if err := w.writeTCP(c, false, ts, header.TCPFlagSyn|header.TCPFlagAck, nil); err != nil {
+		return err
+	}
+	return w.writeTCP(c, true, ts, header.TCPFlagAck, nil)
+}
+
+// writeSegments calls the given function with consecutive segments of the data that are at most maxSegmentSize long.
+func writeSegments(data []byte, f func([]byte) error) error {
+	for len(data) > 0 {
+		n := min(len(data), maxSegmentSize)
+		if err := f(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// endpoints returns the source and destination of a packet. The source of the ConnID is the peer in the cluster,
+// and its destination is the handler.
+func endpoints(id tunnel.ConnID, toHandler bool) (src, dst net.IP, srcPort, dstPort uint16) {
+	src, dst, srcPort, dstPort = id.Source(), id.Destination(), id.SourcePort(), id.DestinationPort()
+	if !toHandler {
+		src, dst, srcPort, dstPort = dst, src, dstPort, srcPort
+	}
+	return src, dst, srcPort, dstPort
+}
+
+func (w *pcapWriter) writeTCP(c *pcapConn, toHandler bool, ts time.Time, flags header.TCPFlags, data []byte) error {
+	src, dst, srcPort, dstPort := endpoints(c.id, toHandler)
+	seq, ack := c.seq, c.ack
+	if !toHandler {
+		seq, ack = ack, seq
+	}
+	if flags&header.TCPFlagAck == 0 {
+		ack = 0
+	}
+	tcp := make(header.TCP, header.TCPMinimumSize)
+	tcp.Encode(&header.TCPFields{
+		SrcPort:    srcPort,
+		DstPort:    dstPort,
+		SeqNum:     seq,
+		AckNum:     ack,
+		DataOffset: header.TCPMinimumSize,
+		Flags:      flags,
+		WindowSize: 0xffff,
+	})
+
+	// SYN and FIN count as one byte of the sequence.
+	advance := uint32(len(data))
+	if flags&(header.TCPFlagSyn|header.TCPFlagFin) != 0 {
+		advance++
+	}
+	if toHandler {
+		c.seq += advance
+	} else {
+		c.ack += advance
+	}
+	return w.writeIP(src, dst, header.TCPProtocolNumber, ts, tcp, data, func(xsum uint16) {
+		tcp.SetChecksum(^tcp.CalculateChecksum(xsum))
+	})
+}
+
+func (w *pcapWriter) writeUDP(id tunnel.ConnID, toHandler bool, ts time.Time, data []byte) error {
+	src, dst, srcPort, dstPort := endpoints(id, toHandler)
+	udp := make(header.UDP, header.UDPMinimumSize)
+	udp.Encode(&header.UDPFields{
+		SrcPort: srcPort,
+		DstPort: dstPort,
+		Length:  uint16(header.UDPMinimumSize + len(data)),
+	})
+	return w.writeIP(src, dst, header.UDPProtocolNumber, ts, udp, data, func(xsum uint16) {
+		udp.SetChecksum(^udp.CalculateChecksum(xsum))
+	})
+}
+
+// writeIP writes a packet with the given transport header and payload. The setChecksum function is called with
+// the partial checksum of the pseudo header and the payload.
+func (w *pcapWriter) writeIP(
+	src, dst net.IP,
+	proto tcpip.TransportProtocolNumber,
+	ts time.Time,
+	transport, data []byte,
+	setChecksum func(uint16),
+) error {
+	var srcAddr, dstAddr tcpip.Address
+	src4, dst4 := src.To4(), dst.To4()
+	if src4 != nil && dst4 != nil {
+		srcAddr, dstAddr = tcpip.AddrFrom4Slice(src4), tcpip.AddrFrom4Slice(dst4)
+	} else {
+		srcAddr, dstAddr = tcpip.AddrFrom16Slice(src.To16()), tcpip.AddrFrom16Slice(dst.To16())
+	}
+	transportLen := len(transport) + len(data)
+	xsum := header.PseudoHeaderChecksum(proto, srcAddr, dstAddr, uint16(transportLen))
+	setChecksum(checksum.Checksum(data, xsum))
+
+	var ip []byte
+	if src4 != nil && dst4 != nil {
+		ip4 := make(header.IPv4, header.IPv4MinimumSize)
+		ip4.Encode(&header.IPv4Fields{
+			TotalLength: uint16(header.IPv4MinimumSize + transportLen),
+			TTL:         64,
+			Protocol:    uint8(proto),
+			SrcAddr:     srcAddr,
+			DstAddr:     dstAddr,
+		})
+		ip4.SetChecksum(^ip4.CalculateChecksum())
+		ip = ip4
+	} else {
+		ip6 := make(header.IPv6, header.IPv6MinimumSize)
+		ip6.Encode(&header.IPv6Fields{
+			PayloadLength:     uint16(transportLen),
+			TransportProtocol: proto,
+			HopLimit:          64,
+			SrcAddr:           srcAddr,
+			DstAddr:           dstAddr,
+		})
+		ip = ip6
+	}
+
+	size := len(ip) + transportLen
+	rec := make([]byte, 16, 16+size)
+	binary.LittleEndian.PutUint32(rec[0:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(rec[4:], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(rec[8:], uint32(size))
+	binary.LittleEndian.PutUint32(rec[12:], uint32(size))
+	rec = append(rec, ip...)
+	rec = append(rec, transport...)
+	rec = append(rec, data...)
+	_, err := w.out.Write(rec)
+	return err
+}
@@ -0,0 +1,68 @@
+// Package wiretap captures the traffic that flows between the daemons and the intercept handlers, and writes it to
+// pcap or HAR files.
+package wiretap
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// eventBufferSize is the number of events that can be buffered before the tapped connections are slowed down
+// to the pace of the receiver.
+const eventBufferSize = 256
+
+type tap struct {
+	ctx    context.Context
+	events chan *daemon.WiretapEvent
+}
+
+func (t *tap) send(e *daemon.WiretapEvent) {
+	e.Time = timestamppb.New(time.Now())
+	select {
+	case <-t.ctx.Done():
+	case t.events <- e:
+	}
+}
+
+func (t *tap) Open(id tunnel.ConnID) {
+	t.send(&daemon.WiretapEvent{Type: daemon.WiretapEvent_OPEN, ConnId: []byte(id)})
+}
+
+func (t *tap) Data(id tunnel.ConnID, toDestination bool, data []byte) {
+	t.send(&daemon.WiretapEvent{
+		Type:      daemon.WiretapEvent_DATA,
+		ConnId:    []byte(id),
+		ToHandler: toDestination,
+		Data:      append([]byte(nil), data...),
+	})
+}
+
+func (t *tap) Close(id tunnel.ConnID) {
+	t.send(&daemon.WiretapEvent{Type: daemon.WiretapEvent_CLOSE, ConnId: []byte(id)})
+}
+
+// Stream taps the connections that this process dials to the given handler, and passes their events to the given
+// send function until the context is cancelled or send returns an error.
+func Stream(ctx context.Context, handler netip.AddrPort, send func(*daemon.WiretapEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	t := &tap{ctx: ctx, events: make(chan *daemon.WiretapEvent, eventBufferSize)}
+	remove := tunnel.AddTap(handler, t)
+	defer remove()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-t.events:
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package wiretap
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+const (
+	FormatPcap = "pcap"
+	FormatHAR  = "har"
+)
+
+// A Writer writes the events of a wiretap in some file format.
+type Writer interface {
+	Write(*daemon.WiretapEvent) error
+
+	// Close writes what remains to be written. It doesn't close the underlying io.Writer.
+	Close() error
+}
+
+// NewWriter returns a Writer for the given format that writes to the given io.Writer.
+func NewWriter(format string, out io.Writer) (Writer, error) {
+	switch format {
+	case FormatPcap:
+		return newPcapWriter(out)
+	case FormatHAR:
+		return newHARWriter(out), nil
+	default:
+		return nil, fmt.Errorf("invalid wiretap format %q, must be %q or %q", format, FormatPcap, FormatHAR)
+	}
+}
+
+// FormatFromFile returns the format that corresponds to the extension of the given file name, or an empty string
+// when the extension is unknown.
+func FormatFromFile(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".pcap":
+		return FormatPcap
+	case ".har":
+		return FormatHAR
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,141 @@
+package wiretap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+var (
+	testStart = time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	testConn  = tunnel.NewConnID(ipproto.TCP, net.IP{10, 1, 0, 7}, net.IP{127, 0, 0, 1}, 41234, 8080)
+)
+
+func testEvents(id tunnel.ConnID) []*daemon.WiretapEvent {
+	ev := func(ms int, t daemon.WiretapEvent_Type, toHandler bool, data string) *daemon.WiretapEvent {
+		return &daemon.WiretapEvent{
+			Type:      t,
+			ConnId:    []byte(id),
+			ToHandler: toHandler,
+			Data:      []byte(data),
+			Time:      timestamppb.New(testStart.Add(time.Duration(ms) * time.Millisecond)),
+		}
+	}
+	return []*daemon.WiretapEvent{
+		ev(0, daemon.WiretapEvent_OPEN, false, ""),
+		ev(1, daemon.WiretapEvent_DATA, true, "POST /orders?id=7&x=1 HTTP/1.1\r\nHost: orders\r\nContent-Length: 5\r\n\r\n"),
+		ev(3, daemon.WiretapEvent_DATA, true, "hello"),
+		ev(10, daemon.WiretapEvent_DATA, false, "HTTP/1.1 201 Created\r\nContent-Type: text/plain\r\nContent-Length: 2\r\n\r\nok"),
+		ev(20, daemon.WiretapEvent_DATA, true, "GET /health HTTP/1.1\r\nHost: orders\r\n\r\n"),
+		ev(25, daemon.WiretapEvent_CLOSE, false, ""),
+	}
+}
+
+func TestPcapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatPcap, &buf)
+	require.NoError(t, err)
+	for _, e := range testEvents(testConn) {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	require.Greater(t, len(data), 24)
+	assert.Equal(t, uint32(pcapMagic), binary.LittleEndian.Uint32(data))
+	assert.Equal(t, uint32(pcapLinkTypeRaw), binary.LittleEndian.Uint32(data[20:]))
+	data = data[24:]
+
+	type packet struct {
+		flags   header.TCPFlags
+		seq     uint32
+		ack     uint32
+		payload int
+	}
+	var packets []packet
+	for len(data) > 0 {
+		size := int(binary.LittleEndian.Uint32(data[8:]))
+		ip := header.IPv4(data[16 : 16+size])
+		require.True(t, ip.IsChecksumValid())
+		tcp := header.TCP(ip.Payload())
+		payload := tcp[tcp.DataOffset():]
+		require.True(t, tcp.IsChecksumValid(ip.SourceAddress(), ip.DestinationAddress(), checksum.Checksum(payload, 0), uint16(len(payload))))
+		packets = append(packets, packet{flags: tcp.Flags(), seq: tcp.SequenceNumber(), ack: tcp.AckNumber(), payload: len(payload)})
+		data = data[16+size:]
+	}
+
+	pa := header.TCPFlagPsh | header.TCPFlagAck
+	fa := header.TCPFlagFin | header.TCPFlagAck
+	assert.Equal(t, []packet{
+		{flags: header.TCPFlagSyn},
+		{flags: header.TCPFlagSyn | header.TCPFlagAck, ack: 1},
+		{flags: header.TCPFlagAck, seq: 1, ack: 1},
+		{flags: pa, seq: 1, ack: 1, payload: 67},
+		{flags: pa, seq: 68, ack: 1, payload: 5},
+		{flags: pa, seq: 1, ack: 73, payload: 71},
+		{flags: pa, seq: 73, ack: 72, payload: 38},
+		{flags: fa, seq: 111, ack: 72},
+		{flags: fa, seq: 72, ack: 112},
+		{flags: header.TCPFlagAck, seq: 112, ack: 73},
+	}, packets)
+}
+
+func TestHARWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatHAR, &buf)
+	require.NoError(t, err)
+	for _, e := range testEvents(testConn) {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+
+	var hl struct {
+		Log harLog `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &hl))
+	assert.Equal(t, "1.2", hl.Log.Version)
+	require.Len(t, hl.Log.Entries, 2)
+
+	e := hl.Log.Entries[0]
+	assert.Equal(t, testStart.Add(time.Millisecond), e.StartedDateTime)
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Equal(t, "http://orders/orders?id=7&x=1", e.Request.URL)
+	assert.Equal(t, []harNameValue{{Name: "id", Value: "7"}, {Name: "x", Value: "1"}}, e.Request.QueryString)
+	require.NotNil(t, e.Request.PostData)
+	assert.Equal(t, "hello", e.Request.PostData.Text)
+	assert.Equal(t, 201, e.Response.Status)
+	assert.Equal(t, "Created", e.Response.StatusText)
+	assert.Equal(t, harContent{Size: 2, MimeType: "text/plain", Text: "ok"}, e.Response.Content)
+	assert.Equal(t, harTimings{Send: 2, Wait: 7, Receive: 0}, e.Timings)
+	assert.Equal(t, 9.0, e.Time)
+	assert.Equal(t, "127.0.0.1", e.ServerIPAddress)
+	assert.Equal(t, "10.1.0.7:41234", e.Connection)
+
+	// The connection was closed before the second request got a response.
+	e = hl.Log.Entries[1]
+	assert.Equal(t, "/health", strings.TrimPrefix(e.Request.URL, "http://orders"))
+	assert.Equal(t, 0, e.Response.Status)
+	assert.Equal(t, -1.0, e.Timings.Wait)
+}
+
+func TestFormatFromFile(t *testing.T) {
+	assert.Equal(t, FormatPcap, FormatFromFile("capture.PCAP"))
+	assert.Equal(t, FormatHAR, FormatFromFile("/tmp/capture.har"))
+	assert.Equal(t, "", FormatFromFile("capture.json"))
+	_, err := NewWriter("json", &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -89,3 +89,34 @@ func ToResult(err error) *common.Result {
 	}
 	return r
 }
+
+type exitCoded struct {
+	error
+	code int
+}
+
+// WithExitCode returns an error that makes the CLI exit with the given code instead of 1.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCoded{error: err, code: code}
+}
+
+// Unwrap this exit coded error.
+func (ec *exitCoded) Unwrap() error {
+	return ec.error
+}
+
+// GetExitCode returns the code that the CLI should exit with when it fails with the given error, i.e. 0 for nil,
+// the code given to WithExitCode, or 1 for other errors.
+func GetExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ec *exitCoded
+	if errors.As(err, &ec) {
+		return ec.code
+	}
+	return 1
+}
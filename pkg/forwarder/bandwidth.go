@@ -0,0 +1,100 @@
+package forwarder
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// minBandwidthChunk is the smallest number of bytes that is read or written in one go on a connection with
+// limited bandwidth.
+const minBandwidthChunk = 1024
+
+// byteRateLimiter paces the bytes that pass through it so that they never exceed a rate. The limiter is shared
+// by all connections of an intercept, so the rate is the total for the intercept and not per connection.
+type byteRateLimiter struct {
+	mu    sync.Mutex
+	rate  int64 // bytes per second
+	chunk int
+	next  time.Time
+}
+
+func newByteRateLimiter(rate int64) *byteRateLimiter {
+	// Pace in chunks that take at most a tenth of a second, so that the traffic flows evenly.
+	return &byteRateLimiter{rate: rate, chunk: int(max(rate/10, minBandwidthChunk))}
+}
+
+// wait blocks until the given number of bytes has been allowed to pass.
+func (l *byteRateLimiter) wait(n int) {
+	d := time.Duration(int64(n) * int64(time.Second) / l.rate)
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(d)
+	until := l.next
+	l.mu.Unlock()
+	time.Sleep(time.Until(until))
+}
+
+// bandwidth limits the traffic in each direction of the connections of an intercept.
+type bandwidth struct {
+	toHandler   *byteRateLimiter
+	fromHandler *byteRateLimiter
+}
+
+func bandwidthOf(spec *manager.InterceptSpec) *bandwidth {
+	if spec.BandwidthLimit <= 0 {
+		return nil
+	}
+	return &bandwidth{
+		toHandler:   newByteRateLimiter(spec.BandwidthLimit),
+		fromHandler: newByteRateLimiter(spec.BandwidthLimit),
+	}
+}
+
+// Conn returns a net.Conn that limits the bandwidth of the given conn, or the given conn when the bandwidth
+// is unlimited. What's read from the conn is sent to the intercept handler, and what's written to it comes
+// from the intercept handler.
+func (b *bandwidth) Conn(conn net.Conn) net.Conn {
+	if b == nil {
+		return conn
+	}
+	return &bandwidthConn{Conn: conn, b: b}
+}
+
+type bandwidthConn struct {
+	net.Conn
+	b *bandwidth
+}
+
+func (c *bandwidthConn) Read(p []byte) (int, error) {
+	l := c.b.toHandler
+	if len(p) > l.chunk {
+		p = p[:l.chunk]
+	}
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		l.wait(n)
+	}
+	return n, err
+}
+
+func (c *bandwidthConn) Write(p []byte) (int, error) {
+	l := c.b.fromHandler
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), l.chunk)
+		l.wait(n)
+		wn, err := c.Conn.Write(p[:n])
+		written += wn
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,55 @@
+package forwarder
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+func TestBandwidthOf(t *testing.T) {
+	assert.Nil(t, bandwidthOf(&manager.InterceptSpec{}))
+	b := bandwidthOf(&manager.InterceptSpec{BandwidthLimit: 100_000})
+	require.NotNil(t, b)
+	assert.NotSame(t, b.toHandler, b.fromHandler)
+	assert.Equal(t, 10_000, b.toHandler.chunk)
+	assert.Equal(t, minBandwidthChunk, newByteRateLimiter(100).chunk)
+
+	var nb *bandwidth
+	conn, _ := net.Pipe()
+	assert.Same(t, conn, nb.Conn(conn))
+}
+
+func TestBandwidthConn(t *testing.T) {
+	// 40 KiB at 200 KiB/s in each direction takes at least 200ms.
+	const size = 40 * 1024
+	b := bandwidthOf(&manager.InterceptSpec{BandwidthLimit: 200 * 1024})
+	client, conn := net.Pipe()
+	conn = b.Conn(conn)
+	defer conn.Close()
+	data := bytes.Repeat([]byte("x"), size)
+
+	start := time.Now()
+	go func() {
+		_, _ = client.Write(data)
+	}()
+	got := make([]byte, size)
+	_, err := io.ReadFull(conn, got)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+
+	start = time.Now()
+	go func() {
+		_, _ = conn.Write(data)
+	}()
+	_, err = io.ReadFull(client, got)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,154 @@
+package forwarder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// injectedErrorBody is the body of the response to a request that fails because of an injected error.
+const injectedErrorBody = "error injected by the traffic-agent\n"
+
+// faults are the faults that are injected into the requests of an intercept.
+type faults struct {
+	latency   time.Duration
+	errorRate float64 // percentage, 0 to 100
+	fail      func() bool
+}
+
+func faultsOf(spec *manager.InterceptSpec) *faults {
+	if spec.InjectLatency <= 0 && spec.InjectErrorRate <= 0 {
+		return nil
+	}
+	f := &faults{latency: time.Duration(spec.InjectLatency), errorRate: float64(spec.InjectErrorRate)}
+	f.fail = func() bool {
+		return f.errorRate > 0 && rand.Float64()*100 < f.errorRate
+	}
+	return f
+}
+
+// faultConn injects faults into the HTTP/1.x requests that are read from a connection. Each request is held back
+// until it has been read in full, and is then delayed by the latency. A request that fails is never forwarded.
+// Instead, the connection ends as if the client had closed it, and once the responses to the preceding requests
+// have been written, the client receives a 503 Service Unavailable response. Data that isn't HTTP/1.x, and
+// data that follows an upgrade to another protocol, is forwarded as is.
+type faultConn struct {
+	net.Conn
+	pr     *io.PipeReader
+	mu     sync.Mutex
+	failed bool
+}
+
+// Conn returns a net.Conn that injects the faults into the requests that are read from the given conn, or the
+// given conn when there are no faults to inject.
+func (f *faults) Conn(conn net.Conn) net.Conn {
+	if f == nil {
+		return conn
+	}
+	pr, pw := io.Pipe()
+	fc := &faultConn{Conn: conn, pr: pr}
+	go fc.pump(pw, f)
+	return fc
+}
+
+func (c *faultConn) Read(p []byte) (int, error) {
+	return c.pr.Read(p)
+}
+
+// Close writes the 503 response when a request has failed, and then closes the connection.
+func (c *faultConn) Close() error {
+	c.mu.Lock()
+	failed := c.failed
+	c.failed = false
+	c.mu.Unlock()
+	if failed {
+		_, _ = fmt.Fprintf(c.Conn, "HTTP/1.1 503 Service Unavailable\r\n"+
+			"Content-Type: text/plain; charset=utf-8\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+			len(injectedErrorBody), injectedErrorBody)
+	}
+	_ = c.pr.Close()
+	return c.Conn.Close()
+}
+
+// rawReader retains the data read from a reader until it's taken.
+type rawReader struct {
+	io.Reader
+	buf []byte
+}
+
+func (r *rawReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return n, err
+}
+
+func (r *rawReader) take(n int) []byte {
+	data := r.buf[:n]
+	r.buf = r.buf[n:]
+	return data
+}
+
+func (c *faultConn) pump(pw *io.PipeWriter, f *faults) {
+	rr := &rawReader{Reader: c.Conn}
+	br := bufio.NewReader(rr)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			pw.CloseWithError(err)
+			return
+		}
+		if b[0] < 'A' || b[0] > 'Z' {
+			// Not an HTTP/1.x request method, so don't wait for a request line that may never come.
+			c.passThrough(pw, rr)
+			return
+		}
+		rq, err := http.ReadRequest(br)
+		if err == nil {
+			_, err = io.Copy(io.Discard, rq.Body)
+		}
+		if err != nil {
+			// Not HTTP/1.x, or incomplete because the client closed the connection.
+			c.passThrough(pw, rr)
+			return
+		}
+		data := rr.take(len(rr.buf) - br.Buffered())
+		if f.fail() {
+			c.mu.Lock()
+			c.failed = true
+			c.mu.Unlock()
+			pw.Close()
+			return
+		}
+		if f.latency > 0 {
+			time.Sleep(f.latency)
+		}
+		if _, err = pw.Write(data); err != nil {
+			return
+		}
+		if rq.ProtoMajor != 1 || rq.Header.Get("Upgrade") != "" {
+			c.passThrough(pw, rr)
+			return
+		}
+	}
+}
+
+// passThrough forwards the data that has been read but not yet forwarded, followed by the rest of the connection.
+func (c *faultConn) passThrough(pw *io.PipeWriter, rr *rawReader) {
+	if _, err := pw.Write(rr.buf); err != nil {
+		return
+	}
+	rr.buf = nil
+	_, err := io.Copy(pw, c.Conn)
+	pw.CloseWithError(err)
+}
@@ -0,0 +1,97 @@
+package forwarder
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+const (
+	faultRq1 = "POST /orders HTTP/1.1\r\nHost: orders\r\nContent-Length: 5\r\n\r\nhello"
+	faultRq2 = "GET /health HTTP/1.1\r\nHost: orders\r\n\r\n"
+)
+
+// faultPipe returns the client side of a connection, and the side that injects the given faults.
+func faultPipe(f *faults) (client, server net.Conn) {
+	client, conn := net.Pipe()
+	return client, f.Conn(conn)
+}
+
+func TestFaultsOf(t *testing.T) {
+	assert.Nil(t, faultsOf(&manager.InterceptSpec{}))
+	f := faultsOf(&manager.InterceptSpec{InjectLatency: int64(time.Second), InjectErrorRate: 100})
+	require.NotNil(t, f)
+	assert.Equal(t, time.Second, f.latency)
+	assert.True(t, f.fail())
+
+	var nf *faults
+	conn, _ := net.Pipe()
+	assert.Same(t, conn, nf.Conn(conn))
+}
+
+func TestFaultConn_latency(t *testing.T) {
+	client, server := faultPipe(&faults{latency: 50 * time.Millisecond, fail: func() bool { return false }})
+	defer server.Close()
+	go func() {
+		_, _ = io.WriteString(client, faultRq1+faultRq2)
+		_ = client.Close()
+	}()
+
+	start := time.Now()
+	data, err := io.ReadAll(server)
+	require.NoError(t, err)
+	assert.Equal(t, faultRq1+faultRq2, string(data))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestFaultConn_error(t *testing.T) {
+	fails := []bool{false, true}
+	client, server := faultPipe(&faults{fail: func() bool {
+		f := fails[0]
+		fails = fails[1:]
+		return f
+	}})
+	go func() {
+		_, _ = io.WriteString(client, faultRq1+faultRq2)
+	}()
+
+	// Only the first request is forwarded.
+	data, err := io.ReadAll(server)
+	require.NoError(t, err)
+	assert.Equal(t, faultRq1, string(data))
+
+	// The failed request gets a 503 response when the connection is closed.
+	rsCh := make(chan *http.Response, 1)
+	go func() {
+		rs, err := http.ReadResponse(bufio.NewReader(client), nil)
+		assert.NoError(t, err)
+		rsCh <- rs
+	}()
+	require.NoError(t, server.Close())
+	rs := <-rsCh
+	require.NotNil(t, rs)
+	assert.Equal(t, http.StatusServiceUnavailable, rs.StatusCode)
+	body, _ := io.ReadAll(rs.Body)
+	assert.Equal(t, injectedErrorBody, string(body))
+}
+
+func TestFaultConn_notHTTP(t *testing.T) {
+	client, server := faultPipe(&faults{fail: func() bool { return true }})
+	defer server.Close()
+	payload := "\x00\x00\x00\x08\x04\xd2\x16\x2f"
+	go func() {
+		_, _ = io.WriteString(client, payload)
+		_ = client.Close()
+	}()
+	data, err := io.ReadAll(server)
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(data))
+}
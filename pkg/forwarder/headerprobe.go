@@ -0,0 +1,152 @@
+package forwarder
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxHeaderPadding is the max number of spaces and tabs that are accepted between the colon that
+// terminates a header name and the header value.
+const maxHeaderPadding = 16
+
+// HeaderProbes keeps track of the header probes that the traffic-manager has armed. The data that
+// clients send on forwarded and intercepted connections is scanned for the armed probes, and each
+// probe is reported once when a header with its name and value is observed.
+//
+// The scan is performed on the raw byte stream, so only plain text HTTP/1.x headers are detected.
+type HeaderProbes struct {
+	mu     sync.Mutex
+	probes map[string]*headerProbe
+	armed  atomic.Int32
+}
+
+type headerProbe struct {
+	name   []byte
+	value  []byte
+	report func()
+	timer  *time.Timer
+}
+
+func NewHeaderProbes() *HeaderProbes {
+	return &HeaderProbes{probes: make(map[string]*headerProbe)}
+}
+
+// Arm arms a probe for the header with the given name and value. The report function is called
+// when the header is observed. The probe is disarmed when it has been observed, or when the
+// timeout expires.
+func (hp *HeaderProbes) Arm(id, name, value string, timeout time.Duration, report func()) {
+	p := &headerProbe{
+		name:   []byte(name),
+		value:  []byte(value),
+		report: report,
+	}
+	hp.mu.Lock()
+	if _, ok := hp.probes[id]; !ok {
+		hp.armed.Add(1)
+	}
+	hp.probes[id] = p
+	p.timer = time.AfterFunc(timeout, func() { hp.disarm(id, p) })
+	hp.mu.Unlock()
+}
+
+func (hp *HeaderProbes) disarm(id string, p *headerProbe) {
+	hp.mu.Lock()
+	if hp.probes[id] == p {
+		delete(hp.probes, id)
+		hp.armed.Add(-1)
+	}
+	hp.mu.Unlock()
+}
+
+// Reader returns a reader that scans what's read from the given reader for armed probes.
+func (hp *HeaderProbes) Reader(r io.Reader) io.Reader {
+	if hp == nil {
+		return r
+	}
+	return &probeReader{Reader: r, probes: hp}
+}
+
+// Conn returns a net.Conn that scans what's read from the given conn for armed probes.
+func (hp *HeaderProbes) Conn(conn net.Conn) net.Conn {
+	if hp == nil {
+		return conn
+	}
+	return &probeConn{Conn: conn, r: hp.Reader(conn)}
+}
+
+// scan reports and disarms all probes that match the given data, and returns the trailing part of
+// the data that must be retained in order to detect a header that is split between two reads.
+func (hp *HeaderProbes) scan(data []byte) []byte {
+	var matched []*headerProbe
+	keep := 0
+	hp.mu.Lock()
+	for id, p := range hp.probes {
+		if p.match(data) {
+			p.timer.Stop()
+			delete(hp.probes, id)
+			hp.armed.Add(-1)
+			matched = append(matched, p)
+		} else {
+			keep = max(keep, len(p.name)+1+maxHeaderPadding+len(p.value))
+		}
+	}
+	hp.mu.Unlock()
+	for _, p := range matched {
+		go p.report()
+	}
+	if keep >= len(data) {
+		return data
+	}
+	return bytes.Clone(data[len(data)-keep:])
+}
+
+// match returns true if the data contains the probe's value, preceded by the probe's name, a colon,
+// and optional spaces or tabs. The name is matched case-insensitively.
+func (p *headerProbe) match(data []byte) bool {
+	for i := 0; ; {
+		j := bytes.Index(data[i:], p.value)
+		if j < 0 {
+			return false
+		}
+		j += i
+		k := j
+		for k > 0 && j-k < maxHeaderPadding && (data[k-1] == ' ' || data[k-1] == '\t') {
+			k--
+		}
+		if k > len(p.name) && data[k-1] == ':' && bytes.EqualFold(data[k-1-len(p.name):k-1], p.name) {
+			return true
+		}
+		i = j + 1
+	}
+}
+
+type probeReader struct {
+	io.Reader
+	probes *HeaderProbes
+	tail   []byte
+}
+
+func (r *probeReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		if r.probes.armed.Load() > 0 {
+			r.tail = r.probes.scan(append(r.tail, p[:n]...))
+		} else {
+			r.tail = nil
+		}
+	}
+	return n, err
+}
+
+type probeConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *probeConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
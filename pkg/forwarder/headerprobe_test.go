@@ -0,0 +1,62 @@
+package forwarder
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderProbe_match(t *testing.T) {
+	p := &headerProbe{name: []byte("X-Telepresence-Id"), value: []byte("probe-1")}
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"GET / HTTP/1.1\r\nx-telepresence-id: probe-1\r\n\r\n", true},
+		{"GET / HTTP/1.1\r\nX-TELEPRESENCE-ID:probe-1\r\n\r\n", true},
+		{"GET / HTTP/1.1\r\nX-Telepresence-Id: \tprobe-1\r\n\r\n", true},
+		{"GET / HTTP/1.1\r\nX-Other: probe-1\r\n\r\n", false},
+		{"GET /probe-1 HTTP/1.1\r\nX-Telepresence-Id: jane\r\n\r\n", false},
+		{"probe-1", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.match([]byte(tt.data)), tt.data)
+	}
+}
+
+func TestHeaderProbes_Reader(t *testing.T) {
+	hp := NewHeaderProbes()
+	reported := make(chan string, 2)
+	hp.Arm("1", "X-Telepresence-Id", "probe-1", time.Minute, func() { reported <- "1" })
+	hp.Arm("2", "X-Telepresence-Id", "probe-2", time.Minute, func() { reported <- "2" })
+
+	// Read one byte at a time to verify that headers that are split between reads are detected.
+	data := "GET / HTTP/1.1\r\nHost: example\r\nX-Telepresence-Id: probe-1\r\n\r\n"
+	r := hp.Reader(iotest.OneByteReader(strings.NewReader(data)))
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(got))
+	select {
+	case id := <-reported:
+		assert.Equal(t, "1", id)
+	case <-time.After(time.Second):
+		t.Fatal("probe was not reported")
+	}
+	assert.Equal(t, int32(1), hp.armed.Load())
+}
+
+func TestHeaderProbes_timeout(t *testing.T) {
+	hp := NewHeaderProbes()
+	hp.Arm("1", "X-Telepresence-Id", "probe-1", time.Millisecond, func() {})
+	assert.Eventually(t, func() bool { return hp.armed.Load() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestHeaderProbes_nil(t *testing.T) {
+	var hp *HeaderProbes
+	r := strings.NewReader("data")
+	assert.Same(t, r, hp.Reader(r))
+}
@@ -0,0 +1,291 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+
+	"golang.org/x/net/http2"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+)
+
+// defaultBodyLimit is the max number of bytes of a request body that are read in order to match it, unless the
+// intercept says otherwise.
+const defaultBodyLimit = 64 * 1024
+
+// dialFunc dials a new connection to the target of an intercept.
+type dialFunc func(ctx context.Context) (net.Conn, error)
+
+// sessionDialFunc dials a new connection to the client of the given session.
+type sessionDialFunc func(ctx context.Context, sessionID string) (net.Conn, error)
+
+// requestFilter selects the HTTP requests that are routed to the client.
+type requestFilter struct {
+	request      matcher.Request
+	body         matcher.Body
+	bodyLimit    int64
+	propagations []matcher.Propagation
+
+	// owner is the session of the client that created the intercept.
+	owner string
+
+	// routes maps the routes of the clients that joined the intercept to their sessions.
+	routes map[string]string
+}
+
+// hasRequestFilter returns true if the given spec selects the HTTP requests that are routed to the client.
+func hasRequestFilter(spec *manager.InterceptSpec) bool {
+	return len(spec.HttpHeaders) > 0 || len(spec.HttpBodyJson) > 0 || len(spec.HttpBodyXpath) > 0
+}
+
+// newRequestFilter creates a filter for the given intercept. The headers are matched after translating the ones
+// that are carried by the given propagations into plain headers.
+func newRequestFilter(iCept *manager.InterceptInfo, propagations []matcher.Propagation) (*requestFilter, error) {
+	spec := iCept.Spec
+	request, err := matcher.NewRequestFromMap(spec.HttpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	body, err := matcher.NewBody(spec.HttpBodyJson, spec.HttpBodyXpath)
+	if err != nil {
+		return nil, err
+	}
+	bodyLimit := spec.HttpBodyLimit
+	if bodyLimit <= 0 {
+		bodyLimit = defaultBodyLimit
+	}
+	routes := make(map[string]string, len(iCept.SharedSessionRoutes))
+	for sessionID, route := range iCept.SharedSessionRoutes {
+		routes[route] = sessionID
+	}
+	return &requestFilter{
+		request:      request,
+		body:         body,
+		bodyLimit:    bodyLimit,
+		propagations: propagations,
+		owner:        iCept.GetClientSession().GetSessionId(),
+		routes:       routes,
+	}, nil
+}
+
+// sessionID returns the session of the client that the given matching request is routed to. That's the client
+// that joined the intercept with the route in the request's x-telepresence-route header, or the client that
+// created the intercept when there's no such header, or when the route is unknown.
+func (rf *requestFilter) sessionID(r *http.Request) string {
+	if route := r.Header.Get(restapi.HeaderRoute); route != "" {
+		if sessionID, ok := rf.routes[route]; ok {
+			return sessionID
+		}
+	}
+	return rf.owner
+}
+
+// matches returns true if the given request is selected by this filter. The body of the request is only read
+// when the path and headers match, and the body has a content type that is inspected. The body is then replaced
+// by one that replays what was read. A body that exceeds the limit doesn't match.
+func (rf *requestFilter) matches(r *http.Request) bool {
+	if !rf.request.Matches(r.URL.Path, matcher.Translate(r.Header, rf.propagations)) {
+		return false
+	}
+	if rf.body == nil {
+		return true
+	}
+	ct := r.Header.Get("Content-Type")
+	if !rf.body.Inspects(ct) || r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, rf.bodyLimit+1))
+	r.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+	return err == nil && int64(len(data)) <= rf.bodyLimit && rf.body.Matches(ct, data)
+}
+
+// replayBody is a request body that replays what has been read from the original body.
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// serveFiltered serves the HTTP/1.x or HTTP/2 requests that are read from the given conn, and routes
+// each request that is selected by the given filter to the client and all other requests to the target.
+// It returns when the conn is closed.
+func serveFiltered(ctx context.Context, conn net.Conn, proto AppProtocol, filter *requestFilter, dialClient sessionDialFunc, dialTarget dialFunc) {
+	transport := http1Transport
+	if proto == ProtocolHTTP2 {
+		transport = h2cTransport
+	}
+	rt := &filterTransport{
+		filter: filter,
+		client: func(sessionID string) http.RoundTripper {
+			return transport(func(ctx context.Context) (net.Conn, error) {
+				return dialClient(ctx, sessionID)
+			})
+		},
+		clients: make(map[string]http.RoundTripper),
+		target:  transport(dialTarget),
+	}
+	defer rt.closeIdleConnections()
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// The request is forwarded as is, so there's no X-Forwarded-For, and the host is retained.
+			pr.Out.URL.Scheme = "http"
+			pr.Out.URL.Host = pr.In.Host
+			pr.Out.Host = pr.In.Host
+		},
+		Transport:     rt,
+		FlushInterval: -1,
+		ErrorLog:      log.New(io.Discard, "", 0),
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if !errors.Is(err, context.Canceled) {
+				dlog.Errorf(ctx, "Error forwarding %s %s: %v", r.Method, r.URL.Path, err)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	if proto == ProtocolHTTP2 {
+		(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Context: ctx, Handler: rp})
+		return
+	}
+
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	srv := &http.Server{
+		Handler:     rp,
+		ErrorLog:    log.New(io.Discard, "", 0),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				closeOnce.Do(func() { close(done) })
+			}
+		},
+	}
+	go func() {
+		_ = srv.Serve(newConnListener(conn))
+	}()
+	select {
+	case <-ctx.Done():
+		_ = srv.Close()
+	case <-done:
+	}
+}
+
+// filterTransport routes the requests that match its filter to a client and all other requests to the target.
+type filterTransport struct {
+	filter *requestFilter
+	client func(sessionID string) http.RoundTripper
+	target http.RoundTripper
+
+	mu      sync.Mutex
+	clients map[string]http.RoundTripper
+}
+
+func (t *filterTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.filter.matches(r) {
+		return t.clientTransport(t.filter.sessionID(r)).RoundTrip(r)
+	}
+	return t.target.RoundTrip(r)
+}
+
+// clientTransport returns the transport to the client of the given session.
+func (t *filterTransport) clientTransport(sessionID string) http.RoundTripper {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rt, ok := t.clients[sessionID]
+	if !ok {
+		rt = t.client(sessionID)
+		t.clients[sessionID] = rt
+	}
+	return rt
+}
+
+func (t *filterTransport) closeIdleConnections() {
+	t.mu.Lock()
+	rts := make([]http.RoundTripper, 0, len(t.clients)+1)
+	for _, rt := range t.clients {
+		rts = append(rts, rt)
+	}
+	t.mu.Unlock()
+	for _, rt := range append(rts, t.target) {
+		if c, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			c.CloseIdleConnections()
+		}
+	}
+}
+
+func http1Transport(dial dialFunc) http.RoundTripper {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx)
+		},
+		DisableCompression: true,
+	}
+}
+
+func h2cTransport(dial dialFunc) http.RoundTripper {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, _, _ string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx)
+		},
+		DisableCompression: true,
+	}
+}
+
+// connListener is a net.Listener that accepts one given connection.
+type connListener struct {
+	conn   chan net.Conn
+	addr   net.Addr
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newConnListener(conn net.Conn) net.Listener {
+	l := &connListener{conn: make(chan net.Conn, 1), addr: conn.LocalAddr(), closed: make(chan struct{})}
+	l.conn <- conn
+	return l
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conn:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// addrConn is a net.Conn that reports the given addresses instead of the addresses of the conn that it wraps.
+type addrConn struct {
+	net.Conn
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *addrConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *addrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
@@ -0,0 +1,199 @@
+package forwarder
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+)
+
+// namedServer returns a h2c capable server that responds with the given name.
+func namedServer(t *testing.T, name string) dialFunc {
+	s := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, name+" "+r.Proto+" "+string(data))
+	}), &http2.Server{}))
+	t.Cleanup(s.Close)
+	return func(ctx context.Context) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", s.Listener.Addr().String())
+	}
+}
+
+// sessionServers returns a function that dials the named servers of the given sessions.
+func sessionServers(t *testing.T, names map[string]string) sessionDialFunc {
+	dials := make(map[string]dialFunc, len(names))
+	for sessionID, name := range names {
+		dials[sessionID] = namedServer(t, name)
+	}
+	return func(ctx context.Context, sessionID string) (net.Conn, error) {
+		return dials[sessionID](ctx)
+	}
+}
+
+func TestServeFiltered(t *testing.T) {
+	filter, err := newRequestFilter(&manager.InterceptInfo{Spec: &manager.InterceptSpec{
+		HttpHeaders:   map[string]string{"x-dev": "alice"},
+		HttpBodyJson:  map[string]string{"type": "order.created"},
+		HttpBodyLimit: 64,
+	}}, nil)
+	require.NoError(t, err)
+	dialClient := sessionServers(t, map[string]string{"": "client"})
+	dialTarget := namedServer(t, "target")
+
+	tests := []struct {
+		name  string
+		proto AppProtocol
+		rt    func(dial func() net.Conn) http.RoundTripper
+	}{
+		{
+			name:  "HTTP/1.1",
+			proto: ProtocolHTTP1,
+			rt: func(dial func() net.Conn) http.RoundTripper {
+				return &http.Transport{DialContext: func(context.Context, string, string) (net.Conn, error) { return dial(), nil }}
+			},
+		},
+		{
+			name:  "HTTP/2.0",
+			proto: ProtocolHTTP2,
+			rt: func(dial func() net.Conn) http.RoundTripper {
+				return &http2.Transport{
+					AllowHTTP:      true,
+					DialTLSContext: func(context.Context, string, string, *tls.Config) (net.Conn, error) { return dial(), nil },
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			dial := func() net.Conn {
+				conn, served := net.Pipe()
+				go serveFiltered(ctx, served, tt.proto, filter, dialClient, dialTarget)
+				return conn
+			}
+			hc := http.Client{Transport: tt.rt(dial)}
+			post := func(dev, body string) string {
+				rq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://orders/", strings.NewReader(body))
+				require.NoError(t, err)
+				rq.Header.Set("Content-Type", "application/json")
+				if dev != "" {
+					rq.Header.Set("X-Dev", dev)
+				}
+				rs, err := hc.Do(rq)
+				require.NoError(t, err)
+				defer rs.Body.Close()
+				data, err := io.ReadAll(rs.Body)
+				require.NoError(t, err)
+				return string(data)
+			}
+			// Requests on the same connection are routed individually, and the bodies that
+			// are inspected reach their destination intact.
+			const created = `{"type": "order.created"}`
+			const deleted = `{"type": "order.deleted"}`
+			large := `{"type": "order.created", "padding": "` + strings.Repeat("x", 64) + `"}`
+			assert.Equal(t, "target "+tt.name+" "+created, post("", created))
+			assert.Equal(t, "client "+tt.name+" "+created, post("alice", created))
+			assert.Equal(t, "target "+tt.name+" "+deleted, post("alice", deleted))
+			assert.Equal(t, "target "+tt.name+" "+created, post("bob", created))
+			assert.Equal(t, "target "+tt.name+" "+large, post("alice", large))
+			assert.Equal(t, "client "+tt.name+" "+created, post("alice", created))
+		})
+	}
+}
+
+func TestServeFilteredPropagation(t *testing.T) {
+	filter, err := newRequestFilter(&manager.InterceptInfo{Spec: &manager.InterceptSpec{
+		HttpHeaders: map[string]string{"x-telepresence-id": "jane"},
+	}}, []matcher.Propagation{matcher.PropagationBaggage, matcher.PropagationB3})
+	require.NoError(t, err)
+	dialClient := sessionServers(t, map[string]string{"": "client"})
+	dialTarget := namedServer(t, "target")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hc := http.Client{Transport: &http.Transport{DialContext: func(context.Context, string, string) (net.Conn, error) {
+		conn, served := net.Pipe()
+		go serveFiltered(ctx, served, ProtocolHTTP1, filter, dialClient, dialTarget)
+		return conn, nil
+	}}}
+	get := func(h http.Header) string {
+		rq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://orders/", nil)
+		require.NoError(t, err)
+		rq.Header = h
+		rs, err := hc.Do(rq)
+		require.NoError(t, err)
+		defer rs.Body.Close()
+		data, err := io.ReadAll(rs.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "client HTTP/1.1 ", get(http.Header{"X-Telepresence-Id": {"jane"}}))
+	assert.Equal(t, "client HTTP/1.1 ", get(http.Header{"Baggage": {"userId=alice,x-telepresence-id=jane"}}))
+	assert.Equal(t, "client HTTP/1.1 ", get(http.Header{"Baggage-X-Telepresence-Id": {"jane"}}))
+	assert.Equal(t, "target HTTP/1.1 ", get(http.Header{"Baggage": {"x-telepresence-id=john"}}))
+	assert.Equal(t, "target HTTP/1.1 ", get(http.Header{
+		"X-Telepresence-Id": {"john"},
+		"Baggage":           {"x-telepresence-id=jane"},
+	}))
+	assert.Equal(t, "target HTTP/1.1 ", get(http.Header{
+		"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"Tracestate":  {"x-telepresence-id@telepresence=jane"},
+	}))
+}
+
+func TestServeFilteredSharedSessions(t *testing.T) {
+	filter, err := newRequestFilter(&manager.InterceptInfo{
+		Spec:                &manager.InterceptSpec{HttpHeaders: map[string]string{"x-dev": "alice"}},
+		ClientSession:       &manager.SessionInfo{SessionId: "alice-session"},
+		SharedSessions:      []*manager.SessionInfo{{SessionId: "bob-session"}},
+		SharedSessionRoutes: map[string]string{"bob-session": "0123456789abcdef"},
+	}, nil)
+	require.NoError(t, err)
+	dialClient := sessionServers(t, map[string]string{"alice-session": "alice", "bob-session": "bob"})
+	dialTarget := namedServer(t, "target")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hc := http.Client{Transport: &http.Transport{DialContext: func(context.Context, string, string) (net.Conn, error) {
+		conn, served := net.Pipe()
+		go serveFiltered(ctx, served, ProtocolHTTP1, filter, dialClient, dialTarget)
+		return conn, nil
+	}}}
+	get := func(dev, route string) string {
+		rq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://orders/", nil)
+		require.NoError(t, err)
+		if dev != "" {
+			rq.Header.Set("X-Dev", dev)
+		}
+		if route != "" {
+			rq.Header.Set("X-Telepresence-Route", route)
+		}
+		rs, err := hc.Do(rq)
+		require.NoError(t, err)
+		defer rs.Body.Close()
+		data, err := io.ReadAll(rs.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+	// Matching requests are routed to the client that created the intercept unless they carry the route of a
+	// client that joined it. Requests that don't match are routed to the target regardless of their route.
+	assert.Equal(t, "alice HTTP/1.1 ", get("alice", ""))
+	assert.Equal(t, "bob HTTP/1.1 ", get("alice", "0123456789abcdef"))
+	assert.Equal(t, "alice HTTP/1.1 ", get("alice", "fedcba9876543210"))
+	assert.Equal(t, "target HTTP/1.1 ", get("", "0123456789abcdef"))
+	assert.Equal(t, "alice HTTP/1.1 ", get("alice", ""))
+}
@@ -2,14 +2,17 @@ package forwarder
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"sync"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
@@ -17,11 +20,18 @@ import (
 type Interceptor interface {
 	io.Closer
 	InterceptId() string
-	InterceptInfo() *restapi.InterceptInfo
+	InterceptInfo(path string, headers http.Header) *restapi.InterceptInfo
 	Serve(context.Context, chan<- net.Addr) error
+	SetHeaderProbes(*HeaderProbes)
 	SetIntercepting(*manager.InterceptInfo)
+	SetPropagations([]matcher.Propagation)
+	SetRecorder(*PortRecorder)
 	SetStreamProvider(tunnel.ClientStreamProvider)
+	SetTerminatingTLS(*tls.Config)
 	Target() (string, uint16)
+
+	// TerminatesTLS returns true if intercepts that request it can have their TLS terminated.
+	TerminatesTLS() bool
 }
 
 type interceptor struct {
@@ -36,8 +46,14 @@ type interceptor struct {
 	targetHost     string
 	targetPort     uint16
 	streamProvider tunnel.ClientStreamProvider
-
-	intercept *manager.InterceptInfo
+	headerProbes   *HeaderProbes
+	recorder       *PortRecorder
+	terminatingTLS *tls.Config
+	propagations   []matcher.Propagation
+
+	intercept    *manager.InterceptInfo
+	bandwidth    *bandwidth
+	opaqueWarned bool
 }
 
 func NewInterceptor(addr net.Addr, targetHost string, targetPort uint16) Interceptor {
@@ -57,6 +73,38 @@ func (f *interceptor) SetStreamProvider(streamProvider tunnel.ClientStreamProvid
 	f.mu.Unlock()
 }
 
+func (f *interceptor) SetHeaderProbes(headerProbes *HeaderProbes) {
+	f.mu.Lock()
+	f.headerProbes = headerProbes
+	f.mu.Unlock()
+}
+
+func (f *interceptor) SetRecorder(recorder *PortRecorder) {
+	f.mu.Lock()
+	f.recorder = recorder
+	f.mu.Unlock()
+}
+
+func (f *interceptor) SetTerminatingTLS(cfg *tls.Config) {
+	f.mu.Lock()
+	f.terminatingTLS = cfg
+	f.mu.Unlock()
+}
+
+// SetPropagations sets the tracing standards that, in addition to plain headers, may carry the headers that
+// the HTTP filters of an intercept match.
+func (f *interceptor) SetPropagations(propagations []matcher.Propagation) {
+	f.mu.Lock()
+	f.propagations = propagations
+	f.mu.Unlock()
+}
+
+func (f *interceptor) TerminatesTLS() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.terminatingTLS != nil
+}
+
 func (f *interceptor) Close() error {
 	f.lCancel()
 	return nil
@@ -69,14 +117,25 @@ func (f *interceptor) Target() (string, uint16) {
 	return f.targetHost, f.targetPort
 }
 
-func (f *interceptor) InterceptInfo() *restapi.InterceptInfo {
+// InterceptInfo returns info about whether a request with the given path and headers is intercepted. A request
+// is intercepted when there's an intercept, and the request matches the intercept's header filters, if any.
+func (f *interceptor) InterceptInfo(path string, headers http.Header) *restapi.InterceptInfo {
 	ii := &restapi.InterceptInfo{}
 	f.mu.Lock()
-	if f.intercept != nil {
+	intercept := f.intercept
+	f.mu.Unlock()
+	if intercept != nil {
+		if hh := intercept.Spec.HttpHeaders; len(hh) > 0 {
+			if filter, err := matcher.NewRequestFromMap(hh); err != nil || !filter.Matches(path, headers) {
+				return ii
+			}
+		}
 		ii.Intercepted = true
-		ii.Metadata = f.intercept.Metadata
+		ii.Metadata = intercept.Metadata
+		ii.ID = intercept.Id
+		ii.Client = intercept.Spec.Client
+		ii.PreviewDomain = intercept.PreviewDomain
 	}
-	f.mu.Unlock()
 	return ii
 }
 
@@ -109,6 +168,8 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 				iputil.JoinHostPort(f.targetHost, f.targetPort), iceptInfo(intercept))
 		} else {
 			if f.intercept.Id == intercept.Id {
+				// Keep the connections, but pick up changes such as clients that joined the intercept.
+				f.intercept = intercept
 				return
 			}
 			dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to intercept %q", iceptInfo(f.intercept), iceptInfo(intercept))
@@ -121,4 +182,20 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 	// Set up new target and lifetime
 	f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
 	f.intercept = intercept
+	f.bandwidth = nil
+	f.opaqueWarned = false
+	if intercept != nil {
+		f.bandwidth = bandwidthOf(intercept.Spec)
+	}
+}
+
+// warnOpaque returns true the first time that it's called for the current intercept with the given id.
+func (f *interceptor) warnOpaque(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.opaqueWarned || f.intercept == nil || f.intercept.Id != id {
+		return false
+	}
+	f.opaqueWarned = true
+	return true
 }
@@ -0,0 +1,282 @@
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+)
+
+const (
+	// maxCapturedRequestSize is the max size of a captured request. Connections that send larger requests
+	// are no longer captured.
+	maxCapturedRequestSize = 4 * 1024 * 1024
+
+	// captureBufferSize is the number of captured requests that can await delivery to a capture. Requests
+	// are dropped when the buffer is full, so that a slow consumer never slows down the forwarded traffic.
+	captureBufferSize = 1024
+
+	// redactedValue replaces the values of the headers in credentialHeaders.
+	redactedValue = "REDACTED"
+)
+
+// credentialHeaders are the lower-case names of the headers whose values are redacted from the captured
+// requests, unless the capture includes credentials.
+var credentialHeaders = map[string]struct{}{ //nolint:gochecknoglobals // constant
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+}
+
+// Recorder keeps track of the captures that are in progress. The data that clients send on forwarded and
+// intercepted connections is parsed as HTTP/1.x requests and delivered to the captures when a capture is
+// in progress at the time when the connection is established.
+type Recorder struct {
+	mu       sync.Mutex
+	captures map[*capture]struct{}
+	active   atomic.Int32
+}
+
+type capture struct {
+	port               uint16
+	includeCredentials bool
+	ch                 chan *agent.CapturedRequest
+	dropped            atomic.Int64
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{captures: make(map[*capture]struct{})}
+}
+
+// Capture starts a capture of the requests sent to the given container port, or to all ports when the
+// port is zero. The captured requests are delivered on the returned channel until the returned stop
+// function is called. The stop function returns the number of requests that were dropped because they
+// weren't received fast enough. The values of headers that carry credentials are redacted unless
+// includeCredentials is true.
+func (r *Recorder) Capture(port uint16, includeCredentials bool) (<-chan *agent.CapturedRequest, func() int64) {
+	c := &capture{port: port, includeCredentials: includeCredentials, ch: make(chan *agent.CapturedRequest, captureBufferSize)}
+	r.mu.Lock()
+	r.captures[c] = struct{}{}
+	r.active.Add(1)
+	r.mu.Unlock()
+	return c.ch, func() int64 {
+		r.mu.Lock()
+		if _, ok := r.captures[c]; ok {
+			delete(r.captures, c)
+			r.active.Add(-1)
+		}
+		r.mu.Unlock()
+		return c.dropped.Load()
+	}
+}
+
+// ForPort returns a PortRecorder that captures the requests sent to the given container port.
+func (r *Recorder) ForPort(port uint16) *PortRecorder {
+	if r == nil {
+		return nil
+	}
+	return &PortRecorder{recorder: r, port: port}
+}
+
+func (r *Recorder) capturing(port uint16) bool {
+	if r.active.Load() == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.captures {
+		if c.port == 0 || c.port == port {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) deliver(cr *agent.CapturedRequest) {
+	port := uint16(cr.Port)
+	var redacted *agent.CapturedRequest
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.captures {
+		if c.port == 0 || c.port == port {
+			dcr := cr
+			if !c.includeCredentials {
+				if redacted == nil {
+					redacted = &agent.CapturedRequest{
+						Time:   cr.Time,
+						Port:   cr.Port,
+						Method: cr.Method,
+						Uri:    cr.Uri,
+						Data:   redactCredentials(cr.Data),
+					}
+				}
+				dcr = redacted
+			}
+			select {
+			case c.ch <- dcr:
+			default:
+				c.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// PortRecorder captures the requests sent to one container port.
+type PortRecorder struct {
+	recorder *Recorder
+	port     uint16
+}
+
+// Reader returns a reader that captures the requests that are read from the given reader, or the given
+// reader when no capture is in progress.
+func (pr *PortRecorder) Reader(r io.Reader) io.Reader {
+	if pr == nil || !pr.recorder.capturing(pr.port) {
+		return r
+	}
+	return &recordReader{Reader: r, recorder: pr}
+}
+
+// Conn returns a net.Conn that captures the requests that are read from the given conn, or the given
+// conn when no capture is in progress.
+func (pr *PortRecorder) Conn(conn net.Conn) net.Conn {
+	if pr == nil || !pr.recorder.capturing(pr.port) {
+		return conn
+	}
+	return &recordConn{Conn: conn, r: &recordReader{Reader: conn, recorder: pr}}
+}
+
+type recordReader struct {
+	io.Reader
+	recorder *PortRecorder
+	buf      []byte
+	start    time.Time
+	done     bool
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 && !r.done {
+		if r.recorder.recorder.active.Load() == 0 {
+			r.stop()
+		} else {
+			if len(r.buf) == 0 {
+				r.start = time.Now()
+			}
+			r.buf = append(r.buf, p[:n]...)
+			r.parse()
+		}
+	}
+	return n, err
+}
+
+func (r *recordReader) stop() {
+	r.done = true
+	r.buf = nil
+}
+
+// parse delivers all complete requests in the buffer and retains what remains. The capture of the connection
+// stops when the data isn't HTTP/1.x, when a request is too large, or when the connection is upgraded to
+// another protocol.
+func (r *recordReader) parse() {
+	for len(r.buf) > 0 {
+		if !bytes.Contains(r.buf, []byte("\r\n\r\n")) && !bytes.Contains(r.buf, []byte("\n\n")) {
+			// The header is incomplete
+			if len(r.buf) > maxCapturedRequestSize {
+				r.stop()
+			}
+			return
+		}
+		br := bytes.NewReader(r.buf)
+		rd := bufio.NewReader(br)
+		rq, err := http.ReadRequest(rd)
+		if err != nil {
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				r.stop()
+			}
+			return
+		}
+		if _, err = io.Copy(io.Discard, rq.Body); err != nil {
+			// The body is incomplete
+			if len(r.buf) > maxCapturedRequestSize {
+				r.stop()
+			}
+			return
+		}
+		consumed := len(r.buf) - br.Len() - rd.Buffered()
+		r.recorder.recorder.deliver(&agent.CapturedRequest{
+			Time:   timestamppb.New(r.start),
+			Port:   int32(r.recorder.port),
+			Method: rq.Method,
+			Uri:    rq.RequestURI,
+			Data:   bytes.Clone(r.buf[:consumed]),
+		})
+		if rq.Header.Get("Upgrade") != "" {
+			r.stop()
+			return
+		}
+		r.buf = r.buf[consumed:]
+		r.start = time.Now()
+	}
+}
+
+// redactCredentials returns a copy of the given request where the values of the headers in credentialHeaders
+// are replaced with redactedValue.
+func redactCredentials(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	rest := data
+	requestLine := true
+	redacting := false
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			return append(out, rest...)
+		}
+		line := rest[:i+1]
+		rest = rest[i+1:]
+		content := bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(content) == 0:
+			// End of header. The body is retained as is.
+			out = append(out, line...)
+			return append(out, rest...)
+		case requestLine:
+			requestLine = false
+		case content[0] == ' ' || content[0] == '\t':
+			// Obsolete line folding continues the value of the previous header.
+			if redacting {
+				continue
+			}
+		default:
+			redacting = false
+			if name, _, ok := bytes.Cut(content, []byte(":")); ok {
+				if _, ok = credentialHeaders[strings.ToLower(string(bytes.TrimSpace(name)))]; ok {
+					redacting = true
+					out = append(out, name...)
+					out = append(out, ": "+redactedValue...)
+					out = append(out, line[len(content):]...)
+					continue
+				}
+			}
+		}
+		out = append(out, line...)
+	}
+}
+
+type recordConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
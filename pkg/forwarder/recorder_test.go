@@ -0,0 +1,87 @@
+package forwarder
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/agent"
+)
+
+func TestRecorder_Reader(t *testing.T) {
+	r := NewRecorder()
+	crCh, stop := r.Capture(8080, true)
+	defer stop()
+
+	// Read one byte at a time to verify that requests that are split between reads are captured.
+	rq1 := "POST /orders?id=7 HTTP/1.1\r\nHost: orders\r\nContent-Length: 5\r\n\r\nhello"
+	rq2 := "GET /health HTTP/1.1\r\nHost: orders\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nok\r\n0\r\n\r\n"
+	data := rq1 + rq2 + "GET /incomp"
+	got, err := io.ReadAll(r.ForPort(8080).Reader(iotest.OneByteReader(strings.NewReader(data))))
+	require.NoError(t, err)
+	assert.Equal(t, data, string(got))
+
+	var crs []*agent.CapturedRequest
+	for len(crCh) > 0 {
+		crs = append(crs, <-crCh)
+	}
+	require.Len(t, crs, 2)
+	assert.Equal(t, "POST", crs[0].Method)
+	assert.Equal(t, "/orders?id=7", crs[0].Uri)
+	assert.Equal(t, int32(8080), crs[0].Port)
+	assert.Equal(t, rq1, string(crs[0].Data))
+	assert.Equal(t, "GET", crs[1].Method)
+	assert.Equal(t, rq2, string(crs[1].Data))
+	assert.False(t, crs[1].Time.AsTime().Before(crs[0].Time.AsTime()))
+}
+
+func TestRecorder_notHTTP(t *testing.T) {
+	r := NewRecorder()
+	crCh, stop := r.Capture(0, true)
+	defer stop()
+	data := "\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03\r\n\r\nGET / HTTP/1.1\r\n\r\n"
+	got, err := io.ReadAll(r.ForPort(8080).Reader(strings.NewReader(data)))
+	require.NoError(t, err)
+	assert.Equal(t, data, string(got))
+	assert.Empty(t, crCh)
+}
+
+func TestRecorder_notCapturing(t *testing.T) {
+	r := NewRecorder()
+	crCh, stop := r.Capture(8081, false)
+	rd := strings.NewReader("data")
+	assert.Same(t, rd, r.ForPort(8080).Reader(rd))
+	assert.NotSame(t, rd, r.ForPort(8081).Reader(rd))
+	stop()
+	assert.Same(t, rd, r.ForPort(8081).Reader(rd))
+	assert.Empty(t, crCh)
+
+	var pr *PortRecorder
+	assert.Same(t, rd, pr.Reader(rd))
+}
+
+func TestRecorder_redactCredentials(t *testing.T) {
+	r := NewRecorder()
+	crCh, stop := r.Capture(0, false)
+	defer stop()
+	credCh, credStop := r.Capture(0, true)
+	defer credStop()
+
+	rq := "POST /login HTTP/1.1\r\nHost: auth\r\nauthorization: Bearer secret\r\nCookie: a=1;\r\n b=2\r\n" +
+		"Content-Length: 14\r\n\r\nCookie: intact"
+	_, err := io.ReadAll(r.ForPort(8080).Reader(strings.NewReader(rq)))
+	require.NoError(t, err)
+
+	require.Len(t, crCh, 1)
+	cr := <-crCh
+	assert.Equal(t, "POST /login HTTP/1.1\r\nHost: auth\r\nauthorization: REDACTED\r\nCookie: REDACTED\r\n"+
+		"Content-Length: 14\r\n\r\nCookie: intact", string(cr.Data))
+	assert.Equal(t, "/login", cr.Uri)
+
+	require.Len(t, credCh, 1)
+	assert.Equal(t, rq, string((<-credCh).Data))
+}
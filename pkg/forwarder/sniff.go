@@ -0,0 +1,132 @@
+package forwarder
+
+import (
+	"bytes"
+	"net"
+	"time"
+)
+
+// AppProtocol is an application protocol that is detected by sniffing the first bytes that a client sends.
+type AppProtocol int
+
+const (
+	// ProtocolOpaque is any protocol that isn't recognized.
+	ProtocolOpaque AppProtocol = iota
+
+	// ProtocolHTTP1 is HTTP/1.0 or HTTP/1.1.
+	ProtocolHTTP1
+
+	// ProtocolHTTP2 is HTTP/2 with prior knowledge (h2c), recognized by the client connection preface.
+	ProtocolHTTP2
+
+	// ProtocolTLS is a TLS handshake.
+	ProtocolTLS
+)
+
+// sniffTimeout is the max time to wait for the first bytes from a client. Protocols where the server
+// speaks first will never send anything, so they end up as opaque when this timeout expires.
+const sniffTimeout = time.Second
+
+// http2Preface is the client connection preface of HTTP/2.
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// maxMethodLength is the length of the longest method that is recognized by sniff.
+const maxMethodLength = len("OPTIONS")
+
+var httpMethods = [][]byte{ //nolint:gochecknoglobals // constant
+	[]byte("CONNECT "),
+	[]byte("DELETE "),
+	[]byte("GET "),
+	[]byte("HEAD "),
+	[]byte("OPTIONS "),
+	[]byte("PATCH "),
+	[]byte("POST "),
+	[]byte("PUT "),
+	[]byte("TRACE "),
+}
+
+func (p AppProtocol) String() string {
+	switch p {
+	case ProtocolHTTP1:
+		return "HTTP/1"
+	case ProtocolHTTP2:
+		return "HTTP/2"
+	case ProtocolTLS:
+		return "TLS"
+	default:
+		return "opaque"
+	}
+}
+
+// IsHTTP returns true if the protocol is HTTP/1.x or HTTP/2.
+func (p AppProtocol) IsHTTP() bool {
+	return p == ProtocolHTTP1 || p == ProtocolHTTP2
+}
+
+// sniff detects the protocol of the given data. It returns true in its second return value if more
+// data is needed in order to tell.
+func sniff(data []byte) (AppProtocol, bool) {
+	if len(data) == 0 {
+		return ProtocolOpaque, true
+	}
+	if data[0] == 0x16 {
+		// TLS handshake record
+		return ProtocolTLS, false
+	}
+	if n := min(len(data), len(http2Preface)); bytes.Equal(data[:n], []byte(http2Preface[:n])) {
+		if n < len(http2Preface) {
+			return ProtocolOpaque, true
+		}
+		return ProtocolHTTP2, false
+	}
+	for _, m := range httpMethods {
+		if n := min(len(data), len(m)); bytes.Equal(data[:n], m[:n]) {
+			if n < len(m) {
+				return ProtocolOpaque, true
+			}
+			return ProtocolHTTP1, false
+		}
+	}
+	return ProtocolOpaque, false
+}
+
+// sniffConn reads the first bytes that the client sends on the given conn and detects their protocol.
+// The returned conn replays the bytes that were read. The protocol is opaque if the client sends
+// nothing before the given timeout expires.
+func sniffConn(conn net.Conn, timeout time.Duration) (AppProtocol, net.Conn, error) {
+	buf := make([]byte, 0, len(http2Preface))
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return ProtocolOpaque, conn, err
+	}
+	proto := ProtocolOpaque
+	for more := true; more && len(buf) < cap(buf); {
+		n, err := conn.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			// A timeout means that the client sent too little to tell. Other errors, such as io.EOF,
+			// are returned again by the next read from the returned conn.
+			proto = ProtocolOpaque
+			break
+		}
+		proto, more = sniff(buf)
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return ProtocolOpaque, conn, err
+	}
+	return proto, &peekedConn{Conn: conn, peeked: buf}, nil
+}
+
+// peekedConn is a net.Conn that replays data that has already been read from it.
+type peekedConn struct {
+	net.Conn
+	peeked []byte
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	if len(c.peeked) > 0 {
+		n := copy(p, c.peeked)
+		c.peeked = c.peeked[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
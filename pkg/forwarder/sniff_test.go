@@ -0,0 +1,67 @@
+package forwarder
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		data  string
+		proto AppProtocol
+		more  bool
+	}{
+		{"", ProtocolOpaque, true},
+		{"GE", ProtocolOpaque, true},
+		{"GET / HTTP/1.1\r\n", ProtocolHTTP1, false},
+		{"OPTIONS * HTTP/1.1\r\n", ProtocolHTTP1, false},
+		{"PRI * HTTP/2.0", ProtocolOpaque, true},
+		{http2Preface, ProtocolHTTP2, false},
+		{"PRI * HTTP/1.1\r\n", ProtocolOpaque, false},
+		{"\x16\x03\x01", ProtocolTLS, false},
+		{"\x00\x00\x00\x0c", ProtocolOpaque, false},
+		{"GETTER", ProtocolOpaque, false},
+	}
+	for _, tt := range tests {
+		proto, more := sniff([]byte(tt.data))
+		assert.Equal(t, tt.proto, proto, "%q", tt.data)
+		assert.Equal(t, tt.more, more, "%q", tt.data)
+	}
+}
+
+func TestSniffConn(t *testing.T) {
+	const rq = "POST /orders HTTP/1.1\r\nHost: orders\r\nContent-Length: 5\r\n\r\nhello"
+	client, server := net.Pipe()
+	go func() {
+		_, _ = io.WriteString(client, rq)
+		_ = client.Close()
+	}()
+	proto, conn, err := sniffConn(server, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolHTTP1, proto)
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, rq, string(data))
+}
+
+func TestSniffConn_serverSpeaksFirst(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	proto, conn, err := sniffConn(server, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolOpaque, proto)
+
+	// The conn remains usable after the timeout.
+	go func() {
+		_, _ = io.WriteString(client, "hello")
+		_ = client.Close()
+	}()
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
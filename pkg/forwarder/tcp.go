@@ -14,6 +14,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
@@ -98,9 +99,28 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	targetHost := f.targetHost
 	targetPort := f.targetPort
 	intercept := f.intercept
+	headerProbes := f.headerProbes
+	recorder := f.recorder
+	terminatingTLS := f.terminatingTLS
+	bandwidth := f.bandwidth
+	propagations := f.propagations
 	f.mu.Unlock()
 	if intercept != nil {
-		return f.interceptConn(ctx, clientConn, intercept)
+		var conn net.Conn = clientConn
+		if intercept.Spec.TerminateTls && terminatingTLS != nil {
+			var err error
+			if conn, err = terminateTLS(ctx, conn, terminatingTLS); err != nil {
+				return err
+			}
+		}
+		conn = headerProbes.Conn(recorder.Conn(conn))
+		// The requests are filtered when others have joined the intercept, so that those that carry a route
+		// reach the client that joined it.
+		if hasRequestFilter(intercept.Spec) || len(intercept.SharedSessionRoutes) > 0 {
+			return f.filterConn(ctx, conn, intercept, propagations, iputil.JoinHostPort(targetHost, targetPort), bandwidth)
+		}
+		conn = faultsOf(intercept.Spec).Conn(bandwidth.Conn(conn))
+		return f.interceptConn(ctx, conn, intercept, intercept.ClientSession.SessionId)
 	}
 
 	targetAddr, err := net.ResolveTCPAddr("tcp", iputil.JoinHostPort(targetHost, targetPort))
@@ -129,7 +149,7 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	done := make(chan struct{})
 
 	go func() {
-		if _, err := io.Copy(targetConn, clientConn); err != nil {
+		if _, err := io.Copy(targetConn, headerProbes.Reader(recorder.Reader(clientConn))); err != nil {
 			dlog.Debugf(ctx, "Error clientConn->targetConn: %+v", err)
 		}
 		_ = targetConn.CloseWrite()
@@ -155,7 +175,55 @@ func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	return nil
 }
 
-func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo) error {
+// filterConn routes the HTTP requests that match the intercept's header and body filters to the client, and all
+// other requests to the target. A matching request that carries the route of a client that joined the intercept is
+// routed to that client. A connection that isn't HTTP/1.x or HTTP/2 is routed to the client that created the
+// intercept in full. The given propagations may carry the headers that the filters match.
+func (f *tcp) filterConn(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo, propagations []matcher.Propagation, targetAddr string, bw *bandwidth) error {
+	defer conn.Close()
+	filter, err := newRequestFilter(iCept, propagations)
+	if err != nil {
+		return fmt.Errorf("invalid filters of intercept %s: %w", iCept.Spec.Name, err)
+	}
+	proto, conn, err := sniffConn(conn, sniffTimeout)
+	if err != nil {
+		return err
+	}
+	intercept := func(conn net.Conn, sessionID string) error {
+		return f.interceptConn(ctx, faultsOf(iCept.Spec).Conn(bw.Conn(conn)), iCept, sessionID)
+	}
+	if !proto.IsHTTP() {
+		if hasRequestFilter(iCept.Spec) && f.warnOpaque(iCept.Id) {
+			dlog.Warnf(ctx, "Intercept %s has HTTP filters, but its connections are %s and not HTTP. Routing all of them to the client",
+				iCept.Spec.Name, proto)
+		}
+		return intercept(conn, iCept.ClientSession.SessionId)
+	}
+
+	ctx = dlog.WithField(ctx, "client", conn.RemoteAddr().String())
+	dlog.Debugf(ctx, "Filtering %s requests", proto)
+	defer dlog.Debugf(ctx, "Done filtering %s requests", proto)
+	dialClient := func(_ context.Context, sessionID string) (net.Conn, error) {
+		// The client end of the pipe reports the addresses of the original connection, so
+		// that the client sees where the requests come from.
+		pc, cc := net.Pipe()
+		go func() {
+			if err := intercept(&addrConn{Conn: cc, local: conn.LocalAddr(), remote: conn.RemoteAddr()}, sessionID); err != nil {
+				dlog.Error(ctx, err)
+			}
+		}()
+		return pc, nil
+	}
+	dialTarget := func(ctx context.Context) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", targetAddr)
+	}
+	serveFiltered(ctx, conn, proto, filter, dialClient, dialTarget)
+	return nil
+}
+
+// interceptConn routes the given connection to the client of the given session.
+func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo, clientSession string) error {
 	ctx, span := otel.Tracer("").Start(ctx, "interceptConn")
 	defer span.End()
 	tracing.RecordInterceptInfo(span, iCept)
@@ -170,7 +238,6 @@ func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.I
 
 	spec := iCept.Spec
 	destIp := iputil.Parse(spec.TargetHost)
-	clientSession := iCept.ClientSession.SessionId
 	id := tunnel.NewConnID(ipproto.Parse(addr.Network()), srcIp, destIp, srcPort, uint16(spec.TargetPort))
 	id.SpanRecord(span)
 	ctx, cancel := context.WithCancel(ctx)
@@ -0,0 +1,348 @@
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tlsHandshakeTimeout = 10 * time.Second
+
+// ClientCertHeader is the header that carries the identity of the client certificate of a connection that
+// was TLS terminated by the traffic-agent. The value uses the format of Envoy's x-forwarded-client-cert
+// header, e.g. `Hash=<sha256>;Subject="CN=frontend";URI=spiffe://cluster.local/ns/default/sa/frontend`.
+const ClientCertHeader = "X-Forwarded-Client-Cert"
+
+// LoadTerminatingTLS loads the TLS configuration used when terminating TLS from the tls.crt and tls.key files in
+// the given directory. Client certificates are required and verified using the ca.crt file when it exists, and
+// aren't requested when it doesn't. A nil configuration and no error is returned when the directory has no tls.crt
+// file.
+func LoadTerminatingTLS(dir string) (*tls.Config, error) {
+	certFile := filepath.Join(dir, "tls.crt")
+	if _, err := os.Stat(certFile); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, filepath.Join(dir, "tls.key"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load terminating TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// The identity is injected into HTTP/1.x requests, so HTTP/2 can't be offered.
+		NextProtos: []string{"http/1.1"},
+		MinVersion: tls.VersionTLS12,
+	}
+	caData, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	switch {
+	case err == nil:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in %s", filepath.Join(dir, "ca.crt"))
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case errors.Is(err, fs.ErrNotExist):
+		// Without a CA, a client certificate can't be verified, and its identity can't be trusted.
+		cfg.ClientAuth = tls.NoClientCert
+	default:
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// terminateTLS performs a TLS server handshake on the given connection and returns a connection that reads
+// the decrypted HTTP/1.x requests with the ClientCertHeader set to the identity of the client certificate. Only
+// certificates that were verified during the handshake are used. A ClientCertHeader sent by the client is always
+// removed.
+func terminateTLS(ctx context.Context, conn net.Conn, cfg *tls.Config) (net.Conn, error) {
+	tc := tls.Server(conn, cfg)
+	ctx, cancel := context.WithTimeout(ctx, tlsHandshakeTimeout)
+	defer cancel()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = tc.Close()
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", conn.RemoteAddr(), err)
+	}
+	var identity string
+	if vcs := tc.ConnectionState().VerifiedChains; len(vcs) > 0 && len(vcs[0]) > 0 {
+		identity = ClientCertIdentity(vcs[0][0])
+	}
+	pr, pw := io.Pipe()
+	ic := &identityConn{Conn: tc, r: pr}
+	go func() {
+		_ = pw.CloseWithError(ic.injectIdentity(bufio.NewReader(tc), pw, identity))
+	}()
+	return ic, nil
+}
+
+// maxRequestHeadBytes limits the size of the request line and headers of a request, like http.DefaultMaxHeaderBytes
+// does for an http.Server.
+const maxRequestHeadBytes = http.DefaultMaxHeaderBytes
+
+// requestHead is the request line and the header lines of an HTTP/1.x request, exactly as the client sent them,
+// except for the ClientCertHeader lines, which are omitted.
+type requestHead struct {
+	method        string
+	lines         []byte
+	contentLength int64
+	chunked       bool
+	upgrade       bool
+}
+
+// readRequestHead reads the request line and the headers of the next request. It returns io.EOF when the client
+// closes the connection between requests.
+func readRequestHead(r *bufio.Reader) (*requestHead, error) {
+	rh := &requestHead{}
+	var connUpgrade, hasUpgrade, omit bool
+	size := 0
+	for first := true; ; first = false {
+		line, err := r.ReadSlice('\n')
+		if err != nil {
+			switch {
+			case errors.Is(err, bufio.ErrBufferFull):
+				err = errors.New("request header line is too long")
+			case errors.Is(err, io.EOF) && (!first || len(line) > 0):
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if size += len(line); size > maxRequestHeadBytes {
+			return nil, errors.New("request header is too large")
+		}
+		text := strings.TrimRight(string(line), "\r\n")
+		switch {
+		case first:
+			method, _, ok := strings.Cut(text, " ")
+			if !ok {
+				return nil, fmt.Errorf("malformed request line %q", text)
+			}
+			rh.method = method
+		case text == "":
+			rh.upgrade = connUpgrade && hasUpgrade
+			rh.lines = append(rh.lines, line...)
+			return rh, nil
+		case text[0] == ' ' || text[0] == '\t':
+			// A continuation of the previous header line.
+			if omit {
+				continue
+			}
+		default:
+			name, value, ok := strings.Cut(text, ":")
+			if !ok {
+				return nil, fmt.Errorf("malformed request header line %q", text)
+			}
+			value = strings.TrimSpace(value)
+			switch omit = false; http.CanonicalHeaderKey(strings.TrimSpace(name)) {
+			case ClientCertHeader:
+				omit = true
+				continue
+			case "Content-Length":
+				if rh.contentLength, err = strconv.ParseInt(value, 10, 64); err != nil || rh.contentLength < 0 {
+					return nil, fmt.Errorf("malformed Content-Length %q", value)
+				}
+			case "Transfer-Encoding":
+				codings := strings.Split(value, ",")
+				rh.chunked = strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked")
+			case "Connection":
+				for _, opt := range strings.Split(value, ",") {
+					if strings.EqualFold(strings.TrimSpace(opt), "upgrade") {
+						connUpgrade = true
+					}
+				}
+			case "Upgrade":
+				hasUpgrade = true
+			}
+		}
+		rh.lines = append(rh.lines, line...)
+	}
+}
+
+// write writes the request head to w, with a ClientCertHeader with the given identity unless it's empty.
+func (rh *requestHead) write(w io.Writer, identity string) error {
+	head := rh.lines
+	if identity != "" {
+		// The identity goes last, just before the empty line that ends the head.
+		end := bytes.LastIndex(head[:len(head)-1], []byte{'\n'}) + 1
+		head = slices.Concat(head[:end], []byte(ClientCertHeader+": "+identity+"\r\n"), head[end:])
+	}
+	_, err := w.Write(head)
+	return err
+}
+
+// copyChunked copies a chunked body, including its trailers, from r to w.
+func copyChunked(r *bufio.Reader, w io.Writer) error {
+	for {
+		line, err := r.ReadSlice('\n')
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(line); err != nil {
+			return err
+		}
+		sizeText, _, _ := strings.Cut(strings.TrimSpace(string(line)), ";")
+		size, err := strconv.ParseUint(strings.TrimSpace(sizeText), 16, 62)
+		if err != nil {
+			return fmt.Errorf("malformed chunk size %q", sizeText)
+		}
+		if size == 0 {
+			// The trailers end with an empty line.
+			for {
+				if line, err = r.ReadSlice('\n'); err != nil {
+					return err
+				}
+				if _, err = w.Write(line); err != nil {
+					return err
+				}
+				if len(bytes.TrimRight(line, "\r\n")) == 0 {
+					return nil
+				}
+			}
+		}
+		// The chunk data is followed by a CRLF.
+		if _, err = io.CopyN(w, r, int64(size)+2); err != nil {
+			return err
+		}
+	}
+}
+
+// injectIdentity copies the HTTP/1.x requests read from r to w, replacing the ClientCertHeader of each request.
+// Everything else is copied unchanged. Once the server has switched protocols in response to an Upgrade request,
+// or accepted a CONNECT request, the rest of the stream is no longer HTTP/1.x, and is copied as is.
+func (c *identityConn) injectIdentity(r *bufio.Reader, w io.Writer, identity string) error {
+	for {
+		rh, err := readRequestHead(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		var switched <-chan bool
+		if rh.upgrade || rh.method == http.MethodConnect {
+			// The response must be watched before the server gets the request.
+			switched = c.watchResponse(rh.method)
+		}
+		if err = rh.write(w, identity); err != nil {
+			return err
+		}
+		switch {
+		case rh.chunked:
+			err = copyChunked(r, w)
+		case rh.contentLength > 0:
+			_, err = io.CopyN(w, r, rh.contentLength)
+		}
+		if err != nil {
+			return err
+		}
+		if switched != nil && <-switched {
+			_, err = io.Copy(w, r)
+			return err
+		}
+	}
+}
+
+// ClientCertIdentity returns the identity of the given certificate, formatted as the value of a ClientCertHeader.
+func ClientCertIdentity(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	parts := []string{
+		"Hash=" + hex.EncodeToString(hash[:]),
+		"Subject=" + strconv.Quote(cert.Subject.String()),
+	}
+	for _, u := range cert.URIs {
+		parts = append(parts, "URI="+u.String())
+	}
+	for _, n := range cert.DNSNames {
+		parts = append(parts, "DNS="+n)
+	}
+	return strings.Join(parts, ";")
+}
+
+type identityConn struct {
+	*tls.Conn
+	r io.Reader
+
+	// respW receives a copy of what's written to the connection while a response is watched.
+	mu    sync.Mutex
+	respW *io.PipeWriter
+}
+
+func (c *identityConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *identityConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.mu.Lock()
+	respW := c.respW
+	c.mu.Unlock()
+	if respW != nil && n > 0 {
+		// Fails when the watch ended while the response was written, which is fine.
+		_, _ = respW.Write(p[:n])
+	}
+	return n, err
+}
+
+func (c *identityConn) Close() error {
+	c.mu.Lock()
+	if c.respW != nil {
+		_ = c.respW.CloseWithError(net.ErrClosed)
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// watchResponse watches the response that is written to the connection for a request with the given method, and
+// reports whether the server switched protocols in response to an Upgrade request, or accepted a CONNECT request.
+// Informational responses, such as 100 Continue, are skipped. A response that can't be parsed is reported as not
+// switching, which means that the requests that follow are still parsed.
+func (c *identityConn) watchResponse(method string) <-chan bool {
+	pr, pw := io.Pipe()
+	c.mu.Lock()
+	c.respW = pw
+	c.mu.Unlock()
+	switched := make(chan bool, 1)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.respW = nil
+			c.mu.Unlock()
+			_ = pr.Close()
+		}()
+		br := bufio.NewReader(pr)
+		rq := &http.Request{Method: method}
+		for {
+			resp, err := http.ReadResponse(br, rq)
+			switch {
+			case err != nil:
+				switched <- false
+			case method == http.MethodConnect && resp.StatusCode/100 != 1:
+				switched <- resp.StatusCode/100 == 2
+			case resp.StatusCode == http.StatusSwitchingProtocols || resp.StatusCode/100 != 1:
+				switched <- resp.StatusCode == http.StatusSwitchingProtocols
+			default:
+				continue
+			}
+			return
+		}
+	}()
+	return switched
+}
@@ -0,0 +1,276 @@
+package forwarder
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, mod func(*x509.Certificate)) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if mod != nil {
+		mod(tpl)
+	}
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+}
+
+func TestLoadTerminatingTLS(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadTerminatingTLS(dir)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	ca, caKey := newTestCert(t, "ca", nil, nil, func(c *x509.Certificate) {
+		c.IsCA = true
+		c.BasicConstraintsValid = true
+		c.KeyUsage = x509.KeyUsageCertSign
+	})
+	srv, srvKey := newTestCert(t, "echo", ca, caKey, nil)
+	writePEM(t, filepath.Join(dir, "tls.crt"), "CERTIFICATE", srv.Raw)
+	keyDER, err := x509.MarshalECPrivateKey(srvKey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "tls.key"), "EC PRIVATE KEY", keyDER)
+
+	cfg, err = LoadTerminatingTLS(dir)
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", ca.Raw)
+	cfg, err = LoadTerminatingTLS(dir)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+}
+
+// newTerminatingConfigs returns a server configuration that verifies client certificates, and a client
+// configuration with a certificate that it verifies.
+func newTerminatingConfigs(t *testing.T) (serverCfg, clientCfg *tls.Config, client *x509.Certificate) {
+	ca, caKey := newTestCert(t, "ca", nil, nil, func(c *x509.Certificate) {
+		c.IsCA = true
+		c.BasicConstraintsValid = true
+		c.KeyUsage = x509.KeyUsageCertSign
+	})
+	srv, srvKey := newTestCert(t, "echo", ca, caKey, func(c *x509.Certificate) {
+		c.DNSNames = []string{"echo"}
+	})
+	spiffe, err := url.Parse("spiffe://cluster.local/ns/default/sa/frontend")
+	require.NoError(t, err)
+	cl, clKey := newTestCert(t, "frontend", ca, caKey, func(c *x509.Certificate) {
+		c.URIs = []*url.URL{spiffe}
+	})
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	serverCfg = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		NextProtos:   []string{"http/1.1"},
+	}
+	clientCfg = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cl.Raw}, PrivateKey: clKey}},
+		RootCAs:      pool,
+		ServerName:   "echo",
+	}
+	return serverCfg, clientCfg, cl
+}
+
+func TestTerminateTLS(t *testing.T) {
+	serverCfg, clientCfg, cl := newTerminatingConfigs(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientSide, serverSide := net.Pipe()
+	go func() {
+		tc := tls.Client(clientSide, clientCfg)
+		defer tc.Close()
+		rq, _ := http.NewRequest(http.MethodGet, "http://echo/hello", nil)
+		rq.Header.Set(ClientCertHeader, "spoofed")
+		_ = rq.Write(tc)
+		_, _ = tc.Read(make([]byte, 1))
+	}()
+
+	conn, err := terminateTLS(ctx, serverSide, serverCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	rq, err := http.ReadRequest(bufio.NewReader(conn))
+	require.NoError(t, err)
+	assert.Equal(t, "/hello", rq.URL.Path)
+	xfcc := rq.Header.Values(ClientCertHeader)
+	require.Len(t, xfcc, 1)
+	assert.Equal(t, ClientCertIdentity(cl), xfcc[0])
+	assert.True(t, strings.Contains(xfcc[0], `Subject="CN=frontend"`))
+	assert.True(t, strings.HasSuffix(xfcc[0], ";URI=spiffe://cluster.local/ns/default/sa/frontend"))
+}
+
+// readHead reads the request or response line and the headers from r, as is.
+func readHead(t *testing.T, r *bufio.Reader) string {
+	var sb strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		sb.WriteString(line)
+		if line == "\r\n" {
+			return sb.String()
+		}
+	}
+}
+
+func TestTerminateTLS_upgrade(t *testing.T) {
+	serverCfg, clientCfg, cl := newTerminatingConfigs(t)
+
+	// What the client sends after the switch isn't HTTP, and must reach the server unchanged.
+	const upgraded = "X-Forwarded-Client-Cert: spoofed\r\n\r\nGET / HTTP/1.1\r\n"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientSide, serverSide := net.Pipe()
+	go func() {
+		tc := tls.Client(clientSide, clientCfg)
+		defer tc.Close()
+		_, _ = io.WriteString(tc, "GET /ws HTTP/1.1\r\n"+
+			"Host: echo\r\n"+
+			"Connection: Upgrade\r\n"+
+			"X-Forwarded-Client-Cert: spoofed\r\n"+
+			"Upgrade: websocket\r\n"+
+			"\r\n")
+		resp, err := http.ReadResponse(bufio.NewReader(tc), nil)
+		if err == nil && resp.StatusCode == http.StatusSwitchingProtocols {
+			_, _ = io.WriteString(tc, upgraded)
+		}
+		_, _ = tc.Read(make([]byte, 1))
+	}()
+
+	conn, err := terminateTLS(ctx, serverSide, serverCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+
+	// Only the ClientCertHeader is changed. Nothing, such as a User-Agent, is added.
+	assert.Equal(t, "GET /ws HTTP/1.1\r\n"+
+		"Host: echo\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: websocket\r\n"+
+		ClientCertHeader+": "+ClientCertIdentity(cl)+"\r\n"+
+		"\r\n", readHead(t, br))
+	_, err = io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	require.NoError(t, err)
+	data := make([]byte, len(upgraded))
+	_, err = io.ReadFull(br, data)
+	require.NoError(t, err)
+	assert.Equal(t, upgraded, string(data))
+}
+
+func TestTerminateTLS_rejectedUpgrade(t *testing.T) {
+	serverCfg, clientCfg, cl := newTerminatingConfigs(t)
+
+	// When the server doesn't switch protocols, the requests that follow must still get the identity.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientSide, serverSide := net.Pipe()
+	go func() {
+		tc := tls.Client(clientSide, clientCfg)
+		defer tc.Close()
+		_, _ = io.WriteString(tc, "GET /ws HTTP/1.1\r\nHost: echo\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		if _, err := http.ReadResponse(bufio.NewReader(tc), nil); err == nil {
+			_, _ = io.WriteString(tc, "POST /echo HTTP/1.1\r\n"+
+				"Host: echo\r\n"+
+				"Transfer-Encoding: chunked\r\n"+
+				"X-Forwarded-Client-Cert: spoofed\r\n"+
+				"\r\n"+
+				"5\r\nhello\r\n0\r\n\r\n")
+		}
+		_, _ = tc.Read(make([]byte, 1))
+	}()
+
+	conn, err := terminateTLS(ctx, serverSide, serverCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	rq, err := http.ReadRequest(br)
+	require.NoError(t, err)
+	assert.Equal(t, "/ws", rq.URL.Path)
+	_, err = io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n")
+	require.NoError(t, err)
+
+	rq, err = http.ReadRequest(br)
+	require.NoError(t, err)
+	assert.Equal(t, "/echo", rq.URL.Path)
+	assert.Equal(t, []string{ClientCertIdentity(cl)}, rq.Header.Values(ClientCertHeader))
+	body, err := io.ReadAll(rq.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
+
+func TestTerminateTLS_unverifiedClientCert(t *testing.T) {
+	srv, srvKey := newTestCert(t, "echo", nil, nil, func(c *x509.Certificate) {
+		c.DNSNames = []string{"echo"}
+	})
+	forged, forgedKey := newTestCert(t, "admin", nil, nil, nil)
+
+	// A configuration that requests, but doesn't verify, client certificates must not yield an identity.
+	serverCfg := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey}},
+		ClientAuth:   tls.RequestClientCert,
+		NextProtos:   []string{"http/1.1"},
+	}
+	clientCfg := &tls.Config{
+		Certificates:       []tls.Certificate{{Certificate: [][]byte{forged.Raw}, PrivateKey: forgedKey}},
+		InsecureSkipVerify: true, //nolint:gosec // the server certificate is irrelevant to the test
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clientSide, serverSide := net.Pipe()
+	go func() {
+		tc := tls.Client(clientSide, clientCfg)
+		defer tc.Close()
+		rq, _ := http.NewRequest(http.MethodGet, "http://echo/hello", nil)
+		rq.Header.Set(ClientCertHeader, "spoofed")
+		_ = rq.Write(tc)
+		_, _ = tc.Read(make([]byte, 1))
+	}()
+
+	conn, err := terminateTLS(ctx, serverSide, serverCfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	rq, err := http.ReadRequest(bufio.NewReader(conn))
+	require.NoError(t, err)
+	assert.Empty(t, rq.Header.Values(ClientCertHeader))
+}
@@ -217,3 +217,8 @@ func (f *udp) interceptConn(ctx context.Context, conn *net.UDPConn, iCept *manag
 	d.Start(ctx)
 	<-d.Done()
 }
+
+// TerminatesTLS always returns false, because TLS isn't used with UDP.
+func (f *udp) TerminatesTLS() bool {
+	return false
+}
@@ -0,0 +1,96 @@
+// Package imageref qualifies container image references and resolves them to the digests of their manifests.
+package imageref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containerd/containerd/images"
+	"github.com/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/pkg/auth"
+	dockerauth "oras.land/oras-go/pkg/auth/docker"
+)
+
+// Qualify returns the fully qualified form of the given image reference, e.g. "docker.io/library/nginx:latest"
+// for "nginx".
+func Qualify(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %w", ref, err)
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
+
+// Resolver resolves image references to the digests of their manifests, using the credentials of the Docker
+// configuration of the current user.
+type Resolver struct {
+	// PlainHTTP makes the resolver use HTTP instead of HTTPS. It's intended for local registries.
+	PlainHTTP bool
+}
+
+// Digest returns the digest of the manifest, or the manifest list of a multi-arch image, that the given image
+// reference refers to.
+func (r *Resolver) Digest(ctx context.Context, ref string) (string, error) {
+	return r.PlatformDigest(ctx, ref, "", "")
+}
+
+// PlatformDigest returns the digest of the manifest for the given operating system and architecture when the given
+// image reference refers to a multi-arch image. The digest of the manifest list is returned when the architecture
+// is empty, and the digest of the manifest is returned when the image isn't a multi-arch image.
+func (r *Resolver) PlatformDigest(ctx context.Context, ref, os, arch string) (string, error) {
+	qr, err := Qualify(ref)
+	if err != nil {
+		return "", err
+	}
+	ac, err := dockerauth.NewClient()
+	if err != nil {
+		return "", err
+	}
+	var opts []auth.ResolverOption
+	if r.PlainHTTP {
+		opts = append(opts, auth.WithResolverPlainHTTP())
+	}
+	rs, err := ac.ResolverWithOpts(opts...)
+	if err != nil {
+		return "", err
+	}
+	_, desc, err := rs.Resolve(ctx, qr)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w", ref, err)
+	}
+	if arch == "" || !images.IsIndexType(desc.MediaType) {
+		return desc.Digest.String(), nil
+	}
+
+	f, err := rs.Fetcher(ctx, qr)
+	if err != nil {
+		return "", err
+	}
+	rc, err := f.Fetch(ctx, desc)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch the manifest list of %s: %w", ref, err)
+	}
+	defer rc.Close()
+	var index ocispec.Index
+	if err = json.NewDecoder(io.LimitReader(rc, desc.Size)).Decode(&index); err != nil {
+		return "", fmt.Errorf("unable to decode the manifest list of %s: %w", ref, err)
+	}
+	for _, m := range index.Manifests {
+		if p := m.Platform; p != nil && p.Architecture == arch && (os == "" || p.OS == os) {
+			return m.Digest.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no image for platform %s", ref, strings.TrimPrefix(os+"/"+arch, "/"))
+}
+
+// WithoutDigest returns the given image reference without its digest, if any.
+func WithoutDigest(ref string) string {
+	if i := strings.IndexByte(ref, '@'); i >= 0 {
+		return ref[:i]
+	}
+	return ref
+}
@@ -0,0 +1,99 @@
+package imageref
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestQualify(t *testing.T) {
+	for ref, want := range map[string]string{
+		"nginx":                           "docker.io/library/nginx:latest",
+		"datawire/tel2:2.21.0":            "docker.io/datawire/tel2:2.21.0",
+		"ghcr.io/telepresenceio/tel2:2.2": "ghcr.io/telepresenceio/tel2:2.2",
+	} {
+		got, err := Qualify(ref)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := Qualify("Invalid:Ref:")
+	assert.Error(t, err)
+}
+
+func TestResolverDigest(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/telepresenceio/tel2/manifests/2.21.0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	ctx := dlog.NewTestContext(t, false)
+	r := &Resolver{PlainHTTP: true}
+	got, err := r.Digest(ctx, host+"/telepresenceio/tel2:2.21.0")
+	require.NoError(t, err)
+	assert.Equal(t, digest, got)
+
+	_, err = r.Digest(ctx, host+"/telepresenceio/tel2:2.20.0")
+	assert.ErrorContains(t, err, "unable to resolve")
+}
+
+func TestResolverPlatformDigest(t *testing.T) {
+	const (
+		amd64 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		arm64 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	index := fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":%q,"size":500,"platform":{"os":"linux","architecture":"amd64"}},
+{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":%q,"size":500,"platform":{"os":"linux","architecture":"arm64"}}]}`,
+		amd64, arm64)
+	indexDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(index)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/telepresenceio/tel2/manifests/2.21.0", "/v2/telepresenceio/tel2/manifests/" + indexDigest:
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+		w.Header().Set("Docker-Content-Digest", indexDigest)
+		w.Header().Set("Content-Length", fmt.Sprint(len(index)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(index))
+		}
+	}))
+	defer srv.Close()
+	img := strings.TrimPrefix(srv.URL, "http://") + "/telepresenceio/tel2:2.21.0"
+
+	ctx := dlog.NewTestContext(t, false)
+	r := &Resolver{PlainHTTP: true}
+	got, err := r.PlatformDigest(ctx, img, "linux", "arm64")
+	require.NoError(t, err)
+	assert.Equal(t, arm64, got)
+
+	got, err = r.PlatformDigest(ctx, img, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, indexDigest, got)
+
+	_, err = r.PlatformDigest(ctx, img, "linux", "s390x")
+	assert.ErrorContains(t, err, "has no image for platform linux/s390x")
+}
+
+func TestWithoutDigest(t *testing.T) {
+	assert.Equal(t, "ghcr.io/telepresenceio/tel2:2.21.0", WithoutDigest("ghcr.io/telepresenceio/tel2:2.21.0@sha256:abc"))
+	assert.Equal(t, "tel2:2.21.0", WithoutDigest("tel2:2.21.0"))
+}
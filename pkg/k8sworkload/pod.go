@@ -0,0 +1,56 @@
+package k8sworkload
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// PodKind is the kind of the workload that represents a bare pod, i.e. a pod that is intercepted
+// directly rather than through the workload that controls it.
+const PodKind = "Pod"
+
+// GetPod returns the pod with the given name in the given namespace as a workload.
+func GetPod(c context.Context, name, namespace string) (k8sapi.Workload, error) {
+	p, err := k8sapi.GetK8sInterface(c).CoreV1().Pods(namespace).Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return Pod(p), nil
+}
+
+// Pod returns a k8sapi.Workload that wraps the given *core.Pod. The pod template of the workload
+// is the metadata and spec of the pod itself.
+func Pod(p *core.Pod) k8sapi.Workload {
+	return barePod{k8sapi.Pod(p)}
+}
+
+// PodImpl casts the given Object as an *core.Pod and returns it together with a status flag
+// indicating whether the cast was possible.
+func PodImpl(o k8sapi.Object) (*core.Pod, bool) {
+	if bp, ok := o.(barePod); ok {
+		return k8sapi.PodImpl(bp.Object)
+	}
+	return nil, false
+}
+
+type barePod struct {
+	k8sapi.Object
+}
+
+func (o barePod) GetPodTemplate() *core.PodTemplateSpec {
+	p, _ := k8sapi.PodImpl(o.Object)
+	return &core.PodTemplateSpec{ObjectMeta: p.ObjectMeta, Spec: p.Spec}
+}
+
+func (o barePod) Replicas() int {
+	return 1
+}
+
+// Updated always returns true, because the spec of a pod is never rolled out.
+func (o barePod) Updated(int64) bool {
+	return true
+}
@@ -0,0 +1,82 @@
+package k8sworkload
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+const (
+	// DeploymentRevisionAnnotation is the annotation that a Deployment controller puts on its ReplicaSets.
+	DeploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+	// RolloutRevisionAnnotation is the annotation that an Argo Rollout controller puts on its ReplicaSets.
+	RolloutRevisionAnnotation = "rollout.argoproj.io/revision"
+
+	// PodTemplateHashLabel is the label that a Deployment controller puts on its ReplicaSets and their pods.
+	PodTemplateHashLabel = apps.DefaultDeploymentUniqueLabelKey
+
+	// RolloutPodTemplateHashLabel is the label that an Argo Rollout controller puts on its ReplicaSets and
+	// their pods.
+	RolloutPodTemplateHashLabel = "rollouts-pod-template-hash"
+)
+
+// OwnedReplicaSets returns the ReplicaSets that are controlled by the given Deployment or Rollout,
+// sorted by revision.
+func OwnedReplicaSets(c context.Context, wl k8sapi.Workload) ([]*apps.ReplicaSet, error) {
+	switch k := wl.GetKind(); k {
+	case "Deployment", "Rollout":
+	default:
+		return nil, fmt.Errorf("a %s has no revisions", k)
+	}
+	ls, err := k8sapi.GetK8sInterface(c).AppsV1().ReplicaSets(wl.GetNamespace()).List(c, meta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var rss []*apps.ReplicaSet
+	for i := range ls.Items {
+		rs := &ls.Items[i]
+		if or := meta.GetControllerOf(rs); or != nil && or.UID == wl.GetUID() {
+			rss = append(rss, rs)
+		}
+	}
+	sort.Slice(rss, func(i, j int) bool {
+		return Revision(rss[i]) < Revision(rss[j])
+	})
+	return rss, nil
+}
+
+// Revision returns the revision of the given ReplicaSet, or zero if it has no valid revision annotation.
+func Revision(rs *apps.ReplicaSet) int64 {
+	v, ok := rs.Annotations[DeploymentRevisionAnnotation]
+	if !ok {
+		v = rs.Annotations[RolloutRevisionAnnotation]
+	}
+	r, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return r
+}
+
+// PodTemplateHash returns the pod-template-hash of the given ReplicaSet, or an empty string if it has none.
+func PodTemplateHash(rs *apps.ReplicaSet) string {
+	if h, ok := rs.Labels[PodTemplateHashLabel]; ok {
+		return h
+	}
+	return rs.Labels[RolloutPodTemplateHashLabel]
+}
+
+// HasPodTemplateHash returns true if the given pod labels contain the given pod-template-hash.
+func HasPodTemplateHash(podLabels map[string]string, hash string) bool {
+	if h, ok := podLabels[PodTemplateHashLabel]; ok {
+		return h == hash
+	}
+	return podLabels[RolloutPodTemplateHashLabel] == hash
+}
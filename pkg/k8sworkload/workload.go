@@ -0,0 +1,52 @@
+// Package k8sworkload extends the workload kinds supported by the k8sapi package with kinds
+// that it doesn't know about, such as DaemonSet.
+package k8sworkload
+
+import (
+	"context"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// GetWorkload returns a workload for the given name, namespace, and workloadKind. The workloadKind
+// is optional. A search is performed in the following order if it is empty:
+//
+//  1. Deployments
+//  2. ReplicaSets
+//  3. StatefulSets
+//  4. Rollouts (Argo Rollouts)
+//  5. DaemonSets
+//
+// The first match is returned. A bare pod is never found by the search, it must be requested
+// explicitly using the PodKind.
+func GetWorkload(c context.Context, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
+	switch workloadKind {
+	case "DaemonSet":
+		return GetDaemonSet(c, name, namespace)
+	case PodKind:
+		return GetPod(c, name, namespace)
+	case "":
+		if obj, err = k8sapi.GetWorkload(c, name, namespace, ""); err == nil || !k8sErrors.IsNotFound(err) {
+			return obj, err
+		}
+		if obj, err = GetDaemonSet(c, name, namespace); err == nil || !k8sErrors.IsNotFound(err) {
+			return obj, err
+		}
+		return nil, k8sErrors.NewNotFound(core.Resource("workload"), name+"."+namespace)
+	default:
+		return k8sapi.GetWorkload(c, name, namespace, workloadKind)
+	}
+}
+
+// WrapWorkload wraps the given runtime.Object in a k8sapi.Workload.
+func WrapWorkload(workload runtime.Object) (k8sapi.Workload, error) {
+	if ds, ok := workload.(*apps.DaemonSet); ok {
+		return DaemonSet(ds), nil
+	}
+	return k8sapi.WrapWorkload(workload)
+}
@@ -0,0 +1,151 @@
+package k8sworkload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fakeargorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/clientset/versioned/fake"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+func testDaemonSet() *apps.DaemonSet {
+	return &apps.DaemonSet{
+		TypeMeta: meta.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: meta.ObjectMeta{
+			Name:       "node-agent",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		Spec: apps.DaemonSetSpec{
+			Selector: &meta.LabelSelector{MatchLabels: map[string]string{"app": "node-agent"}},
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": "node-agent"}},
+			},
+		},
+		Status: apps.DaemonSetStatus{
+			ObservedGeneration:     2,
+			CurrentNumberScheduled: 3,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberAvailable:        3,
+		},
+	}
+}
+
+func TestGetWorkload(t *testing.T) {
+	ctx := k8sapi.WithJoinedClientSetInterface(context.Background(),
+		fake.NewSimpleClientset(testDaemonSet()), fakeargorollouts.NewSimpleClientset())
+
+	for _, kind := range []string{"DaemonSet", ""} {
+		wl, err := GetWorkload(ctx, "node-agent", "default", kind)
+		require.NoError(t, err)
+		assert.Equal(t, "DaemonSet", wl.GetKind())
+		assert.Equal(t, 3, wl.Replicas())
+		ds, ok := DaemonSetImpl(wl)
+		require.True(t, ok)
+		assert.Equal(t, "node-agent", ds.Name)
+	}
+
+	_, err := GetWorkload(ctx, "missing", "default", "")
+	assert.True(t, k8sErrors.IsNotFound(err))
+
+	_, err = GetWorkload(ctx, "node-agent", "default", "Job")
+	assert.ErrorAs(t, err, new(k8sapi.UnsupportedWorkloadKindError))
+}
+
+func TestWrapWorkload(t *testing.T) {
+	wl, err := WrapWorkload(testDaemonSet())
+	require.NoError(t, err)
+	assert.Equal(t, "DaemonSet", wl.GetKind())
+	assert.True(t, wl.Updated(2))
+	assert.False(t, wl.Updated(3))
+
+	wl, err = WrapWorkload(&apps.StatefulSet{})
+	require.NoError(t, err)
+	assert.Equal(t, "StatefulSet", wl.GetKind())
+
+	_, err = WrapWorkload(&core.Pod{})
+	assert.Error(t, err)
+}
+
+func TestDaemonSetUpdated(t *testing.T) {
+	ds := testDaemonSet()
+	ds.Status.UpdatedNumberScheduled = 1
+	assert.False(t, DaemonSet(ds).Updated(2))
+}
+
+func TestPod(t *testing.T) {
+	p := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "scratch",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "scratch"},
+		},
+		Spec: core.PodSpec{Containers: []core.Container{{Name: "app"}}},
+	}
+	ctx := k8sapi.WithJoinedClientSetInterface(context.Background(), fake.NewSimpleClientset(p), fakeargorollouts.NewSimpleClientset())
+
+	wl, err := GetWorkload(ctx, "scratch", "default", PodKind)
+	require.NoError(t, err)
+	assert.Equal(t, PodKind, wl.GetKind())
+	assert.Equal(t, 1, wl.Replicas())
+	tpl := wl.GetPodTemplate()
+	assert.Equal(t, "scratch", tpl.Labels["app"])
+	assert.Equal(t, "app", tpl.Spec.Containers[0].Name)
+	ip, ok := PodImpl(wl)
+	require.True(t, ok)
+	assert.Equal(t, "scratch", ip.Name)
+
+	// A bare pod is never found by the search.
+	_, err = GetWorkload(ctx, "scratch", "default", "")
+	assert.True(t, k8sErrors.IsNotFound(err))
+}
+
+func TestOwnedReplicaSets(t *testing.T) {
+	dep := &apps.Deployment{ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default", UID: "dep-uid"}}
+	isController := true
+	rs := func(name, owner, revision, hash string) *apps.ReplicaSet {
+		return &apps.ReplicaSet{ObjectMeta: meta.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			Annotations:     map[string]string{DeploymentRevisionAnnotation: revision},
+			Labels:          map[string]string{PodTemplateHashLabel: hash},
+			OwnerReferences: []meta.OwnerReference{{Kind: "Deployment", Name: "echo", UID: types.UID(owner), Controller: &isController}},
+		}}
+	}
+	ctx := k8sapi.WithJoinedClientSetInterface(context.Background(), fake.NewSimpleClientset(
+		rs("echo-b", "dep-uid", "2", "b"),
+		rs("echo-a", "dep-uid", "1", "a"),
+		rs("other-c", "other-uid", "3", "c"),
+	), fakeargorollouts.NewSimpleClientset())
+
+	rss, err := OwnedReplicaSets(ctx, k8sapi.Deployment(dep))
+	require.NoError(t, err)
+	require.Len(t, rss, 2)
+	assert.Equal(t, "echo-a", rss[0].Name)
+	assert.Equal(t, int64(1), Revision(rss[0]))
+	assert.Equal(t, "echo-b", rss[1].Name)
+	assert.Equal(t, "b", PodTemplateHash(rss[1]))
+
+	_, err = OwnedReplicaSets(ctx, DaemonSet(testDaemonSet()))
+	assert.Error(t, err)
+}
+
+func TestHasPodTemplateHash(t *testing.T) {
+	assert.True(t, HasPodTemplateHash(map[string]string{PodTemplateHashLabel: "a"}, "a"))
+	assert.False(t, HasPodTemplateHash(map[string]string{PodTemplateHashLabel: "b"}, "a"))
+	assert.True(t, HasPodTemplateHash(map[string]string{RolloutPodTemplateHashLabel: "a"}, "a"))
+	assert.False(t, HasPodTemplateHash(map[string]string{"app": "echo"}, "a"))
+}
@@ -0,0 +1,261 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The Body matcher matches the body of a http request using either JSON fields or XML paths. A Body
+// only inspects bodies of the content type that its matchers apply to, i.e. application/json and
+// types with a +json suffix for JSON fields, and application/xml, text/xml, and types with a +xml
+// suffix for XML paths. All matchers must match.
+type Body interface {
+	fmt.Stringer
+
+	// Inspects returns true if this Body inspects bodies of the given content type. A body of
+	// another content type never matches.
+	Inspects(contentType string) bool
+
+	// Matches returns true if all matchers in this instance are matched by the given body of the given content type.
+	Matches(contentType string, body []byte) bool
+}
+
+type jsonField struct {
+	path  []string
+	value string
+}
+
+type xmlPath struct {
+	expr       string
+	steps      []string
+	descendant bool
+	attr       string
+	value      string
+}
+
+type body struct {
+	jsonFields []*jsonField
+	xmlPaths   []*xmlPath
+}
+
+// NewBody creates a new Body based on the given JSON fields and XML paths, and the values that they
+// must be equal to. JSON fields and XML paths cannot be combined. A JSON field is a dot separated path,
+// where numeric elements index arrays, e.g. order.items.0.sku. A string field must equal the value, and
+// other fields must equal it in their compact JSON form, e.g. 42, true, or null. An XML path is a subset
+// of XPath, where the path is either absolute, e.g. /order/type, or starts with // to select elements
+// anywhere, e.g. //type. A step is an element name or *, and the last step can select an attribute, e.g.
+// /order/@type. The trimmed text content of an element, or the attribute value, must equal the value.
+// NewBody returns nil when there are no matchers.
+func NewBody(jsonFields, xmlPaths map[string]string) (Body, error) {
+	if len(jsonFields) == 0 && len(xmlPaths) == 0 {
+		return nil, nil
+	}
+	if len(jsonFields) > 0 && len(xmlPaths) > 0 {
+		return nil, errors.New("JSON fields and XML paths cannot be combined, because a body is either JSON or XML")
+	}
+	b := &body{}
+	for k, v := range jsonFields {
+		if k == "" || slices.Contains(strings.Split(k, "."), "") {
+			return nil, fmt.Errorf("invalid JSON field %q", k)
+		}
+		b.jsonFields = append(b.jsonFields, &jsonField{path: strings.Split(k, "."), value: v})
+	}
+	for k, v := range xmlPaths {
+		xp, err := parseXMLPath(k)
+		if err != nil {
+			return nil, err
+		}
+		xp.value = v
+		b.xmlPaths = append(b.xmlPaths, xp)
+	}
+	slices.SortFunc(b.jsonFields, func(a, b *jsonField) int {
+		return strings.Compare(strings.Join(a.path, "."), strings.Join(b.path, "."))
+	})
+	slices.SortFunc(b.xmlPaths, func(a, b *xmlPath) int { return strings.Compare(a.expr, b.expr) })
+	return b, nil
+}
+
+func parseXMLPath(expr string) (*xmlPath, error) {
+	xp := &xmlPath{expr: expr}
+	p := expr
+	switch {
+	case strings.HasPrefix(p, "//"):
+		xp.descendant = true
+		p = p[2:]
+	case strings.HasPrefix(p, "/"):
+		p = p[1:]
+	default:
+		return nil, fmt.Errorf("invalid XML path %q, must start with / or //", expr)
+	}
+	steps := strings.Split(p, "/")
+	if last := steps[len(steps)-1]; strings.HasPrefix(last, "@") {
+		xp.attr = last[1:]
+		steps = steps[:len(steps)-1]
+		if xp.attr == "" {
+			return nil, fmt.Errorf("invalid XML path %q, attribute name is missing", expr)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("invalid XML path %q, no element is selected", expr)
+	}
+	for _, s := range steps {
+		if s == "" || strings.ContainsAny(s, "[]()@:=") {
+			return nil, fmt.Errorf("invalid XML path %q, only element names, * and a final @attribute are supported", expr)
+		}
+	}
+	xp.steps = steps
+	return xp, nil
+}
+
+// Inspects returns true if this Body inspects bodies of the given content type.
+func (b *body) Inspects(contentType string) bool {
+	if b == nil {
+		return false
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if len(b.jsonFields) > 0 {
+		return mt == "application/json" || strings.HasSuffix(mt, "+json")
+	}
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+// Matches returns true if all matchers in this instance are matched by the given body of the given content type.
+func (b *body) Matches(contentType string, data []byte) bool {
+	if b == nil {
+		return true
+	}
+	if !b.Inspects(contentType) {
+		return false
+	}
+	if len(b.jsonFields) > 0 {
+		var doc any
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		if err := d.Decode(&doc); err != nil {
+			return false
+		}
+		for _, f := range b.jsonFields {
+			if !f.matches(doc) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, xp := range b.xmlPaths {
+		if !xp.matches(data) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *body) String() string {
+	sb := strings.Builder{}
+	if len(b.jsonFields) > 0 {
+		sb.WriteString("body with JSON fields")
+		for _, f := range b.jsonFields {
+			fmt.Fprintf(&sb, "\n  '%s == %s'", strings.Join(f.path, "."), f.value)
+		}
+	} else {
+		sb.WriteString("body with XML paths")
+		for _, xp := range b.xmlPaths {
+			fmt.Fprintf(&sb, "\n  '%s == %s'", xp.expr, xp.value)
+		}
+	}
+	return sb.String()
+}
+
+func (f *jsonField) matches(doc any) bool {
+	for _, p := range f.path {
+		switch v := doc.(type) {
+		case map[string]any:
+			var ok bool
+			if doc, ok = v[p]; !ok {
+				return false
+			}
+		case []any:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(v) {
+				return false
+			}
+			doc = v[i]
+		default:
+			return false
+		}
+	}
+	if s, ok := doc.(string); ok {
+		return s == f.value
+	}
+	data, err := json.Marshal(doc)
+	return err == nil && string(data) == f.value
+}
+
+// matchesStack returns true if the given stack of element names is selected by the steps of this path.
+func (xp *xmlPath) matchesStack(stack []string) bool {
+	n := len(xp.steps)
+	if len(stack) < n || !xp.descendant && len(stack) != n {
+		return false
+	}
+	stack = stack[len(stack)-n:]
+	for i, s := range xp.steps {
+		if s != "*" && s != stack[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (xp *xmlPath) matches(data []byte) bool {
+	type collector struct {
+		depth int
+		text  strings.Builder
+	}
+	var stack []string
+	var open []*collector
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return false
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if !xp.matchesStack(stack) {
+				break
+			}
+			if xp.attr == "" {
+				open = append(open, &collector{depth: len(stack)})
+				break
+			}
+			for _, a := range t.Attr {
+				if a.Name.Local == xp.attr && a.Value == xp.value {
+					return true
+				}
+			}
+		case xml.CharData:
+			for _, c := range open {
+				c.text.Write(t)
+			}
+		case xml.EndElement:
+			if n := len(open); n > 0 && open[n-1].depth == len(stack) {
+				c := open[n-1]
+				open = open[:n-1]
+				if strings.TrimSpace(c.text.String()) == xp.value {
+					return true
+				}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
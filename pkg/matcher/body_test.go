@@ -0,0 +1,94 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBody(t *testing.T) {
+	b, err := NewBody(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, b)
+
+	for _, tt := range []struct {
+		name       string
+		jsonFields map[string]string
+		xmlPaths   map[string]string
+	}{
+		{"combined", map[string]string{"a": "1"}, map[string]string{"/a": "1"}},
+		{"empty JSON element", map[string]string{"a..b": "1"}, nil},
+		{"relative XML path", nil, map[string]string{"a/b": "1"}},
+		{"XML predicate", nil, map[string]string{"/a/b[1]": "1"}},
+		{"XML attribute only", nil, map[string]string{"/@id": "1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBody(tt.jsonFields, tt.xmlPaths)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBody_JSON(t *testing.T) {
+	const doc = `{"type": "order.created", "order": {"id": 42, "express": true, "items": [{"sku": "A-1"}, {"sku": "B-2"}]}}`
+	tests := []struct {
+		field string
+		value string
+		want  bool
+	}{
+		{"type", "order.created", true},
+		{"type", "order.deleted", false},
+		{"order.id", "42", true},
+		{"order.express", "true", true},
+		{"order.items.1.sku", "B-2", true},
+		{"order.items.2.sku", "B-2", false},
+		{"order.items.x.sku", "B-2", false},
+		{"order.missing", "null", false},
+	}
+	for _, tt := range tests {
+		b, err := NewBody(map[string]string{tt.field: tt.value}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, b.Matches("application/json; charset=utf-8", []byte(doc)), "%s == %s", tt.field, tt.value)
+	}
+
+	b, err := NewBody(map[string]string{"type": "order.created"}, nil)
+	require.NoError(t, err)
+	assert.True(t, b.Inspects("application/cloudevents+json"))
+	assert.False(t, b.Inspects("text/plain"))
+	assert.False(t, b.Matches("text/plain", []byte(doc)))
+	assert.False(t, b.Matches("application/json", []byte(`{"type": "order.created"`)))
+}
+
+func TestBody_XML(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <order type="express"><id> 42 </id><item><sku>A-1</sku></item></order>
+  </soap:Body>
+</soap:Envelope>`
+	tests := []struct {
+		path  string
+		value string
+		want  bool
+	}{
+		{"/Envelope/Body/order/id", "42", true},
+		{"/Envelope/Body/order/id", "43", false},
+		{"/Body/order/id", "42", false},
+		{"//order/id", "42", true},
+		{"//id", "42", true},
+		{"/Envelope/*/order/@type", "express", true},
+		{"//order/@type", "standard", false},
+		{"//item", "A-1", true},
+	}
+	for _, tt := range tests {
+		b, err := NewBody(nil, map[string]string{tt.path: tt.value})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, b.Matches("application/soap+xml", []byte(doc)), "%s == %s", tt.path, tt.value)
+	}
+
+	b, err := NewBody(nil, map[string]string{"//id": "42"})
+	require.NoError(t, err)
+	assert.True(t, b.Inspects("text/xml"))
+	assert.False(t, b.Inspects("application/json"))
+}
@@ -0,0 +1,237 @@
+package matcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// Propagation is a standard for propagating the headers that identify an intercept from one workload to
+// another. Workloads instrumented for tracing will often propagate the tracing context and baggage but drop
+// other headers, so carrying the intercept identity in the tracing headers makes intercepts work without
+// changes to the workloads.
+type Propagation string
+
+const (
+	// PropagationHeader carries each header as is, e.g. "x-telepresence-id: jane".
+	PropagationHeader Propagation = "header"
+
+	// PropagationBaggage carries each header as a member of the W3C "baggage" header, e.g.
+	// "baggage: x-telepresence-id=jane". The value is percent-encoded, and properties are ignored.
+	PropagationBaggage Propagation = "baggage"
+
+	// PropagationTraceParent carries each header as a "telepresence" tenant member of the W3C trace-context
+	// "tracestate" header, e.g. "tracestate: x-telepresence-id@telepresence=jane". The value is percent-encoded.
+	// As mandated by the trace-context specification, the tracestate is only considered when the request also
+	// has a valid "traceparent" header.
+	PropagationTraceParent Propagation = "traceparent"
+
+	// PropagationB3 carries each header as a Zipkin B3 baggage field, e.g. "baggage-x-telepresence-id: jane".
+	PropagationB3 Propagation = "b3"
+)
+
+const (
+	baggageHeader     = "Baggage"
+	traceParentHeader = "Traceparent"
+	traceStateHeader  = "Tracestate"
+	b3BaggagePrefix   = "Baggage-"
+
+	// traceStateSystem is the system id of the multi-tenant tracestate keys that carry headers.
+	traceStateSystem = "telepresence"
+
+	// maxTraceStateMembers is the max number of tracestate members. The whole tracestate is ignored when
+	// it has more members.
+	maxTraceStateMembers = 32
+)
+
+// ParsePropagations parses the given propagation names. An empty slice is returned when no names are given.
+func ParsePropagations(names []string) ([]Propagation, error) {
+	ps := make([]Propagation, 0, len(names))
+	for _, n := range names {
+		p := Propagation(strings.ToLower(strings.TrimSpace(n)))
+		switch p {
+		case "":
+			continue
+		case PropagationHeader, PropagationBaggage, PropagationTraceParent, PropagationB3:
+			ps = append(ps, p)
+		default:
+			return nil, fmt.Errorf("invalid propagation %q, must be one of %s, %s, %s, or %s",
+				n, PropagationHeader, PropagationBaggage, PropagationTraceParent, PropagationB3)
+		}
+	}
+	return ps, nil
+}
+
+// Translate returns the given header, extended with the headers that are carried by the given propagations.
+// A header that is already present is never replaced. The given header is returned unchanged when there's
+// nothing to add, and a copy is returned otherwise. Malformed members are ignored.
+func Translate(h http.Header, ps []Propagation) http.Header {
+	var out http.Header
+	add := func(name, value string) {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if name == "" || h.Get(name) != "" || out.Get(name) != "" {
+			return
+		}
+		if out == nil {
+			out = h.Clone()
+		}
+		out.Set(name, value)
+	}
+	for _, p := range ps {
+		switch p {
+		case PropagationBaggage:
+			for _, m := range listMembers(h.Values(baggageHeader)) {
+				if k, v, ok := baggageMember(m); ok {
+					add(k, v)
+				}
+			}
+		case PropagationTraceParent:
+			if !validTraceParent(h.Values(traceParentHeader)) {
+				continue
+			}
+			ms := listMembers(h.Values(traceStateHeader))
+			if len(ms) > maxTraceStateMembers {
+				continue
+			}
+			for _, m := range ms {
+				if k, v, ok := traceStateMember(m); ok {
+					add(k, v)
+				}
+			}
+		case PropagationB3:
+			for k, vs := range h {
+				if n, ok := strings.CutPrefix(k, b3BaggagePrefix); ok && len(vs) > 0 {
+					add(n, vs[0])
+				}
+			}
+		}
+	}
+	if out == nil {
+		return h
+	}
+	return out
+}
+
+// listMembers returns the trimmed, non-empty, members of the given comma separated list headers.
+func listMembers(vs []string) []string {
+	var ms []string
+	for _, v := range vs {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.Trim(m, " \t"); m != "" {
+				ms = append(ms, m)
+			}
+		}
+	}
+	return ms
+}
+
+// baggageMember returns the key and the decoded value of the given W3C baggage list member, which has the
+// form key OWS "=" OWS value *( OWS ";" OWS property ). The key is a token, and the value consists of
+// baggage-octets and percent-encoded octets.
+func baggageMember(m string) (string, string, bool) {
+	m, _, _ = strings.Cut(m, ";")
+	k, v, ok := strings.Cut(m, "=")
+	if !ok {
+		return "", "", false
+	}
+	k = strings.Trim(k, " \t")
+	v = strings.Trim(v, " \t")
+	if !isToken(k) {
+		return "", "", false
+	}
+	for i := 0; i < len(v); i++ {
+		if !isBaggageOctet(v[i]) {
+			return "", "", false
+		}
+	}
+	v, err := url.PathUnescape(v)
+	if err != nil {
+		return "", "", false
+	}
+	return k, v, true
+}
+
+// traceStateMember returns the header name and the decoded value of the given W3C tracestate list member,
+// provided that the member has a multi-tenant key in the form <header>@telepresence.
+func traceStateMember(m string) (string, string, bool) {
+	k, v, ok := strings.Cut(m, "=")
+	if !ok || len(v) == 0 || len(v) > 256 || v[len(v)-1] == ' ' {
+		return "", "", false
+	}
+	tenant, system, ok := strings.Cut(k, "@")
+	if !ok || system != traceStateSystem || len(tenant) == 0 || len(tenant) > 241 {
+		return "", "", false
+	}
+	if !isLowerAlnum(tenant[0]) {
+		return "", "", false
+	}
+	for i := 1; i < len(tenant); i++ {
+		if c := tenant[i]; !(isLowerAlnum(c) || c == '_' || c == '-' || c == '*' || c == '/') {
+			return "", "", false
+		}
+	}
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return "", "", false
+		}
+	}
+	v, err := url.PathUnescape(v)
+	if err != nil {
+		return "", "", false
+	}
+	return tenant, v, true
+}
+
+// validTraceParent returns true if the given values hold exactly one W3C traceparent, i.e.
+// version "-" trace-id "-" parent-id "-" trace-flags. A version higher than 00 may add fields.
+func validTraceParent(vs []string) bool {
+	if len(vs) != 1 {
+		return false
+	}
+	tp := strings.Trim(vs[0], " \t")
+	if len(tp) < 55 || !isLowerHex(tp[0:2]) || tp[0:2] == "ff" {
+		return false
+	}
+	if tp[0:2] == "00" && len(tp) != 55 || len(tp) > 55 && tp[55] != '-' {
+		return false
+	}
+	traceID, parentID := tp[3:35], tp[36:52]
+	return tp[2] == '-' && tp[35] == '-' && tp[52] == '-' &&
+		isLowerHex(traceID) && traceID != strings.Repeat("0", 32) &&
+		isLowerHex(parentID) && parentID != strings.Repeat("0", 16) &&
+		isLowerHex(tp[53:55])
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isLowerAlnum(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z'
+}
+
+// isToken returns true if the given string is a non-empty RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x21 || c > 0x7e || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isBaggageOctet returns true if the given octet may appear unencoded in a W3C baggage value.
+func isBaggageOctet(c byte) bool {
+	return c == 0x21 || 0x23 <= c && c <= 0x2b || 0x2d <= c && c <= 0x3a || 0x3c <= c && c <= 0x5b || 0x5d <= c && c <= 0x7e
+}
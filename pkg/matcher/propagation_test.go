@@ -0,0 +1,103 @@
+package matcher
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePropagations(t *testing.T) {
+	ps, err := ParsePropagations([]string{"header", " Baggage", "", "b3"})
+	require.NoError(t, err)
+	assert.Equal(t, []Propagation{PropagationHeader, PropagationBaggage, PropagationB3}, ps)
+
+	_, err = ParsePropagations([]string{"jaeger"})
+	assert.Error(t, err)
+}
+
+func TestTranslate(t *testing.T) {
+	all := []Propagation{PropagationHeader, PropagationBaggage, PropagationTraceParent, PropagationB3}
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name   string
+		header http.Header
+		want   map[string]string
+	}{
+		{
+			name:   "baggage",
+			header: http.Header{"Baggage": {"userId=alice, x-telepresence-id=jane%20doe;prop=1"}},
+			want:   map[string]string{"X-Telepresence-Id": "jane doe", "Userid": "alice"},
+		},
+		{
+			name:   "malformed baggage members",
+			header: http.Header{"Baggage": {"x-telepresence-id=jane doe, x-dev=\"bob\", x(y)=1, userId=al%ZZ, x-team=blue"}},
+			want:   map[string]string{"X-Telepresence-Id": "", "X-Dev": "", "Userid": "", "X-Team": "blue"},
+		},
+		{
+			name: "traceparent",
+			header: http.Header{
+				"Traceparent": {traceParent},
+				"Tracestate":  {"x-telepresence-id@telepresence=jane%20doe,rojo=00f067aa0ba902b7,x-dev@congo=bob"},
+			},
+			want: map[string]string{"X-Telepresence-Id": "jane doe", "Rojo": "", "X-Dev": ""},
+		},
+		{
+			name:   "tracestate without traceparent",
+			header: http.Header{"Tracestate": {"x-telepresence-id@telepresence=jane"}},
+			want:   map[string]string{"X-Telepresence-Id": ""},
+		},
+		{
+			name: "tracestate with invalid traceparent",
+			header: http.Header{
+				"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+				"Tracestate":  {"x-telepresence-id@telepresence=jane"},
+			},
+			want: map[string]string{"X-Telepresence-Id": ""},
+		},
+		{
+			name:   "b3",
+			header: http.Header{"Baggage-X-Telepresence-Id": {"jane"}, "X-B3-Traceid": {"80f198ee56343ba8"}},
+			want:   map[string]string{"X-Telepresence-Id": "jane"},
+		},
+		{
+			name: "plain header wins",
+			header: http.Header{
+				"X-Telepresence-Id": {"john"},
+				"Baggage":           {"x-telepresence-id=jane"},
+			},
+			want: map[string]string{"X-Telepresence-Id": "john"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := tt.header.Clone()
+			got := Translate(tt.header, all)
+			assert.Equal(t, orig, tt.header, "the given header must not be modified")
+			for k, v := range tt.want {
+				assert.Equal(t, v, got.Get(k), k)
+			}
+		})
+	}
+
+	h := http.Header{"Baggage": {"x-telepresence-id=jane"}}
+	assert.Equal(t, "", Translate(h, []Propagation{PropagationB3}).Get("X-Telepresence-Id"))
+}
+
+func TestValidTraceParent(t *testing.T) {
+	tests := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":       false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":          false,
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01":       false,
+	}
+	for tp, want := range tests {
+		assert.Equal(t, want, validTraceParent([]string{tp}), tp)
+	}
+	assert.False(t, validTraceParent(nil))
+}
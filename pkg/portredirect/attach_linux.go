@@ -0,0 +1,126 @@
+package portredirect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// loadProgram loads the given SCHED_CLS program. The error contains the output of the verifier when the program
+// is rejected.
+func loadProgram(name string, insns asm.Instructions) (*ebpf.Program, error) {
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name:         name,
+		Type:         ebpf.SchedCLS,
+		Instructions: insns,
+		License:      "Apache-2.0",
+	})
+	if err != nil {
+		var ve *ebpf.VerifierError
+		if errors.As(err, &ve) {
+			return nil, fmt.Errorf("failed to load eBPF program %s: %+v", name, ve)
+		}
+		return nil, fmt.Errorf("failed to load eBPF program %s: %w", name, err)
+	}
+	return prog, nil
+}
+
+// Attach attaches programs that apply the given rules to the traffic control hooks of all network interfaces of
+// the current network namespace except the loopback interface. Programs that were attached earlier, e.g. by a
+// traffic-agent that was restarted, are replaced.
+func Attach(ctx context.Context, rules []Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	// Kernels older than 5.11 account the memory of eBPF programs against RLIMIT_MEMLOCK.
+	if err := rlimit.RemoveMemlock(); err != nil {
+		dlog.Debugf(ctx, "unable to remove the memlock limit: %v", err)
+	}
+	links, err := netlink.LinkList()
+	if err != nil {
+		return fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	attached := false
+	for _, link := range links {
+		la := link.Attrs()
+		if la.Flags&unix.IFF_LOOPBACK != 0 || la.Flags&unix.IFF_UP == 0 {
+			continue
+		}
+		if err = attachLink(ctx, link, rules); err != nil {
+			return fmt.Errorf("failed to attach port redirection to %s: %w", la.Name, err)
+		}
+		attached = true
+	}
+	if !attached {
+		return errors.New("found no network interface to attach port redirection to")
+	}
+	return nil
+}
+
+func attachLink(ctx context.Context, link netlink.Link, rules []Rule) error {
+	la := link.Attrs()
+	qdisc := &netlink.Clsact{QdiscAttrs: netlink.QdiscAttrs{
+		LinkIndex: la.Index,
+		Handle:    netlink.MakeHandle(0xffff, 0),
+		Parent:    netlink.HANDLE_CLSACT,
+	}}
+	// An existing clsact qdisc, e.g. one that is added by the CNI, is retained together with its filters.
+	if err := netlink.QdiscAdd(qdisc); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("failed to add clsact qdisc: %w", err)
+	}
+
+	var l2Len int32
+	if la.EncapType == "ether" {
+		l2Len = ethernetHeaderLen
+	}
+	ingress, err := ingressProgram(rules, l2Len)
+	if err != nil {
+		return err
+	}
+	egress, err := egressProgram(rules, l2Len)
+	if err != nil {
+		return err
+	}
+	for _, hook := range []struct {
+		name   string
+		parent uint32
+		insns  asm.Instructions
+	}{
+		{"tel_ingress", netlink.HANDLE_MIN_INGRESS, ingress},
+		{"tel_egress", netlink.HANDLE_MIN_EGRESS, egress},
+	} {
+		prog, err := loadProgram(hook.name, hook.insns)
+		if err != nil {
+			return err
+		}
+		err = netlink.FilterReplace(&netlink.BpfFilter{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: la.Index,
+				Parent:    hook.parent,
+				Handle:    netlink.MakeHandle(0, 1),
+				Protocol:  unix.ETH_P_ALL,
+				Priority:  1,
+			},
+			Fd:           prog.FD(),
+			Name:         hook.name,
+			DirectAction: true,
+		})
+		// The filter keeps a reference to the program.
+		_ = prog.Close()
+		if err != nil {
+			return fmt.Errorf("failed to attach eBPF program %s: %w", hook.name, err)
+		}
+	}
+	for _, r := range rules {
+		dlog.Debugf(ctx, "redirecting %s on %s", r, la.Name)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package portredirect
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+var testRules = []Rule{
+	{Protocol: core.ProtocolTCP, From: 8080, To: 9900},
+	{Protocol: core.ProtocolUDP, From: 53, To: 9901},
+}
+
+func skipUnlessPrivileged(t *testing.T, err error) {
+	if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+		t.Skipf("test requires the CAP_BPF, CAP_NET_ADMIN, and CAP_SYS_ADMIN capabilities: %v", err)
+	}
+}
+
+// Test_loadProgram verifies that the kernel's verifier accepts the programs.
+func Test_loadProgram(t *testing.T) {
+	for _, l2Len := range []int32{0, ethernetHeaderLen} {
+		in, err := ingressProgram(testRules, l2Len)
+		require.NoError(t, err)
+		out, err := egressProgram(testRules, l2Len)
+		require.NoError(t, err)
+		for _, insns := range []asm.Instructions{in, out} {
+			prog, err := loadProgram("tel_test", insns)
+			skipUnlessPrivileged(t, err)
+			require.NoError(t, err)
+			require.NoError(t, prog.Close())
+		}
+	}
+}
+
+// TestAttach attaches the programs to a veth interface in a new network namespace, and verifies that they are
+// replaced when attached again.
+func TestAttach(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("test must run as root")
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	orig, err := netns.Get()
+	require.NoError(t, err)
+	defer orig.Close()
+	ns, err := netns.New()
+	skipUnlessPrivileged(t, err)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, netns.Set(orig))
+		ns.Close()
+	}()
+
+	ctx := dlog.NewTestContext(t, false)
+	assert.ErrorContains(t, Attach(ctx, testRules), "found no network interface")
+
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "tel0"}, PeerName: "tel1"}
+	err = netlink.LinkAdd(veth)
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skip("the kernel doesn't support veth interfaces")
+	}
+	require.NoError(t, err)
+	require.NoError(t, netlink.LinkSetUp(veth))
+	link, err := netlink.LinkByName("tel0")
+	require.NoError(t, err)
+
+	err = Attach(ctx, testRules)
+	skipUnlessPrivileged(t, err)
+	require.NoError(t, err)
+	require.NoError(t, Attach(ctx, testRules))
+
+	for parent, name := range map[uint32]string{
+		netlink.HANDLE_MIN_INGRESS: "tel_ingress",
+		netlink.HANDLE_MIN_EGRESS:  "tel_egress",
+	} {
+		filters, err := netlink.FilterList(link, parent)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		bf, ok := filters[0].(*netlink.BpfFilter)
+		require.True(t, ok)
+		assert.Contains(t, bf.Name, name)
+		assert.True(t, bf.DirectAction)
+	}
+}
+
+// Test_programRun runs the programs on TCP and UDP packets, and verifies that their ports are rewritten.
+func Test_programRun(t *testing.T) {
+	packet := func(proto byte, src, dst uint16) []byte {
+		pkt := make([]byte, ethernetHeaderLen+20+20)
+		binary.BigEndian.PutUint16(pkt[12:], ethPIPv4)
+		ip := pkt[ethernetHeaderLen:]
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(len(ip)))
+		ip[8] = 64
+		ip[9] = proto
+		copy(ip[12:], []byte{10, 0, 0, 1, 10, 0, 0, 2})
+		l4 := ip[20:]
+		binary.BigEndian.PutUint16(l4[srcPortOffset:], src)
+		binary.BigEndian.PutUint16(l4[dstPortOffset:], dst)
+		if proto == ipProtoTCP {
+			l4[12] = 0x50
+			binary.BigEndian.PutUint16(l4[tcpCsumOffset:], 0x1234)
+		} else {
+			binary.BigEndian.PutUint16(l4[4:], 20)
+		}
+		return pkt
+	}
+	ports := func(pkt []byte) (uint16, uint16) {
+		l4 := pkt[ethernetHeaderLen+20:]
+		return binary.BigEndian.Uint16(l4[srcPortOffset:]), binary.BigEndian.Uint16(l4[dstPortOffset:])
+	}
+
+	in, err := ingressProgram(testRules, ethernetHeaderLen)
+	require.NoError(t, err)
+	ingress, err := loadProgram("tel_ingress", in)
+	skipUnlessPrivileged(t, err)
+	require.NoError(t, err)
+	defer ingress.Close()
+	out, err := egressProgram(testRules, ethernetHeaderLen)
+	require.NoError(t, err)
+	egress, err := loadProgram("tel_egress", out)
+	require.NoError(t, err)
+	defer egress.Close()
+
+	tests := []struct {
+		name    string
+		prog    *ebpf.Program
+		proto   byte
+		src     uint16
+		dst     uint16
+		wantSrc uint16
+		wantDst uint16
+	}{
+		{"ingress tcp", ingress, ipProtoTCP, 40000, 8080, 40000, 9900},
+		{"ingress udp", ingress, ipProtoUDP, 40000, 53, 40000, 9901},
+		{"ingress other port", ingress, ipProtoTCP, 40000, 8081, 40000, 8081},
+		{"ingress other protocol", ingress, ipProtoUDP, 40000, 8080, 40000, 8080},
+		{"egress tcp", egress, ipProtoTCP, 9900, 40000, 8080, 40000},
+		{"egress udp", egress, ipProtoUDP, 9901, 40000, 53, 40000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt := packet(tt.proto, tt.src, tt.dst)
+			opts := &ebpf.RunOptions{Data: pkt, DataOut: make([]byte, len(pkt))}
+			ret, err := tt.prog.Run(opts)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(tcActOK), ret)
+			src, dst := ports(opts.DataOut)
+			assert.Equal(t, tt.wantSrc, src)
+			assert.Equal(t, tt.wantDst, dst)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package portredirect
+
+import (
+	"context"
+	"errors"
+)
+
+// Attach is only supported on Linux.
+func Attach(context.Context, []Rule) error {
+	return errors.New("eBPF port redirection is only supported on Linux")
+}
@@ -0,0 +1,237 @@
+// Package portredirect redirects the ports of a pod using eBPF programs that are attached to the traffic control
+// hooks of the pod's network interfaces. It is an alternative to the iptables rules that are set up by the
+// tel-agent-init container, and requires no init container.
+//
+// A program that is attached to the ingress hook rewrites the destination port of incoming packets from a container
+// port to an agent port, and a program that is attached to the egress hook rewrites the source port of the replies
+// from the agent port back to the container port. Traffic that originates from within the pod, i.e. on the loopback
+// interface, is never redirected.
+package portredirect
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cilium/ebpf/asm"
+	core "k8s.io/api/core/v1"
+)
+
+// Rule redirects packets of the given protocol that are sent to the From port to the To port.
+type Rule struct {
+	Protocol core.Protocol
+	From     uint16
+	To       uint16
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s %d -> %d", r.Protocol, r.From, r.To)
+}
+
+// ipProto returns the IP protocol number of the rule's protocol.
+func (r Rule) ipProto() (int32, error) {
+	switch r.Protocol {
+	case core.ProtocolTCP, "":
+		return ipProtoTCP, nil
+	case core.ProtocolUDP:
+		return ipProtoUDP, nil
+	default:
+		return 0, fmt.Errorf("unable to redirect %s, only TCP and UDP are supported", r)
+	}
+}
+
+const (
+	ethPIPv4 = 0x0800
+	ethPIPv6 = 0x86dd
+
+	ipProtoTCP = 6
+	ipProtoUDP = 17
+
+	// Offsets of the destination and source ports in the TCP and UDP headers.
+	dstPortOffset = 2
+	srcPortOffset = 0
+
+	// Offsets of the checksums in the TCP and UDP headers.
+	tcpCsumOffset = 16
+	udpCsumOffset = 6
+
+	// Offset of the protocol in struct __sk_buff.
+	skbProtocolOffset = 16
+
+	csumFieldSize     = 2    // BPF_F_HDR_FIELD_MASK
+	csumMarkMangled0  = 0x20 // BPF_F_MARK_MANGLED_0
+	tcActOK           = 0
+	stackScratch      = -8
+	ipv6HeaderLen     = 40
+	ipv4FragOffMask   = 0x1fff
+	ethernetHeaderLen = 14
+)
+
+// builder appends instructions to a program, and attaches a pending label to the next instruction.
+type builder struct {
+	insns asm.Instructions
+	label string
+}
+
+func (b *builder) emit(insns ...asm.Instruction) {
+	for _, ins := range insns {
+		if b.label != "" {
+			ins = ins.WithSymbol(b.label)
+			b.label = ""
+		}
+		b.insns = append(b.insns, ins)
+	}
+}
+
+// mark makes the given label refer to the next instruction.
+func (b *builder) mark(label string) {
+	b.label = label
+}
+
+// loadBytes loads size bytes from the packet at the offset in register R2 into the stack scratch area, and jumps
+// to the given label when that fails.
+func (b *builder) loadBytes(size int32, failLabel string) {
+	b.emit(
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, stackScratch),
+		asm.Mov.Imm(asm.R4, size),
+		asm.FnSkbLoadBytes.Call(),
+		asm.JNE.Imm(asm.R0, 0, failLabel),
+	)
+}
+
+// networkOrder returns the value that a register must hold for its lower 16 bits to be stored, or passed to a
+// helper, as the given port in network byte order.
+func networkOrder(port uint16) int32 {
+	return int32(binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, port)))
+}
+
+// program returns a SCHED_CLS program that rewrites the port at the given offset in the TCP or UDP header of IPv4
+// and IPv6 packets from the From port to the To port of the matching rule. The l2Len is the length of the link
+// layer header that precedes the IP header.
+func program(rules []Rule, portOffset, l2Len int32) (asm.Instructions, error) {
+	b := &builder{}
+	b.emit(
+		asm.Mov.Reg(asm.R6, asm.R1), // r6 = skb
+
+		// r7 = skb->protocol
+		asm.LoadMem(asm.R7, asm.R6, skbProtocolOffset, asm.Word),
+		asm.HostTo(asm.BE, asm.R7, asm.Half),
+		asm.JEq.Imm(asm.R7, ethPIPv4, "ipv4"),
+		asm.JEq.Imm(asm.R7, ethPIPv6, "ipv6"),
+		asm.Ja.Label("out"),
+	)
+
+	// r8 = offset of the L4 header, r9 = L4 protocol
+	b.mark("ipv4")
+	b.emit(asm.Mov.Imm(asm.R2, l2Len))
+	b.loadBytes(1, "out")
+	b.emit(
+		asm.LoadMem(asm.R8, asm.RFP, stackScratch, asm.Byte),
+		asm.And.Imm(asm.R8, 0x0f),
+		asm.LSh.Imm(asm.R8, 2),
+		asm.Add.Imm(asm.R8, l2Len),
+	)
+	// Fragments other than the first have no L4 header.
+	b.emit(asm.Mov.Imm(asm.R2, l2Len+6))
+	b.loadBytes(2, "out")
+	b.emit(
+		asm.LoadMem(asm.R1, asm.RFP, stackScratch, asm.Half),
+		asm.HostTo(asm.BE, asm.R1, asm.Half),
+		asm.And.Imm(asm.R1, ipv4FragOffMask),
+		asm.JNE.Imm(asm.R1, 0, "out"),
+		asm.Mov.Imm(asm.R2, l2Len+9),
+	)
+	b.loadBytes(1, "out")
+	b.emit(
+		asm.LoadMem(asm.R9, asm.RFP, stackScratch, asm.Byte),
+		asm.Ja.Label("l4"),
+	)
+
+	// IPv6 packets with extension headers are not redirected.
+	b.mark("ipv6")
+	b.emit(asm.Mov.Imm(asm.R2, l2Len+6))
+	b.loadBytes(1, "out")
+	b.emit(
+		asm.LoadMem(asm.R9, asm.RFP, stackScratch, asm.Byte),
+		asm.Mov.Imm(asm.R8, l2Len+ipv6HeaderLen),
+	)
+
+	// r7 = port
+	b.mark("l4")
+	b.emit(
+		asm.Mov.Reg(asm.R2, asm.R8),
+		asm.Add.Imm(asm.R2, portOffset),
+	)
+	b.loadBytes(2, "out")
+	b.emit(
+		asm.LoadMem(asm.R7, asm.RFP, stackScratch, asm.Half),
+		asm.HostTo(asm.BE, asm.R7, asm.Half),
+	)
+
+	for n, r := range rules {
+		proto, err := r.ipProto()
+		if err != nil {
+			return nil, err
+		}
+		csumOffset, csumFlags := int32(tcpCsumOffset), int32(csumFieldSize)
+		if proto == ipProtoUDP {
+			// A zero UDP checksum means that there is no checksum.
+			csumOffset, csumFlags = udpCsumOffset, csumFieldSize|csumMarkMangled0
+		}
+		next := "out"
+		if n < len(rules)-1 {
+			next = fmt.Sprintf("rule%d", n+1)
+		}
+		b.emit(
+			asm.JNE.Imm(asm.R9, proto, next),
+			asm.JNE.Imm(asm.R7, int32(r.From), next),
+
+			// l4_csum_replace(skb, csumOffset, from, to, flags)
+			asm.Mov.Reg(asm.R1, asm.R6),
+			asm.Mov.Reg(asm.R2, asm.R8),
+			asm.Add.Imm(asm.R2, csumOffset),
+			asm.Mov.Imm(asm.R3, networkOrder(r.From)),
+			asm.Mov.Imm(asm.R4, networkOrder(r.To)),
+			asm.Mov.Imm(asm.R5, csumFlags),
+			asm.FnL4CsumReplace.Call(),
+			asm.JNE.Imm(asm.R0, 0, "out"),
+
+			// skb_store_bytes(skb, portOffset, &to, 2, 0)
+			asm.StoreImm(asm.RFP, stackScratch, int64(networkOrder(r.To)), asm.Half),
+			asm.Mov.Reg(asm.R1, asm.R6),
+			asm.Mov.Reg(asm.R2, asm.R8),
+			asm.Add.Imm(asm.R2, portOffset),
+			asm.Mov.Reg(asm.R3, asm.RFP),
+			asm.Add.Imm(asm.R3, stackScratch),
+			asm.Mov.Imm(asm.R4, 2),
+			asm.Mov.Imm(asm.R5, 0),
+			asm.FnSkbStoreBytes.Call(),
+			asm.Ja.Label("out"),
+		)
+		if next != "out" {
+			b.mark(next)
+		}
+	}
+
+	b.mark("out")
+	b.emit(
+		asm.Mov.Imm(asm.R0, tcActOK),
+		asm.Return(),
+	)
+	return b.insns, nil
+}
+
+// ingressProgram returns the program that rewrites the destination ports of incoming packets.
+func ingressProgram(rules []Rule, l2Len int32) (asm.Instructions, error) {
+	return program(rules, dstPortOffset, l2Len)
+}
+
+// egressProgram returns the program that rewrites the source ports of outgoing packets.
+func egressProgram(rules []Rule, l2Len int32) (asm.Instructions, error) {
+	reversed := make([]Rule, len(rules))
+	for i, r := range rules {
+		reversed[i] = Rule{Protocol: r.Protocol, From: r.To, To: r.From}
+	}
+	return program(reversed, srcPortOffset, l2Len)
+}
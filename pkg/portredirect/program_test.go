@@ -0,0 +1,58 @@
+package portredirect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/cilium/ebpf/asm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+)
+
+func Test_builder(t *testing.T) {
+	b := &builder{}
+	b.emit(asm.JNE.Imm(asm.R7, 8080, "out"))
+	b.emit(asm.Mov.Imm(asm.R0, 1))
+	b.mark("out")
+	b.emit(asm.Return())
+	require.Len(t, b.insns, 3)
+	assert.Equal(t, "out", b.insns[2].Symbol())
+	assert.Empty(t, b.label)
+
+	// Marshal resolves the jump to the label.
+	var buf bytes.Buffer
+	require.NoError(t, b.insns.Marshal(&buf, binary.LittleEndian))
+	bs := buf.Bytes()
+	require.Len(t, bs, 3*asm.InstructionSize)
+	assert.Equal(t, int16(1), int16(binary.LittleEndian.Uint16(bs[2:])), "jump offset")
+
+	b = &builder{}
+	b.emit(asm.Ja.Label("missing"), asm.Return())
+	assert.Error(t, b.insns.Marshal(&buf, binary.LittleEndian))
+}
+
+func Test_networkOrder(t *testing.T) {
+	bs := binary.NativeEndian.AppendUint16(nil, uint16(networkOrder(8080)))
+	assert.Equal(t, []byte{0x1f, 0x90}, bs)
+}
+
+func Test_program(t *testing.T) {
+	rules := []Rule{
+		{Protocol: core.ProtocolTCP, From: 8080, To: 9900},
+		{Protocol: core.ProtocolUDP, From: 53, To: 9901},
+	}
+	in, err := ingressProgram(rules, ethernetHeaderLen)
+	require.NoError(t, err)
+	out, err := egressProgram(rules, ethernetHeaderLen)
+	require.NoError(t, err)
+	assert.Len(t, out, len(in))
+	assert.NotEqual(t, in, out)
+	for _, insns := range []asm.Instructions{in, out} {
+		require.NoError(t, insns.Marshal(&bytes.Buffer{}, binary.LittleEndian))
+	}
+
+	_, err = ingressProgram([]Rule{{Protocol: core.ProtocolSCTP, From: 1, To: 2}}, 0)
+	assert.ErrorContains(t, err, "only TCP and UDP are supported")
+}
@@ -17,7 +17,14 @@ import (
 // dispatched as appropriate for the given platform (SIGTERM and SIGINT on Unix platforms
 // and os.Interrupt on Windows).
 func Start(ctx context.Context, env map[string]string, exe string, args ...string) (*dexec.Cmd, error) {
+	return StartInDir(ctx, "", env, exe, args...)
+}
+
+// StartInDir is like Start, but runs the command in the given working directory. The working directory of
+// the current process is used when dir is empty.
+func StartInDir(ctx context.Context, dir string, env map[string]string, exe string, args ...string) (*dexec.Cmd, error) {
 	cmd := CommandContext(ctx, exe, args...)
+	cmd.Dir = dir
 	cmd.DisableLogging = true
 	cmd.Stdout = dos.Stdout(ctx)
 	cmd.Stderr = dos.Stderr(ctx)
@@ -34,6 +41,18 @@ func Start(ctx context.Context, env map[string]string, exe string, args ...strin
 	return cmd, nil
 }
 
+// ExitCodeError is the error returned by Wait when the process exits with a non-zero exit code.
+type ExitCodeError int
+
+func (e ExitCodeError) Error() string {
+	return fmt.Sprintf("exited with %d", int(e))
+}
+
+// ExitCode returns the exit code of the process.
+func (e ExitCodeError) ExitCode() int {
+	return int(e)
+}
+
 // Wait will wait for the Process of the command to finish.
 // If cancel is not nil, Wait will listen for os signals and call cancel when it
 // receives one.
@@ -71,7 +90,7 @@ func Wait(ctx context.Context, cancel context.CancelFunc, cmd *dexec.Cmd) error
 
 	exitCode := s.ExitCode()
 	if exitCode != 0 {
-		return fmt.Errorf("%s: exited with %d", shellquote.ShellString(cmd.Path, cmd.Args), exitCode)
+		return fmt.Errorf("%s: %w", shellquote.ShellString(cmd.Path, cmd.Args), ExitCodeError(exitCode))
 	}
 	return nil
 }
@@ -115,3 +134,15 @@ func IsAdmin() bool {
 func Terminate(p *os.Process) error {
 	return terminate(p)
 }
+
+// IsAlive returns true if a process with the given pid exists.
+func IsAlive(pid int) bool {
+	return isAlive(pid)
+}
+
+// StartTime returns a value that identifies when the process with the given pid was started. A process that
+// reuses the pid of a process that has exited has a different start time. The value is only meaningful when
+// compared to another value returned by this function on the same host.
+func StartTime(pid int) (int64, error) {
+	return startTime(pid)
+}
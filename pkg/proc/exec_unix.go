@@ -5,6 +5,7 @@ package proc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec" //nolint:depguard // We want no logging and no soft-context signal handling
@@ -62,6 +63,12 @@ func terminate(p *os.Process) error {
 	return p.Signal(unix.SIGTERM)
 }
 
+func isAlive(pid int) bool {
+	// Signal 0 performs the error checking only. EPERM means that the process exists but belongs to another user.
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
+
 func createNewProcessGroup(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &unix.SysProcAttr{
 		Setpgid: true,
@@ -169,6 +169,11 @@ func terminateChildrenOf(ctx context.Context, pid uint32, sig os.Signal, pes []*
 	return nil
 }
 
+func isAlive(pid int) bool {
+	alive, err := processIsAlive(uint32(pid))
+	return err == nil && alive
+}
+
 // processIsAlive checks if the given pid exists in the current process snapshot.
 func processIsAlive(pid uint32) (bool, error) {
 	found := false
@@ -207,3 +212,19 @@ func eachProcess(f func(pe *windows.ProcessEntry32) bool) error {
 	}
 	return nil
 }
+
+// startTime returns the creation time of the process, in nanoseconds since the epoch.
+func startTime(pid int) (int64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = windows.CloseHandle(h)
+	}()
+	var creation, exit, kernel, user windows.Filetime
+	if err = windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	return creation.Nanoseconds(), nil
+}
@@ -0,0 +1,18 @@
+package proc
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// startTime returns the start time of the process, in microseconds since the epoch.
+func startTime(pid int) (int64, error) {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return 0, err
+	}
+	if kp.Proc.P_pid != int32(pid) {
+		return 0, unix.ESRCH
+	}
+	st := kp.Proc.P_starttime
+	return st.Sec*1e6 + int64(st.Usec), nil
+}
@@ -11,13 +11,16 @@ import (
 
 	"github.com/datawire/dlib/dhttp"
 	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 const (
 	HeaderCallerInterceptID = "x-telepresence-caller-intercept-id"
 	HeaderInterceptID       = "x-telepresence-intercept-id"
+	HeaderRoute             = "x-telepresence-route"
 	EndPointConsumeHere     = "/consume-here"
 	EndPointInterceptInfo   = "/intercept-info"
+	EndPointMessageInfo     = "/message-info"
 )
 
 type InterceptInfo struct {
@@ -29,6 +32,39 @@ type InterceptInfo struct {
 
 	// Metadata associated with the intercept. Only available on when Intercepted == ClientSide
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ID of the intercept. Only available when Intercepted is true.
+	ID string `json:"id,omitempty"`
+
+	// Client that owns the intercept, in the form user@host. Only available when Intercepted is true.
+	Client string `json:"client,omitempty"`
+
+	// PreviewDomain of the intercept, if it has a preview URL. Only available when Intercepted is true.
+	PreviewDomain string `json:"previewDomain,omitempty"`
+}
+
+// MessageHeader is a binary header of a message, such as a header of a Kafka record, or an entry in the
+// headers table of an AMQP message. The value is base64 encoded in JSON.
+type MessageHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// MessageInfoRequest is the body of a request to the EndPointMessageInfo.
+type MessageInfoRequest struct {
+	// Headers of the message. A key may be repeated.
+	Headers []MessageHeader `json:"headers"`
+
+	// ContainerPort of the intercept, or zero for any port.
+	ContainerPort uint16 `json:"containerPort,omitempty"`
+}
+
+// MessageInfo is the response from the EndPointMessageInfo.
+type MessageInfo struct {
+	InterceptInfo `json:",inline"`
+
+	// True if the message must be consumed by the queried side.
+	ConsumeHere bool `json:"consumeHere"`
 }
 
 type AgentState interface {
@@ -46,14 +82,18 @@ type ErrorResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
-func NewServer(agent AgentState) Server {
+// NewServer creates a new Server. The headers carried by the given propagations are translated into
+// plain headers before the agent is queried.
+func NewServer(agent AgentState, propagations ...matcher.Propagation) Server {
 	return &server{
-		agent: agent,
+		agent:        agent,
+		propagations: propagations,
 	}
 }
 
 type server struct {
-	agent AgentState
+	agent        AgentState
+	propagations []matcher.Propagation
 }
 
 // ListenAndServe is like Serve but creates a TCP listener on "localhost:<apiPort>".
@@ -66,6 +106,7 @@ func (s *server) ListenAndServe(c context.Context, apiPort int) error {
 }
 
 func (s *server) interceptInfo(c context.Context, p string, cp uint16, h http.Header) (*InterceptInfo, error) {
+	h = matcher.Translate(h, s.propagations)
 	return s.agent.InterceptInfo(c, h.Get(HeaderCallerInterceptID), p, cp, h)
 }
 
@@ -124,6 +165,33 @@ func (s *server) Serve(c context.Context, ln net.Listener) error {
 			dlog.Errorf(c, "error %v when responding with %v", err, ii)
 		}
 	})
+	mux.HandleFunc(EndPointMessageInfo, func(w http.ResponseWriter, r *http.Request) {
+		dlog.Debugf(c, "Received %s", EndPointMessageInfo)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires %s", EndPointMessageInfo, http.MethodPost))
+			return
+		}
+		var mr MessageInfoRequest
+		if err := json.UnmarshalRead(r.Body, &mr); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		// The message headers are matched like the headers of an HTTP request.
+		h := make(http.Header, len(mr.Headers))
+		for _, mh := range mr.Headers {
+			h.Add(mh.Key, string(mh.Value))
+		}
+		if ii, err := s.interceptInfo(c, "", mr.ContainerPort, h); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+		} else {
+			mi := &MessageInfo{InterceptInfo: *ii, ConsumeHere: ii.Intercepted == ii.ClientSide}
+			if err = json.MarshalWrite(w, mi); err != nil {
+				dlog.Errorf(c, "error %v when responding with %v", err, mi)
+			}
+		}
+	})
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 )
 
@@ -63,6 +65,21 @@ func (t *matcherWithMetadata) InterceptInfo(ctx context.Context, callerID, path
 	return ret, nil
 }
 
+type matcherWithOwner struct {
+	textMatcherCluster
+	id, client, previewDomain string
+}
+
+func (t *matcherWithOwner) InterceptInfo(ctx context.Context, callerID, path string, containerPort uint16, headers http.Header) (*restapi.InterceptInfo, error) {
+	ret, _ := t.textMatcherCluster.InterceptInfo(ctx, callerID, path, containerPort, headers)
+	if ret.Intercepted {
+		ret.ID = t.id
+		ret.Client = t.client
+		ret.PreviewDomain = t.previewDomain
+	}
+	return ret, nil
+}
+
 type callerIdMatcherClient string
 
 func (c callerIdMatcherClient) InterceptInfo(_ context.Context, callerID, _ string, _ uint16, _ http.Header) (*restapi.InterceptInfo, error) {
@@ -166,6 +183,28 @@ func Test_server_intercepts(t *testing.T) {
 				},
 			},
 		},
+		{
+			"cluster header - match with owner",
+			&matcherWithOwner{
+				textMatcherCluster: textMatcherCluster{
+					restapi.HeaderInterceptID: "abc:123",
+				},
+				id:            "abc:123",
+				client:        "jane@laptop",
+				previewDomain: "echo-jane.preview.example.com",
+			},
+			map[string]string{
+				restapi.HeaderInterceptID: "abc:123",
+			},
+			restapi.EndPointInterceptInfo,
+			&restapi.InterceptInfo{
+				Intercepted:   true,
+				ClientSide:    false,
+				ID:            "abc:123",
+				Client:        "jane@laptop",
+				PreviewDomain: "echo-jane.preview.example.com",
+			},
+		},
 		{
 			"cluster header - no match",
 			textMatcherCluster{
@@ -277,3 +316,95 @@ func Test_server_intercepts(t *testing.T) {
 		})
 	}
 }
+
+func Test_server_propagation(t *testing.T) {
+	c := dlog.WithLogger(context.Background(), log.NewTestLogger(t, dlog.LogLevelWarn))
+	c, cancel := context.WithCancel(c)
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		agent := textMatcherClient{"x-telepresence-id": "jane"}
+		assert.NoError(t, restapi.NewServer(agent, matcher.PropagationBaggage).Serve(c, ln))
+	}()
+
+	consumeHere := func(headers map[string]string) bool {
+		rq, err := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+restapi.EndPointConsumeHere, nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			rq.Header.Set(k, v)
+		}
+		r, err := http.DefaultClient.Do(rq)
+		require.NoError(t, err)
+		defer r.Body.Close()
+		var rpl bool
+		require.NoError(t, json.UnmarshalRead(r.Body, &rpl))
+		return rpl
+	}
+	assert.True(t, consumeHere(map[string]string{"x-telepresence-id": "jane"}))
+	assert.True(t, consumeHere(map[string]string{"baggage": "userId=alice,x-telepresence-id=jane"}))
+	assert.False(t, consumeHere(map[string]string{"tracestate": "x-telepresence-id=jane"}))
+	cancel()
+	wg.Wait()
+}
+
+func Test_server_messageInfo(t *testing.T) {
+	c := dlog.WithLogger(context.Background(), log.NewTestLogger(t, dlog.LogLevelWarn))
+	c, cancel := context.WithCancel(c)
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		agent := &matcherWithMetadata{
+			textMatcherCluster: textMatcherCluster{"x-telepresence-id": "jane"},
+			meta:               map[string]string{"a": "A"},
+		}
+		assert.NoError(t, restapi.NewServer(agent, matcher.PropagationBaggage).Serve(c, ln))
+	}()
+	url := "http://" + ln.Addr().String() + restapi.EndPointMessageInfo
+
+	messageInfo := func(body string) (int, *restapi.MessageInfo) {
+		r, err := http.Post(url, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer r.Body.Close()
+		if r.StatusCode != http.StatusOK {
+			return r.StatusCode, nil
+		}
+		var mi restapi.MessageInfo
+		require.NoError(t, json.UnmarshalRead(r.Body, &mi))
+		return r.StatusCode, &mi
+	}
+
+	// The values are base64 encoded, i.e. "amFuZQ==" is "jane" and "am9l" is "joe".
+	status, mi := messageInfo(`{"headers": [{"key": "x-telepresence-id", "value": "amFuZQ=="}]}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, &restapi.MessageInfo{
+		InterceptInfo: restapi.InterceptInfo{Intercepted: true, Metadata: map[string]string{"a": "A"}},
+		ConsumeHere:   false,
+	}, mi)
+
+	status, mi = messageInfo(`{"headers": [{"key": "x-telepresence-id", "value": "am9l"}]}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.False(t, mi.Intercepted)
+	assert.True(t, mi.ConsumeHere)
+
+	// Headers carried by a tracing standard are translated.
+	status, mi = messageInfo(`{"headers": [{"key": "baggage", "value": "eC10ZWxlcHJlc2VuY2UtaWQ9amFuZQ=="}]}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, mi.Intercepted)
+
+	status, _ = messageInfo(`{"headers": [{"key": "x-telepresence-id", "value": "not base64"}]}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	r, err := http.Get(url)
+	require.NoError(t, err)
+	_ = r.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, r.StatusCode)
+
+	cancel()
+	wg.Wait()
+}
@@ -77,6 +77,19 @@ func GetRoutingTable(ctx context.Context) ([]*Route, error) {
 	return nil, errInconsistentRT
 }
 
+// InterfaceMTU returns the MTU of the interface that the OS will use when sending packets to the given IP. This
+// is not the path MTU, because links further along the path may have a smaller MTU.
+func InterfaceMTU(ctx context.Context, ip netip.Addr) (int, error) {
+	r, err := GetRoute(ctx, netip.PrefixFrom(ip, ip.BitLen()))
+	if err != nil {
+		return 0, err
+	}
+	if r.Interface == nil || r.Interface.MTU <= 0 {
+		return 0, fmt.Errorf("unable to determine MTU for route %s", r)
+	}
+	return r.Interface.MTU, nil
+}
+
 func (r *Route) Routes(ip netip.Addr) bool {
 	return r.RoutedNet.Contains(ip)
 }
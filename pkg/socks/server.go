@@ -0,0 +1,211 @@
+// Package socks contains a minimal SOCKS5 server (RFC 1928) that supports the CONNECT command without
+// authentication. It is used when the client runs with user-space networking, in which case no virtual
+// network interface is available and applications must be pointed to the proxy explicitly.
+package socks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+const (
+	socksVersion = 5
+
+	methodNoAuth       = 0x00
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+
+	replySucceeded          = 0x00
+	replyHostUnreachable    = 0x04
+	replyCmdNotSupported    = 0x07
+	replyAddrTypeNotSupport = 0x08
+
+	handshakeTimeout = 10 * time.Second
+)
+
+// Relay takes over the client connection once the CONNECT request has been acknowledged. It must not
+// return until the connection has been closed, and it must then release the connection to the destination.
+// A relay is also called when the acknowledgement couldn't be sent, in which case the client connection is
+// already closed.
+type Relay func(ctx context.Context, conn net.Conn)
+
+// Server is a SOCKS5 server.
+type Server struct {
+	// Resolve resolves a host name sent by the client into an IP address. Resolving names on the
+	// server side makes it possible to use cluster names that the workstation's resolver doesn't know about.
+	Resolve func(ctx context.Context, name string) (netip.Addr, error)
+
+	// Connect establishes a connection to the given destination on behalf of the client at src.
+	Connect func(ctx context.Context, src, dst netip.AddrPort) (Relay, error)
+}
+
+// Listen listens to the given address, which must be a loopback address, because the server doesn't
+// authenticate its clients.
+func Listen(ctx context.Context, addr string) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	l, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if ta, ok := l.Addr().(*net.TCPAddr); !ok || !ta.IP.IsLoopback() {
+		_ = l.Close()
+		return nil, fmt.Errorf("%s is not a loopback address. The SOCKS5 proxy doesn't authenticate its clients", addr)
+	}
+	return l, nil
+}
+
+// Serve accepts connections on the given listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	dlog.Infof(ctx, "SOCKS5 proxy listening on %s", l.Addr())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	relay, err := s.handshake(ctx, conn)
+	if err != nil {
+		dlog.Debugf(ctx, "SOCKS5 request from %s failed: %v", conn.RemoteAddr(), err)
+		_ = conn.Close()
+		return
+	}
+	relay(ctx, conn)
+}
+
+func (s *Server) handshake(ctx context.Context, conn net.Conn) (Relay, error) {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer func() {
+		_ = conn.SetDeadline(time.Time{})
+	}()
+
+	// Method selection
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return nil, err
+	}
+	if hdr[0] != socksVersion {
+		return nil, fmt.Errorf("unsupported SOCKS version %d", hdr[0])
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return nil, err
+	}
+	method := byte(methodNoAcceptable)
+	for _, m := range methods {
+		if m == methodNoAuth {
+			method = methodNoAuth
+			break
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return nil, err
+	}
+	if method == methodNoAcceptable {
+		return nil, errors.New("client does not support unauthenticated access")
+	}
+
+	// Request
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return nil, err
+	}
+	if req[0] != socksVersion {
+		return nil, fmt.Errorf("unsupported SOCKS version %d", req[0])
+	}
+	if req[1] != cmdConnect {
+		_ = writeReply(conn, replyCmdNotSupported)
+		return nil, fmt.Errorf("unsupported SOCKS command %d", req[1])
+	}
+	dst, err := s.readAddr(ctx, conn, req[3])
+	if err != nil {
+		return nil, err
+	}
+	src, _ := netip.ParseAddrPort(conn.RemoteAddr().String())
+	relay, err := s.Connect(ctx, src, dst)
+	if err != nil {
+		_ = writeReply(conn, replyHostUnreachable)
+		return nil, err
+	}
+	if err = writeReply(conn, replySucceeded); err != nil {
+		// Only the relay can release the connection to the destination.
+		_ = conn.Close()
+		relay(ctx, conn)
+		return nil, err
+	}
+	return relay, nil
+}
+
+func (s *Server) readAddr(ctx context.Context, conn net.Conn, atyp byte) (netip.AddrPort, error) {
+	var addr netip.Addr
+	switch atyp {
+	case atypIPv4:
+		b := make([]byte, 4)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return netip.AddrPort{}, err
+		}
+		addr = netip.AddrFrom4([4]byte(b))
+	case atypIPv6:
+		b := make([]byte, 16)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return netip.AddrPort{}, err
+		}
+		addr = netip.AddrFrom16([16]byte(b))
+	case atypDomain:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return netip.AddrPort{}, err
+		}
+		b := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return netip.AddrPort{}, err
+		}
+		name := string(b)
+		var err error
+		if addr, err = netip.ParseAddr(name); err != nil {
+			if addr, err = s.Resolve(ctx, name); err != nil {
+				// Consume the port before replying so that the client sees the reply.
+				_, _ = io.ReadFull(conn, make([]byte, 2))
+				_ = writeReply(conn, replyHostUnreachable)
+				return netip.AddrPort{}, fmt.Errorf("unable to resolve %q: %w", name, err)
+			}
+		}
+	default:
+		_ = writeReply(conn, replyAddrTypeNotSupport)
+		return netip.AddrPort{}, fmt.Errorf("unsupported SOCKS address type %d", atyp)
+	}
+	pb := make([]byte, 2)
+	if _, err := io.ReadFull(conn, pb); err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(addr.Unmap(), binary.BigEndian.Uint16(pb)), nil
+}
+
+// writeReply writes a reply with an unspecified bound address. Clients that use CONNECT have no use for it.
+func writeReply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{socksVersion, code, 0, atypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,142 @@
+package socks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func startEcho(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+
+	echo := startEcho(t)
+	echoAddr := netip.MustParseAddrPort(echo.Addr().String())
+
+	// The Connect function runs in the server's goroutines, so the destinations are passed on a channel.
+	connectedTo := make(chan netip.AddrPort, 1)
+	srv := &Server{
+		Resolve: func(_ context.Context, name string) (netip.Addr, error) {
+			if name == "echo.default" {
+				return echoAddr.Addr(), nil
+			}
+			return netip.Addr{}, errors.New("no such host")
+		},
+		Connect: func(ctx context.Context, _, dst netip.AddrPort) (Relay, error) {
+			connectedTo <- dst
+			rc, err := net.Dial("tcp", dst.String())
+			if err != nil {
+				return nil, err
+			}
+			return func(_ context.Context, conn net.Conn) {
+				go func() {
+					_, _ = io.Copy(rc, conn)
+					_ = rc.Close()
+				}()
+				_, _ = io.Copy(conn, rc)
+				_ = conn.Close()
+			}, nil
+		},
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = srv.Serve(ctx, l)
+	}()
+
+	d, err := proxy.SOCKS5("tcp", l.Addr().String(), nil, proxy.Direct)
+	require.NoError(t, err)
+
+	t.Run("domain", func(t *testing.T) {
+		conn, err := d.Dial("tcp", net.JoinHostPort("echo.default", strconv.Itoa(int(echoAddr.Port()))))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte("hello"))
+		require.NoError(t, err)
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(buf))
+		assert.Equal(t, echoAddr, <-connectedTo)
+	})
+
+	t.Run("ip", func(t *testing.T) {
+		conn, err := d.Dial("tcp", echoAddr.String())
+		require.NoError(t, err)
+		_ = conn.Close()
+		assert.Equal(t, echoAddr, <-connectedTo)
+	})
+
+	t.Run("unresolvable", func(t *testing.T) {
+		_, err := d.Dial("tcp", "nowhere.default:80")
+		require.Error(t, err)
+	})
+}
+
+func TestListen(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	l, err := Listen(ctx, "127.0.0.1:0")
+	require.NoError(t, err)
+	_ = l.Close()
+
+	// The proxy doesn't authenticate its clients, so it must not be reachable from other hosts.
+	for _, addr := range []string{":0", "0.0.0.0:0"} {
+		_, err = Listen(ctx, addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestServer_replyFailure(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	// The client goes away before the CONNECT request is acknowledged, so the relay must release the destination.
+	released := make(chan struct{})
+	srv := &Server{
+		Connect: func(context.Context, netip.AddrPort, netip.AddrPort) (Relay, error) {
+			return func(_ context.Context, conn net.Conn) {
+				_, _ = conn.Read(make([]byte, 1))
+				close(released)
+			}, nil
+		},
+	}
+	clientSide, serverSide := net.Pipe()
+	go func() {
+		_, _ = clientSide.Write([]byte{socksVersion, 1, methodNoAuth})
+		_, _ = io.ReadFull(clientSide, make([]byte, 2))
+		_, _ = clientSide.Write([]byte{socksVersion, cmdConnect, 0, atypIPv4, 127, 0, 0, 1, 0, 80})
+		_ = clientSide.Close()
+	}()
+	relay, err := srv.handshake(ctx, serverSide)
+	require.Error(t, err)
+	assert.Nil(t, relay)
+	<-released
+}
@@ -0,0 +1,228 @@
+// Package tarstream writes and extracts the tar archives that are used when files are fetched from, or put
+// into, the remote volumes of an intercept.
+package tarstream
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CleanPath returns the given slash separated path relative to a root, without any leading slash and with all
+// ".." elements that would escape the root removed. An empty string is returned when the path denotes the root.
+func CleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
+}
+
+// ErrOutsideRoot is returned by Write when a requested path is a symbolic link that leads out of the root.
+var ErrOutsideRoot = errors.New("path leads outside of the root")
+
+// Write writes a tar archive containing the given paths to w. Each path is first cleaned using CleanPath and then
+// resolved relative to root. Directories are added recursively. Symbolic links are followed as long as they lead
+// to files within root. Entries with links that lead elsewhere are skipped, and so are entries with names that start
+// with "..", because Kubernetes uses them for the bookkeeping of ConfigMap, Secret, and projected volumes. Entries
+// that are neither directories nor regular files are ignored.
+func Write(ctx context.Context, w io.Writer, root string, paths []string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		name := CleanPath(p)
+		if err := writeEntry(ctx, tw, realRoot, filepath.Join(realRoot, filepath.FromSlash(name)), name, make(map[string]struct{})); err != nil {
+			if errors.Is(err, ErrOutsideRoot) {
+				err = fmt.Errorf("%s: %w", p, err)
+			}
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// WriteFiles writes a tar archive containing the given local files and directories to w. Each entry is named
+// using the base name of its path. Directories are added recursively in the same way as Write does it.
+func WriteFiles(ctx context.Context, w io.Writer, paths []string) error {
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		name := filepath.Base(filepath.Clean(p))
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return fmt.Errorf("invalid path %q", p)
+		}
+		if err := writeEntry(ctx, tw, "", p, name, make(map[string]struct{})); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeEntry writes the file or directory at fullPath using the given name. When root isn't empty, it must be a
+// path without symbolic links, and ErrOutsideRoot is returned when fullPath resolves to a path outside of it.
+func writeEntry(ctx context.Context, tw *tar.Writer, root, fullPath, name string, visited map[string]struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if root != "" {
+		realPath, err := filepath.EvalSymlinks(fullPath)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(root, realPath); err != nil || !(rel == "." || filepath.IsLocal(rel)) {
+			return ErrOutsideRoot
+		}
+		// Use the resolved path from here on, so that the links aren't evaluated again.
+		fullPath = realPath
+	}
+	fi, err := os.Stat(fullPath)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() && !fi.Mode().IsRegular() {
+		return nil
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if fi.IsDir() {
+		// Guard against symbolic links that point to a parent directory.
+		realPath, err := filepath.EvalSymlinks(fullPath)
+		if err != nil {
+			return err
+		}
+		if _, ok := visited[realPath]; ok {
+			return nil
+		}
+		visited[realPath] = struct{}{}
+		defer delete(visited, realPath)
+
+		if name != "" {
+			hdr.Name += "/"
+			if err = tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+		}
+		entries, err := os.ReadDir(fullPath)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), "..") {
+				continue
+			}
+			err = writeEntry(ctx, tw, root, filepath.Join(fullPath, e.Name()), path.Join(name, e.Name()), visited)
+			if err != nil && !errors.Is(err, ErrOutsideRoot) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err = io.CopyN(tw, f, hdr.Size); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Extract extracts the tar archive read from r into the directory dir, and returns the number of files that
+// were extracted. Files are made read-only because they are snapshots of remote files. Existing files are
+// replaced. Entries with names that aren't local to dir, and entries that are neither directories nor regular
+// files, are rejected.
+func Extract(r io.Reader, dir string) (int, error) {
+	return extract(r, dir, true)
+}
+
+// ExtractWritable extracts the tar archive read from r into the directory dir, and returns the number of files
+// that were extracted. Files keep the permissions that they have in the archive, and existing files are
+// truncated and overwritten in place, so that processes that have them open will see the new content.
+func ExtractWritable(r io.Reader, dir string) (int, error) {
+	return extract(r, dir, false)
+}
+
+func extract(r io.Reader, dir string, readOnly bool) (int, error) {
+	tr := tar.NewReader(r)
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return count, err
+		}
+		name := filepath.FromSlash(strings.TrimSuffix(hdr.Name, "/"))
+		if !filepath.IsLocal(name) {
+			return count, fmt.Errorf("invalid archive entry %q", hdr.Name)
+		}
+		dst := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(dst, 0o755); err != nil {
+				return count, err
+			}
+		case tar.TypeReg:
+			if err = extractFile(tr, dst, fs.FileMode(hdr.Mode).Perm(), hdr.ModTime, readOnly); err != nil {
+				return count, err
+			}
+			count++
+		default:
+			return count, fmt.Errorf("unsupported type of archive entry %q", hdr.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, dst string, perm fs.FileMode, modTime time.Time, readOnly bool) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if !readOnly {
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+		if err != nil {
+			return err
+		}
+		return writeFile(f, r, dst, modTime)
+	}
+	// A previously fetched file is read-only, so it must be removed rather than truncated.
+	if fi, err := os.Lstat(dst); err == nil {
+		if fi.Mode().IsRegular() {
+			// Windows refuses to remove read-only files.
+			_ = os.Chmod(dst, 0o600)
+		}
+		if err = os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm&^0o222|0o400)
+	if err != nil {
+		return err
+	}
+	return writeFile(f, r, dst, modTime)
+}
+
+func writeFile(f *os.File, r io.Reader, dst string, modTime time.Time) error {
+	_, err := io.Copy(f, r)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(dst, modTime, modTime)
+}
@@ -0,0 +1,161 @@
+package tarstream
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":                       "",
+		"/":                      "",
+		".":                      "",
+		"/var/run/secrets":       "var/run/secrets",
+		"var/run/secrets/":       "var/run/secrets",
+		"../../etc/passwd":       "etc/passwd",
+		"/var/../../etc/passwd":  "etc/passwd",
+		"var/./run//secrets/../": "var/run",
+	}
+	for p, expected := range tests {
+		assert.Equal(t, expected, CleanPath(p), p)
+	}
+}
+
+func TestWriteAndExtract(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	// Mimic the layout that Kubernetes uses for a ConfigMap volume.
+	cmDir := filepath.Join(root, "etc", "config")
+	dataDir := filepath.Join(cmDir, "..2024_01_01_00_00_00.000000000")
+	require.NoError(t, os.MkdirAll(dataDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "app.yaml"), []byte("key: value\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "hostname"), []byte("echo\n"), 0o644))
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(filepath.Base(dataDir), filepath.Join(cmDir, "..data")))
+		require.NoError(t, os.Symlink(filepath.Join("..data", "app.yaml"), filepath.Join(cmDir, "app.yaml")))
+		// A link to a parent directory must not cause an endless recursion.
+		require.NoError(t, os.Symlink("..", filepath.Join(cmDir, "parent")))
+	} else {
+		require.NoError(t, os.WriteFile(filepath.Join(cmDir, "app.yaml"), []byte("key: value\n"), 0o644))
+	}
+
+	buf := bytes.Buffer{}
+	require.NoError(t, Write(ctx, &buf, root, []string{"/etc"}))
+
+	dst := t.TempDir()
+	count, err := Extract(bytes.NewReader(buf.Bytes()), dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	data, err := os.ReadFile(filepath.Join(dst, "etc", "config", "app.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "key: value\n", string(data))
+	assert.NoDirExists(t, filepath.Join(dst, "etc", "config", "..data"))
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(filepath.Join(dst, "etc", "hostname"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o444), fi.Mode().Perm())
+	}
+
+	// Extracting again replaces the read-only files.
+	count, err = Extract(bytes.NewReader(buf.Bytes()), dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestWriteFilesAndExtractWritable(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "out", "reports"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "out", "reports", "a.txt"), []byte("a\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "b.txt"), []byte("b\n"), 0o644))
+
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteFiles(ctx, &buf, []string{filepath.Join(src, "out"), filepath.Join(src, "b.txt")}))
+
+	dst := t.TempDir()
+	existing := filepath.Join(dst, "b.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("old content\n"), 0o644))
+	count, err := ExtractWritable(bytes.NewReader(buf.Bytes()), dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	data, err := os.ReadFile(filepath.Join(dst, "out", "reports", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a\n", string(data))
+	data, err = os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(data))
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(existing)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o644), fi.Mode().Perm())
+	}
+}
+
+func TestWrite_notFound(t *testing.T) {
+	err := Write(context.Background(), &bytes.Buffer{}, t.TempDir(), []string{"nothing/here"})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWrite_escapingSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on Windows")
+	}
+	ctx := context.Background()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret\n"), 0o644))
+	root := filepath.Join(t.TempDir(), "volume")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "data"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "data", "app.yaml"), []byte("key: value\n"), 0o644))
+
+	// Links that lead out of the root, using an absolute path, a relative path, and a directory.
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "data", "absolute")))
+	rel, err := filepath.Rel(filepath.Join(root, "data"), filepath.Join(outside, "secret"))
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(rel, filepath.Join(root, "data", "relative")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "data", "dir")))
+	// A link that stays within the root.
+	require.NoError(t, os.Symlink("app.yaml", filepath.Join(root, "data", "inside")))
+	// The outside directory must be reachable using the relative link for the test to be meaningful.
+	_, err = os.Stat(filepath.Join(root, "data", "relative"))
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, Write(ctx, &buf, root, []string{"data"}))
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+	}
+	assert.ElementsMatch(t, []string{"data/", "data/app.yaml", "data/inside"}, names)
+
+	// A requested path that leads out of the root is rejected.
+	err = Write(ctx, &bytes.Buffer{}, root, []string{"data/absolute"})
+	assert.ErrorIs(t, err, ErrOutsideRoot)
+}
+
+func TestExtract_invalidEntry(t *testing.T) {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o644}))
+	require.NoError(t, tw.Close())
+
+	dst := t.TempDir()
+	_, err := Extract(&buf, filepath.Join(dst, "sub"))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dst, "escape"))
+}
@@ -0,0 +1,143 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ZstdCompression is the name of the zstd compression algorithm.
+const ZstdCompression = "zstd"
+
+// GzipCompression is the name of the gzip compression algorithm.
+const GzipCompression = gzip.Name
+
+func init() {
+	// The gzip compressor registers itself when its package is imported.
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// Compressions returns the names of the compression algorithms that can be used for tunnels, in order of
+// preference.
+func Compressions() []string {
+	return []string{ZstdCompression, GzipCompression}
+}
+
+// NegotiateCompression returns the first algorithm in offered that is also found in allowed, or an empty
+// string when no such algorithm exists. Algorithms that aren't supported are ignored.
+func NegotiateCompression(offered, allowed []string) string {
+	supported := Compressions()
+	for _, c := range offered {
+		if slices.Contains(allowed, c) && slices.Contains(supported, c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// CompressionCallOptions returns the options to use when creating a tunnel that uses the given compression
+// algorithm. No options are returned when the name is empty.
+func CompressionCallOptions(name string) []grpc.CallOption {
+	if name == "" {
+		return nil
+	}
+	return []grpc.CallOption{grpc.UseCompressor(name)}
+}
+
+type compressingManagerClient struct {
+	manager.ManagerClient
+	opts []grpc.CallOption
+}
+
+func (m *compressingManagerClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (manager.Manager_TunnelClient, error) {
+	return m.ManagerClient.Tunnel(ctx, append(opts, m.opts...)...)
+}
+
+// CompressingManagerClient returns a manager client that uses the given compression algorithm for its tunnels. All
+// other calls are left uncompressed. The client is returned unchanged when the name is empty.
+func CompressingManagerClient(mc manager.ManagerClient, name string) manager.ManagerClient {
+	if name == "" {
+		return mc
+	}
+	return &compressingManagerClient{ManagerClient: mc, opts: CompressionCallOptions(name)}
+}
+
+// zstdCompressor is a grpc encoding.Compressor that uses pooled zstd encoders and decoders. The encoders are
+// tuned for speed because they compress live traffic.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (c *zstdCompressor) Name() string {
+	return ZstdCompression
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := c.encoders.Get().(*zstd.Encoder)
+	if ok {
+		enc.Reset(w)
+	} else {
+		var err error
+		enc, err = zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &zstdWriter{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w.Encoder)
+	return err
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if ok {
+		if err := dec.Reset(r); err != nil {
+			c.decoders.Put(dec)
+			return nil, err
+		}
+	} else {
+		var err error
+		dec, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Decoder.Read(p)
+	if errors.Is(err, io.EOF) {
+		// The message has been fully read, so the decoder can be reused.
+		r.pool.Put(r.Decoder)
+		r.Decoder = nil
+	}
+	return n, err
+}
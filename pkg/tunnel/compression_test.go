@@ -0,0 +1,75 @@
+package tunnel
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	tests := []struct {
+		name     string
+		offered  []string
+		allowed  []string
+		expected string
+	}{
+		{
+			name:     "first offered",
+			offered:  []string{"zstd", "gzip"},
+			allowed:  []string{"gzip", "zstd"},
+			expected: "zstd",
+		},
+		{
+			name:     "only allowed",
+			offered:  []string{"zstd", "gzip"},
+			allowed:  []string{"gzip"},
+			expected: "gzip",
+		},
+		{
+			name:     "unsupported",
+			offered:  []string{"snappy", "gzip"},
+			allowed:  []string{"snappy", "gzip"},
+			expected: "gzip",
+		},
+		{
+			name:    "nothing offered",
+			allowed: []string{"zstd", "gzip"},
+		},
+		{
+			name:    "nothing allowed",
+			offered: []string{"zstd", "gzip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NegotiateCompression(tt.offered, tt.allowed))
+		})
+	}
+}
+
+func TestZstdCompressor(t *testing.T) {
+	c := encoding.GetCompressor(ZstdCompression)
+	require.NotNil(t, c)
+
+	// Run several rounds to make sure that pooled encoders and decoders are reset properly.
+	for i := 0; i < 3; i++ {
+		data := bytes.Repeat([]byte{'a', 'b', byte(i)}, 10000)
+		buf := bytes.Buffer{}
+		w, err := c.Compress(&buf)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		assert.Less(t, buf.Len(), len(data))
+
+		r, err := c.Decompress(&buf)
+		require.NoError(t, err)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, data, out)
+	}
+}
@@ -143,7 +143,7 @@ func (h *dialer) Start(ctx context.Context) {
 				return
 			}
 			dlog.Tracef(ctx, "   CONN %s, dial answered", id)
-			h.conn = conn
+			h.conn = tapConn(id, conn)
 
 		case connecting:
 		default:
@@ -0,0 +1,116 @@
+package tunnel
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+)
+
+// A Tap receives copies of the data that flows through the connections that a dialer establishes to a given
+// destination. The methods are called concurrently for different connections, and must not retain the data.
+type Tap interface {
+	// Open is called when a connection has been established.
+	Open(id ConnID)
+
+	// Data is called with data that was written to the destination, or read from it.
+	Data(id ConnID, toDestination bool, data []byte)
+
+	// Close is called when the connection is closed.
+	Close(id ConnID)
+}
+
+//nolint:gochecknoglobals // process wide registry
+var taps = struct {
+	sync.RWMutex
+	m map[netip.AddrPort][]*Tap
+}{m: make(map[netip.AddrPort][]*Tap)}
+
+// AddTap adds a tap for connections that are dialed to the given destination, and returns a function that removes it.
+func AddTap(dest netip.AddrPort, t Tap) (remove func()) {
+	dest = netip.AddrPortFrom(dest.Addr().Unmap(), dest.Port())
+	tp := &t
+	taps.Lock()
+	taps.m[dest] = append(taps.m[dest], tp)
+	taps.Unlock()
+	return func() {
+		taps.Lock()
+		defer taps.Unlock()
+		ts := taps.m[dest]
+		for i, et := range ts {
+			if et == tp {
+				ts = append(ts[:i:i], ts[i+1:]...)
+				break
+			}
+		}
+		if len(ts) == 0 {
+			delete(taps.m, dest)
+		} else {
+			taps.m[dest] = ts
+		}
+	}
+}
+
+// getTaps returns the taps for the destination of the given ConnID.
+func getTaps(id ConnID) []*Tap {
+	addr, ok := netip.AddrFromSlice(id.Destination())
+	if !ok {
+		return nil
+	}
+	taps.RLock()
+	defer taps.RUnlock()
+	ts := taps.m[netip.AddrPortFrom(addr.Unmap(), id.DestinationPort())]
+	if len(ts) == 0 {
+		return nil
+	}
+	return append([]*Tap(nil), ts...)
+}
+
+// tappedConn is a net.Conn that passes the data that is read and written to a set of taps.
+type tappedConn struct {
+	net.Conn
+	id        ConnID
+	taps      []*Tap
+	closeOnce sync.Once
+}
+
+// tapConn returns the given conn, or a conn that passes its data to the taps that are registered for the
+// destination of the given ConnID, if any.
+func tapConn(id ConnID, conn net.Conn) net.Conn {
+	ts := getTaps(id)
+	if ts == nil {
+		return conn
+	}
+	for _, t := range ts {
+		(*t).Open(id)
+	}
+	return &tappedConn{Conn: conn, id: id, taps: ts}
+}
+
+func (c *tappedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		for _, t := range c.taps {
+			(*t).Data(c.id, false, b[:n])
+		}
+	}
+	return n, err
+}
+
+func (c *tappedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		for _, t := range c.taps {
+			(*t).Data(c.id, true, b[:n])
+		}
+	}
+	return n, err
+}
+
+func (c *tappedConn) Close() error {
+	c.closeOnce.Do(func() {
+		for _, t := range c.taps {
+			(*t).Close(c.id)
+		}
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,71 @@
+package tunnel
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+type recordingTap struct {
+	sync.Mutex
+	events []string
+}
+
+func (t *recordingTap) add(s string) {
+	t.Lock()
+	t.events = append(t.events, s)
+	t.Unlock()
+}
+
+func (t *recordingTap) Open(ConnID) {
+	t.add("open")
+}
+
+func (t *recordingTap) Data(_ ConnID, toDestination bool, data []byte) {
+	if toDestination {
+		t.add("> " + string(data))
+	} else {
+		t.add("< " + string(data))
+	}
+}
+
+func (t *recordingTap) Close(ConnID) {
+	t.add("close")
+}
+
+func TestTapConn(t *testing.T) {
+	id := NewConnID(ipproto.TCP, net.IP{10, 0, 0, 1}, net.IP{127, 0, 0, 1}, 4711, 8080)
+	other := NewConnID(ipproto.TCP, net.IP{10, 0, 0, 1}, net.IP{127, 0, 0, 1}, 4711, 8081)
+	rt := &recordingTap{}
+	remove := AddTap(netip.MustParseAddrPort("127.0.0.1:8080"), rt)
+
+	a, b := net.Pipe()
+	defer b.Close()
+	assert.Same(t, a, tapConn(other, a))
+	conn := tapConn(id, a)
+	require.NotSame(t, a, conn)
+
+	go func() {
+		buf := make([]byte, 10)
+		n, _ := b.Read(buf)
+		_, _ = b.Write(append([]byte("re: "), buf[:n]...))
+	}()
+	_, err := conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 10)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "re: ping", string(buf[:n]))
+	require.NoError(t, conn.Close())
+	_ = conn.Close()
+	assert.Equal(t, []string{"open", "> ping", "< re: ping", "close"}, rt.events)
+
+	remove()
+	assert.Same(t, a, tapConn(id, a))
+}
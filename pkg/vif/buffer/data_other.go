@@ -1,5 +1,5 @@
-//go:build !darwin
-// +build !darwin
+//go:build !darwin && !linux
+// +build !darwin,!linux
 
 package buffer
 
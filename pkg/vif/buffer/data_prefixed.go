@@ -1,12 +1,13 @@
-package buffer
+//go:build darwin || linux
+// +build darwin linux
 
-const PrefixLen = 4
+package buffer
 
-// Data on a macOS consists of two slices that share the same underlying byte array. The
+// Data on macOS and Linux consists of two slices that share the same underlying byte array. The
 // raw data points to the beginning of the array and the buf points PrefixLen into the array.
 // All data manipulation is then done using the buf, except reads/writes to the tun device which
-// uses the raw. This setup enables the read/write to receive and write the required 4-byte
-// header that macOS TUN socket uses without copying data.
+// uses the raw. This setup enables the read/write to receive and write the header that precedes
+// each packet without copying data.
 type Data struct {
 	buf []byte
 	raw []byte
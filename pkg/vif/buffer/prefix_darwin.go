@@ -0,0 +1,4 @@
+package buffer
+
+// PrefixLen is the length of the address family header that the macOS TUN socket uses.
+const PrefixLen = 4
@@ -0,0 +1,5 @@
+package buffer
+
+// PrefixLen is the length of the virtio_net_hdr that precedes each packet when the TUN device
+// is opened with IFF_VNET_HDR.
+const PrefixLen = 10
@@ -36,7 +36,8 @@ type Device interface {
 	WaitForDevice()
 }
 
-const defaultDevMtu = 1500
+// DefaultMTU is the MTU used by the TUN device unless another value is given.
+const DefaultMTU = 1500
 
 // Queue length for outbound packet, arriving at fd side for read. Overflow
 // causes packet drops. gVisor implementation-specific.
@@ -44,15 +45,31 @@ const defaultDevOutQueueLen = 1024
 
 var _ Device = (*device)(nil)
 
-// OpenTun creates a new TUN device and ensures that it is up and running.
-func OpenTun(ctx context.Context) (Device, error) {
+// OpenTun creates a new TUN device and ensures that it is up and running. The DefaultMTU is used
+// unless the given mtu is greater than zero.
+func OpenTun(ctx context.Context, mtu int) (Device, error) {
 	dev, err := openTun(ctx)
 	if err != nil {
 		return nil, err
 	}
-
+	if mtu <= 0 {
+		mtu = DefaultMTU
+	}
+	if err = dev.setMTU(mtu); err != nil {
+		dlog.Errorf(ctx, "%v, using MTU %d", err, DefaultMTU)
+		mtu = DefaultMTU
+	} else {
+		dlog.Infof(ctx, "Using MTU %d for %s", mtu, dev.name)
+	}
+	ep := channel.New(defaultDevOutQueueLen, uint32(mtu), "")
+	if gk := dev.supportedGSO(); gk != stack.GSONotSupported {
+		// Inbound packets may have partial checksums when the device uses offloads. They originate
+		// from the local host, so there's no need to validate them.
+		ep.SupportedGSOKind = gk
+		ep.LinkEPCapabilities |= stack.CapabilityRXChecksumOffload
+	}
 	return &device{
-		Endpoint: channel.New(defaultDevOutQueueLen, defaultDevMtu, ""),
+		Endpoint: ep,
 		ctx:      ctx,
 		dev:      dev,
 	}, nil
@@ -67,9 +84,13 @@ func (d *device) Attach(dp stack.NetworkDispatcher) {
 		}
 		dlog.Info(d.ctx, "Starting Endpoint")
 		ctx, cancel := context.WithCancel(d.ctx)
-		d.wg.Add(2)
-		go d.tunToDispatch(cancel)
+		qc := d.dev.queueCount()
+		d.wg.Add(qc + 1)
+		for q := 0; q < qc; q++ {
+			go d.tunToDispatch(q, cancel)
+		}
 		d.dispatchToTun(ctx)
+		cancel()
 	}()
 }
 
@@ -100,8 +121,13 @@ func (d *device) SetDNS(ctx context.Context, clusterDomain string, server netip.
 	return d.dev.setDNS(ctx, clusterDomain, server, domains)
 }
 
+// SetMTU sets the MTU of the TUN device and of the link endpoint that the stack uses.
 func (d *device) SetMTU(mtu uint32) {
-	_ = d.dev.setMTU(int(mtu))
+	if err := d.dev.setMTU(int(mtu)); err != nil {
+		dlog.Error(d.ctx, err)
+		return
+	}
+	d.Endpoint.SetMTU(mtu)
 }
 
 // RemoveSubnet removes a subnet from this TUN device and also removes the route for that subnet which
@@ -118,7 +144,8 @@ func (d *device) WaitForDevice() {
 	dlog.Info(d.ctx, "Endpoint done")
 }
 
-func (d *device) tunToDispatch(cancel context.CancelFunc) {
+// tunToDispatch reads packets from the given queue of the TUN device and injects them into the stack.
+func (d *device) tunToDispatch(queue int, cancel context.CancelFunc) {
 	defer func() {
 		cancel()
 		d.wg.Done()
@@ -126,7 +153,7 @@ func (d *device) tunToDispatch(cancel context.CancelFunc) {
 	buf := vifBuffer.NewData(0x10000)
 	data := buf.Buf()
 	for ok := true; ok; {
-		n, err := d.dev.readPacket(buf)
+		n, err := d.dev.readPacket(queue, buf)
 		if err != nil {
 			ok = d.IsAttached()
 			if ok && d.ctx.Err() == nil {
@@ -171,6 +198,7 @@ func (d *device) dispatchToTun(ctx context.Context) {
 			copy(b, s)
 			b = b[len(s):]
 		}
+		d.dev.setGSOHeader(buf, pb)
 		pb.DecRef()
 		if _, err := d.dev.writePacket(buf, 0); err != nil {
 			dlog.Errorf(ctx, "WritePacket failed: %v", err)
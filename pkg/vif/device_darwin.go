@@ -13,6 +13,7 @@ import (
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/routing"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
@@ -102,7 +103,18 @@ func (t *nativeDevice) setMTU(mtu int) error {
 	})
 }
 
-func (t *nativeDevice) readPacket(into *buffer.Data) (int, error) {
+func (t *nativeDevice) queueCount() int {
+	return 1
+}
+
+func (t *nativeDevice) supportedGSO() stack.SupportedGSO {
+	return stack.GSONotSupported
+}
+
+func (t *nativeDevice) setGSOHeader(*buffer.Data, *stack.PacketBuffer) {
+}
+
+func (t *nativeDevice) readPacket(_ int, into *buffer.Data) (int, error) {
 	n, err := t.File.Read(into.Raw())
 	if n >= buffer.PrefixLen {
 		n -= buffer.PrefixLen
@@ -2,6 +2,7 @@ package vif
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"net/netip"
 	"os"
@@ -10,25 +11,111 @@ import (
 
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
 
+	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/subnet"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
 )
 
 const devicePath = "/dev/net/tun"
 
+// maxQueues is the maximum number of queues of the multiqueue TUN device. Each queue has its own
+// reader, so that packets from different flows can be processed concurrently.
+const maxQueues = 8
+
+// virtio_net_hdr flags and GSO types, from include/uapi/linux/virtio_net.h.
+const (
+	virtioNetHdrFNeedsCsum = 1
+	virtioNetHdrGSOTCPv4   = 1
+	virtioNetHdrGSOTCPv6   = 4
+)
+
 type nativeDevice struct {
-	*os.File
+	queues         []*os.File
 	name           string
 	interfaceIndex int32
+
+	// vnetHdr is true when each packet is preceded by a virtio_net_hdr of length buffer.PrefixLen.
+	vnetHdr bool
 }
 
-func openTun(_ context.Context) (*nativeDevice, error) {
+func openTun(ctx context.Context) (*nativeDevice, error) {
 	// https://www.kernel.org/doc/html/latest/networking/tuntap.html
+	queues := min(runtime.NumCPU(), maxQueues)
+	flags := int16(unix.IFF_TUN | unix.IFF_NO_PI | unix.IFF_VNET_HDR)
+	if queues > 1 {
+		flags |= unix.IFF_MULTI_QUEUE
+	}
+	fd, name, err := openQueue("tel%d", flags)
+	if err != nil {
+		dlog.Warnf(ctx, "unable to open TUN device with offloads, falling back to a single queue without offloads: %v", err)
+		queues = 1
+		flags = unix.IFF_TUN | unix.IFF_NO_PI
+		if fd, name, err = openQueue("tel%d", flags); err != nil {
+			return nil, err
+		}
+	}
+	t := &nativeDevice{
+		queues:  []*os.File{os.NewFile(uintptr(fd), devicePath)},
+		name:    name,
+		vnetHdr: flags&unix.IFF_VNET_HDR != 0,
+	}
+	defer func() {
+		if err != nil {
+			_ = t.Close()
+		}
+	}()
+
+	for len(t.queues) < queues {
+		if fd, _, err = openQueue(name, flags); err != nil {
+			return nil, err
+		}
+		t.queues = append(t.queues, os.NewFile(uintptr(fd), devicePath))
+	}
 
-	fd, err := unix.Open(devicePath, unix.O_RDWR, 0)
+	if t.vnetHdr {
+		// Let the kernel pass checksum calculation and TCP segmentation to us. Packets written to the device may
+		// use GSO regardless of this setting.
+		if oErr := unix.IoctlSetInt(fd, unix.TUNSETOFFLOAD, unix.TUN_F_CSUM|unix.TUN_F_TSO4|unix.TUN_F_TSO6); oErr != nil {
+			dlog.Warnf(ctx, "failed to enable offloads on %s: %v", name, oErr)
+		}
+	}
+
+	// Bring the device up. This is how it's done in ifconfig.
+	provisioningSocket, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM, unix.IPPROTO_IP)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open TUN device %s: %w", devicePath, err)
+		return nil, fmt.Errorf("failed to open provisioning socket: %w", err)
+	}
+	defer unix.Close(provisioningSocket)
+
+	var flagsRequest struct {
+		name  [unix.IFNAMSIZ]byte
+		flags int16
+	}
+	copy(flagsRequest.name[:], name)
+	if err = ioctl(provisioningSocket, unix.SIOCGIFFLAGS, unsafe.Pointer(&flagsRequest)); err != nil {
+		return nil, fmt.Errorf("failed to get flags for %s: %w", name, err)
+	}
+
+	flagsRequest.flags |= unix.IFF_UP | unix.IFF_RUNNING
+	if err = ioctl(provisioningSocket, unix.SIOCSIFFLAGS, unsafe.Pointer(&flagsRequest)); err != nil {
+		return nil, fmt.Errorf("failed to set flags for %s: %w", name, err)
+	}
+
+	if t.interfaceIndex, err = getInterfaceIndex(provisioningSocket, name); err != nil {
+		return nil, err
+	}
+	dlog.Infof(ctx, "Opened TUN device %s with %d queue(s), virtio-net header %t", name, len(t.queues), t.vnetHdr)
+	return t, nil
+}
+
+// openQueue opens a file descriptor for a queue of the TUN device with the given name, or with a generated
+// name if the given name is a template, and returns it together with the actual name of the device.
+func openQueue(name string, flags int16) (fd int, actualName string, err error) {
+	fd, err = unix.Open(devicePath, unix.O_RDWR, 0)
+	if err != nil {
+		return -1, "", fmt.Errorf("failed to open TUN device %s: %w", devicePath, err)
 	}
 	unix.CloseOnExec(fd)
 	defer func() {
@@ -41,25 +128,24 @@ func openTun(_ context.Context) (*nativeDevice, error) {
 		name  [unix.IFNAMSIZ]byte
 		flags int16
 	}
-	copy(flagsRequest.name[:], "tel%d")
-	flagsRequest.flags = unix.IFF_TUN | unix.IFF_NO_PI
+	copy(flagsRequest.name[:], name)
+	flagsRequest.flags = flags
 
 	err = unix.IoctlSetInt(fd, unix.TUNSETIFF, int(uintptr(unsafe.Pointer(&flagsRequest))))
 	if err != nil {
-		return nil, fmt.Errorf("failed to set TUN device flags: %w", err)
+		return -1, "", fmt.Errorf("failed to set TUN device flags: %w", err)
 	}
 
 	// Retrieve the name that was generated based on the "tel%d" template. The
 	// name is zero terminated.
-	var name string
 	for i := 0; i < unix.IFNAMSIZ; i++ {
 		if flagsRequest.name[i] == 0 {
-			name = string(flagsRequest.name[0:i])
+			actualName = string(flagsRequest.name[0:i])
 			break
 		}
 	}
-	if name == "" {
-		name = string(flagsRequest.name[:])
+	if actualName == "" {
+		actualName = string(flagsRequest.name[:])
 	}
 
 	// Set non-blocking so that ReadPacket() doesn't hang for several seconds when the
@@ -67,37 +153,16 @@ func openTun(_ context.Context) (*nativeDevice, error) {
 	//
 	// See: https://github.com/golang/go/issues/30426#issuecomment-470044803
 	_ = unix.SetNonblock(fd, true)
-
-	// Bring the device up. This is how it's done in ifconfig.
-	provisioningSocket, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM, unix.IPPROTO_IP)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open provisioning socket: %w", err)
-	}
-	defer unix.Close(provisioningSocket)
-
-	flagsRequest.flags = 0
-	if err = ioctl(provisioningSocket, unix.SIOCGIFFLAGS, unsafe.Pointer(&flagsRequest)); err != nil {
-		return nil, fmt.Errorf("failed to get flags for %s: %w", name, err)
-	}
-
-	flagsRequest.flags |= unix.IFF_UP | unix.IFF_RUNNING
-	if err = ioctl(provisioningSocket, unix.SIOCSIFFLAGS, unsafe.Pointer(&flagsRequest)); err != nil {
-		return nil, fmt.Errorf("failed to set flags for %s: %w", name, err)
-	}
-
-	index, err := getInterfaceIndex(provisioningSocket, name)
-	if err != nil {
-		return nil, err
-	}
-	return &nativeDevice{File: os.NewFile(uintptr(fd), devicePath), name: name, interfaceIndex: index}, nil
+	return fd, actualName, nil
 }
 
-func (t *nativeDevice) Close() error {
-	err := t.File.Close()
-	if err != nil {
-		return err
+func (t *nativeDevice) Close() (err error) {
+	for _, q := range t.queues {
+		if cErr := q.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
 	}
-	return nil
+	return err
 }
 
 func (t *nativeDevice) addSubnet(ctx context.Context, pfx netip.Prefix) error {
@@ -142,12 +207,70 @@ func (t *nativeDevice) setMTU(mtu int) error {
 	})
 }
 
-func (t *nativeDevice) readPacket(into *buffer.Data) (int, error) {
-	return t.File.Read(into.Raw())
+func (t *nativeDevice) queueCount() int {
+	return len(t.queues)
+}
+
+func (t *nativeDevice) supportedGSO() stack.SupportedGSO {
+	if t.vnetHdr {
+		return stack.HostGSOSupported
+	}
+	return stack.GSONotSupported
+}
+
+// readPacket reads a packet from the given queue. When the device uses virtio-net headers, the packet
+// may be larger than the MTU, and its transport checksum may be partial.
+func (t *nativeDevice) readPacket(queue int, into *buffer.Data) (int, error) {
+	if !t.vnetHdr {
+		return t.queues[queue].Read(into.Buf())
+	}
+	n, err := t.queues[queue].Read(into.Raw())
+	if n >= buffer.PrefixLen {
+		n -= buffer.PrefixLen
+	}
+	return n, err
+}
+
+// setGSOHeader writes the virtio_net_hdr that describes the checksum offload and segmentation of the
+// given packet into the prefix of the given buffer.
+func (t *nativeDevice) setGSOHeader(into *buffer.Data, pb *stack.PacketBuffer) {
+	if !t.vnetHdr {
+		return
+	}
+	h := into.Raw()[:buffer.PrefixLen]
+	clear(h)
+	gso := &pb.GSOOptions
+	if gso.Type == stack.GSONone {
+		return
+	}
+	binary.LittleEndian.PutUint16(h[2:], uint16(pb.HeaderSize()))
+	if gso.NeedsCsum {
+		h[0] = virtioNetHdrFNeedsCsum
+		binary.LittleEndian.PutUint16(h[6:], gso.L3HdrLen)
+		binary.LittleEndian.PutUint16(h[8:], gso.CsumOffset)
+	}
+	if uint16(pb.Data().Size()) > gso.MSS {
+		switch gso.Type {
+		case stack.GSOTCPv4:
+			h[1] = virtioNetHdrGSOTCPv4
+		case stack.GSOTCPv6:
+			h[1] = virtioNetHdrGSOTCPv6
+		}
+		binary.LittleEndian.PutUint16(h[4:], gso.MSS)
+	}
 }
 
+// writePacket writes a packet to the first queue. The kernel doesn't care which queue that packets are
+// written to, and using one queue from one writer retains the order of the packets.
 func (t *nativeDevice) writePacket(from *buffer.Data, offset int) (int, error) {
-	return t.File.Write(from.Raw()[offset:])
+	if !t.vnetHdr {
+		return t.queues[0].Write(from.Buf()[offset:])
+	}
+	n, err := t.queues[0].Write(from.Raw()[offset:])
+	if n >= buffer.PrefixLen {
+		n -= buffer.PrefixLen
+	}
+	return n, err
 }
 
 func getInterfaceIndex(fd int, name string) (int32, error) {
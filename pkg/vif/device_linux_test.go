@@ -0,0 +1,97 @@
+package vif
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gvBuffer "gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
+)
+
+func Test_setGSOHeader(t *testing.T) {
+	newPacket := func(payloadSize int, gso stack.GSO) *stack.PacketBuffer {
+		pb := stack.NewPacketBuffer(stack.PacketBufferOptions{
+			ReserveHeaderBytes: header.IPv4MinimumSize + header.TCPMinimumSize,
+			Payload:            gvBuffer.MakeWithData(make([]byte, payloadSize)),
+		})
+		pb.TransportHeader().Push(header.TCPMinimumSize)
+		pb.NetworkHeader().Push(header.IPv4MinimumSize)
+		pb.GSOOptions = gso
+		return pb
+	}
+	hdrLen := uint16(header.IPv4MinimumSize + header.TCPMinimumSize)
+
+	tests := []struct {
+		name       string
+		vnetHdr    bool
+		payload    int
+		gso        stack.GSO
+		flags      byte
+		gsoType    byte
+		hdrLen     uint16
+		gsoSize    uint16
+		csumStart  uint16
+		csumOffset uint16
+	}{
+		{
+			name:    "no vnet header",
+			payload: 100,
+			gso:     stack.GSO{Type: stack.GSOTCPv4, NeedsCsum: true, MSS: 1460, L3HdrLen: header.IPv4MinimumSize, CsumOffset: 16},
+		},
+		{
+			name:    "no gso",
+			vnetHdr: true,
+			payload: 100,
+		},
+		{
+			name:       "checksum only",
+			vnetHdr:    true,
+			payload:    100,
+			gso:        stack.GSO{Type: stack.GSOTCPv4, NeedsCsum: true, MSS: 1460, L3HdrLen: header.IPv4MinimumSize, CsumOffset: 16},
+			flags:      virtioNetHdrFNeedsCsum,
+			hdrLen:     hdrLen,
+			csumStart:  header.IPv4MinimumSize,
+			csumOffset: 16,
+		},
+		{
+			name:       "segmentation",
+			vnetHdr:    true,
+			payload:    30000,
+			gso:        stack.GSO{Type: stack.GSOTCPv4, NeedsCsum: true, MSS: 1460, L3HdrLen: header.IPv4MinimumSize, CsumOffset: 16},
+			flags:      virtioNetHdrFNeedsCsum,
+			gsoType:    virtioNetHdrGSOTCPv4,
+			hdrLen:     hdrLen,
+			gsoSize:    1460,
+			csumStart:  header.IPv4MinimumSize,
+			csumOffset: 16,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := newPacket(tt.payload, tt.gso)
+			defer pb.DecRef()
+			buf := buffer.NewData(pb.Size())
+			raw := buf.Raw()
+			for i := 0; i < buffer.PrefixLen; i++ {
+				// Buffers are reused, so stale data must be overwritten.
+				raw[i] = 0xff
+			}
+			(&nativeDevice{vnetHdr: tt.vnetHdr}).setGSOHeader(buf, pb)
+			h := raw[:buffer.PrefixLen]
+			if !tt.vnetHdr {
+				assert.Equal(t, byte(0xff), h[0])
+				return
+			}
+			assert.Equal(t, tt.flags, h[0])
+			assert.Equal(t, tt.gsoType, h[1])
+			assert.Equal(t, tt.hdrLen, binary.LittleEndian.Uint16(h[2:]))
+			assert.Equal(t, tt.gsoSize, binary.LittleEndian.Uint16(h[4:]))
+			assert.Equal(t, tt.csumStart, binary.LittleEndian.Uint16(h[6:]))
+			assert.Equal(t, tt.csumOffset, binary.LittleEndian.Uint16(h[8:]))
+		})
+	}
+}
@@ -2,7 +2,6 @@ package vif
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -13,6 +12,7 @@ import (
 	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/tun"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
 
 	"github.com/datawire/dlib/derror"
 	"github.com/datawire/dlib/dlog"
@@ -150,11 +150,33 @@ func addressFamily(ip netip.Addr) winipcfg.AddressFamily {
 	return f
 }
 
-func (t *nativeDevice) setMTU(int) error {
-	return errors.New("not implemented")
+func (t *nativeDevice) setMTU(mtu int) error {
+	luid := t.getLUID()
+	for _, f := range []winipcfg.AddressFamily{windows.AF_INET, windows.AF_INET6} {
+		ipIf, err := luid.IPInterface(f)
+		if err != nil {
+			return fmt.Errorf("set MTU on %s failed: %w", t.name, err)
+		}
+		ipIf.NLMTU = uint32(mtu)
+		if err = ipIf.Set(); err != nil {
+			return fmt.Errorf("set MTU on %s failed: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+func (t *nativeDevice) queueCount() int {
+	return 1
+}
+
+func (t *nativeDevice) supportedGSO() stack.SupportedGSO {
+	return stack.GSONotSupported
+}
+
+func (t *nativeDevice) setGSOHeader(*buffer.Data, *stack.PacketBuffer) {
 }
 
-func (t *nativeDevice) readPacket(into *buffer.Data) (int, error) {
+func (t *nativeDevice) readPacket(_ int, into *buffer.Data) (int, error) {
 	sz := make([]int, 1)
 	packetsN, err := t.Device.Read([][]byte{into.Raw()}, sz, 0)
 	if err != nil {
@@ -39,7 +39,7 @@ func main() {
 	var dev *vif.TunnelingDevice
 	dev, err = vif.NewTunnelingDevice(ctx, func(context.Context, tunnel.ConnID) (tunnel.Stream, error) {
 		return nil, errors.New("stream routing not enabled; refusing to forward")
-	})
+	}, 0)
 	if err != nil {
 		return
 	}
@@ -19,12 +19,15 @@ type TunnelingDevice struct {
 	table  routing.Table
 }
 
-func NewTunnelingDevice(ctx context.Context, tunnelStreamCreator tunnel.StreamCreator) (*TunnelingDevice, error) {
+// NewTunnelingDevice creates a TUN device with the given MTU and a network stack that dispatches the traffic
+// from the device to streams created by the given tunnelStreamCreator. The DefaultMTU is used unless mtu is
+// greater than zero.
+func NewTunnelingDevice(ctx context.Context, tunnelStreamCreator tunnel.StreamCreator, mtu int) (*TunnelingDevice, error) {
 	routingTable, err := routing.OpenTable(ctx)
 	if err != nil {
 		return nil, err
 	}
-	dev, err := OpenTun(ctx)
+	dev, err := OpenTun(ctx, mtu)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +83,27 @@ func StartStatefulSets(ctx context.Context, ns string) cache.SharedIndexInformer
 	return ix
 }
 
+func StartDaemonSets(ctx context.Context, ns string) cache.SharedIndexInformer {
+	f := informer.GetK8sFactory(ctx, ns)
+	ix := f.Apps().V1().DaemonSets().Informer()
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the daemonset that we don't care about. Saves memory
+		if dep, ok := o.(*apps.DaemonSet); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for DaemonSet %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
 func StartRollouts(ctx context.Context, ns string) cache.SharedIndexInformer {
 	f := informer.GetArgoRolloutsFactory(ctx, ns)
 	dlog.Infof(ctx, "Watching Rollouts in %s", ns)
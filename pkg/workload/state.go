@@ -9,6 +9,7 @@ import (
 	argorollouts "github.com/datawire/argo-rollouts-go-client/pkg/apis/rollouts/v1alpha1"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 )
 
 type State int
@@ -60,6 +61,13 @@ func statefulSetState(_ *appsv1.StatefulSet) State {
 	return StateAvailable
 }
 
+func daemonSetState(d *appsv1.DaemonSet) State {
+	if d.Status.ObservedGeneration < d.Generation || d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled {
+		return StateProgressing
+	}
+	return StateAvailable
+}
+
 func rolloutSetState(r *argorollouts.Rollout) State {
 	conds := r.Status.Conditions
 	sort.Slice(conds, func(i, j int) bool {
@@ -113,6 +121,9 @@ func GetWorkloadState(wl k8sapi.Workload) State {
 	if rt, ok := k8sapi.RolloutImpl(wl); ok {
 		return rolloutSetState(rt)
 	}
+	if ds, ok := k8sworkload.DaemonSetImpl(wl); ok {
+		return daemonSetState(ds)
+	}
 	return StateUnknown
 }
 
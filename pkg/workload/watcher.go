@@ -42,10 +42,17 @@ const (
 	StatefulSetWorkloadKind WorkloadKind = "StatefulSet"
 	ReplicaSetWorkloadKind  WorkloadKind = "ReplicaSet"
 	RolloutWorkloadKind     WorkloadKind = "Rollout"
+	DaemonSetWorkloadKind   WorkloadKind = "DaemonSet"
 )
 
+// IsValid returns true if the kind is one of the built-in kinds, or a kind that has a registered
+// agentmap.WorkloadResolver.
 func (w *WorkloadKind) IsValid() bool {
-	return w != nil && slices.Contains([]WorkloadKind{DeploymentWorkloadKind, StatefulSetWorkloadKind, ReplicaSetWorkloadKind, RolloutWorkloadKind}, *w)
+	if w == nil {
+		return false
+	}
+	return slices.Contains([]WorkloadKind{DeploymentWorkloadKind, StatefulSetWorkloadKind, ReplicaSetWorkloadKind, RolloutWorkloadKind, DaemonSetWorkloadKind}, *w) ||
+		agentmap.GetWorkloadResolver(string(*w)) != nil
 }
 
 func (e EventType) String() string {
@@ -173,6 +180,18 @@ func (w *watcher) Subscribe(ctx context.Context) <-chan []WorkloadEvent {
 			}
 		}
 	}
+	if slices.Contains(w.enabledWorkloadKinds, DaemonSetWorkloadKind) {
+		if dss, err := ai.DaemonSets().Lister().DaemonSets(w.namespace).List(labels.Everything()); err == nil {
+			for _, obj := range dss {
+				if wl, ok := FromAny(obj); ok && !hasValidReplicasetOwner(wl, w.enabledWorkloadKinds) {
+					initialEvents = append(initialEvents, WorkloadEvent{
+						Type:     EventTypeAdd,
+						Workload: wl,
+					})
+				}
+			}
+		}
+	}
 	if slices.Contains(w.enabledWorkloadKinds, RolloutWorkloadKind) {
 		ri := kf.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1()
 		if sps, err := ri.Rollouts().Lister().Rollouts(w.namespace).List(labels.Everything()); err == nil {
@@ -280,6 +299,8 @@ func (w *watcher) addEventHandler(ctx context.Context, ns string) error {
 			ssi = ai.ReplicaSets().Informer()
 		case StatefulSetWorkloadKind:
 			ssi = ai.StatefulSets().Informer()
+		case DaemonSetWorkloadKind:
+			ssi = ai.DaemonSets().Informer()
 		case RolloutWorkloadKind:
 			ri := kf.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1()
 			ssi = ri.Rollouts().Informer()
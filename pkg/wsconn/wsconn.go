@@ -0,0 +1,203 @@
+// Package wsconn makes it possible to use a WebSocket connection as a net.Conn, so that gRPC can be served and
+// dialed over WebSocket. This is useful when the traffic-manager must be reached through proxies that only
+// allow HTTPS and WebSocket traffic.
+package wsconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocol is the WebSocket subprotocol that is negotiated by Dial and required by the Listener.
+const Subprotocol = "telepresence.grpc"
+
+type conn struct {
+	ws     *websocket.Conn
+	reader io.Reader
+	wrMu   sync.Mutex
+}
+
+// New returns a net.Conn that reads and writes binary messages on the given WebSocket connection. Each
+// Write results in one message, and Read treats the messages as a contiguous stream of bytes.
+func New(ws *websocket.Conn) net.Conn {
+	return &conn{ws: ws}
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			mt, r, err := c.ws.NextReader()
+			if err != nil {
+				var ce *websocket.CloseError
+				if errors.As(err, &ce) && ce.Code == websocket.CloseNormalClosure {
+					err = io.EOF
+				}
+				return 0, err
+			}
+			if mt != websocket.BinaryMessage {
+				continue
+			}
+			c.reader = r
+		}
+		n, err := c.reader.Read(p)
+		if errors.Is(err, io.EOF) {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.wrMu.Lock()
+	defer c.wrMu.Unlock()
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *conn) Close() error {
+	c.wrMu.Lock()
+	_ = c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.wrMu.Unlock()
+	return c.ws.Close()
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.ws.LocalAddr()
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+func (c *conn) SetDeadline(t time.Time) error {
+	if err := c.ws.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.ws.SetWriteDeadline(t)
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	return c.ws.SetReadDeadline(t)
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	return c.ws.SetWriteDeadline(t)
+}
+
+// Dial establishes a WebSocket connection to the given ws:// or wss:// URL and returns it as a net.Conn. The
+// connection honors the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables.
+func Dial(ctx context.Context, url string) (net.Conn, error) {
+	d := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     []string{Subprotocol},
+	}
+	ws, rs, err := d.DialContext(ctx, url, nil)
+	if err != nil {
+		if rs != nil {
+			err = fmt.Errorf("%w (%s)", err, rs.Status)
+		}
+		return nil, fmt.Errorf("websocket dial %s: %w", url, err)
+	}
+	return New(ws), nil
+}
+
+// Listener is a net.Listener that accepts WebSocket connections. It's also the http.Handler that
+// upgrades the connections.
+type Listener struct {
+	addr     net.Addr
+	conns    chan net.Conn
+	done     chan struct{}
+	once     sync.Once
+	upgrader websocket.Upgrader
+}
+
+// NewListener returns a new Listener. The given address is returned by its Addr method.
+//
+// Requests that have an Origin header are only upgraded when the origin's host is the host of the request, or when
+// the origin is one of the given allowed origins, e.g. "https://dev.example.com". Clients that aren't browsers don't
+// send an Origin header, and are never rejected by this check.
+func NewListener(addr net.Addr, allowedOrigins ...string) *Listener {
+	return &Listener{
+		addr:  addr,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{Subprotocol},
+			CheckOrigin:  originChecker(allowedOrigins),
+		},
+	}
+}
+
+func originChecker(allowedOrigins []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if slices.ContainsFunc(allowedOrigins, func(o string) bool { return strings.EqualFold(o, origin) }) {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return
+	}
+	ws, err := l.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded with an error.
+		return
+	}
+	if ws.Subprotocol() != Subprotocol {
+		_ = ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseProtocolError, "unsupported subprotocol"), time.Now().Add(time.Second))
+		_ = ws.Close()
+		return
+	}
+	select {
+	case l.conns <- New(ws):
+	case <-l.done:
+		_ = ws.Close()
+	}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *Listener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
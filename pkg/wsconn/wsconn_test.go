@@ -0,0 +1,119 @@
+package wsconn
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestConn(t *testing.T) {
+	ctx := context.Background()
+	l := NewListener(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+	defer l.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, _ = io.Copy(c, c)
+	}()
+
+	c, err := Dial(ctx, url)
+	require.NoError(t, err)
+	defer c.Close()
+	for _, msg := range []string{"hello", " ", "world"} {
+		_, err = c.Write([]byte(msg))
+		require.NoError(t, err)
+	}
+	buf := make([]byte, len("hello world"))
+	_, err = io.ReadFull(c, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(buf))
+}
+
+func TestGRPC(t *testing.T) {
+	ctx := context.Background()
+	l := NewListener(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	gs := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(gs, health.NewServer())
+	go func() {
+		_ = gs.Serve(l)
+	}()
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///test",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return Dial(ctx, url)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	rs, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, rs.Status)
+}
+
+func TestListener_rejectsPlainHTTP(t *testing.T) {
+	l := NewListener(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+	rs, err := srv.Client().Get(srv.URL)
+	require.NoError(t, err)
+	rs.Body.Close()
+	assert.Equal(t, 400, rs.StatusCode)
+}
+
+func TestListener_checksOrigin(t *testing.T) {
+	l := NewListener(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, "https://dev.example.com")
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.Close()
+		}
+	}()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	dial := func(origin string) error {
+		d := websocket.Dialer{Subprotocols: []string{Subprotocol}}
+		h := http.Header{}
+		if origin != "" {
+			h.Set("Origin", origin)
+		}
+		ws, _, err := d.Dial(url, h)
+		if err == nil {
+			_ = ws.Close()
+		}
+		return err
+	}
+	assert.NoError(t, dial(""))
+	assert.NoError(t, dial(srv.URL))
+	assert.NoError(t, dial("https://dev.example.com"))
+	assert.ErrorIs(t, dial("https://evil.example.com"), websocket.ErrBadHandshake)
+}
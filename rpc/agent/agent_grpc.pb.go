@@ -24,9 +24,13 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Agent_Tunnel_FullMethodName    = "/telepresence.agent.Agent/Tunnel"
-	Agent_Version_FullMethodName   = "/telepresence.agent.Agent/Version"
-	Agent_WatchDial_FullMethodName = "/telepresence.agent.Agent/WatchDial"
+	Agent_Tunnel_FullMethodName     = "/telepresence.agent.Agent/Tunnel"
+	Agent_Version_FullMethodName    = "/telepresence.agent.Agent/Version"
+	Agent_WatchDial_FullMethodName  = "/telepresence.agent.Agent/WatchDial"
+	Agent_FetchFiles_FullMethodName = "/telepresence.agent.Agent/FetchFiles"
+	Agent_PutFiles_FullMethodName   = "/telepresence.agent.Agent/PutFiles"
+	Agent_Capture_FullMethodName    = "/telepresence.agent.Agent/Capture"
+	Agent_Mounts_FullMethodName     = "/telepresence.agent.Agent/Mounts"
 )
 
 // AgentClient is the client API for Agent service.
@@ -41,6 +45,24 @@ type AgentClient interface {
 	// a Tunnel to the Telepresence client on the workstation. The receiver of
 	// the request dials a connection and responds with the needed Tunnel.
 	WatchDial(ctx context.Context, in *manager.SessionInfo, opts ...grpc.CallOption) (Agent_WatchDialClient, error)
+	// FetchFiles streams a tar archive containing the requested files and
+	// directories found below a mount point of the agent. It's used as a last
+	// resort to access remote volumes when they cannot be mounted.
+	FetchFiles(ctx context.Context, in *FetchFilesRequest, opts ...grpc.CallOption) (Agent_FetchFilesClient, error)
+	// PutFiles extracts a tar archive into a directory below a mount point of
+	// the agent, so that the files can be read by the containers that mount
+	// the volume.
+	PutFiles(ctx context.Context, opts ...grpc.CallOption) (Agent_PutFilesClient, error)
+	// Capture streams the HTTP/1.x requests that arrive at the agent on
+	// connections that are established while the stream is open. The
+	// requests are captured regardless of whether the port is intercepted
+	// or not. The traffic-manager must confirm that the client session is
+	// allowed to intercept the agent's workload.
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Agent_CaptureClient, error)
+	// Mounts returns the volume mounts of an application container that the
+	// agent exports, together with the ports of the agent's FTP and SFTP
+	// servers.
+	Mounts(ctx context.Context, in *MountsRequest, opts ...grpc.CallOption) (*MountsInfo, error)
 }
 
 type agentClient struct {
@@ -126,6 +148,117 @@ func (x *agentWatchDialClient) Recv() (*manager.DialRequest, error) {
 	return m, nil
 }
 
+func (c *agentClient) FetchFiles(ctx context.Context, in *FetchFilesRequest, opts ...grpc.CallOption) (Agent_FetchFilesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Agent_ServiceDesc.Streams[2], Agent_FetchFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentFetchFilesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_FetchFilesClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type agentFetchFilesClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentFetchFilesClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) PutFiles(ctx context.Context, opts ...grpc.CallOption) (Agent_PutFilesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Agent_ServiceDesc.Streams[3], Agent_PutFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentPutFilesClient{ClientStream: stream}
+	return x, nil
+}
+
+type Agent_PutFilesClient interface {
+	Send(*PutFilesRequest) error
+	CloseAndRecv() (*PutFilesResult, error)
+	grpc.ClientStream
+}
+
+type agentPutFilesClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentPutFilesClient) Send(m *PutFilesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentPutFilesClient) CloseAndRecv() (*PutFilesResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(PutFilesResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Agent_CaptureClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Agent_ServiceDesc.Streams[4], Agent_Capture_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentCaptureClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_CaptureClient interface {
+	Recv() (*CapturedRequest, error)
+	grpc.ClientStream
+}
+
+type agentCaptureClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentCaptureClient) Recv() (*CapturedRequest, error) {
+	m := new(CapturedRequest)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) Mounts(ctx context.Context, in *MountsRequest, opts ...grpc.CallOption) (*MountsInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MountsInfo)
+	err := c.cc.Invoke(ctx, Agent_Mounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServer is the server API for Agent service.
 // All implementations must embed UnimplementedAgentServer
 // for forward compatibility
@@ -138,6 +271,24 @@ type AgentServer interface {
 	// a Tunnel to the Telepresence client on the workstation. The receiver of
 	// the request dials a connection and responds with the needed Tunnel.
 	WatchDial(*manager.SessionInfo, Agent_WatchDialServer) error
+	// FetchFiles streams a tar archive containing the requested files and
+	// directories found below a mount point of the agent. It's used as a last
+	// resort to access remote volumes when they cannot be mounted.
+	FetchFiles(*FetchFilesRequest, Agent_FetchFilesServer) error
+	// PutFiles extracts a tar archive into a directory below a mount point of
+	// the agent, so that the files can be read by the containers that mount
+	// the volume.
+	PutFiles(Agent_PutFilesServer) error
+	// Capture streams the HTTP/1.x requests that arrive at the agent on
+	// connections that are established while the stream is open. The
+	// requests are captured regardless of whether the port is intercepted
+	// or not. The traffic-manager must confirm that the client session is
+	// allowed to intercept the agent's workload.
+	Capture(*CaptureRequest, Agent_CaptureServer) error
+	// Mounts returns the volume mounts of an application container that the
+	// agent exports, together with the ports of the agent's FTP and SFTP
+	// servers.
+	Mounts(context.Context, *MountsRequest) (*MountsInfo, error)
 	mustEmbedUnimplementedAgentServer()
 }
 
@@ -154,6 +305,18 @@ func (UnimplementedAgentServer) Version(context.Context, *emptypb.Empty) (*manag
 func (UnimplementedAgentServer) WatchDial(*manager.SessionInfo, Agent_WatchDialServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchDial not implemented")
 }
+func (UnimplementedAgentServer) FetchFiles(*FetchFilesRequest, Agent_FetchFilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchFiles not implemented")
+}
+func (UnimplementedAgentServer) PutFiles(Agent_PutFilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutFiles not implemented")
+}
+func (UnimplementedAgentServer) Capture(*CaptureRequest, Agent_CaptureServer) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
+func (UnimplementedAgentServer) Mounts(context.Context, *MountsRequest) (*MountsInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mounts not implemented")
+}
 func (UnimplementedAgentServer) mustEmbedUnimplementedAgentServer() {}
 
 // UnsafeAgentServer may be embedded to opt out of forward compatibility for this service.
@@ -232,6 +395,92 @@ func (x *agentWatchDialServer) Send(m *manager.DialRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Agent_FetchFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchFilesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).FetchFiles(m, &agentFetchFilesServer{ServerStream: stream})
+}
+
+type Agent_FetchFilesServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type agentFetchFilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentFetchFilesServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Agent_PutFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServer).PutFiles(&agentPutFilesServer{ServerStream: stream})
+}
+
+type Agent_PutFilesServer interface {
+	SendAndClose(*PutFilesResult) error
+	Recv() (*PutFilesRequest, error)
+	grpc.ServerStream
+}
+
+type agentPutFilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentPutFilesServer) SendAndClose(m *PutFilesResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentPutFilesServer) Recv() (*PutFilesRequest, error) {
+	m := new(PutFilesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Agent_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).Capture(m, &agentCaptureServer{ServerStream: stream})
+}
+
+type Agent_CaptureServer interface {
+	Send(*CapturedRequest) error
+	grpc.ServerStream
+}
+
+type agentCaptureServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentCaptureServer) Send(m *CapturedRequest) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Agent_Mounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Mounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Agent_Mounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).Mounts(ctx, req.(*MountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Agent_ServiceDesc is the grpc.ServiceDesc for Agent service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,6 +492,10 @@ var Agent_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Version",
 			Handler:    _Agent_Version_Handler,
 		},
+		{
+			MethodName: "Mounts",
+			Handler:    _Agent_Mounts_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -256,6 +509,21 @@ var Agent_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Agent_WatchDial_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "FetchFiles",
+			Handler:       _Agent_FetchFiles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PutFiles",
+			Handler:       _Agent_PutFiles_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Capture",
+			Handler:       _Agent_Capture_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "agent/agent.proto",
 }
@@ -8,6 +8,7 @@ package connector
 
 import (
 	context "context"
+	agent "github.com/telepresenceio/telepresence/rpc/v2/agent"
 	common "github.com/telepresenceio/telepresence/rpc/v2/common"
 	daemon "github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	manager "github.com/telepresenceio/telepresence/rpc/v2/manager"
@@ -23,34 +24,56 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Connector_Version_FullMethodName                 = "/telepresence.connector.Connector/Version"
-	Connector_RootDaemonVersion_FullMethodName       = "/telepresence.connector.Connector/RootDaemonVersion"
-	Connector_TrafficManagerVersion_FullMethodName   = "/telepresence.connector.Connector/TrafficManagerVersion"
-	Connector_AgentImageFQN_FullMethodName           = "/telepresence.connector.Connector/AgentImageFQN"
-	Connector_GetIntercept_FullMethodName            = "/telepresence.connector.Connector/GetIntercept"
-	Connector_Connect_FullMethodName                 = "/telepresence.connector.Connector/Connect"
-	Connector_Disconnect_FullMethodName              = "/telepresence.connector.Connector/Disconnect"
-	Connector_GetClusterSubnets_FullMethodName       = "/telepresence.connector.Connector/GetClusterSubnets"
-	Connector_Status_FullMethodName                  = "/telepresence.connector.Connector/Status"
-	Connector_CanIntercept_FullMethodName            = "/telepresence.connector.Connector/CanIntercept"
-	Connector_CreateIntercept_FullMethodName         = "/telepresence.connector.Connector/CreateIntercept"
-	Connector_RemoveIntercept_FullMethodName         = "/telepresence.connector.Connector/RemoveIntercept"
-	Connector_UpdateIntercept_FullMethodName         = "/telepresence.connector.Connector/UpdateIntercept"
-	Connector_Uninstall_FullMethodName               = "/telepresence.connector.Connector/Uninstall"
-	Connector_List_FullMethodName                    = "/telepresence.connector.Connector/List"
-	Connector_WatchWorkloads_FullMethodName          = "/telepresence.connector.Connector/WatchWorkloads"
-	Connector_SetLogLevel_FullMethodName             = "/telepresence.connector.Connector/SetLogLevel"
-	Connector_Quit_FullMethodName                    = "/telepresence.connector.Connector/Quit"
-	Connector_GatherLogs_FullMethodName              = "/telepresence.connector.Connector/GatherLogs"
-	Connector_GatherTraces_FullMethodName            = "/telepresence.connector.Connector/GatherTraces"
-	Connector_AddInterceptor_FullMethodName          = "/telepresence.connector.Connector/AddInterceptor"
-	Connector_RemoveInterceptor_FullMethodName       = "/telepresence.connector.Connector/RemoveInterceptor"
-	Connector_GetNamespaces_FullMethodName           = "/telepresence.connector.Connector/GetNamespaces"
-	Connector_GetKnownWorkloadKinds_FullMethodName   = "/telepresence.connector.Connector/GetKnownWorkloadKinds"
-	Connector_RemoteMountAvailability_FullMethodName = "/telepresence.connector.Connector/RemoteMountAvailability"
-	Connector_GetConfig_FullMethodName               = "/telepresence.connector.Connector/GetConfig"
-	Connector_SetDNSExcludes_FullMethodName          = "/telepresence.connector.Connector/SetDNSExcludes"
-	Connector_SetDNSMappings_FullMethodName          = "/telepresence.connector.Connector/SetDNSMappings"
+	Connector_Version_FullMethodName                     = "/telepresence.connector.Connector/Version"
+	Connector_RootDaemonVersion_FullMethodName           = "/telepresence.connector.Connector/RootDaemonVersion"
+	Connector_TrafficManagerVersion_FullMethodName       = "/telepresence.connector.Connector/TrafficManagerVersion"
+	Connector_TrafficManagerCapabilities_FullMethodName  = "/telepresence.connector.Connector/TrafficManagerCapabilities"
+	Connector_AgentImageFQN_FullMethodName               = "/telepresence.connector.Connector/AgentImageFQN"
+	Connector_GetIntercept_FullMethodName                = "/telepresence.connector.Connector/GetIntercept"
+	Connector_Connect_FullMethodName                     = "/telepresence.connector.Connector/Connect"
+	Connector_Disconnect_FullMethodName                  = "/telepresence.connector.Connector/Disconnect"
+	Connector_GetClusterSubnets_FullMethodName           = "/telepresence.connector.Connector/GetClusterSubnets"
+	Connector_Status_FullMethodName                      = "/telepresence.connector.Connector/Status"
+	Connector_CanIntercept_FullMethodName                = "/telepresence.connector.Connector/CanIntercept"
+	Connector_CreateIntercept_FullMethodName             = "/telepresence.connector.Connector/CreateIntercept"
+	Connector_RemoveIntercept_FullMethodName             = "/telepresence.connector.Connector/RemoveIntercept"
+	Connector_UpdateIntercept_FullMethodName             = "/telepresence.connector.Connector/UpdateIntercept"
+	Connector_Uninstall_FullMethodName                   = "/telepresence.connector.Connector/Uninstall"
+	Connector_List_FullMethodName                        = "/telepresence.connector.Connector/List"
+	Connector_WatchWorkloads_FullMethodName              = "/telepresence.connector.Connector/WatchWorkloads"
+	Connector_SetLogLevel_FullMethodName                 = "/telepresence.connector.Connector/SetLogLevel"
+	Connector_Quit_FullMethodName                        = "/telepresence.connector.Connector/Quit"
+	Connector_GatherLogs_FullMethodName                  = "/telepresence.connector.Connector/GatherLogs"
+	Connector_GatherTraces_FullMethodName                = "/telepresence.connector.Connector/GatherTraces"
+	Connector_AddInterceptor_FullMethodName              = "/telepresence.connector.Connector/AddInterceptor"
+	Connector_RemoveInterceptor_FullMethodName           = "/telepresence.connector.Connector/RemoveInterceptor"
+	Connector_IsInterceptorAttached_FullMethodName       = "/telepresence.connector.Connector/IsInterceptorAttached"
+	Connector_GetNamespaces_FullMethodName               = "/telepresence.connector.Connector/GetNamespaces"
+	Connector_GetKnownWorkloadKinds_FullMethodName       = "/telepresence.connector.Connector/GetKnownWorkloadKinds"
+	Connector_RemoteMountAvailability_FullMethodName     = "/telepresence.connector.Connector/RemoteMountAvailability"
+	Connector_GetConfig_FullMethodName                   = "/telepresence.connector.Connector/GetConfig"
+	Connector_SetDNSExcludes_FullMethodName              = "/telepresence.connector.Connector/SetDNSExcludes"
+	Connector_SetDNSMappings_FullMethodName              = "/telepresence.connector.Connector/SetDNSMappings"
+	Connector_FetchFiles_FullMethodName                  = "/telepresence.connector.Connector/FetchFiles"
+	Connector_PutFiles_FullMethodName                    = "/telepresence.connector.Connector/PutFiles"
+	Connector_VerifyHeaderPropagation_FullMethodName     = "/telepresence.connector.Connector/VerifyHeaderPropagation"
+	Connector_DescribeWorkload_FullMethodName            = "/telepresence.connector.Connector/DescribeWorkload"
+	Connector_ExposeRegistry_FullMethodName              = "/telepresence.connector.Connector/ExposeRegistry"
+	Connector_PublishService_FullMethodName              = "/telepresence.connector.Connector/PublishService"
+	Connector_UnpublishService_FullMethodName            = "/telepresence.connector.Connector/UnpublishService"
+	Connector_ShareIntercept_FullMethodName              = "/telepresence.connector.Connector/ShareIntercept"
+	Connector_JoinIntercept_FullMethodName               = "/telepresence.connector.Connector/JoinIntercept"
+	Connector_LeaveIntercept_FullMethodName              = "/telepresence.connector.Connector/LeaveIntercept"
+	Connector_Evict_FullMethodName                       = "/telepresence.connector.Connector/Evict"
+	Connector_Wiretap_FullMethodName                     = "/telepresence.connector.Connector/Wiretap"
+	Connector_Capture_FullMethodName                     = "/telepresence.connector.Connector/Capture"
+	Connector_Mount_FullMethodName                       = "/telepresence.connector.Connector/Mount"
+	Connector_RefreshInterceptEnvironment_FullMethodName = "/telepresence.connector.Connector/RefreshInterceptEnvironment"
+	Connector_WatchEvents_FullMethodName                 = "/telepresence.connector.Connector/WatchEvents"
+	Connector_HandOff_FullMethodName                     = "/telepresence.connector.Connector/HandOff"
+	Connector_AddPortForwards_FullMethodName             = "/telepresence.connector.Connector/AddPortForwards"
+	Connector_RemovePortForwards_FullMethodName          = "/telepresence.connector.Connector/RemovePortForwards"
+	Connector_ListPortForwards_FullMethodName            = "/telepresence.connector.Connector/ListPortForwards"
 )
 
 // ConnectorClient is the client API for Connector service.
@@ -66,6 +89,10 @@ type ConnectorClient interface {
 	RootDaemonVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*common.VersionInfo, error)
 	// Returns version information from the Traffic Manager
 	TrafficManagerVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*common.VersionInfo, error)
+	// Returns the optional features that the Traffic Manager supports. Features
+	// are derived from the version of Traffic Managers that predate the
+	// GetCapabilities call.
+	TrafficManagerCapabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.Capabilities, error)
 	// Returns the fully qualified image name of the traffic-agent that the agent-injector is configured to inject.
 	AgentImageFQN(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentImageFQN, error)
 	// GetIntercept gets info from intercept name
@@ -77,8 +104,9 @@ type ConnectorClient interface {
 	// MUST_RESTART is returned, based on whether the current connection
 	// is in agreement with the ConnectionRequest.
 	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectInfo, error)
-	// Disconnects the cluster
-	Disconnect(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Disconnects the cluster. The result is wire compatible with
+	// google.protobuf.Empty.
+	Disconnect(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DisconnectResult, error)
 	// GetClusterSubnets gets the outbound info that has been set on daemon
 	GetClusterSubnets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClusterSubnets, error)
 	// Status returns the status of the current connection or DISCONNECTED
@@ -116,6 +144,11 @@ type ConnectorClient interface {
 	AddInterceptor(ctx context.Context, in *Interceptor, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// RemoveInterceptor removes a previously added interceptor
 	RemoveInterceptor(ctx context.Context, in *Interceptor, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// IsInterceptorAttached tells whether a previously added interceptor is
+	// still attached to its intercept, i.e. whether the intercept exists and
+	// the connector hasn't stopped the interceptor. An intercept handler that
+	// exits is only restarted while it's attached.
+	IsInterceptorAttached(ctx context.Context, in *Interceptor, opts ...grpc.CallOption) (*InterceptorAttachedResult, error)
 	// GetNamespaces gets the mapped namespaces with an optional prefix
 	GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error)
 	// GetKnownWorkloadKinds returns the known workload kinds
@@ -129,6 +162,74 @@ type ConnectorClient interface {
 	SetDNSExcludes(ctx context.Context, in *daemon.SetDNSExcludesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(ctx context.Context, in *daemon.SetDNSMappingsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// FetchFiles streams a tar archive containing the requested files and
+	// directories from the remote volumes of an intercept.
+	FetchFiles(ctx context.Context, in *FetchFilesRequest, opts ...grpc.CallOption) (Connector_FetchFilesClient, error)
+	// PutFiles uploads a tar archive with local files and directories to a
+	// directory in the remote volumes of an intercept.
+	PutFiles(ctx context.Context, opts ...grpc.CallOption) (Connector_PutFilesClient, error)
+	// VerifyHeaderPropagation verifies that the header of an intercept is propagated
+	// through a chain of workloads that lead up to the intercepted workload.
+	VerifyHeaderPropagation(ctx context.Context, in *manager.HeaderPropagationRequest, opts ...grpc.CallOption) (*manager.HeaderPropagationResult, error)
+	// DescribeWorkload describes a workload the way that the traffic-manager sees it
+	// when it prepares an intercept. The namespace of the request defaults to the
+	// namespace of the session.
+	DescribeWorkload(ctx context.Context, in *manager.DescribeWorkloadRequest, opts ...grpc.CallOption) (*manager.WorkloadDescription, error)
+	// ExposeRegistry exposes a container image registry on the workstation to the
+	// cluster through the traffic-manager's registry proxy. The address of the
+	// request is resolved by the user daemon. An empty address ends the exposure.
+	ExposeRegistry(ctx context.Context, in *manager.RegistryProxyRequest, opts ...grpc.CallOption) (*manager.RegistryProxyInfo, error)
+	// PublishService creates a Service in the cluster whose connections are
+	// forwarded to ports on the workstation. The Service is deleted when it's
+	// unpublished, or when the session ends.
+	PublishService(ctx context.Context, in *manager.PublishServiceRequest, opts ...grpc.CallOption) (*manager.PublishedServiceInfo, error)
+	// UnpublishService deletes a Service that was created by PublishService.
+	UnpublishService(ctx context.Context, in *manager.PublishServiceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ShareIntercept returns a token that a teammate can use to join the
+	// named intercept.
+	ShareIntercept(ctx context.Context, in *manager.GetInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptShareToken, error)
+	// JoinIntercept joins the intercept that the token was issued for, so
+	// that this workstation receives some of its connections.
+	JoinIntercept(ctx context.Context, in *manager.JoinInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
+	// LeaveIntercept leaves an intercept that was joined using JoinIntercept.
+	LeaveIntercept(ctx context.Context, in *manager.JoinInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Evict forcibly removes sessions and intercepts using the admin API of
+	// the traffic-manager. The bearer token of the request is set from the
+	// kubeconfig of the session.
+	Evict(ctx context.Context, in *manager.EvictRequest, opts ...grpc.CallOption) (*manager.ReapResult, error)
+	// Wiretap streams the traffic of the connections to the handler of the named intercept until the
+	// call is cancelled. The connections may be established by the user daemon or by the root daemon.
+	Wiretap(ctx context.Context, in *WiretapRequest, opts ...grpc.CallOption) (Connector_WiretapClient, error)
+	// Capture streams the HTTP/1.x requests that the traffic-agents of a workload receive until the
+	// call is cancelled. A traffic-agent is injected into the workload if it doesn't have one.
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Connector_CaptureClient, error)
+	// Mount mounts the volumes of a workload's pod without intercepting it. One MountInfo is
+	// sent when the volumes are mounted, and they remain mounted until the call is cancelled.
+	// A traffic-agent is injected into the workload if it doesn't have one.
+	Mount(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (Connector_MountClient, error)
+	// RefreshInterceptEnvironment clears the environment drift of an intercept and
+	// returns its current info, so that the client can rewrite its environment.
+	RefreshInterceptEnvironment(ctx context.Context, in *manager.GetInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
+	// WatchEvents streams events that describe changes of the session, the dispositions and mounts of
+	// its intercepts, and their handlers, as they happen, until the call is cancelled. It's intended
+	// for IDE integrations that would otherwise poll List and Status.
+	WatchEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Connector_WatchEventsClient, error)
+	// HandOff carries one TCP connection that the user daemon accepts on a
+	// localhost port of its own host to a client on another host, which connects
+	// it to the same port of its own host. The first message from the client
+	// names the port, and the daemon responds with a message without data when
+	// it has accepted a connection.
+	HandOff(ctx context.Context, opts ...grpc.CallOption) (Connector_HandOffClient, error)
+	// AddPortForwards forwards localhost ports to ports of a service or pod in
+	// the cluster, through the traffic-manager's tunnel. The forwards remain
+	// until they are removed or the session ends.
+	AddPortForwards(ctx context.Context, in *PortForwardRequest, opts ...grpc.CallOption) (*PortForwardList, error)
+	// RemovePortForwards removes the forwards to the target of the request. All
+	// forwards to the target are removed unless ports are given, in which case
+	// only the forwards from their local ports are removed.
+	RemovePortForwards(ctx context.Context, in *PortForwardRequest, opts ...grpc.CallOption) (*PortForwardList, error)
+	// ListPortForwards lists the port forwards of the session.
+	ListPortForwards(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PortForwardList, error)
 }
 
 type connectorClient struct {
@@ -169,6 +270,16 @@ func (c *connectorClient) TrafficManagerVersion(ctx context.Context, in *emptypb
 	return out, nil
 }
 
+func (c *connectorClient) TrafficManagerCapabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.Capabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.Capabilities)
+	err := c.cc.Invoke(ctx, Connector_TrafficManagerCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *connectorClient) AgentImageFQN(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*manager.AgentImageFQN, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(manager.AgentImageFQN)
@@ -199,9 +310,9 @@ func (c *connectorClient) Connect(ctx context.Context, in *ConnectRequest, opts
 	return out, nil
 }
 
-func (c *connectorClient) Disconnect(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+func (c *connectorClient) Disconnect(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DisconnectResult, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(emptypb.Empty)
+	out := new(DisconnectResult)
 	err := c.cc.Invoke(ctx, Connector_Disconnect_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
@@ -382,6 +493,16 @@ func (c *connectorClient) RemoveInterceptor(ctx context.Context, in *Interceptor
 	return out, nil
 }
 
+func (c *connectorClient) IsInterceptorAttached(ctx context.Context, in *Interceptor, opts ...grpc.CallOption) (*InterceptorAttachedResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterceptorAttachedResult)
+	err := c.cc.Invoke(ctx, Connector_IsInterceptorAttached_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *connectorClient) GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetNamespacesResponse)
@@ -442,236 +563,760 @@ func (c *connectorClient) SetDNSMappings(ctx context.Context, in *daemon.SetDNSM
 	return out, nil
 }
 
-// ConnectorServer is the server API for Connector service.
-// All implementations must embed UnimplementedConnectorServer
-// for forward compatibility
-//
-// The Connector service is responsible for connecting to the traffic manager
-// and manage intercepts. It can only run when a Daemon is running.
-type ConnectorServer interface {
-	// Returns version information from the Connector
-	Version(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
-	// Returns version information from the Root Daemon
-	RootDaemonVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
-	// Returns version information from the Traffic Manager
-	TrafficManagerVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
-	// Returns the fully qualified image name of the traffic-agent that the agent-injector is configured to inject.
-	AgentImageFQN(context.Context, *emptypb.Empty) (*manager.AgentImageFQN, error)
-	// GetIntercept gets info from intercept name
-	GetIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error)
-	// Connects to the cluster and connects the laptop's network (via
-	// the daemon process) to the cluster's network.  A result code of
-	// UNSPECIFIED indicates that the connection was successfully
-	// initiated; if already connected, then either ALREADY_CONNECTED or
-	// MUST_RESTART is returned, based on whether the current connection
-	// is in agreement with the ConnectionRequest.
-	Connect(context.Context, *ConnectRequest) (*ConnectInfo, error)
-	// Disconnects the cluster
-	Disconnect(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
-	// GetClusterSubnets gets the outbound info that has been set on daemon
-	GetClusterSubnets(context.Context, *emptypb.Empty) (*ClusterSubnets, error)
-	// Status returns the status of the current connection or DISCONNECTED
-	// if no connection has been established.
-	Status(context.Context, *emptypb.Empty) (*ConnectInfo, error)
-	// Queries the connector whether it is possible to create the given intercept.
-	CanIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error)
-	// Adds an intercept to a workload.  Requires having already called
-	// Connect.
-	CreateIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error)
-	// Deactivates and removes an existent workload intercept.
-	// Requires having already called Connect.
-	RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error)
-	UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error)
-	// Uninstalls traffic-agents from the cluster.
-	// Requires having already called Connect.
-	Uninstall(context.Context, *UninstallRequest) (*common.Result, error)
-	// Returns a list of workloads and their current intercept status.
-	// Requires having already called Connect.
-	List(context.Context, *ListRequest) (*WorkloadInfoSnapshot, error)
-	// Watch all workloads in the mapped namespaces
-	WatchWorkloads(*WatchWorkloadsRequest, Connector_WatchWorkloadsServer) error
-	// SetLogLevel will temporarily change the log-level of the traffic-manager, traffic-agent, and user and root daemons.
-	SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error)
-	// Quits (terminates) the connector process.
-	Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
-	// GatherLogs will acquire logs for the various Telepresence components in kubernetes
-	// (pending the request) and return them to the caller
-	GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error)
-	// GatherTraces will acquire traces for the various Telepresence components in kubernetes
-	// (pending the request) and save them in a file.
-	GatherTraces(context.Context, *TracesRequest) (*common.Result, error)
-	// AddInterceptor tells the connector that a given process is serving a specific
-	// intercept. The connector must kill this process when the intercept ends
-	AddInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error)
-	// RemoveInterceptor removes a previously added interceptor
-	RemoveInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error)
-	// GetNamespaces gets the mapped namespaces with an optional prefix
-	GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error)
-	// GetKnownWorkloadKinds returns the known workload kinds
-	GetKnownWorkloadKinds(context.Context, *emptypb.Empty) (*manager.KnownWorkloadKinds, error)
-	// RemoteMountAvailability checks if remote mounts are possible using the given
-	// mount type and returns an error if its not.
-	RemoteMountAvailability(context.Context, *emptypb.Empty) (*common.Result, error)
-	// GetConfig returns the current configuration
-	GetConfig(context.Context, *emptypb.Empty) (*ClientConfig, error)
-	// SetDNSExcludes sets the excludes field of DNSConfig.
-	SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error)
-	// SetDNSMappings sets the Mappings field of DNSConfig.
-	SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error)
-	mustEmbedUnimplementedConnectorServer()
+func (c *connectorClient) FetchFiles(ctx context.Context, in *FetchFilesRequest, opts ...grpc.CallOption) (Connector_FetchFilesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[1], Connector_FetchFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorFetchFilesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-// UnimplementedConnectorServer must be embedded to have forward compatible implementations.
-type UnimplementedConnectorServer struct {
+type Connector_FetchFilesClient interface {
+	Recv() (*agent.FileChunk, error)
+	grpc.ClientStream
 }
 
-func (UnimplementedConnectorServer) Version(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
-}
-func (UnimplementedConnectorServer) RootDaemonVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RootDaemonVersion not implemented")
-}
-func (UnimplementedConnectorServer) TrafficManagerVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method TrafficManagerVersion not implemented")
-}
-func (UnimplementedConnectorServer) AgentImageFQN(context.Context, *emptypb.Empty) (*manager.AgentImageFQN, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AgentImageFQN not implemented")
-}
-func (UnimplementedConnectorServer) GetIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetIntercept not implemented")
-}
-func (UnimplementedConnectorServer) Connect(context.Context, *ConnectRequest) (*ConnectInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Connect not implemented")
-}
-func (UnimplementedConnectorServer) Disconnect(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Disconnect not implemented")
-}
-func (UnimplementedConnectorServer) GetClusterSubnets(context.Context, *emptypb.Empty) (*ClusterSubnets, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetClusterSubnets not implemented")
-}
-func (UnimplementedConnectorServer) Status(context.Context, *emptypb.Empty) (*ConnectInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
-}
-func (UnimplementedConnectorServer) CanIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CanIntercept not implemented")
-}
-func (UnimplementedConnectorServer) CreateIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CreateIntercept not implemented")
-}
-func (UnimplementedConnectorServer) RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RemoveIntercept not implemented")
-}
-func (UnimplementedConnectorServer) UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method UpdateIntercept not implemented")
-}
-func (UnimplementedConnectorServer) Uninstall(context.Context, *UninstallRequest) (*common.Result, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Uninstall not implemented")
-}
-func (UnimplementedConnectorServer) List(context.Context, *ListRequest) (*WorkloadInfoSnapshot, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
-}
-func (UnimplementedConnectorServer) WatchWorkloads(*WatchWorkloadsRequest, Connector_WatchWorkloadsServer) error {
-	return status.Errorf(codes.Unimplemented, "method WatchWorkloads not implemented")
-}
-func (UnimplementedConnectorServer) SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
-}
-func (UnimplementedConnectorServer) Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Quit not implemented")
-}
-func (UnimplementedConnectorServer) GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GatherLogs not implemented")
-}
-func (UnimplementedConnectorServer) GatherTraces(context.Context, *TracesRequest) (*common.Result, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GatherTraces not implemented")
-}
-func (UnimplementedConnectorServer) AddInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddInterceptor not implemented")
-}
-func (UnimplementedConnectorServer) RemoveInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RemoveInterceptor not implemented")
-}
-func (UnimplementedConnectorServer) GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetNamespaces not implemented")
-}
-func (UnimplementedConnectorServer) GetKnownWorkloadKinds(context.Context, *emptypb.Empty) (*manager.KnownWorkloadKinds, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetKnownWorkloadKinds not implemented")
-}
-func (UnimplementedConnectorServer) RemoteMountAvailability(context.Context, *emptypb.Empty) (*common.Result, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RemoteMountAvailability not implemented")
+type connectorFetchFilesClient struct {
+	grpc.ClientStream
 }
-func (UnimplementedConnectorServer) GetConfig(context.Context, *emptypb.Empty) (*ClientConfig, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+
+func (x *connectorFetchFilesClient) Recv() (*agent.FileChunk, error) {
+	m := new(agent.FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
-func (UnimplementedConnectorServer) SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SetDNSExcludes not implemented")
+
+func (c *connectorClient) PutFiles(ctx context.Context, opts ...grpc.CallOption) (Connector_PutFilesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[2], Connector_PutFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorPutFilesClient{ClientStream: stream}
+	return x, nil
 }
-func (UnimplementedConnectorServer) SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
+
+type Connector_PutFilesClient interface {
+	Send(*PutFilesRequest) error
+	CloseAndRecv() (*agent.PutFilesResult, error)
+	grpc.ClientStream
 }
-func (UnimplementedConnectorServer) mustEmbedUnimplementedConnectorServer() {}
 
-// UnsafeConnectorServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to ConnectorServer will
-// result in compilation errors.
-type UnsafeConnectorServer interface {
-	mustEmbedUnimplementedConnectorServer()
+type connectorPutFilesClient struct {
+	grpc.ClientStream
 }
 
-func RegisterConnectorServer(s grpc.ServiceRegistrar, srv ConnectorServer) {
-	s.RegisterService(&Connector_ServiceDesc, srv)
+func (x *connectorPutFilesClient) Send(m *PutFilesRequest) error {
+	return x.ClientStream.SendMsg(m)
 }
 
-func _Connector_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
-	if err := dec(in); err != nil {
+func (x *connectorPutFilesClient) CloseAndRecv() (*agent.PutFilesResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(ConnectorServer).Version(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: Connector_Version_FullMethodName,
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).Version(ctx, req.(*emptypb.Empty))
+	m := new(agent.PutFilesResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	return interceptor(ctx, in, info, handler)
+	return m, nil
 }
 
-func _Connector_RootDaemonVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
-	if err := dec(in); err != nil {
+func (c *connectorClient) VerifyHeaderPropagation(ctx context.Context, in *manager.HeaderPropagationRequest, opts ...grpc.CallOption) (*manager.HeaderPropagationResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.HeaderPropagationResult)
+	err := c.cc.Invoke(ctx, Connector_VerifyHeaderPropagation_FullMethodName, in, out, cOpts...)
+	if err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(ConnectorServer).RootDaemonVersion(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: Connector_RootDaemonVersion_FullMethodName,
+	return out, nil
+}
+
+func (c *connectorClient) DescribeWorkload(ctx context.Context, in *manager.DescribeWorkloadRequest, opts ...grpc.CallOption) (*manager.WorkloadDescription, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.WorkloadDescription)
+	err := c.cc.Invoke(ctx, Connector_DescribeWorkload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).RootDaemonVersion(ctx, req.(*emptypb.Empty))
+	return out, nil
+}
+
+func (c *connectorClient) ExposeRegistry(ctx context.Context, in *manager.RegistryProxyRequest, opts ...grpc.CallOption) (*manager.RegistryProxyInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.RegistryProxyInfo)
+	err := c.cc.Invoke(ctx, Connector_ExposeRegistry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
 	}
-	return interceptor(ctx, in, info, handler)
+	return out, nil
 }
 
-func _Connector_TrafficManagerVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
-	if err := dec(in); err != nil {
+func (c *connectorClient) PublishService(ctx context.Context, in *manager.PublishServiceRequest, opts ...grpc.CallOption) (*manager.PublishedServiceInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.PublishedServiceInfo)
+	err := c.cc.Invoke(ctx, Connector_PublishService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) UnpublishService(ctx context.Context, in *manager.PublishServiceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_UnpublishService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) ShareIntercept(ctx context.Context, in *manager.GetInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptShareToken, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.InterceptShareToken)
+	err := c.cc.Invoke(ctx, Connector_ShareIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) JoinIntercept(ctx context.Context, in *manager.JoinInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.InterceptInfo)
+	err := c.cc.Invoke(ctx, Connector_JoinIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) LeaveIntercept(ctx context.Context, in *manager.JoinInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Connector_LeaveIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) Evict(ctx context.Context, in *manager.EvictRequest, opts ...grpc.CallOption) (*manager.ReapResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.ReapResult)
+	err := c.cc.Invoke(ctx, Connector_Evict_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) Wiretap(ctx context.Context, in *WiretapRequest, opts ...grpc.CallOption) (Connector_WiretapClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[3], Connector_Wiretap_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorWiretapClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_WiretapClient interface {
+	Recv() (*daemon.WiretapEvent, error)
+	grpc.ClientStream
+}
+
+type connectorWiretapClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorWiretapClient) Recv() (*daemon.WiretapEvent, error) {
+	m := new(daemon.WiretapEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Connector_CaptureClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[4], Connector_Capture_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorCaptureClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_CaptureClient interface {
+	Recv() (*agent.CapturedRequest, error)
+	grpc.ClientStream
+}
+
+type connectorCaptureClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorCaptureClient) Recv() (*agent.CapturedRequest, error) {
+	m := new(agent.CapturedRequest)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) Mount(ctx context.Context, in *MountRequest, opts ...grpc.CallOption) (Connector_MountClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[5], Connector_Mount_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorMountClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_MountClient interface {
+	Recv() (*MountInfo, error)
+	grpc.ClientStream
+}
+
+type connectorMountClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorMountClient) Recv() (*MountInfo, error) {
+	m := new(MountInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) RefreshInterceptEnvironment(ctx context.Context, in *manager.GetInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.InterceptInfo)
+	err := c.cc.Invoke(ctx, Connector_RefreshInterceptEnvironment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) WatchEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Connector_WatchEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[6], Connector_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorWatchEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type connectorWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) HandOff(ctx context.Context, opts ...grpc.CallOption) (Connector_HandOffClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[7], Connector_HandOff_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorHandOffClient{ClientStream: stream}
+	return x, nil
+}
+
+type Connector_HandOffClient interface {
+	Send(*HandOffMessage) error
+	Recv() (*HandOffMessage, error)
+	grpc.ClientStream
+}
+
+type connectorHandOffClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorHandOffClient) Send(m *HandOffMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *connectorHandOffClient) Recv() (*HandOffMessage, error) {
+	m := new(HandOffMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) AddPortForwards(ctx context.Context, in *PortForwardRequest, opts ...grpc.CallOption) (*PortForwardList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PortForwardList)
+	err := c.cc.Invoke(ctx, Connector_AddPortForwards_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) RemovePortForwards(ctx context.Context, in *PortForwardRequest, opts ...grpc.CallOption) (*PortForwardList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PortForwardList)
+	err := c.cc.Invoke(ctx, Connector_RemovePortForwards_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) ListPortForwards(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PortForwardList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PortForwardList)
+	err := c.cc.Invoke(ctx, Connector_ListPortForwards_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConnectorServer is the server API for Connector service.
+// All implementations must embed UnimplementedConnectorServer
+// for forward compatibility
+//
+// The Connector service is responsible for connecting to the traffic manager
+// and manage intercepts. It can only run when a Daemon is running.
+type ConnectorServer interface {
+	// Returns version information from the Connector
+	Version(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
+	// Returns version information from the Root Daemon
+	RootDaemonVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
+	// Returns version information from the Traffic Manager
+	TrafficManagerVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error)
+	// Returns the optional features that the Traffic Manager supports. Features
+	// are derived from the version of Traffic Managers that predate the
+	// GetCapabilities call.
+	TrafficManagerCapabilities(context.Context, *emptypb.Empty) (*manager.Capabilities, error)
+	// Returns the fully qualified image name of the traffic-agent that the agent-injector is configured to inject.
+	AgentImageFQN(context.Context, *emptypb.Empty) (*manager.AgentImageFQN, error)
+	// GetIntercept gets info from intercept name
+	GetIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error)
+	// Connects to the cluster and connects the laptop's network (via
+	// the daemon process) to the cluster's network.  A result code of
+	// UNSPECIFIED indicates that the connection was successfully
+	// initiated; if already connected, then either ALREADY_CONNECTED or
+	// MUST_RESTART is returned, based on whether the current connection
+	// is in agreement with the ConnectionRequest.
+	Connect(context.Context, *ConnectRequest) (*ConnectInfo, error)
+	// Disconnects the cluster. The result is wire compatible with
+	// google.protobuf.Empty.
+	Disconnect(context.Context, *emptypb.Empty) (*DisconnectResult, error)
+	// GetClusterSubnets gets the outbound info that has been set on daemon
+	GetClusterSubnets(context.Context, *emptypb.Empty) (*ClusterSubnets, error)
+	// Status returns the status of the current connection or DISCONNECTED
+	// if no connection has been established.
+	Status(context.Context, *emptypb.Empty) (*ConnectInfo, error)
+	// Queries the connector whether it is possible to create the given intercept.
+	CanIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error)
+	// Adds an intercept to a workload.  Requires having already called
+	// Connect.
+	CreateIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error)
+	// Deactivates and removes an existent workload intercept.
+	// Requires having already called Connect.
+	RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error)
+	UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error)
+	// Uninstalls traffic-agents from the cluster.
+	// Requires having already called Connect.
+	Uninstall(context.Context, *UninstallRequest) (*common.Result, error)
+	// Returns a list of workloads and their current intercept status.
+	// Requires having already called Connect.
+	List(context.Context, *ListRequest) (*WorkloadInfoSnapshot, error)
+	// Watch all workloads in the mapped namespaces
+	WatchWorkloads(*WatchWorkloadsRequest, Connector_WatchWorkloadsServer) error
+	// SetLogLevel will temporarily change the log-level of the traffic-manager, traffic-agent, and user and root daemons.
+	SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error)
+	// Quits (terminates) the connector process.
+	Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// GatherLogs will acquire logs for the various Telepresence components in kubernetes
+	// (pending the request) and return them to the caller
+	GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error)
+	// GatherTraces will acquire traces for the various Telepresence components in kubernetes
+	// (pending the request) and save them in a file.
+	GatherTraces(context.Context, *TracesRequest) (*common.Result, error)
+	// AddInterceptor tells the connector that a given process is serving a specific
+	// intercept. The connector must kill this process when the intercept ends
+	AddInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error)
+	// RemoveInterceptor removes a previously added interceptor
+	RemoveInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error)
+	// IsInterceptorAttached tells whether a previously added interceptor is
+	// still attached to its intercept, i.e. whether the intercept exists and
+	// the connector hasn't stopped the interceptor. An intercept handler that
+	// exits is only restarted while it's attached.
+	IsInterceptorAttached(context.Context, *Interceptor) (*InterceptorAttachedResult, error)
+	// GetNamespaces gets the mapped namespaces with an optional prefix
+	GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error)
+	// GetKnownWorkloadKinds returns the known workload kinds
+	GetKnownWorkloadKinds(context.Context, *emptypb.Empty) (*manager.KnownWorkloadKinds, error)
+	// RemoteMountAvailability checks if remote mounts are possible using the given
+	// mount type and returns an error if its not.
+	RemoteMountAvailability(context.Context, *emptypb.Empty) (*common.Result, error)
+	// GetConfig returns the current configuration
+	GetConfig(context.Context, *emptypb.Empty) (*ClientConfig, error)
+	// SetDNSExcludes sets the excludes field of DNSConfig.
+	SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error)
+	// SetDNSMappings sets the Mappings field of DNSConfig.
+	SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error)
+	// FetchFiles streams a tar archive containing the requested files and
+	// directories from the remote volumes of an intercept.
+	FetchFiles(*FetchFilesRequest, Connector_FetchFilesServer) error
+	// PutFiles uploads a tar archive with local files and directories to a
+	// directory in the remote volumes of an intercept.
+	PutFiles(Connector_PutFilesServer) error
+	// VerifyHeaderPropagation verifies that the header of an intercept is propagated
+	// through a chain of workloads that lead up to the intercepted workload.
+	VerifyHeaderPropagation(context.Context, *manager.HeaderPropagationRequest) (*manager.HeaderPropagationResult, error)
+	// DescribeWorkload describes a workload the way that the traffic-manager sees it
+	// when it prepares an intercept. The namespace of the request defaults to the
+	// namespace of the session.
+	DescribeWorkload(context.Context, *manager.DescribeWorkloadRequest) (*manager.WorkloadDescription, error)
+	// ExposeRegistry exposes a container image registry on the workstation to the
+	// cluster through the traffic-manager's registry proxy. The address of the
+	// request is resolved by the user daemon. An empty address ends the exposure.
+	ExposeRegistry(context.Context, *manager.RegistryProxyRequest) (*manager.RegistryProxyInfo, error)
+	// PublishService creates a Service in the cluster whose connections are
+	// forwarded to ports on the workstation. The Service is deleted when it's
+	// unpublished, or when the session ends.
+	PublishService(context.Context, *manager.PublishServiceRequest) (*manager.PublishedServiceInfo, error)
+	// UnpublishService deletes a Service that was created by PublishService.
+	UnpublishService(context.Context, *manager.PublishServiceRequest) (*emptypb.Empty, error)
+	// ShareIntercept returns a token that a teammate can use to join the
+	// named intercept.
+	ShareIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptShareToken, error)
+	// JoinIntercept joins the intercept that the token was issued for, so
+	// that this workstation receives some of its connections.
+	JoinIntercept(context.Context, *manager.JoinInterceptRequest) (*manager.InterceptInfo, error)
+	// LeaveIntercept leaves an intercept that was joined using JoinIntercept.
+	LeaveIntercept(context.Context, *manager.JoinInterceptRequest) (*emptypb.Empty, error)
+	// Evict forcibly removes sessions and intercepts using the admin API of
+	// the traffic-manager. The bearer token of the request is set from the
+	// kubeconfig of the session.
+	Evict(context.Context, *manager.EvictRequest) (*manager.ReapResult, error)
+	// Wiretap streams the traffic of the connections to the handler of the named intercept until the
+	// call is cancelled. The connections may be established by the user daemon or by the root daemon.
+	Wiretap(*WiretapRequest, Connector_WiretapServer) error
+	// Capture streams the HTTP/1.x requests that the traffic-agents of a workload receive until the
+	// call is cancelled. A traffic-agent is injected into the workload if it doesn't have one.
+	Capture(*CaptureRequest, Connector_CaptureServer) error
+	// Mount mounts the volumes of a workload's pod without intercepting it. One MountInfo is
+	// sent when the volumes are mounted, and they remain mounted until the call is cancelled.
+	// A traffic-agent is injected into the workload if it doesn't have one.
+	Mount(*MountRequest, Connector_MountServer) error
+	// RefreshInterceptEnvironment clears the environment drift of an intercept and
+	// returns its current info, so that the client can rewrite its environment.
+	RefreshInterceptEnvironment(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error)
+	// WatchEvents streams events that describe changes of the session, the dispositions and mounts of
+	// its intercepts, and their handlers, as they happen, until the call is cancelled. It's intended
+	// for IDE integrations that would otherwise poll List and Status.
+	WatchEvents(*emptypb.Empty, Connector_WatchEventsServer) error
+	// HandOff carries one TCP connection that the user daemon accepts on a
+	// localhost port of its own host to a client on another host, which connects
+	// it to the same port of its own host. The first message from the client
+	// names the port, and the daemon responds with a message without data when
+	// it has accepted a connection.
+	HandOff(Connector_HandOffServer) error
+	// AddPortForwards forwards localhost ports to ports of a service or pod in
+	// the cluster, through the traffic-manager's tunnel. The forwards remain
+	// until they are removed or the session ends.
+	AddPortForwards(context.Context, *PortForwardRequest) (*PortForwardList, error)
+	// RemovePortForwards removes the forwards to the target of the request. All
+	// forwards to the target are removed unless ports are given, in which case
+	// only the forwards from their local ports are removed.
+	RemovePortForwards(context.Context, *PortForwardRequest) (*PortForwardList, error)
+	// ListPortForwards lists the port forwards of the session.
+	ListPortForwards(context.Context, *emptypb.Empty) (*PortForwardList, error)
+	mustEmbedUnimplementedConnectorServer()
+}
+
+// UnimplementedConnectorServer must be embedded to have forward compatible implementations.
+type UnimplementedConnectorServer struct {
+}
+
+func (UnimplementedConnectorServer) Version(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedConnectorServer) RootDaemonVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RootDaemonVersion not implemented")
+}
+func (UnimplementedConnectorServer) TrafficManagerVersion(context.Context, *emptypb.Empty) (*common.VersionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TrafficManagerVersion not implemented")
+}
+func (UnimplementedConnectorServer) TrafficManagerCapabilities(context.Context, *emptypb.Empty) (*manager.Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TrafficManagerCapabilities not implemented")
+}
+func (UnimplementedConnectorServer) AgentImageFQN(context.Context, *emptypb.Empty) (*manager.AgentImageFQN, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AgentImageFQN not implemented")
+}
+func (UnimplementedConnectorServer) GetIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIntercept not implemented")
+}
+func (UnimplementedConnectorServer) Connect(context.Context, *ConnectRequest) (*ConnectInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedConnectorServer) Disconnect(context.Context, *emptypb.Empty) (*DisconnectResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Disconnect not implemented")
+}
+func (UnimplementedConnectorServer) GetClusterSubnets(context.Context, *emptypb.Empty) (*ClusterSubnets, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterSubnets not implemented")
+}
+func (UnimplementedConnectorServer) Status(context.Context, *emptypb.Empty) (*ConnectInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedConnectorServer) CanIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CanIntercept not implemented")
+}
+func (UnimplementedConnectorServer) CreateIntercept(context.Context, *CreateInterceptRequest) (*InterceptResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateIntercept not implemented")
+}
+func (UnimplementedConnectorServer) RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveIntercept not implemented")
+}
+func (UnimplementedConnectorServer) UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateIntercept not implemented")
+}
+func (UnimplementedConnectorServer) Uninstall(context.Context, *UninstallRequest) (*common.Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Uninstall not implemented")
+}
+func (UnimplementedConnectorServer) List(context.Context, *ListRequest) (*WorkloadInfoSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedConnectorServer) WatchWorkloads(*WatchWorkloadsRequest, Connector_WatchWorkloadsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchWorkloads not implemented")
+}
+func (UnimplementedConnectorServer) SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (UnimplementedConnectorServer) Quit(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Quit not implemented")
+}
+func (UnimplementedConnectorServer) GatherLogs(context.Context, *LogsRequest) (*LogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GatherLogs not implemented")
+}
+func (UnimplementedConnectorServer) GatherTraces(context.Context, *TracesRequest) (*common.Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GatherTraces not implemented")
+}
+func (UnimplementedConnectorServer) AddInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddInterceptor not implemented")
+}
+func (UnimplementedConnectorServer) RemoveInterceptor(context.Context, *Interceptor) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveInterceptor not implemented")
+}
+func (UnimplementedConnectorServer) IsInterceptorAttached(context.Context, *Interceptor) (*InterceptorAttachedResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsInterceptorAttached not implemented")
+}
+func (UnimplementedConnectorServer) GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNamespaces not implemented")
+}
+func (UnimplementedConnectorServer) GetKnownWorkloadKinds(context.Context, *emptypb.Empty) (*manager.KnownWorkloadKinds, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKnownWorkloadKinds not implemented")
+}
+func (UnimplementedConnectorServer) RemoteMountAvailability(context.Context, *emptypb.Empty) (*common.Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoteMountAvailability not implemented")
+}
+func (UnimplementedConnectorServer) GetConfig(context.Context, *emptypb.Empty) (*ClientConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedConnectorServer) SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDNSExcludes not implemented")
+}
+func (UnimplementedConnectorServer) SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
+}
+func (UnimplementedConnectorServer) FetchFiles(*FetchFilesRequest, Connector_FetchFilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchFiles not implemented")
+}
+func (UnimplementedConnectorServer) PutFiles(Connector_PutFilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutFiles not implemented")
+}
+func (UnimplementedConnectorServer) VerifyHeaderPropagation(context.Context, *manager.HeaderPropagationRequest) (*manager.HeaderPropagationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyHeaderPropagation not implemented")
+}
+func (UnimplementedConnectorServer) DescribeWorkload(context.Context, *manager.DescribeWorkloadRequest) (*manager.WorkloadDescription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeWorkload not implemented")
+}
+func (UnimplementedConnectorServer) ExposeRegistry(context.Context, *manager.RegistryProxyRequest) (*manager.RegistryProxyInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExposeRegistry not implemented")
+}
+func (UnimplementedConnectorServer) PublishService(context.Context, *manager.PublishServiceRequest) (*manager.PublishedServiceInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishService not implemented")
+}
+func (UnimplementedConnectorServer) UnpublishService(context.Context, *manager.PublishServiceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpublishService not implemented")
+}
+func (UnimplementedConnectorServer) ShareIntercept(context.Context, *manager.GetInterceptRequest) (*manager.InterceptShareToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareIntercept not implemented")
+}
+func (UnimplementedConnectorServer) JoinIntercept(context.Context, *manager.JoinInterceptRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinIntercept not implemented")
+}
+func (UnimplementedConnectorServer) LeaveIntercept(context.Context, *manager.JoinInterceptRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveIntercept not implemented")
+}
+func (UnimplementedConnectorServer) Evict(context.Context, *manager.EvictRequest) (*manager.ReapResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Evict not implemented")
+}
+func (UnimplementedConnectorServer) Wiretap(*WiretapRequest, Connector_WiretapServer) error {
+	return status.Errorf(codes.Unimplemented, "method Wiretap not implemented")
+}
+func (UnimplementedConnectorServer) Capture(*CaptureRequest, Connector_CaptureServer) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
+func (UnimplementedConnectorServer) Mount(*MountRequest, Connector_MountServer) error {
+	return status.Errorf(codes.Unimplemented, "method Mount not implemented")
+}
+func (UnimplementedConnectorServer) RefreshInterceptEnvironment(context.Context, *manager.GetInterceptRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshInterceptEnvironment not implemented")
+}
+func (UnimplementedConnectorServer) WatchEvents(*emptypb.Empty, Connector_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedConnectorServer) HandOff(Connector_HandOffServer) error {
+	return status.Errorf(codes.Unimplemented, "method HandOff not implemented")
+}
+func (UnimplementedConnectorServer) AddPortForwards(context.Context, *PortForwardRequest) (*PortForwardList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPortForwards not implemented")
+}
+func (UnimplementedConnectorServer) RemovePortForwards(context.Context, *PortForwardRequest) (*PortForwardList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePortForwards not implemented")
+}
+func (UnimplementedConnectorServer) ListPortForwards(context.Context, *emptypb.Empty) (*PortForwardList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPortForwards not implemented")
+}
+func (UnimplementedConnectorServer) mustEmbedUnimplementedConnectorServer() {}
+
+// UnsafeConnectorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConnectorServer will
+// result in compilation errors.
+type UnsafeConnectorServer interface {
+	mustEmbedUnimplementedConnectorServer()
+}
+
+func RegisterConnectorServer(s grpc.ServiceRegistrar, srv ConnectorServer) {
+	s.RegisterService(&Connector_ServiceDesc, srv)
+}
+
+func _Connector_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).Version(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_RootDaemonVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).RootDaemonVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_RootDaemonVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).RootDaemonVersion(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_TrafficManagerVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).TrafficManagerVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_TrafficManagerVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).TrafficManagerVersion(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_TrafficManagerCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).TrafficManagerVersion(ctx, in)
+		return srv.(ConnectorServer).TrafficManagerCapabilities(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_TrafficManagerVersion_FullMethodName,
+		FullMethod: Connector_TrafficManagerCapabilities_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).TrafficManagerVersion(ctx, req.(*emptypb.Empty))
+		return srv.(ConnectorServer).TrafficManagerCapabilities(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -838,293 +1483,702 @@ func _Connector_RemoveIntercept_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_UpdateIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(manager.UpdateInterceptRequest)
+func _Connector_UpdateIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.UpdateInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).UpdateIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_UpdateIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).UpdateIntercept(ctx, req.(*manager.UpdateInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_Uninstall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UninstallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).Uninstall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_Uninstall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).Uninstall(ctx, req.(*UninstallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_WatchWorkloads_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchWorkloadsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).WatchWorkloads(m, &connectorWatchWorkloadsServer{ServerStream: stream})
+}
+
+type Connector_WatchWorkloadsServer interface {
+	Send(*WorkloadInfoSnapshot) error
+	grpc.ServerStream
+}
+
+type connectorWatchWorkloadsServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorWatchWorkloadsServer) Send(m *WorkloadInfoSnapshot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_SetLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).SetLogLevel(ctx, req.(*LogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_Quit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).Quit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_Quit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).Quit(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GatherLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GatherLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GatherLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GatherLogs(ctx, req.(*LogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GatherTraces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TracesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GatherTraces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GatherTraces_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GatherTraces(ctx, req.(*TracesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_AddInterceptor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Interceptor)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).AddInterceptor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_AddInterceptor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).AddInterceptor(ctx, req.(*Interceptor))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_RemoveInterceptor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Interceptor)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).RemoveInterceptor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_RemoveInterceptor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).RemoveInterceptor(ctx, req.(*Interceptor))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_IsInterceptorAttached_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Interceptor)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).IsInterceptorAttached(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_IsInterceptorAttached_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).IsInterceptorAttached(ctx, req.(*Interceptor))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNamespacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetNamespaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetNamespaces_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetNamespaces(ctx, req.(*GetNamespacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetKnownWorkloadKinds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetKnownWorkloadKinds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetKnownWorkloadKinds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetKnownWorkloadKinds(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_RemoteMountAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).RemoteMountAvailability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_RemoteMountAvailability_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).RemoteMountAvailability(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).UpdateIntercept(ctx, in)
+		return srv.(ConnectorServer).GetConfig(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_UpdateIntercept_FullMethodName,
+		FullMethod: Connector_GetConfig_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).UpdateIntercept(ctx, req.(*manager.UpdateInterceptRequest))
+		return srv.(ConnectorServer).GetConfig(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_Uninstall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UninstallRequest)
+func _Connector_SetDNSExcludes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.SetDNSExcludesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).Uninstall(ctx, in)
+		return srv.(ConnectorServer).SetDNSExcludes(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_Uninstall_FullMethodName,
+		FullMethod: Connector_SetDNSExcludes_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).Uninstall(ctx, req.(*UninstallRequest))
+		return srv.(ConnectorServer).SetDNSExcludes(ctx, req.(*daemon.SetDNSExcludesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListRequest)
+func _Connector_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.SetDNSMappingsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).List(ctx, in)
+		return srv.(ConnectorServer).SetDNSMappings(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_List_FullMethodName,
+		FullMethod: Connector_SetDNSMappings_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).List(ctx, req.(*ListRequest))
+		return srv.(ConnectorServer).SetDNSMappings(ctx, req.(*daemon.SetDNSMappingsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_WatchWorkloads_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(WatchWorkloadsRequest)
+func _Connector_FetchFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchFilesRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(ConnectorServer).WatchWorkloads(m, &connectorWatchWorkloadsServer{ServerStream: stream})
+	return srv.(ConnectorServer).FetchFiles(m, &connectorFetchFilesServer{ServerStream: stream})
 }
 
-type Connector_WatchWorkloadsServer interface {
-	Send(*WorkloadInfoSnapshot) error
+type Connector_FetchFilesServer interface {
+	Send(*agent.FileChunk) error
 	grpc.ServerStream
 }
 
-type connectorWatchWorkloadsServer struct {
+type connectorFetchFilesServer struct {
 	grpc.ServerStream
 }
 
-func (x *connectorWatchWorkloadsServer) Send(m *WorkloadInfoSnapshot) error {
+func (x *connectorFetchFilesServer) Send(m *agent.FileChunk) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func _Connector_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LogLevelRequest)
+func _Connector_PutFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConnectorServer).PutFiles(&connectorPutFilesServer{ServerStream: stream})
+}
+
+type Connector_PutFilesServer interface {
+	SendAndClose(*agent.PutFilesResult) error
+	Recv() (*PutFilesRequest, error)
+	grpc.ServerStream
+}
+
+type connectorPutFilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorPutFilesServer) SendAndClose(m *agent.PutFilesResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *connectorPutFilesServer) Recv() (*PutFilesRequest, error) {
+	m := new(PutFilesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Connector_VerifyHeaderPropagation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.HeaderPropagationRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).SetLogLevel(ctx, in)
+		return srv.(ConnectorServer).VerifyHeaderPropagation(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_SetLogLevel_FullMethodName,
+		FullMethod: Connector_VerifyHeaderPropagation_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).SetLogLevel(ctx, req.(*LogLevelRequest))
+		return srv.(ConnectorServer).VerifyHeaderPropagation(ctx, req.(*manager.HeaderPropagationRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_Quit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+func _Connector_DescribeWorkload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.DescribeWorkloadRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).Quit(ctx, in)
+		return srv.(ConnectorServer).DescribeWorkload(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_Quit_FullMethodName,
+		FullMethod: Connector_DescribeWorkload_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).Quit(ctx, req.(*emptypb.Empty))
+		return srv.(ConnectorServer).DescribeWorkload(ctx, req.(*manager.DescribeWorkloadRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_GatherLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LogsRequest)
+func _Connector_ExposeRegistry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.RegistryProxyRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).GatherLogs(ctx, in)
+		return srv.(ConnectorServer).ExposeRegistry(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_GatherLogs_FullMethodName,
+		FullMethod: Connector_ExposeRegistry_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).GatherLogs(ctx, req.(*LogsRequest))
+		return srv.(ConnectorServer).ExposeRegistry(ctx, req.(*manager.RegistryProxyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_GatherTraces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(TracesRequest)
+func _Connector_PublishService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.PublishServiceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).GatherTraces(ctx, in)
+		return srv.(ConnectorServer).PublishService(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_GatherTraces_FullMethodName,
+		FullMethod: Connector_PublishService_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).GatherTraces(ctx, req.(*TracesRequest))
+		return srv.(ConnectorServer).PublishService(ctx, req.(*manager.PublishServiceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_AddInterceptor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Interceptor)
+func _Connector_UnpublishService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.PublishServiceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).AddInterceptor(ctx, in)
+		return srv.(ConnectorServer).UnpublishService(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_AddInterceptor_FullMethodName,
+		FullMethod: Connector_UnpublishService_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).AddInterceptor(ctx, req.(*Interceptor))
+		return srv.(ConnectorServer).UnpublishService(ctx, req.(*manager.PublishServiceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_RemoveInterceptor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Interceptor)
+func _Connector_ShareIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.GetInterceptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).RemoveInterceptor(ctx, in)
+		return srv.(ConnectorServer).ShareIntercept(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_RemoveInterceptor_FullMethodName,
+		FullMethod: Connector_ShareIntercept_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).RemoveInterceptor(ctx, req.(*Interceptor))
+		return srv.(ConnectorServer).ShareIntercept(ctx, req.(*manager.GetInterceptRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_GetNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetNamespacesRequest)
+func _Connector_JoinIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.JoinInterceptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).GetNamespaces(ctx, in)
+		return srv.(ConnectorServer).JoinIntercept(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_GetNamespaces_FullMethodName,
+		FullMethod: Connector_JoinIntercept_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).GetNamespaces(ctx, req.(*GetNamespacesRequest))
+		return srv.(ConnectorServer).JoinIntercept(ctx, req.(*manager.JoinInterceptRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_GetKnownWorkloadKinds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+func _Connector_LeaveIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.JoinInterceptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).GetKnownWorkloadKinds(ctx, in)
+		return srv.(ConnectorServer).LeaveIntercept(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_GetKnownWorkloadKinds_FullMethodName,
+		FullMethod: Connector_LeaveIntercept_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).GetKnownWorkloadKinds(ctx, req.(*emptypb.Empty))
+		return srv.(ConnectorServer).LeaveIntercept(ctx, req.(*manager.JoinInterceptRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_RemoteMountAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+func _Connector_Evict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.EvictRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).RemoteMountAvailability(ctx, in)
+		return srv.(ConnectorServer).Evict(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_RemoteMountAvailability_FullMethodName,
+		FullMethod: Connector_Evict_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).RemoteMountAvailability(ctx, req.(*emptypb.Empty))
+		return srv.(ConnectorServer).Evict(ctx, req.(*manager.EvictRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+func _Connector_Wiretap_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WiretapRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).Wiretap(m, &connectorWiretapServer{ServerStream: stream})
+}
+
+type Connector_WiretapServer interface {
+	Send(*daemon.WiretapEvent) error
+	grpc.ServerStream
+}
+
+type connectorWiretapServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorWiretapServer) Send(m *daemon.WiretapEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).Capture(m, &connectorCaptureServer{ServerStream: stream})
+}
+
+type Connector_CaptureServer interface {
+	Send(*agent.CapturedRequest) error
+	grpc.ServerStream
+}
+
+type connectorCaptureServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorCaptureServer) Send(m *agent.CapturedRequest) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_Mount_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MountRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).Mount(m, &connectorMountServer{ServerStream: stream})
+}
+
+type Connector_MountServer interface {
+	Send(*MountInfo) error
+	grpc.ServerStream
+}
+
+type connectorMountServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorMountServer) Send(m *MountInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_RefreshInterceptEnvironment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.GetInterceptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).GetConfig(ctx, in)
+		return srv.(ConnectorServer).RefreshInterceptEnvironment(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_GetConfig_FullMethodName,
+		FullMethod: Connector_RefreshInterceptEnvironment_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).GetConfig(ctx, req.(*emptypb.Empty))
+		return srv.(ConnectorServer).RefreshInterceptEnvironment(ctx, req.(*manager.GetInterceptRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_SetDNSExcludes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(daemon.SetDNSExcludesRequest)
+func _Connector_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).WatchEvents(m, &connectorWatchEventsServer{ServerStream: stream})
+}
+
+type Connector_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type connectorWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_HandOff_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConnectorServer).HandOff(&connectorHandOffServer{ServerStream: stream})
+}
+
+type Connector_HandOffServer interface {
+	Send(*HandOffMessage) error
+	Recv() (*HandOffMessage, error)
+	grpc.ServerStream
+}
+
+type connectorHandOffServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorHandOffServer) Send(m *HandOffMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *connectorHandOffServer) Recv() (*HandOffMessage, error) {
+	m := new(HandOffMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Connector_AddPortForwards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortForwardRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).SetDNSExcludes(ctx, in)
+		return srv.(ConnectorServer).AddPortForwards(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_SetDNSExcludes_FullMethodName,
+		FullMethod: Connector_AddPortForwards_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).SetDNSExcludes(ctx, req.(*daemon.SetDNSExcludesRequest))
+		return srv.(ConnectorServer).AddPortForwards(ctx, req.(*PortForwardRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Connector_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(daemon.SetDNSMappingsRequest)
+func _Connector_RemovePortForwards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortForwardRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ConnectorServer).SetDNSMappings(ctx, in)
+		return srv.(ConnectorServer).RemovePortForwards(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Connector_SetDNSMappings_FullMethodName,
+		FullMethod: Connector_RemovePortForwards_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConnectorServer).SetDNSMappings(ctx, req.(*daemon.SetDNSMappingsRequest))
+		return srv.(ConnectorServer).RemovePortForwards(ctx, req.(*PortForwardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_ListPortForwards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).ListPortForwards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_ListPortForwards_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).ListPortForwards(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1148,6 +2202,10 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TrafficManagerVersion",
 			Handler:    _Connector_TrafficManagerVersion_Handler,
 		},
+		{
+			MethodName: "TrafficManagerCapabilities",
+			Handler:    _Connector_TrafficManagerCapabilities_Handler,
+		},
 		{
 			MethodName: "AgentImageFQN",
 			Handler:    _Connector_AgentImageFQN_Handler,
@@ -1220,6 +2278,10 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RemoveInterceptor",
 			Handler:    _Connector_RemoveInterceptor_Handler,
 		},
+		{
+			MethodName: "IsInterceptorAttached",
+			Handler:    _Connector_IsInterceptorAttached_Handler,
+		},
 		{
 			MethodName: "GetNamespaces",
 			Handler:    _Connector_GetNamespaces_Handler,
@@ -1244,6 +2306,58 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDNSMappings",
 			Handler:    _Connector_SetDNSMappings_Handler,
 		},
+		{
+			MethodName: "VerifyHeaderPropagation",
+			Handler:    _Connector_VerifyHeaderPropagation_Handler,
+		},
+		{
+			MethodName: "DescribeWorkload",
+			Handler:    _Connector_DescribeWorkload_Handler,
+		},
+		{
+			MethodName: "ExposeRegistry",
+			Handler:    _Connector_ExposeRegistry_Handler,
+		},
+		{
+			MethodName: "PublishService",
+			Handler:    _Connector_PublishService_Handler,
+		},
+		{
+			MethodName: "UnpublishService",
+			Handler:    _Connector_UnpublishService_Handler,
+		},
+		{
+			MethodName: "ShareIntercept",
+			Handler:    _Connector_ShareIntercept_Handler,
+		},
+		{
+			MethodName: "JoinIntercept",
+			Handler:    _Connector_JoinIntercept_Handler,
+		},
+		{
+			MethodName: "LeaveIntercept",
+			Handler:    _Connector_LeaveIntercept_Handler,
+		},
+		{
+			MethodName: "Evict",
+			Handler:    _Connector_Evict_Handler,
+		},
+		{
+			MethodName: "RefreshInterceptEnvironment",
+			Handler:    _Connector_RefreshInterceptEnvironment_Handler,
+		},
+		{
+			MethodName: "AddPortForwards",
+			Handler:    _Connector_AddPortForwards_Handler,
+		},
+		{
+			MethodName: "RemovePortForwards",
+			Handler:    _Connector_RemovePortForwards_Handler,
+		},
+		{
+			MethodName: "ListPortForwards",
+			Handler:    _Connector_ListPortForwards_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1251,6 +2365,42 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Connector_WatchWorkloads_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "FetchFiles",
+			Handler:       _Connector_FetchFiles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PutFiles",
+			Handler:       _Connector_PutFiles_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Wiretap",
+			Handler:       _Connector_Wiretap_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Capture",
+			Handler:       _Connector_Capture_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Mount",
+			Handler:       _Connector_Mount_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Connector_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "HandOff",
+			Handler:       _Connector_HandOff_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "connector/connector.proto",
 }
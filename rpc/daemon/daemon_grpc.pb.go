@@ -34,6 +34,7 @@ const (
 	Daemon_SetLogLevel_FullMethodName           = "/telepresence.daemon.Daemon/SetLogLevel"
 	Daemon_WaitForNetwork_FullMethodName        = "/telepresence.daemon.Daemon/WaitForNetwork"
 	Daemon_WaitForAgentIP_FullMethodName        = "/telepresence.daemon.Daemon/WaitForAgentIP"
+	Daemon_Wiretap_FullMethodName               = "/telepresence.daemon.Daemon/Wiretap"
 )
 
 // DaemonClient is the client API for Daemon service.
@@ -67,6 +68,9 @@ type DaemonClient interface {
 	WaitForNetwork(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(ctx context.Context, in *WaitForAgentIPRequest, opts ...grpc.CallOption) (*WaitForAgentIPResponse, error)
+	// Wiretap streams the traffic of the connections that the daemon establishes to an intercept handler
+	// until the call is cancelled.
+	Wiretap(ctx context.Context, in *WiretapRequest, opts ...grpc.CallOption) (Daemon_WiretapClient, error)
 }
 
 type daemonClient struct {
@@ -197,6 +201,39 @@ func (c *daemonClient) WaitForAgentIP(ctx context.Context, in *WaitForAgentIPReq
 	return out, nil
 }
 
+func (c *daemonClient) Wiretap(ctx context.Context, in *WiretapRequest, opts ...grpc.CallOption) (Daemon_WiretapClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[0], Daemon_Wiretap_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonWiretapClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_WiretapClient interface {
+	Recv() (*WiretapEvent, error)
+	grpc.ClientStream
+}
+
+type daemonWiretapClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonWiretapClient) Recv() (*WiretapEvent, error) {
+	m := new(WiretapEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -228,6 +265,9 @@ type DaemonServer interface {
 	WaitForNetwork(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*WaitForAgentIPResponse, error)
+	// Wiretap streams the traffic of the connections that the daemon establishes to an intercept handler
+	// until the call is cancelled.
+	Wiretap(*WiretapRequest, Daemon_WiretapServer) error
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -271,6 +311,9 @@ func (UnimplementedDaemonServer) WaitForNetwork(context.Context, *emptypb.Empty)
 func (UnimplementedDaemonServer) WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*WaitForAgentIPResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WaitForAgentIP not implemented")
 }
+func (UnimplementedDaemonServer) Wiretap(*WiretapRequest, Daemon_WiretapServer) error {
+	return status.Errorf(codes.Unimplemented, "method Wiretap not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -500,6 +543,27 @@ func _Daemon_WaitForAgentIP_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_Wiretap_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WiretapRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).Wiretap(m, &daemonWiretapServer{ServerStream: stream})
+}
+
+type Daemon_WiretapServer interface {
+	Send(*WiretapEvent) error
+	grpc.ServerStream
+}
+
+type daemonWiretapServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonWiretapServer) Send(m *WiretapEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -556,6 +620,12 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Daemon_WaitForAgentIP_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Wiretap",
+			Handler:       _Daemon_Wiretap_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "daemon/daemon.proto",
 }
@@ -30,11 +30,13 @@ const (
 	Manager_CanConnectAmbassadorCloud_FullMethodName = "/telepresence.manager.Manager/CanConnectAmbassadorCloud"
 	Manager_GetCloudConfig_FullMethodName            = "/telepresence.manager.Manager/GetCloudConfig"
 	Manager_GetClientConfig_FullMethodName           = "/telepresence.manager.Manager/GetClientConfig"
+	Manager_GetCapabilities_FullMethodName           = "/telepresence.manager.Manager/GetCapabilities"
 	Manager_GetTelepresenceAPI_FullMethodName        = "/telepresence.manager.Manager/GetTelepresenceAPI"
 	Manager_ArriveAsClient_FullMethodName            = "/telepresence.manager.Manager/ArriveAsClient"
 	Manager_ArriveAsAgent_FullMethodName             = "/telepresence.manager.Manager/ArriveAsAgent"
 	Manager_Remain_FullMethodName                    = "/telepresence.manager.Manager/Remain"
 	Manager_Depart_FullMethodName                    = "/telepresence.manager.Manager/Depart"
+	Manager_ReapStaleSessions_FullMethodName         = "/telepresence.manager.Manager/ReapStaleSessions"
 	Manager_SetLogLevel_FullMethodName               = "/telepresence.manager.Manager/SetLogLevel"
 	Manager_GetLogs_FullMethodName                   = "/telepresence.manager.Manager/GetLogs"
 	Manager_WatchAgentPods_FullMethodName            = "/telepresence.manager.Manager/WatchAgentPods"
@@ -51,13 +53,27 @@ const (
 	Manager_GetIntercept_FullMethodName              = "/telepresence.manager.Manager/GetIntercept"
 	Manager_ReviewIntercept_FullMethodName           = "/telepresence.manager.Manager/ReviewIntercept"
 	Manager_GetKnownWorkloadKinds_FullMethodName     = "/telepresence.manager.Manager/GetKnownWorkloadKinds"
+	Manager_WatchAgentEvents_FullMethodName          = "/telepresence.manager.Manager/WatchAgentEvents"
+	Manager_WatchAgentArrivalProgress_FullMethodName = "/telepresence.manager.Manager/WatchAgentArrivalProgress"
+	Manager_DescribeWorkload_FullMethodName          = "/telepresence.manager.Manager/DescribeWorkload"
 	Manager_LookupDNS_FullMethodName                 = "/telepresence.manager.Manager/LookupDNS"
 	Manager_AgentLookupDNSResponse_FullMethodName    = "/telepresence.manager.Manager/AgentLookupDNSResponse"
 	Manager_WatchLookupDNS_FullMethodName            = "/telepresence.manager.Manager/WatchLookupDNS"
+	Manager_VerifyHeaderPropagation_FullMethodName   = "/telepresence.manager.Manager/VerifyHeaderPropagation"
+	Manager_WatchHeaderProbes_FullMethodName         = "/telepresence.manager.Manager/WatchHeaderProbes"
+	Manager_ReportHeaderProbe_FullMethodName         = "/telepresence.manager.Manager/ReportHeaderProbe"
 	Manager_WatchLogLevel_FullMethodName             = "/telepresence.manager.Manager/WatchLogLevel"
 	Manager_Tunnel_FullMethodName                    = "/telepresence.manager.Manager/Tunnel"
 	Manager_ReportMetrics_FullMethodName             = "/telepresence.manager.Manager/ReportMetrics"
 	Manager_WatchDial_FullMethodName                 = "/telepresence.manager.Manager/WatchDial"
+	Manager_ExposeRegistry_FullMethodName            = "/telepresence.manager.Manager/ExposeRegistry"
+	Manager_PublishService_FullMethodName            = "/telepresence.manager.Manager/PublishService"
+	Manager_UnpublishService_FullMethodName          = "/telepresence.manager.Manager/UnpublishService"
+	Manager_ShareIntercept_FullMethodName            = "/telepresence.manager.Manager/ShareIntercept"
+	Manager_JoinIntercept_FullMethodName             = "/telepresence.manager.Manager/JoinIntercept"
+	Manager_LeaveIntercept_FullMethodName            = "/telepresence.manager.Manager/LeaveIntercept"
+	Manager_Evict_FullMethodName                     = "/telepresence.manager.Manager/Evict"
+	Manager_AuthorizeCapture_FullMethodName          = "/telepresence.manager.Manager/AuthorizeCapture"
 )
 
 // ManagerClient is the client API for Manager service.
@@ -79,6 +95,8 @@ type ManagerClient interface {
 	GetCloudConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AmbassadorCloudConfig, error)
 	// GetClientConfig returns the config that connected clients should use for this manager.
 	GetClientConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CLIConfig, error)
+	// GetCapabilities returns the optional features that this traffic-manager supports.
+	GetCapabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Capabilities, error)
 	// GetTelepresenceAPI returns information about the TelepresenceAPI server
 	GetTelepresenceAPI(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TelepresenceAPIInfo, error)
 	// ArriveAsClient establishes a session between a client and the Manager.
@@ -90,6 +108,11 @@ type ManagerClient interface {
 	Remain(ctx context.Context, in *RemainRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Depart terminates a session.
 	Depart(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ReapStaleSessions removes the sessions of the calling client's installation
+	// and user that have stopped calling Remain, together with their intercepts.
+	// Clients call it when they create a new session, so that intercepts left
+	// behind by a client that crashed are removed right away.
+	ReapStaleSessions(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ReapResult, error)
 	// SetLogLevel will temporarily set the log-level for the traffic-manager and all
 	// traffic-agents for a duration that is determined b the request.
 	SetLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
@@ -145,6 +168,18 @@ type ManagerClient interface {
 	// that the manager can handle. This set may include Deployment, StatefulSet, ReplicaSet, Rollout (Argo Rollouts)
 	// as configured in the manager's Helm values.
 	GetKnownWorkloadKinds(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*KnownWorkloadKinds, error)
+	// WatchAgentEvents streams the Kubernetes events that the traffic-manager
+	// observes while it waits for traffic-agents to arrive when it prepares the
+	// intercepts of the given client session.
+	WatchAgentEvents(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchAgentEventsClient, error)
+	// WatchAgentArrivalProgress streams the stages that workloads reach while
+	// the traffic-manager waits for their traffic-agents to arrive when it
+	// prepares the intercepts of the given client session.
+	WatchAgentArrivalProgress(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchAgentArrivalProgressClient, error)
+	// DescribeWorkload returns the agent config, the interceptable ports, the current
+	// intercepts, and the conditions that block intercepts of a workload. The call
+	// never changes the agent config.
+	DescribeWorkload(ctx context.Context, in *DescribeWorkloadRequest, opts ...grpc.CallOption) (*WorkloadDescription, error)
 	// LookupDNS performs a DNS lookup in the cluster. If the caller has intercepts
 	// active, the lookup will be performed from the intercepted pods.
 	LookupDNS(ctx context.Context, in *DNSRequest, opts ...grpc.CallOption) (*DNSResponse, error)
@@ -152,6 +187,14 @@ type ManagerClient interface {
 	AgentLookupDNSResponse(ctx context.Context, in *DNSAgentResponse, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WatchLookupHost lets an agent receive lookup requests
 	WatchLookupDNS(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchLookupDNSClient, error)
+	// VerifyHeaderPropagation sends a probe request through a chain of workloads
+	// that lead up to an intercepted workload, and reports which workloads that
+	// received the intercept's header, and which workload that dropped it.
+	VerifyHeaderPropagation(ctx context.Context, in *HeaderPropagationRequest, opts ...grpc.CallOption) (*HeaderPropagationResult, error)
+	// WatchHeaderProbes lets an agent receive header probes
+	WatchHeaderProbes(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchHeaderProbesClient, error)
+	// ReportHeaderProbe lets an agent report that a header probe was armed or observed
+	ReportHeaderProbe(ctx context.Context, in *HeaderProbeReport, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WatchLogLevel lets an agent receive log-level updates
 	WatchLogLevel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Manager_WatchLogLevelClient, error)
 	// A Tunnel represents one single connection where the client or
@@ -173,6 +216,44 @@ type ManagerClient interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error)
+	// ExposeRegistry makes the traffic-manager's registry proxy forward the
+	// connections that it receives to a registry on the client's workstation,
+	// using DialRequests sent on the client's WatchDial stream. Only one client
+	// at a time can expose a registry, and the proxy only forwards pulls that
+	// use the credentials returned to that client.
+	ExposeRegistry(ctx context.Context, in *RegistryProxyRequest, opts ...grpc.CallOption) (*RegistryProxyInfo, error)
+	// PublishService creates a Service in the cluster whose connections are
+	// forwarded to ports on the client's workstation, using DialRequests sent
+	// on the client's WatchDial stream. The traffic-manager accepts the
+	// connections on behalf of the Service. The Service is deleted when it's
+	// unpublished, or when the client's session ends.
+	PublishService(ctx context.Context, in *PublishServiceRequest, opts ...grpc.CallOption) (*PublishedServiceInfo, error)
+	// UnpublishService deletes a Service that the client has published.
+	UnpublishService(ctx context.Context, in *PublishServiceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ShareIntercept returns a token that other clients can use to join one
+	// of the client's intercepts. The token is valid until the intercept is
+	// removed.
+	ShareIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptShareToken, error)
+	// JoinIntercept adds the client's session to the shared sessions of the
+	// intercept that the token was issued for, so that the client receives
+	// the requests that carry its route.
+	JoinIntercept(ctx context.Context, in *JoinInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
+	// LeaveIntercept removes the client's session from the shared sessions
+	// of the intercept that the token was issued for.
+	LeaveIntercept(ctx context.Context, in *JoinInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Evict removes the intercepts in the given namespace or of the given
+	// workload, or all sessions of the given user when no namespace or
+	// workload is given. It is only available when the admin API is enabled,
+	// and requires a bearer token that permits deletion of
+	// sessions.telepresence.io.
+	Evict(ctx context.Context, in *EvictRequest, opts ...grpc.CallOption) (*ReapResult, error)
+	// AuthorizeCapture is called by a traffic-agent before it lets a client
+	// capture the requests that it receives. It returns an error unless the
+	// client is allowed to intercept the agent's workload, i.e. unless the
+	// client has a session in the namespace of the workload. A capture that
+	// includes credentials also requires that the client session owns an
+	// intercept of the workload.
+	AuthorizeCapture(ctx context.Context, in *AuthorizeCaptureRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type managerClient struct {
@@ -243,6 +324,16 @@ func (c *managerClient) GetClientConfig(ctx context.Context, in *emptypb.Empty,
 	return out, nil
 }
 
+func (c *managerClient) GetCapabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Capabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, Manager_GetCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) GetTelepresenceAPI(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TelepresenceAPIInfo, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(TelepresenceAPIInfo)
@@ -293,6 +384,16 @@ func (c *managerClient) Depart(ctx context.Context, in *SessionInfo, opts ...grp
 	return out, nil
 }
 
+func (c *managerClient) ReapStaleSessions(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*ReapResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReapResult)
+	err := c.cc.Invoke(ctx, Manager_ReapStaleSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) SetLogLevel(ctx context.Context, in *LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -591,6 +692,82 @@ func (c *managerClient) GetKnownWorkloadKinds(ctx context.Context, in *SessionIn
 	return out, nil
 }
 
+func (c *managerClient) WatchAgentEvents(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchAgentEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchAgentEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchAgentEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchAgentEventsClient interface {
+	Recv() (*AgentEvent, error)
+	grpc.ClientStream
+}
+
+type managerWatchAgentEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchAgentEventsClient) Recv() (*AgentEvent, error) {
+	m := new(AgentEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) WatchAgentArrivalProgress(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchAgentArrivalProgressClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchAgentArrivalProgress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchAgentArrivalProgressClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchAgentArrivalProgressClient interface {
+	Recv() (*AgentArrivalProgress, error)
+	grpc.ClientStream
+}
+
+type managerWatchAgentArrivalProgressClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchAgentArrivalProgressClient) Recv() (*AgentArrivalProgress, error) {
+	m := new(AgentArrivalProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) DescribeWorkload(ctx context.Context, in *DescribeWorkloadRequest, opts ...grpc.CallOption) (*WorkloadDescription, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WorkloadDescription)
+	err := c.cc.Invoke(ctx, Manager_DescribeWorkload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) LookupDNS(ctx context.Context, in *DNSRequest, opts ...grpc.CallOption) (*DNSResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DNSResponse)
@@ -613,7 +790,7 @@ func (c *managerClient) AgentLookupDNSResponse(ctx context.Context, in *DNSAgent
 
 func (c *managerClient) WatchLookupDNS(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchLookupDNSClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchLookupDNS_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_WatchLookupDNS_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -644,9 +821,62 @@ func (x *managerWatchLookupDNSClient) Recv() (*DNSRequest, error) {
 	return m, nil
 }
 
+func (c *managerClient) VerifyHeaderPropagation(ctx context.Context, in *HeaderPropagationRequest, opts ...grpc.CallOption) (*HeaderPropagationResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HeaderPropagationResult)
+	err := c.cc.Invoke(ctx, Manager_VerifyHeaderPropagation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) WatchHeaderProbes(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchHeaderProbesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_WatchHeaderProbes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchHeaderProbesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchHeaderProbesClient interface {
+	Recv() (*HeaderProbe, error)
+	grpc.ClientStream
+}
+
+type managerWatchHeaderProbesClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchHeaderProbesClient) Recv() (*HeaderProbe, error) {
+	m := new(HeaderProbe)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) ReportHeaderProbe(ctx context.Context, in *HeaderProbeReport, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_ReportHeaderProbe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) WatchLogLevel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Manager_WatchLogLevelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchLogLevel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[10], Manager_WatchLogLevel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -679,7 +909,7 @@ func (x *managerWatchLogLevelClient) Recv() (*LogLevelRequest, error) {
 
 func (c *managerClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_TunnelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_Tunnel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[11], Manager_Tunnel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -721,7 +951,7 @@ func (c *managerClient) ReportMetrics(ctx context.Context, in *TunnelMetrics, op
 
 func (c *managerClient) WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_WatchDial_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[12], Manager_WatchDial_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -752,6 +982,86 @@ func (x *managerWatchDialClient) Recv() (*DialRequest, error) {
 	return m, nil
 }
 
+func (c *managerClient) ExposeRegistry(ctx context.Context, in *RegistryProxyRequest, opts ...grpc.CallOption) (*RegistryProxyInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegistryProxyInfo)
+	err := c.cc.Invoke(ctx, Manager_ExposeRegistry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) PublishService(ctx context.Context, in *PublishServiceRequest, opts ...grpc.CallOption) (*PublishedServiceInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishedServiceInfo)
+	err := c.cc.Invoke(ctx, Manager_PublishService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) UnpublishService(ctx context.Context, in *PublishServiceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_UnpublishService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) ShareIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptShareToken, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterceptShareToken)
+	err := c.cc.Invoke(ctx, Manager_ShareIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) JoinIntercept(ctx context.Context, in *JoinInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterceptInfo)
+	err := c.cc.Invoke(ctx, Manager_JoinIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) LeaveIntercept(ctx context.Context, in *JoinInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_LeaveIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) Evict(ctx context.Context, in *EvictRequest, opts ...grpc.CallOption) (*ReapResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReapResult)
+	err := c.cc.Invoke(ctx, Manager_Evict_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) AuthorizeCapture(ctx context.Context, in *AuthorizeCaptureRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Manager_AuthorizeCapture_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServer is the server API for Manager service.
 // All implementations must embed UnimplementedManagerServer
 // for forward compatibility
@@ -771,6 +1081,8 @@ type ManagerServer interface {
 	GetCloudConfig(context.Context, *emptypb.Empty) (*AmbassadorCloudConfig, error)
 	// GetClientConfig returns the config that connected clients should use for this manager.
 	GetClientConfig(context.Context, *emptypb.Empty) (*CLIConfig, error)
+	// GetCapabilities returns the optional features that this traffic-manager supports.
+	GetCapabilities(context.Context, *emptypb.Empty) (*Capabilities, error)
 	// GetTelepresenceAPI returns information about the TelepresenceAPI server
 	GetTelepresenceAPI(context.Context, *emptypb.Empty) (*TelepresenceAPIInfo, error)
 	// ArriveAsClient establishes a session between a client and the Manager.
@@ -782,6 +1094,11 @@ type ManagerServer interface {
 	Remain(context.Context, *RemainRequest) (*emptypb.Empty, error)
 	// Depart terminates a session.
 	Depart(context.Context, *SessionInfo) (*emptypb.Empty, error)
+	// ReapStaleSessions removes the sessions of the calling client's installation
+	// and user that have stopped calling Remain, together with their intercepts.
+	// Clients call it when they create a new session, so that intercepts left
+	// behind by a client that crashed are removed right away.
+	ReapStaleSessions(context.Context, *SessionInfo) (*ReapResult, error)
 	// SetLogLevel will temporarily set the log-level for the traffic-manager and all
 	// traffic-agents for a duration that is determined b the request.
 	SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error)
@@ -837,6 +1154,18 @@ type ManagerServer interface {
 	// that the manager can handle. This set may include Deployment, StatefulSet, ReplicaSet, Rollout (Argo Rollouts)
 	// as configured in the manager's Helm values.
 	GetKnownWorkloadKinds(context.Context, *SessionInfo) (*KnownWorkloadKinds, error)
+	// WatchAgentEvents streams the Kubernetes events that the traffic-manager
+	// observes while it waits for traffic-agents to arrive when it prepares the
+	// intercepts of the given client session.
+	WatchAgentEvents(*SessionInfo, Manager_WatchAgentEventsServer) error
+	// WatchAgentArrivalProgress streams the stages that workloads reach while
+	// the traffic-manager waits for their traffic-agents to arrive when it
+	// prepares the intercepts of the given client session.
+	WatchAgentArrivalProgress(*SessionInfo, Manager_WatchAgentArrivalProgressServer) error
+	// DescribeWorkload returns the agent config, the interceptable ports, the current
+	// intercepts, and the conditions that block intercepts of a workload. The call
+	// never changes the agent config.
+	DescribeWorkload(context.Context, *DescribeWorkloadRequest) (*WorkloadDescription, error)
 	// LookupDNS performs a DNS lookup in the cluster. If the caller has intercepts
 	// active, the lookup will be performed from the intercepted pods.
 	LookupDNS(context.Context, *DNSRequest) (*DNSResponse, error)
@@ -844,6 +1173,14 @@ type ManagerServer interface {
 	AgentLookupDNSResponse(context.Context, *DNSAgentResponse) (*emptypb.Empty, error)
 	// WatchLookupHost lets an agent receive lookup requests
 	WatchLookupDNS(*SessionInfo, Manager_WatchLookupDNSServer) error
+	// VerifyHeaderPropagation sends a probe request through a chain of workloads
+	// that lead up to an intercepted workload, and reports which workloads that
+	// received the intercept's header, and which workload that dropped it.
+	VerifyHeaderPropagation(context.Context, *HeaderPropagationRequest) (*HeaderPropagationResult, error)
+	// WatchHeaderProbes lets an agent receive header probes
+	WatchHeaderProbes(*SessionInfo, Manager_WatchHeaderProbesServer) error
+	// ReportHeaderProbe lets an agent report that a header probe was armed or observed
+	ReportHeaderProbe(context.Context, *HeaderProbeReport) (*emptypb.Empty, error)
 	// WatchLogLevel lets an agent receive log-level updates
 	WatchLogLevel(*emptypb.Empty, Manager_WatchLogLevelServer) error
 	// A Tunnel represents one single connection where the client or
@@ -865,6 +1202,44 @@ type ManagerServer interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(*SessionInfo, Manager_WatchDialServer) error
+	// ExposeRegistry makes the traffic-manager's registry proxy forward the
+	// connections that it receives to a registry on the client's workstation,
+	// using DialRequests sent on the client's WatchDial stream. Only one client
+	// at a time can expose a registry, and the proxy only forwards pulls that
+	// use the credentials returned to that client.
+	ExposeRegistry(context.Context, *RegistryProxyRequest) (*RegistryProxyInfo, error)
+	// PublishService creates a Service in the cluster whose connections are
+	// forwarded to ports on the client's workstation, using DialRequests sent
+	// on the client's WatchDial stream. The traffic-manager accepts the
+	// connections on behalf of the Service. The Service is deleted when it's
+	// unpublished, or when the client's session ends.
+	PublishService(context.Context, *PublishServiceRequest) (*PublishedServiceInfo, error)
+	// UnpublishService deletes a Service that the client has published.
+	UnpublishService(context.Context, *PublishServiceRequest) (*emptypb.Empty, error)
+	// ShareIntercept returns a token that other clients can use to join one
+	// of the client's intercepts. The token is valid until the intercept is
+	// removed.
+	ShareIntercept(context.Context, *GetInterceptRequest) (*InterceptShareToken, error)
+	// JoinIntercept adds the client's session to the shared sessions of the
+	// intercept that the token was issued for, so that the client receives
+	// the requests that carry its route.
+	JoinIntercept(context.Context, *JoinInterceptRequest) (*InterceptInfo, error)
+	// LeaveIntercept removes the client's session from the shared sessions
+	// of the intercept that the token was issued for.
+	LeaveIntercept(context.Context, *JoinInterceptRequest) (*emptypb.Empty, error)
+	// Evict removes the intercepts in the given namespace or of the given
+	// workload, or all sessions of the given user when no namespace or
+	// workload is given. It is only available when the admin API is enabled,
+	// and requires a bearer token that permits deletion of
+	// sessions.telepresence.io.
+	Evict(context.Context, *EvictRequest) (*ReapResult, error)
+	// AuthorizeCapture is called by a traffic-agent before it lets a client
+	// capture the requests that it receives. It returns an error unless the
+	// client is allowed to intercept the agent's workload, i.e. unless the
+	// client has a session in the namespace of the workload. A capture that
+	// includes credentials also requires that the client session owns an
+	// intercept of the workload.
+	AuthorizeCapture(context.Context, *AuthorizeCaptureRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedManagerServer()
 }
 
@@ -890,6 +1265,9 @@ func (UnimplementedManagerServer) GetCloudConfig(context.Context, *emptypb.Empty
 func (UnimplementedManagerServer) GetClientConfig(context.Context, *emptypb.Empty) (*CLIConfig, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetClientConfig not implemented")
 }
+func (UnimplementedManagerServer) GetCapabilities(context.Context, *emptypb.Empty) (*Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
 func (UnimplementedManagerServer) GetTelepresenceAPI(context.Context, *emptypb.Empty) (*TelepresenceAPIInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTelepresenceAPI not implemented")
 }
@@ -905,6 +1283,9 @@ func (UnimplementedManagerServer) Remain(context.Context, *RemainRequest) (*empt
 func (UnimplementedManagerServer) Depart(context.Context, *SessionInfo) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Depart not implemented")
 }
+func (UnimplementedManagerServer) ReapStaleSessions(context.Context, *SessionInfo) (*ReapResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReapStaleSessions not implemented")
+}
 func (UnimplementedManagerServer) SetLogLevel(context.Context, *LogLevelRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
@@ -953,6 +1334,15 @@ func (UnimplementedManagerServer) ReviewIntercept(context.Context, *ReviewInterc
 func (UnimplementedManagerServer) GetKnownWorkloadKinds(context.Context, *SessionInfo) (*KnownWorkloadKinds, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetKnownWorkloadKinds not implemented")
 }
+func (UnimplementedManagerServer) WatchAgentEvents(*SessionInfo, Manager_WatchAgentEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAgentEvents not implemented")
+}
+func (UnimplementedManagerServer) WatchAgentArrivalProgress(*SessionInfo, Manager_WatchAgentArrivalProgressServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAgentArrivalProgress not implemented")
+}
+func (UnimplementedManagerServer) DescribeWorkload(context.Context, *DescribeWorkloadRequest) (*WorkloadDescription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeWorkload not implemented")
+}
 func (UnimplementedManagerServer) LookupDNS(context.Context, *DNSRequest) (*DNSResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LookupDNS not implemented")
 }
@@ -962,6 +1352,15 @@ func (UnimplementedManagerServer) AgentLookupDNSResponse(context.Context, *DNSAg
 func (UnimplementedManagerServer) WatchLookupDNS(*SessionInfo, Manager_WatchLookupDNSServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchLookupDNS not implemented")
 }
+func (UnimplementedManagerServer) VerifyHeaderPropagation(context.Context, *HeaderPropagationRequest) (*HeaderPropagationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyHeaderPropagation not implemented")
+}
+func (UnimplementedManagerServer) WatchHeaderProbes(*SessionInfo, Manager_WatchHeaderProbesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchHeaderProbes not implemented")
+}
+func (UnimplementedManagerServer) ReportHeaderProbe(context.Context, *HeaderProbeReport) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportHeaderProbe not implemented")
+}
 func (UnimplementedManagerServer) WatchLogLevel(*emptypb.Empty, Manager_WatchLogLevelServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchLogLevel not implemented")
 }
@@ -974,6 +1373,30 @@ func (UnimplementedManagerServer) ReportMetrics(context.Context, *TunnelMetrics)
 func (UnimplementedManagerServer) WatchDial(*SessionInfo, Manager_WatchDialServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchDial not implemented")
 }
+func (UnimplementedManagerServer) ExposeRegistry(context.Context, *RegistryProxyRequest) (*RegistryProxyInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExposeRegistry not implemented")
+}
+func (UnimplementedManagerServer) PublishService(context.Context, *PublishServiceRequest) (*PublishedServiceInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishService not implemented")
+}
+func (UnimplementedManagerServer) UnpublishService(context.Context, *PublishServiceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpublishService not implemented")
+}
+func (UnimplementedManagerServer) ShareIntercept(context.Context, *GetInterceptRequest) (*InterceptShareToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareIntercept not implemented")
+}
+func (UnimplementedManagerServer) JoinIntercept(context.Context, *JoinInterceptRequest) (*InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinIntercept not implemented")
+}
+func (UnimplementedManagerServer) LeaveIntercept(context.Context, *JoinInterceptRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveIntercept not implemented")
+}
+func (UnimplementedManagerServer) Evict(context.Context, *EvictRequest) (*ReapResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Evict not implemented")
+}
+func (UnimplementedManagerServer) AuthorizeCapture(context.Context, *AuthorizeCaptureRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthorizeCapture not implemented")
+}
 func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
 
 // UnsafeManagerServer may be embedded to opt out of forward compatibility for this service.
@@ -1095,6 +1518,24 @@ func _Manager_GetClientConfig_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetCapabilities(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_GetTelepresenceAPI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -1185,6 +1626,24 @@ func _Manager_Depart_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_ReapStaleSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ReapStaleSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ReapStaleSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ReapStaleSessions(ctx, req.(*SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogLevelRequest)
 	if err := dec(in); err != nil {
@@ -1491,6 +1950,66 @@ func _Manager_GetKnownWorkloadKinds_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_WatchAgentEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SessionInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchAgentEvents(m, &managerWatchAgentEventsServer{ServerStream: stream})
+}
+
+type Manager_WatchAgentEventsServer interface {
+	Send(*AgentEvent) error
+	grpc.ServerStream
+}
+
+type managerWatchAgentEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchAgentEventsServer) Send(m *AgentEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_WatchAgentArrivalProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SessionInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchAgentArrivalProgress(m, &managerWatchAgentArrivalProgressServer{ServerStream: stream})
+}
+
+type Manager_WatchAgentArrivalProgressServer interface {
+	Send(*AgentArrivalProgress) error
+	grpc.ServerStream
+}
+
+type managerWatchAgentArrivalProgressServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchAgentArrivalProgressServer) Send(m *AgentArrivalProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_DescribeWorkload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeWorkloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).DescribeWorkload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_DescribeWorkload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).DescribeWorkload(ctx, req.(*DescribeWorkloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_LookupDNS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DNSRequest)
 	if err := dec(in); err != nil {
@@ -1548,6 +2067,63 @@ func (x *managerWatchLookupDNSServer) Send(m *DNSRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_VerifyHeaderPropagation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeaderPropagationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).VerifyHeaderPropagation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_VerifyHeaderPropagation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).VerifyHeaderPropagation(ctx, req.(*HeaderPropagationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_WatchHeaderProbes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SessionInfo)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchHeaderProbes(m, &managerWatchHeaderProbesServer{ServerStream: stream})
+}
+
+type Manager_WatchHeaderProbesServer interface {
+	Send(*HeaderProbe) error
+	grpc.ServerStream
+}
+
+type managerWatchHeaderProbesServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchHeaderProbesServer) Send(m *HeaderProbe) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_ReportHeaderProbe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeaderProbeReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ReportHeaderProbe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ReportHeaderProbe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ReportHeaderProbe(ctx, req.(*HeaderProbeReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_WatchLogLevel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(emptypb.Empty)
 	if err := stream.RecvMsg(m); err != nil {
@@ -1634,6 +2210,150 @@ func (x *managerWatchDialServer) Send(m *DialRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_ExposeRegistry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistryProxyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ExposeRegistry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ExposeRegistry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ExposeRegistry(ctx, req.(*RegistryProxyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_PublishService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).PublishService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_PublishService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).PublishService(ctx, req.(*PublishServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_UnpublishService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).UnpublishService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_UnpublishService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).UnpublishService(ctx, req.(*PublishServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_ShareIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ShareIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_ShareIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ShareIntercept(ctx, req.(*GetInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_JoinIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).JoinIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_JoinIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).JoinIntercept(ctx, req.(*JoinInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_LeaveIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).LeaveIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_LeaveIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).LeaveIntercept(ctx, req.(*JoinInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_Evict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvictRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).Evict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_Evict_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).Evict(ctx, req.(*EvictRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_AuthorizeCapture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeCaptureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AuthorizeCapture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_AuthorizeCapture_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AuthorizeCapture(ctx, req.(*AuthorizeCaptureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1665,6 +2385,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetClientConfig",
 			Handler:    _Manager_GetClientConfig_Handler,
 		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Manager_GetCapabilities_Handler,
+		},
 		{
 			MethodName: "GetTelepresenceAPI",
 			Handler:    _Manager_GetTelepresenceAPI_Handler,
@@ -1685,6 +2409,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Depart",
 			Handler:    _Manager_Depart_Handler,
 		},
+		{
+			MethodName: "ReapStaleSessions",
+			Handler:    _Manager_ReapStaleSessions_Handler,
+		},
 		{
 			MethodName: "SetLogLevel",
 			Handler:    _Manager_SetLogLevel_Handler,
@@ -1725,6 +2453,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetKnownWorkloadKinds",
 			Handler:    _Manager_GetKnownWorkloadKinds_Handler,
 		},
+		{
+			MethodName: "DescribeWorkload",
+			Handler:    _Manager_DescribeWorkload_Handler,
+		},
 		{
 			MethodName: "LookupDNS",
 			Handler:    _Manager_LookupDNS_Handler,
@@ -1733,10 +2465,50 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AgentLookupDNSResponse",
 			Handler:    _Manager_AgentLookupDNSResponse_Handler,
 		},
+		{
+			MethodName: "VerifyHeaderPropagation",
+			Handler:    _Manager_VerifyHeaderPropagation_Handler,
+		},
+		{
+			MethodName: "ReportHeaderProbe",
+			Handler:    _Manager_ReportHeaderProbe_Handler,
+		},
 		{
 			MethodName: "ReportMetrics",
 			Handler:    _Manager_ReportMetrics_Handler,
 		},
+		{
+			MethodName: "ExposeRegistry",
+			Handler:    _Manager_ExposeRegistry_Handler,
+		},
+		{
+			MethodName: "PublishService",
+			Handler:    _Manager_PublishService_Handler,
+		},
+		{
+			MethodName: "UnpublishService",
+			Handler:    _Manager_UnpublishService_Handler,
+		},
+		{
+			MethodName: "ShareIntercept",
+			Handler:    _Manager_ShareIntercept_Handler,
+		},
+		{
+			MethodName: "JoinIntercept",
+			Handler:    _Manager_JoinIntercept_Handler,
+		},
+		{
+			MethodName: "LeaveIntercept",
+			Handler:    _Manager_LeaveIntercept_Handler,
+		},
+		{
+			MethodName: "Evict",
+			Handler:    _Manager_Evict_Handler,
+		},
+		{
+			MethodName: "AuthorizeCapture",
+			Handler:    _Manager_AuthorizeCapture_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1769,11 +2541,26 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Manager_WatchClusterInfo_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchAgentEvents",
+			Handler:       _Manager_WatchAgentEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchAgentArrivalProgress",
+			Handler:       _Manager_WatchAgentArrivalProgress_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "WatchLookupDNS",
 			Handler:       _Manager_WatchLookupDNS_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchHeaderProbes",
+			Handler:       _Manager_WatchHeaderProbes_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "WatchLogLevel",
 			Handler:       _Manager_WatchLogLevel_Handler,